@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/stigoleg/keep-alive/internal/platform"
+)
+
+// capabilitiesSchemaVersion is bumped whenever a field of
+// capabilitiesReport is renamed or removed.
+const capabilitiesSchemaVersion = 1
+
+// capabilitiesReport is the `keepalive capabilities --json` document.
+type capabilitiesReport struct {
+	SchemaVersion int `json:"schema_version"`
+	platform.Capabilities
+}
+
+// runCapabilities implements `keepalive capabilities [--json]`.
+func runCapabilities(args []string, stdout io.Writer) int {
+	flags := flag.NewFlagSet("capabilities", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	asJSON := flags.Bool("json", false, "Print the report as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "keepalive: unexpected argument %q\n", flags.Arg(0))
+		return 2
+	}
+
+	// Capability probes log as they go; keep that out of the report.
+	log.SetOutput(io.Discard)
+
+	caps := platform.DetectCapabilities()
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(capabilitiesReport{capabilitiesSchemaVersion, caps}); err != nil {
+			fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	writeCapabilitiesText(stdout, caps)
+	return 0
+}
+
+func writeCapabilitiesText(w io.Writer, c platform.Capabilities) {
+	fmt.Fprintf(w, "Keep-Alive capabilities on %s/%s\n\n", c.OS, c.Arch)
+	for _, row := range c.List() {
+		if !row.Supported {
+			fmt.Fprintf(w, "%-19s no\n", row.Name)
+			continue
+		}
+		fmt.Fprintf(w, "%-19s yes (%s)\n", row.Name, strings.Join(row.Methods, ", "))
+	}
+}
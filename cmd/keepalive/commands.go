@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/stigoleg/keep-alive/internal/buildinfo"
+)
+
+// subcommands are run instead of the TUI when named as the first argument.
+// Each returns the process exit code.
+var subcommands = map[string]func(args []string, stdout io.Writer) int{
+	"version":             runVersion,
+	"crash":               runCrash,
+	"doctor":              runDoctor,
+	"capabilities":        runCapabilities,
+	"run":                 runRun,
+	"service":             runService,
+	"prompt-snippet":      runPromptSnippet,
+	"tmux-status":         runTmuxStatus,
+	"completion":          runCompletion,
+	"install-completions": runInstallCompletions,
+	"history":             runHistory,
+	"upgrade":             runUpgrade,
+	"install":             runInstall,
+	"pause":               runPause,
+	"resume":              runResume,
+	"extend":              runExtend,
+	"set":                 runSet,
+	"simulate-once":       runSimulateOnce,
+	"schema":              runSchema,
+	"privacy":             runPrivacy,
+}
+
+// runSubcommand runs the subcommand named by args[0], if any.
+func runSubcommand(args []string) (code int, ok bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+	cmd, ok := subcommands[args[0]]
+	if !ok {
+		return 0, false
+	}
+	return cmd(args[1:], os.Stdout), true
+}
+
+// runVersion prints build metadata, as JSON with --json.
+func runVersion(args []string, stdout io.Writer) int {
+	flags := flag.NewFlagSet("version", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	asJSON := flags.Bool("json", false, "Print version information as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	info := buildinfo.Get()
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(info); err != nil {
+			fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintf(stdout, "Keep-Alive Version: %s\n", info.Version)
+	if info.Commit != "" {
+		commit := info.Commit
+		if info.Modified {
+			commit += " (modified)"
+		}
+		fmt.Fprintf(stdout, "Commit:     %s\n", commit)
+	}
+	if info.BuildDate != "" {
+		fmt.Fprintf(stdout, "Built:      %s\n", info.BuildDate)
+	}
+	fmt.Fprintf(stdout, "Go:         %s\n", info.GoVersion)
+	fmt.Fprintf(stdout, "Platform:   %s\n", info.Platform)
+	if len(info.Tags) > 0 {
+		fmt.Fprintf(stdout, "Build tags: %s\n", strings.Join(info.Tags, ","))
+	}
+	return 0
+}
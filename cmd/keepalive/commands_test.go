@@ -0,0 +1,827 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/buildinfo"
+	"github.com/stigoleg/keep-alive/internal/config"
+	"github.com/stigoleg/keep-alive/internal/dbusapi"
+	"github.com/stigoleg/keep-alive/internal/history"
+	"github.com/stigoleg/keep-alive/internal/platform"
+	"github.com/stigoleg/keep-alive/internal/privacy"
+	"github.com/stigoleg/keep-alive/internal/statusfile"
+)
+
+func TestRunVersionJSON(t *testing.T) {
+	var out bytes.Buffer
+	if code := runVersion([]string{"--json"}, &out); code != 0 {
+		t.Fatalf("runVersion() exit code = %d", code)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+	for _, key := range []string{"version", "go_version", "platform", "tags"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("missing %q in %s", key, out.String())
+		}
+	}
+}
+
+func TestRunVersionText(t *testing.T) {
+	var out bytes.Buffer
+	if code := runVersion(nil, &out); code != 0 {
+		t.Fatalf("runVersion() exit code = %d", code)
+	}
+	if !strings.HasPrefix(out.String(), "Keep-Alive Version: ") {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+}
+
+func TestRunSubcommandIgnoresFlags(t *testing.T) {
+	if _, ok := runSubcommand([]string{"-d", "10"}); ok {
+		t.Fatal("flags must not be treated as subcommands")
+	}
+	if _, ok := runSubcommand(nil); ok {
+		t.Fatal("no arguments must not run a subcommand")
+	}
+}
+
+func TestParseServiceProfile(t *testing.T) {
+	p, err := parseServiceProfile("service install", []string{"-a", "--ac-only", "--schedule", "weekdays 09:00-17:00"})
+	if err != nil {
+		t.Fatalf("parseServiceProfile() unexpected error: %v", err)
+	}
+	if !p.simulateActivity || !p.acOnly || p.schedule == nil {
+		t.Fatalf("parseServiceProfile() = %+v", p)
+	}
+
+	for _, args := range [][]string{
+		{"--schedule", "someday"},
+		{"--dim", "150"},
+		{"--", "extra"},
+		{"--system", "-a"},
+		{"--system", "--dim", "50"},
+	} {
+		if _, err := parseServiceProfile("service install", args); err == nil {
+			t.Errorf("parseServiceProfile(%q) expected an error", args)
+		}
+	}
+}
+
+func TestRunServiceRequiresAction(t *testing.T) {
+	if code := runService(nil, &bytes.Buffer{}); code != 2 {
+		t.Fatalf("runService() exit code = %d, want 2", code)
+	}
+	if code := runService([]string{"start"}, &bytes.Buffer{}); code != 2 {
+		t.Fatalf("runService(start) exit code = %d, want 2", code)
+	}
+}
+
+func TestPromptSnippetBash(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+	path := filepath.Join(t.TempDir(), "it's", "status")
+	snippet, err := promptSnippet("bash", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		end  time.Time
+		want string
+	}{
+		{"timed", time.Now().Add(42*time.Minute + 30*time.Second), "☕ 43m "},
+		{"long", time.Now().Add(2*time.Hour + 5*time.Minute - 30*time.Second), "☕ 2h05m "},
+		{"indefinite", time.Time{}, "☕ "},
+	} {
+		if err := statusfile.Write(path, tt.end, "systemd-inhibit"); err != nil {
+			t.Fatal(err)
+		}
+		out, err := exec.Command(bash, "-c", snippet+"\nkeepalive_prompt").Output()
+		if err != nil {
+			t.Fatalf("%s: bash failed: %v", tt.name, err)
+		}
+		if string(out) != tt.want {
+			t.Errorf("%s: keepalive_prompt = %q, want %q", tt.name, out, tt.want)
+		}
+	}
+
+	// A crafted line must not reach arithmetic, where bash would run the
+	// command substitution in the array subscript.
+	marker := filepath.Join(t.TempDir(), "injected")
+	hostile := fmt.Sprintf("%d a[$(touch %s)]\n", os.Getpid(), posixQuote(marker))
+	if err := os.WriteFile(path, []byte(hostile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out, _ := exec.Command(bash, "-c", snippet+"\nkeepalive_prompt").Output()
+	if len(out) != 0 {
+		t.Errorf("keepalive_prompt with a hostile status line = %q, want nothing", out)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("keepalive_prompt ran a command from the status file")
+	}
+
+	if err := statusfile.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	out, _ = exec.Command(bash, "-c", snippet+"\nkeepalive_prompt").Output()
+	if len(out) != 0 {
+		t.Errorf("keepalive_prompt without a session = %q, want nothing", out)
+	}
+
+	if _, err := promptSnippet("tcsh", path); err == nil {
+		t.Error("promptSnippet(tcsh) expected an error")
+	}
+}
+
+func TestRunHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), history.FileName)
+	orig := historyPath
+	historyPath = func() (string, error) { return path, nil }
+	t.Cleanup(func() { historyPath = orig })
+
+	end := time.Now().Add(-time.Hour)
+	for _, s := range []history.Session{
+		{Start: end.Add(-2 * time.Hour), End: end, Awake: 2 * time.Hour, Tags: history.Tags{"project": "foo"}},
+		{Start: end.Add(-time.Hour), End: end, Awake: time.Hour, Tags: history.Tags{"project": "bar"}},
+		{Start: end.AddDate(0, 0, -10), End: end.AddDate(0, 0, -10).Add(time.Hour), Awake: time.Hour, Tags: history.Tags{"project": "foo"}},
+	} {
+		if err := history.Append(path, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if code := runHistory([]string{"--tag", "project=foo", "--since", "7d", "--json"}, &out); code != 0 {
+		t.Fatalf("runHistory() exit code = %d", code)
+	}
+	var report historyReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+	if len(report.Sessions) != 1 || report.TotalAwakeSeconds != 7200 {
+		t.Errorf("report = %+v, want one session of 7200s", report)
+	}
+
+	out.Reset()
+	if code := runHistory(nil, &out); code != 0 {
+		t.Fatalf("runHistory() exit code = %d", code)
+	}
+	if !strings.Contains(out.String(), "Total: 4h across 3 sessions") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	if code := runHistory([]string{"--since", "last week"}, &bytes.Buffer{}); code != 2 {
+		t.Errorf("runHistory(--since \"last week\") exit code = %d, want 2", code)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		in   string
+		want time.Time
+	}{
+		{"7d", time.Date(2025, 10, 8, 12, 0, 0, 0, time.UTC)},
+		{"12h", time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{"2025-10-01", time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)},
+	} {
+		got, err := parseSince(tt.in, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseSince(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"-7d", "soon", "2025-13-01"} {
+		if _, err := parseSince(in, now); err == nil {
+			t.Errorf("parseSince(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestRunUpgradeCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"tag_name":"v999.0.0","html_url":"https://example.com/release","assets":[]}`)
+	}))
+	defer srv.Close()
+	orig := latestReleaseURL
+	latestReleaseURL = srv.URL
+	t.Cleanup(func() { latestReleaseURL = orig })
+
+	var out bytes.Buffer
+	code := runUpgrade([]string{"--check"}, &out)
+	if code != 0 {
+		t.Fatalf("runUpgrade(--check) exit code = %d", code)
+	}
+	if !strings.Contains(out.String(), "999.0.0") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestRunPauseAndResume(t *testing.T) {
+	var called []string
+	orig := callRunning
+	callRunning = func(method string, args ...interface{}) error {
+		called = append(called, method)
+		if len(called) > 2 {
+			return dbusapi.ErrUnsupported
+		}
+		return nil
+	}
+	t.Cleanup(func() { callRunning = orig })
+
+	var out bytes.Buffer
+	if code := runPause(nil, &out); code != 0 {
+		t.Fatalf("runPause() exit code = %d", code)
+	}
+	if code := runResume(nil, &out); code != 0 {
+		t.Fatalf("runResume() exit code = %d", code)
+	}
+	if got := strings.Join(called, ","); got != "Pause,Resume" {
+		t.Errorf("called %q, want Pause,Resume", got)
+	}
+	if code := runPause(nil, &out); code != 1 {
+		t.Errorf("runPause() exit code = %d when the call fails, want 1", code)
+	}
+	if code := runPause([]string{"now"}, &out); code != 2 {
+		t.Errorf("runPause(now) exit code = %d, want 2", code)
+	}
+}
+
+// fakeKeepAlive is a platform keep-alive whose simulation cycle returns res.
+type fakeKeepAlive struct {
+	res     platform.SimulationResult
+	running bool
+}
+
+func (f *fakeKeepAlive) Start(context.Context) error       { f.running = true; return nil }
+func (f *fakeKeepAlive) Stop() error                       { f.running = false; return nil }
+func (f *fakeKeepAlive) StopNow()                          {}
+func (f *fakeKeepAlive) SetSimulateActivity(bool)          {}
+func (f *fakeKeepAlive) SetTimings(platform.Timings)       {}
+func (f *fakeKeepAlive) SetMouseShape(platform.MouseShape) {}
+func (f *fakeKeepAlive) SimulateOnce() platform.SimulationResult {
+	if !f.running {
+		return platform.SimulationResult{Err: errors.New("keep-alive is not running")}
+	}
+	return f.res
+}
+
+func TestRunSimulateOnce(t *testing.T) {
+	fake := &fakeKeepAlive{res: platform.SimulationResult{
+		Method:   "ydotool",
+		Tried:    []string{"uinput", "ydotool"},
+		Points:   12,
+		Duration: 1500 * time.Millisecond,
+		Idle:     4 * time.Second,
+	}}
+	orig := newKeepAlive
+	newKeepAlive = func() (platform.KeepAlive, error) { return fake, nil }
+	t.Cleanup(func() { newKeepAlive = orig })
+
+	var out bytes.Buffer
+	if code := runSimulateOnce(nil, &out); code != 0 {
+		t.Fatalf("runSimulateOnce() exit code = %d\n%s", code, out.String())
+	}
+	for _, want := range []string{"Idle before: 4s", "1. uinput", "2. ydotool", "moved the pointer with ydotool (12 points over 1.5s)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	if fake.running {
+		t.Error("keep-alive left running")
+	}
+
+	fake.res = platform.SimulationResult{Tried: []string{"uinput"}, IdleErr: errors.New("no idle source"), Err: errors.New("every input method failed")}
+	out.Reset()
+	if code := runSimulateOnce(nil, &out); code != 1 {
+		t.Errorf("runSimulateOnce() exit code = %d after a failed cycle, want 1", code)
+	}
+	for _, want := range []string{"Idle before: unknown (no idle source)", "Result: failed: every input method failed"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunExtend(t *testing.T) {
+	var gotArgs []interface{}
+	orig := callRunning
+	callRunning = func(method string, args ...interface{}) error {
+		if method != "Extend" {
+			t.Errorf("called %q, want Extend", method)
+		}
+		gotArgs = args
+		return nil
+	}
+	t.Cleanup(func() { callRunning = orig })
+
+	var out bytes.Buffer
+	if code := runExtend([]string{"30m"}, &out); code != 0 {
+		t.Fatalf("runExtend(30m) exit code = %d", code)
+	}
+	if len(gotArgs) != 1 || gotArgs[0] != int64(1800) {
+		t.Errorf("Extend args = %v, want [1800]", gotArgs)
+	}
+	if got := out.String(); got != "Extended by 30m.\n" {
+		t.Errorf("output = %q", got)
+	}
+	for _, args := range [][]string{nil, {"soon"}, {"-5m"}, {"1m", "2m"}} {
+		if code := runExtend(args, &out); code != 2 {
+			t.Errorf("runExtend(%q) exit code = %d, want 2", args, code)
+		}
+	}
+}
+
+func TestRunSetSimMethod(t *testing.T) {
+	var gotArgs []interface{}
+	orig := callRunning
+	callRunning = func(method string, args ...interface{}) error {
+		if method != "SetSimulationMethod" {
+			t.Errorf("called %q, want SetSimulationMethod", method)
+		}
+		gotArgs = args
+		return nil
+	}
+	t.Cleanup(func() { callRunning = orig })
+
+	var out bytes.Buffer
+	if code := runSet([]string{"sim-method", "uinput"}, &out); code != 0 {
+		t.Fatalf("runSet(sim-method uinput) exit code = %d", code)
+	}
+	if len(gotArgs) != 1 || gotArgs[0] != "uinput" {
+		t.Errorf("SetSimulationMethod args = %v, want [uinput]", gotArgs)
+	}
+	if got := out.String(); got != "Simulating activity with uinput.\n" {
+		t.Errorf("output = %q", got)
+	}
+	for _, args := range [][]string{nil, {"sim-method"}, {"volume", "11"}} {
+		if code := runSet(args, &out); code != 2 {
+			t.Errorf("runSet(%q) exit code = %d, want 2", args, code)
+		}
+	}
+}
+
+func TestRunSetSimulation(t *testing.T) {
+	var calls []string
+	orig := callRunning
+	callRunning = func(method string, args ...interface{}) error {
+		calls = append(calls, fmt.Sprint(method, args))
+		return nil
+	}
+	t.Cleanup(func() { callRunning = orig })
+
+	var out bytes.Buffer
+	for _, args := range [][]string{{"active", "off"}, {"sim-interval", "2m"}, {"sim-interval", "default"}} {
+		if code := runSet(args, &out); code != 0 {
+			t.Fatalf("runSet(%q) exit code = %d", args, code)
+		}
+	}
+	want := []string{"SetSimulateActivity[false]", "SetSimulationInterval[120]", "SetSimulationInterval[0]"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+	if want := "Activity simulation off.\nSimulating activity at most every 2m.\nSimulation interval restored to the default.\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+	for _, args := range [][]string{{"active", "maybe"}, {"sim-interval", "soon"}, {"sim-interval", "100ms"}} {
+		if code := runSet(args, &out); code != 2 {
+			t.Errorf("runSet(%q) exit code = %d, want 2", args, code)
+		}
+	}
+}
+
+func TestSubcommandHelpCoversSubcommands(t *testing.T) {
+	for name := range subcommands {
+		if _, ok := subcommandHelp[name]; !ok {
+			t.Errorf("subcommand %q has no completion help", name)
+		}
+	}
+	for name := range subcommandHelp {
+		if _, ok := subcommands[name]; !ok {
+			t.Errorf("completion help for unknown subcommand %q", name)
+		}
+	}
+}
+
+func TestRunCompletion(t *testing.T) {
+	for _, shell := range completionShells {
+		var out bytes.Buffer
+		if code := runCompletion([]string{shell}, &out); code != 0 {
+			t.Fatalf("runCompletion(%s) exit code = %d", shell, code)
+		}
+		for _, want := range []string{"pattern", "zigzag", "log-level", "schedule", "service", "uninstall"} {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("%s completion missing %q", shell, want)
+			}
+		}
+	}
+	if code := runCompletion([]string{"tcsh"}, &bytes.Buffer{}); code != 2 {
+		t.Errorf("runCompletion(tcsh) exit code = %d, want 2", code)
+	}
+}
+
+func TestBashCompletion(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+	var script bytes.Buffer
+	writeBashCompletion(&script, completionFlags())
+
+	for _, tt := range []struct {
+		words string
+		want  string
+	}{
+		{"keepalive --patt", "--pattern --pattern-size"},
+		{"keepalive --pattern z", "zigzag"},
+		{"keepalive -d ''", ""},
+		{"keepalive serv", "service"},
+		{"keepalive crash s", "show submit"},
+	} {
+		cmd := script.String() + "\nCOMP_WORDS=(" + tt.words + ")\nCOMP_CWORD=$(( ${#COMP_WORDS[@]} - 1 ))\n_keepalive\necho -n \"${COMPREPLY[*]}\""
+		out, err := exec.Command(bash, "-c", cmd).Output()
+		if err != nil {
+			t.Fatalf("%s: bash failed: %v", tt.words, err)
+		}
+		if string(out) != tt.want {
+			t.Errorf("completing %q = %q, want %q", tt.words, out, tt.want)
+		}
+	}
+}
+
+func TestRunRequiresCommand(t *testing.T) {
+	if code := runRun(nil, &bytes.Buffer{}); code != 2 {
+		t.Fatalf("runRun() exit code = %d, want 2", code)
+	}
+}
+
+func TestRunChildExitCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	var out bytes.Buffer
+	if code := runChild([]string{"sh", "-c", "echo hello; exit 3"}, &out); code != 3 {
+		t.Fatalf("runChild() exit code = %d, want 3", code)
+	}
+	if out.String() != "hello\n" {
+		t.Fatalf("child output = %q, want %q", out.String(), "hello\n")
+	}
+
+	if code := runChild([]string{"sh", "-c", "kill -TERM $$"}, &out); code != 128+15 {
+		t.Fatalf("runChild() exit code for SIGTERM = %d, want 143", code)
+	}
+
+	if code := runChild([]string{"keepalive-test-no-such-command"}, &out); code != exitNotFound {
+		t.Fatalf("runChild() exit code for missing command = %d, want %d", code, exitNotFound)
+	}
+}
+
+func TestDoctorReportStatus(t *testing.T) {
+	healthy := platform.Diagnostics{
+		Inhibitors:         []string{"caffeinate"},
+		SleepPrevention:    true,
+		ActivitySimulation: platform.ActivitySimulationStatus{Available: true, Method: "test"},
+	}
+	idle := []doctorIdleSource{{Name: "test", IdleSeconds: 3}}
+	report := buildDoctorReport(buildinfo.Info{}, healthy, doctorPower{BatteryError: "no battery"}, idle, nil)
+	if report.Status != "ok" || doctorExitCode(report.Status) != 0 {
+		t.Fatalf("healthy status = %q", report.Status)
+	}
+
+	remote := healthy
+	remote.RemoteSession = true
+	if got := buildDoctorReport(buildinfo.Info{}, remote, doctorPower{}, idle, nil).Status; got != "warning" {
+		t.Fatalf("status in a remote desktop session = %q, want warning", got)
+	}
+
+	standby := healthy
+	standby.ModernStandby = true
+	report = buildDoctorReport(buildinfo.Info{}, standby, doctorPower{}, idle, nil)
+	if report.Status != "ok" || !slices.ContainsFunc(report.Checks, func(c doctorCheck) bool { return c.Name == "modern_standby" }) {
+		t.Fatalf("modern standby report = %+v, want status ok with a modern_standby check", report)
+	}
+
+	sandboxed := healthy
+	sandboxed.Sandbox = "Flatpak"
+	report = buildDoctorReport(buildinfo.Info{}, sandboxed, doctorPower{}, idle, nil)
+	if report.Status != "ok" || !slices.ContainsFunc(report.Checks, func(c doctorCheck) bool { return c.Name == "sandbox" }) {
+		t.Fatalf("sandboxed report = %+v, want status ok with a sandbox check", report)
+	}
+
+	degraded := healthy
+	degraded.ActivitySimulation = platform.ActivitySimulationStatus{Message: "no input backend"}
+	if got := buildDoctorReport(buildinfo.Info{}, degraded, doctorPower{}, idle, nil).Status; got != "warning" {
+		t.Fatalf("status without activity simulation = %q, want warning", got)
+	}
+
+	broken := degraded
+	broken.SleepPrevention = false
+	report = buildDoctorReport(buildinfo.Info{}, broken, doctorPower{}, idle, nil)
+	if report.Status != "error" || doctorExitCode(report.Status) != 2 {
+		t.Fatalf("status without sleep prevention = %q, want error", report.Status)
+	}
+}
+
+func TestDoctorCompetitorsCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		running []platform.Competitor
+		want    string
+	}{
+		{"none", nil, "ok"},
+		{"inhibitor only", []platform.Competitor{{Name: "Amphetamine"}}, "ok"},
+		{"simulator", []platform.Competitor{{Name: "Amphetamine"}, {Name: "Jiggler", Simulates: true}}, "warning"},
+	}
+	for _, tt := range tests {
+		if got := competitorsCheck(tt.running); got.Status != tt.want {
+			t.Errorf("%s: competitorsCheck() = %+v, want status %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDoctorIdleCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources []doctorIdleSource
+		want    string
+	}{
+		{"agree", []doctorIdleSource{{Name: "ioreg", IdleSeconds: 12}, {Name: "CoreGraphics", IdleSeconds: 12.4}}, "ok"},
+		{"fallback", []doctorIdleSource{{Name: "ioreg", Error: "HIDIdleTime not found"}, {Name: "CoreGraphics", IdleSeconds: 12}}, "warning"},
+		{"disagree", []doctorIdleSource{{Name: "ioreg", IdleSeconds: 600}, {Name: "CoreGraphics", IdleSeconds: 2}}, "warning"},
+		{"none work", []doctorIdleSource{{Name: "ioreg", Error: "exit status 1"}}, "warning"},
+		{"unsupported", nil, "warning"},
+	}
+	for _, tt := range tests {
+		if got := idleCheck(tt.sources); got.Status != tt.want {
+			t.Errorf("%s: idleCheck() = %+v, want status %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRunDoctorJSON(t *testing.T) {
+	var out bytes.Buffer
+	code := runDoctor([]string{"--json"}, &out)
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+	for _, key := range []string{"schema_version", "status", "build", "platform", "power", "checks"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("missing %q in %s", key, out.String())
+		}
+	}
+	if want := doctorExitCode(got["status"].(string)); code != want {
+		t.Fatalf("exit code = %d, want %d for status %v", code, want, got["status"])
+	}
+}
+
+func TestRunCapabilitiesJSON(t *testing.T) {
+	var out bytes.Buffer
+	if code := runCapabilities([]string{"--json"}, &out); code != 0 {
+		t.Fatalf("exit code = %d, want 0", code)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+	for _, key := range []string{"schema_version", "os", "sleep_inhibition", "display_inhibition", "lock_prevention", "input_simulation", "idle_detection"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("missing %q in %s", key, out.String())
+		}
+	}
+}
+
+func TestWriteDryRun(t *testing.T) {
+	diag := platform.Diagnostics{
+		OS:                "linux",
+		Arch:              "amd64",
+		Inhibitors:        []string{"systemd-inhibit", "dbus-gnome-suspend"},
+		SleepPrevention:   true,
+		SimulationMethods: []string{"uinput", "ydotool"},
+		Tools:             map[string]bool{"ydotool": true, "xdotool": false},
+	}
+	cfg := &config.Config{Duration: 90, SimulateActivity: true, DimLevel: 10}
+
+	var out bytes.Buffer
+	if code := writeDryRun(&out, cfg, diag); code != 0 {
+		t.Fatalf("writeDryRun() exit code = %d, want 0", code)
+	}
+	for _, want := range []string{
+		"nothing will be activated",
+		"Session: for 1h30m",
+		"Dims the display to 10%",
+		"1. systemd-inhibit\n  2. dbus-gnome-suspend",
+		"1. uinput\n  2. ydotool",
+		"xdotool          missing",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("dry run output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	diag.SleepPrevention = false
+	cfg.SimulateActivity = false
+	out.Reset()
+	if code := writeDryRun(&out, cfg, diag); code != 1 {
+		t.Fatalf("writeDryRun() without sleep prevention exit code = %d, want 1", code)
+	}
+	if !strings.Contains(out.String(), "Activity simulation: off") {
+		t.Errorf("dry run output without --active:\n%s", out.String())
+	}
+
+	cfg.SimulateActivity = true
+	cfg.PresenceOnly = true
+	out.Reset()
+	if code := writeDryRun(&out, cfg, diag); code != 0 {
+		t.Fatalf("writeDryRun() with --presence-only exit code = %d, want 0", code)
+	}
+	if !strings.Contains(out.String(), "Sleep prevention: off") || strings.Contains(out.String(), "systemd-inhibit") {
+		t.Errorf("dry run output with --presence-only:\n%s", out.String())
+	}
+}
+
+func TestCompletionTarget(t *testing.T) {
+	env := map[string]string{"HOME": "/home/u", "XDG_CONFIG_HOME": "/cfg"}
+	getenv := func(k string) string { return env[k] }
+	tests := []struct {
+		shell    string
+		fpath    []string
+		want     string
+		wantHint bool
+	}{
+		{shell: "bash", want: "/home/u/.local/share/bash-completion/completions/keepalive"},
+		{shell: "fish", want: "/cfg/fish/completions/keepalive.fish"},
+		{shell: "zsh", fpath: []string{"/usr/share/zsh/functions", "/home/u/.oh-my-zsh/plugins/git", "/home/u/.zsh/completions"}, want: "/home/u/.zsh/completions/_keepalive"},
+		{shell: "zsh", fpath: []string{"/usr/share/zsh/functions"}, want: "/home/u/.zfunc/_keepalive", wantHint: true},
+	}
+	for _, tt := range tests {
+		got, hint, err := completionTarget(tt.shell, getenv, tt.fpath)
+		if err != nil {
+			t.Fatalf("completionTarget(%s): %v", tt.shell, err)
+		}
+		if got != tt.want || (hint != "") != tt.wantHint {
+			t.Errorf("completionTarget(%s, %v) = %q, hint %q; want %q, hint %v", tt.shell, tt.fpath, got, hint, tt.want, tt.wantHint)
+		}
+	}
+	for _, shell := range []string{"", "tcsh"} {
+		if _, _, err := completionTarget(shell, getenv, nil); err == nil {
+			t.Errorf("completionTarget(%q) succeeded", shell)
+		}
+	}
+}
+
+func TestRunInstallCompletions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("completions are installed through the PowerShell profile on Windows")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	origManpath := manpathDirs
+	manpathDirs = func() []string { return []string{"/usr/share/man"} }
+	t.Cleanup(func() { manpathDirs = origManpath })
+
+	var out bytes.Buffer
+	if code := runInstallCompletions([]string{"--shell", "fish"}, &out); code != 0 {
+		t.Fatalf("exit code = %d, output %q", code, out.String())
+	}
+	script, err := os.ReadFile(filepath.Join(home, ".config", "fish", "completions", "keepalive.fish"))
+	if err != nil || !strings.Contains(string(script), "complete -c keepalive") {
+		t.Fatalf("fish completions not installed: %v", err)
+	}
+	page, err := os.ReadFile(filepath.Join(home, ".local", "share", "man", "man1", "keepalive.1"))
+	if err != nil || !strings.Contains(string(page), "install\\-completions") {
+		t.Fatalf("man page not installed: %v", err)
+	}
+	if !strings.Contains(out.String(), "MANPATH") {
+		t.Errorf("expected a MANPATH hint, got %q", out.String())
+	}
+}
+
+func TestRunSchema(t *testing.T) {
+	var out bytes.Buffer
+	if code := runSchema(nil, &out); code != 0 {
+		t.Fatalf("runSchema() exit code = %d, want 0", code)
+	}
+	if out.String() != "event\nhistory-entry\nstatus\n" {
+		t.Errorf("schema list = %q", out.String())
+	}
+
+	out.Reset()
+	if code := runSchema([]string{"status"}, &out); code != 0 {
+		t.Fatalf("runSchema(status) exit code = %d, want 0", code)
+	}
+	if !json.Valid(out.Bytes()) || !strings.Contains(out.String(), `"schema_version"`) {
+		t.Errorf("status schema = %s", out.String())
+	}
+
+	if code := runSchema([]string{"nonexistent"}, &out); code != 2 {
+		t.Errorf("runSchema(nonexistent) exit code = %d, want 2", code)
+	}
+}
+
+func TestRunPrivacy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "privacy.json")
+	orig := consentPath
+	consentPath = func() (string, error) { return path, nil }
+	t.Cleanup(func() { consentPath = orig })
+
+	var out bytes.Buffer
+	if code := runPrivacy(nil, &out); code != 0 {
+		t.Fatalf("runPrivacy() exit code = %d, want 0", code)
+	}
+	if !strings.Contains(out.String(), "Enabled level: inhibit-only") || !strings.Contains(out.String(), "none recorded") {
+		t.Errorf("status without consent = %q", out.String())
+	}
+
+	out.Reset()
+	if code := runPrivacy([]string{"grant", "synthetic-input"}, &out); code != 0 {
+		t.Fatalf("runPrivacy(grant) exit code = %d, want 0", code)
+	}
+	c, err := loadConsent()
+	if err != nil || c.Level != privacy.SyntheticInput || c.Via != "command" || c.GrantedAt.IsZero() {
+		t.Fatalf("consent after grant = %+v, %v", c, err)
+	}
+	out.Reset()
+	runPrivacy([]string{"status"}, &out)
+	if !strings.Contains(out.String(), "Enabled level: synthetic-input") || !strings.Contains(out.String(), "(command)") {
+		t.Errorf("status after grant = %q", out.String())
+	}
+
+	if code := runPrivacy([]string{"grant", "inhibit-only"}, &out); code != 1 {
+		t.Errorf("runPrivacy(grant inhibit-only) exit code = %d, want 1", code)
+	}
+	if code := runPrivacy([]string{"grant", "everything"}, &out); code != 2 {
+		t.Errorf("runPrivacy(grant everything) exit code = %d, want 2", code)
+	}
+
+	if code := runPrivacy([]string{"revoke"}, &out); code != 0 {
+		t.Fatalf("runPrivacy(revoke) exit code = %d, want 0", code)
+	}
+	if c, _ := loadConsent(); c.Allows(privacy.SyntheticInput) {
+		t.Error("synthetic-input still allowed after revoke")
+	}
+}
+
+func TestTmuxStatus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keepalive", "status")
+	alive := func(int) (bool, error) { return true, nil }
+	now := time.Now()
+
+	if got := tmuxStatus(path, defaultTmuxFormat, "idle", now, alive); got != "idle" {
+		t.Errorf("without a status file = %q, want %q", got, "idle")
+	}
+
+	if err := statusfile.Write(path, now.Add(2*time.Hour+5*time.Minute-30*time.Second), "systemd-inhibit"); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		format, want string
+	}{
+		{defaultTmuxFormat, "☕ 2h05m"},
+		{"%remaining via %method (100%%)", "2h05m via systemd-inhibit (100%)"},
+		{"#[fg=green]%icon#[default]", "#[fg=green]☕#[default]"},
+	} {
+		if got := tmuxStatus(path, tt.format, "", now, alive); got != tt.want {
+			t.Errorf("tmuxStatus(%q) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+
+	dead := func(int) (bool, error) { return false, nil }
+	if got := tmuxStatus(path, defaultTmuxFormat, "", now, dead); got != "" {
+		t.Errorf("with the session process gone = %q, want empty", got)
+	}
+	if got := tmuxStatus(path, defaultTmuxFormat, "", now.Add(3*time.Hour), alive); got != "" {
+		t.Errorf("after the session ran out = %q, want empty", got)
+	}
+
+	if err := statusfile.Write(path, time.Time{}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := tmuxStatus(path, "%icon %remaining %method", "", now, alive); got != "☕ ∞ " {
+		t.Errorf("indefinite = %q, want %q", got, "☕ ∞ ")
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/stigoleg/keep-alive/internal/config"
+)
+
+// completionShells are the shells `keepalive completion` writes scripts for.
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// subcommandHelp describes each subcommand for completion: a summary and
+// the words accepted as its first argument. It has an entry for every
+// subcommand, which is checked by tests.
+var subcommandHelp = map[string]struct {
+	desc string
+	args []string
+}{
+	"version":             {"Show version information", []string{"--json"}},
+	"crash":               {"List, show or report crash reports", []string{"list", "show", "submit"}},
+	"doctor":              {"Check sleep prevention, activity simulation and dependencies", []string{"--json"}},
+	"capabilities":        {"Report what keep-alive can do on this machine", []string{"--json"}},
+	"run":                 {"Keep the system awake while a command runs", nil},
+	"service":             {"Install, remove or inspect the login service", []string{"install", "uninstall", "status", "run"}},
+	"prompt-snippet":      {"Print a shell prompt helper showing the time left", []string{"bash", "zsh", "fish"}},
+	"tmux-status":         {"Print a short session status for the tmux status line", []string{"--format", "--idle"}},
+	"completion":          {"Print a shell completion script", completionShells},
+	"history":             {"List recorded sessions and their awake time", []string{"--tag", "--since", "--json"}},
+	"upgrade":             {"Download and install the latest release", []string{"--check"}},
+	"install":             {"Install this binary for the current user and add it to PATH (Windows)", []string{"--shortcut"}},
+	"pause":               {"Pause the running session", nil},
+	"resume":              {"Resume the paused session", nil},
+	"extend":              {"Push the end of the running timed session back", nil},
+	"set":                 {"Change a setting of the running session", []string{"sim-method", "active", "sim-interval"}},
+	"install-completions": {"Install shell completions and the man page for the current user", []string{"--shell", "--no-man"}},
+	"simulate-once":       {"Run one activity-simulation cycle now and report the result", nil},
+	"schema":              {"Print the JSON Schema of the status, events or history", []string{"event", "history-entry", "status"}},
+	"privacy":             {"Show, enable or withdraw the privacy level consented to", []string{"status", "grant", "revoke"}},
+}
+
+// completionFlag is a command-line flag as offered for completion.
+type completionFlag struct {
+	name    string // with its dashes: -d or --duration
+	desc    string
+	value   bool
+	choices []string
+}
+
+// completionFlags returns the flags that the TUI accepts, from their
+// definitions.
+func completionFlags() []completionFlag {
+	choices := config.FlagChoices()
+	var flags []completionFlag
+	config.FlagSet().VisitAll(func(f *flag.Flag) {
+		name := "--" + f.Name
+		if len(f.Name) == 1 {
+			name = "-" + f.Name
+		}
+		boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, completionFlag{
+			name:    name,
+			desc:    f.Usage,
+			value:   !ok || !boolFlag.IsBoolFlag(),
+			choices: choices[f.Name],
+		})
+	})
+	return flags
+}
+
+// subcommandNames returns the subcommands in order.
+func subcommandNames() []string {
+	names := make([]string, 0, len(subcommandHelp))
+	for name := range subcommandHelp {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runCompletion implements `keepalive completion <shell>`.
+func runCompletion(args []string, stdout io.Writer) int {
+	usage := "usage: keepalive completion <" + strings.Join(completionShells, "|") + ">"
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	if !writeCompletion(stdout, args[0]) {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	return 0
+}
+
+// writeCompletion writes the completion script for shell, reporting false
+// for an unknown shell.
+func writeCompletion(w io.Writer, shell string) bool {
+	flags := completionFlags()
+	switch shell {
+	case "bash":
+		writeBashCompletion(w, flags)
+	case "zsh":
+		writeZshCompletion(w, flags)
+	case "fish":
+		writeFishCompletion(w, flags)
+	case "powershell":
+		writePowerShellCompletion(w, flags)
+	default:
+		return false
+	}
+	return true
+}
+
+func writeBashCompletion(w io.Writer, flags []completionFlag) {
+	var names, valueFlags []string
+	for _, f := range flags {
+		names = append(names, f.name)
+		if f.value && f.choices == nil {
+			valueFlags = append(valueFlags, f.name)
+		}
+	}
+
+	fmt.Fprintln(w, "# bash completion for keepalive. Load it with:")
+	fmt.Fprintln(w, "#   source <(keepalive completion bash)")
+	fmt.Fprintln(w, "_keepalive() {")
+	fmt.Fprintln(w, "\tlocal cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}")
+	fmt.Fprintln(w, "\tif (( COMP_CWORD == 2 )); then")
+	fmt.Fprintln(w, "\t\tcase ${COMP_WORDS[1]} in")
+	for _, name := range subcommandNames() {
+		if args := subcommandHelp[name].args; args != nil {
+			fmt.Fprintf(w, "\t\t%s) COMPREPLY=( $(compgen -W %q -- \"$cur\") ); return ;;\n", name, strings.Join(args, " "))
+		}
+	}
+	fmt.Fprintln(w, "\t\tesac")
+	fmt.Fprintln(w, "\tfi")
+	fmt.Fprintln(w, "\tcase $prev in")
+	for _, f := range flags {
+		if f.choices != nil {
+			fmt.Fprintf(w, "\t%s) COMPREPLY=( $(compgen -W %q -- \"$cur\") ); return ;;\n", f.name, strings.Join(f.choices, " "))
+		}
+	}
+	fmt.Fprintf(w, "\t%s) return ;;\n", strings.Join(valueFlags, "|"))
+	fmt.Fprintln(w, "\tesac")
+	fmt.Fprintln(w, "\tif [[ $cur == -* ]]; then")
+	fmt.Fprintf(w, "\t\tCOMPREPLY=( $(compgen -W %q -- \"$cur\") )\n", strings.Join(names, " "))
+	fmt.Fprintln(w, "\telif (( COMP_CWORD == 1 )); then")
+	fmt.Fprintf(w, "\t\tCOMPREPLY=( $(compgen -W %q -- \"$cur\") )\n", strings.Join(subcommandNames(), " "))
+	fmt.Fprintln(w, "\tfi")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -F _keepalive keepalive")
+}
+
+func writeZshCompletion(w io.Writer, flags []completionFlag) {
+	fmt.Fprintln(w, "#compdef keepalive")
+	fmt.Fprintln(w, "# zsh completion for keepalive. Load it with:")
+	fmt.Fprintln(w, "#   source <(keepalive completion zsh)")
+	fmt.Fprintln(w, "_keepalive() {")
+	fmt.Fprintln(w, "\tif (( CURRENT == 3 )); then")
+	fmt.Fprintln(w, "\t\tcase $words[2] in")
+	for _, name := range subcommandNames() {
+		if args := subcommandHelp[name].args; args != nil {
+			fmt.Fprintf(w, "\t\t%s) compadd -- %s; return ;;\n", name, strings.Join(args, " "))
+		}
+	}
+	fmt.Fprintln(w, "\t\tesac")
+	fmt.Fprintln(w, "\tfi")
+	fmt.Fprintln(w, "\tif (( CURRENT == 2 )) && [[ $words[2] != -* ]]; then")
+	fmt.Fprintln(w, "\t\tlocal -a commands=(")
+	for _, name := range subcommandNames() {
+		fmt.Fprintf(w, "\t\t\t%s\n", zshQuote(name+":"+subcommandHelp[name].desc))
+	}
+	fmt.Fprintln(w, "\t\t)")
+	fmt.Fprintln(w, "\t\t_describe command commands")
+	fmt.Fprintln(w, "\t\treturn")
+	fmt.Fprintln(w, "\tfi")
+	fmt.Fprintln(w, "\t_arguments \\")
+	for i, f := range flags {
+		spec := f.name + "[" + zshEscapeDesc(f.desc) + "]"
+		switch {
+		case f.choices != nil:
+			spec += ":value:(" + strings.Join(f.choices, " ") + ")"
+		case f.value:
+			spec += ":value:"
+		}
+		sep := " \\"
+		if i == len(flags)-1 {
+			sep = ""
+		}
+		fmt.Fprintf(w, "\t\t%s%s\n", zshQuote(spec), sep)
+	}
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "compdef _keepalive keepalive")
+}
+
+func writeFishCompletion(w io.Writer, flags []completionFlag) {
+	fmt.Fprintln(w, "# fish completion for keepalive. Load it with:")
+	fmt.Fprintln(w, "#   keepalive completion fish | source")
+	fmt.Fprintln(w, "complete -c keepalive -f")
+	for _, name := range subcommandNames() {
+		h := subcommandHelp[name]
+		fmt.Fprintf(w, "complete -c keepalive -n __fish_use_subcommand -a %s -d %s\n", name, fishQuote(h.desc))
+		if h.args != nil {
+			fmt.Fprintf(w, "complete -c keepalive -n '__fish_seen_subcommand_from %s' -a %s\n", name, fishQuote(strings.Join(h.args, " ")))
+		}
+	}
+	for _, f := range flags {
+		var b strings.Builder
+		b.WriteString("complete -c keepalive -n __fish_use_subcommand")
+		if strings.HasPrefix(f.name, "--") {
+			b.WriteString(" -l " + strings.TrimPrefix(f.name, "--"))
+		} else {
+			b.WriteString(" -s " + strings.TrimPrefix(f.name, "-"))
+		}
+		if f.value {
+			b.WriteString(" -x")
+		}
+		if f.choices != nil {
+			b.WriteString(" -a " + fishQuote(strings.Join(f.choices, " ")))
+		}
+		b.WriteString(" -d " + fishQuote(f.desc))
+		fmt.Fprintln(w, b.String())
+	}
+}
+
+func writePowerShellCompletion(w io.Writer, flags []completionFlag) {
+	var names []string
+	var choices []string
+	for _, f := range flags {
+		names = append(names, psQuote(f.name))
+		if f.choices != nil {
+			choices = append(choices, fmt.Sprintf("%s = @(%s)", psQuote(f.name), psList(f.choices)))
+		}
+	}
+	var subArgs []string
+	for _, name := range subcommandNames() {
+		if args := subcommandHelp[name].args; args != nil {
+			subArgs = append(subArgs, fmt.Sprintf("%s = @(%s)", psQuote(name), psList(args)))
+		}
+	}
+
+	fmt.Fprintln(w, "# PowerShell completion for keepalive. Load it with:")
+	fmt.Fprintln(w, "#   keepalive completion powershell | Out-String | Invoke-Expression")
+	fmt.Fprintln(w, "Register-ArgumentCompleter -Native -CommandName keepalive, keepalive.exe -ScriptBlock {")
+	fmt.Fprintln(w, "\tparam($wordToComplete, $commandAst, $cursorPosition)")
+	fmt.Fprintln(w, "\t$words = @($commandAst.CommandElements | ForEach-Object { $_.ToString() })")
+	fmt.Fprintln(w, "\t$index = $words.Count")
+	fmt.Fprintln(w, "\tif ($wordToComplete -ne '') { $index-- }")
+	fmt.Fprintln(w, "\t$prev = if ($index -ge 1) { $words[$index - 1] } else { '' }")
+	fmt.Fprintf(w, "\t$choices = @{ %s }\n", strings.Join(choices, "; "))
+	fmt.Fprintf(w, "\t$subArgs = @{ %s }\n", strings.Join(subArgs, "; "))
+	fmt.Fprintln(w, "\tif ($choices.ContainsKey($prev)) {")
+	fmt.Fprintln(w, "\t\t$candidates = $choices[$prev]")
+	fmt.Fprintln(w, "\t} elseif ($index -eq 2 -and $subArgs.ContainsKey($words[1])) {")
+	fmt.Fprintln(w, "\t\t$candidates = $subArgs[$words[1]]")
+	fmt.Fprintln(w, "\t} elseif ($wordToComplete -like '-*') {")
+	fmt.Fprintf(w, "\t\t$candidates = @(%s)\n", strings.Join(names, ", "))
+	fmt.Fprintln(w, "\t} elseif ($index -eq 1) {")
+	fmt.Fprintf(w, "\t\t$candidates = @(%s)\n", psList(subcommandNames()))
+	fmt.Fprintln(w, "\t} else {")
+	fmt.Fprintln(w, "\t\treturn")
+	fmt.Fprintln(w, "\t}")
+	fmt.Fprintln(w, "\t$candidates | Where-Object { $_ -like \"$wordToComplete*\" } | ForEach-Object {")
+	fmt.Fprintln(w, "\t\t[System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)")
+	fmt.Fprintln(w, "\t}")
+	fmt.Fprintln(w, "}")
+}
+
+// zshQuote single-quotes s for zsh.
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// zshEscapeDesc escapes the characters _arguments treats specially in an
+// option description.
+func zshEscapeDesc(s string) string {
+	return strings.NewReplacer("[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
+
+// psQuote single-quotes s for PowerShell.
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func psList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = psQuote(item)
+	}
+	return strings.Join(quoted, ", ")
+}
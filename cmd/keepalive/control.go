@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/dbusapi"
+	"github.com/stigoleg/keep-alive/internal/util"
+)
+
+// callRunning invokes a method on the running instance; it is replaced in
+// tests.
+var callRunning = dbusapi.Call
+
+// queryRunning reads the status of the running instance; it is replaced in
+// tests.
+var queryRunning = dbusapi.Query
+
+// runPause implements `keepalive pause`.
+func runPause(args []string, stdout io.Writer) int {
+	return runControl("pause", "Pause", "Paused.", args, stdout)
+}
+
+// runResume implements `keepalive resume`.
+func runResume(args []string, stdout io.Writer) int {
+	return runControl("resume", "Resume", "Resumed.", args, stdout)
+}
+
+// runExtend implements `keepalive extend <duration>`, which pushes the end
+// of the running timed session back.
+func runExtend(args []string, stdout io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: keepalive extend <duration>")
+		return 2
+	}
+	d, err := util.ParseDuration(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: extend: %v\n", err)
+		return 2
+	}
+	if d < time.Second {
+		fmt.Fprintln(os.Stderr, "keepalive: extend: duration must be at least one second")
+		return 2
+	}
+	if err := callRunning("Extend", int64(d/time.Second)); err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: extend: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Extended by %s.\n", util.FormatDuration(d.Truncate(time.Second)))
+	return 0
+}
+
+// runSet implements `keepalive set <setting> <value>`, which changes a
+// setting of the running session: the simulation method, whether activity
+// is simulated, or the interval between simulated moves.
+func runSet(args []string, stdout io.Writer) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: keepalive set sim-method <uinput|ydotool|xtest|auto>")
+		fmt.Fprintln(os.Stderr, "       keepalive set active <on|off>")
+		fmt.Fprintln(os.Stderr, "       keepalive set sim-interval <duration|default>")
+		return 2
+	}
+	switch setting, value := args[0], args[1]; setting {
+	case "active":
+		var enabled bool
+		switch value {
+		case "on":
+			enabled = true
+		case "off":
+		default:
+			fmt.Fprintf(os.Stderr, "keepalive: set: active must be on or off, not %q\n", value)
+			return 2
+		}
+		if err := callRunning("SetSimulateActivity", enabled); err != nil {
+			fmt.Fprintf(os.Stderr, "keepalive: set: %v\n", err)
+			return 1
+		}
+		if enabled {
+			fmt.Fprintln(stdout, "Activity simulation on.")
+		} else {
+			fmt.Fprintln(stdout, "Activity simulation off.")
+		}
+		return 0
+	case "sim-interval":
+		var d time.Duration
+		if value != "default" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil || parsed < time.Second {
+				fmt.Fprintf(os.Stderr, "keepalive: set: invalid sim-interval %q: use a duration such as 30s or 2m, or default\n", value)
+				return 2
+			}
+			d = parsed
+		}
+		if err := callRunning("SetSimulationInterval", int64(d/time.Second)); err != nil {
+			fmt.Fprintf(os.Stderr, "keepalive: set: %v\n", err)
+			return 1
+		}
+		if d == 0 {
+			fmt.Fprintln(stdout, "Simulation interval restored to the default.")
+		} else {
+			fmt.Fprintf(stdout, "Simulating activity at most every %s.\n", util.FormatDuration(d.Truncate(time.Second)))
+		}
+		return 0
+	case "sim-method":
+		if err := callRunning("SetSimulationMethod", value); err != nil {
+			fmt.Fprintf(os.Stderr, "keepalive: set: %v\n", err)
+			return 1
+		}
+		if value == "auto" {
+			fmt.Fprintln(stdout, "Simulation method is chosen automatically.")
+		} else {
+			fmt.Fprintf(stdout, "Simulating activity with %s.\n", value)
+		}
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "keepalive: set: unknown setting %q\n", setting)
+		return 2
+	}
+}
+
+// runControl calls method, which takes no arguments, on the running
+// instance and prints done when it succeeds.
+func runControl(name, method, done string, args []string, stdout io.Writer) int {
+	if len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "usage: keepalive %s\n", name)
+		return 2
+	}
+	if err := callRunning(method); err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: %s: %v\n", name, err)
+		return 1
+	}
+	fmt.Fprintln(stdout, done)
+	return 0
+}
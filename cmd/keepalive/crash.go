@@ -0,0 +1,185 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/stigoleg/keep-alive/internal/crash"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const issueURL = "https://github.com/stigoleg/keep-alive/issues/new"
+
+// maxIssueStackLines bounds the stack excerpt placed in the issue URL; the
+// full report is attached by the user.
+const maxIssueStackLines = 25
+
+// crashGuardModel records panics raised in Update and View before Bubble Tea
+// restores the terminal.
+type crashGuardModel struct {
+	tea.Model
+}
+
+func (m crashGuardModel) Update(msg tea.Msg) (model tea.Model, cmd tea.Cmd) {
+	defer crash.Guard("ui")
+	model, cmd = m.Model.Update(msg)
+	return crashGuardModel{Model: model}, cmd
+}
+
+func (m crashGuardModel) View() string {
+	defer crash.Guard("ui")
+	return m.Model.View()
+}
+
+// runCrash implements `keepalive crash <list|show|submit>`.
+func runCrash(args []string, stdout io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: keepalive crash <list|show|submit> [report]")
+		return 2
+	}
+
+	switch args[0] {
+	case "list":
+		return runCrashList(stdout)
+	case "show":
+		return withCrashReport(args[1:], func(path string, r crash.Report) int {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+				return 1
+			}
+			stdout.Write(data)
+			return 0
+		})
+	case "submit":
+		flags := flag.NewFlagSet("crash submit", flag.ContinueOnError)
+		flags.SetOutput(os.Stderr)
+		open := flags.Bool("open", false, "Open the prefilled issue in a browser")
+		if err := flags.Parse(args[1:]); err != nil {
+			return 2
+		}
+		return withCrashReport(flags.Args(), func(path string, r crash.Report) int {
+			link := crashIssueURL(r)
+			fmt.Fprintf(stdout, "Crash report: %s\n\n", path)
+			fmt.Fprintln(stdout, "Nothing has been sent. To share this crash, open the link below,")
+			fmt.Fprintln(stdout, "review the prefilled text and attach the report file above.")
+			fmt.Fprintf(stdout, "\n%s\n", link)
+			if *open {
+				if err := openBrowser(link); err != nil {
+					fmt.Fprintf(os.Stderr, "keepalive: could not open browser: %v\n", err)
+					return 1
+				}
+			}
+			return 0
+		})
+	default:
+		fmt.Fprintf(os.Stderr, "keepalive: unknown crash command %q\n", args[0])
+		return 2
+	}
+}
+
+func runCrashList(stdout io.Writer) int {
+	paths, err := crash.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+		return 1
+	}
+	if len(paths) == 0 {
+		fmt.Fprintln(stdout, "No crash reports.")
+		return 0
+	}
+	for _, path := range paths {
+		r, err := crash.Load(path)
+		if err != nil {
+			fmt.Fprintf(stdout, "%s\t(unreadable: %v)\n", path, err)
+			continue
+		}
+		fmt.Fprintf(stdout, "%s\t%s\t%s\n", path, r.Time.Local().Format("2006-01-02 15:04"), firstLine(r.Panic))
+	}
+	return 0
+}
+
+// withCrashReport loads the report named in args, or the newest one.
+func withCrashReport(args []string, fn func(path string, r crash.Report) int) int {
+	var path string
+	if len(args) > 0 {
+		path = args[0]
+	} else {
+		paths, err := crash.List()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+			return 1
+		}
+		if len(paths) == 0 {
+			fmt.Fprintln(os.Stderr, "keepalive: no crash reports found")
+			return 1
+		}
+		path = paths[0]
+	}
+
+	r, err := crash.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+		return 1
+	}
+	return fn(path, r)
+}
+
+// crashIssueURL builds a new-issue link prefilled with a summary of r.
+func crashIssueURL(r crash.Report) string {
+	stack := strings.Split(strings.TrimSpace(r.Stack), "\n")
+	if len(stack) > maxIssueStackLines {
+		stack = append(stack[:maxIssueStackLines], "...")
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "**Version:** %s\n", r.Build)
+	fmt.Fprintf(&body, "**Platform:** %s (%s)\n", r.Build.Platform, r.Build.GoVersion)
+	fmt.Fprintf(&body, "**Where:** %s\n\n", r.Where)
+	fmt.Fprintf(&body, "**Panic:** `%s`\n\n", firstLine(r.Panic))
+	fmt.Fprintf(&body, "```\n%s\n```\n\n", strings.Join(stack, "\n"))
+	body.WriteString("<!-- Please attach the full crash report file and describe what you were doing. -->\n")
+
+	q := url.Values{}
+	q.Set("title", "Crash: "+firstLine(r.Panic))
+	q.Set("body", body.String())
+	return issueURL + "?" + q.Encode()
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+func openBrowser(link string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", link)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", link)
+	default:
+		cmd = exec.Command("xdg-open", link)
+	}
+	return cmd.Start()
+}
+
+// reportPanic tells the user where a crash report was saved after the TUI
+// exited because of a panic.
+func reportPanic() {
+	path := crash.LastReport()
+	if path == "" {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\nA crash report was saved to %s\n", path)
+	fmt.Fprintln(os.Stderr, "Run `keepalive crash submit` to share it. Nothing is sent automatically.")
+}
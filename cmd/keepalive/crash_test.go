@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/crash"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type panickyModel struct{}
+
+func (panickyModel) Init() tea.Cmd                       { return nil }
+func (panickyModel) Update(tea.Msg) (tea.Model, tea.Cmd) { panic("update failed") }
+func (panickyModel) View() string                        { return "" }
+
+func TestCrashGuardModelRecordsPanic(t *testing.T) {
+	t.Setenv(crash.DirEnv, t.TempDir())
+
+	var recovered interface{}
+	func() {
+		defer func() { recovered = recover() }()
+		crashGuardModel{Model: panickyModel{}}.Update(nil)
+	}()
+
+	p, ok := recovered.(*crash.Panic)
+	if !ok || p.Report == "" {
+		t.Fatalf("recovered %#v, want recorded *crash.Panic", recovered)
+	}
+
+	var out bytes.Buffer
+	if code := runCrash([]string{"list"}, &out); code != 0 {
+		t.Fatalf("crash list exit code = %d", code)
+	}
+	if !strings.Contains(out.String(), "update failed") {
+		t.Fatalf("crash list output = %q", out.String())
+	}
+
+	out.Reset()
+	if code := runCrash([]string{"submit"}, &out); code != 0 {
+		t.Fatalf("crash submit exit code = %d", code)
+	}
+	if !strings.Contains(out.String(), "Nothing has been sent") || !strings.Contains(out.String(), issueURL) {
+		t.Fatalf("crash submit output = %q", out.String())
+	}
+}
+
+func TestCrashIssueURLTruncatesStack(t *testing.T) {
+	r := crash.Report{
+		Time:  time.Now(),
+		Where: "ui",
+		Panic: "boom\nsecond line",
+		Stack: strings.Repeat("frame\n", 100),
+	}
+	u, err := url.Parse(crashIssueURL(r))
+	if err != nil {
+		t.Fatalf("invalid URL: %v", err)
+	}
+	if got := u.Query().Get("title"); got != "Crash: boom" {
+		t.Errorf("title = %q", got)
+	}
+	if n := strings.Count(u.Query().Get("body"), "frame"); n != maxIssueStackLines {
+		t.Errorf("body has %d stack lines, want %d", n, maxIssueStackLines)
+	}
+}
+
+func TestCrashSubmitWithoutReports(t *testing.T) {
+	t.Setenv(crash.DirEnv, t.TempDir())
+	if code := runCrash([]string{"submit"}, &bytes.Buffer{}); code == 0 {
+		t.Fatal("expected failure with no reports")
+	}
+}
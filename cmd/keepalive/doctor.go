@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/buildinfo"
+	"github.com/stigoleg/keep-alive/internal/platform"
+)
+
+// doctorSchemaVersion is bumped whenever a field of doctorReport is renamed or
+// removed, so that tools aggregating reports can detect the change.
+const doctorSchemaVersion = 1
+
+// Overall and per-check statuses, ordered by severity. The exit code of
+// `keepalive doctor` is the index of the overall status.
+var doctorStatuses = []string{"ok", "warning", "error"}
+
+// doctorReport is the `keepalive doctor --json` document.
+type doctorReport struct {
+	SchemaVersion int                  `json:"schema_version"`
+	Status        string               `json:"status"`
+	Build         buildinfo.Info       `json:"build"`
+	Platform      platform.Diagnostics `json:"platform"`
+	Power         doctorPower          `json:"power"`
+	Idle          []doctorIdleSource   `json:"idle_sources"`
+	Checks        []doctorCheck        `json:"checks"`
+}
+
+// doctorPower describes battery, power source and brightness support. Errors
+// are reported as strings because desktops commonly have no battery.
+type doctorPower struct {
+	Source            string `json:"source"`
+	SourceError       string `json:"source_error,omitempty"`
+	BatteryPercent    *int   `json:"battery_percent"`
+	BatteryError      string `json:"battery_error,omitempty"`
+	BrightnessPercent *int   `json:"brightness_percent"`
+	BrightnessError   string `json:"brightness_error,omitempty"`
+}
+
+// doctorIdleSource is the idle time read by one detection method.
+type doctorIdleSource struct {
+	Name        string  `json:"name"`
+	IdleSeconds float64 `json:"idle_seconds"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// idleMismatchTolerance is how far apart two idle sources may be before they
+// are reported as disagreeing. The sources are read one after the other, so
+// they never match exactly.
+const idleMismatchTolerance = 5 * time.Second
+
+// doctorCheck is a single pass/fail finding.
+type doctorCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// runDoctor implements `keepalive doctor [--json]`.
+func runDoctor(args []string, stdout io.Writer) int {
+	flags := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	asJSON := flags.Bool("json", false, "Print the report as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	// Capability probes log as they go; keep that out of the report.
+	log.SetOutput(io.Discard)
+
+	report := buildDoctorReport(buildinfo.Get(), platform.Diagnose(), readDoctorPower(), readDoctorIdle(), platform.DetectCompetitors())
+	code := doctorExitCode(report.Status)
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+			return 1
+		}
+		return code
+	}
+
+	writeDoctorText(stdout, report)
+	return code
+}
+
+func readDoctorPower() doctorPower {
+	var p doctorPower
+	source, err := platform.GetPowerSource()
+	p.Source = source.String()
+	if err != nil {
+		p.SourceError = err.Error()
+	}
+	if status, err := platform.GetBatteryStatus(); err != nil {
+		p.BatteryError = err.Error()
+	} else {
+		p.BatteryPercent = &status.Percentage
+	}
+	if level, err := platform.GetBrightness(); err != nil {
+		p.BrightnessError = err.Error()
+	} else {
+		p.BrightnessPercent = &level
+	}
+	return p
+}
+
+func readDoctorIdle() []doctorIdleSource {
+	sources := []doctorIdleSource{}
+	for _, src := range platform.IdleSources() {
+		s := doctorIdleSource{Name: src.Name, IdleSeconds: src.Idle.Seconds()}
+		if src.Err != nil {
+			s.Error = src.Err.Error()
+		}
+		sources = append(sources, s)
+	}
+	return sources
+}
+
+// idleCheck reports whether the idle time can be read, and whether the
+// sources agree when there are several.
+func idleCheck(sources []doctorIdleSource) doctorCheck {
+	var working []doctorIdleSource
+	var failures []string
+	for _, s := range sources {
+		if s.Error != "" {
+			failures = append(failures, s.Name+": "+s.Error)
+			continue
+		}
+		working = append(working, s)
+	}
+
+	switch {
+	case len(sources) == 0:
+		return doctorCheck{"idle_detection", "warning", "Idle detection is unsupported on this platform"}
+	case len(working) == 0:
+		return doctorCheck{"idle_detection", "warning", "Idle time unavailable; --active and --until-idle-for will not work (" + strings.Join(failures, "; ") + ")"}
+	case len(failures) > 0:
+		return doctorCheck{"idle_detection", "warning", fmt.Sprintf("Using %s (%s)", working[0].Name, strings.Join(failures, "; "))}
+	}
+	for _, s := range working[1:] {
+		diff := time.Duration((s.IdleSeconds - working[0].IdleSeconds) * float64(time.Second)).Abs()
+		if diff > idleMismatchTolerance {
+			return doctorCheck{"idle_detection", "warning", fmt.Sprintf("%s reports %.0fs idle but %s reports %.0fs", working[0].Name, working[0].IdleSeconds, s.Name, s.IdleSeconds)}
+		}
+	}
+	names := make([]string, len(working))
+	for i, s := range working {
+		names[i] = s.Name
+	}
+	return doctorCheck{"idle_detection", "ok", fmt.Sprintf("Idle for %.0fs (%s)", working[0].IdleSeconds, strings.Join(names, ", "))}
+}
+
+// competitorsCheck reports other keep-awake and jiggler tools that are
+// running. One that simulates input is a warning, because it blocks --active.
+func competitorsCheck(running []platform.Competitor) doctorCheck {
+	if len(running) == 0 {
+		return doctorCheck{"competing_tools", "ok", "No other keep-awake tools running"}
+	}
+	var simulating []platform.Competitor
+	for _, c := range running {
+		if c.Simulates {
+			simulating = append(simulating, c)
+		}
+	}
+	if len(simulating) > 0 {
+		return doctorCheck{"competing_tools", "warning", fmt.Sprintf("%s simulates input; --active is refused while it runs unless --ignore-conflicts is given", platform.CompetitorNames(simulating))}
+	}
+	return doctorCheck{"competing_tools", "ok", platform.CompetitorNames(running) + " is also running; it only prevents sleep"}
+}
+
+// buildDoctorReport evaluates the checks and the overall status.
+func buildDoctorReport(build buildinfo.Info, diag platform.Diagnostics, power doctorPower, idle []doctorIdleSource, competitors []platform.Competitor) doctorReport {
+	var checks []doctorCheck
+
+	if diag.SleepPrevention {
+		checks = append(checks, doctorCheck{"sleep_prevention", "ok", "Available methods: " + strings.Join(diag.Inhibitors, ", ")})
+	} else {
+		checks = append(checks, doctorCheck{"sleep_prevention", "error", "No sleep prevention method has the tools it needs"})
+	}
+
+	if diag.ActivitySimulation.Available {
+		checks = append(checks, doctorCheck{"activity_simulation", "ok", "Using " + diag.ActivitySimulation.Method})
+	} else {
+		checks = append(checks, doctorCheck{"activity_simulation", "warning", diag.ActivitySimulation.Message})
+	}
+
+	checks = append(checks, idleCheck(idle))
+	checks = append(checks, competitorsCheck(competitors))
+	if diag.RemoteSession {
+		checks = append(checks, doctorCheck{"remote_session", "warning", platform.RemoteSessionNote})
+	}
+	if diag.ModernStandby {
+		// Informational: nothing is wrong, but not every way into standby
+		// can be held off.
+		checks = append(checks, doctorCheck{"modern_standby", "ok", platform.ModernStandbyNote})
+	}
+	if diag.Sandbox != "" {
+		checks = append(checks, doctorCheck{"sandbox", "ok", fmt.Sprintf("Running inside %s: sleep and idle are held off through the desktop portal (org.freedesktop.portal.Inhibit)", diag.Sandbox)})
+	}
+
+	if n := len(diag.MissingDependencies); n > 0 {
+		names := make([]string, n)
+		for i, dep := range diag.MissingDependencies {
+			names[i] = dep.Name
+		}
+		checks = append(checks, doctorCheck{"dependencies", "warning", "Missing optional dependencies: " + strings.Join(names, ", ")})
+	} else {
+		checks = append(checks, doctorCheck{"dependencies", "ok", "No missing dependencies"})
+	}
+
+	if power.BatteryPercent != nil {
+		checks = append(checks, doctorCheck{"battery", "ok", fmt.Sprintf("Battery at %d%% (%s power)", *power.BatteryPercent, power.Source)})
+	} else {
+		// A missing battery is normal on desktops and servers.
+		checks = append(checks, doctorCheck{"battery", "ok", "No battery information: " + power.BatteryError})
+	}
+
+	status := doctorStatuses[0]
+	for _, c := range checks {
+		if doctorExitCode(c.Status) > doctorExitCode(status) {
+			status = c.Status
+		}
+	}
+
+	return doctorReport{
+		SchemaVersion: doctorSchemaVersion,
+		Status:        status,
+		Build:         build,
+		Platform:      diag,
+		Power:         power,
+		Idle:          idle,
+		Checks:        checks,
+	}
+}
+
+func doctorExitCode(status string) int {
+	for i, s := range doctorStatuses {
+		if s == status {
+			return i
+		}
+	}
+	return len(doctorStatuses) - 1
+}
+
+func writeDoctorText(w io.Writer, r doctorReport) {
+	fmt.Fprintf(w, "Keep-Alive %s on %s/%s\n", r.Build.Version, r.Platform.OS, r.Platform.Arch)
+	if r.Platform.DesktopEnvironment != "" || r.Platform.DisplayServer != "" {
+		fmt.Fprintf(w, "Desktop: %s (%s)\n", r.Platform.DesktopEnvironment, r.Platform.DisplayServer)
+	}
+	fmt.Fprintln(w)
+	for _, c := range r.Checks {
+		fmt.Fprintf(w, "[%-7s] %-19s %s\n", c.Status, c.Name, c.Message)
+	}
+	for _, dep := range r.Platform.MissingDependencies {
+		fmt.Fprintf(w, "\n%s: %s\n", dep.Name, dep.WhyNeeded)
+		if dep.InstallCmd != "" {
+			fmt.Fprintf(w, "  install: %s\n", dep.InstallCmd)
+		}
+	}
+	fmt.Fprintf(w, "\nOverall: %s\n", r.Status)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/config"
+	"github.com/stigoleg/keep-alive/internal/platform"
+	"github.com/stigoleg/keep-alive/internal/util"
+)
+
+// writeDryRun prints what a session with cfg would do on a machine described
+// by diag, and returns the exit code: 0 if the system can be kept awake, 1 if
+// no sleep-prevention method has the tools it needs. With --presence-only it
+// is 1 if no activity-simulation method is available instead.
+func writeDryRun(w io.Writer, cfg *config.Config, diag platform.Diagnostics) int {
+	fmt.Fprintf(w, "Keep-Alive dry run on %s/%s: nothing will be activated.\n", diag.OS, diag.Arch)
+	if diag.DesktopEnvironment != "" || diag.DisplayServer != "" {
+		fmt.Fprintf(w, "Desktop: %s (%s)\n", diag.DesktopEnvironment, diag.DisplayServer)
+	}
+
+	fmt.Fprintf(w, "\nSession: %s\n", dryRunSession(cfg))
+	for _, opt := range dryRunOptions(cfg) {
+		fmt.Fprintf(w, "  %s\n", opt)
+	}
+
+	if cfg.PresenceOnly {
+		fmt.Fprintln(w, "\nSleep prevention: off (--presence-only leaves sleep to the system)")
+	} else {
+		fmt.Fprintln(w, "\nSleep prevention, in the order tried:")
+		writeNumbered(w, diag.Inhibitors)
+		if !diag.SleepPrevention {
+			fmt.Fprintln(w, "  None of these has the tools it needs; the system cannot be kept awake.")
+		}
+	}
+
+	switch {
+	case !cfg.SimulateActivity:
+		fmt.Fprintln(w, "\nActivity simulation: off (enable with --active)")
+	case len(diag.SimulationMethods) == 0:
+		fmt.Fprintf(w, "\nActivity simulation: unavailable\n  %s\n", strings.TrimSpace(diag.ActivitySimulation.Message))
+	default:
+		fmt.Fprintf(w, "\nActivity simulation (%s), in the order tried:\n", dryRunShape(cfg.MouseShape))
+		writeNumbered(w, diag.SimulationMethods)
+	}
+
+	if len(diag.Tools) > 0 {
+		names := make([]string, 0, len(diag.Tools))
+		for name := range diag.Tools {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintln(w, "\nTools:")
+		for _, name := range names {
+			found := "missing"
+			if diag.Tools[name] {
+				found = "found"
+			}
+			fmt.Fprintf(w, "  %-16s %s\n", name, found)
+		}
+	}
+
+	if cfg.PresenceOnly {
+		if len(diag.SimulationMethods) == 0 {
+			return 1
+		}
+		return 0
+	}
+	if !diag.SleepPrevention {
+		return 1
+	}
+	return 0
+}
+
+func writeNumbered(w io.Writer, items []string) {
+	for i, item := range items {
+		fmt.Fprintf(w, "  %d. %s\n", i+1, item)
+	}
+}
+
+// dryRunSession describes how long the session would last.
+func dryRunSession(cfg *config.Config) string {
+	var limits []string
+	switch {
+	case !cfg.Clock.IsZero():
+		limits = append(limits, "until "+cfg.Clock.Format("15:04 Mon"))
+	case cfg.Duration > 0:
+		limits = append(limits, "for "+util.FormatDuration(time.Duration(cfg.Duration)*time.Minute))
+	}
+	if cfg.BatteryThreshold > 0 {
+		limits = append(limits, fmt.Sprintf("until the battery reaches %d%%", cfg.BatteryThreshold))
+	}
+	if !cfg.Watch.IsZero() {
+		limits = append(limits, fmt.Sprintf("until %s exits", cfg.Watch))
+	}
+	if cfg.WhileCmd != "" {
+		limits = append(limits, fmt.Sprintf("while %q succeeds", cfg.WhileCmd))
+	}
+	if cfg.UntilIdle > 0 {
+		limits = append(limits, "until you are idle for "+util.FormatDuration(cfg.UntilIdle))
+	}
+	if len(limits) == 0 && cfg.Schedule != nil {
+		return "indefinite, until you quit"
+	}
+	if len(limits) == 0 {
+		return "indefinite, until you quit (without a duration or clock time the menu is shown first)"
+	}
+	return strings.Join(limits, ", or ")
+}
+
+// dryRunOptions lists the other options that change what the session does.
+func dryRunOptions(cfg *config.Config) []string {
+	var opts []string
+	if cfg.ACOnly {
+		opts = append(opts, "Pauses while on battery power")
+	}
+	if cfg.Schedule != nil {
+		opts = append(opts, "Pauses outside "+cfg.Schedule.String())
+	}
+	if cfg.SimulateActivity && cfg.QuietHours != nil {
+		opts = append(opts, "Pauses activity simulation during "+cfg.QuietHours.String())
+	}
+	if cfg.DimLevel > 0 {
+		opts = append(opts, fmt.Sprintf("Dims the display to %d%%", cfg.DimLevel))
+	}
+	if cfg.SimulateActivity && cfg.MaxSimulations > 0 {
+		opts = append(opts, fmt.Sprintf("Stops simulating activity after %d moves", cfg.MaxSimulations))
+	}
+	if cfg.AwayMode {
+		opts = append(opts, "Requests away mode: "+platform.GetAwayModeStatus().Message)
+	}
+	if cfg.AllowHibernate {
+		opts = append(opts, "Allows hibernation: "+platform.GetAllowHibernateStatus().Message)
+	}
+	if len(cfg.Assertions) > 0 {
+		names := make([]string, len(cfg.Assertions))
+		for i, a := range cfg.Assertions {
+			names[i] = string(a)
+		}
+		opts = append(opts, "Holds only these power assertions (macOS): "+strings.Join(names, ", "))
+	}
+	if cfg.OnExpire != "" {
+		opts = append(opts, fmt.Sprintf("Runs %q when the time is up", cfg.OnExpire))
+	}
+	if cfg.Notify {
+		opts = append(opts, "Shows a notification when the session ends")
+	}
+	if len(cfg.Tags) > 0 {
+		opts = append(opts, "Records the session in the history as "+cfg.Tags.String())
+	}
+	return opts
+}
+
+func dryRunShape(s platform.MouseShape) string {
+	pattern := string(s.Pattern)
+	if pattern == "" {
+		pattern = string(platform.MousePatternCircle)
+	}
+	if s.Size > 0 {
+		return fmt.Sprintf("%s pattern, %dpx", pattern, s.Size)
+	}
+	return pattern + " pattern"
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/history"
+	"github.com/stigoleg/keep-alive/internal/util"
+)
+
+// historyReport is the `keepalive history --json` document.
+type historyReport struct {
+	Sessions          []historySession `json:"sessions"`
+	TotalAwakeSeconds int64            `json:"total_awake_seconds"`
+}
+
+// historySession is a session in historyReport.
+type historySession struct {
+	Start        time.Time         `json:"start"`
+	End          time.Time         `json:"end"`
+	AwakeSeconds int64             `json:"awake_seconds"`
+	Tags         map[string]string `json:"tags,omitempty"`
+}
+
+// historyPath is replaced in tests.
+var historyPath = history.DefaultPath
+
+// runHistory implements `keepalive history [--tag key=value]... [--since when] [--json]`.
+func runHistory(args []string, stdout io.Writer) int {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	tags := history.Tags{}
+	flags.Var(tags, "tag", "Only show sessions with this key=value tag (repeatable)")
+	since := flags.String("since", "", "Only show sessions that ended in this period (e.g., \"7d\", \"12h\") or since a date (YYYY-MM-DD)")
+	asJSON := flags.Bool("json", false, "Print the sessions as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "keepalive: unexpected argument %q\n", flags.Arg(0))
+		return 2
+	}
+
+	q := history.Query{Tags: tags}
+	if *since != "" {
+		t, err := parseSince(*since, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+			return 2
+		}
+		q.Since = t
+	}
+
+	path, err := historyPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+		return 1
+	}
+	sessions, err := history.Read(path, q)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: reading history: %v\n", err)
+		return 1
+	}
+
+	var total time.Duration
+	for _, s := range sessions {
+		total += s.Awake
+	}
+
+	if *asJSON {
+		report := historyReport{Sessions: []historySession{}, TotalAwakeSeconds: int64(total.Seconds())}
+		for _, s := range sessions {
+			report.Sessions = append(report.Sessions, historySession{
+				Start:        s.Start,
+				End:          s.End,
+				AwakeSeconds: int64(s.Awake.Seconds()),
+				Tags:         s.Tags,
+			})
+		}
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if len(sessions) == 0 {
+		fmt.Fprintln(stdout, "No sessions recorded.")
+		return 0
+	}
+	for _, s := range sessions {
+		line := fmt.Sprintf("%s  %-8s", s.Start.Local().Format("2006-01-02 15:04"), util.FormatDuration(s.Awake))
+		if len(s.Tags) > 0 {
+			line += "  " + s.Tags.String()
+		}
+		fmt.Fprintln(stdout, strings.TrimRight(line, " "))
+	}
+	noun := "sessions"
+	if len(sessions) == 1 {
+		noun = "session"
+	}
+	fmt.Fprintf(stdout, "Total: %s across %d %s\n", util.FormatDuration(total), len(sessions), noun)
+	return 0
+}
+
+// parseSince resolves a --since value: a period before now such as "7d" or
+// "12h", or a date, meaning midnight local time.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use a period such as 7d or 12h, or a date such as 2025-10-01", value)
+}
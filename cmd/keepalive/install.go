@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/stigoleg/keep-alive/internal/install"
+)
+
+// runInstall implements `keepalive install [--shortcut]`.
+func runInstall(args []string, stdout io.Writer) int {
+	flags := flag.NewFlagSet("install", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	shortcut := flags.Bool("shortcut", false, "Also create a Start Menu shortcut")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "keepalive: unexpected argument %q\n", flags.Arg(0))
+		return 2
+	}
+
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: locating this binary: %v\n", err)
+		return 1
+	}
+
+	res, err := install.Install(exe, install.Options{Shortcut: *shortcut})
+	if errors.Is(err, install.ErrUnsupported) {
+		fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+		return 1
+	}
+	// Report the steps that succeeded before any failure.
+	if res.Copied {
+		fmt.Fprintf(stdout, "Installed %s.\n", res.Path)
+	} else if res.Path != "" && err == nil {
+		fmt.Fprintf(stdout, "%s is already installed.\n", res.Path)
+	}
+	if res.Unblocked {
+		fmt.Fprintln(stdout, "The installed copy is not marked as downloaded, so SmartScreen will not warn when it starts.")
+	}
+	if res.AddedToPath {
+		fmt.Fprintf(stdout, "Added %s to your PATH. Open a new terminal to run `keepalive` from anywhere.\n", res.Dir)
+	} else if err == nil {
+		fmt.Fprintf(stdout, "%s is already on your PATH.\n", res.Dir)
+	}
+	if res.Shortcut != "" {
+		fmt.Fprintf(stdout, "Created the Start Menu shortcut %s.\n", res.Shortcut)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+		return 1
+	}
+	return 0
+}
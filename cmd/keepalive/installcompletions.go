@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/config"
+	"github.com/stigoleg/keep-alive/internal/manpage"
+)
+
+// shellQueryTimeout bounds asking zsh for its fpath and man for its search
+// path, which may run the user's startup files.
+const shellQueryTimeout = 5 * time.Second
+
+// zshFpath and manpathDirs are replaced in tests.
+var (
+	zshFpath    = queryZshFpath
+	manpathDirs = queryManpath
+)
+
+// runInstallCompletions implements `keepalive install-completions [--shell
+// bash|zsh|fish] [--no-man]`.
+func runInstallCompletions(args []string, stdout io.Writer) int {
+	flags := flag.NewFlagSet("install-completions", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	shellFlag := flags.String("shell", "", "Shell to install completions for: bash, zsh or fish (default: from $SHELL)")
+	noMan := flags.Bool("no-man", false, "Do not install the man page")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "keepalive: unexpected argument %q\n", flags.Arg(0))
+		return 2
+	}
+	if runtime.GOOS == "windows" {
+		fmt.Fprintln(os.Stderr, "keepalive: on Windows, add `keepalive completion powershell | Out-String | Invoke-Expression` to your PowerShell profile instead")
+		return 1
+	}
+
+	shell := *shellFlag
+	if shell == "" {
+		shell = filepath.Base(os.Getenv("SHELL"))
+	}
+	var fpath []string
+	if shell == "zsh" {
+		fpath = zshFpath()
+	}
+	target, hint, err := completionTarget(shell, os.Getenv, fpath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+		return 2
+	}
+	var script bytes.Buffer
+	writeCompletion(&script, shell)
+	if err := writeUserFile(target, script.Bytes()); err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Installed %s completions to %s.\n", shell, target)
+	if hint != "" {
+		fmt.Fprintln(stdout, hint)
+	} else {
+		fmt.Fprintln(stdout, "They take effect in new shells.")
+	}
+
+	if *noMan {
+		return 0
+	}
+	dir := filepath.Join(dataHome(os.Getenv), "man")
+	page := filepath.Join(dir, "man1", "keepalive.1")
+	if err := writeUserFile(page, []byte(manpage.Render(config.FlagSet(), manCommands()))); err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Installed the man page to %s.\n", page)
+	if dirs := manpathDirs(); dirs != nil && !containsPath(dirs, dir) {
+		fmt.Fprintf(stdout, "%s is not searched by man; add `export MANPATH=\"%s:$MANPATH\"` to your shell's startup file to read it with `man keepalive`.\n", dir, dir)
+	}
+	return 0
+}
+
+// completionTarget returns where shell loads keepalive's completions from
+// for the current user, and a hint when the shell must be set up to look
+// there. fpath is zsh's function search path.
+func completionTarget(shell string, getenv func(string) string, fpath []string) (path, hint string, err error) {
+	home := getenv("HOME")
+	if home == "" {
+		return "", "", fmt.Errorf("HOME is not set")
+	}
+	switch shell {
+	case "bash":
+		// bash-completion loads completions from here on demand.
+		return filepath.Join(dataHome(getenv), "bash-completion", "completions", "keepalive"), "", nil
+	case "fish":
+		return filepath.Join(configHome(getenv), "fish", "completions", "keepalive.fish"), "", nil
+	case "zsh":
+		for _, dir := range fpath {
+			// Plugin and theme directories belong to a framework that
+			// may replace them.
+			if strings.HasPrefix(dir, home+string(filepath.Separator)) && !strings.Contains(dir, "/plugins/") && !strings.Contains(dir, "/themes/") {
+				return filepath.Join(dir, "_keepalive"), "", nil
+			}
+		}
+		dir := filepath.Join(home, ".zfunc")
+		return filepath.Join(dir, "_keepalive"), fmt.Sprintf("%s is not in your fpath; add `fpath=(%s $fpath)` before `compinit` in ~/.zshrc.", dir, dir), nil
+	case "":
+		return "", "", fmt.Errorf("cannot tell the shell from $SHELL; use --shell bash, zsh or fish")
+	default:
+		return "", "", fmt.Errorf("completions cannot be installed for %s; use --shell bash, zsh or fish, or `keepalive completion`", shell)
+	}
+}
+
+// dataHome returns the XDG data directory.
+func dataHome(getenv func(string) string) string {
+	if dir := getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(getenv("HOME"), ".local", "share")
+}
+
+// configHome returns the XDG configuration directory.
+func configHome(getenv func(string) string) string {
+	if dir := getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(getenv("HOME"), ".config")
+}
+
+// containsPath reports whether dirs includes dir, ignoring trailing
+// separators.
+func containsPath(dirs []string, dir string) bool {
+	for _, d := range dirs {
+		if filepath.Clean(d) == filepath.Clean(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// manCommands lists the subcommands for the man page.
+func manCommands() []manpage.Command {
+	names := subcommandNames()
+	commands := make([]manpage.Command, len(names))
+	for i, name := range names {
+		commands[i] = manpage.Command{Name: name, Desc: subcommandHelp[name].desc}
+	}
+	return commands
+}
+
+// writeUserFile writes data to path, creating its directory.
+func writeUserFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// queryZshFpath returns the fpath of an interactive zsh, which includes the
+// directories added by ~/.zshrc, or nil if zsh cannot be asked.
+func queryZshFpath() []string {
+	ctx, cancel := context.WithTimeout(context.Background(), shellQueryTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "zsh", "-ic", "print -rl -- $fpath").Output()
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, line := range strings.Split(string(out), "\n") {
+		// Startup files may print other output.
+		if line = strings.TrimSpace(line); filepath.IsAbs(line) {
+			dirs = append(dirs, line)
+		}
+	}
+	return dirs
+}
+
+// queryManpath returns the directories man searches, or nil if they cannot
+// be determined.
+func queryManpath() []string {
+	ctx, cancel := context.WithTimeout(context.Background(), shellQueryTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "manpath").Output()
+	if err != nil {
+		return nil
+	}
+	return filepath.SplitList(strings.TrimSpace(string(out)))
+}
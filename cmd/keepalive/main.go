@@ -7,24 +7,34 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/stigoleg/keep-alive/internal/buildinfo"
 	"github.com/stigoleg/keep-alive/internal/config"
+	"github.com/stigoleg/keep-alive/internal/crash"
+	"github.com/stigoleg/keep-alive/internal/dbusapi"
+	"github.com/stigoleg/keep-alive/internal/history"
 	"github.com/stigoleg/keep-alive/internal/keepalive"
+	"github.com/stigoleg/keep-alive/internal/logging"
+	"github.com/stigoleg/keep-alive/internal/menubar"
 	"github.com/stigoleg/keep-alive/internal/platform"
+	"github.com/stigoleg/keep-alive/internal/policy"
+	"github.com/stigoleg/keep-alive/internal/privacy"
+	"github.com/stigoleg/keep-alive/internal/sessionstate"
+	"github.com/stigoleg/keep-alive/internal/statusfile"
 	"github.com/stigoleg/keep-alive/internal/ui"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-const (
-	appVersion      = "1.5.3"
-	shutdownTimeout = 5 * time.Second
-)
+const shutdownTimeout = 5 * time.Second
 
 var (
 	cleanupOnce sync.Once
@@ -33,7 +43,14 @@ var (
 )
 
 func main() {
-	cfg, err := config.ParseFlags(appVersion)
+	defer crash.Guard("main")
+
+	if code, ok := runSubcommand(os.Args[1:]); ok {
+		os.Exit(code)
+	}
+
+	build := buildinfo.Get()
+	cfg, err := config.ParseFlags(build.Version)
 	if err != nil {
 		if errors.Is(err, flag.ErrHelp) {
 			return
@@ -42,29 +59,39 @@ func main() {
 		os.Exit(1)
 	}
 	if cfg.ShowVersion {
-		fmt.Printf("Keep-Alive Version: %s\n", appVersion)
+		fmt.Printf("Keep-Alive Version: %s\n", build)
 		return
 	}
+	if cfg.DryRun {
+		// Capability probes log as they go; keep that out of the report.
+		log.SetOutput(io.Discard)
+		os.Exit(writeDryRun(os.Stdout, cfg, platform.Diagnose()))
+	}
+	if cfg.Menubar && !menubar.Available {
+		fmt.Fprint(os.Stderr, ui.ErrorBanner(menubar.ErrUnavailable.Error()))
+		os.Exit(1)
+	}
 
 	if cfg.EnableLogging {
-		f, err := tea.LogToFile("debug.log", "debug")
+		f, logPath, fallbackCause, err := openLog(cfg.LogFile)
 		if err != nil {
-			fallbackPath := filepath.Join(os.TempDir(), "keepalive-debug.log")
-			fallbackFile, fallbackErr := os.OpenFile(fallbackPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
-			if fallbackErr != nil {
-				log.Fatalf("failed to enable logging: primary error=%v fallback error=%v", err, fallbackErr)
-			}
-			logFile = fallbackFile
-			log.SetOutput(fallbackFile)
-			log.Printf("logging enabled via fallback file %s (primary debug.log unavailable: %v)", fallbackPath, err)
-		} else {
-			logFile = f
-			log.SetOutput(f)
-			if absPath, err := filepath.Abs("debug.log"); err == nil {
-				log.Printf("logging enabled; writing debug logs to %s", absPath)
-			} else {
-				log.Printf("logging enabled; writing debug logs to debug.log")
-			}
+			fmt.Fprint(os.Stderr, ui.ErrorBanner(fmt.Sprintf("failed to enable logging: %v", err)))
+			os.Exit(1)
+		}
+		logFile = f
+
+		// The platform and keeper packages log through the injected logger;
+		// anything still using the standard log package lands in the same file.
+		logger := logging.New(f, cfg.LogLevel)
+		slog.SetDefault(logger)
+		platform.SetLogger(logger)
+		keepalive.SetLogger(logger)
+		if absPath, err := filepath.Abs(logPath); err == nil {
+			logPath = absPath
+		}
+		slog.Info("logging enabled", "path", logPath, "level", cfg.LogLevel)
+		if fallbackCause != nil {
+			slog.Warn("default log file unavailable, using fallback file", "err", fallbackCause)
 		}
 	} else {
 		log.SetOutput(io.Discard)
@@ -77,6 +104,58 @@ func main() {
 		}
 	}()
 
+	pol, err := policy.Load()
+	if err != nil {
+		fmt.Fprint(os.Stderr, ui.ErrorBanner(fmt.Sprintf("reading administrator policy: %v", err)))
+		os.Exit(1)
+	}
+	if !pol.IsZero() {
+		slog.Info("administrator policy applied", "source", pol.Source, "max_duration", pol.MaxDuration, "disable_active", pol.DisableActive)
+	}
+	startNow := cfg.Duration > 0 || !cfg.Clock.IsZero() || cfg.BatteryThreshold > 0 || !cfg.Watch.IsZero() || cfg.UntilIdle > 0 || cfg.WhileCmd != "" || cfg.Schedule != nil || cfg.Task != nil
+
+	// Another instance, such as the login service, already owns the control
+	// service: act as a front-end to it rather than starting a second keeper.
+	if _, err := queryRunning(); err == nil {
+		if cfg.Menubar {
+			fmt.Fprint(os.Stderr, ui.ErrorBanner("another keepalive is already running; stop it before showing one in the menu bar"))
+			os.Exit(1)
+		}
+		os.Exit(runAttached(startNow))
+	}
+	if cfg.SimulateActivity {
+		if err := pol.CheckActive(); err != nil {
+			fmt.Fprint(os.Stderr, ui.ErrorBanner(err.Error()))
+			os.Exit(1)
+		}
+	}
+	consent, err := loadConsent()
+	if err != nil {
+		fmt.Fprint(os.Stderr, ui.ErrorBanner(fmt.Sprintf("reading privacy consent: %v", err)))
+		os.Exit(1)
+	}
+	// Activity simulation needs the synthetic-input level. The TUI asks for
+	// it before simulating; the menu bar cannot, and presence-only sessions
+	// have nothing to run without it.
+	askConsent := cfg.SimulateActivity && !consent.Allows(privacy.SyntheticInput)
+	if askConsent && (cfg.Menubar || cfg.PresenceOnly) {
+		fmt.Fprint(os.Stderr, ui.ErrorBanner(consent.Check(privacy.SyntheticInput).Error()))
+		os.Exit(1)
+	}
+	if askConsent {
+		cfg.SimulateActivity = false
+	}
+	if startNow {
+		limit := time.Duration(cfg.Duration) * time.Minute
+		if !cfg.Clock.IsZero() {
+			limit = time.Until(cfg.Clock)
+		}
+		if err := pol.CheckDuration(limit); err != nil {
+			fmt.Fprint(os.Stderr, ui.ErrorBanner(err.Error()))
+			os.Exit(1)
+		}
+	}
+
 	var model ui.Model
 	var batteryStatus platform.BatteryStatus
 	if cfg.BatteryThreshold > 0 {
@@ -92,29 +171,161 @@ func main() {
 		batteryStatus = status
 	}
 
-	if cfg.Duration > 0 || cfg.BatteryThreshold > 0 {
+	if !cfg.Watch.IsZero() {
+		if err := cfg.Watch.Check(); err != nil {
+			fmt.Fprint(os.Stderr, ui.ErrorBanner(err.Error()))
+			os.Exit(1)
+		}
+	}
+
+	if cfg.WhileCmd != "" && !keepalive.CommandCondition(cfg.WhileCmd)() {
+		fmt.Fprint(os.Stderr, ui.ErrorBanner(fmt.Sprintf("--while-cmd %q does not succeed now", cfg.WhileCmd)))
+		os.Exit(1)
+	}
+
+	if cfg.StopOnLock {
+		if _, err := platform.ScreenLocked(); err != nil {
+			fmt.Fprint(os.Stderr, ui.ErrorBanner(fmt.Sprintf("screen lock unavailable, cannot use --stop-on-lock: %v", err)))
+			os.Exit(1)
+		}
+	}
+
+	if cfg.UntilIdle > 0 {
+		if _, err := platform.IdleTime(); err != nil {
+			fmt.Fprint(os.Stderr, ui.ErrorBanner(fmt.Sprintf("idle time unavailable, cannot use --until-idle-for: %v", err)))
+			os.Exit(1)
+		}
+	}
+
+	switch {
+	case !cfg.Clock.IsZero():
+		model = ui.InitialModelUntil(cfg.Clock, cfg.BatteryThreshold, batteryStatus, cfg.SimulateActivity)
+	case startNow:
 		model = ui.InitialModelWithLimits(cfg.Duration, cfg.BatteryThreshold, batteryStatus, cfg.SimulateActivity)
-	} else {
+	default:
 		model = ui.InitialModel()
 		model.SimulateActivity = cfg.SimulateActivity
 	}
-	model.SetVersion(appVersion)
+	model.SetVersion(build.Version)
+	model.KeepAlive.SetPolicy(pol)
+	model.KeepAlive.SetConsent(consent)
+	model.ShowConsent = askConsent
+	model.KeepAlive.SetStatusFile(statusfile.Path())
+	if path, err := history.DefaultPath(); err == nil {
+		model.KeepAlive.SetHistory(path)
+	}
+	if path, err := sessionstate.DefaultPath(); err == nil {
+		if !startNow {
+			if s, ok := sessionstate.Resumable(path, time.Now(), platform.ProcessAlive); ok {
+				model.SetResumable(s)
+				slog.Info("previous session can be resumed", "started", s.Start, "ends", s.End)
+			}
+		}
+		model.KeepAlive.SetStateFile(path)
+	}
+	model.KeepAlive.SetTags(cfg.Tags)
+	if cfg.Task != nil {
+		model.SetReason(cfg.Task.Description)
+		slog.Info("session started for task", "task", cfg.Task.ID, "description", cfg.Task.Description, "estimate", cfg.Task.Estimate)
+	}
+	model.KeepAlive.SetTimings(cfg.Timings)
+	model.KeepAlive.SetMouseShape(cfg.MouseShape)
+	if err := model.KeepAlive.SetSimulationKey(cfg.SimulationKey); err != nil {
+		fmt.Fprint(os.Stderr, ui.ErrorBanner(err.Error()))
+		os.Exit(1)
+	}
+	if cfg.ACOnly {
+		model.SetACOnly(true)
+	}
+	if cfg.Schedule != nil {
+		model.SetSchedule(cfg.Schedule)
+	}
+	model.KeepAlive.SetQuietHours(cfg.QuietHours)
+	model.KeepAlive.SetDimLevel(cfg.DimLevel)
+	model.KeepAlive.SetOnExpire(cfg.OnExpire)
+	model.KeepAlive.SetNotify(cfg.Notify)
+	model.KeepAlive.SetAwayMode(cfg.AwayMode)
+	model.KeepAlive.SetAllowHibernate(cfg.AllowHibernate)
+	model.KeepAlive.SetPresenceOnly(cfg.PresenceOnly)
+	model.KeepAlive.SetAssertions(cfg.Assertions)
+	model.KeepAlive.SetMaxSimulations(cfg.MaxSimulations)
+	model.KeepAlive.SetIgnoreConflicts(cfg.IgnoreConflicts)
+	if !cfg.Watch.IsZero() {
+		model.SetProcessWatch(cfg.Watch)
+	}
+	if cfg.UntilIdle > 0 {
+		model.SetUntilIdle(cfg.UntilIdle)
+	}
+	model.KeepAlive.SetStopOnLock(cfg.StopOnLock)
+	if cfg.WhileCmd != "" {
+		model.SetWhileCmd(cfg.WhileCmd)
+	}
 
 	// Check for missing dependencies and store in model for TUI display
 	depMessage := platform.GetDependencyMessage()
 	if depMessage != "" {
 		model.SetDependencyWarning(depMessage)
-		log.Printf("linux: missing dependencies detected:\n%s", depMessage)
+		slog.Warn("missing dependencies", "details", depMessage)
+	}
+	if cfg.AwayMode {
+		if status := platform.GetAwayModeStatus(); !status.Available {
+			model.SetDependencyWarning(strings.TrimSpace(model.DependencyWarning + "\n\n" + status.Message))
+			slog.Warn("away mode unavailable", "reason", status.Message)
+		}
+	}
+	if cfg.AllowHibernate {
+		if status := platform.GetAllowHibernateStatus(); !status.Available {
+			model.SetDependencyWarning(strings.TrimSpace(model.DependencyWarning + "\n\n" + status.Message))
+			slog.Warn("allowing hibernation unavailable", "reason", status.Message)
+		}
+	}
+	if platform.InRemoteSession() {
+		model.SetDependencyWarning(strings.TrimSpace(model.DependencyWarning + "\n\n" + platform.RemoteSessionNote))
+		slog.Warn("running in a remote desktop session")
+	}
+	if running := platform.DetectCompetitors(); len(running) > 0 {
+		msg := fmt.Sprintf("%s is also keeping this machine awake.", platform.CompetitorNames(running))
+		for _, c := range running {
+			if c.Simulates {
+				msg += fmt.Sprintf(" %s simulates input, so sessions with activity simulation are refused unless --ignore-conflicts is given.", c.Name)
+			}
+		}
+		model.SetDependencyWarning(strings.TrimSpace(model.DependencyWarning + "\n\n" + msg))
+		slog.Warn("other keep-awake tools are running", "tools", platform.CompetitorNames(running))
 	}
 	if cfg.SimulateActivity {
 		activeStatus := platform.GetActivitySimulationStatus()
 		if !activeStatus.Available {
 			model.SetActivityWarning(activeStatus.Message)
-			log.Printf("activity simulation unavailable: %s", activeStatus.Message)
+			slog.Warn("activity simulation unavailable", "reason", activeStatus.Message)
+		}
+		if !platform.AccessibilityTrusted() {
+			model.ShowAccessibility = true
+			slog.Warn("keep-alive is not trusted for Accessibility; mouse events go through osascript")
 		}
 	}
 
 	keeperRef = model.KeepAlive
+	crash.SetDiagnostics(func() map[string]string {
+		return map[string]string{
+			"args":               strings.Join(os.Args[1:], " "),
+			"running":            strconv.FormatBool(keeperRef.IsRunning()),
+			"simulate_activity":  strconv.FormatBool(keeperRef.SimulateActivity()),
+			"ac_only":            strconv.FormatBool(keeperRef.ACOnly()),
+			"dim_level":          strconv.Itoa(keeperRef.DimLevel()),
+			"suspended":          strconv.FormatBool(keeperRef.Suspended()),
+			"watch":              keeperRef.ProcessWatch().String(),
+			"on_expire":          keeperRef.OnExpire(),
+			"until_idle":         keeperRef.UntilIdle().String(),
+			"while_cmd":          cfg.WhileCmd,
+			"dependency_warning": depMessage,
+			"activity_warning":   model.ActivityWarning,
+		}
+	})
+
+	if cfg.Menubar {
+		os.Exit(runMenubar(cfg, keeperRef))
+	}
 
 	// Set up signal handling
 	sigChan := make(chan os.Signal, 1)
@@ -123,60 +334,107 @@ func main() {
 
 	// Create program with signal handling
 	p := tea.NewProgram(
-		model,
+		crashGuardModel{Model: model},
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
 		tea.WithoutSignalHandler(),
 	)
 
+	// Expose the control service where a session bus is available. Failure is
+	// not fatal: the TUI works the same without it.
+	dbusService, err := dbusapi.Serve(&programController{program: p, keeper: keeperRef, build: build})
+	if err != nil {
+		slog.Info("dbus control service not started", "err", err)
+	}
+	defer dbusService.Close()
+
+	if cfg.CheckUpdates {
+		go func() {
+			defer crash.Guard("update-check")
+			if version := checkForUpdate(build.Version); version != "" {
+				slog.Info("update available", "version", version)
+				p.Send(ui.UpdateAvailableMsg{Version: version})
+			}
+		}()
+	}
+
+	go func() {
+		defer crash.Guard("capabilities")
+		p.Send(ui.CapabilitiesMsg{Capabilities: platform.DetectCapabilities()})
+	}()
+
 	// Handle first termination signal in a separate goroutine.
 	go func() {
+		defer crash.Guard("signals")
 		sig := <-sigChan
-		log.Printf("Received signal: %v", sig)
+		slog.Info("received signal", "signal", sig)
 
 		// Handle SIGTSTP (Ctrl+Z) - prevent suspension and initiate shutdown
 		if isSIGTSTP(sig) {
-			log.Printf("SIGTSTP received: preventing suspension and initiating graceful shutdown")
+			slog.Info("SIGTSTP received: preventing suspension and shutting down")
 		}
 
-		executeCleanup(p)
+		executeCleanup(p, true)
 	}()
 
 	if _, err := p.Run(); err != nil {
-		log.Printf("Error running program: %v", err)
+		slog.Error("program failed", "err", err)
+		if errors.Is(err, tea.ErrProgramPanic) {
+			// Keep the saved session so that it can be resumed.
+			keeperRef.SetStateFile("")
+			executeCleanup(nil, false)
+			reportPanic()
+		}
 		os.Exit(1)
 	}
 
 	// Ensure cleanup runs on normal exit
-	executeCleanup(nil)
+	executeCleanup(nil, false)
 }
 
-// executeCleanup performs cleanup operations with timeout protection
-func executeCleanup(p *tea.Program) {
-	cleanupOnce.Do(func() {
-		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
-		defer cancel()
+// openLog opens the log file chosen with --log-file, or the default one. When
+// the default location cannot be used, it falls back to a per-user directory
+// under the temporary directory and also returns the error that caused the
+// fallback.
+func openLog(path string) (f *os.File, opened string, fallbackCause, err error) {
+	if path != "" {
+		f, err := logging.Open(path)
+		return f, path, nil, err
+	}
 
-		done := make(chan struct{})
-		go func() {
-			defer close(done)
+	path, err = logging.DefaultPath()
+	if err == nil {
+		if f, err = logging.Open(path); err == nil {
+			return f, path, nil, nil
+		}
+	}
+	fallbackPath := logging.FallbackPath()
+	f, fallbackErr := logging.OpenFallback()
+	if fallbackErr != nil {
+		return nil, "", nil, fmt.Errorf("%v; fallback %s: %v", err, fallbackPath, fallbackErr)
+	}
+	return f, fallbackPath, err, nil
+}
 
-			if keeperRef != nil {
-				if err := keeperRef.Stop(); err != nil {
-					log.Printf("Error stopping keep-alive: %v", err)
+// executeCleanup stops the keeper and flushes the log. Signal handlers pass
+// immediate so that exit never waits on a hung D-Bus or gsettings call;
+// otherwise the keeper gets shutdownTimeout to deactivate cleanly.
+func executeCleanup(p *tea.Program, immediate bool) {
+	cleanupOnce.Do(func() {
+		if keeperRef != nil {
+			if immediate {
+				keeperRef.StopNow()
+			} else {
+				ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+				if err := keeperRef.StopContext(ctx); err != nil {
+					slog.Error("stopping keep-alive failed", "err", err)
 				}
+				cancel()
 			}
+		}
 
-			if logFile != nil {
-				logFile.Sync()
-			}
-		}()
-
-		select {
-		case <-done:
-			log.Printf("Cleanup completed successfully")
-		case <-ctx.Done():
-			log.Printf("Cleanup timeout exceeded after %v, forcing exit", shutdownTimeout)
+		if logFile != nil {
+			logFile.Sync()
 		}
 
 		if p != nil {
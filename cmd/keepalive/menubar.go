@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/config"
+	"github.com/stigoleg/keep-alive/internal/crash"
+	"github.com/stigoleg/keep-alive/internal/keepalive"
+	"github.com/stigoleg/keep-alive/internal/menubar"
+	"github.com/stigoleg/keep-alive/internal/ui"
+)
+
+// runMenubar starts the session given on the command line, if any, and
+// shows k in the menu bar until Quit is chosen from it or a termination
+// signal arrives.
+func runMenubar(cfg *config.Config, k *keepalive.Keeper) int {
+	var err error
+	switch {
+	case !cfg.Clock.IsZero():
+		err = k.StartUntil(cfg.Clock)
+	case cfg.Duration > 0:
+		err = k.StartTimed(time.Duration(cfg.Duration) * time.Minute)
+	case cfg.Schedule != nil:
+		err = k.StartIndefinite()
+	}
+	if err != nil {
+		fmt.Fprint(os.Stderr, ui.ErrorBanner(err.Error()))
+		return 1
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, getSignals()...)
+	go func() {
+		defer crash.Guard("signals")
+		sig := <-sigChan
+		slog.Info("received signal", "signal", sig)
+		menubar.Quit()
+	}()
+
+	slog.Info("showing the session in the menu bar")
+	if err := menubar.Run(k); err != nil {
+		if !errors.Is(err, menubar.ErrUnavailable) {
+			slog.Error("menu bar failed", "err", err)
+		}
+		fmt.Fprint(os.Stderr, ui.ErrorBanner(err.Error()))
+		executeCleanup(nil, false)
+		return 1
+	}
+	executeCleanup(nil, false)
+	return 0
+}
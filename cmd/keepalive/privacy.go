@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/privacy"
+)
+
+// consentPath returns where consent is kept; it is replaced in tests.
+var consentPath = privacy.DefaultPath
+
+// loadConsent reads the privacy levels the user has enabled.
+func loadConsent() (privacy.Consent, error) {
+	path, err := consentPath()
+	if err != nil {
+		return privacy.Consent{Level: privacy.InhibitOnly}, nil
+	}
+	return privacy.Load(path)
+}
+
+// runPrivacy implements `keepalive privacy`: it shows the privacy level the
+// user has enabled and when, enables a level that needs consent, or
+// withdraws consent.
+func runPrivacy(args []string, stdout io.Writer) int {
+	usage := func() int {
+		fmt.Fprintln(os.Stderr, "usage: keepalive privacy [status]")
+		fmt.Fprintln(os.Stderr, "       keepalive privacy grant <synthetic-input|presence-api>")
+		fmt.Fprintln(os.Stderr, "       keepalive privacy revoke")
+		return 2
+	}
+	if len(args) == 0 {
+		args = []string{"status"}
+	}
+	path, err := consentPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: privacy: %v\n", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "status":
+		if len(args) != 1 {
+			return usage()
+		}
+		c, err := privacy.Load(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "keepalive: privacy: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Enabled level: %s\n", c.Level)
+		if c.GrantedAt.IsZero() {
+			fmt.Fprintln(stdout, "Consent:       none recorded; activity simulation asks for it first")
+		} else {
+			consent := c.GrantedAt.Local().Format(time.DateTime)
+			if c.User != "" {
+				consent += " by " + c.User
+			}
+			if c.Via != "" {
+				consent += " (" + c.Via + ")"
+			}
+			fmt.Fprintf(stdout, "Consent:       %s\n", consent)
+		}
+		fmt.Fprintf(stdout, "Recorded in:   %s\n", path)
+		return 0
+	case "grant":
+		if len(args) != 2 {
+			return usage()
+		}
+		level, err := privacy.ParseLevel(args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "keepalive: privacy: %v\n", err)
+			return 2
+		}
+		c, err := privacy.Grant(path, level, "command", time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "keepalive: privacy: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Enabled %s (recorded %s).\n", c.Level, c.GrantedAt.Local().Format(time.DateTime))
+		return 0
+	case "revoke":
+		if len(args) != 1 {
+			return usage()
+		}
+		if err := privacy.Revoke(path); err != nil {
+			fmt.Fprintf(os.Stderr, "keepalive: privacy: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Consent withdrawn; sessions are %s until a higher level is enabled again.\n", privacy.InhibitOnly)
+		return 0
+	default:
+		return usage()
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/stigoleg/keep-alive/internal/statusfile"
+)
+
+// The prompt helpers read the status file with shell builtins, so a prompt
+// costs no extra processes in bash and zsh. fish has no builtin clock or
+// kill, so its helper checks the session process at most every 10 seconds.
+// Each helper ignores a status file or directory owned by another user and
+// a line that is not two numbers, because bash and zsh evaluate variables
+// inside arithmetic, which would run commands hidden in a crafted line.
+const bashPromptSnippet = `# Keep-Alive prompt helper. Add it to ~/.bashrc:
+#   eval "$(keepalive prompt-snippet bash)"
+#   PS1='$(keepalive_prompt)'"$PS1"
+_keepalive_status=@STATUS@
+keepalive_prompt() {
+	local pid end now left
+	[[ -O ${_keepalive_status%/*} && -O $_keepalive_status ]] || return 0
+	read -r pid end < "$_keepalive_status" || return 0
+	case $pid in ''|*[!0-9]*) return 0 ;; esac
+	case $end in ''|*[!0-9]*) return 0 ;; esac
+	kill -0 "$pid" 2>/dev/null || return 0
+	if (( end == 0 )); then
+		printf '☕ '
+		return 0
+	fi
+	printf -v now '%(%s)T' -1 2>/dev/null || now=$(date +%s)
+	left=$(( (end - now + 59) / 60 ))
+	(( left > 0 )) || return 0
+	if (( left >= 60 )); then
+		printf '☕ %dh%02dm ' $(( left / 60 )) $(( left % 60 ))
+	else
+		printf '☕ %dm ' "$left"
+	fi
+}
+`
+
+const zshPromptSnippet = `# Keep-Alive prompt helper. Add it to ~/.zshrc:
+#   eval "$(keepalive prompt-snippet zsh)"
+#   setopt prompt_subst
+#   PROMPT='$(keepalive_prompt)'"$PROMPT"
+zmodload -F zsh/datetime p:EPOCHSECONDS 2>/dev/null
+_keepalive_status=@STATUS@
+keepalive_prompt() {
+	local pid end left
+	[[ -O ${_keepalive_status%/*} && -O $_keepalive_status ]] || return 0
+	read -r pid end < "$_keepalive_status" || return 0
+	case $pid in ''|*[!0-9]*) return 0 ;; esac
+	case $end in ''|*[!0-9]*) return 0 ;; esac
+	kill -0 "$pid" 2>/dev/null || return 0
+	if (( end == 0 )); then
+		print -n '☕ '
+		return 0
+	fi
+	left=$(( (end - ${EPOCHSECONDS:-$(date +%s)} + 59) / 60 ))
+	(( left > 0 )) || return 0
+	if (( left >= 60 )); then
+		printf '☕ %dh%02dm ' $(( left / 60 )) $(( left % 60 ))
+	else
+		printf '☕ %dm ' $left
+	fi
+}
+`
+
+const fishPromptSnippet = `# Keep-Alive prompt helper. Add it to ~/.config/fish/config.fish:
+#   keepalive prompt-snippet fish | source
+# and call keepalive_prompt from fish_prompt or fish_right_prompt.
+set -g _keepalive_status @STATUS@
+set -g _keepalive_alive_pid ''
+set -g _keepalive_alive_at 0
+function keepalive_prompt --description 'Show the time left in the keep-alive session'
+	test -O (string replace -r '/[^/]*$' '' -- $_keepalive_status); and test -O $_keepalive_status; or return 0
+	read -l pid end < $_keepalive_status; or return 0
+	string match -qr '^[0-9]+$' -- "$pid"; and string match -qr '^[0-9]+$' -- "$end"; or return 0
+	set -l now (date +%s)
+	if test "$pid" != "$_keepalive_alive_pid"; or test $now -ge (math $_keepalive_alive_at + 10)
+		set -g _keepalive_alive_pid ''
+		kill -0 $pid 2>/dev/null; or return 0
+		set -g _keepalive_alive_pid $pid
+		set -g _keepalive_alive_at $now
+	end
+	if test "$end" = 0
+		printf '☕ '
+		return 0
+	end
+	set -l left (math --scale=0 "($end - $now + 59) / 60")
+	test $left -gt 0; or return 0
+	if test $left -ge 60
+		printf '☕ %dh%02dm ' (math --scale=0 "$left / 60") (math "$left % 60")
+	else
+		printf '☕ %dm ' $left
+	end
+end
+`
+
+// runPromptSnippet implements `keepalive prompt-snippet <bash|zsh|fish>`: it
+// prints a shell function that shows the time left in the running session,
+// for use in the prompt.
+func runPromptSnippet(args []string, stdout io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: keepalive prompt-snippet <bash|zsh|fish>")
+		return 2
+	}
+	snippet, err := promptSnippet(args[0], statusfile.Path())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+		return 2
+	}
+	fmt.Fprint(stdout, snippet)
+	return 0
+}
+
+// promptSnippet returns the prompt helper for shell, reading the status file
+// at path.
+func promptSnippet(shell, path string) (string, error) {
+	switch shell {
+	case "bash":
+		return strings.ReplaceAll(bashPromptSnippet, "@STATUS@", posixQuote(path)), nil
+	case "zsh":
+		return strings.ReplaceAll(zshPromptSnippet, "@STATUS@", posixQuote(path)), nil
+	case "fish":
+		return strings.ReplaceAll(fishPromptSnippet, "@STATUS@", fishQuote(path)), nil
+	default:
+		return "", fmt.Errorf("unsupported shell %q: use bash, zsh or fish", shell)
+	}
+}
+
+// posixQuote quotes s for bash and zsh.
+func posixQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote quotes s for fish, where backslashes and single quotes are
+// escaped inside single quotes.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/buildinfo"
+	"github.com/stigoleg/keep-alive/internal/dbusapi"
+	"github.com/stigoleg/keep-alive/internal/keepalive"
+	"github.com/stigoleg/keep-alive/internal/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// remoteCommandTimeout bounds how long an external request waits for the TUI
+// to apply it.
+const remoteCommandTimeout = 5 * time.Second
+
+// programController forwards external control requests to the running TUI so
+// that its screen stays consistent with the keeper state.
+type programController struct {
+	program *tea.Program
+	keeper  *keepalive.Keeper
+	build   buildinfo.Info
+}
+
+func (c *programController) send(cmd ui.RemoteCommand, d time.Duration) error {
+	reply := make(chan error, 1)
+	// Send blocks until the program reads the message, so keep it off the
+	// caller's goroutine to let the timeout below apply.
+	go c.program.Send(ui.RemoteCommandMsg{Command: cmd, Duration: d, Reply: reply})
+
+	select {
+	case err := <-reply:
+		return err
+	case <-time.After(remoteCommandTimeout):
+		return errors.New("timed out waiting for keepalive to apply the request")
+	}
+}
+
+func (c *programController) Start(d time.Duration) error {
+	return c.send(ui.RemoteStart, d)
+}
+
+func (c *programController) Stop() error {
+	return c.send(ui.RemoteStop, 0)
+}
+
+func (c *programController) Extend(d time.Duration) error {
+	return c.send(ui.RemoteExtend, d)
+}
+
+func (c *programController) Pause() error {
+	return c.send(ui.RemotePause, 0)
+}
+
+func (c *programController) Resume() error {
+	return c.send(ui.RemoteResume, 0)
+}
+
+// SetSimulationMethod is applied to the keeper directly: the TUI reads the
+// method from it when drawing, so there is no screen state to update.
+func (c *programController) SetSimulationMethod(method string) error {
+	return c.keeper.SetSimulationMethod(method)
+}
+
+// SetSimulateActivity goes through the TUI, which shows whether activity
+// simulation is on.
+func (c *programController) SetSimulateActivity(enabled bool) error {
+	if enabled {
+		return c.send(ui.RemoteSimulationOn, 0)
+	}
+	return c.send(ui.RemoteSimulationOff, 0)
+}
+
+// SetSimulationInterval is applied to the keeper directly, like
+// SetSimulationMethod.
+func (c *programController) SetSimulationInterval(d time.Duration) error {
+	return c.keeper.SetSimulationInterval(d)
+}
+
+func (c *programController) Status() dbusapi.Status {
+	return dbusapi.Status{
+		Running:            c.keeper.IsRunning(),
+		SimulateActivity:   c.keeper.SimulateActivity(),
+		Paused:             c.keeper.Paused(),
+		SimulationMethod:   c.keeper.SimulationMethod(),
+		SimulationInterval: c.keeper.SimulationInterval(),
+		EndTime:            c.keeper.EndTime(),
+		Remaining:          c.keeper.TimeRemaining(),
+		Version:            c.build.Version,
+		Commit:             c.build.Commit,
+		BuildDate:          c.build.BuildDate,
+	}
+}
+
+// busAttachment reaches an instance running in another process through its
+// D-Bus control service.
+type busAttachment struct{}
+
+func (busAttachment) Status() (ui.AttachedStatus, error) {
+	s, err := queryRunning()
+	if err != nil {
+		return ui.AttachedStatus{}, err
+	}
+	return ui.AttachedStatus{
+		Running:          s.Running,
+		Paused:           s.Paused,
+		SimulateActivity: s.SimulateActivity,
+		SimulationMethod: s.SimulationMethod,
+		EndTime:          s.EndTime,
+		Version:          s.Version,
+	}, nil
+}
+
+func (busAttachment) Control(cmd ui.RemoteCommand, d time.Duration) error {
+	switch cmd {
+	case ui.RemoteStart:
+		return callRunning("Start", int64(d/time.Second))
+	case ui.RemoteStop:
+		return callRunning("Stop")
+	case ui.RemoteExtend:
+		return callRunning("Extend", int64(d/time.Second))
+	case ui.RemotePause:
+		return callRunning("Pause")
+	case ui.RemoteResume:
+		return callRunning("Resume")
+	case ui.RemoteSimulationOn, ui.RemoteSimulationOff:
+		return callRunning("SetSimulateActivity", cmd == ui.RemoteSimulationOn)
+	}
+	return errors.New("unknown remote command")
+}
+
+// runAttached shows the dashboard over the instance that is already
+// running. sessionFlags reports whether flags that start a session were
+// given; they are not applied to that instance.
+func runAttached(sessionFlags bool) int {
+	slog.Info("attaching to the running keepalive")
+	notice := ""
+	if sessionFlags {
+		notice = "Session options were not applied: stop the running session first to start a new one."
+	}
+	p := tea.NewProgram(crashGuardModel{Model: ui.NewAttachedModel(busAttachment{}, notice)}, tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		slog.Error("program failed", "err", err)
+		return 1
+	}
+	return 0
+}
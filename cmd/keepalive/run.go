@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/stigoleg/keep-alive/internal/history"
+	"github.com/stigoleg/keep-alive/internal/keepalive"
+	"github.com/stigoleg/keep-alive/internal/platform"
+	"github.com/stigoleg/keep-alive/internal/policy"
+	"github.com/stigoleg/keep-alive/internal/privacy"
+	"github.com/stigoleg/keep-alive/internal/statusfile"
+)
+
+// Exit codes used by `keepalive run` when the command itself cannot run,
+// following the shell conventions.
+const (
+	exitCannotExecute = 126
+	exitNotFound      = 127
+	exitSignalBase    = 128
+)
+
+// runRun implements `keepalive run [flags] -- command [args...]`: it keeps the
+// system awake while the command runs and exits with the command's status.
+func runRun(args []string, stdout io.Writer) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: keepalive run [-a] [--ignore-conflicts] [--ac-only] [--away-mode] [--dim percent] [--tag key=value]... -- command [args...]")
+		flags.PrintDefaults()
+	}
+	simulateActivity := flags.Bool("active", false, "Simulate activity while the command runs")
+	flags.BoolVar(simulateActivity, "a", false, "Simulate activity while the command runs")
+	ignoreConflicts := flags.Bool("ignore-conflicts", false, "Simulate activity even while another mouse jiggler is running")
+	acOnly := flags.Bool("ac-only", false, "Suspend keep-alive while running on battery power")
+	awayMode := flags.Bool("away-mode", false, "Let the display and audio turn off while the command runs (Windows)")
+	dimLevel := flags.Int("dim", 0, "Dim the display to this brightness percentage while the command runs")
+	tags := history.Tags{}
+	flags.Var(tags, "tag", "Label the session in the history with key=value (repeatable)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	command := flags.Args()
+	if len(command) == 0 {
+		flags.Usage()
+		return 2
+	}
+	if *dimLevel < 0 || *dimLevel > 99 {
+		fmt.Fprintln(os.Stderr, "keepalive: dim level must be between 1 and 99")
+		return 2
+	}
+
+	// Keep the keeper's diagnostics out of the command's output.
+	log.SetOutput(io.Discard)
+
+	if *simulateActivity {
+		if status := platform.GetActivitySimulationStatus(); !status.Available {
+			fmt.Fprintf(os.Stderr, "keepalive: activity simulation unavailable: %s\n", strings.TrimSpace(status.Message))
+		}
+	}
+	if *awayMode {
+		if status := platform.GetAwayModeStatus(); !status.Available {
+			fmt.Fprintf(os.Stderr, "keepalive: away mode unavailable: %s\n", strings.TrimSpace(status.Message))
+		}
+	}
+
+	pol, err := policy.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: reading administrator policy: %v\n", err)
+		return 1
+	}
+	if *simulateActivity {
+		if err := pol.CheckActive(); err != nil {
+			fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+			return 1
+		}
+	}
+	consent, err := loadConsent()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: reading privacy consent: %v\n", err)
+		return 1
+	}
+	if *simulateActivity {
+		if err := consent.Check(privacy.SyntheticInput); err != nil {
+			fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+			return 1
+		}
+	}
+
+	keeper := keepalive.New(keepalive.WithSimulateActivity(*simulateActivity), keepalive.WithAwayMode(*awayMode))
+	keeper.SetPolicy(pol)
+	keeper.SetConsent(consent)
+	keeper.SetStatusFile(statusfile.Path())
+	if path, err := history.DefaultPath(); err == nil {
+		keeper.SetHistory(path)
+	}
+	keeper.SetTags(tags)
+	keeper.SetIgnoreConflicts(*ignoreConflicts)
+	keeper.SetACOnly(*acOnly)
+	keeper.SetDimLevel(*dimLevel)
+	// Under a duration cap the command is only kept awake for that long.
+	if pol.MaxDuration > 0 {
+		err = keeper.StartTimed(pol.MaxDuration)
+	} else {
+		err = keeper.StartIndefinite()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+		return 1
+	}
+	code := runChild(command, stdout)
+	if err := keeper.Stop(); err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: stopping keep-alive: %v\n", err)
+	}
+	return code
+}
+
+// runChild runs command with the terminal attached, forwarding termination
+// signals to it, and returns its exit code.
+func runChild(command []string, stdout io.Writer) int {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, forwardedSignals()...)
+	defer signal.Stop(sigChan)
+
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+		if errors.Is(err, exec.ErrNotFound) || errors.Is(err, os.ErrNotExist) {
+			return exitNotFound
+		}
+		return exitCannotExecute
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-sigChan:
+				// Not every signal can be delivered on every platform; the
+				// terminal usually signals the child directly anyway.
+				_ = cmd.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return exitCode(cmd.Wait())
+}
+
+// forwardedSignals are the handled signals other than SIGTSTP, which is left
+// to the terminal so that job control still works for the wrapped command.
+func forwardedSignals() []os.Signal {
+	var signals []os.Signal
+	for _, sig := range getSignals() {
+		if !isSIGTSTP(sig) {
+			signals = append(signals, sig)
+		}
+	}
+	return signals
+}
+
+// exitCode converts the result of cmd.Wait to a process exit code. A command
+// killed by a signal reports 128+signal, as shells do.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+		return 1
+	}
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return exitSignalBase + int(status.Signal())
+	}
+	return exitErr.ExitCode()
+}
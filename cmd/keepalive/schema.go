@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/stigoleg/keep-alive/internal/schema"
+)
+
+// runSchema implements `keepalive schema [name]`: it lists the JSON
+// Schemas of the documents Keep-Alive gives other programs, or prints the
+// one named.
+func runSchema(args []string, stdout io.Writer) int {
+	switch len(args) {
+	case 0:
+		for _, name := range schema.Names() {
+			fmt.Fprintln(stdout, name)
+		}
+		return 0
+	case 1:
+		data, err := schema.Get(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "keepalive: schema: %v\n", err)
+			return 2
+		}
+		if _, err := stdout.Write(data); err != nil {
+			fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+			return 1
+		}
+		return 0
+	default:
+		fmt.Fprintln(os.Stderr, "usage: keepalive schema [name]")
+		return 2
+	}
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/stigoleg/keep-alive/internal/history"
+	"github.com/stigoleg/keep-alive/internal/keepalive"
+	"github.com/stigoleg/keep-alive/internal/logging"
+	"github.com/stigoleg/keep-alive/internal/platform"
+	"github.com/stigoleg/keep-alive/internal/policy"
+	"github.com/stigoleg/keep-alive/internal/privacy"
+	"github.com/stigoleg/keep-alive/internal/schedule"
+	"github.com/stigoleg/keep-alive/internal/service"
+	"github.com/stigoleg/keep-alive/internal/statusfile"
+)
+
+const serviceUsage = "usage: keepalive service <install|uninstall|status> [--system] [-a] [--ac-only] [--dim percent] [--schedule spec] [--log]"
+
+// serviceProfile holds the options a login service runs with.
+type serviceProfile struct {
+	simulateActivity bool
+	acOnly           bool
+	dimLevel         int
+	schedule         *schedule.Schedule
+	log              bool
+	// system installs a Windows service that runs at boot rather than a
+	// login service.
+	system bool
+}
+
+// parseServiceProfile reads the profile options given to `service install`
+// and `service run`.
+func parseServiceProfile(name string, args []string) (serviceProfile, error) {
+	var p serviceProfile
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	flags.BoolVar(&p.simulateActivity, "active", false, "Simulate activity")
+	flags.BoolVar(&p.simulateActivity, "a", false, "Simulate activity")
+	flags.BoolVar(&p.acOnly, "ac-only", false, "Suspend keep-alive while running on battery power")
+	flags.IntVar(&p.dimLevel, "dim", 0, "Dim the display to this brightness percentage while active")
+	spec := flags.String("schedule", "", "Keep the system awake only within these weekly hours")
+	flags.BoolVar(&p.log, "log", false, "Write a log to the default log file")
+	flags.BoolVar(&p.system, "system", false, "Install a Windows service that runs at boot, without a user logged in")
+	if err := flags.Parse(args); err != nil {
+		return p, err
+	}
+	if flags.NArg() > 0 {
+		return p, fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
+	if p.system {
+		if err := checkSystemService(); err != nil {
+			return p, err
+		}
+		// A service runs outside every desktop session.
+		if p.simulateActivity {
+			return p, errors.New("--system cannot be combined with --active: a Windows service has no desktop to simulate activity on")
+		}
+		if p.dimLevel > 0 {
+			return p, errors.New("--system cannot be combined with --dim: a Windows service has no display to dim")
+		}
+	}
+	if p.dimLevel < 0 || p.dimLevel > 99 {
+		return p, errors.New("dim level must be between 1 and 99")
+	}
+	if *spec != "" {
+		s, err := schedule.Parse(*spec)
+		if err != nil {
+			return p, fmt.Errorf("invalid --schedule: %w", err)
+		}
+		p.schedule = s
+	}
+	return p, nil
+}
+
+// runService implements `keepalive service <install|uninstall|status|run>`.
+// run is what the installed service executes.
+func runService(args []string, stdout io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, serviceUsage)
+		return 2
+	}
+
+	switch args[0] {
+	case "install":
+		return runServiceInstall(args[1:], stdout)
+	case "uninstall":
+		system, err := parseServiceSystem("service uninstall", args[1:])
+		if err != nil {
+			return 2
+		}
+		if system {
+			err = service.UninstallSystem()
+		} else {
+			err = service.Uninstall()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+			return 1
+		}
+		if system {
+			fmt.Fprintln(stdout, "Windows service removed.")
+		} else {
+			fmt.Fprintln(stdout, "Login service removed.")
+		}
+		return 0
+	case "status":
+		system, err := parseServiceSystem("service status", args[1:])
+		if err != nil {
+			return 2
+		}
+		var st service.State
+		if system {
+			st, err = service.SystemStatus()
+		} else {
+			st, err = service.Status()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+			return 1
+		}
+		if !st.Installed {
+			fmt.Fprintln(stdout, "Installed: no")
+			return 0
+		}
+		fmt.Fprintf(stdout, "Installed: yes (%s)\n", st.Path)
+		fmt.Fprintf(stdout, "Running:   %s\n", yesNo(st.Running))
+		return 0
+	case "run":
+		return runServiceRun(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, serviceUsage)
+		return 2
+	}
+}
+
+// parseServiceSystem reads the --system option of `service uninstall` and
+// `service status`, reporting errors itself.
+func parseServiceSystem(name string, args []string) (bool, error) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	system := flags.Bool("system", false, "Act on the Windows service rather than the login service")
+	err := flags.Parse(args)
+	if err == nil && flags.NArg() > 0 {
+		err = fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
+	if err == nil && *system {
+		err = checkSystemService()
+	}
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+	}
+	return *system, err
+}
+
+// checkSystemService returns an error on systems without Windows services.
+func checkSystemService() error {
+	if runtime.GOOS != "windows" {
+		return errors.New("--system installs a Windows service and is only supported on Windows")
+	}
+	return nil
+}
+
+// runServiceInstall registers the service to run this executable with the
+// given profile.
+func runServiceInstall(args []string, stdout io.Writer) int {
+	p, err := parseServiceProfile("service install", args)
+	if err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+		}
+		return 2
+	}
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: locating executable: %v\n", err)
+		return 1
+	}
+
+	command := append([]string{exe, "service", "run"}, args...)
+	if p.system {
+		name, err := service.InstallSystem(command)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Windows service installed: %s\n", name)
+		fmt.Fprintf(stdout, "Runs at boot: %s\n", strings.Join(command, " "))
+		return 0
+	}
+	path, err := service.Install(command)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Login service installed: %s\n", path)
+	fmt.Fprintf(stdout, "Runs at login: %s\n", strings.Join(command, " "))
+	return 0
+}
+
+// runServiceRun keeps the system awake with the given profile, without the
+// TUI, until it is signalled to stop, or until the service control manager
+// stops it when it runs as a Windows service.
+func runServiceRun(args []string) int {
+	p, err := parseServiceProfile("service run", args)
+	if err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+		}
+		return 2
+	}
+
+	events := service.OpenEventLog()
+	defer events.Close()
+	if service.IsSystemService() {
+		err = service.RunSystemService(func(stop <-chan struct{}) error {
+			return serveProfile(p, args, stop, events)
+		})
+	} else {
+		stop := make(chan struct{})
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, getSignals()...)
+		defer signal.Stop(sigChan)
+		go func() {
+			sig := <-sigChan
+			slog.Info("received signal", "signal", sig)
+			close(stop)
+		}()
+		err = serveProfile(p, args, stop, events)
+	}
+	if err != nil {
+		slog.Error("service failed", "err", err)
+		events.Failed(err)
+		fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// serveProfile keeps the system awake with profile p until stop is closed,
+// recording when it starts and stops in events.
+func serveProfile(p serviceProfile, args []string, stop <-chan struct{}, events *service.EventLog) error {
+	if p.log {
+		f, _, _, err := openLog("")
+		if err != nil {
+			return fmt.Errorf("failed to enable logging: %w", err)
+		}
+		defer f.Close()
+		logger := logging.New(f, slog.LevelInfo)
+		slog.SetDefault(logger)
+		platform.SetLogger(logger)
+		keepalive.SetLogger(logger)
+	} else {
+		log.SetOutput(io.Discard)
+	}
+
+	pol, err := policy.Load()
+	if err != nil {
+		return fmt.Errorf("reading administrator policy: %w", err)
+	}
+	if p.simulateActivity {
+		if err := pol.CheckActive(); err != nil {
+			return err
+		}
+	}
+	consent, err := loadConsent()
+	if err != nil {
+		return fmt.Errorf("reading privacy consent: %w", err)
+	}
+	if p.simulateActivity {
+		if err := consent.Check(privacy.SyntheticInput); err != nil {
+			return err
+		}
+	}
+
+	keeper := keepalive.New(keepalive.WithSimulateActivity(p.simulateActivity))
+	keeper.SetPolicy(pol)
+	keeper.SetConsent(consent)
+	keeper.SetStatusFile(statusfile.Path())
+	if path, err := history.DefaultPath(); err == nil {
+		keeper.SetHistory(path)
+	}
+	keeper.SetACOnly(p.acOnly)
+	keeper.SetDimLevel(p.dimLevel)
+	keeper.SetSchedule(p.schedule)
+	if pol.MaxDuration > 0 {
+		err = keeper.StartTimed(pol.MaxDuration)
+	} else {
+		err = keeper.StartIndefinite()
+	}
+	if err != nil {
+		return err
+	}
+	slog.Info("service started", "args", strings.Join(args, " "))
+	events.Started(strings.Join(args, " "))
+
+	<-stop
+	if err := keeper.Stop(); err != nil {
+		return fmt.Errorf("stopping keep-alive: %w", err)
+	}
+	slog.Info("service stopped")
+	events.Stopped()
+	return nil
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/platform"
+	"github.com/stigoleg/keep-alive/internal/util"
+)
+
+// newKeepAlive creates the platform keep-alive; it is replaced in tests.
+var newKeepAlive = platform.NewKeepAlive
+
+// runSimulateOnce implements `keepalive simulate-once`: it starts a
+// keep-alive just long enough to run one activity-simulation cycle and
+// reports how it went.
+func runSimulateOnce(args []string, stdout io.Writer) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "usage: keepalive simulate-once")
+		return 2
+	}
+
+	ka, err := newKeepAlive()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: simulate-once: %v\n", err)
+		return 1
+	}
+	sk, ok := ka.(platform.SimulatingKeepAlive)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "keepalive: simulate-once: activity simulation is unsupported on this platform")
+		return 1
+	}
+	if err := ka.Start(context.Background()); err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: simulate-once: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Running one activity-simulation cycle on %s/%s...\n", runtime.GOOS, runtime.GOARCH)
+	res := sk.SimulateOnce()
+	if err := ka.Stop(); err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: simulate-once: stopping: %v\n", err)
+	}
+	return writeSimulation(stdout, res)
+}
+
+// writeSimulation prints res and returns the exit code: 0 if the pointer
+// moved, 1 otherwise.
+func writeSimulation(w io.Writer, res platform.SimulationResult) int {
+	if res.IdleErr != nil {
+		fmt.Fprintf(w, "Idle before: unknown (%v)\n", res.IdleErr)
+	} else {
+		fmt.Fprintf(w, "Idle before: %s\n", util.FormatDuration(res.Idle.Round(time.Second)))
+	}
+	if len(res.Tried) > 0 {
+		fmt.Fprintln(w, "Input methods, in the order tried:")
+		writeNumbered(w, res.Tried)
+	}
+	if res.Err != nil {
+		fmt.Fprintf(w, "Result: failed: %v\n", res.Err)
+		return 1
+	}
+	fmt.Fprintf(w, "Result: moved the pointer with %s (%d points over %s)\n", res.Method, res.Points, res.Duration.Round(time.Millisecond))
+	return 0
+}
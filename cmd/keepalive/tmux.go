@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/platform"
+	"github.com/stigoleg/keep-alive/internal/statusfile"
+)
+
+// defaultTmuxFormat is what `keepalive tmux-status` prints without --format.
+const defaultTmuxFormat = "%icon %remaining"
+
+// tmuxIcon is the %icon token, the same cup the prompt helpers show.
+const tmuxIcon = "☕"
+
+// runTmuxStatus implements `keepalive tmux-status`: it prints one short line
+// describing the running session for tmux's status-right, read from the
+// status file so that it costs no more than starting the binary. Nothing, or
+// the --idle text, is printed when no session is running.
+func runTmuxStatus(args []string, stdout io.Writer) int {
+	flags := flag.NewFlagSet("tmux-status", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	format := flags.String("format", defaultTmuxFormat, "Status format; %icon, %remaining, %method and %% are replaced")
+	idle := flags.String("idle", "", "Text printed when no session is running")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "usage: keepalive tmux-status [--format FORMAT] [--idle TEXT]")
+		return 2
+	}
+	fmt.Fprintln(stdout, tmuxStatus(statusfile.Path(), *format, *idle, time.Now(), platform.ProcessAlive))
+	return 0
+}
+
+// tmuxStatus expands format for the session recorded at path, or returns
+// idle when there is none: no status file, a process that has exited or a
+// session that has run out by now.
+func tmuxStatus(path, format, idle string, now time.Time, alive func(pid int) (bool, error)) string {
+	s, err := statusfile.Read(path)
+	if err != nil {
+		return idle
+	}
+	if running, err := alive(s.PID); err != nil || !running {
+		return idle
+	}
+	remaining := "∞"
+	if !s.End.IsZero() {
+		left := int((s.End.Sub(now) + time.Minute - time.Second) / time.Minute)
+		if left <= 0 {
+			return idle
+		}
+		remaining = formatMinutesLeft(left)
+	}
+	return strings.NewReplacer(
+		"%%", "%",
+		"%icon", tmuxIcon,
+		"%remaining", remaining,
+		"%method", s.Method,
+	).Replace(format)
+}
+
+// formatMinutesLeft formats a number of minutes as the prompt helpers do:
+// "42m" below an hour and "2h05m" above.
+func formatMinutesLeft(minutes int) string {
+	if minutes >= 60 {
+		return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
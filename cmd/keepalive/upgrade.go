@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/buildinfo"
+	"github.com/stigoleg/keep-alive/internal/update"
+)
+
+// upgradeTimeout bounds the release lookup and download.
+const upgradeTimeout = 5 * time.Minute
+
+// updateCheckTimeout bounds the startup check made with --check-updates.
+const updateCheckTimeout = 10 * time.Second
+
+// latestReleaseURL is replaced in tests.
+var latestReleaseURL = update.LatestURL
+
+// runUpgrade implements `keepalive upgrade [--check]`.
+func runUpgrade(args []string, stdout io.Writer) int {
+	flags := flag.NewFlagSet("upgrade", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	check := flags.Bool("check", false, "Only report whether a newer release is available")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "keepalive: unexpected argument %q\n", flags.Arg(0))
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), upgradeTimeout)
+	defer cancel()
+
+	current := buildinfo.Get().Version
+	latest, err := update.Latest(ctx, latestReleaseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: checking for updates: %v\n", err)
+		return 1
+	}
+	if !update.Newer(current, latest.Version) {
+		if current == latest.Version {
+			fmt.Fprintf(stdout, "Keep-Alive %s is the latest release.\n", current)
+		} else {
+			fmt.Fprintf(stdout, "Keep-Alive %s is installed; the latest release is %s.\n", current, latest.Version)
+		}
+		return 0
+	}
+	fmt.Fprintf(stdout, "Keep-Alive %s is available (installed: %s).\n", latest.Version, current)
+	if *check {
+		if latest.URL != "" {
+			fmt.Fprintf(stdout, "Release notes: %s\n", latest.URL)
+		}
+		fmt.Fprintln(stdout, "Run `keepalive upgrade` to install it.")
+		return 0
+	}
+
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: locating the installed binary: %v\n", err)
+		return 1
+	}
+	binary, err := update.Download(ctx, latest)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: %v\n", err)
+		return 1
+	}
+	if err := update.Replace(exe, binary); err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: replacing %s: %v\n", exe, err)
+		fmt.Fprintln(os.Stderr, "If Keep-Alive was installed with a package manager, upgrade it there instead.")
+		return 1
+	}
+	fmt.Fprintf(stdout, "Upgraded %s to %s.\n", exe, latest.Version)
+	return 0
+}
+
+// checkForUpdate returns the newer release's version, or "" if the running
+// version is current or the check fails.
+func checkForUpdate(current string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), updateCheckTimeout)
+	defer cancel()
+	latest, err := update.Latest(ctx, latestReleaseURL)
+	if err != nil || !update.Newer(current, latest.Version) {
+		return ""
+	}
+	return latest.Version
+}
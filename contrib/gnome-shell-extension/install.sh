@@ -0,0 +1,16 @@
+#!/bin/sh
+# Installs the reference indicator for the current user, bundling the
+# generated D-Bus interface description from docs/dbus.
+set -eu
+
+here=$(cd "$(dirname "$0")" && pwd)
+root=$(cd "$here/../.." && pwd)
+uuid="keepalive-indicator@stigoleg.github.io"
+dest="${XDG_DATA_HOME:-$HOME/.local/share}/gnome-shell/extensions/$uuid"
+
+mkdir -p "$dest"
+cp "$here/$uuid/metadata.json" "$here/$uuid/extension.js" "$dest/"
+cp "$root/docs/dbus/org.keepalive.Manager.xml" "$dest/"
+
+echo "Installed to $dest"
+echo "Enable with: gnome-extensions enable $uuid (log out and back in on Wayland first)"
@@ -0,0 +1,156 @@
+// Reference panel indicator for keepalive.
+//
+// Talks to org.keepalive.Manager on the session bus. The interface
+// description is loaded from org.keepalive.Manager.xml, which is generated by
+// `go run ./cmd/gen-docs` and copied next to this file by install.sh.
+
+import GLib from 'gi://GLib';
+import Gio from 'gi://Gio';
+import GObject from 'gi://GObject';
+import St from 'gi://St';
+import Clutter from 'gi://Clutter';
+
+import {Extension} from 'resource:///org/gnome/shell/extensions/extension.js';
+import * as Main from 'resource:///org/gnome/shell/ui/main.js';
+import * as PanelMenu from 'resource:///org/gnome/shell/ui/panelMenu.js';
+import * as PopupMenu from 'resource:///org/gnome/shell/ui/popupMenu.js';
+
+const BUS_NAME = 'org.keepalive.Manager';
+const OBJECT_PATH = '/org/keepalive/Manager';
+const INTERFACE = 'org.keepalive.Manager';
+
+// Oldest interface revision this indicator understands.
+const MIN_API_VERSION = 1;
+const EXTEND_SECONDS = 30 * 60;
+
+function formatRemaining(seconds) {
+    const h = Math.floor(seconds / 3600);
+    const m = Math.floor((seconds % 3600) / 60);
+    const s = seconds % 60;
+    const mm = String(m).padStart(2, '0');
+    const ss = String(s).padStart(2, '0');
+    return h > 0 ? `${h}:${mm}:${ss}` : `${m}:${ss}`;
+}
+
+const KeepAliveIndicator = GObject.registerClass(
+class KeepAliveIndicator extends PanelMenu.Button {
+    _init(interfaceInfo) {
+        super._init(0.0, 'Keep-Alive');
+
+        this._interfaceInfo = interfaceInfo;
+        this._proxy = null;
+        this._tickId = 0;
+
+        this._label = new St.Label({text: '', y_align: Clutter.ActorAlign.CENTER});
+        this.add_child(this._label);
+
+        this._extendItem = new PopupMenu.PopupMenuItem('Extend 30 minutes');
+        this._extendItem.connect('activate', () => this._call('Extend', new GLib.Variant('(x)', [EXTEND_SECONDS])));
+        this.menu.addMenuItem(this._extendItem);
+
+        const stopItem = new PopupMenu.PopupMenuItem('Stop');
+        stopItem.connect('activate', () => this._call('Stop', null));
+        this.menu.addMenuItem(stopItem);
+
+        this.visible = false;
+        this._watchId = Gio.bus_watch_name(
+            Gio.BusType.SESSION, BUS_NAME, Gio.BusNameWatcherFlags.NONE,
+            () => this._onAppeared(), () => this._onVanished());
+    }
+
+    _onAppeared() {
+        Gio.DBusProxy.new_for_bus(
+            Gio.BusType.SESSION, Gio.DBusProxyFlags.NONE, this._interfaceInfo,
+            BUS_NAME, OBJECT_PATH, INTERFACE, null,
+            (_source, result) => {
+                try {
+                    this._proxy = Gio.DBusProxy.new_for_bus_finish(result);
+                } catch (e) {
+                    logError(e, 'keepalive: failed to create proxy');
+                    return;
+                }
+
+                const api = this._property('APIVersion', 0);
+                if (api < MIN_API_VERSION) {
+                    log(`keepalive: unsupported APIVersion ${api}; need ${MIN_API_VERSION}`);
+                    this._proxy = null;
+                    return;
+                }
+
+                this._proxy.connect('g-properties-changed', () => this._refresh());
+                this._tickId = GLib.timeout_add_seconds(GLib.PRIORITY_DEFAULT, 1, () => {
+                    this._refresh();
+                    return GLib.SOURCE_CONTINUE;
+                });
+                this._refresh();
+            });
+    }
+
+    _onVanished() {
+        this._stopTicking();
+        this._proxy = null;
+        this.visible = false;
+    }
+
+    _property(name, fallback) {
+        const value = this._proxy?.get_cached_property(name);
+        return value ? value.deepUnpack() : fallback;
+    }
+
+    _refresh() {
+        if (!this._proxy || !this._property('Running', false)) {
+            this.visible = false;
+            return;
+        }
+
+        const endTime = this._property('EndTime', 0);
+        this._extendItem.visible = endTime > 0;
+        if (endTime > 0) {
+            const now = Math.floor(GLib.get_real_time() / GLib.USEC_PER_SEC);
+            this._label.text = `☕ ${formatRemaining(Math.max(0, endTime - now))}`;
+        } else {
+            this._label.text = '☕ ∞';
+        }
+        this.visible = true;
+    }
+
+    _call(method, params) {
+        this._proxy?.call(method, params, Gio.DBusCallFlags.NONE, -1, null, (proxy, result) => {
+            try {
+                proxy.call_finish(result);
+            } catch (e) {
+                Main.notifyError('Keep-Alive', e.message);
+            }
+        });
+    }
+
+    _stopTicking() {
+        if (this._tickId) {
+            GLib.source_remove(this._tickId);
+            this._tickId = 0;
+        }
+    }
+
+    destroy() {
+        this._stopTicking();
+        Gio.bus_unwatch_name(this._watchId);
+        super.destroy();
+    }
+});
+
+export default class KeepAliveIndicatorExtension extends Extension {
+    enable() {
+        const file = this.dir.get_child(`${INTERFACE}.xml`);
+        const [, contents] = file.load_contents(null);
+        const xml = new TextDecoder().decode(contents);
+        const interfaceInfo = Gio.DBusNodeInfo.new_for_xml(xml).lookup_interface(INTERFACE);
+
+        this._indicator = new KeepAliveIndicator(interfaceInfo);
+        Main.panel.addToStatusArea(this.uuid, this._indicator);
+    }
+
+    disable() {
+        this._indicator?.destroy();
+        this._indicator = null;
+    }
+}
@@ -0,0 +1,113 @@
+// Package buildinfo reports the version and build metadata of the running
+// binary. Release builds inject the values with -ldflags, for example:
+//
+//	go build -ldflags "-X github.com/stigoleg/keep-alive/internal/buildinfo.version=1.6.0 \
+//	  -X github.com/stigoleg/keep-alive/internal/buildinfo.commit=$(git rev-parse HEAD) \
+//	  -X github.com/stigoleg/keep-alive/internal/buildinfo.date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Builds without ldflags fall back to the module and VCS information recorded
+// by the Go toolchain.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// Set via -ldflags -X.
+var (
+	version = ""
+	commit  = ""
+	date    = ""
+)
+
+// devVersion is reported when no version is known.
+const devVersion = "dev"
+
+// Info describes the running binary.
+type Info struct {
+	Version   string   `json:"version"`
+	Commit    string   `json:"commit,omitempty"`
+	BuildDate string   `json:"build_date,omitempty"`
+	GoVersion string   `json:"go_version"`
+	Platform  string   `json:"platform"`
+	Tags      []string `json:"tags"`
+	CGO       bool     `json:"cgo"`
+	Modified  bool     `json:"modified,omitempty"`
+}
+
+// Get returns the build metadata of the running binary.
+func Get() Info {
+	info := Info{
+		Version:   strings.TrimPrefix(version, "v"),
+		Commit:    commit,
+		BuildDate: date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Tags:      []string{},
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		applyBuildInfo(&info, bi)
+	}
+	if info.Version == "" {
+		info.Version = devVersion
+	}
+	return info
+}
+
+// applyBuildInfo fills fields that were not injected with ldflags from the
+// toolchain-recorded build information.
+func applyBuildInfo(info *Info, bi *debug.BuildInfo) {
+	if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		info.Version = strings.TrimPrefix(bi.Main.Version, "v")
+	}
+
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = s.Value
+			}
+		case "vcs.time":
+			if info.BuildDate == "" {
+				info.BuildDate = s.Value
+			}
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		case "-tags":
+			for _, tag := range strings.Split(s.Value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					info.Tags = append(info.Tags, tag)
+				}
+			}
+		case "CGO_ENABLED":
+			info.CGO = s.Value == "1"
+		}
+	}
+}
+
+// ShortCommit returns the first 12 characters of the commit hash.
+func (i Info) ShortCommit() string {
+	if len(i.Commit) > 12 {
+		return i.Commit[:12]
+	}
+	return i.Commit
+}
+
+// String returns a one-line summary such as
+// "1.6.0 (a1b2c3d4e5f6, 2026-01-02T15:04:05Z)".
+func (i Info) String() string {
+	var details []string
+	if c := i.ShortCommit(); c != "" {
+		details = append(details, c)
+	}
+	if i.BuildDate != "" {
+		details = append(details, i.BuildDate)
+	}
+	if len(details) == 0 {
+		return i.Version
+	}
+	return i.Version + " (" + strings.Join(details, ", ") + ")"
+}
@@ -0,0 +1,56 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestApplyBuildInfo(t *testing.T) {
+	info := Info{Tags: []string{}}
+	applyBuildInfo(&info, &debug.BuildInfo{
+		Main: debug.Module{Version: "v1.6.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123456789abcdef"},
+			{Key: "vcs.time", Value: "2026-01-02T15:04:05Z"},
+			{Key: "vcs.modified", Value: "true"},
+			{Key: "-tags", Value: "nogui, netgo"},
+			{Key: "CGO_ENABLED", Value: "1"},
+		},
+	})
+
+	if info.Version != "1.6.0" {
+		t.Errorf("Version = %q, want 1.6.0", info.Version)
+	}
+	if info.Commit != "0123456789abcdef" || info.ShortCommit() != "0123456789ab" {
+		t.Errorf("Commit = %q, ShortCommit = %q", info.Commit, info.ShortCommit())
+	}
+	if !info.Modified || !info.CGO {
+		t.Errorf("Modified = %v, CGO = %v, want both true", info.Modified, info.CGO)
+	}
+	if len(info.Tags) != 2 || info.Tags[0] != "nogui" || info.Tags[1] != "netgo" {
+		t.Errorf("Tags = %v, want [nogui netgo]", info.Tags)
+	}
+	if got, want := info.String(), "1.6.0 (0123456789ab, 2026-01-02T15:04:05Z)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestLDFlagsTakePrecedence(t *testing.T) {
+	info := Info{Version: "2.0.0", Commit: "abc", BuildDate: "today"}
+	applyBuildInfo(&info, &debug.BuildInfo{
+		Main: debug.Module{Version: "v1.6.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123456789abcdef"},
+			{Key: "vcs.time", Value: "2026-01-02T15:04:05Z"},
+		},
+	})
+	if info.Version != "2.0.0" || info.Commit != "abc" || info.BuildDate != "today" {
+		t.Fatalf("injected values were overwritten: %+v", info)
+	}
+}
+
+func TestGetDefaultsToDev(t *testing.T) {
+	if got := Get(); got.Version == "" || got.GoVersion == "" || got.Platform == "" {
+		t.Fatalf("Get() = %+v, want version, go version and platform set", got)
+	}
+}
@@ -4,22 +4,64 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/stigoleg/keep-alive/internal/history"
+	"github.com/stigoleg/keep-alive/internal/keepalive"
+	"github.com/stigoleg/keep-alive/internal/logging"
+	"github.com/stigoleg/keep-alive/internal/platform"
+	"github.com/stigoleg/keep-alive/internal/schedule"
+	"github.com/stigoleg/keep-alive/internal/task"
 	"github.com/stigoleg/keep-alive/internal/ui"
 	"github.com/stigoleg/keep-alive/internal/util"
 )
 
 type Config struct {
+	// Duration is the session length in minutes. Clock is the end time
+	// given with -c; it is kept as a time, not converted to a duration, so
+	// that the session follows the wall clock.
 	Duration         int
 	Clock            time.Time
 	BatteryThreshold int
 	SimulateActivity bool
-	EnableLogging    bool
-	ShowVersion      bool
+	IgnoreConflicts  bool
+	PresenceOnly     bool
+	ACOnly           bool
+	Schedule         *schedule.Schedule
+	QuietHours       *schedule.Schedule
+	DimLevel         int
+	Timings          platform.Timings
+	MouseShape       platform.MouseShape
+	// SimulationKey is the key tapped instead of moving the mouse, or "".
+	SimulationKey  string
+	Watch          keepalive.ProcessWatch
+	UntilIdle      time.Duration
+	StopOnLock     bool
+	WhileCmd       string
+	OnExpire       string
+	Notify         bool
+	AwayMode       bool
+	AllowHibernate bool
+	// Assertions are the power assertions to hold on macOS, or nil for the
+	// defaults.
+	Assertions []platform.Assertion
+	Tags       history.Tags
+	// Task is the work item given with --from-task, or nil.
+	Task           *task.Task
+	MaxSimulations int
+	EnableLogging  bool
+	LogLevel       slog.Level
+	LogFile        string
+	ShowVersion    bool
+	DryRun         bool
+	CheckUpdates   bool
+	// Menubar shows the session in the macOS menu bar, or the Windows
+	// notification area, instead of the TUI.
+	Menubar bool
 }
 
 func formatError(err error) string {
@@ -45,6 +87,167 @@ func formatError(err error) string {
 	return ui.ErrorBanner(msg)
 }
 
+// flagValues holds the values of the command-line flags once parsed.
+type flagValues struct {
+	duration         *string
+	clock            *string
+	battery          *int
+	showVersion      *bool
+	dryRun           *bool
+	checkUpdates     *bool
+	menubar          *bool
+	tray             *bool
+	showHelp         *bool
+	simulateActivity *bool
+	ignoreConflicts  *bool
+	presenceOnly     *bool
+	acOnly           *bool
+	scheduleSpec     *string
+	quietHours       *string
+	dimLevel         *int
+	idleThreshold    *string
+	simInterval      *string
+	activityInterval *string
+	maxSimulations   *int
+	pattern          *string
+	patternSize      *int
+	simKey           *string
+	watchPID         *int
+	watchName        *string
+	untilIdle        *string
+	stopOnLock       *bool
+	whileCmd         *string
+	onExpire         *string
+	notify           *bool
+	awayMode         *bool
+	allowHibernate   *bool
+	assertions       *string
+	displayOnly      *bool
+	tags             history.Tags
+	fromTask         *string
+	taskCommand      *string
+	enableLogging    *bool
+	logLevel         *string
+	verbose          *bool
+	logFile          *string
+}
+
+// defineFlags defines the command-line flags on flags.
+func defineFlags(flags *flag.FlagSet) *flagValues {
+	v := &flagValues{}
+
+	v.duration = flags.String("duration", "", "Duration to keep system alive (e.g., \"2h30m\")")
+	flags.StringVar(v.duration, "d", "", "Duration to keep system alive (e.g., \"2h30m\")")
+
+	v.clock = flags.String("clock", "", "Time to keep system alive until (e.g., \"22:00\", \"tomorrow 07:30\", \"17:00 CET\" or \"maintenance\")")
+	flags.StringVar(v.clock, "c", "", "Time to keep system alive until (e.g., \"22:00\", \"tomorrow 07:30\", \"17:00 CET\" or \"maintenance\")")
+
+	v.battery = flags.Int("battery", 0, "Battery percentage threshold to keep system alive until")
+	flags.IntVar(v.battery, "b", 0, "Battery percentage threshold to keep system alive until")
+	flags.IntVar(v.battery, "battery-min", 0, "Battery percentage threshold to keep system alive until")
+
+	v.showVersion = flags.Bool("version", false, "Show version information")
+	flags.BoolVar(v.showVersion, "v", false, "Show version information")
+
+	v.dryRun = flags.Bool("dry-run", false, "Show which sleep-prevention and simulation methods would be used, without activating anything")
+
+	v.checkUpdates = flags.Bool("check-updates", false, "Check GitHub for a newer release at startup and show it in the TUI")
+	v.menubar = flags.Bool("menubar", false, "Show the session in the macOS menu bar instead of the terminal (builds with the menubar tag)")
+	v.tray = flags.Bool("tray", false, "Show the session in the Windows notification area instead of the terminal")
+
+	v.showHelp = flags.Bool("help", false, "Show help message")
+	flags.BoolVar(v.showHelp, "h", false, "Show help message")
+
+	v.simulateActivity = flags.Bool("active", false, "Simulate activity to keep chat apps active")
+	flags.BoolVar(v.simulateActivity, "a", false, "Simulate activity to keep chat apps active")
+	v.ignoreConflicts = flags.Bool("ignore-conflicts", false, "Simulate activity even while another mouse jiggler is running")
+	v.presenceOnly = flags.Bool("presence-only", false, "Simulate activity without preventing sleep (implies --active)")
+
+	v.acOnly = flags.Bool("ac-only", false, "Suspend keep-alive while running on battery power")
+	v.scheduleSpec = flags.String("schedule", "", "Keep the system awake only within these weekly hours (e.g., \"Mon-Fri 09:00-17:30\")")
+	v.quietHours = flags.String("quiet-hours", "", "Pause activity simulation within these weekly hours while keeping the system awake (e.g., \"Mon-Fri 14:00-15:00\")")
+
+	v.dimLevel = flags.Int("dim", 0, "Dim the display to this brightness percentage while active")
+
+	v.idleThreshold = flags.String("idle-threshold", "", "Idle time before simulating activity (e.g., \"30s\")")
+	v.simInterval = flags.String("sim-interval", "", "Minimum time between simulated activity (e.g., \"45s\")")
+	v.activityInterval = flags.String("activity-interval", "", "Interval between system activity assertions (e.g., \"10s\")")
+
+	v.maxSimulations = flags.Int("max-idle-simulations", 0, "Stop simulating activity after this many mouse moves in a session")
+
+	v.pattern = flags.String("pattern", "", "Mouse jitter shape: circle, square, zigzag, walk or random")
+	v.patternSize = flags.Int("pattern-size", 0, "Maximum mouse jitter distance in pixels")
+	v.simKey = flags.String("sim-key", "", "Tap this key instead of moving the mouse to simulate activity (Windows, F13-F24, e.g., \"F15\")")
+
+	v.watchPID = flags.Int("watch-pid", 0, "Keep the system awake until the process with this PID exits")
+	v.watchName = flags.String("watch-name", "", "Keep the system awake while a process with this name runs")
+
+	v.untilIdle = flags.String("until-idle-for", "", "Stop once the user has been idle this long (e.g., \"10m\")")
+	v.stopOnLock = flags.Bool("stop-on-lock", false, "Stop once the screen is locked")
+	v.whileCmd = flags.String("while-cmd", "", "Keep the system awake while this shell command succeeds (e.g., \"pgrep ffmpeg\")")
+
+	v.onExpire = flags.String("on-expire", "", "Shell command to run when a timed session ends")
+	v.notify = flags.Bool("notify", false, "Show a desktop notification when a timed session ends")
+	v.awayMode = flags.Bool("away-mode", false, "Let the display and audio turn off while the system stays awake (Windows)")
+	v.allowHibernate = flags.Bool("allow-hibernate", false, "Hold off sleep but let the system hibernate, e.g. on a critical battery (Linux)")
+	v.assertions = flags.String("assertions", "", "Power assertions to hold: display, idle, disk, system, user-active (macOS, e.g., \"idle,disk\")")
+	v.displayOnly = flags.Bool("display-only", false, "Keep only the display awake (macOS, same as --assertions display)")
+
+	v.tags = history.Tags{}
+	flags.Var(v.tags, "tag", "Label the session in the history with key=value (repeatable, e.g., \"project=foo\")")
+	v.fromTask = flags.String("from-task", "", "Start a session for this taskwarrior task, taking its estimate as the duration")
+	v.taskCommand = flags.String("task-command", task.DefaultCommand, "Command that prints the --from-task task; {id} is replaced by its ID")
+
+	v.enableLogging = flags.Bool("log", false, "Enable logging to the log file")
+	flags.BoolVar(v.enableLogging, "l", false, "Enable logging to the log file")
+	v.logLevel = flags.String("log-level", "", "Minimum level written to the log: debug, info, warn or error (implies --log)")
+	v.verbose = flags.Bool("verbose", false, "Write debug messages to the log (same as --log-level debug)")
+	v.logFile = flags.String("log-file", "", "Write the log to this file instead of the default location (implies --log)")
+
+	return v
+}
+
+// FlagSet returns the command-line flags that ParseFlags accepts, for
+// generating completions and documentation from their definitions.
+func FlagSet() *flag.FlagSet {
+	flags := flag.NewFlagSet("keepalive", flag.ContinueOnError)
+	defineFlags(flags)
+	return flags
+}
+
+// FlagChoices lists the accepted values of the flags that take one of a fixed
+// set, by flag name.
+func FlagChoices() map[string][]string {
+	patterns := make([]string, len(platform.MousePatterns))
+	for i, p := range platform.MousePatterns {
+		patterns[i] = string(p)
+	}
+	return map[string][]string{
+		"pattern":   patterns,
+		"sim-key":   platform.SimulationKeys(),
+		"log-level": logging.Levels,
+	}
+}
+
+// nextMaintenanceWindow is replaced in tests.
+var nextMaintenanceWindow = platform.NextMaintenanceWindow
+
+// lookupTask is replaced in tests.
+var lookupTask = task.Lookup
+
+// parseClock resolves a --clock value: a clock time, or "maintenance" for
+// the next scheduled maintenance window.
+func parseClock(value string, now time.Time) (time.Time, error) {
+	if !strings.EqualFold(strings.TrimSpace(value), platform.MaintenanceTarget) {
+		return util.ParseTimeStringWithNow(value, now)
+	}
+	w, err := nextMaintenanceWindow(now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return w.Start, nil
+}
+
 // ParseFlags parses command line flags and returns the configuration
 func ParseFlags(version string) (*Config, error) {
 	return ParseFlagsWithNow(version, time.Now())
@@ -64,26 +267,7 @@ func ParseFlagsWithNow(version string, now time.Time) (*Config, error) {
 		fmt.Println(model.View())
 	}
 
-	duration := flags.String("duration", "", "Duration to keep system alive (e.g., \"2h30m\")")
-	flags.StringVar(duration, "d", "", "Duration to keep system alive (e.g., \"2h30m\")")
-
-	clock := flags.String("clock", "", "Time to keep system alive until (e.g., \"22:00\" or \"10:00PM\")")
-	flags.StringVar(clock, "c", "", "Time to keep system alive until (e.g., \"22:00\" or \"10:00PM\")")
-
-	battery := flags.Int("battery", 0, "Battery percentage threshold to keep system alive until")
-	flags.IntVar(battery, "b", 0, "Battery percentage threshold to keep system alive until")
-
-	showVersion := flags.Bool("version", false, "Show version information")
-	flags.BoolVar(showVersion, "v", false, "Show version information")
-
-	showHelp := flags.Bool("help", false, "Show help message")
-	flags.BoolVar(showHelp, "h", false, "Show help message")
-
-	simulateActivity := flags.Bool("active", false, "Simulate activity to keep chat apps active")
-	flags.BoolVar(simulateActivity, "a", false, "Simulate activity to keep chat apps active")
-
-	enableLogging := flags.Bool("log", false, "Enable logging to debug.log file")
-	flags.BoolVar(enableLogging, "l", false, "Enable logging to debug.log file")
+	v := defineFlags(flags)
 
 	if err := flags.Parse(os.Args[1:]); err != nil {
 		if err == flag.ErrHelp {
@@ -92,57 +276,251 @@ func ParseFlagsWithNow(version string, now time.Time) (*Config, error) {
 		return nil, fmt.Errorf("%s", formatError(err))
 	}
 
-	if *showVersion {
+	if *v.showVersion {
 		return &Config{ShowVersion: true}, nil
 	}
-	if *showHelp {
+	if *v.showHelp {
 		printUsage()
 		return nil, flag.ErrHelp
 	}
 
 	batterySet := false
 	flags.Visit(func(f *flag.Flag) {
-		if f.Name == "battery" || f.Name == "b" {
+		if f.Name == "battery" || f.Name == "b" || f.Name == "battery-min" {
 			batterySet = true
 		}
 	})
-	if batterySet && (*battery < 1 || *battery > 100) {
+	if batterySet && (*v.battery < 1 || *v.battery > 100) {
 		return nil, fmt.Errorf("%s", formatError(fmt.Errorf("battery threshold must be between 1 and 100")))
 	}
 
+	dimSet := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "dim" {
+			dimSet = true
+		}
+	})
+	if dimSet && (*v.dimLevel < 1 || *v.dimLevel > 99) {
+		return nil, fmt.Errorf("%s", formatError(fmt.Errorf("dim level must be between 1 and 99")))
+	}
+
+	var timings platform.Timings
+	for _, f := range []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"idle-threshold", *v.idleThreshold, &timings.IdleThreshold},
+		{"sim-interval", *v.simInterval, &timings.ChatAppActivityInterval},
+		{"activity-interval", *v.activityInterval, &timings.ActivityInterval},
+	} {
+		if f.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(f.value)
+		if err != nil {
+			return nil, fmt.Errorf("%s", formatError(fmt.Errorf("invalid --%s %q: use a duration such as 30s or 2m", f.name, f.value)))
+		}
+		*f.dst = d
+	}
+	if err := timings.Validate(); err != nil {
+		return nil, fmt.Errorf("%s", formatError(err))
+	}
+
+	mousePattern, err := platform.ParseMousePattern(*v.pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%s", formatError(err))
+	}
+	patternSizeSet := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "pattern-size" {
+			patternSizeSet = true
+		}
+	})
+	if patternSizeSet && (*v.patternSize < platform.MinMousePatternSize || *v.patternSize > platform.MaxMousePatternSize) {
+		return nil, fmt.Errorf("%s", formatError(fmt.Errorf("pattern size must be between %d and %d pixels", platform.MinMousePatternSize, platform.MaxMousePatternSize)))
+	}
+
+	var simKey string
+	if *v.simKey != "" {
+		simKey, err = platform.ParseSimulationKey(*v.simKey)
+		if err != nil {
+			return nil, fmt.Errorf("%s", formatError(fmt.Errorf("invalid --sim-key: %w", err)))
+		}
+	}
+
+	maxSimulationsSet := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "max-idle-simulations" {
+			maxSimulationsSet = true
+		}
+	})
+	if maxSimulationsSet && *v.maxSimulations < 1 {
+		return nil, fmt.Errorf("%s", formatError(fmt.Errorf("max idle simulations must be a positive number")))
+	}
+
+	watchPIDSet := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "watch-pid" {
+			watchPIDSet = true
+		}
+	})
+	if watchPIDSet && *v.watchPID < 1 {
+		return nil, fmt.Errorf("%s", formatError(fmt.Errorf("watch pid must be a positive process ID")))
+	}
+	if watchPIDSet && *v.watchName != "" {
+		return nil, fmt.Errorf("%s", formatError(fmt.Errorf("cannot specify both --watch-pid and --watch-name")))
+	}
+
+	var sched *schedule.Schedule
+	if *v.scheduleSpec != "" {
+		s, err := schedule.Parse(*v.scheduleSpec)
+		if err != nil {
+			return nil, fmt.Errorf("%s", formatError(fmt.Errorf("invalid --schedule: %w", err)))
+		}
+		sched = s
+	}
+
+	var quiet *schedule.Schedule
+	if *v.quietHours != "" {
+		s, err := schedule.Parse(*v.quietHours)
+		if err != nil {
+			return nil, fmt.Errorf("%s", formatError(fmt.Errorf("invalid --quiet-hours: %w", err)))
+		}
+		quiet = s
+	}
+
+	var untilIdleFor time.Duration
+	if *v.untilIdle != "" {
+		d, err := time.ParseDuration(*v.untilIdle)
+		if err != nil || d < keepalive.MinUntilIdle || d > keepalive.MaxUntilIdle {
+			return nil, fmt.Errorf("%s", formatError(fmt.Errorf("invalid --until-idle-for %q: use a duration between %s and %s, such as 10m", *v.untilIdle, keepalive.MinUntilIdle, keepalive.MaxUntilIdle)))
+		}
+		if *v.simulateActivity || *v.presenceOnly {
+			return nil, fmt.Errorf("%s", formatError(fmt.Errorf("cannot combine --until-idle-for with --active: simulated activity resets the idle time")))
+		}
+		untilIdleFor = d
+	}
+
+	whileCmd := strings.TrimSpace(*v.whileCmd)
+	if whileCmd != "" && (*v.duration != "" || *v.clock != "") {
+		return nil, fmt.Errorf("%s", formatError(fmt.Errorf("cannot combine --while-cmd with --duration or --clock")))
+	}
+
+	if *v.presenceOnly && (*v.awayMode || *v.allowHibernate) {
+		return nil, fmt.Errorf("%s", formatError(fmt.Errorf("cannot combine --presence-only with --away-mode or --allow-hibernate: no sleep is held off")))
+	}
+
+	if (*v.menubar || *v.tray) && (*v.battery > 0 || *v.watchPID != 0 || *v.watchName != "" || *v.untilIdle != "" || *v.whileCmd != "") {
+		name := "--menubar"
+		if *v.tray {
+			name = "--tray"
+		}
+		return nil, fmt.Errorf("%s", formatError(fmt.Errorf("%s supports only --duration and --clock sessions, not --battery, --watch-pid, --watch-name, --until-idle-for or --while-cmd", name)))
+	}
+
+	var assertions []platform.Assertion
+	if *v.assertions != "" {
+		if *v.displayOnly {
+			return nil, fmt.Errorf("%s", formatError(fmt.Errorf("cannot specify both --assertions and --display-only")))
+		}
+		a, err := platform.ParseAssertions(*v.assertions)
+		if err != nil {
+			return nil, fmt.Errorf("%s", formatError(fmt.Errorf("invalid --assertions: %w", err)))
+		}
+		assertions = a
+	} else if *v.displayOnly {
+		assertions = []platform.Assertion{platform.AssertionDisplay}
+	}
+	if *v.presenceOnly && assertions != nil {
+		return nil, fmt.Errorf("%s", formatError(fmt.Errorf("cannot combine --presence-only with --assertions or --display-only: no assertion is held")))
+	}
+
+	level := slog.LevelInfo
+	if *v.logLevel != "" {
+		if *v.verbose {
+			return nil, fmt.Errorf("%s", formatError(fmt.Errorf("cannot specify both --verbose and --log-level")))
+		}
+		l, err := logging.ParseLevel(*v.logLevel)
+		if err != nil {
+			return nil, fmt.Errorf("%s", formatError(err))
+		}
+		level = l
+	} else if *v.verbose {
+		level = slog.LevelDebug
+	}
+
 	var minutes int
 	var clockTime time.Time
 
-	if *duration != "" && *clock != "" {
+	if *v.duration != "" && *v.clock != "" {
 		return nil, fmt.Errorf("%s", formatError(fmt.Errorf("cannot specify both duration (-d) and clock time (-c)")))
 	}
 
-	if *duration != "" {
-		d, err := util.ParseDuration(*duration)
+	if *v.duration != "" {
+		d, err := util.ParseDuration(*v.duration)
 		if err != nil {
 			return nil, fmt.Errorf("%s", formatError(err))
 		}
 		minutes = int(d.Minutes())
-	} else if *clock != "" {
-		t, err := util.ParseTimeStringWithNow(*clock, now)
+	} else if *v.clock != "" {
+		t, err := parseClock(*v.clock, now)
 		if err != nil {
 			return nil, fmt.Errorf("%s", formatError(err))
 		}
+		clockTime = t
+	}
 
-		if t.Before(now) {
-			// If the specified time is before now, assume it's for tomorrow
-			t = t.Add(24 * time.Hour)
+	var work *task.Task
+	if id := strings.TrimSpace(*v.fromTask); id != "" {
+		t, err := lookupTask(id, *v.taskCommand)
+		if err != nil {
+			return nil, fmt.Errorf("%s", formatError(fmt.Errorf("--from-task: %w", err)))
+		}
+		work = &t
+		// An explicit length wins over the estimate.
+		if t.Estimate > 0 && minutes == 0 && clockTime.IsZero() && whileCmd == "" {
+			minutes = int((t.Estimate + time.Minute - 1) / time.Minute)
+		}
+		if _, ok := v.tags["task"]; !ok {
+			v.tags["task"] = t.ID
+		}
+		if _, ok := v.tags["reason"]; !ok {
+			v.tags["reason"] = t.Description
 		}
-
-		minutes = int(t.Sub(now).Minutes())
-		clockTime = t
 	}
 
 	return &Config{
 		Duration:         minutes,
 		Clock:            clockTime,
-		BatteryThreshold: *battery,
-		SimulateActivity: *simulateActivity,
-		EnableLogging:    *enableLogging,
+		BatteryThreshold: *v.battery,
+		SimulateActivity: *v.simulateActivity || *v.presenceOnly,
+		IgnoreConflicts:  *v.ignoreConflicts,
+		PresenceOnly:     *v.presenceOnly,
+		ACOnly:           *v.acOnly,
+		Schedule:         sched,
+		QuietHours:       quiet,
+		DimLevel:         *v.dimLevel,
+		Timings:          timings,
+		MouseShape:       platform.MouseShape{Pattern: mousePattern, Size: *v.patternSize},
+		SimulationKey:    simKey,
+		Watch:            keepalive.ProcessWatch{PID: *v.watchPID, Name: strings.TrimSpace(*v.watchName)},
+		UntilIdle:        untilIdleFor,
+		StopOnLock:       *v.stopOnLock,
+		WhileCmd:         whileCmd,
+		OnExpire:         strings.TrimSpace(*v.onExpire),
+		Notify:           *v.notify,
+		AwayMode:         *v.awayMode,
+		AllowHibernate:   *v.allowHibernate,
+		Assertions:       assertions,
+		Tags:             v.tags,
+		Task:             work,
+		MaxSimulations:   *v.maxSimulations,
+		EnableLogging:    *v.enableLogging || *v.logLevel != "" || *v.verbose || *v.logFile != "",
+		LogLevel:         level,
+		LogFile:          strings.TrimSpace(*v.logFile),
+		DryRun:           *v.dryRun,
+		CheckUpdates:     *v.checkUpdates,
+		Menubar:          *v.menubar || *v.tray,
 	}, nil
 }
@@ -2,9 +2,16 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/stigoleg/keep-alive/internal/keepalive"
+	"github.com/stigoleg/keep-alive/internal/platform"
+	"github.com/stigoleg/keep-alive/internal/task"
 )
 
 func TestParseFlags(t *testing.T) {
@@ -20,6 +27,8 @@ func TestParseFlags(t *testing.T) {
 		args        []string
 		wantMinutes int
 		wantBattery int
+		wantACOnly  bool
+		wantDim     int
 		wantErr     bool
 		wantVersion bool
 	}{
@@ -70,6 +79,16 @@ func TestParseFlags(t *testing.T) {
 			args:        []string{"keepalive", "--battery", "30"},
 			wantBattery: 30,
 		},
+		{
+			name:        "valid battery-min alias",
+			args:        []string{"keepalive", "--battery-min", "20"},
+			wantBattery: 20,
+		},
+		{
+			name:    "battery-min rejects out of range",
+			args:    []string{"keepalive", "--battery-min", "0"},
+			wantErr: true,
+		},
 		{
 			name:        "battery combines with duration",
 			args:        []string{"keepalive", "-d", "20", "-b", "65"},
@@ -79,7 +98,6 @@ func TestParseFlags(t *testing.T) {
 		{
 			name:        "battery combines with clock",
 			args:        []string{"keepalive", "-c", "12:00", "-b", "65"},
-			wantMinutes: 120,
 			wantBattery: 65,
 		},
 		{
@@ -107,6 +125,32 @@ func TestParseFlags(t *testing.T) {
 			args:    []string{"keepalive", "-b", "twenty"},
 			wantErr: true,
 		},
+		{
+			name:       "ac-only flag",
+			args:       []string{"keepalive", "--ac-only"},
+			wantACOnly: true,
+		},
+		{
+			name:        "ac-only combines with duration",
+			args:        []string{"keepalive", "--ac-only", "-d", "2h"},
+			wantMinutes: 120,
+			wantACOnly:  true,
+		},
+		{
+			name:    "dim flag",
+			args:    []string{"keepalive", "--dim", "15"},
+			wantDim: 15,
+		},
+		{
+			name:    "dim level out of range",
+			args:    []string{"keepalive", "--dim", "100"},
+			wantErr: true,
+		},
+		{
+			name:    "dim level zero",
+			args:    []string{"keepalive", "--dim", "0"},
+			wantErr: true,
+		},
 		{
 			name:        "no flags",
 			args:        []string{"keepalive"},
@@ -140,6 +184,14 @@ func TestParseFlags(t *testing.T) {
 				t.Errorf("ParseFlags() BatteryThreshold = %d, want %d", cfg.BatteryThreshold, tt.wantBattery)
 			}
 
+			if cfg.ACOnly != tt.wantACOnly {
+				t.Errorf("ParseFlags() ACOnly = %v, want %v", cfg.ACOnly, tt.wantACOnly)
+			}
+
+			if cfg.DimLevel != tt.wantDim {
+				t.Errorf("ParseFlags() DimLevel = %d, want %d", cfg.DimLevel, tt.wantDim)
+			}
+
 			if tt.wantMinutes != 0 && cfg.Duration != tt.wantMinutes {
 				t.Errorf("ParseFlags() got duration %d, want %d", cfg.Duration, tt.wantMinutes)
 			}
@@ -179,8 +231,514 @@ func TestParseFlagsTimeCalculation(t *testing.T) {
 		t.Errorf("ParseFlags() clock minute %d, want 0", cfg.Clock.Minute())
 	}
 
-	// Verify the duration is exactly 2 hours
-	if cfg.Duration != 120 {
-		t.Errorf("ParseFlags() duration %d minutes, want exactly 120 minutes", cfg.Duration)
+	// Verify the end is exactly 2 hours away and kept as a time
+	if got := cfg.Clock.Sub(now); got != 2*time.Hour {
+		t.Errorf("ParseFlags() clock %v after now, want exactly 2h", got)
+	}
+	if cfg.Duration != 0 {
+		t.Errorf("ParseFlags() duration %d minutes, want 0 for a clock time", cfg.Duration)
+	}
+}
+
+func TestParseFlagsQualifiedClock(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	os.Args = []string{"keepalive", "-c", "tomorrow 09:00 UTC"}
+	cfg, err := ParseFlagsWithNow("test-version", now)
+	if err != nil {
+		t.Fatalf("ParseFlags() unexpected error: %v", err)
+	}
+	if want := now.Add(23 * time.Hour); !cfg.Clock.Equal(want) {
+		t.Errorf("ParseFlags() clock %v, want %v", cfg.Clock, want)
+	}
+	if cfg.Duration != 0 {
+		t.Errorf("ParseFlags() duration %d minutes, want 0 for a clock time", cfg.Duration)
+	}
+
+	os.Args = []string{"keepalive", "-c", "2023-12-31 12:00"}
+	if _, err := ParseFlagsWithNow("test-version", now); err == nil {
+		t.Error("ParseFlags() expected error for a date in the past")
+	}
+}
+
+func TestParseFlagsMaintenanceClock(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	orig := nextMaintenanceWindow
+	defer func() { nextMaintenanceWindow = orig }()
+	nextMaintenanceWindow = func(time.Time) (platform.MaintenanceWindow, error) {
+		return platform.MaintenanceWindow{Start: now.Add(90 * time.Minute), Source: "test"}, nil
+	}
+
+	os.Args = []string{"keepalive", "-c", "Maintenance"}
+	cfg, err := ParseFlagsWithNow("test-version", now)
+	if err != nil {
+		t.Fatalf("ParseFlags() unexpected error: %v", err)
+	}
+	if want := now.Add(90 * time.Minute); !cfg.Clock.Equal(want) || cfg.Duration != 0 {
+		t.Errorf("ParseFlags() clock %v (%d minutes), want %v (0 minutes)", cfg.Clock, cfg.Duration, want)
+	}
+
+	nextMaintenanceWindow = func(time.Time) (platform.MaintenanceWindow, error) {
+		return platform.MaintenanceWindow{}, platform.ErrNoMaintenanceWindow
+	}
+	if _, err := ParseFlagsWithNow("test-version", now); err == nil {
+		t.Error("ParseFlags() expected error without a maintenance window")
+	}
+}
+
+func TestParseFlagsTimings(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	os.Args = []string{"keepalive", "--idle-threshold", "30s", "--sim-interval", "45s", "--activity-interval", "15s"}
+	cfg, err := ParseFlagsWithNow("test-version", time.Now())
+	if err != nil {
+		t.Fatalf("ParseFlags() unexpected error: %v", err)
+	}
+	if cfg.Timings.IdleThreshold != 30*time.Second {
+		t.Errorf("IdleThreshold = %v, want 30s", cfg.Timings.IdleThreshold)
+	}
+	if cfg.Timings.ChatAppActivityInterval != 45*time.Second {
+		t.Errorf("ChatAppActivityInterval = %v, want 45s", cfg.Timings.ChatAppActivityInterval)
+	}
+	if cfg.Timings.ActivityInterval != 15*time.Second {
+		t.Errorf("ActivityInterval = %v, want 15s", cfg.Timings.ActivityInterval)
+	}
+
+	for _, args := range [][]string{
+		{"keepalive", "--idle-threshold", "30"},
+		{"keepalive", "--idle-threshold", "1s"},
+		{"keepalive", "--sim-interval", "2h"},
+		{"keepalive", "--activity-interval", "0.5s"},
+	} {
+		os.Args = args
+		if _, err := ParseFlagsWithNow("test-version", time.Now()); err == nil {
+			t.Errorf("ParseFlags(%v) expected error", args[1:])
+		}
+	}
+}
+
+func TestParseFlagsSimulationKey(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	os.Args = []string{"keepalive", "--sim-key", "f15"}
+	cfg, err := ParseFlagsWithNow("test-version", time.Now())
+	if err != nil {
+		t.Fatalf("ParseFlags() unexpected error: %v", err)
+	}
+	if cfg.SimulationKey != "F15" {
+		t.Errorf("SimulationKey = %q, want F15", cfg.SimulationKey)
+	}
+
+	os.Args = []string{"keepalive", "--sim-key", "enter"}
+	if _, err := ParseFlagsWithNow("test-version", time.Now()); err == nil {
+		t.Error("ParseFlags() accepted --sim-key enter")
+	}
+}
+
+func TestParseFlagsMousePattern(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	os.Args = []string{"keepalive", "--pattern", "Zigzag", "--pattern-size", "12"}
+	cfg, err := ParseFlagsWithNow("test-version", time.Now())
+	if err != nil {
+		t.Fatalf("ParseFlags() unexpected error: %v", err)
+	}
+	want := platform.MouseShape{Pattern: platform.MousePatternZigzag, Size: 12}
+	if cfg.MouseShape != want {
+		t.Errorf("MouseShape = %+v, want %+v", cfg.MouseShape, want)
+	}
+
+	os.Args = []string{"keepalive"}
+	cfg, err = ParseFlagsWithNow("test-version", time.Now())
+	if err != nil {
+		t.Fatalf("ParseFlags() unexpected error: %v", err)
+	}
+	if cfg.MouseShape != (platform.MouseShape{Pattern: platform.MousePatternCircle}) {
+		t.Errorf("default MouseShape = %+v, want circle with random size", cfg.MouseShape)
+	}
+
+	for _, args := range [][]string{
+		{"keepalive", "--pattern", "triangle"},
+		{"keepalive", "--pattern-size", "0"},
+		{"keepalive", "--pattern-size", "4"},
+		{"keepalive", "--pattern-size", "201"},
+	} {
+		os.Args = args
+		if _, err := ParseFlagsWithNow("test-version", time.Now()); err == nil {
+			t.Errorf("ParseFlags(%v) expected error", args[1:])
+		}
+	}
+}
+
+func TestParseFlagsMaxSimulations(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	os.Args = []string{"keepalive", "-a", "--max-idle-simulations", "50"}
+	cfg, err := ParseFlagsWithNow("test-version", time.Now())
+	if err != nil {
+		t.Fatalf("ParseFlags() unexpected error: %v", err)
+	}
+	if cfg.MaxSimulations != 50 {
+		t.Errorf("MaxSimulations = %d, want 50", cfg.MaxSimulations)
+	}
+
+	os.Args = []string{"keepalive", "-a", "--max-idle-simulations", "0"}
+	if _, err := ParseFlagsWithNow("test-version", time.Now()); err == nil {
+		t.Error("ParseFlags() expected error for a zero budget")
+	}
+}
+
+func TestParseFlagsSchedule(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	os.Args = []string{"keepalive", "--schedule", "Mon-Fri 09:00-17:30"}
+	cfg, err := ParseFlagsWithNow("test-version", time.Now())
+	if err != nil {
+		t.Fatalf("ParseFlags() unexpected error: %v", err)
+	}
+	if cfg.Schedule == nil || cfg.Schedule.String() != "Mon-Fri 09:00-17:30" {
+		t.Errorf("Schedule = %v, want Mon-Fri 09:00-17:30", cfg.Schedule)
+	}
+
+	os.Args = []string{"keepalive", "--schedule", "Mon-Fri 09:00"}
+	if _, err := ParseFlagsWithNow("test-version", time.Now()); err == nil {
+		t.Error("ParseFlags() expected error for a schedule without an end time")
+	}
+}
+
+func TestParseFlagsQuietHours(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	os.Args = []string{"keepalive", "-a", "--quiet-hours", "Mon,Wed 14:00-15:00"}
+	cfg, err := ParseFlagsWithNow("test-version", time.Now())
+	if err != nil {
+		t.Fatalf("ParseFlags() unexpected error: %v", err)
+	}
+	if cfg.QuietHours == nil || cfg.QuietHours.String() != "Mon,Wed 14:00-15:00" {
+		t.Errorf("QuietHours = %v, want Mon,Wed 14:00-15:00", cfg.QuietHours)
+	}
+	if cfg.Schedule != nil {
+		t.Errorf("Schedule = %v, want nil", cfg.Schedule)
+	}
+
+	os.Args = []string{"keepalive", "--quiet-hours", "someday 14:00-15:00"}
+	if _, err := ParseFlagsWithNow("test-version", time.Now()); err == nil {
+		t.Error("ParseFlags() expected error for invalid quiet hours")
+	}
+}
+
+func TestParseFlagsTags(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	os.Args = []string{"keepalive", "--tag", "project=foo", "--tag", "client=acme"}
+	cfg, err := ParseFlagsWithNow("test-version", time.Now())
+	if err != nil {
+		t.Fatalf("ParseFlags() unexpected error: %v", err)
+	}
+	if got := cfg.Tags.String(); got != "client=acme,project=foo" {
+		t.Errorf("Tags = %q, want client=acme,project=foo", got)
+	}
+
+	os.Args = []string{"keepalive", "--tag", "project"}
+	if _, err := ParseFlagsWithNow("test-version", time.Now()); err == nil {
+		t.Error("ParseFlags() expected error for a tag without a value")
+	}
+}
+
+func TestParseFlagsWatch(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	for _, tt := range []struct {
+		args []string
+		want keepalive.ProcessWatch
+	}{
+		{[]string{"keepalive", "--watch-pid", "1234"}, keepalive.ProcessWatch{PID: 1234}},
+		{[]string{"keepalive", "--watch-name", "rsync"}, keepalive.ProcessWatch{Name: "rsync"}},
+		{[]string{"keepalive", "-d", "2h", "--watch-name", "rsync"}, keepalive.ProcessWatch{Name: "rsync"}},
+		{[]string{"keepalive"}, keepalive.ProcessWatch{}},
+	} {
+		os.Args = tt.args
+		cfg, err := ParseFlagsWithNow("test-version", time.Now())
+		if err != nil {
+			t.Fatalf("ParseFlags(%v) unexpected error: %v", tt.args[1:], err)
+		}
+		if cfg.Watch != tt.want {
+			t.Errorf("ParseFlags(%v) Watch = %+v, want %+v", tt.args[1:], cfg.Watch, tt.want)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"keepalive", "--watch-pid", "0"},
+		{"keepalive", "--watch-pid", "-5"},
+		{"keepalive", "--watch-pid", "12", "--watch-name", "rsync"},
+	} {
+		os.Args = args
+		if _, err := ParseFlagsWithNow("test-version", time.Now()); err == nil {
+			t.Errorf("ParseFlags(%v) expected error", args[1:])
+		}
+	}
+}
+
+func TestParseFlagsOnExpire(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	os.Args = []string{"keepalive", "-d", "1h", "--on-expire", "systemctl suspend", "--notify", "--away-mode", "--allow-hibernate"}
+	cfg, err := ParseFlagsWithNow("test-version", time.Now())
+	if err != nil {
+		t.Fatalf("ParseFlags() unexpected error: %v", err)
+	}
+	if cfg.OnExpire != "systemctl suspend" {
+		t.Errorf("OnExpire = %q, want %q", cfg.OnExpire, "systemctl suspend")
+	}
+	if !cfg.Notify {
+		t.Error("Notify = false, want true")
+	}
+	if !cfg.AwayMode {
+		t.Error("AwayMode = false, want true")
+	}
+	if !cfg.AllowHibernate {
+		t.Error("AllowHibernate = false, want true")
+	}
+}
+
+func TestParseFlagsPresenceOnly(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	os.Args = []string{"keepalive", "--presence-only"}
+	cfg, err := ParseFlagsWithNow("test-version", time.Now())
+	if err != nil {
+		t.Fatalf("ParseFlags() unexpected error: %v", err)
+	}
+	if !cfg.PresenceOnly || !cfg.SimulateActivity {
+		t.Errorf("PresenceOnly, SimulateActivity = %v, %v, want true, true", cfg.PresenceOnly, cfg.SimulateActivity)
+	}
+
+	os.Args = []string{"keepalive", "--presence-only", "--away-mode"}
+	if _, err := ParseFlagsWithNow("test-version", time.Now()); err == nil {
+		t.Error("expected an error combining --presence-only with --away-mode")
+	}
+}
+
+func TestParseFlagsAssertions(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	tests := []struct {
+		args    []string
+		want    []platform.Assertion
+		wantErr bool
+	}{
+		{args: []string{"keepalive"}},
+		{args: []string{"keepalive", "--assertions", "idle,disk"}, want: []platform.Assertion{platform.AssertionIdle, platform.AssertionDisk}},
+		{args: []string{"keepalive", "--display-only"}, want: []platform.Assertion{platform.AssertionDisplay}},
+		{args: []string{"keepalive", "--assertions", "screen"}, wantErr: true},
+		{args: []string{"keepalive", "--assertions", "idle", "--display-only"}, wantErr: true},
+		{args: []string{"keepalive", "--display-only", "--presence-only"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		os.Args = tt.args
+		cfg, err := ParseFlagsWithNow("test-version", time.Now())
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%v: expected an error", tt.args[1:])
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tt.args[1:], err)
+		}
+		if !reflect.DeepEqual(cfg.Assertions, tt.want) {
+			t.Errorf("%v: Assertions = %v, want %v", tt.args[1:], cfg.Assertions, tt.want)
+		}
+	}
+}
+
+func TestParseFlagsMenubar(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	os.Args = []string{"keepalive", "--menubar", "-d", "1h"}
+	cfg, err := ParseFlagsWithNow("test-version", time.Now())
+	if err != nil {
+		t.Fatalf("ParseFlags() unexpected error: %v", err)
+	}
+	if !cfg.Menubar || cfg.Duration != 60 {
+		t.Errorf("Menubar, Duration = %v, %d, want true, 60", cfg.Menubar, cfg.Duration)
+	}
+
+	os.Args = []string{"keepalive", "--menubar", "--while-cmd", "true"}
+	if _, err := ParseFlagsWithNow("test-version", time.Now()); err == nil {
+		t.Error("expected an error combining --menubar with --while-cmd")
+	}
+
+	os.Args = []string{"keepalive", "--tray", "-c", "17:00"}
+	cfg, err = ParseFlagsWithNow("test-version", time.Date(2024, 1, 1, 9, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatalf("ParseFlags() unexpected error: %v", err)
+	}
+	if !cfg.Menubar {
+		t.Error("--tray did not set Menubar")
+	}
+
+	os.Args = []string{"keepalive", "--tray", "--battery-min", "20"}
+	if _, err := ParseFlagsWithNow("test-version", time.Now()); err == nil || !strings.Contains(err.Error(), "--tray") {
+		t.Errorf("combining --tray with --battery-min: err = %v, want one naming --tray", err)
+	}
+}
+
+func TestParseFlagsUntilIdle(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	os.Args = []string{"keepalive", "--until-idle-for", "10m"}
+	cfg, err := ParseFlagsWithNow("test-version", time.Now())
+	if err != nil {
+		t.Fatalf("ParseFlags() unexpected error: %v", err)
+	}
+	if cfg.UntilIdle != 10*time.Minute {
+		t.Errorf("UntilIdle = %v, want 10m", cfg.UntilIdle)
+	}
+
+	for _, args := range [][]string{
+		{"keepalive", "--until-idle-for", "10"},
+		{"keepalive", "--until-idle-for", "30s"},
+		{"keepalive", "--until-idle-for", "25h"},
+		{"keepalive", "--until-idle-for", "10m", "--active"},
+	} {
+		os.Args = args
+		if _, err := ParseFlagsWithNow("test-version", time.Now()); err == nil {
+			t.Errorf("ParseFlags(%v) expected error", args[1:])
+		}
+	}
+}
+
+func TestParseFlagsWhileCmd(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	os.Args = []string{"keepalive", "--while-cmd", "pgrep ffmpeg"}
+	cfg, err := ParseFlagsWithNow("test-version", time.Now())
+	if err != nil {
+		t.Fatalf("ParseFlags() unexpected error: %v", err)
+	}
+	if cfg.WhileCmd != "pgrep ffmpeg" {
+		t.Errorf("WhileCmd = %q, want %q", cfg.WhileCmd, "pgrep ffmpeg")
+	}
+
+	for _, args := range [][]string{
+		{"keepalive", "--while-cmd", "pgrep ffmpeg", "-d", "1h"},
+		{"keepalive", "--while-cmd", "pgrep ffmpeg", "-c", "22:00"},
+	} {
+		os.Args = args
+		if _, err := ParseFlagsWithNow("test-version", time.Now()); err == nil {
+			t.Errorf("ParseFlags(%v) expected error", args[1:])
+		}
+	}
+}
+
+func TestParseFlagsLogLevel(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	tests := []struct {
+		args    []string
+		logging bool
+		level   slog.Level
+	}{
+		{[]string{"keepalive"}, false, slog.LevelInfo},
+		{[]string{"keepalive", "--log"}, true, slog.LevelInfo},
+		{[]string{"keepalive", "--verbose"}, true, slog.LevelDebug},
+		{[]string{"keepalive", "--log-level", "warn"}, true, slog.LevelWarn},
+		{[]string{"keepalive", "--log-file", "/tmp/keepalive.log"}, true, slog.LevelInfo},
+	}
+	for _, tt := range tests {
+		os.Args = tt.args
+		cfg, err := ParseFlagsWithNow("test-version", time.Now())
+		if err != nil {
+			t.Fatalf("ParseFlags(%v) unexpected error: %v", tt.args[1:], err)
+		}
+		if cfg.EnableLogging != tt.logging || cfg.LogLevel != tt.level {
+			t.Errorf("ParseFlags(%v) = logging %v level %v, want %v %v", tt.args[1:], cfg.EnableLogging, cfg.LogLevel, tt.logging, tt.level)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"keepalive", "--log-level", "trace"},
+		{"keepalive", "--log-level", "debug", "--verbose"},
+	} {
+		os.Args = args
+		if _, err := ParseFlagsWithNow("test-version", time.Now()); err == nil {
+			t.Errorf("ParseFlags(%v) expected error", args[1:])
+		}
+	}
+}
+
+func TestParseFlagsFromTask(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+	orig := lookupTask
+	defer func() { lookupTask = orig }()
+
+	var gotID, gotCommand string
+	lookupTask = func(id, command string) (task.Task, error) {
+		gotID, gotCommand = id, command
+		if id == "404" {
+			return task.Task{}, fmt.Errorf("no task found")
+		}
+		return task.Task{ID: "uuid-12", Description: "Write the report", Estimate: 89*time.Minute + 30*time.Second}, nil
+	}
+
+	os.Args = []string{"keepalive", "--from-task", "12"}
+	cfg, err := ParseFlagsWithNow("test-version", time.Now())
+	if err != nil {
+		t.Fatalf("ParseFlags() unexpected error: %v", err)
+	}
+	if gotID != "12" || gotCommand != task.DefaultCommand {
+		t.Errorf("looked up %q with %q, want %q with %q", gotID, gotCommand, "12", task.DefaultCommand)
+	}
+	if cfg.Task == nil || cfg.Task.Description != "Write the report" {
+		t.Fatalf("Task = %+v, want the looked up task", cfg.Task)
+	}
+	if cfg.Duration != 90 {
+		t.Errorf("Duration = %d, want the estimate rounded up to 90 minutes", cfg.Duration)
+	}
+	if cfg.Tags["task"] != "uuid-12" || cfg.Tags["reason"] != "Write the report" {
+		t.Errorf("Tags = %v, want task and reason", cfg.Tags)
+	}
+
+	os.Args = []string{"keepalive", "--from-task", "12", "-d", "30m", "--tag", "reason=review", "--task-command", "todo {id}"}
+	cfg, err = ParseFlagsWithNow("test-version", time.Now())
+	if err != nil {
+		t.Fatalf("ParseFlags() unexpected error: %v", err)
+	}
+	if gotCommand != "todo {id}" {
+		t.Errorf("task command = %q, want %q", gotCommand, "todo {id}")
+	}
+	if cfg.Duration != 30 {
+		t.Errorf("Duration = %d, want -d to win over the estimate", cfg.Duration)
+	}
+	if cfg.Tags["reason"] != "review" {
+		t.Errorf("reason tag = %q, want the one given with --tag", cfg.Tags["reason"])
+	}
+
+	os.Args = []string{"keepalive", "--from-task", "404"}
+	if _, err := ParseFlagsWithNow("test-version", time.Now()); err == nil {
+		t.Error("ParseFlags() expected error for a task that cannot be read")
 	}
 }
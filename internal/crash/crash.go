@@ -0,0 +1,220 @@
+// Package crash records panics to local report files. Reports stay on disk
+// until the user chooses to share one; nothing is ever sent automatically.
+package crash
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/buildinfo"
+)
+
+// maxReports bounds how many reports are kept; older ones are removed.
+const maxReports = 10
+
+// Report is the content of a crash file.
+type Report struct {
+	Time        time.Time         `json:"time"`
+	Where       string            `json:"where"`
+	Panic       string            `json:"panic"`
+	Stack       string            `json:"stack"`
+	Build       buildinfo.Info    `json:"build"`
+	Diagnostics map[string]string `json:"diagnostics,omitempty"`
+}
+
+// Panic wraps a recovered value once it has been recorded so that outer
+// handlers re-panic without writing a second report.
+type Panic struct {
+	Value  interface{}
+	Report string
+}
+
+func (p *Panic) String() string {
+	if p.Report == "" {
+		return fmt.Sprint(p.Value)
+	}
+	return fmt.Sprintf("%v\n\ncrash report saved to %s", p.Value, p.Report)
+}
+
+func (p *Panic) Error() string { return p.String() }
+
+var (
+	mu          sync.Mutex
+	diagnostics func() map[string]string
+	lastReport  string
+
+	// dir is replaced in tests.
+	dir = defaultDir
+)
+
+// SetDiagnostics registers a function that snapshots application state for
+// inclusion in reports. It must not panic or block.
+func SetDiagnostics(fn func() map[string]string) {
+	mu.Lock()
+	defer mu.Unlock()
+	diagnostics = fn
+}
+
+// LastReport returns the path of the report written by this process, if any.
+func LastReport() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return lastReport
+}
+
+// DirEnv overrides the crash report directory.
+const DirEnv = "KEEPALIVE_CRASH_DIR"
+
+// Dir returns the directory that holds crash reports.
+func Dir() (string, error) {
+	return dir()
+}
+
+func defaultDir() (string, error) {
+	if d := os.Getenv(DirEnv); d != "" {
+		return d, nil
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "keepalive", "crashes"), nil
+}
+
+// Guard records a panic in the calling goroutine and re-panics. Use it as
+//
+//	defer crash.Guard("component")
+//
+// at the top of main and of long-lived goroutines.
+func Guard(where string) {
+	if r := recover(); r != nil {
+		panic(record(where, r, debug.Stack()))
+	}
+}
+
+// record writes a report for r unless it was already recorded and returns
+// the value to re-panic with.
+func record(where string, r interface{}, stack []byte) *Panic {
+	if p, ok := r.(*Panic); ok {
+		return p
+	}
+
+	report := Report{
+		Time:  time.Now().UTC(),
+		Where: where,
+		Panic: redact(fmt.Sprint(r)),
+		Stack: redact(string(stack)),
+		Build: buildinfo.Get(),
+	}
+
+	mu.Lock()
+	fn := diagnostics
+	mu.Unlock()
+	if fn != nil {
+		report.Diagnostics = snapshot(fn)
+	}
+
+	path, err := Write(report)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keepalive: failed to save crash report: %v\n", err)
+		return &Panic{Value: r}
+	}
+
+	mu.Lock()
+	lastReport = path
+	mu.Unlock()
+	return &Panic{Value: r, Report: path}
+}
+
+// snapshot calls fn, tolerating a panic inside it.
+func snapshot(fn func() map[string]string) (out map[string]string) {
+	defer func() {
+		if r := recover(); r != nil {
+			out = map[string]string{"error": fmt.Sprintf("diagnostics panicked: %v", r)}
+		}
+	}()
+	out = make(map[string]string)
+	for k, v := range fn() {
+		out[k] = redact(v)
+	}
+	return out
+}
+
+// Write stores r in the crash directory and prunes old reports.
+func Write(r Report) (string, error) {
+	d, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(d, 0o700); err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("crash-%s-%d.json", r.Time.Format("20060102T150405Z"), os.Getpid())
+	path := filepath.Join(d, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", err
+	}
+
+	prune(d)
+	return path, nil
+}
+
+// List returns report paths, newest first.
+func List() ([]string, error) {
+	d, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(d, "crash-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	// Names embed a sortable timestamp.
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	return paths, nil
+}
+
+// Load reads a report from path.
+func Load(path string) (Report, error) {
+	var r Report
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return r, fmt.Errorf("invalid crash report %s: %w", path, err)
+	}
+	return r, nil
+}
+
+func prune(d string) {
+	paths, err := filepath.Glob(filepath.Join(d, "crash-*.json"))
+	if err != nil || len(paths) <= maxReports {
+		return
+	}
+	sort.Strings(paths)
+	for _, p := range paths[:len(paths)-maxReports] {
+		os.Remove(p)
+	}
+}
+
+// redact replaces the home directory in s so that reports can be shared
+// without revealing the user's account name.
+func redact(s string) string {
+	if home, err := os.UserHomeDir(); err == nil && len(home) > 1 {
+		s = strings.ReplaceAll(s, home, "~")
+	}
+	return s
+}
@@ -0,0 +1,95 @@
+package crash
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func useTempDir(t *testing.T) string {
+	t.Helper()
+	d := t.TempDir()
+	orig := dir
+	dir = func() (string, error) { return d, nil }
+	t.Cleanup(func() { dir = orig })
+	return d
+}
+
+func TestGuardWritesReportAndRepanics(t *testing.T) {
+	useTempDir(t)
+	SetDiagnostics(func() map[string]string { return map[string]string{"running": "true"} })
+	t.Cleanup(func() { SetDiagnostics(nil) })
+
+	var recovered interface{}
+	func() {
+		defer func() { recovered = recover() }()
+		func() {
+			defer Guard("outer")
+			func() {
+				defer Guard("inner")
+				panic("boom")
+			}()
+		}()
+	}()
+
+	p, ok := recovered.(*Panic)
+	if !ok {
+		t.Fatalf("recovered %T, want *Panic", recovered)
+	}
+	if p.Value != "boom" || p.Report == "" {
+		t.Fatalf("unexpected panic value %+v", p)
+	}
+
+	paths, err := List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(paths) != 1 {
+		t.Fatalf("got %d reports, want exactly 1 for nested guards", len(paths))
+	}
+
+	r, err := Load(paths[0])
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if r.Where != "inner" || r.Panic != "boom" {
+		t.Fatalf("report = %+v", r)
+	}
+	if !strings.Contains(r.Stack, "TestGuardWritesReportAndRepanics") {
+		t.Error("expected stack trace in report")
+	}
+	if r.Diagnostics["running"] != "true" {
+		t.Errorf("diagnostics = %v", r.Diagnostics)
+	}
+	if LastReport() != paths[0] {
+		t.Errorf("LastReport() = %q, want %q", LastReport(), paths[0])
+	}
+}
+
+func TestWritePrunesOldReports(t *testing.T) {
+	d := useTempDir(t)
+	for i := 0; i < maxReports+3; i++ {
+		name := filepath.Join(d, "crash-2026010"+string(rune('0'+i%10))+"T000000Z-"+string(rune('a'+i))+".json")
+		if err := os.WriteFile(name, []byte("{}"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	prune(d)
+
+	paths, _ := List()
+	if len(paths) != maxReports {
+		t.Fatalf("got %d reports after prune, want %d", len(paths), maxReports)
+	}
+}
+
+func TestRedactHomeDir(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil || len(home) <= 1 {
+		t.Skip("no home directory")
+	}
+	got := redact(filepath.Join(home, "src", "main.go"))
+	if strings.Contains(got, home) {
+		t.Fatalf("redact() = %q still contains home dir", got)
+	}
+}
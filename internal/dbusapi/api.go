@@ -0,0 +1,130 @@
+package dbusapi
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/godbus/dbus/v5/introspect"
+)
+
+// APIVersion identifies the revision of the org.keepalive.Manager interface.
+// Members may be added in a new revision, but existing members never change
+// signature or meaning, so clients can require a minimum version and keep
+// working with newer releases.
+//
+// Revision history:
+//
+//	1: initial interface
+//	2: Commit and BuildDate properties
+//	3: Pause and Resume methods and the Paused property
+//	4: SetSimulationMethod method and the SimulationMethod property
+//	5: SetSimulateActivity and SetSimulationInterval methods and the
+//	   SimulationInterval property
+const APIVersion uint32 = 5
+
+// propertySpec describes one exported property.
+type propertySpec struct {
+	name      string
+	signature string
+	// emits is the org.freedesktop.DBus.Property.EmitsChangedSignal value.
+	emits string
+}
+
+// managerProperties lists the exported properties in their documented order.
+var managerProperties = []propertySpec{
+	{name: "APIVersion", signature: "u", emits: "const"},
+	{name: "Version", signature: "s", emits: "const"},
+	{name: "Commit", signature: "s", emits: "const"},
+	{name: "BuildDate", signature: "s", emits: "const"},
+	{name: "Running", signature: "b", emits: "true"},
+	{name: "SimulateActivity", signature: "b", emits: "true"},
+	{name: "Paused", signature: "b", emits: "true"},
+	{name: "SimulationMethod", signature: "s", emits: "true"},
+	{name: "SimulationInterval", signature: "x", emits: "true"},
+	{name: "EndTime", signature: "x", emits: "true"},
+	// Remaining changes every second; clients derive it from EndTime.
+	{name: "Remaining", signature: "x", emits: "false"},
+}
+
+// ManagerInterface returns the introspection description of Interface. It is
+// the single source for the live introspection data and the generated XML in
+// docs/dbus.
+func ManagerInterface() introspect.Interface {
+	props := make([]introspect.Property, 0, len(managerProperties))
+	for _, p := range managerProperties {
+		props = append(props, introspect.Property{
+			Name:   p.name,
+			Type:   p.signature,
+			Access: "read",
+			Annotations: []introspect.Annotation{
+				{Name: "org.freedesktop.DBus.Property.EmitsChangedSignal", Value: p.emits},
+			},
+		})
+	}
+
+	return introspect.Interface{
+		Name: Interface,
+		Methods: []introspect.Method{
+			{Name: "Start", Args: []introspect.Arg{{Name: "seconds", Type: "x", Direction: "in"}}},
+			{Name: "Stop"},
+			{Name: "Extend", Args: []introspect.Arg{{Name: "seconds", Type: "x", Direction: "in"}}},
+			{Name: "Pause"},
+			{Name: "Resume"},
+			{Name: "SetSimulationMethod", Args: []introspect.Arg{{Name: "method", Type: "s", Direction: "in"}}},
+			{Name: "SetSimulateActivity", Args: []introspect.Arg{{Name: "enabled", Type: "b", Direction: "in"}}},
+			{Name: "SetSimulationInterval", Args: []introspect.Arg{{Name: "seconds", Type: "x", Direction: "in"}}},
+			{Name: "Status", Args: []introspect.Arg{{Name: "status", Type: "a{sv}", Direction: "out"}}},
+		},
+		Properties: props,
+	}
+}
+
+// introspectionNode describes the exported object with all its interfaces.
+func introspectionNode() *introspect.Node {
+	return &introspect.Node{
+		Name: ObjectPath,
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			propertiesInterface,
+			ManagerInterface(),
+		},
+	}
+}
+
+// propertiesInterface mirrors the org.freedesktop.DBus.Properties description
+// so the generated XML does not depend on the platform-specific exporter.
+var propertiesInterface = introspect.Interface{
+	Name: "org.freedesktop.DBus.Properties",
+	Methods: []introspect.Method{
+		{Name: "Get", Args: []introspect.Arg{
+			{Name: "interface", Type: "s", Direction: "in"},
+			{Name: "property", Type: "s", Direction: "in"},
+			{Name: "value", Type: "v", Direction: "out"},
+		}},
+		{Name: "GetAll", Args: []introspect.Arg{
+			{Name: "interface", Type: "s", Direction: "in"},
+			{Name: "props", Type: "a{sv}", Direction: "out"},
+		}},
+		{Name: "Set", Args: []introspect.Arg{
+			{Name: "interface", Type: "s", Direction: "in"},
+			{Name: "property", Type: "s", Direction: "in"},
+			{Name: "value", Type: "v", Direction: "in"},
+		}},
+	},
+	Signals: []introspect.Signal{
+		{Name: "PropertiesChanged", Args: []introspect.Arg{
+			{Name: "interface", Type: "s"},
+			{Name: "changed_properties", Type: "a{sv}"},
+			{Name: "invalidates_properties", Type: "as"},
+		}},
+	},
+}
+
+// IntrospectionXML renders the introspection document for ObjectPath.
+func IntrospectionXML() (string, error) {
+	b, err := xml.MarshalIndent(introspectionNode(), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(introspect.IntrospectDeclarationString) + "\n" + string(b) + "\n", nil
+}
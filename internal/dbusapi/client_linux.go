@@ -0,0 +1,70 @@
+//go:build linux
+
+package dbusapi
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// ErrNotRunning is returned by Call when no keepalive owns BusName.
+var ErrNotRunning = errors.New("no running keepalive found on the session bus")
+
+// Call invokes method of Interface on the running instance, for commands
+// that control it from another process.
+func Call(method string, args ...interface{}) error {
+	conn, err := connectRunning()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	call := conn.Object(BusName, ObjectPath).Call(Interface+"."+method, 0, args...)
+	var dbusErr dbus.Error
+	if errors.As(call.Err, &dbusErr) && len(dbusErr.Body) > 0 {
+		// Failures from the controller carry its message as the body.
+		return fmt.Errorf("%v", dbusErr.Body[0])
+	}
+	return call.Err
+}
+
+// Query returns the status of the running instance.
+func Query() (Status, error) {
+	conn, err := connectRunning()
+	if err != nil {
+		return Status{}, err
+	}
+	defer conn.Close()
+
+	var values map[string]dbus.Variant
+	if err := conn.Object(BusName, ObjectPath).Call(Interface+".Status", 0).Store(&values); err != nil {
+		return Status{}, fmt.Errorf("read status: %w", err)
+	}
+	plain := make(map[string]interface{}, len(values))
+	for name, v := range values {
+		plain[name] = v.Value()
+	}
+	return statusFromProperties(plain), nil
+}
+
+// connectRunning connects to the session bus and checks that a keepalive
+// owns BusName. The caller must close the connection.
+func connectRunning() (*dbus.Conn, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("connect to session bus: %w", err)
+	}
+
+	var owned bool
+	if err := conn.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, BusName).Store(&owned); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("look up %s: %w", BusName, err)
+	}
+	if !owned {
+		conn.Close()
+		return nil, ErrNotRunning
+	}
+	return conn, nil
+}
@@ -0,0 +1,111 @@
+// Package dbusapi exports keep-alive control over the D-Bus session bus so
+// desktop shell extensions, widgets, and scripts can drive a running instance.
+package dbusapi
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// BusName is the well-known name requested on the session bus.
+	BusName = "org.keepalive.Manager"
+	// ObjectPath is where the manager object is exported.
+	ObjectPath = "/org/keepalive/Manager"
+	// Interface is the D-Bus interface implemented by the manager object.
+	Interface = "org.keepalive.Manager"
+
+	// statusPollInterval controls how often state is sampled to emit
+	// PropertiesChanged signals.
+	statusPollInterval = time.Second
+)
+
+// ErrUnsupported is returned by Serve on platforms without a session bus.
+var ErrUnsupported = errors.New("dbus service is only supported on linux")
+
+// Status is a snapshot of the keep-alive state published over D-Bus.
+type Status struct {
+	Running          bool
+	SimulateActivity bool
+	Paused           bool
+	SimulationMethod string
+	// SimulationInterval is the minimum time between simulated moves.
+	SimulationInterval time.Duration
+	EndTime            time.Time
+	Remaining          time.Duration
+	Version            string
+	Commit             string
+	BuildDate          string
+}
+
+// Controller applies requests received over D-Bus. Implementations must be
+// safe for concurrent use.
+type Controller interface {
+	Start(d time.Duration) error
+	Stop() error
+	Extend(d time.Duration) error
+	Pause() error
+	Resume() error
+	SetSimulationMethod(method string) error
+	SetSimulateActivity(enabled bool) error
+	SetSimulationInterval(d time.Duration) error
+	Status() Status
+}
+
+// properties flattens a status into the property values exposed on the bus.
+// Times are exported as unix seconds and durations as whole seconds, with
+// zero meaning "not set".
+func properties(s Status) map[string]interface{} {
+	var endTime int64
+	if !s.EndTime.IsZero() {
+		endTime = s.EndTime.Unix()
+	}
+	return map[string]interface{}{
+		"APIVersion":         APIVersion,
+		"Running":            s.Running,
+		"SimulateActivity":   s.SimulateActivity,
+		"Paused":             s.Paused,
+		"SimulationMethod":   s.SimulationMethod,
+		"SimulationInterval": int64(s.SimulationInterval / time.Second),
+		"EndTime":            endTime,
+		"Remaining":          int64(s.Remaining / time.Second),
+		"Version":            s.Version,
+		"Commit":             s.Commit,
+		"BuildDate":          s.BuildDate,
+	}
+}
+
+// statusFromProperties reverses properties for a client reading the values
+// back. Missing or mistyped values, as from an older instance, are left
+// zero.
+func statusFromProperties(values map[string]interface{}) Status {
+	var s Status
+	s.Running, _ = values["Running"].(bool)
+	s.SimulateActivity, _ = values["SimulateActivity"].(bool)
+	s.Paused, _ = values["Paused"].(bool)
+	s.SimulationMethod, _ = values["SimulationMethod"].(string)
+	if interval, _ := values["SimulationInterval"].(int64); interval > 0 {
+		s.SimulationInterval = time.Duration(interval) * time.Second
+	}
+	if end, _ := values["EndTime"].(int64); end > 0 {
+		s.EndTime = time.Unix(end, 0)
+	}
+	if remaining, _ := values["Remaining"].(int64); remaining > 0 {
+		s.Remaining = time.Duration(remaining) * time.Second
+	}
+	s.Version, _ = values["Version"].(string)
+	s.Commit, _ = values["Commit"].(string)
+	s.BuildDate, _ = values["BuildDate"].(string)
+	return s
+}
+
+// secondsToDuration validates a duration argument received over the bus.
+func secondsToDuration(seconds int64) (time.Duration, error) {
+	if seconds < 0 {
+		return 0, errors.New("duration must not be negative")
+	}
+	if seconds > int64((1<<63-1)/time.Second) {
+		return 0, errors.New("duration is too large")
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
@@ -0,0 +1,124 @@
+package dbusapi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPropertiesFlattenStatus(t *testing.T) {
+	end := time.Unix(1700000000, 0)
+	got := properties(Status{
+		Running:          true,
+		SimulateActivity: true,
+		EndTime:          end,
+		Remaining:        90*time.Second + 500*time.Millisecond,
+		Version:          "1.2.3",
+	})
+
+	want := map[string]interface{}{
+		"Running":          true,
+		"SimulateActivity": true,
+		"EndTime":          int64(1700000000),
+		"Remaining":        int64(90),
+		"Version":          "1.2.3",
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("properties()[%q] = %v, want %v", name, got[name], value)
+		}
+	}
+}
+
+func TestPropertiesZeroEndTime(t *testing.T) {
+	got := properties(Status{Running: true})
+	if got["EndTime"] != int64(0) {
+		t.Fatalf("properties()[EndTime] = %v, want 0 for indefinite sessions", got["EndTime"])
+	}
+}
+
+func TestStatusFromPropertiesRoundTrip(t *testing.T) {
+	want := Status{
+		Running:          true,
+		SimulateActivity: true,
+		Paused:           true,
+		SimulationMethod: "uinput",
+		EndTime:          time.Unix(1700000000, 0),
+		Remaining:        90 * time.Second,
+		Version:          "1.2.3",
+		Commit:           "abc123",
+		BuildDate:        "2024-01-01",
+	}
+	got := statusFromProperties(properties(want))
+	if !got.EndTime.Equal(want.EndTime) {
+		t.Errorf("EndTime = %v, want %v", got.EndTime, want.EndTime)
+	}
+	got.EndTime = want.EndTime
+	if got != want {
+		t.Errorf("statusFromProperties(properties(s)) = %+v, want %+v", got, want)
+	}
+
+	if s := statusFromProperties(map[string]interface{}{"Running": true}); !s.Running || !s.EndTime.IsZero() {
+		t.Errorf("statusFromProperties with missing values = %+v, want only Running set", s)
+	}
+}
+
+func TestSecondsToDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		seconds int64
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "zero", seconds: 0, want: 0},
+		{name: "one hour", seconds: 3600, want: time.Hour},
+		{name: "negative", seconds: -1, wantErr: true},
+		{name: "overflow", seconds: 1 << 62, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := secondsToDuration(tt.seconds)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("secondsToDuration(%d) expected error, got %v", tt.seconds, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("secondsToDuration(%d) unexpected error: %v", tt.seconds, err)
+			}
+			if got != tt.want {
+				t.Fatalf("secondsToDuration(%d) = %v, want %v", tt.seconds, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPropertiesMatchInterface(t *testing.T) {
+	values := properties(Status{})
+	if len(values) != len(managerProperties) {
+		t.Fatalf("properties() has %d entries, interface declares %d", len(values), len(managerProperties))
+	}
+	for _, p := range managerProperties {
+		if _, ok := values[p.name]; !ok {
+			t.Errorf("properties() is missing declared property %q", p.name)
+		}
+	}
+}
+
+func TestIntrospectionXMLMatchesDocs(t *testing.T) {
+	got, err := IntrospectionXML()
+	if err != nil {
+		t.Fatalf("IntrospectionXML() error = %v", err)
+	}
+
+	want, err := os.ReadFile(filepath.Join("..", "..", "docs", "dbus", Interface+".xml"))
+	if err != nil {
+		t.Fatalf("read committed interface: %v", err)
+	}
+	if got != string(want) {
+		t.Fatal("docs/dbus/" + Interface + ".xml is out of date; run `go run ./cmd/gen-docs` and review APIVersion")
+	}
+}
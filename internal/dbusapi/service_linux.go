@@ -0,0 +1,226 @@
+//go:build linux
+
+package dbusapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/crash"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+// Service is a running org.keepalive.Manager export on the session bus.
+type Service struct {
+	conn   *dbus.Conn
+	props  *prop.Properties
+	ctrl   Controller
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// manager holds the methods exported on Interface. It is kept separate from
+// Service so that only the intended methods are visible on the bus.
+type manager struct {
+	ctrl Controller
+}
+
+// Start begins a session. A duration of zero keeps the system awake
+// indefinitely.
+func (m *manager) Start(seconds int64) *dbus.Error {
+	d, err := secondsToDuration(seconds)
+	if err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	if err := m.ctrl.Start(d); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+// Stop ends the current session.
+func (m *manager) Stop() *dbus.Error {
+	if err := m.ctrl.Stop(); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+// Extend pushes the end of the current timed session back.
+func (m *manager) Extend(seconds int64) *dbus.Error {
+	d, err := secondsToDuration(seconds)
+	if err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	if err := m.ctrl.Extend(d); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+// Pause suspends the current session until Resume.
+func (m *manager) Pause() *dbus.Error {
+	if err := m.ctrl.Pause(); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+// Resume continues a paused session.
+func (m *manager) Resume() *dbus.Error {
+	if err := m.ctrl.Resume(); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+// SetSimulationMethod selects the input method activity simulation uses;
+// "auto" restores automatic selection.
+func (m *manager) SetSimulationMethod(method string) *dbus.Error {
+	if err := m.ctrl.SetSimulationMethod(method); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+// SetSimulateActivity turns activity simulation on or off for the running
+// session and later ones.
+func (m *manager) SetSimulateActivity(enabled bool) *dbus.Error {
+	if err := m.ctrl.SetSimulateActivity(enabled); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+// SetSimulationInterval sets the minimum time between simulated moves; 0
+// restores the default.
+func (m *manager) SetSimulationInterval(seconds int64) *dbus.Error {
+	d, err := secondsToDuration(seconds)
+	if err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	if err := m.ctrl.SetSimulationInterval(d); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+// Status returns the same values as the exported properties.
+func (m *manager) Status() (map[string]dbus.Variant, *dbus.Error) {
+	out := make(map[string]dbus.Variant)
+	for name, value := range properties(m.ctrl.Status()) {
+		out[name] = dbus.MakeVariant(value)
+	}
+	return out, nil
+}
+
+// Serve connects to the session bus, claims BusName, and exports the manager
+// object. State changes reported by ctrl are published as PropertiesChanged
+// signals until Close is called.
+func Serve(ctrl Controller) (*Service, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("connect to session bus: %w", err)
+	}
+
+	reply, err := conn.RequestName(BusName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("request name %s: %w", BusName, err)
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		conn.Close()
+		return nil, fmt.Errorf("name %s is already owned; is another keepalive running?", BusName)
+	}
+
+	m := &manager{ctrl: ctrl}
+	if err := conn.Export(m, ObjectPath, Interface); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("export %s: %w", Interface, err)
+	}
+
+	props, err := prop.Export(conn, ObjectPath, propertyMap(ctrl.Status()))
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("export properties: %w", err)
+	}
+
+	data, err := IntrospectionXML()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("render introspection: %w", err)
+	}
+	if err := conn.Export(introspect.Introspectable(data), ObjectPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("export introspection: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Service{conn: conn, props: props, ctrl: ctrl, cancel: cancel}
+	s.wg.Add(1)
+	go s.publish(ctx)
+
+	slog.Info("dbus control service exported", "name", BusName, "path", ObjectPath)
+	return s, nil
+}
+
+// propertyMap builds the initial property table for Interface.
+func propertyMap(s Status) prop.Map {
+	values := properties(s)
+	emit := map[string]prop.EmitType{
+		"true":  prop.EmitTrue,
+		"false": prop.EmitFalse,
+		"const": prop.EmitConst,
+	}
+
+	props := make(map[string]*prop.Prop, len(managerProperties))
+	for _, p := range managerProperties {
+		props[p.name] = &prop.Prop{Value: values[p.name], Emit: emit[p.emits]}
+	}
+	return prop.Map{Interface: props}
+}
+
+// publish samples the controller and updates exported properties. Properties
+// whose value did not change are left untouched so no signal is emitted.
+func (s *Service) publish(ctx context.Context) {
+	defer s.wg.Done()
+	defer crash.Guard("dbus")
+
+	ticker := time.NewTicker(statusPollInterval)
+	defer ticker.Stop()
+
+	last := properties(s.ctrl.Status())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current := properties(s.ctrl.Status())
+			for name, value := range current {
+				if last[name] != value {
+					s.props.SetMust(Interface, name, value)
+				}
+			}
+			last = current
+		}
+	}
+}
+
+// Close releases the bus name and disconnects.
+func (s *Service) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.cancel()
+	s.wg.Wait()
+	if _, err := s.conn.ReleaseName(BusName); err != nil {
+		slog.Warn("dbus release name failed", "err", err)
+	}
+	return s.conn.Close()
+}
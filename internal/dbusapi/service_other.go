@@ -0,0 +1,26 @@
+//go:build !linux
+
+package dbusapi
+
+// Service is a placeholder on platforms without D-Bus support.
+type Service struct{}
+
+// Serve always returns ErrUnsupported outside Linux.
+func Serve(ctrl Controller) (*Service, error) {
+	return nil, ErrUnsupported
+}
+
+// Close is a no-op outside Linux.
+func (s *Service) Close() error {
+	return nil
+}
+
+// Call always returns ErrUnsupported outside Linux.
+func Call(method string, args ...interface{}) error {
+	return ErrUnsupported
+}
+
+// Query always returns ErrUnsupported outside Linux.
+func Query() (Status, error) {
+	return Status{}, ErrUnsupported
+}
@@ -0,0 +1,140 @@
+// Package history records finished sessions, one JSON object per line, and
+// answers queries over them for reporting.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/logging"
+	"github.com/stigoleg/keep-alive/internal/schema"
+)
+
+// FileName is the history file's name, kept next to the log file.
+const FileName = "history.jsonl"
+
+// Session is a finished session.
+type Session struct {
+	// SchemaVersion is the version of the "history-entry" schema the line
+	// was written with. Append sets it; lines written before it was
+	// recorded have none and follow version 1.
+	SchemaVersion int       `json:"schema_version,omitempty"`
+	Start         time.Time `json:"start"`
+	End           time.Time `json:"end"`
+	// Awake is how long the system was kept awake, excluding time the
+	// session spent suspended.
+	Awake time.Duration `json:"awake_ns"`
+	Tags  Tags          `json:"tags,omitempty"`
+}
+
+// Tags are key=value labels attached to a session. As a flag.Value, each
+// Set adds one tag.
+type Tags map[string]string
+
+// Set adds a "key=value" tag.
+func (t Tags) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return fmt.Errorf("invalid tag %q: use key=value", s)
+	}
+	t[key] = strings.TrimSpace(value)
+	return nil
+}
+
+// String returns the tags as sorted key=value pairs.
+func (t Tags) String() string {
+	pairs := make([]string, 0, len(t))
+	for k, v := range t {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Match reports whether t has every tag in want.
+func (t Tags) Match(want Tags) bool {
+	for k, v := range want {
+		if got, ok := t[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// DefaultPath returns where history is kept: next to the default log file.
+func DefaultPath() (string, error) {
+	logPath, err := logging.DefaultPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(logPath), FileName), nil
+}
+
+// Append adds s to the history file at path, creating it if needed. Like the
+// log, the file is readable by the owner only.
+func Append(path string, s Session) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	s.SchemaVersion = schema.HistoryEntryVersion
+	line, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Query selects sessions from the history.
+type Query struct {
+	// Tags must all be present on a session.
+	Tags Tags
+	// Since, if set, selects sessions that ended after it.
+	Since time.Time
+}
+
+// Read returns the sessions in the history file at path that match q, oldest
+// first. A missing file has no sessions. Lines that cannot be parsed, such
+// as one cut short by a crash, are skipped.
+func Read(path string, q Query) ([]Session, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var sessions []Session
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var s Session
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			continue
+		}
+		if !q.Since.IsZero() && !s.End.After(q.Since) {
+			continue
+		}
+		if !s.Tags.Match(q.Tags) {
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, scanner.Err()
+}
@@ -0,0 +1,83 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keepalive", FileName)
+	day := time.Date(2025, 10, 6, 9, 0, 0, 0, time.UTC)
+	sessions := []Session{
+		{Start: day, End: day.Add(time.Hour), Awake: time.Hour, Tags: Tags{"project": "foo"}},
+		{Start: day.Add(24 * time.Hour), End: day.Add(26 * time.Hour), Awake: 2 * time.Hour, Tags: Tags{"project": "bar"}},
+		{Start: day.Add(48 * time.Hour), End: day.Add(49 * time.Hour), Awake: 30 * time.Minute, Tags: Tags{"project": "foo", "client": "acme"}},
+	}
+	for _, s := range sessions {
+		if err := Append(path, s); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+	// A line cut short by a crash is skipped.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"start":"2025-10`)
+	f.Close()
+
+	tests := []struct {
+		name string
+		q    Query
+		want int
+	}{
+		{"all", Query{}, 3},
+		{"tag", Query{Tags: Tags{"project": "foo"}}, 2},
+		{"tags", Query{Tags: Tags{"project": "foo", "client": "acme"}}, 1},
+		{"since", Query{Since: day.Add(25 * time.Hour)}, 2},
+		{"tag and since", Query{Tags: Tags{"project": "foo"}, Since: day.Add(25 * time.Hour)}, 1},
+		{"no match", Query{Tags: Tags{"project": "baz"}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read(path, tt.q)
+			if err != nil {
+				t.Fatalf("Read() error: %v", err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("Read() returned %d sessions, want %d", len(got), tt.want)
+			}
+		})
+	}
+
+	got, _ := Read(path, Query{})
+	if got[2].Awake != 30*time.Minute || got[2].Tags["client"] != "acme" {
+		t.Errorf("round trip = %+v", got[2])
+	}
+}
+
+func TestReadMissingFile(t *testing.T) {
+	got, err := Read(filepath.Join(t.TempDir(), FileName), Query{})
+	if err != nil || got != nil {
+		t.Errorf("Read() = %v, %v; want no sessions", got, err)
+	}
+}
+
+func TestTagsSet(t *testing.T) {
+	tags := Tags{}
+	for _, s := range []string{"project=foo", "client = acme", "empty="} {
+		if err := tags.Set(s); err != nil {
+			t.Errorf("Set(%q) error: %v", s, err)
+		}
+	}
+	if got, want := tags.String(), "client=acme,empty=,project=foo"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	for _, s := range []string{"project", "=foo", ""} {
+		if err := tags.Set(s); err == nil {
+			t.Errorf("Set(%q) succeeded, want error", s)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Package install puts a downloaded keepalive binary where a user's shell
+// finds it. It is meant for Windows, where releases are a bare executable:
+// the binary is copied to the per-user programs directory, that directory
+// is added to the user's PATH and, on request, a Start Menu shortcut is
+// created. Package managers do this on macOS and Linux.
+package install
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrUnsupported is returned on systems where a package manager or the
+// install script should be used instead.
+var ErrUnsupported = errors.New("keepalive install is only available on Windows; use Homebrew or the install script on macOS and Linux")
+
+// Options selects the optional steps of Install.
+type Options struct {
+	// Shortcut creates a Start Menu shortcut that opens Keep-Alive.
+	Shortcut bool
+}
+
+// Result describes what Install did.
+type Result struct {
+	// Path is the installed binary.
+	Path string
+	// Copied is false when the binary was already installed at Path.
+	Copied bool
+	// Unblocked is set when the binary carried the mark of a download,
+	// which makes SmartScreen warn about it, and the installed copy does
+	// not.
+	Unblocked bool
+	// Dir is the directory put on the PATH; AddedToPath is false when it
+	// already was.
+	Dir         string
+	AddedToPath bool
+	// Shortcut is the Start Menu shortcut created, if any.
+	Shortcut string
+}
+
+// pathContains reports whether the semicolon-separated Windows path list
+// includes dir, ignoring case and trailing backslashes.
+func pathContains(list, dir string) bool {
+	dir = strings.TrimRight(dir, `\`)
+	for _, entry := range strings.Split(list, ";") {
+		if strings.EqualFold(strings.TrimRight(strings.TrimSpace(entry), `\`), dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendPath adds dir to the end of the Windows path list.
+func appendPath(list, dir string) string {
+	list = strings.TrimRight(list, ";")
+	if list == "" {
+		return dir
+	}
+	return list + ";" + dir
+}
+
+// psQuote quotes s as a PowerShell single-quoted string.
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
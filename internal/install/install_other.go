@@ -0,0 +1,8 @@
+//go:build !windows
+
+package install
+
+// Install is not supported on this system.
+func Install(exe string, opts Options) (Result, error) {
+	return Result{}, ErrUnsupported
+}
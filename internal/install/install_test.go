@@ -0,0 +1,41 @@
+package install
+
+import "testing"
+
+func TestPathContains(t *testing.T) {
+	dir := `C:\Users\me\AppData\Local\Programs\keepalive`
+	tests := []struct {
+		list string
+		want bool
+	}{
+		{``, false},
+		{`C:\Windows;C:\Tools`, false},
+		{`C:\Windows;` + dir, true},
+		{`C:\Windows; c:\users\ME\appdata\local\programs\KEEPALIVE\ ;C:\Tools`, true},
+		{`C:\Windows;` + dir + `2`, false},
+	}
+	for _, tt := range tests {
+		if got := pathContains(tt.list, dir); got != tt.want {
+			t.Errorf("pathContains(%q) = %v, want %v", tt.list, got, tt.want)
+		}
+	}
+}
+
+func TestAppendPath(t *testing.T) {
+	for list, want := range map[string]string{
+		``:            `C:\k`,
+		`C:\Windows`:  `C:\Windows;C:\k`,
+		`C:\Windows;`: `C:\Windows;C:\k`,
+		`C:\a;C:\b;;`: `C:\a;C:\b;C:\k`,
+	} {
+		if got := appendPath(list, `C:\k`); got != want {
+			t.Errorf("appendPath(%q) = %q, want %q", list, got, want)
+		}
+	}
+}
+
+func TestPSQuote(t *testing.T) {
+	if got, want := psQuote(`C:\Users\O'Brien`), `'C:\Users\O''Brien'`; got != want {
+		t.Errorf("psQuote() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,124 @@
+//go:build windows
+
+package install
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// binaryName is the installed executable's name.
+const binaryName = "keepalive.exe"
+
+// shortcutName is the Start Menu entry.
+const shortcutName = "Keep-Alive.lnk"
+
+// zoneIdentifier is the alternate data stream in which Windows marks files
+// downloaded from the internet; SmartScreen checks unsigned binaries that
+// carry it.
+const zoneIdentifier = ":Zone.Identifier"
+
+// Install copies exe to %LOCALAPPDATA%\Programs\keepalive, adds that
+// directory to the user's PATH and, with opts.Shortcut, creates a Start
+// Menu shortcut. Running it again from the installed binary only repairs
+// what is missing.
+func Install(exe string, opts Options) (Result, error) {
+	local := os.Getenv("LOCALAPPDATA")
+	if local == "" {
+		return Result{}, errors.New("LOCALAPPDATA is not set")
+	}
+	res := Result{Dir: filepath.Join(local, "Programs", "keepalive")}
+	res.Path = filepath.Join(res.Dir, binaryName)
+
+	if _, err := os.Stat(exe + zoneIdentifier); err == nil {
+		res.Unblocked = true
+	}
+	if strings.EqualFold(filepath.Clean(exe), filepath.Clean(res.Path)) {
+		if res.Unblocked {
+			if err := os.Remove(res.Path + zoneIdentifier); err != nil {
+				res.Unblocked = false
+			}
+		}
+	} else {
+		// The copy holds only the file's contents, so it does not carry
+		// the download mark.
+		if err := copyFile(exe, res.Path); err != nil {
+			return res, fmt.Errorf("copying to %s: %w", res.Path, err)
+		}
+		res.Copied = true
+	}
+
+	userPath, err := powershell(`[Environment]::GetEnvironmentVariable('Path', 'User')`)
+	if err != nil {
+		return res, fmt.Errorf("reading PATH: %w", err)
+	}
+	if !pathContains(userPath, res.Dir) {
+		// SetEnvironmentVariable also tells running programs, such as
+		// Explorer, that the environment changed.
+		script := fmt.Sprintf(`[Environment]::SetEnvironmentVariable('Path', %s, 'User')`, psQuote(appendPath(userPath, res.Dir)))
+		if _, err := powershell(script); err != nil {
+			return res, fmt.Errorf("adding %s to PATH: %w", res.Dir, err)
+		}
+		res.AddedToPath = true
+	}
+
+	if opts.Shortcut {
+		appData := os.Getenv("APPDATA")
+		if appData == "" {
+			return res, errors.New("APPDATA is not set")
+		}
+		lnk := filepath.Join(appData, "Microsoft", "Windows", "Start Menu", "Programs", shortcutName)
+		script := fmt.Sprintf(`$s = (New-Object -ComObject WScript.Shell).CreateShortcut(%s); $s.TargetPath = %s; $s.WorkingDirectory = %s; $s.Description = 'Keep the system awake'; $s.Save()`,
+			psQuote(lnk), psQuote(res.Path), psQuote(res.Dir))
+		if _, err := powershell(script); err != nil {
+			return res, fmt.Errorf("creating the Start Menu shortcut: %w", err)
+		}
+		res.Shortcut = lnk
+	}
+	return res, nil
+}
+
+// copyFile copies src to dst, replacing it, through a temporary file so
+// that a failed copy leaves an existing installation intact.
+func copyFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".keepalive-*.exe")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+// powershell runs script and returns its trimmed output.
+func powershell(script string) (string, error) {
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,460 @@
+//go:build linux && degraded
+
+package integration
+
+// The degraded environment matrix runs Keep-Alive against a PATH that holds
+// only fake tools and fake system and session buses, and checks which inhibitors are
+// tried, which of them hold, and what the user is told. It is the executable
+// form of the Linux fallback chain described in the README:
+//
+//	logind → loginctl, idle-inhibit protocol (Wayland) → desktop D-Bus inhibitors
+//	or sway/Hyprland IPC → gsettings (GNOME, Cosmic) → org.freedesktop.ScreenSaver
+//	→ xset (X11)
+//
+// with the desktop portal in place of the desktop inhibitors, gsettings and
+// the ScreenSaver inside a Flatpak or Snap sandbox,
+//
+// and, for activity simulation, uinput → ydotool → XTEST (X11).
+//
+// Run it with:
+//
+//	go test -tags degraded -run TestDegradedEnvironments -v ./internal/integration/
+//
+// The fakes are shell scripts that only use builtins and absolute paths, so
+// nothing from the real system leaks in through PATH. Each fake bus is a
+// private dbus-daemon, which must be installed; the test answers logind and
+// the services a scenario lists on them, and the daemon reports the rest as
+// unknown, the way a session bus without that desktop does.
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stigoleg/keep-alive/internal/keepalive"
+	"github.com/stigoleg/keep-alive/internal/platform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Bus names the fake buses can be told to answer.
+const (
+	busLogind      = "org.freedesktop.login1"
+	busGNOME       = "org.gnome.SessionManager"
+	busKDE         = "org.freedesktop.PowerManagement.Inhibit"
+	busPowerDevil  = "org.kde.Solid.PowerManagement.PolicyAgent"
+	busScreenSaver = "org.freedesktop.ScreenSaver"
+	busPortal      = "org.freedesktop.portal.Desktop"
+)
+
+// fakeTools are the scripts a scenario can put on PATH. They succeed and
+// exit; swaymsg lists a window owned by the test process, which is the one
+// Keep-Alive runs in.
+var fakeTools = map[string]string{
+	"loginctl":  "exit 0\n",
+	"gsettings": "[ \"$1\" = get ] && echo 0\nexit 0\n",
+	"xset":      "exit 0\n",
+	"ydotool":   "exit 0\n",
+	"swaymsg":   `[ "$1" = -t ] && echo "{\"id\":1,\"nodes\":[{\"id\":7,\"pid\":$PPID}]}"` + "\nexit 0\n",
+}
+
+type degradedEnv struct {
+	// tools are installed from fakeTools.
+	tools []string
+	// logind runs on the system bus and hands out inhibitor locks.
+	logind bool
+	// bus runs a session bus; without it the bus is down.
+	bus bool
+	// services are the bus names the fake bus answers.
+	services []string
+	desktop  string
+	// session is "wayland", "x11" or empty for a headless session.
+	session string
+	// flatpak runs Keep-Alive as if inside a Flatpak sandbox.
+	flatpak bool
+}
+
+type degradedWant struct {
+	inhibitors []string
+	// active lists the inhibitors that hold; none means Start must fail.
+	active []string
+	// failed are inhibitors the start error must report.
+	failed          []string
+	simulation      string
+	simulationHint  string
+	missingDeps     []string
+	sleepPrevention bool
+}
+
+func TestDegradedEnvironments(t *testing.T) {
+	tests := []struct {
+		name string
+		env  degradedEnv
+		want degradedWant
+	}{
+		{
+			name: "nothing_installed",
+			env:  degradedEnv{},
+			want: degradedWant{
+				inhibitors:     []string{"logind", "dbus-freedesktop"},
+				failed:         []string{"logind: connect to system bus", "dbus-freedesktop: dbus call failed"},
+				simulationHint: "Configure uinput permissions or install ydotool.",
+				missingDeps:    []string{"ydotool"},
+			},
+		},
+		{
+			name: "headless_logind_only",
+			env:  degradedEnv{logind: true},
+			want: degradedWant{
+				inhibitors:      []string{"logind", "dbus-freedesktop"},
+				active:          []string{"logind"},
+				simulationHint:  "Configure uinput permissions or install ydotool.",
+				missingDeps:     []string{"ydotool"},
+				sleepPrevention: true,
+			},
+		},
+		{
+			name: "gnome_wayland_without_logind",
+			env: degradedEnv{
+				tools:    []string{"loginctl", "gsettings"},
+				bus:      true,
+				services: []string{busGNOME, busScreenSaver},
+				desktop:  "GNOME",
+				session:  "wayland",
+			},
+			want: degradedWant{
+				inhibitors:      []string{"logind", "loginctl", "wayland-idle-inhibit", "dbus-gnome-suspend", "dbus-gnome-idle", "gsettings", "dbus-freedesktop"},
+				active:          []string{"loginctl", "dbus-gnome-suspend", "dbus-gnome-idle", "gsettings", "dbus-freedesktop"},
+				simulationHint:  "Configure uinput permissions or install ydotool.",
+				missingDeps:     []string{"ydotool"},
+				sleepPrevention: true,
+			},
+		},
+		{
+			name: "gnome_wayland_bus_down",
+			env: degradedEnv{
+				logind:  true,
+				desktop: "GNOME",
+				session: "wayland",
+			},
+			want: degradedWant{
+				inhibitors:      []string{"logind", "wayland-idle-inhibit", "dbus-gnome-suspend", "dbus-gnome-idle", "gsettings", "dbus-freedesktop"},
+				active:          []string{"logind"},
+				simulationHint:  "Configure uinput permissions or install ydotool.",
+				missingDeps:     []string{"ydotool"},
+				sleepPrevention: true,
+			},
+		},
+		{
+			name: "flatpak_gnome_wayland_portal",
+			env: degradedEnv{
+				bus:      true,
+				services: []string{busGNOME, busPortal},
+				desktop:  "GNOME",
+				session:  "wayland",
+				flatpak:  true,
+			},
+			want: degradedWant{
+				inhibitors:      []string{"logind", "wayland-idle-inhibit", "portal"},
+				active:          []string{"portal"},
+				simulationHint:  "Configure uinput permissions or install ydotool.",
+				missingDeps:     []string{"ydotool"},
+				sleepPrevention: true,
+			},
+		},
+		{
+			name: "sway_without_logind_or_bus",
+			env: degradedEnv{
+				tools:   []string{"swaymsg"},
+				desktop: "sway",
+				session: "wayland",
+			},
+			want: degradedWant{
+				inhibitors:     []string{"logind", "wayland-idle-inhibit", "sway", "dbus-freedesktop"},
+				active:         []string{"sway"},
+				simulationHint: "Configure uinput permissions or install ydotool.",
+				missingDeps:    []string{"ydotool"},
+			},
+		},
+		{
+			name: "cosmic_wayland_with_ydotool",
+			env: degradedEnv{
+				tools:    []string{"ydotool"},
+				bus:      true,
+				services: []string{busGNOME},
+				desktop:  "pop:COSMIC",
+				session:  "wayland",
+			},
+			want: degradedWant{
+				inhibitors:      []string{"logind", "wayland-idle-inhibit", "dbus-cosmic-suspend", "dbus-cosmic-idle", "gsettings", "dbus-freedesktop"},
+				active:          []string{"dbus-cosmic-suspend", "dbus-cosmic-idle"},
+				simulation:      "ydotool",
+				sleepPrevention: true,
+			},
+		},
+		{
+			name: "kde_x11",
+			env: degradedEnv{
+				tools:    []string{"xset", "ydotool"},
+				bus:      true,
+				services: []string{busPowerDevil, busKDE, busScreenSaver},
+				desktop:  "KDE",
+				session:  "x11",
+			},
+			want: degradedWant{
+				inhibitors:      []string{"logind", "dbus-kde-powerdevil", "dbus-kde", "dbus-freedesktop", "xset"},
+				active:          []string{"dbus-kde-powerdevil", "dbus-kde", "dbus-freedesktop", "xset"},
+				simulation:      "ydotool",
+				sleepPrevention: true,
+			},
+		},
+		{
+			name: "xfce_x11_bus_down_xset_only",
+			env: degradedEnv{
+				tools:   []string{"xset"},
+				desktop: "XFCE",
+				session: "x11",
+			},
+			want: degradedWant{
+				inhibitors:      []string{"logind", "dbus-xfce", "dbus-freedesktop", "xset"},
+				active:          []string{"xset"},
+				simulationHint:  "On X11, the X server's XTEST extension is also supported.",
+				missingDeps:     []string{"ydotool"},
+				sleepPrevention: true,
+			},
+		},
+		{
+			name: "mate_x11_session_manager_missing",
+			env: degradedEnv{
+				bus:      true,
+				services: []string{busScreenSaver},
+				desktop:  "MATE",
+				session:  "x11",
+			},
+			want: degradedWant{
+				inhibitors:      []string{"logind", "dbus-mate", "dbus-freedesktop", "xset"},
+				active:          []string{"dbus-freedesktop"},
+				simulationHint:  "On X11, the X server's XTEST extension is also supported.",
+				missingDeps:     []string{"ydotool"},
+				sleepPrevention: true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupDegradedEnv(t, tt.env)
+
+			diag := platform.Diagnose()
+			if diag.Tools["uinput"] {
+				t.Skip("uinput is usable here, so the simulation fallback cannot be exercised")
+			}
+			assert.Equal(t, tt.want.inhibitors, diag.Inhibitors, "inhibitors should be tried in the documented order")
+			assert.Equal(t, tt.want.sleepPrevention, diag.SleepPrevention)
+
+			sim := platform.GetActivitySimulationStatus()
+			if tt.want.simulation != "" {
+				assert.True(t, sim.Available, "activity simulation should be available")
+				assert.Equal(t, tt.want.simulation, sim.Method)
+				assert.Equal(t, []string{tt.want.simulation}, diag.SimulationMethods)
+			} else {
+				assert.False(t, sim.Available, "activity simulation should be unavailable")
+				assert.Contains(t, sim.Message, "Active status simulation is unavailable")
+				assert.Contains(t, sim.Message, tt.want.simulationHint)
+				assert.Empty(t, diag.SimulationMethods)
+			}
+
+			var missing []string
+			for _, dep := range diag.MissingDependencies {
+				missing = append(missing, dep.Name)
+			}
+			assert.Equal(t, tt.want.missingDeps, missing)
+			deps := platform.GetDependencyMessage()
+			if len(tt.want.missingDeps) == 0 {
+				assert.Empty(t, deps)
+			}
+			for i, name := range tt.want.missingDeps {
+				assert.Contains(t, deps, fmt.Sprintf("%d. %s\n", i+1, name))
+			}
+
+			k := keepalive.New()
+			err := k.StartIndefinite()
+			if len(tt.want.active) == 0 {
+				require.Error(t, err, "start should fail when no inhibitor holds")
+				assert.Contains(t, err.Error(), "linux: no keep-alive method successfully activated")
+				assert.Contains(t, err.Error(), "Troubleshooting:")
+				for _, f := range tt.want.failed {
+					assert.Contains(t, err.Error(), f)
+				}
+				assert.False(t, k.IsRunning())
+				return
+			}
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = k.Stop() })
+			assert.Equal(t, tt.want.active, k.Status().Methods, "every inhibitor that can hold should be active")
+		})
+	}
+}
+
+// setupDegradedEnv points PATH at a directory holding only the scenario's
+// fake tools and describes the session through the environment.
+func setupDegradedEnv(t *testing.T, env degradedEnv) {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range env.tools {
+		body, ok := fakeTools[name]
+		require.True(t, ok, "no fake for %s", name)
+		writeFakeTool(t, dir, name, body)
+	}
+	// No real bus is reachable, even when the scenario has no bus at all.
+	systemBus := "unix:path=" + filepath.Join(dir, "no-system-bus")
+	if env.logind {
+		systemBus = startFakeBus(t, dir, "system", []string{busLogind})
+	}
+	sessionBus := "unix:path=" + filepath.Join(dir, "no-session-bus")
+	if env.bus {
+		sessionBus = startFakeBus(t, dir, "session", env.services)
+	}
+
+	t.Setenv("PATH", dir)
+	t.Setenv("DBUS_SYSTEM_BUS_ADDRESS", systemBus)
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", sessionBus)
+	t.Setenv("XDG_CURRENT_DESKTOP", env.desktop)
+	t.Setenv("DESKTOP_SESSION", "")
+	t.Setenv("XDG_SESSION_TYPE", env.session)
+	t.Setenv("FLATPAK_ID", "")
+	t.Setenv("SWAYSOCK", "")
+	t.Setenv("HYPRLAND_INSTANCE_SIGNATURE", "")
+	t.Setenv("SNAP", "")
+	if env.flatpak {
+		t.Setenv("FLATPAK_ID", "io.github.stigoleg.KeepAlive")
+	}
+	// No compositor answers on the Wayland socket.
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+	t.Setenv("WAYLAND_DISPLAY", "")
+	t.Setenv("DISPLAY", "")
+	switch env.session {
+	case "wayland":
+		t.Setenv("WAYLAND_DISPLAY", "wayland-0")
+	case "x11":
+		// No X server answers on this socket.
+		t.Setenv("DISPLAY", filepath.Join(dir, "x11")+":0")
+	}
+}
+
+// fakeBusConfig configures a bus that starts no services on demand, so
+// only the names a scenario answers exist on it.
+const fakeBusConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>%s</type>
+  <listen>unix:path=%s</listen>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>
+`
+
+// fakeService is the object and interface a service inhibits through, and
+// its methods.
+type fakeService struct {
+	path    dbus.ObjectPath
+	iface   string
+	methods map[string]interface{}
+}
+
+// fakeServices returns the services a fake bus can answer. The descriptors
+// the fake logind hands out stay open until the test ends.
+func fakeServices(t *testing.T) map[string]fakeService {
+	return map[string]fakeService{
+		busLogind: {"/org/freedesktop/login1", busLogind + ".Manager", map[string]interface{}{
+			"Inhibit": func(what, who, why, mode string) (dbus.UnixFD, *dbus.Error) {
+				r, w, err := os.Pipe()
+				if err != nil {
+					return -1, dbus.MakeFailedError(err)
+				}
+				t.Cleanup(func() {
+					_ = r.Close()
+					_ = w.Close()
+				})
+				return dbus.UnixFD(w.Fd()), nil
+			},
+		}},
+		busGNOME: {"/org/gnome/SessionManager", busGNOME, map[string]interface{}{
+			"Inhibit": func(app string, xid uint32, reason string, flags uint32) (uint32, *dbus.Error) {
+				return 42, nil
+			},
+			"Uninhibit": func(cookie uint32) *dbus.Error { return nil },
+		}},
+		busKDE: {"/org/freedesktop/PowerManagement/Inhibit", busKDE, map[string]interface{}{
+			"Inhibit":   func(app, reason string) (uint32, *dbus.Error) { return 42, nil },
+			"UnInhibit": func(cookie uint32) *dbus.Error { return nil },
+		}},
+		busPowerDevil: {"/org/kde/Solid/PowerManagement/PolicyAgent", busPowerDevil, map[string]interface{}{
+			"AddInhibition":     func(types uint32, app, reason string) (uint32, *dbus.Error) { return 42, nil },
+			"ReleaseInhibition": func(cookie uint32) *dbus.Error { return nil },
+		}},
+		busScreenSaver: {"/org/freedesktop/ScreenSaver", busScreenSaver, map[string]interface{}{
+			"Inhibit":   func(app, reason string) (uint32, *dbus.Error) { return 42, nil },
+			"UnInhibit": func(cookie uint32) *dbus.Error { return nil },
+		}},
+		busPortal: {"/org/freedesktop/portal/desktop", "org.freedesktop.portal.Inhibit", map[string]interface{}{
+			"Inhibit": func(window string, flags uint32, options map[string]dbus.Variant) (dbus.ObjectPath, *dbus.Error) {
+				return "/org/freedesktop/portal/desktop/request/1_42/keepalive", nil
+			},
+		}},
+	}
+}
+
+// startFakeBus starts a private dbus-daemon of the given type, "system" or
+// "session", with its socket in dir, answers the given services on it and
+// returns its address.
+func startFakeBus(t *testing.T, dir, busType string, services []string) string {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon is not installed")
+	}
+	config := filepath.Join(dir, busType+".conf")
+	socket := filepath.Join(dir, busType+"-bus")
+	require.NoError(t, os.WriteFile(config, []byte(fmt.Sprintf(fakeBusConfig, busType, socket)), 0o644))
+
+	cmd := exec.Command(daemon, "--config-file="+config, "--nofork", "--print-address=1")
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	require.NoError(t, err, "dbus-daemon did not print its address")
+	address := strings.TrimSpace(line)
+
+	conn, err := dbus.Connect(address)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	fakes := fakeServices(t)
+	for _, name := range services {
+		svc, ok := fakes[name]
+		require.True(t, ok, "no fake for %s", name)
+		require.NoError(t, conn.ExportMethodTable(svc.methods, svc.path, svc.iface))
+		reply, err := conn.RequestName(name, dbus.NameFlagDoNotQueue)
+		require.NoError(t, err)
+		require.Equal(t, dbus.RequestNameReplyPrimaryOwner, reply)
+	}
+	return address
+}
+
+func writeFakeTool(t *testing.T, dir, name, body string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0o755))
+}
@@ -0,0 +1,169 @@
+package keepalive
+
+import (
+	"sync"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/crash"
+	"github.com/stigoleg/keep-alive/internal/platform"
+)
+
+// getBrightness and setBrightness are replaced in tests.
+var (
+	getBrightness = platform.GetBrightness
+	setBrightness = platform.SetBrightness
+)
+
+// SetDimLevel dims the display to percent while a session keeps the system
+// awake and restores the previous brightness when it ends. Zero disables
+// dimming. Changes apply immediately to a running session.
+func (k *Keeper) SetDimLevel(percent int) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.dimLevel = percent
+	if !k.running || k.suspended {
+		return
+	}
+	if percent == 0 {
+		k.restoreBrightnessLocked()
+		return
+	}
+	k.dimLocked()
+}
+
+// DimLevel returns the configured dim level in percent, or zero when
+// dimming is disabled.
+func (k *Keeper) DimLevel() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.dimLevel
+}
+
+// brightnessControl applies the dimming a session asks for. Reading or
+// changing the brightness runs a helper command that may hang, so it is done
+// by a worker of its own rather than under k.mu.
+type brightnessControl struct {
+	mu sync.Mutex
+	// want is the dim level to hold, or zero to restore the display;
+	// applied is the level the worker last acted on.
+	want, applied int
+	// idle is closed once the worker has caught up with want; it is nil
+	// while no worker runs.
+	idle chan struct{}
+
+	// dimmed and saved, the brightness to restore, belong to the worker.
+	dimmed bool
+	saved  int
+}
+
+// dimLocked lowers the display brightness to the dim level. A display that is
+// already at or below the level is left alone. Callers must hold k.mu.
+func (k *Keeper) dimLocked() {
+	k.requestBrightness(k.dimLevel)
+}
+
+// restoreBrightnessLocked undoes dimLocked. Callers must hold k.mu.
+func (k *Keeper) restoreBrightnessLocked() {
+	k.requestBrightness(0)
+}
+
+// requestBrightness asks the worker to hold level, starting it if it is not
+// running.
+func (k *Keeper) requestBrightness(level int) {
+	b := &k.brightness
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.want = level
+	if b.idle == nil && b.want != b.applied {
+		b.idle = make(chan struct{})
+		go k.applyBrightness()
+	}
+}
+
+// waitBrightness waits until the requested brightness has been applied, or
+// at most timeout when it is positive.
+func (k *Keeper) waitBrightness(timeout time.Duration) {
+	k.brightness.mu.Lock()
+	idle := k.brightness.idle
+	k.brightness.mu.Unlock()
+	if idle == nil {
+		return
+	}
+	if timeout <= 0 {
+		<-idle
+		return
+	}
+	select {
+	case <-idle:
+	case <-time.After(timeout):
+	}
+}
+
+// applyBrightness applies requested levels until it has caught up.
+func (k *Keeper) applyBrightness() {
+	defer crash.Guard("brightness")
+
+	b := &k.brightness
+	for {
+		b.mu.Lock()
+		want := b.want
+		if want == b.applied {
+			close(b.idle)
+			b.idle = nil
+			b.mu.Unlock()
+			return
+		}
+		b.mu.Unlock()
+
+		if want == 0 {
+			k.restoreBrightness()
+		} else {
+			k.dim(want)
+		}
+
+		b.mu.Lock()
+		b.applied = want
+		b.mu.Unlock()
+	}
+}
+
+// dim lowers the display brightness to level.
+func (k *Keeper) dim(level int) {
+	b := &k.brightness
+	if !b.dimmed {
+		current, err := getBrightness()
+		if err != nil {
+			k.logger().Warn("brightness unavailable, not dimming", "err", err)
+			return
+		}
+		if current <= level {
+			return
+		}
+		b.saved = current
+	} else if level >= b.saved {
+		k.restoreBrightness()
+		return
+	}
+
+	if err := setBrightness(level); err != nil {
+		k.logger().Warn("dim failed", "err", err)
+		return
+	}
+	b.dimmed = true
+	k.logger().Info("display dimmed", "percent", level, "was", b.saved)
+}
+
+// restoreBrightness undoes dim.
+func (k *Keeper) restoreBrightness() {
+	b := &k.brightness
+	if !b.dimmed {
+		return
+	}
+	b.dimmed = false
+	if err := setBrightness(b.saved); err != nil {
+		k.logger().Warn("brightness restore failed", "err", err)
+		return
+	}
+	k.logger().Info("display brightness restored", "percent", b.saved)
+}
@@ -0,0 +1,96 @@
+package keepalive
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/crash"
+	"github.com/stigoleg/keep-alive/internal/util"
+)
+
+// CommandPollInterval is how often the command of a CommandCondition is run
+// by the command line's --while-cmd.
+const CommandPollInterval = 5 * time.Second
+
+// conditionTimeout bounds how long a CommandCondition check may run.
+const conditionTimeout = 30 * time.Second
+
+// StartWhile starts keeping the system alive while cond returns true,
+// checking it every poll. The session stops on its own once cond returns
+// false, and StartWhile fails if it does so already.
+func (k *Keeper) StartWhile(cond func() bool, poll time.Duration) error {
+	return k.StartWhileContext(context.Background(), cond, poll)
+}
+
+// StartWhileContext is StartWhile that also stops the session when ctx is
+// done.
+func (k *Keeper) StartWhileContext(ctx context.Context, cond func() bool, poll time.Duration) error {
+	if cond == nil {
+		return errors.New("condition must not be nil")
+	}
+	if poll <= 0 {
+		return errors.New("poll interval must be positive")
+	}
+	if !cond() {
+		return errors.New("condition does not hold")
+	}
+	return k.startIndefinite(ctx, cond, poll)
+}
+
+// watchCondition polls cond every poll until sessionCtx is done, stopping
+// the session once cond returns false.
+func (k *Keeper) watchCondition(sessionCtx context.Context, cond func() bool, poll time.Duration) {
+	defer crash.Guard("condition-watch")
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-sessionCtx.Done():
+			return
+		case <-ticker.C:
+		}
+		if cond() {
+			continue
+		}
+
+		k.mu.Lock()
+		stillCurrent := sessionCtx.Err() == nil && k.running && k.ctx == sessionCtx
+		k.mu.Unlock()
+		if stillCurrent {
+			k.logger().Info("condition no longer holds, stopping")
+			k.Stop()
+		}
+		return
+	}
+}
+
+// CommandCondition returns a condition for StartWhile that holds while
+// command, run through the system shell, exits with status zero. A command
+// that cannot be run or times out is logged and treated as still holding, so
+// that a transient failure does not end the session.
+func CommandCondition(command string) func() bool {
+	return func() bool {
+		ctx, cancel := context.WithTimeout(context.Background(), conditionTimeout)
+		defer cancel()
+
+		cmd := util.ShellCommand(ctx, command)
+		cmd.WaitDelay = time.Second
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			logger().Warn("condition command timed out", "command", command, "timeout", conditionTimeout)
+			return true
+		case errors.As(err, &exitErr):
+			return false
+		case err != nil:
+			logger().Warn("cannot run condition command", "command", command, "err", err)
+			return true
+		}
+		return true
+	}
+}
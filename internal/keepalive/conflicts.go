@@ -0,0 +1,41 @@
+package keepalive
+
+import (
+	"fmt"
+
+	"github.com/stigoleg/keep-alive/internal/platform"
+)
+
+// detectCompetitors is replaced in tests.
+var detectCompetitors = platform.DetectCompetitors
+
+// SetIgnoreConflicts lets sessions simulate activity even while another
+// tool that moves the mouse or presses keys is running.
+func (k *Keeper) SetIgnoreConflicts(ignore bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.ignoreConflicts = ignore
+}
+
+// checkCompetitorsLocked looks for other keep-awake and jiggler tools before
+// a session starts. They are logged; one that simulates input refuses a
+// session that would simulate activity too, so the two do not compound
+// cursor movement, unless conflicts are ignored. Callers must hold k.mu.
+func (k *Keeper) checkCompetitorsLocked() error {
+	running := detectCompetitors()
+	if len(running) == 0 {
+		return nil
+	}
+	var simulating []platform.Competitor
+	for _, c := range running {
+		if c.Simulates {
+			simulating = append(simulating, c)
+		}
+	}
+	if k.simulateActivity && len(simulating) > 0 && !k.ignoreConflicts {
+		return fmt.Errorf("%s is already simulating input; quit it, turn off activity simulation, or pass --ignore-conflicts",
+			platform.CompetitorNames(simulating))
+	}
+	k.logger().Warn("other keep-awake tools are running", "tools", platform.CompetitorNames(running))
+	return nil
+}
@@ -0,0 +1,50 @@
+package keepalive
+
+import "sync/atomic"
+
+// Counters tallies what happened during a session, for working out why a
+// machine slept anyway.
+type Counters struct {
+	// Activations counts the times the platform keep-alive was started,
+	// including after a suspension and by the watchdog.
+	Activations int64 `json:"activations"`
+	// Reactivations counts recoveries from failure: inhibitors the platform
+	// restored on its own and restarts by the watchdog.
+	Reactivations int64 `json:"reactivations"`
+	// Simulations counts the times simulated input moved the pointer.
+	Simulations int64 `json:"simulations"`
+	// IdleSkips counts the checks at which activity simulation held off
+	// because the user was using the machine.
+	IdleSkips int64 `json:"idle_skips"`
+	// InhibitorFailures counts the sleep inhibitors that stopped working.
+	InhibitorFailures int64 `json:"inhibitor_failures"`
+}
+
+// sessionCounters holds a session's Counters. They are updated by platform
+// reports, which may arrive while k.mu is held elsewhere, so they have no
+// lock of their own.
+type sessionCounters struct {
+	activations       atomic.Int64
+	reactivations     atomic.Int64
+	simulations       atomic.Int64
+	idleSkips         atomic.Int64
+	inhibitorFailures atomic.Int64
+}
+
+func (c *sessionCounters) snapshot() Counters {
+	return Counters{
+		Activations:       c.activations.Load(),
+		Reactivations:     c.reactivations.Load(),
+		Simulations:       c.simulations.Load(),
+		IdleSkips:         c.idleSkips.Load(),
+		InhibitorFailures: c.inhibitorFailures.Load(),
+	}
+}
+
+func (c *sessionCounters) reset() {
+	c.activations.Store(0)
+	c.reactivations.Store(0)
+	c.simulations.Store(0)
+	c.idleSkips.Store(0)
+	c.inhibitorFailures.Store(0)
+}
@@ -0,0 +1,218 @@
+package keepalive
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/schema"
+)
+
+// eventBuffer is how many events a subscriber may fall behind by; further
+// events are dropped for it until it catches up.
+const eventBuffer = 16
+
+// EventType identifies a change in a Keeper's state.
+type EventType int
+
+const (
+	// EventStarted is sent when a session starts.
+	EventStarted EventType = iota + 1
+	// EventStopped is sent when a session is stopped before its end.
+	EventStopped
+	// EventExpired is sent when a timed session reaches its end.
+	EventExpired
+	// EventInhibitorFailed is sent when a sleep inhibitor stops working.
+	// The platform tries to restore it on its own.
+	EventInhibitorFailed
+	// EventSimulationPerformed is sent each time simulated input moves the
+	// pointer.
+	EventSimulationPerformed
+	// EventPlatformRestarted is sent when the watchdog found the platform
+	// keep-alive no longer holding the system awake and restarted it. Err
+	// says what was wrong.
+	EventPlatformRestarted
+	// EventSimulationChanged is sent when activity simulation is turned on
+	// or off, or its interval or method is changed, during a session.
+	EventSimulationChanged
+	// EventSleepAttempted is sent when the system tries to sleep during a
+	// session. Blocked says whether the platform kept it awake. Only macOS
+	// reports these.
+	EventSleepAttempted
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventStarted:
+		return "started"
+	case EventStopped:
+		return "stopped"
+	case EventExpired:
+		return "expired"
+	case EventInhibitorFailed:
+		return "inhibitor_failed"
+	case EventSimulationPerformed:
+		return "simulation_performed"
+	case EventPlatformRestarted:
+		return "platform_restarted"
+	case EventSimulationChanged:
+		return "simulation_changed"
+	case EventSleepAttempted:
+		return "sleep_attempted"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes a change in a Keeper's state.
+type Event struct {
+	Type EventType
+	Time time.Time
+	// Inhibitor names the inhibitor that failed, and Err why, for
+	// EventInhibitorFailed. Err also says why the platform keep-alive was
+	// restarted for EventPlatformRestarted.
+	Inhibitor string
+	Err       error
+	// Method names the input backend for EventSimulationPerformed, and the
+	// selected one, or "auto", for EventSimulationChanged.
+	Method string
+	// SimulateActivity and Interval are the activity-simulation settings
+	// in effect after an EventSimulationChanged.
+	SimulateActivity bool
+	Interval         time.Duration
+	// Blocked is set for EventSleepAttempted when the attempt was vetoed.
+	Blocked bool
+}
+
+// eventJSON is the JSON form of Event, described by the "event" schema.
+type eventJSON struct {
+	SchemaVersion int       `json:"schema_version"`
+	Type          string    `json:"type"`
+	Time          time.Time `json:"time"`
+	Inhibitor     string    `json:"inhibitor,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	Method        string    `json:"method,omitempty"`
+	// SimulateActivity and IntervalSeconds are only set for
+	// simulation_changed.
+	SimulateActivity *bool   `json:"simulate_activity,omitempty"`
+	IntervalSeconds  float64 `json:"interval_seconds,omitempty"`
+	// Blocked is only set for sleep_attempted.
+	Blocked *bool `json:"blocked,omitempty"`
+}
+
+// MarshalJSON encodes e as described by the "event" schema, with its type
+// by name and its error as a message.
+func (e Event) MarshalJSON() ([]byte, error) {
+	doc := eventJSON{
+		SchemaVersion: schema.EventVersion,
+		Type:          e.Type.String(),
+		Time:          e.Time,
+		Inhibitor:     e.Inhibitor,
+		Method:        e.Method,
+	}
+	if e.Err != nil {
+		doc.Error = e.Err.Error()
+	}
+	if e.Type == EventSimulationChanged {
+		simulate := e.SimulateActivity
+		doc.SimulateActivity = &simulate
+		doc.IntervalSeconds = e.Interval.Seconds()
+	}
+	if e.Type == EventSleepAttempted {
+		blocked := e.Blocked
+		doc.Blocked = &blocked
+	}
+	return json.Marshal(doc)
+}
+
+// events fans Keeper events out to subscribers. It has its own lock because
+// platform keep-alives report while holding theirs, which the Keeper may be
+// waiting on under k.mu.
+type events struct {
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+// Subscribe returns a channel of the Keeper's events and a function that
+// ends the subscription and closes the channel. Events are never waited on:
+// a subscriber that falls behind misses them.
+func (k *Keeper) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, eventBuffer)
+	k.events.mu.Lock()
+	if k.events.subs == nil {
+		k.events.subs = make(map[chan Event]struct{})
+	}
+	k.events.subs[ch] = struct{}{}
+	k.events.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			k.events.mu.Lock()
+			delete(k.events.subs, ch)
+			k.events.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// emit sends e to every subscriber that has room for it.
+func (k *Keeper) emit(e Event) {
+	if e.Time.IsZero() {
+		e.Time = now()
+	}
+	k.events.mu.Lock()
+	defer k.events.mu.Unlock()
+	for ch := range k.events.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// keeperObserver turns platform reports into Keeper events.
+type keeperObserver struct {
+	k *Keeper
+}
+
+func (o keeperObserver) InhibitorFailed(name string, err error) {
+	o.k.counters.inhibitorFailures.Add(1)
+	o.k.emit(Event{Type: EventInhibitorFailed, Inhibitor: name, Err: err})
+}
+
+func (o keeperObserver) InhibitorRestored(string) {
+	o.k.counters.reactivations.Add(1)
+}
+
+func (o keeperObserver) SimulationSkipped() {
+	o.k.counters.idleSkips.Add(1)
+}
+
+func (o keeperObserver) SimulationPerformed(method string) {
+	o.k.counters.simulations.Add(1)
+	t := now()
+	o.k.activeMethod.Store(method)
+	o.k.lastSimulation.Store(t)
+	o.k.emit(Event{Type: EventSimulationPerformed, Time: t, Method: method})
+}
+
+func (o keeperObserver) SleepAttempted(t time.Time, blocked bool) {
+	o.k.lastSleepAttempt.Store(SleepAttempt{Time: t, Blocked: blocked})
+	o.k.emit(Event{Type: EventSleepAttempted, Time: t, Blocked: blocked})
+}
+
+// SleepAttempt is an attempt by the system to sleep during a session.
+type SleepAttempt struct {
+	Time time.Time
+	// Blocked is set when the platform kept the system awake.
+	Blocked bool
+}
+
+// LastSleepAttempt returns the system's last attempt to sleep during the
+// running session, or false if it has made none or the platform does not
+// report them.
+func (k *Keeper) LastSleepAttempt() (SleepAttempt, bool) {
+	a, _ := k.lastSleepAttempt.Load().(SleepAttempt)
+	return a, !a.Time.IsZero()
+}
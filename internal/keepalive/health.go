@@ -0,0 +1,102 @@
+package keepalive
+
+import (
+	"context"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/crash"
+	"github.com/stigoleg/keep-alive/internal/platform"
+)
+
+// healthCheckInterval is how often the watchdog asks the platform
+// keep-alive whether it still holds the system awake. It is replaced in
+// tests.
+var healthCheckInterval = 30 * time.Second
+
+// startHealthWatchLocked starts the watchdog for the current session if the
+// platform keep-alive can report its health. Callers must hold k.mu.
+func (k *Keeper) startHealthWatchLocked() {
+	if _, ok := k.keeper.(platform.HealthCheckingKeepAlive); !ok {
+		return
+	}
+	go k.watchHealth(k.ctx)
+}
+
+// watchHealth checks the platform keep-alive until the session in
+// sessionCtx ends.
+func (k *Keeper) watchHealth(sessionCtx context.Context) {
+	defer crash.Guard("health-watch")
+
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-sessionCtx.Done():
+			return
+		case <-ticker.C:
+		}
+		k.checkHealth(sessionCtx)
+	}
+}
+
+// checkHealth restarts the platform keep-alive of the session in sessionCtx
+// if it no longer holds the system awake, for example because caffeinate
+// was killed or systemd-inhibit exited. A suspended session is left alone.
+// A failed restart is tried again on the next check.
+//
+// The restart runs without k.mu, since stopping the platform keep-alive can
+// wait up to its stop timeout. k.switching keeps suspensions out until it
+// is done, and the session is checked again afterwards in case it ended
+// meanwhile.
+func (k *Keeper) checkHealth(sessionCtx context.Context) {
+	k.switching.Lock()
+	defer k.switching.Unlock()
+
+	k.mu.Lock()
+	if sessionCtx.Err() != nil || !k.running || k.ctx != sessionCtx || k.suspended {
+		k.mu.Unlock()
+		return
+	}
+	hc, ok := k.keeper.(platform.HealthCheckingKeepAlive)
+	if !ok {
+		k.mu.Unlock()
+		return
+	}
+	cause := hc.Healthy()
+	if cause == nil {
+		k.mu.Unlock()
+		return
+	}
+	k.configureKeeperLocked()
+	keeper := k.keeper
+	k.mu.Unlock()
+
+	k.logger().Warn("platform keep-alive failed, restarting", "err", cause)
+	if err := keeper.Stop(); err != nil {
+		k.logger().Debug("stopping failed platform keep-alive", "err", err)
+	}
+	startErr := keeper.Start(sessionCtx)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if sessionCtx.Err() != nil || !k.running || k.ctx != sessionCtx || k.suspended {
+		// The session ended or was suspended while restarting; its own
+		// Stop may have run before this Start, so undo the restart unless
+		// a new session has taken the platform keep-alive over.
+		if startErr == nil && (!k.running || k.suspended) {
+			if err := keeper.Stop(); err != nil {
+				k.logger().Debug("stopping restarted platform keep-alive", "err", err)
+			}
+		}
+		return
+	}
+	if startErr != nil {
+		k.logger().Error("restarting platform keep-alive failed", "err", startErr)
+		return
+	}
+	k.counters.activations.Add(1)
+	k.counters.reactivations.Add(1)
+	k.logger().Info("platform keep-alive restarted")
+	k.emit(Event{Type: EventPlatformRestarted, Err: cause})
+}
@@ -0,0 +1,52 @@
+package keepalive
+
+import (
+	"maps"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/history"
+)
+
+// SetHistory records finished sessions in the history file at path. An empty
+// path disables it.
+func (k *Keeper) SetHistory(path string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.historyPath = path
+}
+
+// SetTags labels sessions in the history. A running session is recorded with
+// the tags set when it ends.
+func (k *Keeper) SetTags(tags history.Tags) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.tags = maps.Clone(tags)
+}
+
+// historyRecordLocked describes the running session as it ends. Callers must
+// hold k.mu.
+func (k *Keeper) historyRecordLocked() history.Session {
+	end := time.Now()
+	suspended := k.suspendedFor
+	if k.suspended && !k.suspendedAt.IsZero() {
+		suspended += now().Sub(k.suspendedAt)
+	}
+	total := end.Sub(k.startTime)
+	awake := min(max(total-suspended, 0), total)
+	return history.Session{
+		Start: k.startTime,
+		End:   end,
+		Awake: awake.Round(time.Second),
+		Tags:  maps.Clone(k.tags),
+	}
+}
+
+// appendHistory records s in the history file at path, if any.
+func appendHistory(path string, s history.Session) {
+	if path == "" {
+		return
+	}
+	if err := history.Append(path, s); err != nil {
+		logger().Warn("session not recorded in history", "path", path, "err", err)
+	}
+}
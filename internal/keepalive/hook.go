@@ -0,0 +1,89 @@
+package keepalive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/util"
+)
+
+// hookTimeout bounds how long a hook command may run before it is killed.
+const hookTimeout = time.Minute
+
+// execHook is replaced in tests.
+var execHook = runHook
+
+// runHook runs command through the system shell, logging its output, and
+// kills it once timeout elapses.
+func runHook(command string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := util.ShellCommand(ctx, command)
+
+	// A shell killed on timeout can leave children holding the output pipe;
+	// stop waiting for them shortly after.
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	out, err := cmd.CombinedOutput()
+	if output := strings.TrimSpace(string(out)); output != "" {
+		logger().Info("hook output", "command", command, "output", output)
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("hook %q timed out after %s", command, timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("hook %q failed: %v", command, err)
+	}
+	logger().Info("hook finished", "command", command, "elapsed", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// SetOnExpire sets a shell command to run when a timed session reaches its
+// end. The empty string disables the hook. Sessions ended by Stop, or by a
+// battery threshold, do not run it.
+func (k *Keeper) SetOnExpire(command string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.onExpire = command
+}
+
+// OnExpire returns the on-expire hook command.
+func (k *Keeper) OnExpire() string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.onExpire
+}
+
+// Expire ends a timed session because its time is up and runs the on-expire
+// hook. Callers whose own countdown finished use it instead of Stop; whichever
+// of them and the stop timer comes first runs the hook, and the others wait
+// for it to finish so that the process does not exit under it.
+func (k *Keeper) Expire() error {
+	k.mu.Lock()
+	if !k.running || k.endTime.IsZero() || k.expiring {
+		k.mu.Unlock()
+		k.hooks.Wait()
+		return nil
+	}
+	k.expiring = true
+	hook := k.onExpire
+	if hook != "" {
+		k.hooks.Add(1)
+	}
+	k.mu.Unlock()
+
+	err := k.Stop()
+	if hook != "" {
+		defer k.hooks.Done()
+		k.logger().Info("session expired, running on-expire hook")
+		if herr := execHook(hook, hookTimeout); herr != nil {
+			k.logger().Error("on-expire hook failed", "err", herr)
+		}
+	}
+	return err
+}
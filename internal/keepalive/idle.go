@@ -0,0 +1,107 @@
+package keepalive
+
+import (
+	"context"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/crash"
+	"github.com/stigoleg/keep-alive/internal/platform"
+)
+
+// idlePollInterval is how often the user's idle time is sampled for
+// SetUntilIdle.
+const idlePollInterval = 5 * time.Second
+
+// Bounds for the idle limit accepted from users. Limits much shorter than a
+// minute would end sessions during ordinary pauses.
+const (
+	MinUntilIdle = time.Minute
+	MaxUntilIdle = 24 * time.Hour
+)
+
+// readIdleTime is replaced in tests.
+var readIdleTime = platform.IdleTime
+
+// SetUntilIdle ends the session once the user has been idle for d, so the
+// machine stays awake while someone is using it and may sleep shortly after
+// they leave. Zero disables the limit. Simulated activity resets the idle
+// time, so the limit is only useful without it. Changes apply immediately to
+// a running session.
+func (k *Keeper) SetUntilIdle(d time.Duration) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.untilIdle == d {
+		return
+	}
+	k.untilIdle = d
+	if k.idleCancel != nil {
+		k.idleCancel()
+		k.idleCancel = nil
+	}
+	if k.running {
+		k.startIdleWatchLocked()
+	}
+}
+
+// UntilIdle returns the idle limit, or zero when none is set.
+func (k *Keeper) UntilIdle() time.Duration {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.untilIdle
+}
+
+// startIdleWatchLocked starts sampling the idle time for the current session.
+// Callers must hold k.mu.
+func (k *Keeper) startIdleWatchLocked() {
+	if k.untilIdle <= 0 || k.idleCancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(k.ctx)
+	k.idleCancel = cancel
+	go k.watchIdle(ctx, k.ctx, k.untilIdle)
+}
+
+// watchIdle samples the idle time until ctx is done or the limit is reached.
+func (k *Keeper) watchIdle(ctx, sessionCtx context.Context, limit time.Duration) {
+	defer crash.Guard("idle-watch")
+
+	ticker := time.NewTicker(idlePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if k.checkIdle(ctx, sessionCtx, limit) {
+			return
+		}
+	}
+}
+
+// checkIdle stops the session in sessionCtx once the user has been idle for
+// limit and reports whether it did. Errors are logged and ignored.
+func (k *Keeper) checkIdle(ctx, sessionCtx context.Context, limit time.Duration) bool {
+	idle, err := readIdleTime()
+	if err != nil {
+		k.logger().Warn("idle time unavailable", "err", err)
+		return false
+	}
+	if idle < limit {
+		return false
+	}
+
+	k.mu.Lock()
+	stillCurrent := ctx.Err() == nil && k.running && k.ctx == sessionCtx
+	k.mu.Unlock()
+	if !stillCurrent {
+		return true
+	}
+
+	k.logger().Info("user idle, stopping", "idle", idle.Round(time.Second))
+	k.Stop()
+	return true
+}
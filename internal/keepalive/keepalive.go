@@ -3,13 +3,43 @@ package keepalive
 import (
 	"context"
 	"errors"
-	"log"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/stigoleg/keep-alive/internal/crash"
+	"github.com/stigoleg/keep-alive/internal/history"
 	"github.com/stigoleg/keep-alive/internal/platform"
+	"github.com/stigoleg/keep-alive/internal/policy"
+	"github.com/stigoleg/keep-alive/internal/privacy"
+	"github.com/stigoleg/keep-alive/internal/schedule"
 )
 
+// defaultStopTimeout bounds how long Stop waits for the platform to clean up.
+const defaultStopTimeout = 5 * time.Second
+
+// stopNowGrace bounds how long StopNow waits for the display brightness to
+// be restored, like the platform's own StopNow waits for its inhibitors.
+const stopNowGrace = 500 * time.Millisecond
+
+// deadlineCheckInterval bounds how long a session with an absolute deadline
+// waits before comparing it with the wall clock again, so that system sleep
+// and clock changes are noticed. It is replaced in tests.
+var deadlineCheckInterval = time.Minute
+
+// powerSourcePollInterval is how often AC-only sessions sample the power source.
+const powerSourcePollInterval = 10 * time.Second
+
+// readPowerSource and powerSourceChanges are replaced in tests.
+var (
+	readPowerSource    = platform.GetPowerSource
+	powerSourceChanges = platform.PowerSourceChanges
+)
+
+// inRemoteSession is replaced in tests.
+var inRemoteSession = platform.InRemoteSession
+
 // Keeper manages the system's keep-alive state
 type Keeper struct {
 	running bool
@@ -19,13 +49,140 @@ type Keeper struct {
 	ctx     context.Context
 	cancel  context.CancelFunc
 	endTime time.Time
+	// startTime is when the current session began.
+	startTime time.Time
+	// untilDeadline is set for sessions started with StartUntil, whose
+	// endTime is a wall-clock time rather than a duration from the start.
+	untilDeadline bool
 
 	simulateActivity bool
-}
+	timings          platform.Timings
+	mouseShape       platform.MouseShape
+	// awayMode asks platforms that support it to let the display and audio
+	// turn off while the system stays awake.
+	awayMode bool
+	// allowHibernate asks platforms that support it to let the system
+	// hibernate while sleep is held off.
+	allowHibernate bool
+	// presenceOnly runs activity simulation without the platform's sleep
+	// inhibition.
+	presenceOnly bool
+	// assertions selects the power assertions held on platforms that
+	// support choosing them; nil keeps the platform's defaults.
+	assertions []platform.Assertion
+	// maxSimulations caps the jitters in each session; budget tracks the
+	// current session's use of it.
+	maxSimulations int
+	budget         *platform.SimulationBudget
+
+	// ignoreConflicts allows activity simulation alongside another tool
+	// that simulates input.
+	ignoreConflicts bool
+
+	// simMethod is the selected activity-simulation method, or "" for the
+	// platform's own ranking. activeMethod holds the method that last moved
+	// the pointer; it is set by platform reports, so it has no lock of its
+	// own.
+	simMethod    string
+	activeMethod atomic.Value
+	// simKey is the key tapped instead of moving the pointer, or "".
+	simKey string
+	// lastSimulation holds when activeMethod last moved the pointer, as a
+	// time.Time.
+	lastSimulation atomic.Value
+	// lastSleepAttempt holds the system's last attempt to sleep during the
+	// session, as a SleepAttempt.
+	lastSleepAttempt atomic.Value
+	// counters tallies the session's activations, simulations and failures.
+	counters sessionCounters
+
+	// acOnly suspends the platform keep-alive while running on battery.
+	acOnly      bool
+	onBattery   bool
+	suspended   bool
+	watchCancel context.CancelFunc
+	// switching is held while the platform keep-alive is stopped or
+	// started for a suspension, a restart or a new session, so that those
+	// happen one at a time. It is taken before k.mu.
+	switching sync.Mutex
+	// suspendedAt is when the current suspension began; suspendedFor is the
+	// session's total time suspended before it.
+	suspendedAt  time.Time
+	suspendedFor time.Duration
+
+	// paused suspends the session on request until Resume. pausedAt is when
+	// the current pause began; pausedFor is the session's total time paused
+	// before it.
+	paused    bool
+	pausedAt  time.Time
+	pausedFor time.Duration
+
+	// schedule suspends the platform keep-alive outside its windows.
+	schedule       *schedule.Schedule
+	offSchedule    bool
+	scheduleCancel context.CancelFunc
+
+	// quietHours suppresses activity simulation within its windows while
+	// the session carries on.
+	quietHours  *schedule.Schedule
+	quiet       bool
+	quietCancel context.CancelFunc
+
+	// dimLevel is the display brightness, in percent, held during a session.
+	dimLevel   int
+	brightness brightnessControl
+
+	// processWatch ends the session when the watched process exits.
+	processWatch  ProcessWatch
+	processCancel context.CancelFunc
+
+	// condition is set for sessions started by StartWhile, which end once
+	// their condition no longer holds.
+	condition bool
+
+	// untilIdle ends the session once the user has been idle this long.
+	untilIdle  time.Duration
+	idleCancel context.CancelFunc
+
+	// stopOnLock ends the session once the screen is locked.
+	stopOnLock bool
+	lockCancel context.CancelFunc
+
+	// onExpire is run by Expire when a timed session reaches its end.
+	onExpire string
+	hooks    sync.WaitGroup
+	expiring bool
+
+	// notify shows a desktop notification when a timed session ends.
+	notify bool
+
+	// statusPath is the status file the session is published in.
+	statusPath string
+
+	// statePath is the state file the session is saved in for resuming.
+	statePath string
 
-// NewKeeper creates a new Keeper instance.
-func NewKeeper() *Keeper {
-	return &Keeper{}
+	// historyPath is the history file finished sessions are recorded in,
+	// labelled with tags.
+	historyPath string
+	tags        history.Tags
+
+	// progressDone is closed once the taskbar progress has been removed.
+	progressDone chan struct{}
+
+	// policy holds administrator-enforced limits on sessions.
+	policy policy.Policy
+
+	// consent holds the privacy levels the user has chosen, or is nil when
+	// sessions are not limited by consent, as for programs using the
+	// library.
+	consent *privacy.Consent
+
+	// events delivers state changes to subscribers.
+	events events
+
+	// log is the logger for this Keeper, or nil for the package logger.
+	log *slog.Logger
 }
 
 // IsRunning returns whether the keep-alive is currently active
@@ -37,12 +194,32 @@ func (k *Keeper) IsRunning() bool {
 
 // StartIndefinite starts keeping the system alive indefinitely
 func (k *Keeper) StartIndefinite() error {
+	return k.StartIndefiniteContext(context.Background())
+}
+
+// StartIndefiniteContext starts keeping the system alive until the session
+// is stopped or ctx is done.
+func (k *Keeper) StartIndefiniteContext(ctx context.Context) error {
+	return k.startIndefinite(ctx, nil, 0)
+}
+
+// startIndefinite starts an indefinite session. A non-nil cond is polled
+// every poll and ends the session once it returns false.
+func (k *Keeper) startIndefinite(ctx context.Context, cond func() bool, poll time.Duration) error {
+	k.switching.Lock()
+	defer k.switching.Unlock()
 	k.mu.Lock()
 	defer k.mu.Unlock()
 
 	if k.running {
 		return errors.New("keep-alive already running")
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := k.policy.CheckDuration(0); err != nil {
+		return err
+	}
 
 	// Initialize the platform-specific keeper if needed
 	if k.keeper == nil {
@@ -54,28 +231,85 @@ func (k *Keeper) StartIndefinite() error {
 	}
 
 	// Create a new context for this session
-	k.ctx, k.cancel = context.WithCancel(context.Background())
+	k.ctx, k.cancel = context.WithCancel(ctx)
 
 	// Start the platform-specific keep-alive
-	k.keeper.SetSimulateActivity(k.simulateActivity)
-	if err := k.keeper.Start(k.ctx); err != nil {
+	if err := k.startKeeperLocked(); err != nil {
 		k.cancel()
 		return err
 	}
 
 	k.running = true
-	log.Printf("keeper: started (indefinite)")
+	k.startTime = time.Now()
+	if !k.suspended {
+		k.dimLocked()
+	}
+	k.startPowerWatchLocked()
+	k.startScheduleWatchLocked()
+	k.startQuietWatchLocked()
+	k.startHealthWatchLocked()
+	k.startProcessWatchLocked()
+	k.startIdleWatchLocked()
+	k.startLockWatchLocked()
+	k.stopWithParentLocked(ctx)
+	k.writeStatusLocked()
+	k.writeStateLocked()
+	if cond != nil {
+		k.condition = true
+		go k.watchCondition(k.ctx, cond, poll)
+		k.logger().Info("session started", "mode", "while", "poll", poll)
+	} else {
+		k.logger().Info("session started", "mode", "indefinite")
+	}
+	k.emit(Event{Type: EventStarted, Time: k.startTime})
 	return nil
 }
 
 // StartTimed starts keeping the system alive for the specified duration
 func (k *Keeper) StartTimed(d time.Duration) error {
+	return k.StartTimedContext(context.Background(), d)
+}
+
+// StartTimedContext starts keeping the system alive for d, or until ctx is
+// done if that comes first. Unlike reaching the end of d, cancelling ctx
+// stops the session without running the on-expire hook.
+func (k *Keeper) StartTimedContext(ctx context.Context, d time.Duration) error {
+	return k.startTimed(ctx, d, time.Time{})
+}
+
+// StartUntil starts keeping the system alive until the wall-clock time t.
+// Unlike StartTimed, the session follows the wall clock, so it still ends
+// at t after the system has slept or the clock has been changed.
+func (k *Keeper) StartUntil(t time.Time) error {
+	return k.StartUntilContext(context.Background(), t)
+}
+
+// StartUntilContext is StartUntil, ending the session early if ctx is done.
+func (k *Keeper) StartUntilContext(ctx context.Context, t time.Time) error {
+	d := t.Sub(now())
+	if d <= 0 {
+		return errors.New("end time must be in the future")
+	}
+	return k.startTimed(ctx, d, t)
+}
+
+// startTimed starts a timed session lasting d, or ending at deadline when
+// it is set.
+func (k *Keeper) startTimed(ctx context.Context, d time.Duration, deadline time.Time) error {
+	k.switching.Lock()
+	defer k.switching.Unlock()
 	k.mu.Lock()
 	defer k.mu.Unlock()
 
 	if k.running {
 		return errors.New("keep-alive already running")
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := k.policy.CheckDuration(d); err != nil {
+		return err
+	}
 
 	// Initialize the platform-specific keeper if needed
 	if k.keeper == nil {
@@ -86,32 +320,144 @@ func (k *Keeper) StartTimed(d time.Duration) error {
 		}
 	}
 
-	// Create a new context for this session
-	k.ctx, k.cancel = context.WithTimeout(context.Background(), d)
+	// Create a new context for this session. The session deadline is enforced
+	// by the stop timer rather than the context so that it can be extended.
+	k.ctx, k.cancel = context.WithCancel(ctx)
 
 	// Start the platform-specific keep-alive
-	k.keeper.SetSimulateActivity(k.simulateActivity)
-	if err := k.keeper.Start(k.ctx); err != nil {
+	if err := k.startKeeperLocked(); err != nil {
 		k.cancel()
 		return err
 	}
 
 	k.running = true
-	k.endTime = time.Now().Add(d)
-	k.timer = time.AfterFunc(d, func() {
+	k.startTime = time.Now()
+	k.endTime = k.startTime.Add(d)
+	if !deadline.IsZero() {
+		// Strip the monotonic reading so that the end is compared with the
+		// wall clock.
+		k.endTime = deadline.Round(0)
+		k.untilDeadline = true
+	}
+	k.setPowerReasonLocked()
+	k.scheduleStopLocked(d)
+	if !k.suspended {
+		k.dimLocked()
+	}
+	k.startPowerWatchLocked()
+	k.startScheduleWatchLocked()
+	k.startQuietWatchLocked()
+	k.startHealthWatchLocked()
+	k.startProcessWatchLocked()
+	k.startIdleWatchLocked()
+	k.startLockWatchLocked()
+	k.startProgressLocked()
+	k.stopWithParentLocked(ctx)
+	k.writeStatusLocked()
+	k.writeStateLocked()
+
+	if k.untilDeadline {
+		k.logger().Info("session started", "mode", "until", "ends", k.endTime.Format(time.RFC3339))
+	} else {
+		k.logger().Info("session started", "mode", "timed", "duration", d)
+	}
+	k.emit(Event{Type: EventStarted, Time: k.startTime})
+	return nil
+}
+
+// Extend pushes the end of a running timed session back by d, or brings it
+// forward when d is negative. A session cannot be shortened by the time it
+// has left or more.
+func (k *Keeper) Extend(d time.Duration) error {
+	if d == 0 {
+		return errors.New("extension must not be zero")
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if !k.running {
+		return errors.New("keep-alive is not running")
+	}
+	if k.endTime.IsZero() {
+		return errors.New("cannot extend an indefinite session")
+	}
+	if d < 0 {
+		if !k.endTime.Add(d).After(k.countdownAtLocked()) {
+			return errors.New("cannot shorten the session by the time it has left")
+		}
+	} else if err := k.policy.CheckDuration(k.endTime.Add(d).Sub(k.startTime) - k.pausedFor); err != nil {
+		return err
+	}
+
+	k.endTime = k.endTime.Add(d)
+	k.setPowerReasonLocked()
+	if !k.paused {
+		// A paused session's timer is armed again when it resumes.
+		if k.timer != nil {
+			k.timer.Stop()
+		}
+		k.scheduleStopLocked(k.endTime.Sub(now()))
+		k.writeStatusLocked()
+	}
+	k.writeStateLocked()
+
+	k.logger().Info("session extended", "by", d, "ends", k.endTime.Format(time.RFC3339))
+	return nil
+}
+
+// stopWithParentLocked stops the current session once parent, the context
+// it was started with, is done. Callers must hold k.mu.
+func (k *Keeper) stopWithParentLocked(parent context.Context) {
+	if parent.Done() == nil {
+		return
+	}
+	sessionCtx := k.ctx
+	context.AfterFunc(sessionCtx, func() {
+		defer crash.Guard("parent-context")
+		if parent.Err() == nil {
+			// The session ended on its own.
+			return
+		}
+		k.mu.Lock()
+		stillCurrent := k.running && k.ctx == sessionCtx
+		k.mu.Unlock()
+		if stillCurrent {
+			k.logger().Info("parent context done, stopping", "err", parent.Err())
+			k.Stop()
+		}
+	})
+}
+
+// scheduleStopLocked arms the timer that stops a timed session after d.
+// A session with a deadline is checked against the wall clock at least
+// every deadlineCheckInterval, since timers do not advance while the
+// system sleeps. Callers must hold k.mu.
+func (k *Keeper) scheduleStopLocked(d time.Duration) {
+	if k.untilDeadline {
+		d = min(d, deadlineCheckInterval)
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
 		// Check if still running before calling Stop to avoid race condition
 		// This prevents the timer callback from calling Stop() if Stop() was already called
+		// or if the session was extended and this timer was replaced.
 		k.mu.Lock()
-		stillRunning := k.running
+		stillCurrent := k.running && k.timer == timer
+		if stillCurrent && k.untilDeadline {
+			if left := k.endTime.Sub(now()); left > 0 {
+				k.scheduleStopLocked(left)
+				k.mu.Unlock()
+				return
+			}
+		}
 		k.mu.Unlock()
 
-		if stillRunning {
-			k.Stop()
+		if stillCurrent {
+			k.Expire()
 		}
 	})
-
-	log.Printf("keeper: started (timed=%s)", d)
-	return nil
+	k.timer = timer
 }
 
 // Stop stops keeping the system alive
@@ -119,28 +465,147 @@ func (k *Keeper) Stop() error {
 	return k.StopWithTimeout(0)
 }
 
-// StopWithTimeout stops keeping the system alive with a timeout
+// StopWithTimeout stops keeping the system alive, waiting at most timeout
+// (defaultStopTimeout if it is not positive) for the platform to clean up.
 func (k *Keeper) StopWithTimeout(timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = defaultStopTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return k.StopContext(ctx)
+}
+
+// StopContext stops keeping the system alive and waits until the platform has
+// released its sleep inhibitors or ctx is done, whichever comes first.
+func (k *Keeper) StopContext(ctx context.Context) error {
+	platformKeeper, ok := k.endSession(true)
+	if !ok {
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		if platformKeeper == nil {
+			done <- nil
+			return
+		}
+		done <- platformKeeper.Stop()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			k.logger().Error("session stopped with error", "err", err)
+			return err
+		}
+		k.logger().Info("session stopped")
+		return nil
+	case <-ctx.Done():
+		k.logger().Warn("stop interrupted", "err", ctx.Err())
+		return ctx.Err()
+	}
+}
+
+// StopNow stops keeping the system alive without waiting on other processes
+// or services: the platform releases what it can at once and abandons the
+// rest. The --notify notification is not shown, the display brightness is
+// given stopNowGrace to be restored, and the saved session state is kept so
+// that the session can be resumed. It is meant for signal handlers and other
+// paths that are about to exit.
+func (k *Keeper) StopNow() {
+	platformKeeper, ok := k.endSession(false)
+	if !ok {
+		return
+	}
+	if platformKeeper != nil {
+		platformKeeper.StopNow()
+	}
+	k.logger().Info("session stopped immediately")
+}
+
+// endSession ends the running session and returns the platform keep-alive
+// still to be stopped, or false when no session was running. With wait, it
+// also removes the saved session state, shows the end-of-session
+// notification and waits for the taskbar progress to be removed and the
+// display brightness to be restored; without, it waits for the brightness at
+// most stopNowGrace.
+func (k *Keeper) endSession(wait bool) (platform.KeepAlive, bool) {
 	k.mu.Lock()
 	if !k.running {
 		k.mu.Unlock()
-		return nil
+		return nil, false
 	}
 
-	if timeout <= 0 {
-		timeout = 5 * time.Second
-	}
+	k.restoreBrightnessLocked()
+
+	notify := k.notify && !k.endTime.IsZero()
+	expired := k.expiring
+	record, historyPath := k.historyRecordLocked(), k.historyPath
+	statePath := k.statePath
 
 	timer := k.timer
 	cancel := k.cancel
+	progressDone := k.progressDone
 	platformKeeper := k.keeper
+	if k.suspended {
+		// Already stopped when the session was suspended.
+		platformKeeper = nil
+	}
 
 	k.timer = nil
 	k.cancel = nil
 	k.endTime = time.Time{}
+	k.startTime = time.Time{}
+	k.untilDeadline = false
 	k.running = false
+	k.suspended = false
+	k.suspendedAt = time.Time{}
+	k.suspendedFor = 0
+	k.paused = false
+	k.pausedAt = time.Time{}
+	k.pausedFor = 0
+	k.onBattery = false
+	k.offSchedule = false
+	k.watchCancel = nil
+	k.scheduleCancel = nil
+	k.quiet = false
+	k.quietCancel = nil
+	k.processCancel = nil
+	k.condition = false
+	k.idleCancel = nil
+	k.lockCancel = nil
+	k.progressDone = nil
+	k.expiring = false
+	k.activeMethod.Store("")
+	k.lastSimulation.Store(time.Time{})
+	k.lastSleepAttempt.Store(SleepAttempt{})
+	counters := k.counters.snapshot()
+	k.counters.reset()
+	removeStatus(k.statusPath)
 	k.mu.Unlock()
 
+	k.logger().Info("session counters",
+		"activations", counters.Activations, "reactivations", counters.Reactivations,
+		"simulations", counters.Simulations, "idle_skips", counters.IdleSkips,
+		"inhibitor_failures", counters.InhibitorFailures)
+
+	appendHistory(historyPath, record)
+	if wait {
+		// A session stopped at once is about to be cut short, so it is
+		// kept for resuming.
+		removeState(statePath)
+	}
+	if expired {
+		k.emit(Event{Type: EventExpired})
+	} else {
+		k.emit(Event{Type: EventStopped})
+	}
+
+	if notify && wait {
+		notifyEnded(expired)
+	}
+
 	if timer != nil {
 		timer.Stop()
 	}
@@ -148,31 +613,16 @@ func (k *Keeper) StopWithTimeout(timeout time.Duration) error {
 	if cancel != nil {
 		cancel()
 	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
-	done := make(chan error, 1)
-	go func() {
-		if platformKeeper == nil {
-			done <- nil
-			return
-		}
-		done <- platformKeeper.Stop()
-	}()
-
-	select {
-	case err := <-done:
-		if err != nil {
-			log.Printf("keeper: stopped with error: %v", err)
-			return err
-		}
-		log.Printf("keeper: stopped")
-		return nil
-	case <-ctx.Done():
-		log.Printf("keeper: stop timeout exceeded after %v", timeout)
-		return ctx.Err()
+	if progressDone != nil && wait {
+		// Remove the progress before returning, since callers may exit.
+		<-progressDone
+	}
+	if wait {
+		k.waitBrightness(0)
+	} else {
+		k.waitBrightness(stopNowGrace)
 	}
+	return platformKeeper, true
 }
 
 // TimeRemaining returns the remaining duration for timed mode
@@ -188,15 +638,517 @@ func (k *Keeper) TimeRemaining() time.Duration {
 		return 0
 	}
 
-	remaining := time.Until(k.endTime)
+	remaining := k.endTime.Sub(k.countdownAtLocked())
 	if remaining < 0 {
 		return 0
 	}
 	return remaining
 }
 
+// EndTime returns when the current timed session ends, or the zero time for
+// indefinite sessions and when not running.
+func (k *Keeper) EndTime() time.Time {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.endTime
+}
+
+// SimulateActivity reports whether activity simulation is requested.
+func (k *Keeper) SimulateActivity() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.simulateActivity
+}
+
+// SetACOnly controls whether the keep-alive is suspended while the machine
+// runs on battery. It may be changed while a session is running.
+func (k *Keeper) SetACOnly(acOnly bool) {
+	k.mu.Lock()
+	if k.acOnly == acOnly {
+		k.mu.Unlock()
+		return
+	}
+	k.acOnly = acOnly
+	if !k.running {
+		k.mu.Unlock()
+		return
+	}
+	if acOnly {
+		k.startPowerWatchLocked()
+		k.mu.Unlock()
+		return
+	}
+	if k.watchCancel != nil {
+		k.watchCancel()
+		k.watchCancel = nil
+	}
+	k.onBattery = false
+	sessionCtx := k.ctx
+	k.mu.Unlock()
+	k.updateSuspended(sessionCtx, "ac-only off")
+}
+
+// ACOnly reports whether the keep-alive is restricted to AC power.
+func (k *Keeper) ACOnly() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.acOnly
+}
+
+// Suspended reports whether a running session is currently suspended because
+// the machine is on battery power, outside its schedule or paused.
+func (k *Keeper) Suspended() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.suspended
+}
+
+// startPowerWatchLocked starts the power source watcher for an AC-only
+// session. Callers must hold k.mu.
+func (k *Keeper) startPowerWatchLocked() {
+	if !k.acOnly || k.watchCancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(k.ctx)
+	k.watchCancel = cancel
+	go k.watchPowerSource(ctx, k.ctx, readPowerSource, powerSourceChanges(ctx))
+}
+
+// watchPowerSource samples the power source until ctx is done and suspends or
+// resumes the session in sessionCtx accordingly. It also samples it right
+// away when changes, which is nil where the system does not report changes
+// of the power source, receives.
+func (k *Keeper) watchPowerSource(ctx, sessionCtx context.Context, read func() (platform.PowerSource, error), changes <-chan struct{}) {
+	defer crash.Guard("power-watch")
+
+	ticker := time.NewTicker(powerSourcePollInterval)
+	defer ticker.Stop()
+
+	for {
+		source, err := read()
+		if err != nil {
+			k.logger().Debug("power source unavailable", "err", err)
+		} else {
+			k.applyPowerSource(ctx, sessionCtx, source)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-changes:
+		}
+	}
+}
+
+// applyPowerSource suspends the session on battery and resumes it on AC.
+// Unknown sources leave the session as it is.
+func (k *Keeper) applyPowerSource(ctx, sessionCtx context.Context, source platform.PowerSource) {
+	k.mu.Lock()
+	if ctx.Err() != nil || !k.running || k.ctx != sessionCtx {
+		k.mu.Unlock()
+		return
+	}
+
+	reason := ""
+	switch source {
+	case platform.PowerSourceBattery:
+		k.onBattery = true
+		reason = "battery"
+	case platform.PowerSourceAC:
+		k.onBattery = false
+		reason = "ac power"
+	}
+	k.mu.Unlock()
+	if reason != "" {
+		k.updateSuspended(sessionCtx, reason)
+	}
+}
+
+// updateSuspended suspends the session in sessionCtx while it is on battery
+// with AC-only set, outside its schedule or paused, and resumes it
+// otherwise. reason is logged with the change. It returns the error of a
+// resume that failed to start the platform keep-alive, which leaves the
+// session suspended. Callers must not hold k.mu.
+//
+// The platform keep-alive is stopped or started without k.mu, since that
+// can wait up to its stop timeout; k.switching keeps the changes in order.
+// The suspension is decided from the session's state when the change
+// begins, so a caller that changes that state and then calls
+// updateSuspended never leaves it out of date.
+func (k *Keeper) updateSuspended(sessionCtx context.Context, reason string) error {
+	k.switching.Lock()
+	defer k.switching.Unlock()
+
+	k.mu.Lock()
+	if sessionCtx.Err() != nil || !k.running || k.ctx != sessionCtx {
+		k.mu.Unlock()
+		return nil
+	}
+	want := (k.acOnly && k.onBattery) || k.offSchedule || k.paused
+	keeper := k.keeper
+	switch {
+	case want && !k.suspended:
+		// Marked suspended first, so that the session's own Stop leaves
+		// the platform keep-alive to this one.
+		k.suspended = true
+		k.suspendedAt = now()
+		k.restoreBrightnessLocked()
+		k.mu.Unlock()
+		if err := keeper.Stop(); err != nil {
+			k.logger().Error("suspend failed to stop keep-alive", "err", err)
+		}
+		k.logger().Info("session suspended", "reason", reason)
+		return nil
+	case !want && k.suspended:
+		k.configureKeeperLocked()
+		k.mu.Unlock()
+		return k.resume(sessionCtx, keeper, reason)
+	}
+	k.mu.Unlock()
+	return nil
+}
+
+// resume restarts keeper, the platform keep-alive of the session in
+// sessionCtx, after a suspension. Callers must hold k.switching but not
+// k.mu.
+func (k *Keeper) resume(sessionCtx context.Context, keeper platform.KeepAlive, reason string) error {
+	startErr := keeper.Start(sessionCtx)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if sessionCtx.Err() != nil || !k.running || k.ctx != sessionCtx {
+		// The session ended while starting and left the platform
+		// keep-alive to this resume, since it was still suspended.
+		if startErr == nil && !k.running {
+			if err := keeper.Stop(); err != nil {
+				k.logger().Debug("stopping resumed platform keep-alive", "err", err)
+			}
+		}
+		return nil
+	}
+	if startErr != nil {
+		k.logger().Error("resume failed", "err", startErr)
+		return startErr
+	}
+	k.counters.activations.Add(1)
+	k.suspended = false
+	k.suspendedFor += now().Sub(k.suspendedAt)
+	k.suspendedAt = time.Time{}
+	k.dimLocked()
+	k.logger().Info("session resumed", "reason", reason)
+	return nil
+}
+
+// startKeeperLocked starts the platform keep-alive for a new session, or
+// leaves the session suspended when it begins outside its schedule. Callers
+// must hold k.mu.
+func (k *Keeper) startKeeperLocked() error {
+	if err := k.checkCompetitorsLocked(); err != nil {
+		return err
+	}
+	k.budget = platform.NewSimulationBudget(k.maxSimulations)
+	k.quiet = k.quietHours != nil && k.quietHours.Active(now())
+	if k.schedule != nil && !k.schedule.Active(now()) {
+		k.offSchedule = true
+		k.suspended = true
+		k.suspendedAt = now()
+		k.logger().Info("session suspended", "reason", "schedule")
+		return nil
+	}
+	if k.presenceOnly {
+		if _, ok := k.keeper.(platform.ActivitySimulator); !ok {
+			return errors.New("presence-only mode is not supported on this platform")
+		}
+		if !k.simulateActivity {
+			return errors.New("presence-only mode needs activity simulation")
+		}
+	}
+	if len(k.assertions) > 0 {
+		if _, ok := k.keeper.(platform.AssertionSelectingKeepAlive); !ok {
+			return errors.New("choosing power assertions is only supported on macOS")
+		}
+	}
+	if k.simKey != "" {
+		if _, ok := k.keeper.(platform.KeyTappingKeepAlive); !ok {
+			return errors.New("simulating activity with a key is only supported on Windows")
+		}
+	}
+	k.configureKeeperLocked()
+	if err := k.keeper.Start(k.ctx); err != nil {
+		return err
+	}
+	k.counters.activations.Add(1)
+	return nil
+}
+
+// configureKeeperLocked passes the session options to the platform
+// keep-alive before it starts. Callers must hold k.mu.
+func (k *Keeper) configureKeeperLocked() {
+	k.keeper.SetSimulateActivity(k.simulateActivity && !k.quiet)
+	k.keeper.SetTimings(k.timings)
+	k.keeper.SetMouseShape(k.mouseShape)
+	if am, ok := k.keeper.(platform.AwayModeKeepAlive); ok {
+		am.SetAwayMode(k.awayMode)
+	}
+	if hk, ok := k.keeper.(platform.HibernationKeepAlive); ok {
+		hk.SetAllowHibernate(k.allowHibernate)
+	}
+	if as, ok := k.keeper.(platform.ActivitySimulator); ok {
+		as.SetPresenceOnly(k.presenceOnly)
+	}
+	if ak, ok := k.keeper.(platform.AssertionSelectingKeepAlive); ok {
+		ak.SetAssertions(k.assertions)
+	}
+	if pb, ok := k.keeper.(platform.ProcessBoundKeepAlive); ok {
+		pb.SetWatchPID(k.processWatch.PID)
+	}
+	k.setPowerReasonLocked()
+	if bk, ok := k.keeper.(platform.BudgetedKeepAlive); ok {
+		bk.SetSimulationBudget(k.budget)
+	}
+	if kt, ok := k.keeper.(platform.KeyTappingKeepAlive); ok {
+		if err := kt.SetSimulationKey(k.simKey); err != nil {
+			k.logger().Warn("simulation key not applied", "key", k.simKey, "err", err)
+		}
+	}
+	if ms, ok := k.keeper.(platform.MethodSelectingKeepAlive); ok {
+		if err := ms.SetSimulationMethod(k.simMethod); err != nil {
+			k.logger().Warn("simulation method not applied", "method", k.simMethod, "err", err)
+		}
+	}
+	if ob, ok := k.keeper.(platform.ObservableKeepAlive); ok {
+		ob.SetObserver(keeperObserver{k})
+	}
+}
+
+// setPowerReasonLocked tells the platform keep-alive why the system is kept
+// awake, for tools such as `powercfg /requests`: "keep-alive: user requested
+// awake until 22:00", or "until stopped" for an untimed session. The reason
+// follows the end as the session is extended. Callers must hold k.mu.
+func (k *Keeper) setPowerReasonLocked() {
+	rk, ok := k.keeper.(platform.ReasonedKeepAlive)
+	if !ok {
+		return
+	}
+	until := "stopped"
+	switch {
+	case k.endTime.IsZero():
+	case k.endTime.Sub(now()) < 24*time.Hour:
+		until = k.endTime.Format("15:04")
+	default:
+		until = k.endTime.Format("Jan 2 15:04")
+	}
+	rk.SetReason("keep-alive: user requested awake until " + until)
+}
+
+// SetSimulateActivity turns activity simulation on or off. Changes apply
+// immediately to a running session and are announced with
+// EventSimulationChanged.
 func (k *Keeper) SetSimulateActivity(simulate bool) {
 	k.mu.Lock()
 	defer k.mu.Unlock()
+	if simulate {
+		if err := k.policy.CheckActive(); err != nil {
+			k.logger().Warn("activity simulation refused", "err", err)
+			simulate = false
+		} else if k.consent != nil {
+			if err := k.consent.Check(privacy.SyntheticInput); err != nil {
+				k.logger().Warn("activity simulation refused", "err", err)
+				simulate = false
+			}
+		}
+	}
+	changed := simulate != k.simulateActivity
 	k.simulateActivity = simulate
+	if changed && k.running {
+		if !k.suspended && k.keeper != nil {
+			k.keeper.SetSimulateActivity(simulate && !k.quiet)
+		}
+		k.logger().Info("activity simulation changed", "enabled", simulate)
+		k.emitSimulationChangedLocked()
+	}
+}
+
+// SetPolicy applies administrator-enforced limits to sessions started
+// afterwards. Activity simulation is turned off if the policy forbids it.
+func (k *Keeper) SetPolicy(p policy.Policy) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.policy = p
+	if p.CheckActive() != nil {
+		k.simulateActivity = false
+	}
+}
+
+// Policy returns the administrator-enforced limits.
+func (k *Keeper) Policy() policy.Policy {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.policy
+}
+
+// SetConsent limits sessions to the privacy levels in c. Activity
+// simulation, which injects input, is turned off and refused unless c
+// allows it.
+func (k *Keeper) SetConsent(c privacy.Consent) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.consent = &c
+	if !c.Allows(privacy.SyntheticInput) && k.simulateActivity {
+		k.simulateActivity = false
+		if k.running && !k.suspended && k.keeper != nil {
+			k.keeper.SetSimulateActivity(false)
+		}
+		k.logger().Info("activity simulation turned off", "reason", "no consent")
+		if k.running {
+			k.emitSimulationChangedLocked()
+		}
+	}
+}
+
+// Consent returns the privacy levels the user has chosen, and false when
+// sessions are not limited by consent.
+func (k *Keeper) Consent() (privacy.Consent, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.consent == nil {
+		return privacy.Consent{}, false
+	}
+	return *k.consent, true
+}
+
+// PrivacyLevel returns the privacy level the session uses: none when no
+// session runs, synthetic-input while activity is simulated, and
+// inhibit-only otherwise.
+func (k *Keeper) PrivacyLevel() privacy.Level {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.privacyLevelLocked()
+}
+
+func (k *Keeper) privacyLevelLocked() privacy.Level {
+	switch {
+	case !k.running:
+		return privacy.None
+	case k.simulateActivity:
+		return privacy.SyntheticInput
+	default:
+		return privacy.InhibitOnly
+	}
+}
+
+// SetTimings overrides the activity intervals. Zero fields keep their
+// defaults. Changes apply immediately to a running session.
+func (k *Keeper) SetTimings(t platform.Timings) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.timings = t
+	if k.running && k.keeper != nil {
+		k.keeper.SetTimings(t)
+	}
+}
+
+// SetAwayMode requests away mode on platforms that support it. Changes
+// apply immediately to a running session.
+func (k *Keeper) SetAwayMode(enabled bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.awayMode = enabled
+	if k.running && k.keeper != nil {
+		if am, ok := k.keeper.(platform.AwayModeKeepAlive); ok {
+			am.SetAwayMode(enabled)
+		}
+	}
+}
+
+// SetMaxSimulations caps how many times activity simulation may move the
+// mouse in a session. Zero means no limit. Once the cap is reached the
+// session keeps preventing sleep without simulating input. Changes apply
+// immediately to a running session, whose count starts afresh.
+func (k *Keeper) SetMaxSimulations(n int) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.maxSimulations = n
+	if k.running && k.keeper != nil {
+		k.budget = platform.NewSimulationBudget(n)
+		if bk, ok := k.keeper.(platform.BudgetedKeepAlive); ok {
+			bk.SetSimulationBudget(k.budget)
+		}
+	}
+}
+
+// SimulationBudget returns the current session's simulation budget, or nil
+// before the first session.
+func (k *Keeper) SimulationBudget() *platform.SimulationBudget {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.budget
+}
+
+// AwayMode reports whether away mode is requested.
+func (k *Keeper) AwayMode() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.awayMode
+}
+
+// SetAllowHibernate lets the system hibernate while sleep is held off, on
+// platforms that support it. It applies to sessions started afterwards.
+func (k *Keeper) SetAllowHibernate(allow bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.allowHibernate = allow
+}
+
+// AllowHibernate reports whether hibernation is allowed.
+func (k *Keeper) AllowHibernate() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.allowHibernate
+}
+
+// SetPresenceOnly runs sessions started afterwards with activity
+// simulation alone: the pointer moves keep chat apps showing the user as
+// present, and no sleep inhibitor is held, so sleep is left to the
+// system's own settings. Activity simulation must be on.
+func (k *Keeper) SetPresenceOnly(presenceOnly bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.presenceOnly = presenceOnly
+}
+
+// PresenceOnly reports whether sessions run activity simulation alone.
+func (k *Keeper) PresenceOnly() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.presenceOnly
+}
+
+// SetAssertions selects the power assertions held by sessions started
+// afterwards, such as only the display assertion, on platforms that
+// support choosing them. Nil keeps the platform's defaults.
+func (k *Keeper) SetAssertions(assertions []platform.Assertion) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.assertions = assertions
+}
+
+// Assertions returns the selected power assertions, or nil for the
+// platform's defaults.
+func (k *Keeper) Assertions() []platform.Assertion {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.assertions
+}
+
+// SetMouseShape selects the jitter pattern used by activity simulation.
+// Changes apply immediately to a running session.
+func (k *Keeper) SetMouseShape(shape platform.MouseShape) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.mouseShape = shape
+	if k.running && k.keeper != nil {
+		k.keeper.SetMouseShape(shape)
+	}
 }
@@ -1,11 +1,30 @@
 package keepalive
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/stigoleg/keep-alive/internal/history"
+	"github.com/stigoleg/keep-alive/internal/platform"
+	"github.com/stigoleg/keep-alive/internal/policy"
+	"github.com/stigoleg/keep-alive/internal/privacy"
+	"github.com/stigoleg/keep-alive/internal/schedule"
+	"github.com/stigoleg/keep-alive/internal/schema"
+	"github.com/stigoleg/keep-alive/internal/sessionstate"
 )
 
 func TestKeepAlive(t *testing.T) {
@@ -30,7 +49,7 @@ func TestKeepAlive(t *testing.T) {
 	})
 
 	t.Run("Basic Operations", func(t *testing.T) {
-		k := &Keeper{}
+		k := New()
 		defer k.Stop() // Ensure cleanup even if test fails
 
 		if k.IsRunning() {
@@ -67,7 +86,7 @@ func TestKeepAlive(t *testing.T) {
 	})
 
 	t.Run("Timed Operation", func(t *testing.T) {
-		k := &Keeper{}
+		k := New()
 		defer k.Stop() // Ensure cleanup even if test fails
 
 		// Start timed
@@ -99,3 +118,2390 @@ func TestKeepAlive(t *testing.T) {
 		}
 	})
 }
+
+func TestNewAppliesOptions(t *testing.T) {
+	var logs bytes.Buffer
+	fake := &simulateFlagKeepAlive{}
+	k := New(
+		WithPlatform(fake),
+		WithIdleThreshold(45*time.Second),
+		WithActivityInterval(5*time.Second),
+		WithSimulationInterval(time.Minute),
+		WithSimulateActivity(true),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+	)
+
+	want := platform.Timings{IdleThreshold: 45 * time.Second, ActivityInterval: 5 * time.Second, ChatAppActivityInterval: time.Minute}
+	if k.timings != want {
+		t.Errorf("timings = %+v, want %+v", k.timings, want)
+	}
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite: %v", err)
+	}
+	defer k.Stop()
+	if !fake.simulating() {
+		t.Error("activity simulation not passed to the platform")
+	}
+	if !strings.Contains(logs.String(), "session started") {
+		t.Errorf("session start not logged to the Keeper's logger: %q", logs.String())
+	}
+}
+
+func TestStartUntilFollowsWallClock(t *testing.T) {
+	var clock atomic.Pointer[time.Time]
+	start := time.Date(2025, 1, 13, 10, 0, 0, 0, time.UTC)
+	clock.Store(&start)
+	orig, origInterval := now, deadlineCheckInterval
+	now = func() time.Time { return *clock.Load() }
+	deadlineCheckInterval = 10 * time.Millisecond
+	t.Cleanup(func() { now, deadlineCheckInterval = orig, origInterval })
+
+	k := New(WithPlatform(&countingKeepAlive{}))
+	if err := k.StartUntil(start.Add(-time.Minute)); err == nil {
+		t.Fatal("StartUntil accepted a time in the past")
+	}
+	deadline := start.Add(2 * time.Hour)
+	if err := k.StartUntil(deadline); err != nil {
+		t.Fatalf("StartUntil: %v", err)
+	}
+	defer k.Stop()
+	if !k.EndTime().Equal(deadline) {
+		t.Fatalf("EndTime() = %v, want %v", k.EndTime(), deadline)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if !k.IsRunning() {
+		t.Fatal("session ended before its deadline")
+	}
+
+	// The wall clock jumps past the deadline, as after the system slept.
+	later := deadline.Add(time.Second)
+	clock.Store(&later)
+	for i := 0; i < 100 && k.IsRunning(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if k.IsRunning() {
+		t.Fatal("session still running after the wall clock passed its deadline")
+	}
+}
+
+func TestExtendRequiresTimedSession(t *testing.T) {
+	k := New()
+	if err := k.Extend(time.Minute); err == nil {
+		t.Fatal("expected error extending a stopped keeper")
+	}
+	if err := k.Extend(0); err == nil {
+		t.Fatal("expected error for a zero extension")
+	}
+}
+
+func TestExtendShortensSession(t *testing.T) {
+	k := New(WithPlatform(&countingKeepAlive{}))
+	if err := k.StartTimed(time.Hour); err != nil {
+		t.Fatalf("StartTimed failed: %v", err)
+	}
+	defer k.Stop()
+
+	before := k.EndTime()
+	if err := k.Extend(-10 * time.Minute); err != nil {
+		t.Fatalf("Extend(-10m) failed: %v", err)
+	}
+	if got := before.Sub(k.EndTime()); got != 10*time.Minute {
+		t.Fatalf("EndTime moved forward by %v, want 10m", got)
+	}
+	if err := k.Extend(-time.Hour); err == nil {
+		t.Fatal("expected error shortening by more than the time left")
+	}
+	if got := before.Sub(k.EndTime()); got != 10*time.Minute {
+		t.Fatalf("a refused Extend moved EndTime: %v", got)
+	}
+	if !k.IsRunning() {
+		t.Fatal("session stopped after shortening")
+	}
+}
+
+func TestExtendShortensBySessionClock(t *testing.T) {
+	var clock atomic.Pointer[time.Time]
+	start := time.Now()
+	clock.Store(&start)
+	orig := now
+	now = func() time.Time { return *clock.Load() }
+	t.Cleanup(func() { now = orig })
+
+	k := New(WithPlatform(&countingKeepAlive{}))
+	if err := k.StartTimed(time.Hour); err != nil {
+		t.Fatalf("StartTimed failed: %v", err)
+	}
+	defer k.Stop()
+
+	later := start.Add(50 * time.Minute)
+	clock.Store(&later)
+	if err := k.Extend(-15 * time.Minute); err == nil {
+		t.Fatal("expected error shortening by more than the 10m left")
+	}
+	if err := k.Extend(-5 * time.Minute); err != nil {
+		t.Fatalf("Extend(-5m) failed: %v", err)
+	}
+}
+
+func TestExtendTimedSession(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode")
+	}
+
+	k := New()
+	defer k.Stop()
+
+	err := k.StartTimed(500 * time.Millisecond)
+	if err != nil && err.Error() == "unsupported platform" {
+		t.Skip("Skipping on unsupported platform")
+	}
+	if err != nil {
+		t.Fatalf("StartTimed failed: %v", err)
+	}
+
+	before := k.EndTime()
+	if err := k.Extend(time.Second); err != nil {
+		t.Fatalf("Extend failed: %v", err)
+	}
+	if got := k.EndTime().Sub(before); got != time.Second {
+		t.Fatalf("EndTime moved by %v, want 1s", got)
+	}
+
+	// The original deadline must no longer stop the session.
+	time.Sleep(800 * time.Millisecond)
+	if !k.IsRunning() {
+		t.Fatal("expected keeper to still be running after original deadline")
+	}
+}
+
+type countingKeepAlive struct {
+	mu       sync.Mutex
+	starts   int
+	stops    int
+	stopNows int
+}
+
+func (c *countingKeepAlive) Start(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.starts++
+	return nil
+}
+
+func (c *countingKeepAlive) Stop() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stops++
+	return nil
+}
+
+func (c *countingKeepAlive) StopNow() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopNows++
+}
+
+func (c *countingKeepAlive) SetSimulateActivity(bool) {}
+
+func (c *countingKeepAlive) SetTimings(platform.Timings) {}
+
+func (c *countingKeepAlive) SetMouseShape(platform.MouseShape) {}
+
+func (c *countingKeepAlive) counts() (int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.starts, c.stops
+}
+
+// awayModeKeepAlive records the away mode it was last given.
+type awayModeKeepAlive struct {
+	countingKeepAlive
+	awayMode bool
+}
+
+func (a *awayModeKeepAlive) SetAwayMode(enabled bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.awayMode = enabled
+}
+
+func (a *awayModeKeepAlive) away() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.awayMode
+}
+
+func TestAwayModePassedToPlatform(t *testing.T) {
+	fake := &awayModeKeepAlive{}
+	k := New(WithPlatform(fake))
+	k.SetAwayMode(true)
+	if fake.away() {
+		t.Fatal("away mode applied before the session started")
+	}
+
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite: %v", err)
+	}
+	defer k.Stop()
+	if !fake.away() {
+		t.Fatal("away mode not applied on start")
+	}
+
+	k.SetAwayMode(false)
+	if fake.away() {
+		t.Fatal("away mode not cleared on a running session")
+	}
+}
+
+func TestWithAwayMode(t *testing.T) {
+	fake := &awayModeKeepAlive{}
+	k := New(WithPlatform(fake), WithAwayMode(true))
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite: %v", err)
+	}
+	defer k.Stop()
+	if !fake.away() {
+		t.Fatal("away mode not applied on start")
+	}
+}
+
+// hibernationKeepAlive records whether it was last told to allow
+// hibernation.
+type hibernationKeepAlive struct {
+	countingKeepAlive
+	allow bool
+}
+
+func (h *hibernationKeepAlive) SetAllowHibernate(allow bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.allow = allow
+}
+
+func TestAllowHibernatePassedToPlatform(t *testing.T) {
+	fake := &hibernationKeepAlive{}
+	k := New(WithPlatform(fake))
+	k.SetAllowHibernate(true)
+
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite: %v", err)
+	}
+	defer k.Stop()
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if !fake.allow {
+		t.Fatal("allow hibernate not applied on start")
+	}
+}
+
+// presenceKeepAlive records whether it was last told to run presence-only.
+type presenceKeepAlive struct {
+	countingKeepAlive
+	presenceOnly bool
+}
+
+func (p *presenceKeepAlive) SetPresenceOnly(presenceOnly bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.presenceOnly = presenceOnly
+}
+
+func TestPresenceOnly(t *testing.T) {
+	fake := &presenceKeepAlive{}
+	k := New(WithPlatform(fake))
+	k.SetPresenceOnly(true)
+
+	if err := k.StartIndefinite(); err == nil {
+		k.Stop()
+		t.Fatal("presence-only session started without activity simulation")
+	}
+
+	k.SetSimulateActivity(true)
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite: %v", err)
+	}
+	defer k.Stop()
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if !fake.presenceOnly {
+		t.Fatal("presence-only not applied on start")
+	}
+}
+
+func TestPresenceOnlyNeedsActivitySimulator(t *testing.T) {
+	k := New(WithPlatform(&countingKeepAlive{}), WithSimulateActivity(true))
+	k.SetPresenceOnly(true)
+
+	if err := k.StartIndefinite(); err == nil {
+		k.Stop()
+		t.Fatal("presence-only session started on a platform without it")
+	}
+}
+
+// assertionKeepAlive records the power assertions it was last given.
+type assertionKeepAlive struct {
+	countingKeepAlive
+	assertions []platform.Assertion
+}
+
+func (a *assertionKeepAlive) SetAssertions(assertions []platform.Assertion) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.assertions = assertions
+}
+
+func TestAssertions(t *testing.T) {
+	want := []platform.Assertion{platform.AssertionIdle, platform.AssertionDisk}
+
+	k := New(WithPlatform(&countingKeepAlive{}))
+	k.SetAssertions(want)
+	if err := k.StartIndefinite(); err == nil {
+		k.Stop()
+		t.Fatal("session with chosen assertions started on a platform without them")
+	}
+
+	fake := &assertionKeepAlive{}
+	k = New(WithPlatform(fake))
+	k.SetAssertions(want)
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite: %v", err)
+	}
+	defer k.Stop()
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if !slices.Equal(fake.assertions, want) {
+		t.Fatalf("assertions applied on start = %v, want %v", fake.assertions, want)
+	}
+}
+
+// budgetKeepAlive records the simulation budget it was last given.
+type budgetKeepAlive struct {
+	countingKeepAlive
+	budget *platform.SimulationBudget
+}
+
+func (b *budgetKeepAlive) SetSimulationBudget(budget *platform.SimulationBudget) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.budget = budget
+}
+
+func TestSimulationBudgetPerSession(t *testing.T) {
+	fake := &budgetKeepAlive{}
+	k := New(WithPlatform(fake))
+	k.SetMaxSimulations(3)
+
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite: %v", err)
+	}
+	first := k.SimulationBudget()
+	if _, limit := first.Used(); limit != 3 {
+		t.Fatalf("budget limit = %d, want 3", limit)
+	}
+	fake.mu.Lock()
+	given := fake.budget
+	fake.mu.Unlock()
+	if given != first {
+		t.Fatal("platform was not given the session budget")
+	}
+	k.Stop()
+
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite: %v", err)
+	}
+	defer k.Stop()
+	if k.SimulationBudget() == first {
+		t.Fatal("a new session reused the previous budget")
+	}
+}
+
+func TestPolicyLimitsSessions(t *testing.T) {
+	k := New(WithPlatform(&countingKeepAlive{}))
+	k.SetPolicy(policy.Policy{MaxDuration: time.Hour, DisableActive: true, Source: "test"})
+
+	k.SetSimulateActivity(true)
+	if k.SimulateActivity() {
+		t.Error("activity simulation enabled despite policy")
+	}
+	if err := k.StartIndefinite(); err == nil {
+		t.Fatal("StartIndefinite succeeded despite a duration cap")
+	}
+	if err := k.StartTimed(2 * time.Hour); err == nil {
+		t.Fatal("StartTimed(2h) succeeded despite a 1h cap")
+	}
+	if err := k.StartTimed(45 * time.Minute); err != nil {
+		t.Fatalf("StartTimed(45m): %v", err)
+	}
+	defer k.Stop()
+	if err := k.Extend(30 * time.Minute); err == nil {
+		t.Fatal("Extend past the cap succeeded")
+	}
+	if err := k.Extend(10 * time.Minute); err != nil {
+		t.Fatalf("Extend within the cap: %v", err)
+	}
+}
+
+func TestConsentLimitsSimulation(t *testing.T) {
+	k := New(WithPlatform(&countingKeepAlive{}), WithSimulateActivity(true))
+	if got := k.PrivacyLevel(); got != privacy.None {
+		t.Errorf("PrivacyLevel() before starting = %v, want none", got)
+	}
+
+	k.SetConsent(privacy.Consent{Level: privacy.InhibitOnly})
+	if k.SimulateActivity() {
+		t.Error("activity simulation left on without consent")
+	}
+	k.SetSimulateActivity(true)
+	if k.SimulateActivity() {
+		t.Error("activity simulation enabled without consent")
+	}
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite: %v", err)
+	}
+	defer k.Stop()
+	if got := k.Status().PrivacyLevel; got != "inhibit-only" {
+		t.Errorf("Status().PrivacyLevel = %q, want inhibit-only", got)
+	}
+
+	granted := time.Date(2026, 10, 16, 9, 12, 0, 0, time.UTC)
+	k.SetConsent(privacy.Consent{Level: privacy.SyntheticInput, GrantedAt: granted})
+	k.SetSimulateActivity(true)
+	if !k.SimulateActivity() {
+		t.Fatal("activity simulation refused after consent")
+	}
+	s := k.Status()
+	if s.PrivacyLevel != "synthetic-input" || !s.ConsentedAt.Equal(granted) {
+		t.Errorf("Status() privacy = %q consented %v, want synthetic-input consented %v", s.PrivacyLevel, s.ConsentedAt, granted)
+	}
+}
+
+// stubPowerSource keeps the background watcher from reading the real power
+// source so that tests drive applyPowerSource directly.
+func stubPowerSource(t *testing.T) {
+	t.Helper()
+	orig := readPowerSource
+	readPowerSource = func() (platform.PowerSource, error) { return platform.PowerSourceUnknown, nil }
+	t.Cleanup(func() { readPowerSource = orig })
+}
+
+func TestACOnlySuspendsOnBattery(t *testing.T) {
+	stubPowerSource(t)
+	fake := &countingKeepAlive{}
+	k := New(WithPlatform(fake))
+	k.SetACOnly(true)
+
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	k.mu.Lock()
+	session := k.ctx
+	k.mu.Unlock()
+
+	k.applyPowerSource(session, session, platform.PowerSourceBattery)
+	if !k.Suspended() {
+		t.Fatal("expected session to be suspended on battery")
+	}
+	if !k.IsRunning() {
+		t.Fatal("suspended session should still be running")
+	}
+
+	k.applyPowerSource(session, session, platform.PowerSourceUnknown)
+	if !k.Suspended() {
+		t.Fatal("unknown power source should not resume the session")
+	}
+
+	k.applyPowerSource(session, session, platform.PowerSourceAC)
+	if k.Suspended() {
+		t.Fatal("expected session to resume on AC power")
+	}
+	if starts, stops := fake.counts(); starts != 2 || stops != 1 {
+		t.Fatalf("platform starts/stops = %d/%d, want 2/1", starts, stops)
+	}
+
+	k.applyPowerSource(session, session, platform.PowerSourceBattery)
+	if err := k.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if k.Suspended() {
+		t.Fatal("Stop should clear the suspended state")
+	}
+	if _, stops := fake.counts(); stops != 2 {
+		t.Fatalf("platform stops = %d, want 2 (no second stop while suspended)", stops)
+	}
+}
+
+func TestPowerSourceChangeIsReadAtOnce(t *testing.T) {
+	var source, reads atomic.Int32
+	source.Store(int32(platform.PowerSourceAC))
+	changes := make(chan struct{}, 1)
+	origRead, origChanges := readPowerSource, powerSourceChanges
+	readPowerSource = func() (platform.PowerSource, error) {
+		reads.Add(1)
+		return platform.PowerSource(source.Load()), nil
+	}
+	powerSourceChanges = func(context.Context) <-chan struct{} { return changes }
+	t.Cleanup(func() { readPowerSource, powerSourceChanges = origRead, origChanges })
+
+	k := New(WithPlatform(&countingKeepAlive{}))
+	k.SetACOnly(true)
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	defer k.Stop()
+
+	// The watcher reads the source once when it starts; the next poll is
+	// far off.
+	deadline := time.Now().Add(2 * time.Second)
+	for reads.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	source.Store(int32(platform.PowerSourceBattery))
+	changes <- struct{}{}
+	for !k.Suspended() {
+		if time.Now().After(deadline) {
+			t.Fatal("session not suspended after the power source changed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDisablingACOnlyResumes(t *testing.T) {
+	stubPowerSource(t)
+	fake := &countingKeepAlive{}
+	k := New(WithPlatform(fake))
+	k.SetACOnly(true)
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	defer k.Stop()
+
+	k.mu.Lock()
+	session := k.ctx
+	k.mu.Unlock()
+	k.applyPowerSource(session, session, platform.PowerSourceBattery)
+
+	k.SetACOnly(false)
+	if k.Suspended() {
+		t.Fatal("expected disabling AC-only to resume the session")
+	}
+}
+
+func TestScheduleSuspendsOutsideWindows(t *testing.T) {
+	var clock atomic.Pointer[time.Time]
+	setClock := func(s string) {
+		c, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clock.Store(&c)
+	}
+	orig := now
+	now = func() time.Time { return *clock.Load() }
+	t.Cleanup(func() { now = orig })
+
+	s, err := schedule.Parse("Mon-Fri 09:00-17:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &countingKeepAlive{}
+	k := New(WithPlatform(fake))
+
+	setClock("2025-01-11 10:00") // Saturday
+	k.SetSchedule(s)
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	defer k.Stop()
+	if !k.Suspended() || !k.OffSchedule() {
+		t.Fatal("expected a session started outside the schedule to be suspended")
+	}
+	if starts, _ := fake.counts(); starts != 0 {
+		t.Fatalf("platform starts = %d, want 0 while off schedule", starts)
+	}
+
+	setClock("2025-01-13 10:00") // Monday
+	k.SetSchedule(s)
+	if k.Suspended() {
+		t.Fatal("expected the session to resume inside the schedule")
+	}
+
+	k.SetSchedule(nil)
+	setClock("2025-01-13 18:00")
+	if k.Suspended() {
+		t.Fatal("removing the schedule should leave the session running")
+	}
+	if starts, stops := fake.counts(); starts != 1 || stops != 0 {
+		t.Fatalf("platform starts/stops = %d/%d, want 1/0", starts, stops)
+	}
+}
+
+// simulateFlagKeepAlive records whether activity simulation was last
+// enabled.
+type simulateFlagKeepAlive struct {
+	countingKeepAlive
+	simulate bool
+}
+
+func (f *simulateFlagKeepAlive) SetSimulateActivity(simulate bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.simulate = simulate
+}
+
+func (f *simulateFlagKeepAlive) simulating() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.simulate
+}
+
+func TestQuietHoursSuppressSimulation(t *testing.T) {
+	var clock atomic.Pointer[time.Time]
+	setClock := func(s string) {
+		c, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clock.Store(&c)
+	}
+	orig := now
+	now = func() time.Time { return *clock.Load() }
+	t.Cleanup(func() { now = orig })
+
+	s, err := schedule.Parse("Mon-Fri 14:00-15:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &simulateFlagKeepAlive{}
+	k := New(WithPlatform(fake))
+	k.SetSimulateActivity(true)
+	k.SetQuietHours(s)
+
+	setClock("2025-01-13 14:30") // Monday
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	defer k.Stop()
+	if !k.Quiet() || fake.simulating() {
+		t.Fatal("expected simulation to be suppressed within quiet hours")
+	}
+	if k.Suspended() {
+		t.Fatal("quiet hours should not suspend the session")
+	}
+
+	setClock("2025-01-13 15:30")
+	k.SetQuietHours(s)
+	if k.Quiet() || !fake.simulating() {
+		t.Fatal("expected simulation to resume after quiet hours")
+	}
+
+	setClock("2025-01-14 14:10") // Tuesday
+	k.SetQuietHours(s)
+	if !k.Quiet() {
+		t.Fatal("expected quiet hours to apply again the next weekday")
+	}
+	k.SetQuietHours(nil)
+	if k.Quiet() || !fake.simulating() {
+		t.Fatal("removing the quiet hours should restore simulation")
+	}
+	if starts, stops := fake.counts(); starts != 1 || stops != 0 {
+		t.Fatalf("platform starts/stops = %d/%d, want 1/0", starts, stops)
+	}
+}
+
+func TestStatusFileFollowsSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keepalive", "status")
+	k := New(WithPlatform(&countingKeepAlive{}))
+	k.SetStatusFile(path)
+
+	if err := k.StartTimed(time.Hour); err != nil {
+		t.Fatalf("StartTimed failed: %v", err)
+	}
+	if err := k.Extend(30 * time.Minute); err != nil {
+		t.Fatalf("Extend failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("status file not written: %v", err)
+	}
+	want := fmt.Sprintf("%d %d\n", os.Getpid(), k.EndTime().Unix())
+	if string(data) != want {
+		t.Errorf("status file = %q, want %q", data, want)
+	}
+
+	if err := k.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("status file still present after Stop: %v", err)
+	}
+}
+
+func TestStateFileKeptOnlyForInterruptedSessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), sessionstate.FileName)
+	k := New(WithPlatform(&countingKeepAlive{}))
+	k.SetStateFile(path)
+	k.SetSimulateActivity(true)
+
+	if err := k.StartTimed(time.Hour); err != nil {
+		t.Fatalf("StartTimed failed: %v", err)
+	}
+	k.Pause()
+	s, err := sessionstate.Load(path)
+	if err != nil {
+		t.Fatalf("state not saved: %v", err)
+	}
+	if left := s.Remaining(time.Now()); left < 59*time.Minute || left > time.Hour || !s.SimulateActivity || s.PID != os.Getpid() {
+		t.Errorf("saved state = %+v with %v left", s, left)
+	}
+	if err := k.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("state file still present after Stop: %v", err)
+	}
+
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	k.StopNow()
+	if s, err := sessionstate.Load(path); err != nil || !s.End.IsZero() {
+		t.Errorf("state after StopNow = %+v, %v; want the indefinite session kept", s, err)
+	}
+}
+
+func TestHistoryRecordsTaggedSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	k := New(WithPlatform(&countingKeepAlive{}))
+	k.SetHistory(path)
+	k.SetTags(history.Tags{"project": "foo"})
+
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	if err := k.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	sessions, err := history.Read(path, history.Query{Tags: history.Tags{"project": "foo"}})
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("got %d sessions, want 1", len(sessions))
+	}
+	s := sessions[0]
+	if s.Start.IsZero() || s.End.Before(s.Start) {
+		t.Errorf("session spans %v to %v", s.Start, s.End)
+	}
+	if s.Tags["project"] != "foo" {
+		t.Errorf("tags = %v, want project=foo", s.Tags)
+	}
+}
+
+// stubBrightness replaces the brightness backend with an in-memory display.
+func stubBrightness(t *testing.T, level int) *int {
+	t.Helper()
+	origGet, origSet := getBrightness, setBrightness
+	getBrightness = func() (int, error) { return level, nil }
+	setBrightness = func(percent int) error {
+		level = percent
+		return nil
+	}
+	t.Cleanup(func() { getBrightness, setBrightness = origGet, origSet })
+	return &level
+}
+
+func TestDimLevelRestoresOnStop(t *testing.T) {
+	level := stubBrightness(t, 80)
+	k := New(WithPlatform(&countingKeepAlive{}))
+	k.SetDimLevel(20)
+
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	k.waitBrightness(0)
+	if *level != 20 {
+		t.Fatalf("brightness during session = %d, want 20", *level)
+	}
+
+	k.SetDimLevel(35)
+	k.waitBrightness(0)
+	if *level != 35 {
+		t.Fatalf("brightness after changing dim level = %d, want 35", *level)
+	}
+
+	if err := k.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if *level != 80 {
+		t.Fatalf("brightness after stop = %d, want 80", *level)
+	}
+}
+
+func TestDimLevelLeavesDarkerDisplay(t *testing.T) {
+	level := stubBrightness(t, 10)
+	k := New(WithPlatform(&countingKeepAlive{}))
+	k.SetDimLevel(30)
+
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	defer k.Stop()
+	k.waitBrightness(0)
+	if *level != 10 {
+		t.Fatalf("brightness = %d, want unchanged 10", *level)
+	}
+}
+
+func TestDimLevelFollowsACOnlySuspension(t *testing.T) {
+	stubPowerSource(t)
+	level := stubBrightness(t, 90)
+	k := New(WithPlatform(&countingKeepAlive{}))
+	k.SetACOnly(true)
+	k.SetDimLevel(25)
+
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	defer k.Stop()
+	k.mu.Lock()
+	session := k.ctx
+	k.mu.Unlock()
+
+	k.applyPowerSource(session, session, platform.PowerSourceBattery)
+	k.waitBrightness(0)
+	if *level != 90 {
+		t.Fatalf("brightness while suspended = %d, want restored 90", *level)
+	}
+	k.applyPowerSource(session, session, platform.PowerSourceAC)
+	k.waitBrightness(0)
+	if *level != 25 {
+		t.Fatalf("brightness after resume = %d, want 25", *level)
+	}
+}
+
+func TestDimLevelDoesNotHoldLock(t *testing.T) {
+	stubBrightness(t, 80)
+	reading, release := make(chan struct{}), make(chan struct{})
+	getBrightness = func() (int, error) {
+		close(reading)
+		<-release
+		return 80, nil
+	}
+	k := New(WithPlatform(&countingKeepAlive{}))
+	k.SetDimLevel(20)
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	<-reading
+
+	running := make(chan bool)
+	go func() { running <- k.IsRunning() }()
+	select {
+	case <-running:
+	case <-time.After(time.Second):
+		t.Fatal("IsRunning blocked while the brightness was read")
+	}
+	close(release)
+	if err := k.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+}
+
+func TestStopNowDoesNotWaitOnBrightness(t *testing.T) {
+	stubBrightness(t, 80)
+	release := make(chan struct{})
+	k := New(WithPlatform(&countingKeepAlive{}))
+	defer func() {
+		close(release)
+		k.waitBrightness(0)
+	}()
+	k.SetDimLevel(20)
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	k.waitBrightness(0)
+	setBrightness = func(int) error {
+		<-release
+		return nil
+	}
+
+	start := time.Now()
+	k.StopNow()
+	if elapsed := time.Since(start); elapsed > stopNowGrace+time.Second {
+		t.Fatalf("StopNow took %v with a hung brightness helper", elapsed)
+	}
+	if k.IsRunning() {
+		t.Fatal("session still running after StopNow")
+	}
+}
+
+// stubProcesses replaces process lookups with a single fake process whose
+// liveness the test controls.
+func stubProcesses(t *testing.T, pid int, name string) *atomic.Bool {
+	t.Helper()
+	alive := &atomic.Bool{}
+	alive.Store(true)
+	origAlive, origFind := processAlive, findProcesses
+	processAlive = func(p int) (bool, error) { return p == pid && alive.Load(), nil }
+	findProcesses = func(n string) ([]int, error) {
+		if n == name && alive.Load() {
+			return []int{pid}, nil
+		}
+		return nil, nil
+	}
+	t.Cleanup(func() { processAlive, findProcesses = origAlive, origFind })
+	return alive
+}
+
+func TestProcessWatchCheck(t *testing.T) {
+	stubProcesses(t, 4242, "rsync")
+
+	for _, w := range []ProcessWatch{{PID: 4242}, {Name: "rsync"}} {
+		if err := w.Check(); err != nil {
+			t.Errorf("Check(%v) = %v, want nil", w, err)
+		}
+	}
+	for _, w := range []ProcessWatch{{PID: 1}, {Name: "rsynx"}} {
+		if err := w.Check(); err == nil {
+			t.Errorf("Check(%v) = nil, want error", w)
+		}
+	}
+}
+
+func TestProcessWatchStopsSession(t *testing.T) {
+	for _, w := range []ProcessWatch{{PID: 4242}, {Name: "rsync"}} {
+		alive := stubProcesses(t, 4242, "rsync")
+		fake := &countingKeepAlive{}
+		k := New(WithPlatform(fake))
+		k.SetProcessWatch(w)
+		if err := k.StartIndefinite(); err != nil {
+			t.Fatalf("StartIndefinite failed: %v", err)
+		}
+		k.mu.Lock()
+		session := k.ctx
+		k.mu.Unlock()
+
+		if k.checkProcess(session, session, w) {
+			t.Fatalf("%v: session stopped while the process is alive", w)
+		}
+		alive.Store(false)
+		if !k.checkProcess(session, session, w) {
+			t.Fatalf("%v: expected the watcher to finish after the process exited", w)
+		}
+		if k.IsRunning() {
+			t.Fatalf("%v: expected keeper to stop when the process exited", w)
+		}
+		if _, stops := fake.counts(); stops != 1 {
+			t.Fatalf("%v: platform stops = %d, want 1", w, stops)
+		}
+	}
+}
+
+// boundKeepAlive records the process it was last bound to.
+type boundKeepAlive struct {
+	countingKeepAlive
+	watchPID int
+}
+
+func (b *boundKeepAlive) SetWatchPID(pid int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.watchPID = pid
+}
+
+func TestProcessWatchBindsPlatform(t *testing.T) {
+	stubProcesses(t, 4242, "rsync")
+	for _, tt := range []struct {
+		watch ProcessWatch
+		want  int
+	}{
+		{ProcessWatch{PID: 4242}, 4242},
+		// A watch by name can match a different process each check, so the
+		// platform is not bound to one.
+		{ProcessWatch{Name: "rsync"}, 0},
+	} {
+		fake := &boundKeepAlive{}
+		k := New(WithPlatform(fake))
+		k.SetProcessWatch(tt.watch)
+		if err := k.StartIndefinite(); err != nil {
+			t.Fatalf("StartIndefinite failed: %v", err)
+		}
+		fake.mu.Lock()
+		got := fake.watchPID
+		fake.mu.Unlock()
+		k.Stop()
+		if got != tt.want {
+			t.Errorf("%v: platform bound to pid %d, want %d", tt.watch, got, tt.want)
+		}
+	}
+}
+
+// reasonedKeepAlive records the reason it was last given.
+type reasonedKeepAlive struct {
+	countingKeepAlive
+	reason string
+}
+
+func (r *reasonedKeepAlive) SetReason(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reason = reason
+}
+
+func (r *reasonedKeepAlive) lastReason() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reason
+}
+
+func TestPowerReasonNamesEnd(t *testing.T) {
+	fake := &reasonedKeepAlive{}
+	k := New(WithPlatform(fake))
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	if got, want := fake.lastReason(), "keep-alive: user requested awake until stopped"; got != want {
+		t.Errorf("indefinite session reason = %q, want %q", got, want)
+	}
+	k.Stop()
+
+	if err := k.StartTimed(time.Hour); err != nil {
+		t.Fatalf("StartTimed failed: %v", err)
+	}
+	defer k.Stop()
+	if got, want := fake.lastReason(), "keep-alive: user requested awake until "+k.EndTime().Format("15:04"); got != want {
+		t.Errorf("timed session reason = %q, want %q", got, want)
+	}
+	if err := k.Extend(30 * time.Minute); err != nil {
+		t.Fatalf("Extend failed: %v", err)
+	}
+	if got, want := fake.lastReason(), "keep-alive: user requested awake until "+k.EndTime().Format("15:04"); got != want {
+		t.Errorf("extended session reason = %q, want %q", got, want)
+	}
+}
+
+func TestStartWhileStopsWhenConditionFails(t *testing.T) {
+	fake := &countingKeepAlive{}
+	k := New(WithPlatform(fake))
+
+	var holds atomic.Bool
+	cond := func() bool { return holds.Load() }
+	if err := k.StartWhile(cond, 10*time.Millisecond); err == nil {
+		t.Fatal("StartWhile succeeded although the condition does not hold")
+	}
+	if k.IsRunning() {
+		t.Fatal("keeper running after a refused StartWhile")
+	}
+
+	holds.Store(true)
+	if err := k.StartWhile(cond, 10*time.Millisecond); err != nil {
+		t.Fatalf("StartWhile failed: %v", err)
+	}
+	if mode := k.Status().Mode; mode != ModeWhile {
+		t.Fatalf("Status().Mode = %q, want %q", mode, ModeWhile)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if !k.IsRunning() {
+		t.Fatal("session stopped while the condition holds")
+	}
+
+	holds.Store(false)
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, stops := fake.counts(); stops > 0 && !k.IsRunning() {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if k.IsRunning() {
+		t.Fatal("expected keeper to stop once the condition failed")
+	}
+	if _, stops := fake.counts(); stops != 1 {
+		t.Fatalf("platform stops = %d, want 1", stops)
+	}
+}
+
+func TestCommandCondition(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh exit statuses")
+	}
+	if !CommandCondition("exit 0")() {
+		t.Error("CommandCondition(exit 0) = false, want true")
+	}
+	if CommandCondition("exit 1")() {
+		t.Error("CommandCondition(exit 1) = true, want false")
+	}
+}
+
+// stubHook records hook commands instead of running them.
+func stubHook(t *testing.T) func() []string {
+	t.Helper()
+	var mu sync.Mutex
+	var ran []string
+	orig := execHook
+	execHook = func(command string, _ time.Duration) error {
+		mu.Lock()
+		defer mu.Unlock()
+		ran = append(ran, command)
+		return nil
+	}
+	t.Cleanup(func() { execHook = orig })
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), ran...)
+	}
+}
+
+func TestOnExpireRunsOnce(t *testing.T) {
+	ran := stubHook(t)
+	k := New(WithPlatform(&countingKeepAlive{}))
+	k.SetOnExpire("notify-send done")
+
+	if err := k.StartTimed(50 * time.Millisecond); err != nil {
+		t.Fatalf("StartTimed failed: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for k.IsRunning() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// The UI's own countdown ends at about the same time.
+	if err := k.Expire(); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+
+	if got := ran(); len(got) != 1 || got[0] != "notify-send done" {
+		t.Fatalf("hooks run = %q, want one on-expire hook", got)
+	}
+}
+
+func TestOnExpireSkippedOnStop(t *testing.T) {
+	ran := stubHook(t)
+	k := New(WithPlatform(&countingKeepAlive{}))
+	k.SetOnExpire("notify-send done")
+
+	if err := k.StartTimed(time.Hour); err != nil {
+		t.Fatalf("StartTimed failed: %v", err)
+	}
+	if err := k.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	if err := k.Expire(); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+	if !k.IsRunning() {
+		t.Fatal("Expire should not end an indefinite session")
+	}
+	k.Stop()
+
+	if got := ran(); len(got) != 0 {
+		t.Fatalf("hooks run = %q, want none", got)
+	}
+}
+
+func TestRunHookTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	if err := runHook("exit 0", time.Second); err != nil {
+		t.Fatalf("runHook() error = %v", err)
+	}
+	if err := runHook("exit 3", time.Second); err == nil {
+		t.Fatal("expected an error for a failing hook")
+	}
+	start := time.Now()
+	if err := runHook("sleep 5", 100*time.Millisecond); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("runHook() error = %v, want timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Fatalf("runHook() took %s, want it killed at the timeout", elapsed)
+	}
+}
+
+// stubNotify records notification bodies instead of showing them.
+func stubNotify(t *testing.T) func() []string {
+	t.Helper()
+	var mu sync.Mutex
+	var bodies []string
+	orig := sendNotification
+	sendNotification = func(_, body string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		bodies = append(bodies, body)
+		return nil
+	}
+	t.Cleanup(func() { sendNotification = orig })
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), bodies...)
+	}
+}
+
+func TestNotifyOnTimedSessionEnd(t *testing.T) {
+	sent := stubNotify(t)
+	k := New(WithPlatform(&countingKeepAlive{}))
+	k.SetNotify(true)
+
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	k.Stop()
+	if got := sent(); len(got) != 0 {
+		t.Fatalf("notifications after indefinite session = %q, want none", got)
+	}
+
+	if err := k.StartTimed(time.Hour); err != nil {
+		t.Fatalf("StartTimed failed: %v", err)
+	}
+	k.Stop()
+	if err := k.StartTimed(time.Hour); err != nil {
+		t.Fatalf("StartTimed failed: %v", err)
+	}
+	k.Expire()
+
+	got := sent()
+	if len(got) != 2 || !strings.Contains(got[0], "stopped") || !strings.Contains(got[1], "finished") {
+		t.Fatalf("notifications = %q, want one stopped and one finished", got)
+	}
+}
+
+func TestUntilIdleStopsSession(t *testing.T) {
+	var idle atomic.Int64
+	orig := readIdleTime
+	readIdleTime = func() (time.Duration, error) { return time.Duration(idle.Load()), nil }
+	t.Cleanup(func() { readIdleTime = orig })
+
+	k := New(WithPlatform(&countingKeepAlive{}))
+	k.SetUntilIdle(10 * time.Minute)
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	k.mu.Lock()
+	session := k.ctx
+	k.mu.Unlock()
+
+	idle.Store(int64(9 * time.Minute))
+	if k.checkIdle(session, session, 10*time.Minute) || !k.IsRunning() {
+		t.Fatal("session stopped before the idle limit")
+	}
+	idle.Store(int64(10 * time.Minute))
+	if !k.checkIdle(session, session, 10*time.Minute) {
+		t.Fatal("expected the idle watcher to finish at the limit")
+	}
+	if k.IsRunning() {
+		t.Fatal("expected keeper to stop once idle for the limit")
+	}
+}
+
+func TestStopOnLockStopsSession(t *testing.T) {
+	var locked atomic.Bool
+	orig := readScreenLocked
+	readScreenLocked = func() (bool, error) { return locked.Load(), nil }
+	t.Cleanup(func() { readScreenLocked = orig })
+
+	k := New(WithPlatform(&countingKeepAlive{}))
+	k.SetStopOnLock(true)
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	k.mu.Lock()
+	session, watching := k.ctx, k.lockCancel != nil
+	k.mu.Unlock()
+	if !watching {
+		t.Fatal("screen lock not watched")
+	}
+
+	if done, err := k.checkLock(session, session); done || err != nil || !k.IsRunning() {
+		t.Fatalf("checkLock() = %v, %v while unlocked", done, err)
+	}
+	locked.Store(true)
+	if done, err := k.checkLock(session, session); !done || err != nil {
+		t.Fatalf("checkLock() = %v, %v while locked", done, err)
+	}
+	if k.IsRunning() {
+		t.Fatal("expected keeper to stop once the screen locked")
+	}
+}
+
+func TestTaskbarProgressShownForTimedSession(t *testing.T) {
+	var mu sync.Mutex
+	var shown []float64
+	cleared := 0
+	origSet, origClear := setTaskbarProgress, clearTaskbarProgress
+	setTaskbarProgress = func(fraction float64) error {
+		mu.Lock()
+		defer mu.Unlock()
+		shown = append(shown, fraction)
+		return nil
+	}
+	clearTaskbarProgress = func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		cleared++
+		return nil
+	}
+	t.Cleanup(func() { setTaskbarProgress, clearTaskbarProgress = origSet, origClear })
+
+	k := New(WithPlatform(&countingKeepAlive{}))
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	k.Stop()
+	mu.Lock()
+	if len(shown) != 0 || cleared != 0 {
+		t.Fatalf("indefinite session touched the taskbar: shown %v, cleared %d", shown, cleared)
+	}
+	mu.Unlock()
+
+	if err := k.StartTimed(time.Hour); err != nil {
+		t.Fatalf("StartTimed failed: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		n := len(shown)
+		mu.Unlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("progress not shown for timed session")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	k.Stop()
+
+	// Stop waits for the progress to be removed.
+	mu.Lock()
+	defer mu.Unlock()
+	if shown[0] < 0 || shown[0] > 0.01 {
+		t.Errorf("initial progress = %v, want about 0", shown[0])
+	}
+	if cleared != 1 {
+		t.Errorf("progress cleared %d times, want 1", cleared)
+	}
+}
+
+// hungKeepAlive is a platform keep-alive whose Stop never returns, like one
+// waiting on a hung D-Bus call.
+type hungKeepAlive struct {
+	countingKeepAlive
+	release chan struct{}
+}
+
+func (h *hungKeepAlive) Stop() error {
+	<-h.release
+	return nil
+}
+
+func TestStopContextGivesUpWhenDone(t *testing.T) {
+	platformKeeper := &hungKeepAlive{release: make(chan struct{})}
+	defer close(platformKeeper.release)
+	k := New(WithPlatform(platformKeeper))
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := k.StopContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("StopContext() = %v, want deadline exceeded", err)
+	}
+	if k.IsRunning() {
+		t.Error("keeper still running after StopContext")
+	}
+}
+
+func TestStopNowSkipsGracefulStop(t *testing.T) {
+	platformKeeper := &hungKeepAlive{release: make(chan struct{})}
+	defer close(platformKeeper.release)
+	k := New(WithPlatform(platformKeeper))
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		k.StopNow()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("StopNow blocked on the platform Stop")
+	}
+
+	platformKeeper.mu.Lock()
+	stopNows := platformKeeper.stopNows
+	platformKeeper.mu.Unlock()
+	if stopNows != 1 {
+		t.Errorf("platform StopNow called %d times, want 1", stopNows)
+	}
+	if k.IsRunning() {
+		t.Error("keeper still running after StopNow")
+	}
+	k.StopNow()
+	if err := k.Stop(); err != nil {
+		t.Errorf("Stop after StopNow = %v, want nil", err)
+	}
+}
+
+func TestParentContextStopsSession(t *testing.T) {
+	ran := stubHook(t)
+	for _, timed := range []bool{false, true} {
+		fake := &countingKeepAlive{}
+		k := New(WithPlatform(fake))
+		k.SetOnExpire("notify-send done")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var err error
+		if timed {
+			err = k.StartTimedContext(ctx, time.Hour)
+		} else {
+			err = k.StartIndefiniteContext(ctx)
+		}
+		if err != nil {
+			t.Fatalf("start failed: %v", err)
+		}
+
+		cancel()
+		// The platform is stopped after the session is marked as ended.
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if _, stops := fake.counts(); stops > 0 && !k.IsRunning() {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if k.IsRunning() {
+			t.Fatalf("timed=%v: session still running after the parent context was cancelled", timed)
+		}
+		if _, stops := fake.counts(); stops != 1 {
+			t.Fatalf("timed=%v: platform stops = %d, want 1", timed, stops)
+		}
+	}
+	if got := ran(); len(got) != 0 {
+		t.Fatalf("hooks run = %q, want none", got)
+	}
+}
+
+func TestStartContextAlreadyDone(t *testing.T) {
+	k := New(WithPlatform(&countingKeepAlive{}))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := k.StartIndefiniteContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("StartIndefiniteContext() = %v, want context.Canceled", err)
+	}
+	if k.IsRunning() {
+		t.Fatal("keeper running after starting with a done context")
+	}
+
+	// A session started after an earlier one was stopped is unaffected by
+	// the earlier session's context.
+	ctx, cancel = context.WithCancel(context.Background())
+	if err := k.StartIndefiniteContext(ctx); err != nil {
+		t.Fatalf("StartIndefiniteContext failed: %v", err)
+	}
+	k.Stop()
+	cancel()
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if !k.IsRunning() {
+		t.Fatal("cancelling an old session's context stopped the new session")
+	}
+	k.Stop()
+}
+
+func TestPauseFreezesTimedSession(t *testing.T) {
+	fake := &countingKeepAlive{}
+	k := New(WithPlatform(fake))
+	if err := k.StartTimed(300 * time.Millisecond); err != nil {
+		t.Fatalf("StartTimed failed: %v", err)
+	}
+	if err := k.Pause(); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	if !k.Paused() || !k.Suspended() {
+		t.Fatal("expected the session to be paused and suspended")
+	}
+	if _, stops := fake.counts(); stops != 1 {
+		t.Fatalf("platform stops = %d, want 1 after Pause", stops)
+	}
+	remaining := k.TimeRemaining()
+
+	// The session would have ended by now had it not been paused.
+	time.Sleep(400 * time.Millisecond)
+	if !k.IsRunning() {
+		t.Fatal("paused session expired")
+	}
+	if got := k.TimeRemaining(); got != remaining {
+		t.Fatalf("TimeRemaining() while paused = %v, want %v", got, remaining)
+	}
+
+	if err := k.Resume(); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if k.Paused() || k.Suspended() {
+		t.Fatal("expected the session to run again after Resume")
+	}
+	if starts, _ := fake.counts(); starts != 2 {
+		t.Fatalf("platform starts = %d, want 2 after Resume", starts)
+	}
+	if got := k.TimeRemaining(); got < remaining-50*time.Millisecond {
+		t.Fatalf("TimeRemaining() after Resume = %v, want about %v", got, remaining)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for k.IsRunning() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if k.IsRunning() {
+		t.Fatal("resumed session did not expire")
+	}
+}
+
+func TestPauseFollowsClock(t *testing.T) {
+	var clock atomic.Pointer[time.Time]
+	start := time.Now()
+	clock.Store(&start)
+	orig := now
+	now = func() time.Time { return *clock.Load() }
+	t.Cleanup(func() { now = orig })
+
+	k := New(WithPlatform(&countingKeepAlive{}))
+	if err := k.StartTimed(time.Hour); err != nil {
+		t.Fatalf("StartTimed failed: %v", err)
+	}
+	defer k.Stop()
+	end := k.EndTime()
+	if err := k.Pause(); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	remaining := k.TimeRemaining()
+
+	later := start.Add(10 * time.Minute)
+	clock.Store(&later)
+	if got := k.TimeRemaining(); got != remaining {
+		t.Fatalf("TimeRemaining() while paused = %v, want %v", got, remaining)
+	}
+	if err := k.Resume(); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if got, want := k.EndTime(), end.Add(10*time.Minute); !got.Equal(want) {
+		t.Fatalf("EndTime() after Resume = %v, want %v", got, want)
+	}
+}
+
+func TestPauseKeepsDeadline(t *testing.T) {
+	var clock atomic.Pointer[time.Time]
+	start := time.Now().Round(0)
+	clock.Store(&start)
+	orig := now
+	now = func() time.Time { return *clock.Load() }
+	t.Cleanup(func() { now = orig })
+
+	k := New(WithPlatform(&countingKeepAlive{}))
+	deadline := start.Add(time.Hour)
+	if err := k.StartUntil(deadline); err != nil {
+		t.Fatalf("StartUntil failed: %v", err)
+	}
+	defer k.Stop()
+	if err := k.Pause(); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+
+	later := start.Add(10 * time.Minute)
+	clock.Store(&later)
+	if got := k.TimeRemaining(); got != 50*time.Minute {
+		t.Fatalf("TimeRemaining() while paused = %v, want 50m", got)
+	}
+	if err := k.Resume(); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if got := k.EndTime(); !got.Equal(deadline) {
+		t.Fatalf("EndTime() after Resume = %v, want the deadline %v", got, deadline)
+	}
+}
+
+func TestPauseRequiresRunningSession(t *testing.T) {
+	k := New(WithPlatform(&countingKeepAlive{}))
+	if err := k.Pause(); err == nil {
+		t.Fatal("expected an error pausing while idle")
+	}
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	if err := k.Resume(); err != nil {
+		t.Fatalf("Resume of a running session = %v, want nil", err)
+	}
+	k.Pause()
+	k.Stop()
+	if k.Paused() {
+		t.Fatal("session still paused after Stop")
+	}
+}
+
+// refusingKeepAlive fails to start once refuse is set.
+type refusingKeepAlive struct {
+	countingKeepAlive
+	refuse bool
+}
+
+func (r *refusingKeepAlive) Start(ctx context.Context) error {
+	r.mu.Lock()
+	refuse := r.refuse
+	r.mu.Unlock()
+	if refuse {
+		return errors.New("caffeinate could not be started")
+	}
+	return r.countingKeepAlive.Start(ctx)
+}
+
+func TestResumeFailureLeavesSessionPaused(t *testing.T) {
+	fake := &refusingKeepAlive{}
+	k := New(WithPlatform(fake))
+	if err := k.StartTimed(time.Hour); err != nil {
+		t.Fatalf("StartTimed failed: %v", err)
+	}
+	defer k.Stop()
+	if err := k.Pause(); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	remaining := k.TimeRemaining()
+
+	fake.mu.Lock()
+	fake.refuse = true
+	fake.mu.Unlock()
+	if err := k.Resume(); err == nil {
+		t.Fatal("Resume succeeded although the platform failed to start")
+	}
+	if !k.Paused() || !k.Suspended() {
+		t.Fatal("session no longer paused after a failed Resume")
+	}
+	if got := k.TimeRemaining(); got != remaining {
+		t.Fatalf("TimeRemaining() after a failed Resume = %v, want %v", got, remaining)
+	}
+
+	fake.mu.Lock()
+	fake.refuse = false
+	fake.mu.Unlock()
+	if err := k.Resume(); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if k.Paused() || k.Suspended() {
+		t.Fatal("expected the session to run again after Resume")
+	}
+}
+
+// simulatingKeepAlive counts simulation test fires.
+type simulatingKeepAlive struct {
+	countingKeepAlive
+	fires int
+}
+
+func (s *simulatingKeepAlive) SimulateOnce() platform.SimulationResult {
+	s.fires++
+	return platform.SimulationResult{Method: "fake", Points: 3}
+}
+
+func TestSimulateOnce(t *testing.T) {
+	fake := &simulatingKeepAlive{}
+	k := New(WithPlatform(fake))
+	if _, err := k.SimulateOnce(); err == nil {
+		t.Fatal("expected an error test firing while idle")
+	}
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	defer k.Stop()
+
+	res, err := k.SimulateOnce()
+	if err != nil || res.Method != "fake" || fake.fires != 1 {
+		t.Fatalf("SimulateOnce() = %+v, %v after %d fires", res, err, fake.fires)
+	}
+
+	k.Pause()
+	if _, err := k.SimulateOnce(); err == nil {
+		t.Error("expected an error test firing while paused")
+	}
+	if fake.fires != 1 {
+		t.Errorf("fires = %d, want 1", fake.fires)
+	}
+}
+
+func TestSimulateOnceUnsupported(t *testing.T) {
+	k := New(WithPlatform(&countingKeepAlive{}))
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	defer k.Stop()
+	if _, err := k.SimulateOnce(); err == nil {
+		t.Error("expected an error from a keep-alive that cannot simulate")
+	}
+}
+
+func TestCompetingSimulatorRefusesActiveSession(t *testing.T) {
+	orig := detectCompetitors
+	detectCompetitors = func() []platform.Competitor {
+		return []platform.Competitor{{Name: "Amphetamine"}, {Name: "Jiggler", Simulates: true}}
+	}
+	t.Cleanup(func() { detectCompetitors = orig })
+
+	k := New(WithPlatform(&countingKeepAlive{}))
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("a session without activity simulation was refused: %v", err)
+	}
+	k.Stop()
+
+	k.SetSimulateActivity(true)
+	err := k.StartIndefinite()
+	if err == nil || !strings.Contains(err.Error(), "Jiggler") || strings.Contains(err.Error(), "Amphetamine") {
+		t.Fatalf("StartIndefinite() error = %v, want one naming only Jiggler", err)
+	}
+	if k.IsRunning() {
+		t.Fatal("refused session is running")
+	}
+
+	k.SetIgnoreConflicts(true)
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite() with conflicts ignored: %v", err)
+	}
+	k.Stop()
+}
+
+// observableKeepAlive keeps the Observer it was given.
+type observableKeepAlive struct {
+	countingKeepAlive
+	observer platform.Observer
+}
+
+func (o *observableKeepAlive) SetObserver(obs platform.Observer) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.observer = obs
+}
+
+func TestSubscribeReceivesSessionEvents(t *testing.T) {
+	fake := &observableKeepAlive{}
+	k := New(WithPlatform(fake))
+	events, unsubscribe := k.Subscribe()
+	defer unsubscribe()
+
+	if err := k.StartTimed(time.Hour); err != nil {
+		t.Fatalf("StartTimed failed: %v", err)
+	}
+	fake.observer.InhibitorFailed("caffeinate", errors.New("exited"))
+	fake.observer.SimulationPerformed("uinput")
+	k.Stop()
+	if err := k.StartTimed(time.Hour); err != nil {
+		t.Fatalf("StartTimed failed: %v", err)
+	}
+	k.Expire()
+
+	want := []EventType{EventStarted, EventInhibitorFailed, EventSimulationPerformed, EventStopped, EventStarted, EventExpired}
+	for i, w := range want {
+		select {
+		case e := <-events:
+			if e.Type != w {
+				t.Fatalf("event %d = %v, want %v", i, e.Type, w)
+			}
+			if e.Type == EventInhibitorFailed && (e.Inhibitor != "caffeinate" || e.Err == nil) {
+				t.Errorf("inhibitor event = %+v", e)
+			}
+			if e.Type == EventSimulationPerformed && e.Method != "uinput" {
+				t.Errorf("simulation event = %+v", e)
+			}
+		default:
+			t.Fatalf("event %d missing, want %v", i, w)
+		}
+	}
+
+	unsubscribe()
+	if _, open := <-events; open {
+		t.Error("channel still open after unsubscribing")
+	}
+	unsubscribe()
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	k.Stop()
+}
+
+func TestSleepAttempts(t *testing.T) {
+	fake := &observableKeepAlive{}
+	k := New(WithPlatform(fake))
+	events, unsubscribe := k.Subscribe()
+	defer unsubscribe()
+
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	<-events
+	if _, ok := k.LastSleepAttempt(); ok {
+		t.Fatal("LastSleepAttempt() reports an attempt before any")
+	}
+	at := time.Date(2026, 10, 16, 14, 32, 0, 0, time.UTC)
+	fake.observer.(platform.SleepObserver).SleepAttempted(at, true)
+	select {
+	case e := <-events:
+		if e.Type != EventSleepAttempted || !e.Time.Equal(at) || !e.Blocked {
+			t.Errorf("sleep event = %+v", e)
+		}
+	default:
+		t.Fatal("no event for the sleep attempt")
+	}
+	if a, ok := k.LastSleepAttempt(); !ok || !a.Time.Equal(at) || !a.Blocked {
+		t.Errorf("LastSleepAttempt() = %+v, %v", a, ok)
+	}
+
+	k.Stop()
+	if _, ok := k.LastSleepAttempt(); ok {
+		t.Error("LastSleepAttempt() kept after the session stopped")
+	}
+}
+
+// keyTappingKeepAlive records the simulation key it was last given.
+type keyTappingKeepAlive struct {
+	countingKeepAlive
+	key string
+}
+
+func (t *keyTappingKeepAlive) SetSimulationKey(key string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.key = key
+	return nil
+}
+
+func TestSimulationKey(t *testing.T) {
+	k := New(WithPlatform(&countingKeepAlive{}))
+	if err := k.SetSimulationKey("F12"); err == nil {
+		t.Error("SetSimulationKey accepted a key outside F13-F24")
+	}
+	if err := k.SetSimulationKey("f15"); err != nil {
+		t.Fatalf("SetSimulationKey failed: %v", err)
+	}
+	if err := k.StartIndefinite(); err == nil {
+		k.Stop()
+		t.Error("session started with a key on a platform that cannot tap one")
+	}
+
+	fake := &keyTappingKeepAlive{}
+	k = New(WithPlatform(fake))
+	if err := k.SetSimulationKey("f15"); err != nil {
+		t.Fatalf("SetSimulationKey failed: %v", err)
+	}
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	defer k.Stop()
+	fake.mu.Lock()
+	key := fake.key
+	fake.mu.Unlock()
+	if key != "F15" || k.SimulationKey() != "F15" {
+		t.Errorf("platform key = %q, SimulationKey() = %q, want F15", key, k.SimulationKey())
+	}
+	if err := k.SetSimulationKey(""); err != nil {
+		t.Fatalf("SetSimulationKey(\"\") failed: %v", err)
+	}
+	fake.mu.Lock()
+	key = fake.key
+	fake.mu.Unlock()
+	if key != "" {
+		t.Errorf("platform still taps %q", key)
+	}
+}
+
+// simulationKeepAlive records the activity-simulation settings it was
+// last given.
+type simulationKeepAlive struct {
+	countingKeepAlive
+	simulate bool
+	timings  platform.Timings
+}
+
+func (s *simulationKeepAlive) SetSimulateActivity(simulate bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.simulate = simulate
+}
+
+func (s *simulationKeepAlive) SetTimings(t platform.Timings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timings = t
+}
+
+func TestSimulationChangesDuringSession(t *testing.T) {
+	fake := &simulationKeepAlive{}
+	k := New(WithPlatform(fake))
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	defer k.Stop()
+	events, unsubscribe := k.Subscribe()
+	defer unsubscribe()
+
+	k.SetSimulateActivity(true)
+	if err := k.SetSimulationInterval(time.Second); err == nil {
+		t.Error("SetSimulationInterval accepted an interval below the minimum")
+	}
+	if err := k.SetSimulationInterval(2 * time.Minute); err != nil {
+		t.Fatalf("SetSimulationInterval failed: %v", err)
+	}
+	k.SetSimulateActivity(true)
+
+	fake.mu.Lock()
+	simulate, interval := fake.simulate, fake.timings.ChatAppActivityInterval
+	fake.mu.Unlock()
+	if !simulate || interval != 2*time.Minute {
+		t.Errorf("platform simulate, interval = %v, %v, want true, 2m", simulate, interval)
+	}
+
+	want := []Event{
+		{Type: EventSimulationChanged, Method: "auto", SimulateActivity: true, Interval: platform.ChatAppActivityInterval},
+		{Type: EventSimulationChanged, Method: "auto", SimulateActivity: true, Interval: 2 * time.Minute},
+	}
+	for i, w := range want {
+		select {
+		case e := <-events:
+			e.Time = time.Time{}
+			if e != w {
+				t.Errorf("event %d = %+v, want %+v", i, e, w)
+			}
+		default:
+			t.Fatalf("event %d missing, want %+v", i, w)
+		}
+	}
+	select {
+	case e := <-events:
+		t.Errorf("unexpected event %+v for an unchanged setting", e)
+	default:
+	}
+}
+
+// methodKeepAlive records the simulation method it was given and refuses
+// xdotool.
+type methodKeepAlive struct {
+	observableKeepAlive
+	method string
+}
+
+func (m *methodKeepAlive) SetSimulationMethod(method string) error {
+	if method == "xdotool" {
+		return errors.New("xdotool is not available")
+	}
+	m.method = method
+	return nil
+}
+
+func TestSetSimulationMethod(t *testing.T) {
+	fake := &methodKeepAlive{}
+	k := New(WithPlatform(fake))
+	if err := k.SetSimulationMethod("ydotool"); err != nil {
+		t.Fatalf("SetSimulationMethod before start: %v", err)
+	}
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	defer k.Stop()
+	if fake.method != "ydotool" {
+		t.Errorf("platform method = %q after start, want ydotool", fake.method)
+	}
+
+	if err := k.SetSimulationMethod("xdotool"); err == nil {
+		t.Error("expected the platform's refusal")
+	}
+	if err := k.SetSimulationMethod("auto"); err != nil || fake.method != "" {
+		t.Errorf("SetSimulationMethod(auto) = %v, platform method %q", err, fake.method)
+	}
+
+	if got := k.SimulationMethod(); got != "" {
+		t.Errorf("SimulationMethod() = %q before any simulation", got)
+	}
+	fake.observer.SimulationPerformed("uinput")
+	if got := k.SimulationMethod(); got != "uinput" {
+		t.Errorf("SimulationMethod() = %q, want uinput", got)
+	}
+	k.Stop()
+	if got := k.SimulationMethod(); got != "" {
+		t.Errorf("SimulationMethod() = %q after the session ended", got)
+	}
+}
+
+// fragileKeepAlive fails its health check once broken is set, until it is
+// started again.
+type fragileKeepAlive struct {
+	countingKeepAlive
+	broken bool
+}
+
+func (f *fragileKeepAlive) Start(ctx context.Context) error {
+	f.mu.Lock()
+	f.broken = false
+	f.mu.Unlock()
+	return f.countingKeepAlive.Start(ctx)
+}
+
+func (f *fragileKeepAlive) Healthy() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.broken {
+		return errors.New("caffeinate is not running")
+	}
+	return nil
+}
+
+func (f *fragileKeepAlive) breakDown() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.broken = true
+}
+
+func TestWatchdogRestartsFailedPlatform(t *testing.T) {
+	origInterval := healthCheckInterval
+	healthCheckInterval = 10 * time.Millisecond
+	t.Cleanup(func() { healthCheckInterval = origInterval })
+
+	fake := &fragileKeepAlive{}
+	k := New(WithPlatform(fake))
+	events, unsubscribe := k.Subscribe()
+	defer unsubscribe()
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	defer k.Stop()
+	<-events // EventStarted
+
+	time.Sleep(50 * time.Millisecond)
+	if starts, stops := fake.counts(); starts != 1 || stops != 0 {
+		t.Fatalf("healthy platform restarted: %d starts, %d stops", starts, stops)
+	}
+
+	fake.breakDown()
+	select {
+	case e := <-events:
+		if e.Type != EventPlatformRestarted || e.Err == nil {
+			t.Fatalf("event = %+v, want EventPlatformRestarted with its cause", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("failed platform was not restarted")
+	}
+	if starts, stops := fake.counts(); starts != 2 || stops != 1 {
+		t.Errorf("platform started %d and stopped %d times, want 2 and 1", starts, stops)
+	}
+	if !k.IsRunning() {
+		t.Error("session ended when the platform was restarted")
+	}
+}
+
+// slowStoppingKeepAlive is a fragileKeepAlive whose Stop waits until
+// release is closed, like a platform waiting out its stop timeout.
+type slowStoppingKeepAlive struct {
+	fragileKeepAlive
+	stopping chan struct{}
+	release  chan struct{}
+	once     sync.Once
+}
+
+func (s *slowStoppingKeepAlive) Stop() error {
+	s.once.Do(func() {
+		close(s.stopping)
+		<-s.release
+	})
+	return s.fragileKeepAlive.Stop()
+}
+
+func TestWatchdogRestartDoesNotHoldLock(t *testing.T) {
+	origInterval := healthCheckInterval
+	healthCheckInterval = 10 * time.Millisecond
+	t.Cleanup(func() { healthCheckInterval = origInterval })
+
+	fake := &slowStoppingKeepAlive{stopping: make(chan struct{}), release: make(chan struct{})}
+	k := New(WithPlatform(fake))
+	events, unsubscribe := k.Subscribe()
+	defer unsubscribe()
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	defer k.Stop()
+	<-events // EventStarted
+
+	fake.breakDown()
+	select {
+	case <-fake.stopping:
+	case <-time.After(2 * time.Second):
+		t.Fatal("failed platform was not stopped")
+	}
+	running := make(chan bool)
+	go func() { running <- k.IsRunning() }()
+	select {
+	case ok := <-running:
+		if !ok {
+			t.Error("IsRunning() = false while the platform restarts")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("IsRunning blocked while the platform was stopping")
+	}
+	close(fake.release)
+
+	select {
+	case e := <-events:
+		if e.Type != EventPlatformRestarted {
+			t.Fatalf("event = %+v, want EventPlatformRestarted", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("failed platform was not restarted")
+	}
+}
+
+func TestWatchdogRestartAfterSessionEnded(t *testing.T) {
+	origInterval := healthCheckInterval
+	healthCheckInterval = 10 * time.Millisecond
+	t.Cleanup(func() { healthCheckInterval = origInterval })
+
+	fake := &slowStoppingKeepAlive{stopping: make(chan struct{}), release: make(chan struct{})}
+	k := New(WithPlatform(fake))
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+
+	fake.breakDown()
+	select {
+	case <-fake.stopping:
+	case <-time.After(2 * time.Second):
+		t.Fatal("failed platform was not stopped")
+	}
+	k.StopNow()
+	close(fake.release)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		starts, stops := fake.counts()
+		if starts == 2 && stops == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("platform started %d and stopped %d times, want the restart undone", starts, stops)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPauseDoesNotHoldLock(t *testing.T) {
+	fake := &slowStoppingKeepAlive{stopping: make(chan struct{}), release: make(chan struct{})}
+	k := New(WithPlatform(fake))
+	if err := k.StartTimed(time.Hour); err != nil {
+		t.Fatalf("StartTimed failed: %v", err)
+	}
+	defer k.Stop()
+
+	paused := make(chan error)
+	go func() { paused <- k.Pause() }()
+	select {
+	case <-fake.stopping:
+	case <-time.After(2 * time.Second):
+		t.Fatal("platform was not stopped on Pause")
+	}
+	remaining := make(chan time.Duration)
+	go func() { remaining <- k.TimeRemaining() }()
+	select {
+	case <-remaining:
+	case <-time.After(time.Second):
+		t.Fatal("TimeRemaining blocked while the platform was stopping")
+	}
+	close(fake.release)
+
+	select {
+	case err := <-paused:
+		if err != nil {
+			t.Fatalf("Pause failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Pause did not return")
+	}
+	if !k.Paused() || !k.Suspended() {
+		t.Fatal("expected the session to be paused and suspended")
+	}
+	if err := k.Resume(); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if starts, stops := fake.counts(); starts != 2 || stops != 1 {
+		t.Fatalf("platform started %d and stopped %d times, want 2 and 1", starts, stops)
+	}
+}
+
+// reportingKeepAlive reports fixed inhibitors while running.
+type reportingKeepAlive struct {
+	observableKeepAlive
+}
+
+func (r *reportingKeepAlive) ActiveMethods() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.starts == r.stops {
+		return []string{}
+	}
+	return []string{"systemd-inhibit", "dbus-freedesktop"}
+}
+
+func TestStatus(t *testing.T) {
+	fixed := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	defer func(old func() time.Time) { now = old }(now)
+	now = func() time.Time { return fixed }
+
+	fake := &reportingKeepAlive{}
+	k := New(WithPlatform(fake))
+	if s := k.Status(); s.Running || s.Mode != "" || len(s.Methods) != 0 {
+		t.Fatalf("Status() before start = %+v", s)
+	}
+
+	if err := k.StartTimed(time.Hour); err != nil {
+		t.Fatalf("StartTimed failed: %v", err)
+	}
+	defer k.Stop()
+	s := k.Status()
+	if !s.Running || s.Mode != ModeTimed || !s.EndTime.Equal(k.EndTime()) || s.EndTime.IsZero() {
+		t.Errorf("Status() = %+v, want a timed session ending at %v", s, k.EndTime())
+	}
+	if want := []string{"systemd-inhibit", "dbus-freedesktop"}; !slices.Equal(s.Methods, want) {
+		t.Errorf("Methods = %v, want %v", s.Methods, want)
+	}
+	if s.SimulationMethod != "" || !s.LastSimulation.IsZero() {
+		t.Errorf("simulation reported before any: %+v", s)
+	}
+	if s.RemoteSession {
+		t.Errorf("remote desktop session reported at the console: %+v", s)
+	}
+	inRemoteSession = func() bool { return true }
+	defer func() { inRemoteSession = platform.InRemoteSession }()
+	if s := k.Status(); !s.RemoteSession {
+		t.Errorf("Status() in a remote desktop session = %+v", s)
+	}
+
+	fake.observer.SimulationPerformed("uinput")
+	if s := k.Status(); s.SimulationMethod != "uinput" || !s.LastSimulation.Equal(fixed) {
+		t.Errorf("Status() after a simulation = %+v", s)
+	}
+
+	if err := k.Pause(); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	if s := k.Status(); !s.Running || len(s.Methods) != 0 {
+		t.Errorf("Status() while paused = %+v, want running with no methods", s)
+	}
+	if err := k.Resume(); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+
+	k.Stop()
+	if s := k.Status(); s.Running || !s.LastSimulation.IsZero() {
+		t.Errorf("Status() after stop = %+v", s)
+	}
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	if s := k.Status(); s.Mode != ModeIndefinite || !s.EndTime.IsZero() {
+		t.Errorf("Status() = %+v, want an indefinite session", s)
+	}
+}
+
+func TestStatusCounters(t *testing.T) {
+	fake := &observableKeepAlive{}
+	k := New(WithPlatform(fake))
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+
+	fake.observer.SimulationSkipped()
+	fake.observer.SimulationSkipped()
+	fake.observer.SimulationPerformed("uinput")
+	fake.observer.InhibitorFailed("systemd-inhibit", errors.New("exited"))
+	fake.observer.InhibitorRestored("systemd-inhibit")
+	if err := k.Pause(); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	if err := k.Resume(); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+
+	want := Counters{Activations: 2, Reactivations: 1, Simulations: 1, IdleSkips: 2, InhibitorFailures: 1}
+	if got := k.Status().Counters; got != want {
+		t.Errorf("Status().Counters = %+v, want %+v", got, want)
+	}
+
+	k.Stop()
+	if got := k.Status().Counters; got != (Counters{}) {
+		t.Errorf("Status().Counters after stop = %+v, want zero", got)
+	}
+}
+
+func TestJSONFollowsSchemas(t *testing.T) {
+	at := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	docs := map[string][]any{
+		"status": {
+			Status{},
+			Status{Running: true, Mode: ModeTimed, EndTime: at, Methods: []string{"caffeinate"},
+				SimulationMethod: "uinput", LastSimulation: at, Counters: Counters{Activations: 1}, RemoteSession: true},
+		},
+		"event": {
+			Event{Type: EventStarted, Time: at},
+			Event{Type: EventInhibitorFailed, Time: at, Inhibitor: "systemd-inhibit", Err: errors.New("exited")},
+			Event{Type: EventSimulationPerformed, Time: at, Method: "uinput"},
+			Event{Type: EventSimulationChanged, Time: at, Method: "auto", Interval: 45 * time.Second},
+			Event{Type: EventSleepAttempted, Time: at, Blocked: true},
+		},
+	}
+	for name, values := range docs {
+		data, err := schema.Get(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sch struct {
+			Required   []string                   `json:"required"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		}
+		if err := json.Unmarshal(data, &sch); err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range values {
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				t.Fatalf("marshal %+v: %v", v, err)
+			}
+			var got map[string]any
+			if err := json.Unmarshal(encoded, &got); err != nil {
+				t.Fatal(err)
+			}
+			for key := range got {
+				if _, ok := sch.Properties[key]; !ok {
+					t.Errorf("%s JSON %s has %q, which the schema does not describe", name, encoded, key)
+				}
+			}
+			for _, key := range sch.Required {
+				if _, ok := got[key]; !ok {
+					t.Errorf("%s JSON %s lacks required %q", name, encoded, key)
+				}
+			}
+		}
+	}
+}
+
+func TestSetSimulationMethodUnsupported(t *testing.T) {
+	k := New(WithPlatform(&countingKeepAlive{}))
+	if err := k.StartIndefinite(); err != nil {
+		t.Fatalf("StartIndefinite failed: %v", err)
+	}
+	defer k.Stop()
+	if err := k.SetSimulationMethod("uinput"); err == nil {
+		t.Error("expected an error from a keep-alive with a single method")
+	}
+}
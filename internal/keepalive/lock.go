@@ -0,0 +1,101 @@
+package keepalive
+
+import (
+	"context"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/crash"
+	"github.com/stigoleg/keep-alive/internal/platform"
+)
+
+// lockPollInterval is how often the screen lock is checked for
+// SetStopOnLock.
+const lockPollInterval = 5 * time.Second
+
+// readScreenLocked is replaced in tests.
+var readScreenLocked = platform.ScreenLocked
+
+// SetStopOnLock ends the session once the screen is locked, for users who
+// lock the machine when they leave it and want it to sleep then. Changes
+// apply immediately to a running session.
+func (k *Keeper) SetStopOnLock(stop bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.stopOnLock == stop {
+		return
+	}
+	k.stopOnLock = stop
+	if k.lockCancel != nil {
+		k.lockCancel()
+		k.lockCancel = nil
+	}
+	if k.running {
+		k.startLockWatchLocked()
+	}
+}
+
+// StopOnLock reports whether the session ends once the screen is locked.
+func (k *Keeper) StopOnLock() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.stopOnLock
+}
+
+// startLockWatchLocked starts checking the screen lock for the current
+// session. Callers must hold k.mu.
+func (k *Keeper) startLockWatchLocked() {
+	if !k.stopOnLock || k.lockCancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(k.ctx)
+	k.lockCancel = cancel
+	go k.watchLock(ctx, k.ctx)
+}
+
+// watchLock checks the screen lock until ctx is done or the screen locks.
+func (k *Keeper) watchLock(ctx, sessionCtx context.Context) {
+	defer crash.Guard("lock-watch")
+
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+
+	// failing is set while checks fail, so each failure is logged once.
+	failing := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		done, err := k.checkLock(ctx, sessionCtx)
+		if err != nil && !failing {
+			k.logger().Warn("screen lock unavailable", "err", err)
+		}
+		failing = err != nil
+		if done {
+			return
+		}
+	}
+}
+
+// checkLock stops the session in sessionCtx if the screen is locked and
+// reports whether it did.
+func (k *Keeper) checkLock(ctx, sessionCtx context.Context) (bool, error) {
+	locked, err := readScreenLocked()
+	if err != nil || !locked {
+		return false, err
+	}
+
+	k.mu.Lock()
+	stillCurrent := ctx.Err() == nil && k.running && k.ctx == sessionCtx
+	k.mu.Unlock()
+	if !stillCurrent {
+		return true, nil
+	}
+
+	k.logger().Info("screen locked, stopping")
+	k.Stop()
+	return true, nil
+}
@@ -0,0 +1,29 @@
+package keepalive
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+var currentLogger atomic.Pointer[slog.Logger]
+
+// SetLogger sets the logger used by the package and by Keepers built
+// without WithLogger. A nil logger restores the default, slog.Default.
+func SetLogger(l *slog.Logger) {
+	currentLogger.Store(l)
+}
+
+func logger() *slog.Logger {
+	if l := currentLogger.Load(); l != nil {
+		return l
+	}
+	return slog.Default()
+}
+
+// logger returns the logger set with WithLogger, or the package logger.
+func (k *Keeper) logger() *slog.Logger {
+	if k.log != nil {
+		return k.log
+	}
+	return logger()
+}
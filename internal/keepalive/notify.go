@@ -0,0 +1,34 @@
+package keepalive
+
+import (
+	"github.com/stigoleg/keep-alive/internal/platform"
+)
+
+// sendNotification is replaced in tests.
+var sendNotification = platform.Notify
+
+// SetNotify controls whether a desktop notification is shown when a timed
+// session expires or is stopped.
+func (k *Keeper) SetNotify(notify bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.notify = notify
+}
+
+// Notify reports whether end-of-session notifications are enabled.
+func (k *Keeper) Notify() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.notify
+}
+
+// notifyEnded tells the user that a timed session is over.
+func notifyEnded(expired bool) {
+	body := "Timed session stopped. Your computer can sleep again."
+	if expired {
+		body = "Timed session finished. Your computer can sleep again."
+	}
+	if err := sendNotification("Keep-Alive", body); err != nil {
+		logger().Warn("notification failed", "err", err)
+	}
+}
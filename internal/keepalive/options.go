@@ -0,0 +1,104 @@
+package keepalive
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/platform"
+)
+
+// Option configures a Keeper built by New.
+type Option func(*Keeper)
+
+// New returns a Keeper configured by opts. Everything an option sets can
+// also be changed later with the corresponding Set method.
+func New(opts ...Option) *Keeper {
+	k := &Keeper{}
+	for _, opt := range opts {
+		opt(k)
+	}
+	return k
+}
+
+// WithIdleThreshold sets how long the user must be idle before activity is
+// simulated.
+func WithIdleThreshold(d time.Duration) Option {
+	return func(k *Keeper) {
+		k.timings.IdleThreshold = d
+	}
+}
+
+// WithActivityInterval sets the period of system-level activity
+// assertions.
+func WithActivityInterval(d time.Duration) Option {
+	return func(k *Keeper) {
+		k.timings.ActivityInterval = d
+	}
+}
+
+// WithSimulationInterval sets the minimum time between simulated mouse
+// movements.
+func WithSimulationInterval(d time.Duration) Option {
+	return func(k *Keeper) {
+		k.timings.ChatAppActivityInterval = d
+	}
+}
+
+// WithTimings sets all the activity intervals at once. Zero fields keep
+// their defaults.
+func WithTimings(t platform.Timings) Option {
+	return func(k *Keeper) {
+		k.timings = t
+	}
+}
+
+// WithLogger sets the logger for the Keeper's own messages, in place of
+// the package logger set with SetLogger.
+func WithLogger(l *slog.Logger) Option {
+	return func(k *Keeper) {
+		k.log = l
+	}
+}
+
+// WithPlatform replaces the platform keep-alive that sessions drive, for
+// embedding Keep-Alive with a keep-alive of one's own or a fake in tests.
+func WithPlatform(p platform.KeepAlive) Option {
+	return func(k *Keeper) {
+		k.keeper = p
+	}
+}
+
+// WithSimulateActivity turns activity simulation on or off. It is subject
+// to the policy set afterwards with SetPolicy.
+func WithSimulateActivity(simulate bool) Option {
+	return func(k *Keeper) {
+		k.simulateActivity = simulate
+	}
+}
+
+// WithPresenceOnly runs sessions with activity simulation alone, as
+// SetPresenceOnly does.
+func WithPresenceOnly(presenceOnly bool) Option {
+	return func(k *Keeper) {
+		k.presenceOnly = presenceOnly
+	}
+}
+
+// WithAwayMode requests away mode on platforms that support it, as
+// SetAwayMode does.
+func WithAwayMode(enabled bool) Option {
+	return func(k *Keeper) {
+		k.awayMode = enabled
+	}
+}
+
+// WithSimulationMethod selects the activity-simulation input method, as
+// SetSimulationMethod does, or "auto" to let the platform choose.
+func WithSimulationMethod(method string) Option {
+	return func(k *Keeper) {
+		if method == "auto" {
+			method = ""
+		}
+		k.simMethod = method
+	}
+}
@@ -0,0 +1,121 @@
+package keepalive
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Pause suspends a running session until Resume: the system may sleep again
+// and activity simulation stops. A timed session's countdown is frozen while
+// it is paused, so Resume restores the time that was left. A session started
+// with StartUntil keeps its deadline instead, and ends when it is resumed
+// if the deadline passed meanwhile. Pausing a paused session does nothing.
+func (k *Keeper) Pause() error {
+	k.mu.Lock()
+	if !k.running {
+		k.mu.Unlock()
+		return errors.New("keep-alive is not running")
+	}
+	if k.paused {
+		k.mu.Unlock()
+		return nil
+	}
+	k.paused = true
+	k.pausedAt = now()
+	if k.timer != nil {
+		k.timer.Stop()
+		k.timer = nil
+	}
+	removeStatus(k.statusPath)
+	k.writeStateLocked()
+	sessionCtx := k.ctx
+	k.mu.Unlock()
+
+	k.updateSuspended(sessionCtx, "paused")
+	k.logger().Info("session paused")
+	return nil
+}
+
+// Resume continues a paused session. A timed session ends as much later as
+// it was paused for, unless it was started with StartUntil. Resuming a session that is not paused does nothing. If
+// the platform keep-alive fails to start again, the session stays paused
+// and the error is returned.
+func (k *Keeper) Resume() error {
+	k.mu.Lock()
+	if !k.running {
+		k.mu.Unlock()
+		return errors.New("keep-alive is not running")
+	}
+	if !k.paused {
+		k.mu.Unlock()
+		return nil
+	}
+	// The pause is accounted for now, so that a Pause made while the
+	// platform keep-alive starts begins a pause of its own.
+	at := now()
+	pausedAt := k.pausedAt
+	pausedFor := at.Sub(pausedAt)
+	k.paused = false
+	k.pausedAt = time.Time{}
+	k.pausedFor += pausedFor
+	if !k.endTime.IsZero() && !k.untilDeadline {
+		k.endTime = k.endTime.Add(pausedFor)
+	}
+	sessionCtx := k.ctx
+	k.mu.Unlock()
+
+	err := k.updateSuspended(sessionCtx, "resumed")
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !k.running || k.ctx != sessionCtx {
+		return errors.New("keep-alive is not running")
+	}
+	if err != nil {
+		if !k.paused {
+			// The session stays paused from when the resume began.
+			k.paused = true
+			k.pausedAt = at
+		}
+		return fmt.Errorf("resume failed: %w", err)
+	}
+	if k.paused {
+		// Paused again while resuming.
+		return nil
+	}
+	if !k.endTime.IsZero() {
+		k.scheduleStopLocked(k.endTime.Sub(now()))
+	}
+	k.writeStatusLocked()
+	k.writeStateLocked()
+	k.logger().Info("session resumed after pause", "paused_for", pausedFor.Round(time.Second))
+	return nil
+}
+
+// Paused reports whether the running session is paused.
+func (k *Keeper) Paused() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.paused
+}
+
+// countdownAtLocked returns the time a timed session's remaining time is
+// counted from: now, or when it was paused, since a pause freezes the
+// countdown of all but sessions with a deadline. Callers must hold k.mu.
+func (k *Keeper) countdownAtLocked() time.Time {
+	if k.paused && !k.untilDeadline {
+		return k.pausedAt
+	}
+	return now()
+}
+
+// activeLocked returns how long the current session has been running,
+// excluding the time it was paused. Callers must hold k.mu.
+func (k *Keeper) activeLocked(at time.Time) time.Duration {
+	active := at.Sub(k.startTime) - k.pausedFor
+	if k.paused {
+		active -= at.Sub(k.pausedAt)
+	}
+	return active
+}
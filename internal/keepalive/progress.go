@@ -0,0 +1,77 @@
+package keepalive
+
+import (
+	"context"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/crash"
+	"github.com/stigoleg/keep-alive/internal/platform"
+)
+
+// progressInterval is how often the taskbar progress of a timed session is
+// refreshed.
+const progressInterval = 5 * time.Second
+
+// setTaskbarProgress and clearTaskbarProgress are replaced in tests.
+var (
+	setTaskbarProgress   = platform.SetTaskbarProgress
+	clearTaskbarProgress = platform.ClearTaskbarProgress
+)
+
+// startProgressLocked starts showing the progress of the current timed
+// session on the taskbar or dock icon. Callers must hold k.mu.
+func (k *Keeper) startProgressLocked() {
+	if k.endTime.IsZero() || k.progressDone != nil {
+		return
+	}
+	done := make(chan struct{})
+	k.progressDone = done
+	go k.showProgress(k.ctx, done)
+}
+
+// showProgress refreshes the taskbar progress until ctx is done and then
+// removes it. It gives up quietly where no taskbar integration is available.
+func (k *Keeper) showProgress(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	defer crash.Guard("taskbar-progress")
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	for {
+		fraction, ok := k.progress(ctx)
+		if !ok {
+			break
+		}
+		if err := setTaskbarProgress(fraction); err != nil {
+			k.logger().Debug("taskbar progress unavailable", "err", err)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+		}
+	}
+
+	if err := clearTaskbarProgress(); err != nil {
+		k.logger().Debug("clearing taskbar progress failed", "err", err)
+	}
+}
+
+// progress returns the elapsed fraction of the timed session in ctx, or false
+// once that session is over.
+func (k *Keeper) progress(ctx context.Context) (float64, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if ctx.Err() != nil || !k.running || k.ctx != ctx || k.endTime.IsZero() {
+		return 0, false
+	}
+	total := k.endTime.Sub(k.startTime) - k.pausedFor
+	if total <= 0 {
+		return 1, true
+	}
+	fraction := float64(k.activeLocked(time.Now())) / float64(total)
+	return min(max(fraction, 0), 1), true
+}
@@ -0,0 +1,104 @@
+package keepalive
+
+import (
+	"context"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/crash"
+	"github.com/stigoleg/keep-alive/internal/schedule"
+)
+
+// SetQuietHours suppresses activity simulation within the windows of s,
+// such as during recorded demos, while sleep inhibition carries on. Nil
+// removes the quiet hours. Changes apply immediately to a running session.
+func (k *Keeper) SetQuietHours(s *schedule.Schedule) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.quietHours = s
+	if k.quietCancel != nil {
+		k.quietCancel()
+		k.quietCancel = nil
+	}
+	if !k.running {
+		return
+	}
+	k.setQuietLocked(s != nil && s.Active(now()))
+	k.startQuietWatchLocked()
+}
+
+// QuietHours returns the quiet hours, or nil when there are none.
+func (k *Keeper) QuietHours() *schedule.Schedule {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.quietHours
+}
+
+// Quiet reports whether a running session has activity simulation
+// suppressed because it is within its quiet hours.
+func (k *Keeper) Quiet() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.running && k.quiet
+}
+
+// setQuietLocked enters or leaves the quiet hours, passing the change to
+// the platform keep-alive unless it is suspended; resuming configures it
+// afresh. Callers must hold k.mu.
+func (k *Keeper) setQuietLocked(quiet bool) {
+	if k.quiet == quiet {
+		return
+	}
+	k.quiet = quiet
+	if !k.suspended {
+		k.keeper.SetSimulateActivity(k.simulateActivity && !quiet)
+	}
+	if quiet {
+		k.logger().Info("quiet hours began, activity simulation suppressed")
+	} else {
+		k.logger().Info("quiet hours ended")
+	}
+}
+
+// startQuietWatchLocked starts following the quiet hours for the current
+// session. Callers must hold k.mu.
+func (k *Keeper) startQuietWatchLocked() {
+	if k.quietHours == nil || k.quietCancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(k.ctx)
+	k.quietCancel = cancel
+	go k.watchQuietHours(ctx, k.ctx, k.quietHours, now)
+}
+
+// watchQuietHours suppresses and restores activity simulation for the
+// session in sessionCtx as windows of s open and close by clock, until ctx
+// is done.
+func (k *Keeper) watchQuietHours(ctx, sessionCtx context.Context, s *schedule.Schedule, clock func() time.Time) {
+	defer crash.Guard("quiet-hours-watch")
+
+	for {
+		t := clock()
+		k.applyQuietHours(ctx, sessionCtx, s.Active(t))
+
+		timer := time.NewTimer(scheduleWait(s, t))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// applyQuietHours suppresses activity simulation within the quiet hours and
+// restores it outside them.
+func (k *Keeper) applyQuietHours(ctx, sessionCtx context.Context, quiet bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if ctx.Err() != nil || !k.running || k.ctx != sessionCtx {
+		return
+	}
+	k.setQuietLocked(quiet)
+}
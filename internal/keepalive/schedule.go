@@ -0,0 +1,111 @@
+package keepalive
+
+import (
+	"context"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/crash"
+	"github.com/stigoleg/keep-alive/internal/schedule"
+)
+
+// scheduleCheckInterval bounds how long the schedule watcher sleeps, so
+// that clock changes and system sleep are noticed.
+const scheduleCheckInterval = time.Minute
+
+// now is replaced in tests.
+var now = time.Now
+
+// SetSchedule restricts a running session to the windows of s: outside
+// them the session is suspended, as on battery with SetACOnly, and it
+// resumes when the next window opens. Nil removes the restriction. Changes
+// apply immediately to a running session.
+func (k *Keeper) SetSchedule(s *schedule.Schedule) {
+	k.mu.Lock()
+	k.schedule = s
+	if k.scheduleCancel != nil {
+		k.scheduleCancel()
+		k.scheduleCancel = nil
+	}
+	if !k.running {
+		k.mu.Unlock()
+		return
+	}
+	k.offSchedule = s != nil && !s.Active(now())
+	k.startScheduleWatchLocked()
+	sessionCtx := k.ctx
+	k.mu.Unlock()
+	k.updateSuspended(sessionCtx, "schedule")
+}
+
+// Schedule returns the session schedule, or nil when there is none.
+func (k *Keeper) Schedule() *schedule.Schedule {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.schedule
+}
+
+// OffSchedule reports whether a running session is suspended because it is
+// outside its scheduled windows.
+func (k *Keeper) OffSchedule() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.running && k.offSchedule
+}
+
+// startScheduleWatchLocked starts following the schedule for the current
+// session. Callers must hold k.mu.
+func (k *Keeper) startScheduleWatchLocked() {
+	if k.schedule == nil || k.scheduleCancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(k.ctx)
+	k.scheduleCancel = cancel
+	go k.watchSchedule(ctx, k.ctx, k.schedule, now)
+}
+
+// watchSchedule suspends and resumes the session in sessionCtx as windows of
+// s close and open by clock, until ctx is done.
+func (k *Keeper) watchSchedule(ctx, sessionCtx context.Context, s *schedule.Schedule, clock func() time.Time) {
+	defer crash.Guard("schedule-watch")
+
+	for {
+		t := clock()
+		k.applySchedule(ctx, sessionCtx, s.Active(t))
+
+		timer := time.NewTimer(scheduleWait(s, t))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// scheduleWait returns how long to wait at t before checking s again: until
+// its open window closes or the next one opens, but no longer than
+// scheduleCheckInterval.
+func scheduleWait(s *schedule.Schedule, t time.Time) time.Duration {
+	next := s.NextStart(t)
+	if end := s.End(t); !end.IsZero() {
+		next = end
+	}
+	wait := scheduleCheckInterval
+	if d := next.Sub(t); !next.IsZero() && d < wait {
+		wait = d
+	}
+	return wait
+}
+
+// applySchedule suspends the session outside the schedule and resumes it
+// inside.
+func (k *Keeper) applySchedule(ctx, sessionCtx context.Context, active bool) {
+	k.mu.Lock()
+	if ctx.Err() != nil || !k.running || k.ctx != sessionCtx {
+		k.mu.Unlock()
+		return
+	}
+	k.offSchedule = !active
+	k.mu.Unlock()
+	k.updateSuspended(sessionCtx, "schedule")
+}
@@ -0,0 +1,128 @@
+package keepalive
+
+import (
+	"errors"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/platform"
+)
+
+// SetSimulationMethod selects the input method activity simulation uses,
+// such as "uinput", for the running session and later ones. "" or "auto"
+// lets the platform rank the methods itself, switching away from one that
+// starts failing.
+func (k *Keeper) SetSimulationMethod(method string) error {
+	if method == "auto" {
+		method = ""
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.running && !k.suspended {
+		ms, ok := k.keeper.(platform.MethodSelectingKeepAlive)
+		if !ok {
+			return errors.New("selecting the simulation method is unsupported on this platform")
+		}
+		if err := ms.SetSimulationMethod(method); err != nil {
+			return err
+		}
+	}
+	k.simMethod = method
+	if method == "" {
+		method = "auto"
+	}
+	k.logger().Info("simulation method selected", "method", method)
+	if k.running {
+		k.emitSimulationChangedLocked()
+	}
+	return nil
+}
+
+// SetSimulationKey has activity simulation tap key, one of
+// platform.SimulationKeys, instead of moving the pointer, for the running
+// session and later ones; "" moves the pointer again. Some virtual desktops
+// ignore injected pointer moves but pass key presses on. Only Windows
+// supports it; sessions elsewhere refuse to start with a key set.
+func (k *Keeper) SetSimulationKey(key string) error {
+	if key != "" {
+		canonical, err := platform.ParseSimulationKey(key)
+		if err != nil {
+			return err
+		}
+		key = canonical
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.running && !k.suspended {
+		kt, ok := k.keeper.(platform.KeyTappingKeepAlive)
+		if !ok {
+			return errors.New("simulating activity with a key is only supported on Windows")
+		}
+		if err := kt.SetSimulationKey(key); err != nil {
+			return err
+		}
+	}
+	k.simKey = key
+	if key != "" {
+		k.logger().Info("simulation key selected", "key", key)
+	}
+	return nil
+}
+
+// SimulationKey returns the key activity simulation taps, or "" when it
+// moves the pointer.
+func (k *Keeper) SimulationKey() string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.simKey
+}
+
+// SetSimulationInterval sets the minimum time between simulated mouse
+// movements, or restores the default when d is zero. Changes apply
+// immediately to a running session and are announced with
+// EventSimulationChanged.
+func (k *Keeper) SetSimulationInterval(d time.Duration) error {
+	if err := (platform.Timings{ChatAppActivityInterval: d}).Validate(); err != nil {
+		return err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.timings.ChatAppActivityInterval = d
+	k.logger().Info("simulation interval changed", "interval", k.timings.WithDefaults().ChatAppActivityInterval)
+	if k.running {
+		if k.keeper != nil {
+			k.keeper.SetTimings(k.timings)
+		}
+		k.emitSimulationChangedLocked()
+	}
+	return nil
+}
+
+// SimulationInterval returns the minimum time between simulated mouse
+// movements.
+func (k *Keeper) SimulationInterval() time.Duration {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.timings.WithDefaults().ChatAppActivityInterval
+}
+
+// emitSimulationChangedLocked announces the activity-simulation settings
+// now in effect. Callers must hold k.mu.
+func (k *Keeper) emitSimulationChangedLocked() {
+	method := k.simMethod
+	if method == "" {
+		method = "auto"
+	}
+	k.emit(Event{
+		Type:             EventSimulationChanged,
+		Method:           method,
+		SimulateActivity: k.simulateActivity,
+		Interval:         k.timings.WithDefaults().ChatAppActivityInterval,
+	})
+}
+
+// SimulationMethod returns the input method that last moved the pointer in
+// the running session, or "" if none has yet.
+func (k *Keeper) SimulationMethod() string {
+	method, _ := k.activeMethod.Load().(string)
+	return method
+}
@@ -0,0 +1,35 @@
+package keepalive
+
+import (
+	"errors"
+
+	"github.com/stigoleg/keep-alive/internal/platform"
+)
+
+// SimulateOnce runs one activity-simulation cycle right away, without
+// waiting for the idle threshold and whether or not activity simulation is
+// enabled. It is meant for checking that simulation works on a machine. The
+// session must be running and not suspended.
+func (k *Keeper) SimulateOnce() (platform.SimulationResult, error) {
+	k.mu.Lock()
+	running, suspended, keeper := k.running, k.suspended, k.keeper
+	k.mu.Unlock()
+
+	switch {
+	case !running:
+		return platform.SimulationResult{}, errors.New("keep-alive is not running")
+	case suspended:
+		return platform.SimulationResult{}, errors.New("keep-alive is suspended")
+	}
+	sk, ok := keeper.(platform.SimulatingKeepAlive)
+	if !ok {
+		return platform.SimulationResult{}, errors.New("activity simulation is unsupported on this platform")
+	}
+	res := sk.SimulateOnce()
+	if res.Err != nil {
+		k.logger().Warn("simulation test fire failed", "tried", res.Tried, "err", res.Err)
+	} else {
+		k.logger().Info("simulation test fire", "method", res.Method, "points", res.Points, "duration", res.Duration)
+	}
+	return res, nil
+}
@@ -0,0 +1,123 @@
+package keepalive
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/platform"
+	"github.com/stigoleg/keep-alive/internal/schema"
+)
+
+// Session modes reported by Status.
+const (
+	ModeIndefinite = "indefinite"
+	ModeTimed      = "timed"
+	ModeUntil      = "until"
+	ModeWhile      = "while"
+)
+
+// Status is a snapshot of a Keeper's session.
+type Status struct {
+	Running bool
+	// Mode is ModeIndefinite, ModeTimed, ModeUntil or ModeWhile, or ""
+	// when not running.
+	Mode string
+	// EndTime is when a timed or until session ends; it is zero otherwise.
+	EndTime time.Time
+	// Methods lists the platform's sleep-prevention methods in effect, in
+	// the order they were activated. On Linux these are the inhibitors that
+	// activated. It is empty while the session is paused or suspended, and
+	// for platforms that do not report their methods.
+	Methods []string
+	// SimulationMethod is the input method that last moved the pointer, and
+	// LastSimulation when it did; both are zero until one has.
+	SimulationMethod string
+	LastSimulation   time.Time
+	// Counters tallies the session so far; it is zero when not running.
+	Counters Counters
+	// RemoteSession is set when the session runs in a Remote Desktop
+	// session on Windows; see platform.RemoteSessionNote.
+	RemoteSession bool
+	// PrivacyLevel is how far the session reaches into the machine: "none"
+	// when not running, "inhibit-only" or "synthetic-input". ConsentedAt is
+	// when the user chose the levels that need consent; it is zero when
+	// they have not, or when the Keeper is not limited by consent.
+	PrivacyLevel string
+	ConsentedAt  time.Time
+}
+
+// Status returns a snapshot of the current session.
+func (k *Keeper) Status() Status {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	s := Status{Running: k.running, Methods: []string{}, PrivacyLevel: k.privacyLevelLocked().String()}
+	if k.consent != nil {
+		s.ConsentedAt = k.consent.GrantedAt
+	}
+	if !k.running {
+		return s
+	}
+	switch {
+	case k.untilDeadline:
+		s.Mode = ModeUntil
+	case !k.endTime.IsZero():
+		s.Mode = ModeTimed
+	case k.condition:
+		s.Mode = ModeWhile
+	default:
+		s.Mode = ModeIndefinite
+	}
+	s.EndTime = k.endTime
+	if r, ok := k.keeper.(platform.ReportingKeepAlive); ok && !k.suspended && !k.paused {
+		s.Methods = r.ActiveMethods()
+	}
+	s.SimulationMethod, _ = k.activeMethod.Load().(string)
+	s.LastSimulation, _ = k.lastSimulation.Load().(time.Time)
+	s.Counters = k.counters.snapshot()
+	s.RemoteSession = inRemoteSession()
+	return s
+}
+
+// statusJSON is the JSON form of Status, described by the "status" schema.
+type statusJSON struct {
+	SchemaVersion    int        `json:"schema_version"`
+	Running          bool       `json:"running"`
+	Mode             string     `json:"mode,omitempty"`
+	EndTime          *time.Time `json:"end_time,omitempty"`
+	Methods          []string   `json:"methods"`
+	SimulationMethod string     `json:"simulation_method,omitempty"`
+	LastSimulation   *time.Time `json:"last_simulation,omitempty"`
+	Counters         Counters   `json:"counters"`
+	RemoteSession    bool       `json:"remote_session,omitempty"`
+	PrivacyLevel     string     `json:"privacy_level,omitempty"`
+	ConsentedAt      *time.Time `json:"consented_at,omitempty"`
+}
+
+// MarshalJSON encodes s as described by the "status" schema, leaving out
+// the times that are zero.
+func (s Status) MarshalJSON() ([]byte, error) {
+	doc := statusJSON{
+		SchemaVersion:    schema.StatusVersion,
+		Running:          s.Running,
+		Mode:             s.Mode,
+		Methods:          s.Methods,
+		SimulationMethod: s.SimulationMethod,
+		Counters:         s.Counters,
+		RemoteSession:    s.RemoteSession,
+		PrivacyLevel:     s.PrivacyLevel,
+	}
+	if doc.Methods == nil {
+		doc.Methods = []string{}
+	}
+	if !s.EndTime.IsZero() {
+		doc.EndTime = &s.EndTime
+	}
+	if !s.LastSimulation.IsZero() {
+		doc.LastSimulation = &s.LastSimulation
+	}
+	if !s.ConsentedAt.IsZero() {
+		doc.ConsentedAt = &s.ConsentedAt
+	}
+	return json.Marshal(doc)
+}
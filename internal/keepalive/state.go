@@ -0,0 +1,52 @@
+package keepalive
+
+import (
+	"os"
+
+	"github.com/stigoleg/keep-alive/internal/sessionstate"
+)
+
+// SetStateFile saves running sessions at path, so that one cut short by a
+// crash or a signal can be resumed on the next launch. A session that ends
+// through Stop or Expire removes the file; StopNow leaves it. An empty path
+// disables it.
+func (k *Keeper) SetStateFile(path string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.statePath = path
+	if k.running {
+		k.writeStateLocked()
+	}
+}
+
+// writeStateLocked saves the current session in the state file. A paused
+// timed session is saved as ending its remaining time from now, and one
+// with a deadline as ending at it. Callers must hold k.mu.
+func (k *Keeper) writeStateLocked() {
+	if k.statePath == "" {
+		return
+	}
+	s := sessionstate.State{
+		PID:              os.Getpid(),
+		Start:            k.startTime,
+		End:              k.endTime,
+		SimulateActivity: k.simulateActivity,
+		Saved:            now(),
+	}
+	if k.paused && !k.untilDeadline && !k.endTime.IsZero() {
+		s.End = s.Saved.Add(k.endTime.Sub(k.pausedAt))
+	}
+	if err := sessionstate.Save(k.statePath, s); err != nil {
+		k.logger().Debug("session state not saved", "path", k.statePath, "err", err)
+	}
+}
+
+// removeState deletes the state file at path, if any.
+func removeState(path string) {
+	if path == "" {
+		return
+	}
+	if err := sessionstate.Remove(path); err != nil {
+		logger().Debug("session state not removed", "path", path, "err", err)
+	}
+}
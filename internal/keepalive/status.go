@@ -0,0 +1,44 @@
+package keepalive
+
+import (
+	"github.com/stigoleg/keep-alive/internal/platform"
+	"github.com/stigoleg/keep-alive/internal/statusfile"
+)
+
+// SetStatusFile publishes running sessions in the status file at path, which
+// shell prompts read to show the remaining time. An empty path disables it.
+func (k *Keeper) SetStatusFile(path string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.statusPath = path
+	if k.running {
+		k.writeStatusLocked()
+	}
+}
+
+// writeStatusLocked records the current session in the status file. Callers
+// must hold k.mu.
+func (k *Keeper) writeStatusLocked() {
+	if k.statusPath == "" {
+		return
+	}
+	var method string
+	if r, ok := k.keeper.(platform.ReportingKeepAlive); ok && !k.suspended {
+		if methods := r.ActiveMethods(); len(methods) > 0 {
+			method = methods[0]
+		}
+	}
+	if err := statusfile.Write(k.statusPath, k.endTime, method); err != nil {
+		k.logger().Debug("status file not written", "path", k.statusPath, "err", err)
+	}
+}
+
+// removeStatus deletes the status file at path, if any.
+func removeStatus(path string) {
+	if path == "" {
+		return
+	}
+	if err := statusfile.Remove(path); err != nil {
+		logger().Debug("status file not removed", "path", path, "err", err)
+	}
+}
@@ -0,0 +1,151 @@
+package keepalive
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/crash"
+	"github.com/stigoleg/keep-alive/internal/platform"
+)
+
+// processPollInterval is how often a watched process is checked.
+const processPollInterval = 2 * time.Second
+
+// processAlive and findProcesses are replaced in tests.
+var (
+	processAlive  = platform.ProcessAlive
+	findProcesses = platform.FindProcesses
+)
+
+// ProcessWatch names a process whose exit ends the session. Either PID or
+// Name is set; the zero value watches nothing.
+type ProcessWatch struct {
+	PID int
+	// Name matches the process name. The session lasts while any process
+	// with that name is running.
+	Name string
+}
+
+// IsZero reports whether w watches nothing.
+func (w ProcessWatch) IsZero() bool {
+	return w.PID == 0 && w.Name == ""
+}
+
+func (w ProcessWatch) String() string {
+	if w.PID != 0 {
+		return "PID " + strconv.Itoa(w.PID)
+	}
+	return w.Name
+}
+
+// Alive reports whether the watched process is running.
+func (w ProcessWatch) Alive() (bool, error) {
+	if w.PID != 0 {
+		return processAlive(w.PID)
+	}
+	pids, err := findProcesses(w.Name)
+	if err != nil {
+		return false, err
+	}
+	return len(pids) > 0, nil
+}
+
+// Check returns an error unless the watched process is running now, so that
+// a mistyped pid or name is reported instead of ending the session at once.
+func (w ProcessWatch) Check() error {
+	alive, err := w.Alive()
+	if err != nil {
+		return fmt.Errorf("cannot watch %s: %v", w, err)
+	}
+	if !alive {
+		if w.PID != 0 {
+			return fmt.Errorf("no process with PID %d is running", w.PID)
+		}
+		return fmt.Errorf("no process named %q is running", w.Name)
+	}
+	return nil
+}
+
+// SetProcessWatch ends the session when the watched process exits. The zero
+// ProcessWatch disables watching. Changes apply immediately to a running
+// session.
+func (k *Keeper) SetProcessWatch(w ProcessWatch) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.processWatch == w {
+		return
+	}
+	k.processWatch = w
+	if k.processCancel != nil {
+		k.processCancel()
+		k.processCancel = nil
+	}
+	if k.running {
+		k.startProcessWatchLocked()
+	}
+}
+
+// ProcessWatch returns the watched process, or the zero value when none is.
+func (k *Keeper) ProcessWatch() ProcessWatch {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.processWatch
+}
+
+// startProcessWatchLocked starts polling the watched process for the current
+// session. Callers must hold k.mu.
+func (k *Keeper) startProcessWatchLocked() {
+	if k.processWatch.IsZero() || k.processCancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(k.ctx)
+	k.processCancel = cancel
+	go k.watchProcess(ctx, k.ctx, k.processWatch)
+}
+
+// watchProcess polls w until ctx is done or the process exits.
+func (k *Keeper) watchProcess(ctx, sessionCtx context.Context, w ProcessWatch) {
+	defer crash.Guard("process-watch")
+
+	ticker := time.NewTicker(processPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if k.checkProcess(ctx, sessionCtx, w) {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkProcess stops the session in sessionCtx if w has exited and reports
+// whether it did. Errors are logged and treated as the process still running.
+func (k *Keeper) checkProcess(ctx, sessionCtx context.Context, w ProcessWatch) bool {
+	alive, err := w.Alive()
+	if err != nil {
+		k.logger().Warn("cannot check watched process", "process", w, "err", err)
+		return false
+	}
+	if alive {
+		return false
+	}
+
+	k.mu.Lock()
+	stillCurrent := ctx.Err() == nil && k.running && k.ctx == sessionCtx
+	k.mu.Unlock()
+	if !stillCurrent {
+		return true
+	}
+
+	k.logger().Info("watched process exited, stopping", "process", w)
+	k.Stop()
+	return true
+}
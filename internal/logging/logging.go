@@ -0,0 +1,100 @@
+// Package logging builds the leveled, structured logger that writes the debug
+// log.
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/stigoleg/keep-alive/internal/util"
+)
+
+// FileName is the name of the log file in the default log directory.
+const FileName = "keepalive.log"
+
+// Levels lists the accepted level names, from most to least verbose.
+var Levels = []string{"debug", "info", "warn", "error"}
+
+// ParseLevel converts a level name to a slog.Level. Names are
+// case-insensitive and "warning" is accepted for "warn".
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q (use %s)", s, strings.Join(Levels, ", "))
+}
+
+// New returns a logger that writes records at or above level to w as
+// key=value lines.
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// DefaultPath returns where the log is written unless another file is chosen:
+// ~/Library/Logs/keepalive on macOS, %LocalAppData%\keepalive on Windows and
+// $XDG_STATE_HOME/keepalive (~/.local/state/keepalive) elsewhere.
+func DefaultPath() (string, error) {
+	var dir string
+	switch runtime.GOOS {
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, "Library", "Logs")
+	case "windows":
+		dir = os.Getenv("LocalAppData")
+		if dir == "" {
+			return "", errors.New("%LocalAppData% is not defined")
+		}
+	default:
+		dir = os.Getenv("XDG_STATE_HOME")
+		if !filepath.IsAbs(dir) {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			dir = filepath.Join(home, ".local", "state")
+		}
+	}
+	return filepath.Join(dir, "keepalive", FileName), nil
+}
+
+// FallbackPath returns where the log is written when the default location
+// cannot be used: a per-user directory under the temporary directory.
+func FallbackPath() string {
+	return filepath.Join(util.UserTempDir(), FileName)
+}
+
+// OpenFallback opens the log at FallbackPath, refusing a directory that
+// another user owns or can access, since its name is predictable.
+func OpenFallback() (*os.File, error) {
+	path := FallbackPath()
+	if err := util.MakePrivateDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	return Open(path)
+}
+
+// Open opens path for appending, creating it and its directory if needed.
+// The log may contain command lines and process names, so it is readable by
+// the owner only.
+func Open(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+}
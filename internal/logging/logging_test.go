@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"debug", slog.LevelDebug, false},
+		{"INFO", slog.LevelInfo, false},
+		{"warn", slog.LevelWarn, false},
+		{"warning", slog.LevelWarn, false},
+		{" error ", slog.LevelError, false},
+		{"", 0, true},
+		{"trace", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLevel(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewFiltersByLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, slog.LevelWarn)
+	l.Info("hidden")
+	l.Warn("shown", "pid", 42)
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Errorf("info record written at warn level: %q", out)
+	}
+	if !strings.Contains(out, "level=WARN") || !strings.Contains(out, "msg=shown") || !strings.Contains(out, "pid=42") {
+		t.Errorf("unexpected output %q", out)
+	}
+}
+
+func TestDefaultPathUsesXDGStateHome(t *testing.T) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("XDG_STATE_HOME is only used on Linux and other Unix systems")
+	}
+	state := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", state)
+	got, err := DefaultPath()
+	if err != nil {
+		t.Fatalf("DefaultPath() error: %v", err)
+	}
+	if want := filepath.Join(state, "keepalive", FileName); got != want {
+		t.Errorf("DefaultPath() = %q, want %q", got, want)
+	}
+
+	// Relative values are invalid per the XDG spec and ignored.
+	t.Setenv("XDG_STATE_HOME", "relative")
+	t.Setenv("HOME", state)
+	got, err = DefaultPath()
+	if err != nil {
+		t.Fatalf("DefaultPath() error: %v", err)
+	}
+	if want := filepath.Join(state, ".local", "state", "keepalive", FileName); got != want {
+		t.Errorf("DefaultPath() = %q, want %q", got, want)
+	}
+}
+
+func TestOpenCreatesDirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "dir", FileName)
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	f.Close()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("log file not created: %v", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Errorf("log file mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestOpenFallbackRefusesSharedDirectory(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the temporary directory is per user on Windows")
+	}
+	t.Setenv("TMPDIR", t.TempDir())
+
+	f, err := OpenFallback()
+	if err != nil {
+		t.Fatalf("OpenFallback() error: %v", err)
+	}
+	f.Close()
+	if got, want := f.Name(), FallbackPath(); got != want {
+		t.Errorf("OpenFallback() opened %s, want %s", got, want)
+	}
+
+	if err := os.Chmod(filepath.Dir(FallbackPath()), 0o777); err != nil {
+		t.Fatal(err)
+	}
+	if f, err := OpenFallback(); err == nil {
+		f.Close()
+		t.Error("OpenFallback() used a directory other users can write to")
+	}
+}
@@ -0,0 +1,113 @@
+// Package manpage renders the keepalive(1) man page from the command-line
+// flag definitions, so that the page written by gen-docs and the one the
+// binary installs with `keepalive install-completions` never fall out of
+// date.
+package manpage
+
+import (
+	"flag"
+	"sort"
+	"strings"
+)
+
+const (
+	appName        = "keepalive"
+	appDescription = "A lightweight, cross-platform utility to prevent your system from going to sleep."
+)
+
+// Command is a subcommand listed in the COMMANDS section.
+type Command struct {
+	Name string
+	Desc string
+}
+
+// option is one OPTIONS entry: the flags that share a definition, such as
+// -d and --duration.
+type option struct {
+	names []string
+	arg   string
+	desc  string
+}
+
+// Render returns the man page in roff for the flags and subcommands given.
+func Render(flags *flag.FlagSet, commands []Command) string {
+	var b strings.Builder
+	b.WriteString(".TH \"" + strings.ToUpper(appName) + "\" \"1\" \"\" \"keep-alive\" \"User Commands\"\n")
+	b.WriteString(".SH NAME\n" + appName + " \\- " + escape(appDescription) + "\n")
+	b.WriteString(".SH SYNOPSIS\n.B " + appName + "\n[\\fIoptions\\fR]\n.br\n.B " + appName + "\n\\fIcommand\\fR [\\fIarguments\\fR]\n")
+	b.WriteString(".SH DESCRIPTION\n" + escape(appDescription) + "\n")
+	b.WriteString("Without a duration, clock time or other limit, an interactive menu is shown.\n")
+
+	b.WriteString(".SH OPTIONS\n")
+	for _, o := range options(flags) {
+		names := make([]string, len(o.names))
+		for i, n := range o.names {
+			names[i] = "\\fB" + escapeName(n) + "\\fR"
+		}
+		b.WriteString(".TP\n" + strings.Join(names, ", "))
+		if o.arg != "" {
+			b.WriteString(" \\fI" + o.arg + "\\fR")
+		}
+		b.WriteString("\n" + escape(o.desc) + "\n")
+	}
+
+	if len(commands) > 0 {
+		b.WriteString(".SH COMMANDS\n")
+		for _, c := range commands {
+			b.WriteString(".TP\n\\fB" + escapeName(c.Name) + "\\fR\n" + escape(c.Desc) + "\n")
+		}
+	}
+
+	b.WriteString(".SH EXAMPLES\n")
+	b.WriteString(".TP\n\\fB" + appName + "\\fR\nStart the interactive TUI.\n")
+	b.WriteString(".TP\n\\fB" + appName + " \\-d 2h30m\\fR\nKeep the system awake for 2 hours 30 minutes.\n")
+	b.WriteString(".TP\n\\fB" + appName + " \\-c 22:00\\fR\nKeep the system awake until 10:00 PM.\n")
+	b.WriteString(".SH SEE ALSO\nProject homepage: https://github.com/stigoleg/keep-alive\n")
+	return b.String()
+}
+
+// options groups the flags that share a usage string, which is how
+// aliases are defined, keeping the order of their first long name.
+func options(flags *flag.FlagSet) []option {
+	var opts []option
+	index := map[string]int{}
+	flags.VisitAll(func(f *flag.Flag) {
+		name := "--" + f.Name
+		if len(f.Name) == 1 {
+			name = "-" + f.Name
+		}
+		if i, ok := index[f.Usage]; ok {
+			opts[i].names = append(opts[i].names, name)
+			return
+		}
+		arg, desc := flag.UnquoteUsage(f)
+		index[f.Usage] = len(opts)
+		opts = append(opts, option{names: []string{name}, arg: arg, desc: desc})
+	})
+	for _, o := range opts {
+		// Short names first, then long ones alphabetically.
+		sort.SliceStable(o.names, func(i, j int) bool {
+			si, sj := !strings.HasPrefix(o.names[i], "--"), !strings.HasPrefix(o.names[j], "--")
+			if si != sj {
+				return si
+			}
+			return o.names[i] < o.names[j]
+		})
+	}
+	return opts
+}
+
+// escape makes s safe as roff text.
+func escape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
+
+// escapeName escapes s and its hyphens, which roff would otherwise print
+// as typographic hyphens that cannot be copied into a shell.
+func escapeName(s string) string {
+	return strings.ReplaceAll(escape(s), "-", `\-`)
+}
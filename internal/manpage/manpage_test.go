@@ -0,0 +1,31 @@
+package manpage
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	flags := flag.NewFlagSet("keepalive", flag.ContinueOnError)
+	d := flags.String("duration", "", "Duration to keep system alive")
+	flags.StringVar(d, "d", "", "Duration to keep system alive")
+	flags.Bool("notify", false, `Show a notification; see \n`)
+	flags.Int("dim", 0, "Dim the display to this `percent`")
+
+	page := Render(flags, []Command{{Name: "simulate-once", Desc: "Run one cycle"}})
+	for _, want := range []string{
+		".TH \"KEEPALIVE\" \"1\"",
+		".TP\n\\fB\\-d\\fR, \\fB\\-\\-duration\\fR \\fIstring\\fR\nDuration to keep system alive\n",
+		".TP\n\\fB\\-\\-notify\\fR\nShow a notification; see \\en\n",
+		"\\fB\\-\\-dim\\fR \\fIpercent\\fR\nDim the display to this percent\n",
+		".SH COMMANDS\n.TP\n\\fBsimulate\\-once\\fR\nRun one cycle\n",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("man page missing %q:\n%s", want, page)
+		}
+	}
+	if strings.Count(page, "Duration to keep system alive") != 1 {
+		t.Error("aliases should share one entry")
+	}
+}
@@ -0,0 +1,125 @@
+// Package menubar shows a Keeper as a macOS menu bar status item, or a
+// Windows notification-area icon, with the time left and actions to start,
+// stop and extend a session. On macOS the status item needs a build with
+// cgo and the menubar tag:
+//
+//	go build -tags menubar ./cmd/keepalive
+//
+// Windows builds always have the icon. Other builds have the same API, and
+// Run returns ErrUnavailable.
+package menubar
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/keepalive"
+	"github.com/stigoleg/keep-alive/internal/util"
+)
+
+// ErrUnavailable is returned by Run in builds without the status item.
+var ErrUnavailable = errors.New("the menu bar mode needs Windows or a macOS build with cgo and the menubar tag (go build -tags menubar)")
+
+// ExtendStep is how far the Extend action moves the end of a session.
+const ExtendStep = 15 * time.Minute
+
+// Action identifies a menu entry. The values are shared with the
+// Objective-C side as menu item tags.
+type Action int
+
+const (
+	ActionStart Action = iota + 1
+	ActionStop
+	ActionExtend
+	ActionQuit
+)
+
+// controller turns menu actions into Keeper calls and renders the status
+// item's title.
+type controller struct {
+	keeper *keepalive.Keeper
+}
+
+// title is the text shown in the menu bar: the time left in a timed
+// session, ∞ for a session without one, and nothing but the cup while no
+// session runs.
+func (c *controller) title() string {
+	switch {
+	case !c.keeper.IsRunning():
+		return "☕"
+	case c.keeper.Paused():
+		return "☕ paused"
+	case c.keeper.EndTime().IsZero():
+		return "☕ ∞"
+	default:
+		return "☕ " + util.FormatDuration(c.keeper.TimeRemaining().Round(time.Minute))
+	}
+}
+
+// tooltip is the text shown when pointing at the Windows icon, which has
+// no title of its own.
+func (c *controller) tooltip() string {
+	switch {
+	case !c.keeper.IsRunning():
+		return "Keep-Alive: not running"
+	case c.keeper.Paused():
+		return "Keep-Alive: paused"
+	case c.keeper.EndTime().IsZero():
+		return "Keep-Alive: awake until stopped"
+	default:
+		end := c.keeper.EndTime()
+		return fmt.Sprintf("Keep-Alive: %s left (until %s)", util.FormatDuration(c.keeper.TimeRemaining().Round(time.Minute)), end.Format("15:04"))
+	}
+}
+
+// enabled reports whether the menu entry for a can be chosen.
+func (c *controller) enabled(a Action) bool {
+	switch a {
+	case ActionStart:
+		return !c.keeper.IsRunning()
+	case ActionStop:
+		return c.keeper.IsRunning()
+	case ActionExtend:
+		return c.keeper.IsRunning() && !c.keeper.EndTime().IsZero()
+	}
+	return true
+}
+
+// perform applies a and reports whether the status item should go away.
+// Quitting stops the session.
+func (c *controller) perform(a Action) (quit bool) {
+	var err error
+	switch a {
+	case ActionStart:
+		err = c.keeper.StartIndefinite()
+	case ActionStop:
+		c.keeper.Stop()
+	case ActionExtend:
+		err = c.keeper.Extend(ExtendStep)
+	case ActionQuit:
+		if c.keeper.IsRunning() {
+			c.keeper.Stop()
+		}
+		return true
+	}
+	if err != nil {
+		slog.Warn("menu bar action failed", "action", a, "err", err)
+	}
+	return false
+}
+
+// Run shows the status item for k and blocks until Quit is chosen from its
+// menu or Quit is called. It must be called from the main goroutine, since
+// macOS runs its user interface on the main thread; on Windows the icon
+// runs on the thread of the calling goroutine.
+func Run(k *keepalive.Keeper) error {
+	return run(&controller{keeper: k})
+}
+
+// Quit removes the status item, making Run return. The session is stopped
+// as if Quit had been chosen from the menu.
+func Quit() {
+	quit()
+}
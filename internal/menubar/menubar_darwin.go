@@ -0,0 +1,78 @@
+//go:build darwin && cgo && menubar
+
+package menubar
+
+/*
+#cgo LDFLAGS: -framework Cocoa
+#include <stdbool.h>
+#include <stdlib.h>
+
+void kaMenubarRun(void);
+void kaMenubarTerminate(void);
+*/
+import "C"
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Available reports whether this build can show the status item.
+const Available = true
+
+func init() {
+	// AppKit must run on the main thread, which is the one running init.
+	runtime.LockOSThread()
+}
+
+var (
+	activeMu sync.Mutex
+	active   *controller
+)
+
+func current() *controller {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	return active
+}
+
+func run(c *controller) error {
+	activeMu.Lock()
+	active = c
+	activeMu.Unlock()
+	C.kaMenubarRun()
+	return nil
+}
+
+func quit() {
+	if c := current(); c != nil {
+		c.perform(ActionQuit)
+	}
+	C.kaMenubarTerminate()
+}
+
+//export kaMenubarAction
+func kaMenubarAction(action C.int) C.bool {
+	c := current()
+	if c == nil {
+		return C.bool(false)
+	}
+	return C.bool(c.perform(Action(action)))
+}
+
+//export kaMenubarEnabled
+func kaMenubarEnabled(action C.int) C.bool {
+	c := current()
+	return C.bool(c != nil && c.enabled(Action(action)))
+}
+
+// kaMenubarTitle returns the title as a C string that the caller frees.
+//
+//export kaMenubarTitle
+func kaMenubarTitle() *C.char {
+	c := current()
+	if c == nil {
+		return C.CString("☕")
+	}
+	return C.CString(c.title())
+}
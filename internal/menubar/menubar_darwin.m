@@ -0,0 +1,99 @@
+//go:build darwin && cgo && menubar
+
+#import <Cocoa/Cocoa.h>
+#include "_cgo_export.h"
+
+// The tags match the Action constants in menubar.go.
+enum {
+	kaActionStart = 1,
+	kaActionStop = 2,
+	kaActionExtend = 3,
+	kaActionQuit = 4,
+};
+
+static NSStatusItem *kaItem;
+
+static void kaRefresh(void) {
+	char *title = kaMenubarTitle();
+	kaItem.button.title = [NSString stringWithUTF8String:title];
+	free(title);
+}
+
+// kaStop ends [NSApp run] so that kaMenubarRun returns to Go, which then
+// cleans up; terminate: would exit the process on the spot.
+static void kaStop(void) {
+	[[NSStatusBar systemStatusBar] removeStatusItem:kaItem];
+	[NSApp stop:nil];
+	// stop: takes effect after the next event, so post one.
+	NSEvent *wake = [NSEvent otherEventWithType:NSEventTypeApplicationDefined
+	                                   location:NSZeroPoint
+	                              modifierFlags:0
+	                                  timestamp:0
+	                               windowNumber:0
+	                                    context:nil
+	                                    subtype:0
+	                                      data1:0
+	                                      data2:0];
+	[NSApp postEvent:wake atStart:YES];
+}
+
+@interface KAMenubarTarget : NSObject <NSMenuItemValidation>
+@end
+
+@implementation KAMenubarTarget
+- (void)choose:(NSMenuItem *)item {
+	if (kaMenubarAction((int)item.tag)) {
+		kaStop();
+		return;
+	}
+	kaRefresh();
+}
+
+- (void)tick:(NSTimer *)timer {
+	kaRefresh();
+}
+
+- (BOOL)validateMenuItem:(NSMenuItem *)item {
+	return kaMenubarEnabled((int)item.tag);
+}
+@end
+
+static KAMenubarTarget *kaTarget;
+
+static void kaAddItem(NSMenu *menu, NSString *title, NSInteger tag, NSString *key) {
+	NSMenuItem *item = [[NSMenuItem alloc] initWithTitle:title action:@selector(choose:) keyEquivalent:key];
+	item.target = kaTarget;
+	item.tag = tag;
+	[menu addItem:item];
+	[item release];
+}
+
+void kaMenubarRun(void) {
+	@autoreleasepool {
+		[NSApplication sharedApplication];
+		// An accessory app has no Dock icon or menu of its own.
+		[NSApp setActivationPolicy:NSApplicationActivationPolicyAccessory];
+
+		kaTarget = [[KAMenubarTarget alloc] init];
+		kaItem = [[[NSStatusBar systemStatusBar] statusItemWithLength:NSVariableStatusItemLength] retain];
+
+		NSMenu *menu = [[NSMenu alloc] initWithTitle:@"Keep-Alive"];
+		kaAddItem(menu, @"Keep Awake", kaActionStart, @"s");
+		kaAddItem(menu, @"Stop", kaActionStop, @"x");
+		kaAddItem(menu, @"Extend by 15 Minutes", kaActionExtend, @"+");
+		[menu addItem:[NSMenuItem separatorItem]];
+		kaAddItem(menu, @"Quit Keep-Alive", kaActionQuit, @"q");
+		kaItem.menu = menu;
+		[menu release];
+
+		kaRefresh();
+		[NSTimer scheduledTimerWithTimeInterval:1.0 target:kaTarget selector:@selector(tick:) userInfo:nil repeats:YES];
+		[NSApp run];
+	}
+}
+
+void kaMenubarTerminate(void) {
+	dispatch_async(dispatch_get_main_queue(), ^{
+		kaStop();
+	});
+}
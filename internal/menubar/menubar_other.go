@@ -0,0 +1,12 @@
+//go:build !(darwin && cgo && menubar) && !windows
+
+package menubar
+
+// Available reports whether this build can show the status item.
+const Available = false
+
+func run(*controller) error {
+	return ErrUnavailable
+}
+
+func quit() {}
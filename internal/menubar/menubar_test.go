@@ -0,0 +1,70 @@
+package menubar
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/keepalive"
+	"github.com/stigoleg/keep-alive/internal/platform"
+)
+
+type fakeKeepAlive struct{}
+
+func (fakeKeepAlive) Start(context.Context) error         { return nil }
+func (fakeKeepAlive) Stop() error                         { return nil }
+func (fakeKeepAlive) StopNow()                            {}
+func (fakeKeepAlive) SetSimulateActivity(bool)            {}
+func (fakeKeepAlive) SetTimings(platform.Timings)         {}
+func (fakeKeepAlive) SetMouseShape(s platform.MouseShape) {}
+
+func TestController(t *testing.T) {
+	k := keepalive.New(keepalive.WithPlatform(fakeKeepAlive{}))
+	c := &controller{keeper: k}
+
+	if got := c.title(); got != "☕" {
+		t.Errorf("title while stopped = %q", got)
+	}
+	if got := c.tooltip(); got != "Keep-Alive: not running" {
+		t.Errorf("tooltip while stopped = %q", got)
+	}
+	if !c.enabled(ActionStart) || c.enabled(ActionStop) || c.enabled(ActionExtend) {
+		t.Error("only Start should be enabled while stopped")
+	}
+
+	if c.perform(ActionStart) {
+		t.Fatal("Start quit the menu bar")
+	}
+	if !k.IsRunning() {
+		t.Fatal("Start did not start a session")
+	}
+	if got := c.title(); got != "☕ ∞" {
+		t.Errorf("title of an indefinite session = %q", got)
+	}
+	if c.enabled(ActionStart) || !c.enabled(ActionStop) || c.enabled(ActionExtend) {
+		t.Error("only Stop should be enabled during an indefinite session")
+	}
+	c.perform(ActionStop)
+
+	if err := k.StartTimed(time.Hour); err != nil {
+		t.Fatalf("StartTimed: %v", err)
+	}
+	if !c.enabled(ActionExtend) {
+		t.Error("Extend should be enabled during a timed session")
+	}
+	c.perform(ActionExtend)
+	if got := c.title(); !strings.HasPrefix(got, "☕ 1h1") {
+		t.Errorf("title after extending an hour by 15 minutes = %q", got)
+	}
+	if got, want := c.tooltip(), " (until "+k.EndTime().Format("15:04")+")"; !strings.HasPrefix(got, "Keep-Alive: 1h1") || !strings.HasSuffix(got, want) {
+		t.Errorf("tooltip after extending = %q", got)
+	}
+
+	if !c.perform(ActionQuit) {
+		t.Error("Quit did not end the menu bar")
+	}
+	if k.IsRunning() {
+		t.Error("Quit left the session running")
+	}
+}
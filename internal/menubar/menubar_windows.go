@@ -0,0 +1,335 @@
+//go:build windows
+
+package menubar
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+// Available reports whether this build can show the status item.
+const Available = true
+
+const (
+	wmNull      = 0x0000
+	wmDestroy   = 0x0002
+	wmClose     = 0x0010
+	wmTimer     = 0x0113
+	wmLButtonUp = 0x0202
+	wmRButtonUp = 0x0205
+	wmApp       = 0x8000
+
+	nimAdd    = 0
+	nimModify = 1
+	nimDelete = 2
+
+	nifMessage = 0x1
+	nifIcon    = 0x2
+	nifTip     = 0x4
+
+	mfString    = 0x0
+	mfGrayed    = 0x1
+	mfSeparator = 0x800
+
+	tpmRightButton = 0x0002
+	tpmBottomAlign = 0x0020
+	tpmNoNotify    = 0x0080
+	tpmReturnCmd   = 0x0100
+
+	idiApplication = 32512
+)
+
+// trayMessage is the message the icon sends its window when it is clicked.
+const trayMessage = wmApp + 1
+
+// refreshTimer is the timer that updates the tooltip every second.
+const refreshTimer = 1
+
+var (
+	user32   = syscall.NewLazyDLL("user32.dll")
+	shell32  = syscall.NewLazyDLL("shell32.dll")
+	kernel32 = syscall.NewLazyDLL("kernel32.dll")
+
+	procRegisterClassExW       = user32.NewProc("RegisterClassExW")
+	procUnregisterClassW       = user32.NewProc("UnregisterClassW")
+	procCreateWindowExW        = user32.NewProc("CreateWindowExW")
+	procDestroyWindow          = user32.NewProc("DestroyWindow")
+	procDefWindowProcW         = user32.NewProc("DefWindowProcW")
+	procRegisterWindowMessageW = user32.NewProc("RegisterWindowMessageW")
+	procGetMessageW            = user32.NewProc("GetMessageW")
+	procTranslateMessage       = user32.NewProc("TranslateMessage")
+	procDispatchMessageW       = user32.NewProc("DispatchMessageW")
+	procPostMessageW           = user32.NewProc("PostMessageW")
+	procPostQuitMessage        = user32.NewProc("PostQuitMessage")
+	procSetTimer               = user32.NewProc("SetTimer")
+	procLoadIconW              = user32.NewProc("LoadIconW")
+	procCreatePopupMenu        = user32.NewProc("CreatePopupMenu")
+	procAppendMenuW            = user32.NewProc("AppendMenuW")
+	procDestroyMenu            = user32.NewProc("DestroyMenu")
+	procTrackPopupMenu         = user32.NewProc("TrackPopupMenu")
+	procSetForegroundWindow    = user32.NewProc("SetForegroundWindow")
+	procGetCursorPos           = user32.NewProc("GetCursorPos")
+	procShellNotifyIconW       = shell32.NewProc("Shell_NotifyIconW")
+	procGetModuleHandleW       = kernel32.NewProc("GetModuleHandleW")
+	procFreeConsole            = kernel32.NewProc("FreeConsole")
+)
+
+type wndClassEx struct {
+	cbSize        uint32
+	style         uint32
+	lpfnWndProc   uintptr
+	cbClsExtra    int32
+	cbWndExtra    int32
+	hInstance     uintptr
+	hIcon         uintptr
+	hCursor       uintptr
+	hbrBackground uintptr
+	lpszMenuName  *uint16
+	lpszClassName *uint16
+	hIconSm       uintptr
+}
+
+type point struct {
+	x, y int32
+}
+
+type winMsg struct {
+	hwnd    uintptr
+	message uint32
+	wParam  uintptr
+	lParam  uintptr
+	time    uint32
+	pt      point
+}
+
+type guid struct {
+	data1 uint32
+	data2 uint16
+	data3 uint16
+	data4 [8]byte
+}
+
+// notifyIconData is NOTIFYICONDATAW.
+type notifyIconData struct {
+	cbSize           uint32
+	hWnd             uintptr
+	uID              uint32
+	uFlags           uint32
+	uCallbackMessage uint32
+	hIcon            uintptr
+	szTip            [128]uint16
+	dwState          uint32
+	dwStateMask      uint32
+	szInfo           [256]uint16
+	uVersion         uint32
+	szInfoTitle      [64]uint16
+	dwInfoFlags      uint32
+	guidItem         guid
+	hBalloonIcon     uintptr
+}
+
+// menuItems are the entries of the icon's menu, in order, with zero for a
+// separator. They match the macOS menu.
+var menuItems = []struct {
+	action Action
+	label  string
+}{
+	{ActionStart, "Keep Awake"},
+	{ActionStop, "Stop"},
+	{ActionExtend, "Extend by 15 Minutes"},
+	{0, ""},
+	{ActionQuit, "Quit Keep-Alive"},
+}
+
+var (
+	activeMu sync.Mutex
+	active   *controller
+
+	// window is the hidden window that owns the icon, or 0 when none is
+	// shown. The icon and its window belong to the thread running run;
+	// other goroutines only post messages to it.
+	window atomic.Uintptr
+
+	// The following are only used on the thread running run.
+	icon           uintptr
+	taskbarCreated uint32
+	wndProcPtr     = syscall.NewCallback(wndProc)
+)
+
+func current() *controller {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	return active
+}
+
+// run shows the notification-area icon and runs its window's message loop
+// until the window is closed. A window and its messages belong to the
+// thread that created it, so the goroutine stays on one thread throughout.
+func run(c *controller) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	activeMu.Lock()
+	active = c
+	activeMu.Unlock()
+	defer func() {
+		activeMu.Lock()
+		active = nil
+		activeMu.Unlock()
+	}()
+
+	instance, _, _ := procGetModuleHandleW.Call(0)
+	className, _ := syscall.UTF16PtrFromString("KeepAliveTray")
+	wc := wndClassEx{lpfnWndProc: wndProcPtr, hInstance: instance, lpszClassName: className}
+	wc.cbSize = uint32(unsafe.Sizeof(wc))
+	if r, _, err := procRegisterClassExW.Call(uintptr(unsafe.Pointer(&wc))); r == 0 {
+		return fmt.Errorf("RegisterClassEx: %w", err)
+	}
+	defer procUnregisterClassW.Call(uintptr(unsafe.Pointer(className)), instance)
+
+	// A top-level window that is never shown, rather than a message-only
+	// one, since only top-level windows hear that Explorer has restarted
+	// and can take the foreground for the menu.
+	title, _ := syscall.UTF16PtrFromString("Keep-Alive")
+	hwnd, _, err := procCreateWindowExW.Call(0, uintptr(unsafe.Pointer(className)), uintptr(unsafe.Pointer(title)),
+		0, 0, 0, 0, 0, 0, 0, instance, 0)
+	if hwnd == 0 {
+		return fmt.Errorf("CreateWindowEx: %w", err)
+	}
+
+	name, _ := syscall.UTF16PtrFromString("TaskbarCreated")
+	r, _, _ := procRegisterWindowMessageW.Call(uintptr(unsafe.Pointer(name)))
+	taskbarCreated = uint32(r)
+	icon, _, _ = procLoadIconW.Call(0, idiApplication)
+	if err := notifyIcon(nimAdd, hwnd); err != nil {
+		procDestroyWindow.Call(hwnd)
+		return err
+	}
+	window.Store(hwnd)
+	procSetTimer.Call(hwnd, refreshTimer, 1000, 0)
+	// A console window opened only for this process, as from a shortcut,
+	// closes; a terminal it was started from stays.
+	procFreeConsole.Call()
+
+	var m winMsg
+	for {
+		r, _, err := procGetMessageW.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+		switch int32(r) {
+		case -1:
+			return fmt.Errorf("GetMessage: %w", err)
+		case 0:
+			return nil
+		}
+		procTranslateMessage.Call(uintptr(unsafe.Pointer(&m)))
+		procDispatchMessageW.Call(uintptr(unsafe.Pointer(&m)))
+	}
+}
+
+// quit stops the session and closes the icon's window, from any goroutine.
+func quit() {
+	if c := current(); c != nil {
+		c.perform(ActionQuit)
+	}
+	if hwnd := window.Load(); hwnd != 0 {
+		procPostMessageW.Call(hwnd, wmClose, 0, 0)
+	}
+}
+
+func wndProc(hwnd, message, wParam, lParam uintptr) uintptr {
+	switch uint32(message) {
+	case trayMessage:
+		if l := uint32(lParam); l == wmLButtonUp || l == wmRButtonUp {
+			showMenu(hwnd)
+		}
+		return 0
+	case wmTimer:
+		_ = notifyIcon(nimModify, hwnd)
+		return 0
+	case wmClose:
+		_ = notifyIcon(nimDelete, hwnd)
+		procDestroyWindow.Call(hwnd)
+		return 0
+	case wmDestroy:
+		window.Store(0)
+		procPostQuitMessage.Call(0)
+		return 0
+	}
+	if taskbarCreated != 0 && uint32(message) == taskbarCreated {
+		// Explorer restarted and lost the icon.
+		_ = notifyIcon(nimAdd, hwnd)
+		return 0
+	}
+	r, _, _ := procDefWindowProcW.Call(hwnd, message, wParam, lParam)
+	return r
+}
+
+// notifyIcon adds, updates or removes the icon, with the session's state
+// as its tooltip.
+func notifyIcon(op uintptr, hwnd uintptr) error {
+	data := notifyIconData{
+		hWnd:             hwnd,
+		uID:              1,
+		uFlags:           nifMessage | nifIcon | nifTip,
+		uCallbackMessage: trayMessage,
+		hIcon:            icon,
+	}
+	data.cbSize = uint32(unsafe.Sizeof(data))
+	if c := current(); c != nil {
+		tip, _ := syscall.UTF16FromString(c.tooltip())
+		if len(tip) > len(data.szTip) {
+			tip = append(tip[:len(data.szTip)-1], 0)
+		}
+		copy(data.szTip[:], tip)
+	}
+	if r, _, err := procShellNotifyIconW.Call(op, uintptr(unsafe.Pointer(&data))); r == 0 {
+		return fmt.Errorf("Shell_NotifyIcon: %w", err)
+	}
+	return nil
+}
+
+// showMenu shows the icon's menu at the pointer and performs the action
+// chosen from it.
+func showMenu(hwnd uintptr) {
+	c := current()
+	if c == nil {
+		return
+	}
+	menu, _, _ := procCreatePopupMenu.Call()
+	if menu == 0 {
+		return
+	}
+	defer procDestroyMenu.Call(menu)
+	for _, item := range menuItems {
+		if item.action == 0 {
+			procAppendMenuW.Call(menu, mfSeparator, 0, 0)
+			continue
+		}
+		flags := uintptr(mfString)
+		if !c.enabled(item.action) {
+			flags |= mfGrayed
+		}
+		label, _ := syscall.UTF16PtrFromString(item.label)
+		procAppendMenuW.Call(menu, flags, uintptr(item.action), uintptr(unsafe.Pointer(label)))
+	}
+
+	var pt point
+	procGetCursorPos.Call(uintptr(unsafe.Pointer(&pt)))
+	// The menu only closes when clicking elsewhere if its window is in the
+	// foreground, and only takes the foreground once a message follows.
+	procSetForegroundWindow.Call(hwnd)
+	chosen, _, _ := procTrackPopupMenu.Call(menu, tpmReturnCmd|tpmNoNotify|tpmRightButton|tpmBottomAlign,
+		uintptr(pt.x), uintptr(pt.y), 0, hwnd, 0)
+	procPostMessageW.Call(hwnd, wmNull, 0, 0)
+	if chosen == 0 {
+		return
+	}
+	if c.perform(Action(chosen)) {
+		procPostMessageW.Call(hwnd, wmClose, 0, 0)
+		return
+	}
+	_ = notifyIcon(nimModify, hwnd)
+}
@@ -0,0 +1,26 @@
+//go:build darwin
+
+package platform
+
+import "os/exec"
+
+// accessibilitySettingsURL opens System Settings at Privacy & Security >
+// Accessibility.
+const accessibilitySettingsURL = "x-apple.systempreferences:com.apple.preference.security?Privacy_Accessibility"
+
+// AccessibilityTrusted reports whether keep-alive may post mouse events
+// itself. Builds without cgo only post them through osascript, whose grant
+// cannot be checked, so they report true.
+func AccessibilityTrusted() bool {
+	return !nativeEventsAvailable || cgAccessibilityTrusted()
+}
+
+// OpenAccessibilitySettings asks macOS to list keep-alive under
+// Accessibility, then opens that pane of System Settings so the user can
+// turn the grant on.
+func OpenAccessibilitySettings() error {
+	if nativeEventsAvailable && cgRequestAccessibility() {
+		return nil
+	}
+	return exec.Command("open", accessibilitySettingsURL).Run()
+}
@@ -0,0 +1,16 @@
+//go:build !darwin
+
+package platform
+
+import "errors"
+
+// AccessibilityTrusted reports true: only macOS asks for a grant before
+// input events can be posted.
+func AccessibilityTrusted() bool {
+	return true
+}
+
+// OpenAccessibilitySettings is only supported on macOS.
+func OpenAccessibilitySettings() error {
+	return errors.New("opening the Accessibility settings is only supported on macOS")
+}
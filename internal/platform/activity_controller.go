@@ -1,7 +1,6 @@
 package platform
 
 import (
-	"log"
 	"sync/atomic"
 	"time"
 )
@@ -16,6 +15,12 @@ type JitterExecutor func(points []MousePoint, sessionDuration time.Duration)
 // logging logic used by all platforms for chat-app activity simulation. Each
 // platform provides an IdleDetector and JitterExecutor; the controller handles
 // the common state machine (synthetic idle tracking, interval enforcement, etc.).
+//
+// Simulation is switched with hysteresis: it turns on once the user has been
+// idle for the idle threshold, and turns off only once they have been using
+// the machine for PresenceMinDwell. A brief touch of the mouse merely holds
+// off jitter until the user has been idle for half the threshold again.
+// Either way, it stays on or off for at least PresenceMinDwell.
 type ActivityController struct {
 	// platformName is used for log prefixes (e.g. "darwin", "windows", "linux").
 	platformName string
@@ -23,12 +28,33 @@ type ActivityController struct {
 	// patternGen generates mouse movement patterns.
 	patternGen *MousePatternGenerator
 
+	// now is replaced in tests.
+	now func() time.Time
+
 	// lastActiveLogNS: last time we logged that user is active (unix nanos).
 	lastActiveLogNS int64
 	// lastJitterNS: last time we executed activity jitter (unix nanos).
 	lastJitterNS int64
 	// lastUserActiveNS: last time user activity was observed (unix nanos).
 	lastUserActiveNS int64
+
+	// simulating is set while simulation is on; changedNS is when it last
+	// turned on or off, and activeSinceNS is when the user's current spell
+	// of activity began while it is on, or 0 (unix nanos).
+	simulating    atomic.Bool
+	changedNS     int64
+	activeSinceNS int64
+
+	// idleThresholdNS and jitterIntervalNS hold the active Timings so they can
+	// be changed while the controller is in use.
+	idleThresholdNS  int64
+	jitterIntervalNS int64
+
+	// budget limits the number of jitters; nil means no limit.
+	budget atomic.Pointer[SimulationBudget]
+
+	// observer is told when jitter holds off for the user; nil tells no one.
+	observer *observerSlot
 }
 
 // NewActivityController creates a new ActivityController.
@@ -36,15 +62,53 @@ func NewActivityController(platformName string, patternGen *MousePatternGenerato
 	return &ActivityController{
 		platformName:     platformName,
 		patternGen:       patternGen,
+		now:              time.Now,
 		lastUserActiveNS: time.Now().UnixNano(),
+		idleThresholdNS:  int64(IdleThreshold),
+		jitterIntervalNS: int64(ChatAppActivityInterval),
 	}
 }
 
+// SetTimings updates the idle threshold and jitter interval.
+func (ac *ActivityController) SetTimings(t Timings) {
+	t = t.WithDefaults()
+	atomic.StoreInt64(&ac.idleThresholdNS, int64(t.IdleThreshold))
+	atomic.StoreInt64(&ac.jitterIntervalNS, int64(t.ChatAppActivityInterval))
+}
+
+// SetBudget limits the jitters performed to those budget allows.
+func (ac *ActivityController) SetBudget(budget *SimulationBudget) {
+	ac.budget.Store(budget)
+}
+
+// setObserver reports jitter held off for the user to o.
+func (ac *ActivityController) setObserver(o *observerSlot) {
+	ac.observer = o
+}
+
+// skipped reports that jitter held off because the user is active.
+func (ac *ActivityController) skipped() bool {
+	if ac.observer != nil {
+		ac.observer.simulationSkipped()
+	}
+	return false
+}
+
 // Reset clears all timing state. Call on Stop().
 func (ac *ActivityController) Reset() {
 	atomic.StoreInt64(&ac.lastActiveLogNS, 0)
 	atomic.StoreInt64(&ac.lastJitterNS, 0)
 	atomic.StoreInt64(&ac.lastUserActiveNS, 0)
+	ac.simulating.Store(false)
+	atomic.StoreInt64(&ac.changedNS, 0)
+	atomic.StoreInt64(&ac.activeSinceNS, 0)
+}
+
+// setSimulating turns simulation on or off at nowNS.
+func (ac *ActivityController) setSimulating(on bool, nowNS int64) {
+	ac.simulating.Store(on)
+	atomic.StoreInt64(&ac.changedNS, nowNS)
+	atomic.StoreInt64(&ac.activeSinceNS, 0)
 }
 
 // MaybeJitter checks idle state and, if conditions are met, executes a jitter
@@ -52,67 +116,102 @@ func (ac *ActivityController) Reset() {
 func (ac *ActivityController) MaybeJitter(getIdle IdleDetector, execute JitterExecutor) bool {
 	idle, err := getIdle()
 
-	nowNS := time.Now().UnixNano()
+	nowNS := ac.now().UnixNano()
 	lastActiveLog := atomic.LoadInt64(&ac.lastActiveLogNS)
 	lastJitterNS := atomic.LoadInt64(&ac.lastJitterNS)
 	lastUserActiveNS := atomic.LoadInt64(&ac.lastUserActiveNS)
+	idleThreshold := time.Duration(atomic.LoadInt64(&ac.idleThresholdNS))
+	jitterInterval := time.Duration(atomic.LoadInt64(&ac.jitterIntervalNS))
+	changedNS := atomic.LoadInt64(&ac.changedNS)
 
 	if err != nil {
 		if lastActiveLog == 0 || time.Duration(nowNS-lastActiveLog) > 2*time.Minute {
 			atomic.StoreInt64(&ac.lastActiveLogNS, nowNS)
-			log.Printf("%s: idle detection failed (%v); skipping activity simulation to avoid interference", ac.platformName, err)
+			logger().Warn("idle detection failed; skipping activity simulation", "platform", ac.platformName, "err", err)
 		}
 		return false
 	}
 
-	// Detect real user activity since our last synthetic jitter.
-	// If observed idle time is significantly less than expected, user moved the mouse.
-	if lastJitterNS != 0 {
-		expectedIdle := time.Duration(nowNS - lastJitterNS)
-		if expectedIdle > 0 && idle+SyntheticIdleResetTolerance < expectedIdle {
-			atomic.StoreInt64(&ac.lastJitterNS, 0)
+	if ac.simulating.Load() {
+		// Detect real user activity since the last input we know of, ours or
+		// the user's: the observed idle time is then significantly shorter.
+		lastInputNS := max(lastJitterNS, lastUserActiveNS)
+		if expectedIdle := time.Duration(nowNS - lastInputNS); expectedIdle > 0 && idle+SyntheticIdleResetTolerance < expectedIdle {
+			activeNS := observedActiveTimestamp(nowNS, idle)
+			atomic.StoreInt64(&ac.lastUserActiveNS, activeNS)
+			activeSince := atomic.LoadInt64(&ac.activeSinceNS)
+			if activeSince == 0 {
+				activeSince = activeNS
+				atomic.StoreInt64(&ac.activeSinceNS, activeSince)
+			}
+			if time.Duration(nowNS-activeSince) < PresenceMinDwell || time.Duration(nowNS-changedNS) < PresenceMinDwell {
+				return ac.skipped()
+			}
+			ac.setSimulating(false, nowNS)
+			if lastActiveLog == 0 || time.Duration(nowNS-lastActiveLog) > 2*time.Minute {
+				atomic.StoreInt64(&ac.lastActiveLogNS, nowNS)
+				logger().Info("user activity detected; pausing activity simulation", "platform", ac.platformName, "idle", idle)
+			}
+			return ac.skipped()
+		}
+		// The user touched the machine since the last jitter: hold off
+		// until they have been idle for half the threshold again.
+		if lastUserActiveNS > lastJitterNS && idle < idleThreshold/2 {
+			return ac.skipped()
+		}
+		atomic.StoreInt64(&ac.activeSinceNS, 0)
+	} else {
+		if idle < idleThreshold {
 			atomic.StoreInt64(&ac.lastUserActiveNS, observedActiveTimestamp(nowNS, idle))
 			if lastActiveLog == 0 || time.Duration(nowNS-lastActiveLog) > 2*time.Minute {
 				atomic.StoreInt64(&ac.lastActiveLogNS, nowNS)
-				log.Printf("%s: user activity detected (idle: %v); pausing activity simulation", ac.platformName, idle)
+				logger().Debug("user is active; skipping activity simulation", "platform", ac.platformName, "idle", idle)
 			}
+			return ac.skipped()
+		}
+		if lastUserActiveNS != 0 && time.Duration(nowNS-lastUserActiveNS) < idleThreshold {
+			return ac.skipped()
+		}
+		if changedNS != 0 && time.Duration(nowNS-changedNS) < PresenceMinDwell {
 			return false
 		}
-	}
 
-	// Check if user is idle enough to simulate activity.
-	idleQualified := idle >= IdleThreshold || lastJitterNS != 0
-	if !idleQualified {
-		atomic.StoreInt64(&ac.lastJitterNS, 0)
-		atomic.StoreInt64(&ac.lastUserActiveNS, observedActiveTimestamp(nowNS, idle))
-		if lastActiveLog == 0 || time.Duration(nowNS-lastActiveLog) > 2*time.Minute {
-			atomic.StoreInt64(&ac.lastActiveLogNS, nowNS)
-			log.Printf("%s: user is active (idle: %v); skipping simulation to avoid interference", ac.platformName, idle)
+		// User became idle — log transition.
+		ac.setSimulating(true, nowNS)
+		if lastActiveLog != 0 {
+			atomic.StoreInt64(&ac.lastActiveLogNS, 0)
+			logger().Info("user became idle; resuming activity simulation", "platform", ac.platformName, "idle", idle)
 		}
-		return false
 	}
 
-	if lastUserActiveNS != 0 && time.Duration(nowNS-lastUserActiveNS) < IdleThreshold {
+	// Enforce minimum interval between jitter sessions.
+	if lastJitterNS != 0 && time.Duration(nowNS-lastJitterNS) < jitterInterval {
 		return false
 	}
 
-	// User became idle — log transition.
-	if lastActiveLog != 0 {
-		atomic.StoreInt64(&ac.lastActiveLogNS, 0)
-		log.Printf("%s: user became idle (%v); resuming activity simulation", ac.platformName, idle)
-	}
-
-	// Enforce minimum interval between jitter sessions.
-	if lastJitterNS != 0 && time.Duration(nowNS-lastJitterNS) < ChatAppActivityInterval {
+	if !ac.budget.Load().take() {
 		return false
 	}
 
 	// Execute jitter.
-	points := ac.patternGen.GenerateRoundJitterPoints()
+	points := ac.patternGen.GenerateShapePoints()
 	sessionDuration := ac.patternGen.JitterSessionDuration()
 	execute(points, sessionDuration)
 	atomic.StoreInt64(&ac.lastJitterNS, nowNS)
 
-	log.Printf("%s: idle detected (%v); jittered round mouse pattern (%v)", ac.platformName, idle, sessionDuration)
+	logger().Debug("simulated activity", "platform", ac.platformName, "idle", idle, "duration", sessionDuration)
 	return true
 }
+
+// Fire executes one jitter pattern immediately, ignoring idle time, the
+// jitter interval and the budget, and returns the number of points and the
+// session duration. The controller's timing state is left untouched so the
+// regular idle-gated jitter carries on as before.
+func (ac *ActivityController) Fire(execute JitterExecutor) (int, time.Duration) {
+	points := ac.patternGen.GenerateShapePoints()
+	sessionDuration := ac.patternGen.JitterSessionDuration()
+	execute(points, sessionDuration)
+
+	logger().Debug("simulated activity on request", "platform", ac.platformName, "duration", sessionDuration)
+	return len(points), sessionDuration
+}
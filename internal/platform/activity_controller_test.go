@@ -0,0 +1,177 @@
+package platform
+
+import (
+	"testing"
+	"time"
+)
+
+// idleTrace drives an ActivityController through a synthetic session: the
+// user touches the machine at each of inputs, checks run every
+// ChatAppCheckInterval, and idle time runs from the latest input, the
+// user's or a jitter's.
+type idleTrace struct {
+	inputs []time.Duration
+	length time.Duration
+}
+
+// traceResult records what the controller did over a trace.
+type traceResult struct {
+	jitters     []time.Duration
+	transitions int
+	skips       int
+}
+
+// skipCounter is an Observer that counts the jitters held off for the user.
+type skipCounter struct {
+	skips int
+}
+
+func (c *skipCounter) InhibitorFailed(string, error) {}
+func (c *skipCounter) InhibitorRestored(string)      {}
+func (c *skipCounter) SimulationPerformed(string)    {}
+func (c *skipCounter) SimulationSkipped()            { c.skips++ }
+
+func (tr idleTrace) run(t *testing.T) traceResult {
+	t.Helper()
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	clock := start
+	ac := NewActivityController("test", NewMousePatternGenerator(newCryptoSeededRand()))
+	ac.now = func() time.Time { return clock }
+	ac.lastUserActiveNS = start.UnixNano()
+	counter := &skipCounter{}
+	var slot observerSlot
+	slot.set(counter)
+	ac.setObserver(&slot)
+
+	var res traceResult
+	lastInput := time.Duration(0)
+	next := 0
+	simulating := false
+	for at := time.Duration(0); at <= tr.length; at += ChatAppCheckInterval {
+		clock = start.Add(at)
+		for next < len(tr.inputs) && tr.inputs[next] <= at {
+			lastInput = max(lastInput, tr.inputs[next])
+			next++
+		}
+		ac.MaybeJitter(
+			func() (time.Duration, error) { return at - lastInput, nil },
+			func([]MousePoint, time.Duration) {
+				res.jitters = append(res.jitters, at)
+				lastInput = at
+			},
+		)
+		if on := ac.simulating.Load(); on != simulating {
+			simulating = on
+			res.transitions++
+		}
+	}
+	res.skips = counter.skips
+	return res
+}
+
+// every returns input times from start to end, step apart.
+func every(start, end, step time.Duration) []time.Duration {
+	var ts []time.Duration
+	for at := start; at <= end; at += step {
+		ts = append(ts, at)
+	}
+	return ts
+}
+
+func TestActivityControllerStartsAfterIdleThreshold(t *testing.T) {
+	res := idleTrace{length: 5 * time.Minute}.run(t)
+	if len(res.jitters) == 0 || res.jitters[0] != IdleThreshold {
+		t.Fatalf("jitters = %v, want the first at %v", res.jitters, IdleThreshold)
+	}
+	for i := 1; i < len(res.jitters); i++ {
+		if gap := res.jitters[i] - res.jitters[i-1]; gap < ChatAppActivityInterval {
+			t.Errorf("jitters %v and %v are closer than the interval", res.jitters[i-1], res.jitters[i])
+		}
+	}
+	if res.transitions != 1 {
+		t.Errorf("transitions = %d, want 1", res.transitions)
+	}
+}
+
+func TestActivityControllerReportsIdleSkips(t *testing.T) {
+	res := idleTrace{inputs: every(0, 10*time.Minute, 10*time.Second), length: 10 * time.Minute}.run(t)
+	if len(res.jitters) != 0 {
+		t.Fatalf("jitters = %v, want none while the user is active", res.jitters)
+	}
+	if want := int(10*time.Minute/ChatAppCheckInterval) + 1; res.skips != want {
+		t.Errorf("skips = %d, want %d", res.skips, want)
+	}
+}
+
+func TestActivityControllerIgnoresBriefActivity(t *testing.T) {
+	// Once simulating, the user nudges the mouse every 70 seconds, never for
+	// long enough to count as being back.
+	tr := idleTrace{inputs: every(3*time.Minute, 15*time.Minute, 70*time.Second), length: 16 * time.Minute}
+	res := tr.run(t)
+	if res.transitions != 1 {
+		t.Fatalf("transitions = %d, want simulation to stay on", res.transitions)
+	}
+	for _, input := range tr.inputs {
+		for _, j := range res.jitters {
+			if j > input && j-input < IdleThreshold/2 {
+				t.Errorf("jitter at %v only %v after input at %v", j, j-input, input)
+			}
+		}
+	}
+}
+
+func TestActivityControllerStopsForSustainedActivity(t *testing.T) {
+	// The user comes back at 4m and works for three minutes, then leaves.
+	tr := idleTrace{inputs: every(4*time.Minute, 7*time.Minute, 3*time.Second), length: 12 * time.Minute}
+	res := tr.run(t)
+	if res.transitions != 3 {
+		t.Fatalf("transitions = %d, want on, off and on again", res.transitions)
+	}
+	for _, j := range res.jitters {
+		if j >= 4*time.Minute && j < 7*time.Minute+IdleThreshold {
+			t.Errorf("jitter at %v while the user was working or not yet idle", j)
+		}
+	}
+	if last := res.jitters[len(res.jitters)-1]; last < 9*time.Minute {
+		t.Errorf("simulation did not resume after the user left: last jitter at %v", last)
+	}
+}
+
+func TestActivityControllerMinimumDwell(t *testing.T) {
+	// The user is active on and off around the threshold; simulation may
+	// switch, but never sooner than PresenceMinDwell after the last switch.
+	inputs := []time.Duration{}
+	for base := time.Duration(0); base < 20*time.Minute; base += 150 * time.Second {
+		inputs = append(inputs, every(base, base+70*time.Second, 5*time.Second)...)
+	}
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	clock := start
+	ac := NewActivityController("test", NewMousePatternGenerator(newCryptoSeededRand()))
+	ac.now = func() time.Time { return clock }
+	ac.lastUserActiveNS = start.UnixNano()
+	ac.SetTimings(Timings{IdleThreshold: 30 * time.Second})
+
+	lastInput, next := time.Duration(0), 0
+	simulating, lastChange, switches := false, time.Duration(-time.Hour), 0
+	for at := time.Duration(0); at <= 20*time.Minute; at += ChatAppCheckInterval {
+		clock = start.Add(at)
+		for next < len(inputs) && inputs[next] <= at {
+			lastInput = max(lastInput, inputs[next])
+			next++
+		}
+		ac.MaybeJitter(
+			func() (time.Duration, error) { return at - lastInput, nil },
+			func([]MousePoint, time.Duration) { lastInput = at },
+		)
+		if on := ac.simulating.Load(); on != simulating {
+			if at-lastChange < PresenceMinDwell {
+				t.Fatalf("switched at %v, only %v after the previous switch", at, at-lastChange)
+			}
+			simulating, lastChange = on, at
+			switches++
+		}
+	}
+	if switches < 2 {
+		t.Fatalf("simulation switched %d times, want the trace to exercise both directions", switches)
+	}
+}
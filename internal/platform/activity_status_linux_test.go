@@ -29,12 +29,12 @@ func TestLinuxActivitySimulationStatusUsesRealBackends(t *testing.T) {
 			want: "ydotool",
 		},
 		{
-			name: "xdotool works only on x11",
+			name: "xtest works only on x11",
 			caps: linuxCapabilities{
-				displayServer:    displayServerX11,
-				xdotoolAvailable: true,
+				displayServer:  displayServerX11,
+				xtestAvailable: true,
 			},
-			want: "xdotool",
+			want: "xtest",
 		},
 	}
 
@@ -53,10 +53,9 @@ func TestLinuxActivitySimulationStatusUsesRealBackends(t *testing.T) {
 
 func TestLinuxActivitySimulationStatusRejectsSoftFallback(t *testing.T) {
 	status := linuxActivitySimulationStatus(linuxCapabilities{
-		displayServer:     displayServerWayland,
-		gdbusAvailable:    true,
-		dbusSendAvailable: true,
-		wtypeAvailable:    true,
+		displayServer:       displayServerWayland,
+		sessionBusAvailable: true,
+		wtypeAvailable:      true,
 	}, false)
 
 	if status.Available {
@@ -66,3 +65,24 @@ func TestLinuxActivitySimulationStatusRejectsSoftFallback(t *testing.T) {
 		t.Fatalf("status.Message = %q, want real backend warning", status.Message)
 	}
 }
+
+func TestRankMoversPrefersLastWorking(t *testing.T) {
+	k := &linuxKeepAlive{}
+	if got := strings.Join(k.rankMovers(""), ","); got != "uinput,ydotool,xtest" {
+		t.Errorf("rankMovers() = %s, want the default order", got)
+	}
+	k.lastMethod = "xtest"
+	if got := strings.Join(k.rankMovers(""), ","); got != "xtest,uinput,ydotool" {
+		t.Errorf("rankMovers() = %s, want xtest first", got)
+	}
+	if got := strings.Join(k.rankMovers("ydotool"), ","); got != "ydotool" {
+		t.Errorf("rankMovers(ydotool) = %s, want only the pinned mover", got)
+	}
+
+	if err := k.SetSimulationMethod("mouse"); err == nil {
+		t.Error("SetSimulationMethod accepted an unknown method")
+	}
+	if err := k.SetSimulationMethod("ydotool"); err != nil || k.simMethod != "ydotool" {
+		t.Errorf("SetSimulationMethod(ydotool) = %v, method %q", err, k.simMethod)
+	}
+}
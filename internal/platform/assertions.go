@@ -0,0 +1,120 @@
+package platform
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Assertion names a power assertion that keep-alive can hold on macOS.
+type Assertion string
+
+const (
+	// AssertionDisplay keeps the display from sleeping (caffeinate -d).
+	AssertionDisplay Assertion = "display"
+	// AssertionIdle keeps the system from idle sleeping (caffeinate -i).
+	AssertionIdle Assertion = "idle"
+	// AssertionDisk keeps the disk from idle sleeping (caffeinate -m).
+	AssertionDisk Assertion = "disk"
+	// AssertionSystem keeps the system from sleeping while on AC power
+	// (caffeinate -s).
+	AssertionSystem Assertion = "system"
+	// AssertionUserActive declares the user active, which also wakes the
+	// display (caffeinate -u).
+	AssertionUserActive Assertion = "user-active"
+)
+
+// Assertions lists the accepted assertion names in display order.
+var Assertions = []Assertion{
+	AssertionDisplay,
+	AssertionIdle,
+	AssertionDisk,
+	AssertionSystem,
+	AssertionUserActive,
+}
+
+// DefaultAssertions are held when none are selected.
+var DefaultAssertions = []Assertion{
+	AssertionDisplay,
+	AssertionIdle,
+	AssertionDisk,
+	AssertionSystem,
+}
+
+// ParseAssertions converts a comma-separated list of assertion names, such
+// as "idle,disk", to assertions. Duplicates are dropped.
+func ParseAssertions(s string) ([]Assertion, error) {
+	var out []Assertion
+	seen := make(map[Assertion]bool)
+	for _, field := range strings.Split(s, ",") {
+		name := Assertion(strings.ToLower(strings.TrimSpace(field)))
+		if !knownAssertion(name) {
+			names := make([]string, len(Assertions))
+			for i, a := range Assertions {
+				names[i] = string(a)
+			}
+			return nil, fmt.Errorf("unknown assertion %q (use %s)", strings.TrimSpace(field), strings.Join(names, ", "))
+		}
+		if !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	return out, nil
+}
+
+func knownAssertion(name Assertion) bool {
+	for _, a := range Assertions {
+		if name == a {
+			return true
+		}
+	}
+	return false
+}
+
+// vetoesIdleSleep reports whether a session holding assertions is meant to
+// keep the system from idle sleep, so that an attempt at it may be refused.
+func vetoesIdleSleep(assertions []Assertion) bool {
+	if len(assertions) == 0 {
+		assertions = DefaultAssertions
+	}
+	for _, a := range assertions {
+		if a == AssertionIdle || a == AssertionSystem {
+			return true
+		}
+	}
+	return false
+}
+
+// caffeinateArgs returns the caffeinate flags that hold assertions, or
+// DefaultAssertions when assertions is empty. A non-zero watchPID has
+// caffeinate release them when that process exits.
+func caffeinateArgs(assertions []Assertion, watchPID int) []string {
+	if len(assertions) == 0 {
+		assertions = DefaultAssertions
+	}
+	flags := map[Assertion]string{
+		AssertionDisplay:    "-d",
+		AssertionIdle:       "-i",
+		AssertionDisk:       "-m",
+		AssertionSystem:     "-s",
+		AssertionUserActive: "-u",
+	}
+	var args []string
+	userActive := false
+	for _, a := range assertions {
+		args = append(args, flags[a])
+		userActive = userActive || a == AssertionUserActive
+	}
+	if userActive {
+		// Without a timeout caffeinate holds the user-active assertion for
+		// only five seconds; with one it holds every assertion that long
+		// and then exits, so use the longest it accepts.
+		args = append(args, "-t", strconv.Itoa(math.MaxInt32))
+	}
+	if watchPID > 0 {
+		args = append(args, "-w", strconv.Itoa(watchPID))
+	}
+	return args
+}
@@ -0,0 +1,61 @@
+package platform
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestParseAssertions(t *testing.T) {
+	got, err := ParseAssertions(" Idle, disk,idle ")
+	if err != nil {
+		t.Fatalf("ParseAssertions returned error: %v", err)
+	}
+	if want := []Assertion{AssertionIdle, AssertionDisk}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseAssertions = %v, want %v", got, want)
+	}
+
+	for _, bad := range []string{"", "display,", "screen"} {
+		if _, err := ParseAssertions(bad); err == nil {
+			t.Errorf("ParseAssertions(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestCaffeinateArgs(t *testing.T) {
+	tests := []struct {
+		assertions []Assertion
+		watchPID   int
+		want       []string
+	}{
+		{nil, 0, []string{"-d", "-i", "-m", "-s"}},
+		{[]Assertion{AssertionDisplay}, 0, []string{"-d"}},
+		{[]Assertion{AssertionIdle, AssertionDisk}, 0, []string{"-i", "-m"}},
+		{[]Assertion{AssertionUserActive}, 0, []string{"-u", "-t", strconv.Itoa(math.MaxInt32)}},
+		{nil, 4242, []string{"-d", "-i", "-m", "-s", "-w", "4242"}},
+	}
+	for _, tt := range tests {
+		if got := caffeinateArgs(tt.assertions, tt.watchPID); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("caffeinateArgs(%v, %d) = %v, want %v", tt.assertions, tt.watchPID, got, tt.want)
+		}
+	}
+}
+
+func TestVetoesIdleSleep(t *testing.T) {
+	tests := []struct {
+		assertions []Assertion
+		want       bool
+	}{
+		{nil, true},
+		{[]Assertion{AssertionIdle}, true},
+		{[]Assertion{AssertionSystem}, true},
+		{[]Assertion{AssertionDisplay}, false},
+		{[]Assertion{AssertionDisk, AssertionUserActive}, false},
+	}
+	for _, tt := range tests {
+		if got := vetoesIdleSleep(tt.assertions); got != tt.want {
+			t.Errorf("vetoesIdleSleep(%v) = %v, want %v", tt.assertions, got, tt.want)
+		}
+	}
+}
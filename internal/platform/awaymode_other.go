@@ -0,0 +1,10 @@
+//go:build !windows
+
+package platform
+
+// GetAwayModeStatus reports that away mode is a Windows feature.
+func GetAwayModeStatus() AwayModeStatus {
+	return AwayModeStatus{
+		Message: "Away mode is only available on Windows; keeping the system awake normally.",
+	}
+}
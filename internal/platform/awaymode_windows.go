@@ -0,0 +1,80 @@
+//go:build windows
+
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+)
+
+// Power setting GUIDs for "Allow Away Mode Policy" in the sleep subgroup.
+const (
+	powerSubgroupSleep   = "238c9fa8-0aad-41ed-83f4-97be242c8f20"
+	powerSettingAwayMode = "25dfa149-5dd1-4736-b5ab-e8a37b5b8187"
+)
+
+var powercfgIndexRe = regexp.MustCompile(`0x([0-9a-fA-F]+)`)
+
+// parsePowercfgIndices extracts the current AC and DC values from the output
+// of powercfg /query for a single setting. The labels are localized, but the
+// current values are the only hexadecimal numbers printed for an enumerated
+// setting, AC first.
+func parsePowercfgIndices(out string) (ac, dc uint64, err error) {
+	matches := powercfgIndexRe.FindAllStringSubmatch(out, -1)
+	if len(matches) < 2 {
+		return 0, 0, errors.New("power setting values not found in powercfg output")
+	}
+	last := matches[len(matches)-2:]
+	if ac, err = strconv.ParseUint(last[0][1], 16, 32); err != nil {
+		return 0, 0, err
+	}
+	if dc, err = strconv.ParseUint(last[1][1], 16, 32); err != nil {
+		return 0, 0, err
+	}
+	return ac, dc, nil
+}
+
+// awayModeAllowed reports whether the active power plan allows away mode
+// on the current power source.
+func awayModeAllowed() (bool, error) {
+	out, err := exec.Command("powercfg", "/query", "SCHEME_CURRENT", powerSubgroupSleep, powerSettingAwayMode).Output()
+	if err != nil {
+		return false, fmt.Errorf("powercfg: %w", err)
+	}
+	ac, dc, err := parsePowercfgIndices(string(out))
+	if err != nil {
+		return false, err
+	}
+	if source, _ := GetPowerSource(); source == PowerSourceBattery {
+		return dc != 0, nil
+	}
+	return ac != 0, nil
+}
+
+// GetAwayModeStatus reports whether the power plan lets keep-alive request
+// away mode.
+func GetAwayModeStatus() AwayModeStatus {
+	if ModernStandby() {
+		return AwayModeStatus{
+			Message: "Modern standby systems have no away mode; keeping the system awake normally with the display on.",
+		}
+	}
+	allowed, err := awayModeAllowed()
+	if err != nil {
+		return AwayModeStatus{
+			Message: fmt.Sprintf("Could not read the away mode power setting (%v); keeping the system awake normally.", err),
+		}
+	}
+	if !allowed {
+		return AwayModeStatus{
+			Message: "The power plan does not allow away mode; keeping the system awake normally with the display on.",
+		}
+	}
+	return AwayModeStatus{
+		Available: true,
+		Message:   "Away mode is allowed by the power plan.",
+	}
+}
@@ -0,0 +1,31 @@
+//go:build windows
+
+package platform
+
+import "testing"
+
+func TestParsePowercfgIndices(t *testing.T) {
+	out := `Power Scheme GUID: 381b4222-f694-41f0-9685-ff5bb260df2e  (Balanced)
+  Subgroup GUID: 238c9fa8-0aad-41ed-83f4-97be242c8f20  (Sleep)
+    Power Setting GUID: 25dfa149-5dd1-4736-b5ab-e8a37b5b8187  (Allow Away Mode Policy)
+      Possible Setting Index: 000
+      Possible Setting Friendly Name: No
+      Possible Setting Index: 001
+      Possible Setting Friendly Name: Yes
+    Current AC Power Setting Index: 0x00000001
+    Current DC Power Setting Index: 0x00000000
+`
+	ac, dc, err := parsePowercfgIndices(out)
+	if err != nil {
+		t.Fatalf("parsePowercfgIndices() error = %v", err)
+	}
+	if ac != 1 || dc != 0 {
+		t.Fatalf("parsePowercfgIndices() = %d, %d, want 1, 0", ac, dc)
+	}
+}
+
+func TestParsePowercfgIndicesRejectsMissingValues(t *testing.T) {
+	if _, _, err := parsePowercfgIndices("The power scheme, subgroup or setting specified does not exist."); err == nil {
+		t.Fatal("parsePowercfgIndices() expected error")
+	}
+}
@@ -5,3 +5,23 @@ type BatteryStatus struct {
 	Percentage int
 	Available  bool
 }
+
+// PowerSource identifies what the machine is currently drawing power from.
+type PowerSource int
+
+const (
+	PowerSourceUnknown PowerSource = iota
+	PowerSourceAC
+	PowerSourceBattery
+)
+
+func (s PowerSource) String() string {
+	switch s {
+	case PowerSourceAC:
+		return "AC"
+	case PowerSourceBattery:
+		return "battery"
+	default:
+		return "unknown"
+	}
+}
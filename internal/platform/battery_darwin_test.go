@@ -22,3 +22,47 @@ func TestParseDarwinBatteryPercentageRejectsMissingValue(t *testing.T) {
 		t.Fatal("parseDarwinBatteryPercentage() expected error")
 	}
 }
+
+func TestParseIORegBatteryPercentage(t *testing.T) {
+	input := `+-o AppleSmartBattery  <class AppleSmartBattery, id 0x100000254, registered, matched, active, busy 0 (0 ms), retain 7>
+    {
+      "AppleRawCurrentCapacity" = 3050
+      "MaxCapacity" = 5100
+      "CurrentCapacity" = 3060
+      "ExternalConnected" = No
+    }`
+
+	got, err := parseIORegBatteryPercentage(input)
+	if err != nil {
+		t.Fatalf("parseIORegBatteryPercentage() error = %v", err)
+	}
+	if got != 60 {
+		t.Fatalf("parseIORegBatteryPercentage() = %d, want 60", got)
+	}
+}
+
+func TestParseIORegBatteryPercentageRejectsMissingValue(t *testing.T) {
+	if _, err := parseIORegBatteryPercentage(`"CurrentCapacity" = 50`); err == nil {
+		t.Fatal("parseIORegBatteryPercentage() expected error")
+	}
+}
+
+func TestParseDarwinPowerSource(t *testing.T) {
+	tests := map[string]PowerSource{
+		"Now drawing from 'AC Power'\n -InternalBattery-0 (id=1)\t100%; charged":         PowerSourceAC,
+		"Now drawing from 'Battery Power'\n -InternalBattery-0 (id=1)\t80%; discharging": PowerSourceBattery,
+		"Now drawing from 'UPS Power'": PowerSourceBattery,
+	}
+	for input, want := range tests {
+		got, err := parseDarwinPowerSource(input)
+		if err != nil {
+			t.Fatalf("parseDarwinPowerSource(%q) error = %v", input, err)
+		}
+		if got != want {
+			t.Fatalf("parseDarwinPowerSource(%q) = %v, want %v", input, got, want)
+		}
+	}
+	if _, err := parseDarwinPowerSource("garbage"); err == nil {
+		t.Fatal("parseDarwinPowerSource() expected error")
+	}
+}
@@ -55,3 +55,92 @@ func writePowerSupply(t *testing.T, root, name, supplyType, capacity string) {
 		t.Fatalf("write capacity: %v", err)
 	}
 }
+
+func TestParseUPowerPercentage(t *testing.T) {
+	input := `  native-path:          (null)
+  power supply:         yes
+  updated:              Thu 16 Oct 2026 10:12:01 AM CEST (12 seconds ago)
+  has history:          no
+  has statistics:       no
+  battery
+    present:             yes
+    state:               discharging
+    warning-level:       none
+    energy:              31.5 Wh
+    percentage:          57.4%
+    icon-name:          'battery-good-symbolic'
+`
+	got, err := parseUPowerPercentage(input)
+	if err != nil {
+		t.Fatalf("parseUPowerPercentage() error = %v", err)
+	}
+	if got != 57 {
+		t.Fatalf("parseUPowerPercentage() = %d, want 57", got)
+	}
+}
+
+func TestParseUPowerPercentageRejectsMissingBattery(t *testing.T) {
+	input := `  power supply:         no
+  unknown
+    present:             no
+    percentage:          0%
+`
+	if _, err := parseUPowerPercentage(input); err == nil {
+		t.Fatal("parseUPowerPercentage() expected error for absent battery")
+	}
+	if _, err := parseUPowerPercentage("  power supply: yes\n"); err == nil {
+		t.Fatal("parseUPowerPercentage() expected error for missing percentage")
+	}
+}
+
+func TestReadLinuxPowerSource(t *testing.T) {
+	write := func(t *testing.T, root, name string, attrs map[string]string) {
+		t.Helper()
+		dir := filepath.Join(root, name)
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", dir, err)
+		}
+		for k, v := range attrs {
+			if err := os.WriteFile(filepath.Join(dir, k), []byte(v+"\n"), 0o644); err != nil {
+				t.Fatalf("write %s: %v", k, err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name     string
+		supplies map[string]map[string]string
+		want     PowerSource
+	}{
+		{"desktop", nil, PowerSourceAC},
+		{"plugged in", map[string]map[string]string{
+			"AC":   {"type": "Mains", "online": "1"},
+			"BAT0": {"type": "Battery", "status": "Charging"},
+		}, PowerSourceAC},
+		{"unplugged", map[string]map[string]string{
+			"AC":   {"type": "Mains", "online": "0"},
+			"BAT0": {"type": "Battery", "status": "Full"},
+		}, PowerSourceBattery},
+		{"no mains entry", map[string]map[string]string{
+			"BAT0": {"type": "Battery", "status": "Discharging"},
+		}, PowerSourceBattery},
+		{"peripheral battery ignored", map[string]map[string]string{
+			"hidpp_battery_0": {"type": "Battery", "scope": "Device", "status": "Discharging"},
+		}, PowerSourceAC},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for name, attrs := range tt.supplies {
+				write(t, root, name, attrs)
+			}
+			got, err := readLinuxPowerSource(root)
+			if err != nil {
+				t.Fatalf("readLinuxPowerSource() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("readLinuxPowerSource() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -19,3 +19,28 @@ func TestBatteryPercentageFromWindowsStatusRejectsUnknown(t *testing.T) {
 		t.Fatal("batteryPercentageFromWindowsStatus() expected error")
 	}
 }
+
+func TestPowerSourceFromWindowsStatus(t *testing.T) {
+	tests := map[byte]PowerSource{0: PowerSourceBattery, 1: PowerSourceAC, 255: PowerSourceUnknown}
+	for line, want := range tests {
+		if got := powerSourceFromWindowsStatus(systemPowerStatus{ACLineStatus: line}); got != want {
+			t.Fatalf("powerSourceFromWindowsStatus(%d) = %v, want %v", line, got, want)
+		}
+	}
+}
+
+func TestParseTasklistCSV(t *testing.T) {
+	out := "\"rsync.exe\",\"1234\",\"Console\",\"1\",\"10,000 K\"\r\n\"rsync.exe\",\"5678\",\"Console\",\"1\",\"9,000 K\"\r\n"
+	got, err := parseTasklistCSV(out)
+	if err != nil {
+		t.Fatalf("parseTasklistCSV() error = %v", err)
+	}
+	if len(got) != 2 || got[0] != 1234 || got[1] != 5678 {
+		t.Fatalf("parseTasklistCSV() = %v, want [1234 5678]", got)
+	}
+
+	got, err = parseTasklistCSV("INFO: No tasks are running which match the specified criteria.\r\n")
+	if err != nil || len(got) != 0 {
+		t.Fatalf("parseTasklistCSV(no match) = %v, %v, want none", got, err)
+	}
+}
@@ -0,0 +1,56 @@
+package platform
+
+import "sync/atomic"
+
+// SimulationBudget caps how many jitter patterns a session may inject. It is
+// shared by every platform keep-alive started during the session, so pausing
+// and resuming does not refill it.
+type SimulationBudget struct {
+	max       int64
+	used      atomic.Int64
+	exhausted atomic.Bool
+}
+
+// NewSimulationBudget returns a budget of limit simulations. A limit of
+// zero or less means no limit.
+func NewSimulationBudget(limit int) *SimulationBudget {
+	return &SimulationBudget{max: int64(limit)}
+}
+
+// take reserves one simulation, reporting false once the budget is spent.
+// A nil budget has no limit.
+func (b *SimulationBudget) take() bool {
+	if b == nil {
+		return true
+	}
+	if b.max <= 0 {
+		b.used.Add(1)
+		return true
+	}
+	for {
+		used := b.used.Load()
+		if used >= b.max {
+			if b.exhausted.CompareAndSwap(false, true) {
+				logger().Warn("simulation budget exhausted; sleep prevention continues without simulated input", "max", b.max)
+			}
+			return false
+		}
+		if b.used.CompareAndSwap(used, used+1) {
+			return true
+		}
+	}
+}
+
+// Used returns the number of simulations performed and the limit, which is
+// zero when there is none.
+func (b *SimulationBudget) Used() (used, limit int) {
+	if b == nil {
+		return 0, 0
+	}
+	return int(b.used.Load()), int(max(b.max, 0))
+}
+
+// Exhausted reports whether a simulation has been refused for lack of budget.
+func (b *SimulationBudget) Exhausted() bool {
+	return b != nil && b.exhausted.Load()
+}
@@ -0,0 +1,70 @@
+package platform
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSimulationBudgetStopsJitter(t *testing.T) {
+	budget := NewSimulationBudget(2)
+	ac := NewActivityController("test", NewMousePatternGenerator(newCryptoSeededRand()))
+	ac.SetTimings(Timings{ChatAppActivityInterval: MinChatAppActivityInterval})
+	ac.SetBudget(budget)
+
+	executed := 0
+	for i := 0; i < 4; i++ {
+		// Fake enough idle time for every call to qualify.
+		ac.lastUserActiveNS = time.Now().Add(-time.Hour).UnixNano()
+		ac.lastJitterNS = 0
+		ac.MaybeJitter(
+			func() (time.Duration, error) { return time.Hour, nil },
+			func([]MousePoint, time.Duration) { executed++ },
+		)
+	}
+
+	if executed != 2 {
+		t.Fatalf("executed %d jitters, want 2", executed)
+	}
+	if used, limit := budget.Used(); used != 2 || limit != 2 {
+		t.Fatalf("Used() = %d, %d, want 2, 2", used, limit)
+	}
+	if !budget.Exhausted() {
+		t.Fatal("Exhausted() = false after the budget was spent")
+	}
+}
+
+func TestSimulationBudgetUnlimited(t *testing.T) {
+	var nilBudget *SimulationBudget
+	if !nilBudget.take() || nilBudget.Exhausted() {
+		t.Fatal("nil budget should never run out")
+	}
+
+	budget := NewSimulationBudget(0)
+	for i := 0; i < 5; i++ {
+		if !budget.take() {
+			t.Fatal("unlimited budget refused a simulation")
+		}
+	}
+	if used, limit := budget.Used(); used != 5 || limit != 0 {
+		t.Fatalf("Used() = %d, %d, want 5, 0", used, limit)
+	}
+}
+
+func TestFireIgnoresIdleAndBudget(t *testing.T) {
+	budget := NewSimulationBudget(1)
+	budget.take()
+	ac := NewActivityController("test", NewMousePatternGenerator(newCryptoSeededRand()))
+	ac.SetBudget(budget)
+
+	var got int
+	points, d := ac.Fire(func(p []MousePoint, _ time.Duration) { got = len(p) })
+	if points == 0 || got != points || d <= 0 {
+		t.Fatalf("Fire() = %d points over %v, executor saw %d", points, d, got)
+	}
+	if used, _ := budget.Used(); used != 1 {
+		t.Errorf("Fire() took from the budget: used = %d", used)
+	}
+	if ac.lastJitterNS != 0 {
+		t.Error("Fire() changed the jitter timing state")
+	}
+}
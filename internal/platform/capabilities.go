@@ -0,0 +1,153 @@
+package platform
+
+import "slices"
+
+// Capability reports whether keep-alive can do one thing on this machine,
+// and with which methods.
+type Capability struct {
+	Supported bool `json:"supported"`
+	// Methods lists the mechanisms that provide it, in the order they are
+	// tried.
+	Methods []string `json:"methods"`
+}
+
+// Capabilities summarizes what keep-alive can do on this machine in the
+// same terms on every operating system, for wrapper tooling that should not
+// need to know each platform's inhibitors.
+type Capabilities struct {
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+	// SleepInhibition keeps the system from suspending.
+	SleepInhibition Capability `json:"sleep_inhibition"`
+	// DisplayInhibition keeps the display from blanking.
+	DisplayInhibition Capability `json:"display_inhibition"`
+	// LockPrevention keeps the idle screen lock from engaging.
+	LockPrevention Capability `json:"lock_prevention"`
+	// InputSimulation moves the pointer for --active.
+	InputSimulation Capability `json:"input_simulation"`
+	// IdleDetection reads how long the user has been idle.
+	IdleDetection Capability `json:"idle_detection"`
+}
+
+// NamedCapability is a Capability with its name for display.
+type NamedCapability struct {
+	Name string
+	Capability
+}
+
+// List returns the capabilities in report order, named for display.
+func (c Capabilities) List() []NamedCapability {
+	return []NamedCapability{
+		{"Sleep inhibition", c.SleepInhibition},
+		{"Display inhibition", c.DisplayInhibition},
+		{"Lock prevention", c.LockPrevention},
+		{"Input simulation", c.InputSimulation},
+		{"Idle detection", c.IdleDetection},
+	}
+}
+
+// inhibitorEffect records what an inhibitor holds off and the tools it
+// needs; any one of the tools is enough, and none means it needs nothing.
+type inhibitorEffect struct {
+	sleep, display, lock bool
+	tools                []string
+}
+
+// dbusTools is the session bus, which the D-Bus inhibitors reach without an
+// external client.
+var dbusTools = []string{"dbus"}
+
+// inhibitorEffects describes the inhibitors Diagnose lists, by name.
+var inhibitorEffects = map[string]inhibitorEffect{
+	// macOS: caffeinate -s -d -m -i holds the display on as well.
+	"caffeinate":  {sleep: true, display: true, lock: true, tools: []string{"caffeinate"}},
+	"pmset touch": {sleep: true, tools: []string{"pmset"}},
+
+	// Windows: the execution state includes ES_DISPLAY_REQUIRED.
+	"SetThreadExecutionState": {sleep: true, display: true, lock: true},
+	"PowerShell":              {sleep: true, display: true, lock: true, tools: []string{"powershell"}},
+
+	// Linux
+	"logind":               {sleep: true, tools: []string{"logind"}},
+	"loginctl":             {sleep: true, tools: []string{"loginctl"}},
+	"wayland-idle-inhibit": {display: true, lock: true, tools: []string{"wayland-idle-inhibit"}},
+	"portal":               {sleep: true, display: true, lock: true, tools: []string{"portal"}},
+	"dbus-gnome-suspend":   {sleep: true, tools: dbusTools},
+	"dbus-gnome-idle":      {display: true, lock: true, tools: dbusTools},
+	"dbus-cosmic-suspend":  {sleep: true, tools: dbusTools},
+	"dbus-cosmic-idle":     {display: true, lock: true, tools: dbusTools},
+	"gsettings":            {sleep: true, display: true, lock: true, tools: []string{"gsettings"}},
+	"sway":                 {display: true, lock: true, tools: []string{"swaymsg"}},
+	"hyprland":             {display: true, lock: true, tools: []string{"hyprctl"}},
+	"dbus-kde-powerdevil":  {sleep: true, display: true, lock: true, tools: dbusTools},
+	"dbus-kde":             {sleep: true, tools: dbusTools},
+	"dbus-xfce":            {sleep: true, tools: dbusTools},
+	"dbus-mate":            {sleep: true, display: true, lock: true, tools: dbusTools},
+	"dbus-freedesktop":     {display: true, lock: true, tools: dbusTools},
+	"xset":                 {display: true, lock: true, tools: []string{"xset"}},
+}
+
+// DetectCapabilities probes this machine for what keep-alive can do. It
+// reads the idle time, so it takes as long as Diagnose and IdleSources.
+func DetectCapabilities() Capabilities {
+	return capabilitiesFrom(Diagnose(), IdleSources())
+}
+
+// capabilitiesFrom normalizes d and the idle sources into Capabilities. An
+// inhibitor counts only if the tools it needs are installed.
+func capabilitiesFrom(d Diagnostics, idle []IdleSource) Capabilities {
+	c := Capabilities{
+		OS:                d.OS,
+		Arch:              d.Arch,
+		SleepInhibition:   Capability{Methods: []string{}},
+		DisplayInhibition: Capability{Methods: []string{}},
+		LockPrevention:    Capability{Methods: []string{}},
+		InputSimulation:   Capability{Methods: []string{}},
+		IdleDetection:     Capability{Methods: []string{}},
+	}
+	for _, name := range d.Inhibitors {
+		effect, ok := inhibitorEffects[name]
+		if !ok || !hasAnyTool(d.Tools, effect.tools) {
+			continue
+		}
+		if effect.sleep {
+			c.SleepInhibition.add(name)
+		}
+		if effect.display {
+			c.DisplayInhibition.add(name)
+		}
+		if effect.lock {
+			c.LockPrevention.add(name)
+		}
+	}
+	if d.ActivitySimulation.Available {
+		for _, method := range d.SimulationMethods {
+			c.InputSimulation.add(method)
+		}
+	}
+	for _, src := range idle {
+		if src.Err == nil {
+			c.IdleDetection.add(src.Name)
+		}
+	}
+	return c
+}
+
+func (c *Capability) add(method string) {
+	if !slices.Contains(c.Methods, method) {
+		c.Methods = append(c.Methods, method)
+	}
+	c.Supported = true
+}
+
+func hasAnyTool(installed map[string]bool, tools []string) bool {
+	if len(tools) == 0 {
+		return true
+	}
+	for _, tool := range tools {
+		if installed[tool] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package platform
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestCapabilitiesFrom(t *testing.T) {
+	d := Diagnostics{
+		OS:         "linux",
+		Inhibitors: []string{"logind", "dbus-gnome-suspend", "dbus-gnome-idle", "gsettings", "dbus-freedesktop", "xset"},
+		Tools:      map[string]bool{"logind": true, "dbus": true, "gsettings": false, "xset": false},
+		ActivitySimulation: ActivitySimulationStatus{
+			Available: true,
+			Method:    "uinput",
+		},
+		SimulationMethods: []string{"uinput", "ydotool"},
+	}
+	idle := []IdleSource{{Name: "xprintidle/D-Bus"}, {Name: "broken", Err: errors.New("no")}}
+
+	c := capabilitiesFrom(d, idle)
+	checks := []struct {
+		name string
+		got  Capability
+		want []string
+	}{
+		{"sleep", c.SleepInhibition, []string{"logind", "dbus-gnome-suspend"}},
+		{"display", c.DisplayInhibition, []string{"dbus-gnome-idle", "dbus-freedesktop"}},
+		{"lock", c.LockPrevention, []string{"dbus-gnome-idle", "dbus-freedesktop"}},
+		{"input", c.InputSimulation, []string{"uinput", "ydotool"}},
+		{"idle", c.IdleDetection, []string{"xprintidle/D-Bus"}},
+	}
+	for _, tt := range checks {
+		if !tt.got.Supported || !slices.Equal(tt.got.Methods, tt.want) {
+			t.Errorf("%s = %+v, want supported by %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestCapabilitiesFromUnsupported(t *testing.T) {
+	d := Diagnostics{
+		OS:                 "plan9",
+		ActivitySimulation: ActivitySimulationStatus{Method: "none"},
+		SimulationMethods:  []string{"none"},
+	}
+	c := capabilitiesFrom(d, nil)
+	for name, got := range map[string]Capability{
+		"sleep":   c.SleepInhibition,
+		"display": c.DisplayInhibition,
+		"lock":    c.LockPrevention,
+		"input":   c.InputSimulation,
+		"idle":    c.IdleDetection,
+	} {
+		if got.Supported || got.Methods == nil || len(got.Methods) != 0 {
+			t.Errorf("%s = %+v, want unsupported with no methods", name, got)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+//go:build darwin && cgo
+
+package platform
+
+/*
+#cgo LDFLAGS: -framework ApplicationServices -framework CoreFoundation
+#include <ApplicationServices/ApplicationServices.h>
+
+static int ka_cursor_position(double *x, double *y) {
+	CGEventRef ev = CGEventCreate(NULL);
+	if (ev == NULL) {
+		return 0;
+	}
+	CGPoint p = CGEventGetLocation(ev);
+	CFRelease(ev);
+	*x = p.x;
+	*y = p.y;
+	return 1;
+}
+
+static int ka_post_mouse_move(double x, double y) {
+	CGEventRef ev = CGEventCreateMouseEvent(NULL, kCGEventMouseMoved, CGPointMake(x, y), kCGMouseButtonLeft);
+	if (ev == NULL) {
+		return 0;
+	}
+	CGEventPost(kCGHIDEventTap, ev);
+	CFRelease(ev);
+	return 1;
+}
+
+// ka_display_bounds stores the frame of the display holding (x, y) and
+// returns 1, or returns 0 if no display holds it.
+static int ka_display_bounds(double x, double y, double *bx, double *by, double *bw, double *bh) {
+	CGDirectDisplayID display;
+	uint32_t count = 0;
+	if (CGGetDisplaysWithPoint(CGPointMake(x, y), 1, &display, &count) != kCGErrorSuccess || count == 0) {
+		return 0;
+	}
+	CGRect r = CGDisplayBounds(display);
+	*bx = r.origin.x;
+	*by = r.origin.y;
+	*bw = r.size.width;
+	*bh = r.size.height;
+	return 1;
+}
+
+static int ka_warp_cursor(double x, double y) {
+	return CGWarpMouseCursorPosition(CGPointMake(x, y)) == kCGErrorSuccess;
+}
+
+// ka_screen_locked returns 1 if the session's screen is locked, 0 if not
+// and -1 if this process has no window server session to ask.
+static int ka_screen_locked(void) {
+	CFDictionaryRef session = CGSessionCopyCurrentDictionary();
+	if (session == NULL) {
+		return -1;
+	}
+	int locked = 0;
+	CFTypeRef v = CFDictionaryGetValue(session, CFSTR("CGSSessionScreenIsLocked"));
+	if (v != NULL && CFGetTypeID(v) == CFBooleanGetTypeID()) {
+		locked = CFBooleanGetValue((CFBooleanRef)v) ? 1 : 0;
+	}
+	CFRelease(session);
+	return locked;
+}
+
+// ka_idle_seconds returns the seconds since the last keyboard, mouse or
+// tablet event of the HID system, which is what the user did rather than
+// what any process posted.
+static double ka_idle_seconds(void) {
+	return CGEventSourceSecondsSinceLastEventType(kCGEventSourceStateHIDSystemState, kCGAnyInputEventType);
+}
+
+// ka_accessibility_trusted checks the Accessibility grant. With prompt set,
+// macOS adds the process to the Accessibility list and shows its own alert
+// if the grant is missing.
+static int ka_accessibility_trusted(int prompt) {
+	const void *keys[] = { kAXTrustedCheckOptionPrompt };
+	const void *values[] = { prompt ? kCFBooleanTrue : kCFBooleanFalse };
+	CFDictionaryRef options = CFDictionaryCreate(NULL, keys, values, 1,
+		&kCFTypeDictionaryKeyCallBacks, &kCFTypeDictionaryValueCallBacks);
+	if (options == NULL) {
+		return AXIsProcessTrusted() ? 1 : 0;
+	}
+	Boolean trusted = AXIsProcessTrustedWithOptions(options);
+	CFRelease(options);
+	return trusted ? 1 : 0;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// nativeEventsAvailable reports whether this build posts input events
+// through CoreGraphics itself rather than only through osascript.
+const nativeEventsAvailable = true
+
+// cgAccessibilityTrusted reports whether this process may post input
+// events. Without the Accessibility grant, CGEventPost drops them without
+// an error.
+func cgAccessibilityTrusted() bool {
+	return C.ka_accessibility_trusted(0) != 0
+}
+
+// cgRequestAccessibility is cgAccessibilityTrusted, except that a missing
+// grant makes macOS list this process under Accessibility and say so.
+func cgRequestAccessibility() bool {
+	return C.ka_accessibility_trusted(1) != 0
+}
+
+// cgIdleTime reads the idle time with CGEventSourceSecondsSinceLastEventType,
+// without starting a process.
+func cgIdleTime() (time.Duration, error) {
+	seconds := float64(C.ka_idle_seconds())
+	if math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		return 0, errors.New("CGEventSourceSecondsSinceLastEventType returned no time")
+	}
+	if seconds < 0 {
+		seconds = 0
+	}
+	if seconds > math.MaxInt64/float64(time.Second) {
+		return time.Duration(math.MaxInt64), nil
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// cgScreenLocked reports whether the screen of this login session is
+// locked, as recorded in the window server's session dictionary.
+func cgScreenLocked() (bool, error) {
+	switch C.ka_screen_locked() {
+	case -1:
+		return false, errors.New("no window server session")
+	case 1:
+		return true, nil
+	}
+	return false, nil
+}
+
+// cgPostMouseMove posts a mouse-moved event to (x, y), in global display
+// coordinates, as the HID system would.
+func cgPostMouseMove(x, y float64) error {
+	if C.ka_post_mouse_move(C.double(x), C.double(y)) == 0 {
+		return errors.New("CGEventCreateMouseEvent failed")
+	}
+	return nil
+}
+
+// cgDisplayBounds returns the frame of the display holding (x, y), from
+// CGDisplayBounds.
+func cgDisplayBounds(x, y float64) (displayBounds, error) {
+	var bx, by, bw, bh C.double
+	if C.ka_display_bounds(C.double(x), C.double(y), &bx, &by, &bw, &bh) == 0 {
+		return displayBounds{}, errors.New("no display holds the pointer")
+	}
+	return displayBounds{X: float64(bx), Y: float64(by), Width: float64(bw), Height: float64(bh)}, nil
+}
+
+// cgCursor reads the pointer position from a CoreGraphics event and sets
+// it with CGWarpMouseCursorPosition.
+type cgCursor struct{}
+
+func (cgCursor) cursorPosition() (cursorPos, error) {
+	var x, y C.double
+	if C.ka_cursor_position(&x, &y) == 0 {
+		return cursorPos{}, errors.New("CGEventCreate failed")
+	}
+	return cursorPos{X: int(x), Y: int(y)}, nil
+}
+
+func (cgCursor) setCursorPosition(p cursorPos) error {
+	if C.ka_warp_cursor(C.double(p.X), C.double(p.Y)) == 0 {
+		return errors.New("CGWarpMouseCursorPosition failed")
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+//go:build darwin && !cgo
+
+package platform
+
+import (
+	"errors"
+	"time"
+)
+
+// nativeEventsAvailable reports whether this build posts input events
+// through CoreGraphics itself. Builds without cgo only have osascript.
+const nativeEventsAvailable = false
+
+var errNoCgo = errors.New("built without cgo")
+
+func cgAccessibilityTrusted() bool {
+	return false
+}
+
+func cgIdleTime() (time.Duration, error) {
+	return 0, errNoCgo
+}
+
+func cgRequestAccessibility() bool {
+	return false
+}
+
+func cgScreenLocked() (bool, error) {
+	return false, errNoCgo
+}
+
+func cgPostMouseMove(x, y float64) error {
+	return errNoCgo
+}
+
+func cgDisplayBounds(x, y float64) (displayBounds, error) {
+	return displayBounds{}, errNoCgo
+}
+
+type cgCursor struct{}
+
+func (cgCursor) cursorPosition() (cursorPos, error) {
+	return cursorPos{}, errNoCgo
+}
+
+func (cgCursor) setCursorPosition(cursorPos) error {
+	return errNoCgo
+}
@@ -0,0 +1,70 @@
+package platform
+
+import (
+	"runtime"
+	"strings"
+)
+
+// Competitor is another keep-awake or mouse-jiggler tool that may be running
+// alongside keep-alive.
+type Competitor struct {
+	Name string
+	// Processes are the process names the tool runs as, as matched by
+	// FindProcesses.
+	Processes []string
+	// Simulates is set for tools that move the mouse or press keys, whose
+	// input would compound with activity simulation. The others only keep
+	// the system awake, which is harmless to duplicate.
+	Simulates bool
+}
+
+// competitors lists the known tools by operating system.
+var competitors = map[string][]Competitor{
+	"linux": {
+		{Name: "caffeine-ng", Processes: []string{"caffeine-ng", "caffeine"}},
+	},
+	"darwin": {
+		{Name: "Amphetamine", Processes: []string{"Amphetamine"}},
+		{Name: "Caffeine", Processes: []string{"Caffeine"}},
+		{Name: "KeepingYouAwake", Processes: []string{"KeepingYouAwake"}},
+		{Name: "Jiggler", Processes: []string{"Jiggler"}, Simulates: true},
+	},
+	"windows": {
+		{Name: "PowerToys Awake", Processes: []string{"PowerToys.Awake"}},
+		// Caffeine keeps Windows awake by pressing F15.
+		{Name: "Caffeine", Processes: []string{"caffeine", "caffeine32", "caffeine64"}, Simulates: true},
+		{Name: "Mouse Jiggler", Processes: []string{"MouseJiggle", "MouseJiggler"}, Simulates: true},
+	},
+}
+
+// DetectCompetitors returns the known keep-awake and jiggler tools that are
+// running. Lookup failures are treated as the tool not running.
+func DetectCompetitors() []Competitor {
+	return detectCompetitors(competitors[runtime.GOOS], FindProcesses)
+}
+
+func detectCompetitors(known []Competitor, find func(name string) ([]int, error)) []Competitor {
+	var running []Competitor
+	for _, c := range known {
+		for _, name := range c.Processes {
+			if pids, err := find(name); err == nil && len(pids) > 0 {
+				running = append(running, c)
+				break
+			}
+		}
+	}
+	return running
+}
+
+// CompetitorNames joins the names of cs for messages, such as
+// "Amphetamine and Jiggler".
+func CompetitorNames(cs []Competitor) string {
+	names := make([]string, len(cs))
+	for i, c := range cs {
+		names[i] = c.Name
+	}
+	if len(names) <= 1 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
@@ -0,0 +1,49 @@
+package platform
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDetectCompetitors(t *testing.T) {
+	known := []Competitor{
+		{Name: "Caffeine", Processes: []string{"caffeine", "caffeine64"}, Simulates: true},
+		{Name: "Awake", Processes: []string{"Awake"}},
+		{Name: "Broken", Processes: []string{"broken"}},
+	}
+	find := func(name string) ([]int, error) {
+		switch name {
+		case "caffeine64":
+			return []int{42}, nil
+		case "broken":
+			return nil, errors.New("lookup failed")
+		}
+		return nil, nil
+	}
+
+	got := detectCompetitors(known, find)
+	if len(got) != 1 || got[0].Name != "Caffeine" || !got[0].Simulates {
+		t.Fatalf("detectCompetitors() = %+v, want only Caffeine", got)
+	}
+}
+
+func TestCompetitorNames(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  string
+	}{
+		{nil, ""},
+		{[]string{"Amphetamine"}, "Amphetamine"},
+		{[]string{"Amphetamine", "Jiggler"}, "Amphetamine and Jiggler"},
+		{[]string{"A", "B", "C"}, "A, B and C"},
+	}
+	for _, tt := range tests {
+		cs := make([]Competitor, len(tt.names))
+		for i, n := range tt.names {
+			cs[i].Name = n
+		}
+		if got := CompetitorNames(cs); got != tt.want {
+			t.Errorf("CompetitorNames(%v) = %q, want %q", tt.names, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+//go:build linux
+
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// compositorIPCTimeout bounds each swaymsg and hyprctl command, so that a
+// compositor whose IPC socket is wedged cannot hang Start or Stop.
+const compositorIPCTimeout = 5 * time.Second
+
+// parentPID returns the parent of process pid from its stat file under
+// procRoot.
+func parentPID(procRoot string, pid int) (int, error) {
+	data, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0, err
+	}
+	// The command name in parentheses may hold spaces and parentheses of
+	// its own, so the fields are counted from the last ')'.
+	end := strings.LastIndexByte(string(data), ')')
+	if end < 0 {
+		return 0, fmt.Errorf("malformed stat for process %d", pid)
+	}
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("malformed stat for process %d", pid)
+	}
+	return strconv.Atoi(fields[1])
+}
+
+// processLineage returns pid followed by its ancestors, nearest first, up to
+// but not including init.
+func processLineage(procRoot string, pid int) []int {
+	var pids []int
+	for pid > 1 && len(pids) < 64 {
+		pids = append(pids, pid)
+		ppid, err := parentPID(procRoot, pid)
+		if err != nil {
+			break
+		}
+		pid = ppid
+	}
+	return pids
+}
+
+// nearestWindow returns the index in lineage of windowPID, the process
+// owning a window, or -1 when it is not keep-alive or one of its ancestors.
+func nearestWindow(lineage []int, windowPID int) int {
+	for i, pid := range lineage {
+		if pid == windowPID {
+			return i
+		}
+	}
+	return -1
+}
+
+// swayNode is a node of sway's layout tree.
+type swayNode struct {
+	ID            int64      `json:"id"`
+	PID           int        `json:"pid"`
+	Nodes         []swayNode `json:"nodes"`
+	FloatingNodes []swayNode `json:"floating_nodes"`
+}
+
+// findSwayView returns the id of the view in tree, the output of swaymsg
+// -t get_tree, owned by the process nearest in lineage: keep-alive itself
+// or the terminal it runs in.
+func findSwayView(tree []byte, lineage []int) (int64, error) {
+	var root swayNode
+	if err := json.Unmarshal(tree, &root); err != nil {
+		return 0, fmt.Errorf("parse sway tree: %w", err)
+	}
+	best, bestRank := int64(0), -1
+	var walk func(n swayNode)
+	walk = func(n swayNode) {
+		if n.PID > 0 {
+			if rank := nearestWindow(lineage, n.PID); rank >= 0 && (bestRank < 0 || rank < bestRank) {
+				best, bestRank = n.ID, rank
+			}
+		}
+		for _, c := range n.Nodes {
+			walk(c)
+		}
+		for _, c := range n.FloatingNodes {
+			walk(c)
+		}
+	}
+	walk(root)
+	if bestRank < 0 {
+		return 0, errors.New("no sway window belongs to keep-alive or its terminal")
+	}
+	return best, nil
+}
+
+// swayInhibitor holds off idle through sway's IPC: it wakes the outputs and
+// sets inhibit_idle on the window keep-alive runs in, which holds off
+// swayidle's timeouts for as long as the window stays open.
+type swayInhibitor struct {
+	view int64
+}
+
+func (s *swayInhibitor) Name() string { return "sway" }
+func (s *swayInhibitor) Activate(ctx context.Context) error {
+	if !hasCommand("swaymsg") {
+		return fmt.Errorf("swaymsg command not found")
+	}
+	tree, err := runVerboseContext(ctx, compositorIPCTimeout, "swaymsg", "-t", "get_tree", "-r")
+	if err != nil {
+		return fmt.Errorf("swaymsg get_tree failed: %v", err)
+	}
+	view, err := findSwayView([]byte(tree), processLineage("/proc", os.Getpid()))
+	if err != nil {
+		return err
+	}
+	// Outputs powered off by an earlier timeout stay off until input
+	// arrives; "power" replaced "dpms" in sway 1.8.
+	if _, err := runVerboseContext(ctx, compositorIPCTimeout, "swaymsg", "output * power on"); err != nil {
+		if out, err := runVerboseContext(ctx, compositorIPCTimeout, "swaymsg", "output * dpms on"); err != nil {
+			logger().Debug("waking sway outputs failed", "err", err, "output", out)
+		}
+	}
+	if out, err := runVerboseContext(ctx, compositorIPCTimeout, "swaymsg", fmt.Sprintf("[con_id=%d] inhibit_idle open", view)); err != nil {
+		return fmt.Errorf("swaymsg inhibit_idle failed: %v: %s", err, out)
+	}
+	s.view = view
+	logger().Info("sway idle inhibit set", "con_id", view)
+	return nil
+}
+
+func (s *swayInhibitor) Deactivate() error {
+	if s.view == 0 {
+		return nil
+	}
+	_, err := runVerboseTimeout(compositorIPCTimeout, "swaymsg", fmt.Sprintf("[con_id=%d] inhibit_idle none", s.view))
+	s.view = 0
+	return err
+}
+
+// hyprlandClient is a window as hyprctl clients -j lists it.
+type hyprlandClient struct {
+	Address string `json:"address"`
+	PID     int    `json:"pid"`
+}
+
+// findHyprlandWindow returns the address of the window in clients, the
+// output of hyprctl clients -j, owned by the process nearest in lineage.
+func findHyprlandWindow(clients []byte, lineage []int) (string, error) {
+	var list []hyprlandClient
+	if err := json.Unmarshal(clients, &list); err != nil {
+		return "", fmt.Errorf("parse Hyprland clients: %w", err)
+	}
+	best, bestRank := "", -1
+	for _, c := range list {
+		if rank := nearestWindow(lineage, c.PID); rank >= 0 && (bestRank < 0 || rank < bestRank) && c.Address != "" {
+			best, bestRank = c.Address, rank
+		}
+	}
+	if bestRank < 0 {
+		return "", errors.New("no Hyprland window belongs to keep-alive or its terminal")
+	}
+	return best, nil
+}
+
+// hyprctl runs a hyprctl command that answers "ok" on success; hyprctl
+// exits 0 even when the command fails.
+func hyprctl(ctx context.Context, args ...string) error {
+	out, err := runVerboseContext(ctx, compositorIPCTimeout, "hyprctl", args...)
+	if err != nil {
+		return fmt.Errorf("hyprctl %s failed: %v", args[0], err)
+	}
+	if out != "ok" {
+		return fmt.Errorf("hyprctl %s: %s", args[0], out)
+	}
+	return nil
+}
+
+// hyprlandInhibitor holds off idle through hyprctl: it wakes the outputs and
+// sets the idleinhibit property on the window keep-alive runs in, which
+// holds off hypridle for as long as the window stays open.
+type hyprlandInhibitor struct {
+	window string
+}
+
+func (h *hyprlandInhibitor) Name() string { return "hyprland" }
+func (h *hyprlandInhibitor) Activate(ctx context.Context) error {
+	if !hasCommand("hyprctl") {
+		return fmt.Errorf("hyprctl command not found")
+	}
+	clients, err := runVerboseContext(ctx, compositorIPCTimeout, "hyprctl", "clients", "-j")
+	if err != nil {
+		return fmt.Errorf("hyprctl clients failed: %v", err)
+	}
+	window, err := findHyprlandWindow([]byte(clients), processLineage("/proc", os.Getpid()))
+	if err != nil {
+		return err
+	}
+	if err := hyprctl(ctx, "dispatch", "dpms", "on"); err != nil {
+		logger().Debug("waking Hyprland outputs failed", "err", err)
+	}
+	if err := hyprctl(ctx, "setprop", "address:"+window, "idleinhibit", "always"); err != nil {
+		return err
+	}
+	h.window = window
+	logger().Info("Hyprland idle inhibit set", "window", window)
+	return nil
+}
+
+func (h *hyprlandInhibitor) Deactivate() error {
+	if h.window == "" {
+		return nil
+	}
+	err := hyprctl(context.Background(), "setprop", "address:"+h.window, "idleinhibit", "none")
+	h.window = ""
+	return err
+}
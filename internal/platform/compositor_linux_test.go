@@ -0,0 +1,83 @@
+//go:build linux
+
+package platform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestProcessLineage(t *testing.T) {
+	root := t.TempDir()
+	for pid, stat := range map[int]string{
+		40: "40 (keepalive) S 30 40 30 0",
+		30: "30 (zsh) S 20 30 30 0",
+		20: "20 (foot (server)) S 1 20 20 0",
+	} {
+		dir := filepath.Join(root, strconv.Itoa(pid))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := processLineage(root, 40); !slices.Equal(got, []int{40, 30, 20}) {
+		t.Errorf("processLineage() = %v, want [40 30 20]", got)
+	}
+	if got := processLineage(root, 99); !slices.Equal(got, []int{99}) {
+		t.Errorf("processLineage() of an unknown process = %v, want [99]", got)
+	}
+}
+
+func TestFindSwayView(t *testing.T) {
+	tree := `{"id":1,"nodes":[{"id":2,"nodes":[{"id":10,"pid":20},{"id":11,"pid":77}],
+		"floating_nodes":[{"id":12,"pid":30}]}]}`
+	if got, err := findSwayView([]byte(tree), []int{40, 30, 20}); err != nil || got != 12 {
+		t.Errorf("findSwayView() = %d, %v; want the nearest ancestor's view 12", got, err)
+	}
+	if _, err := findSwayView([]byte(tree), []int{50}); err == nil {
+		t.Error("findSwayView() found a view for an unrelated process")
+	}
+	if _, err := findSwayView([]byte("not json"), []int{40}); err == nil {
+		t.Error("findSwayView() accepted malformed output")
+	}
+}
+
+func TestFindHyprlandWindow(t *testing.T) {
+	clients := `[{"address":"0x1","pid":77},{"address":"0x2","pid":20},{"address":"0x3","pid":30}]`
+	if got, err := findHyprlandWindow([]byte(clients), []int{40, 30, 20}); err != nil || got != "0x3" {
+		t.Errorf("findHyprlandWindow() = %q, %v; want the nearest ancestor's window 0x3", got, err)
+	}
+	if _, err := findHyprlandWindow([]byte(clients), []int{50}); err == nil {
+		t.Error("findHyprlandWindow() found a window for an unrelated process")
+	}
+}
+
+func TestCompositorInhibitorsGiveUpOnWedgedIPC(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"swaymsg", "hyprctl"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\nexec /bin/sleep 30\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+
+	for _, inh := range []inhibitor{&swayInhibitor{}, &hyprlandInhibitor{}} {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		start := time.Now()
+		err := inh.Activate(ctx)
+		cancel()
+		if err == nil {
+			t.Errorf("%s Activate() succeeded against a wedged compositor", inh.Name())
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("%s Activate() took %v, want it to end with its context", inh.Name(), elapsed)
+		}
+	}
+}
@@ -19,6 +19,12 @@ const (
 	// This prevents interference when the user is actively using the computer
 	IdleThreshold = 2 * time.Minute
 
+	// PresenceMinDwell is the least time activity simulation stays on or off
+	// once it changes, and how long the user must keep using the machine to
+	// turn it off, so that a user hovering around the idle threshold does not
+	// make it flap.
+	PresenceMinDwell = 60 * time.Second
+
 	// SyntheticIdleResetTolerance is the allowed drift when comparing observed idle
 	// time against the last synthetic jitter timestamp.
 	SyntheticIdleResetTolerance = 4 * time.Second
@@ -27,6 +33,16 @@ const (
 	// real input backend.
 	ActivityWarningInterval = 60 * time.Second
 
+	// CursorDriftEpsilon is how far, in pixels, the pointer may end a
+	// pattern from where it began before it is moved back.
+	CursorDriftEpsilon = 2.0
+
+	// DisplayEdgeMargin is how close, in pixels, a pattern may bring the
+	// pointer to the edges of its display. Reaching an edge can reveal a
+	// hidden Dock or menu bar, reaching a corner can trigger a hot corner,
+	// and crossing one moves the pointer to another display.
+	DisplayEdgeMargin = 8.0
+
 	// Round jitter path geometry
 	MouseJitterRadiusMin       = 18.0
 	MouseJitterRadiusMax       = 45.0
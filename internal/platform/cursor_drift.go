@@ -0,0 +1,75 @@
+package platform
+
+import (
+	"math"
+	"sync"
+)
+
+// cursorPos is an absolute pointer position in screen pixels.
+type cursorPos struct {
+	X, Y int
+}
+
+// cursorLocator reads and sets the absolute pointer position, where the
+// platform allows it.
+type cursorLocator interface {
+	cursorPosition() (cursorPos, error)
+	setCursorPosition(cursorPos) error
+}
+
+// maxCursorCorrection is the farthest, in pixels, a pointer is moved back
+// after a pattern. A pointer farther away than any pattern reaches was moved
+// by the user, and is left where they put it.
+const maxCursorCorrection = 2 * MaxMousePatternSize
+
+// cursorDrift moves the pointer back to where a pattern began when relative
+// moves left it elsewhere, as pointer acceleration can, and keeps a tally
+// so that a slow migration shows up in the log.
+type cursorDrift struct {
+	mu          sync.Mutex
+	corrections int
+	total       float64
+}
+
+// restore compares the pointer with origin after a pattern and moves it
+// back if it has drifted by more than CursorDriftEpsilon.
+func (d *cursorDrift) restore(loc cursorLocator, origin cursorPos) {
+	end, err := loc.cursorPosition()
+	if err != nil {
+		logger().Debug("cannot read pointer position after simulation", "err", err)
+		return
+	}
+	drift := math.Hypot(float64(end.X-origin.X), float64(end.Y-origin.Y))
+	if !d.needsCorrection(drift) {
+		return
+	}
+	if err := loc.setCursorPosition(origin); err != nil {
+		logger().Warn("moving pointer back after simulation failed", "drift_px", drift, "err", err)
+		return
+	}
+	d.record(drift)
+}
+
+// needsCorrection reports whether a pointer drift pixels from where the
+// pattern began should be moved back.
+func (d *cursorDrift) needsCorrection(drift float64) bool {
+	if drift <= CursorDriftEpsilon {
+		return false
+	}
+	if drift > maxCursorCorrection {
+		logger().Debug("pointer moved during simulation, leaving it", "drift_px", drift)
+		return false
+	}
+	return true
+}
+
+// record logs a corrected drift along with the running totals.
+func (d *cursorDrift) record(drift float64) {
+	d.mu.Lock()
+	d.corrections++
+	d.total += drift
+	corrections, total := d.corrections, d.total
+	d.mu.Unlock()
+
+	logger().Info("pointer drift corrected", "drift_px", math.Round(drift*10)/10, "corrections", corrections, "total_drift_px", math.Round(total*10)/10)
+}
@@ -0,0 +1,48 @@
+package platform
+
+import "testing"
+
+// fakeCursor is a cursorLocator over a position held in memory.
+type fakeCursor struct {
+	pos  cursorPos
+	sets int
+}
+
+func (c *fakeCursor) cursorPosition() (cursorPos, error) { return c.pos, nil }
+
+func (c *fakeCursor) setCursorPosition(p cursorPos) error {
+	c.pos = p
+	c.sets++
+	return nil
+}
+
+func TestCursorDriftRestore(t *testing.T) {
+	origin := cursorPos{X: 500, Y: 500}
+	tests := []struct {
+		name    string
+		end     cursorPos
+		restore bool
+	}{
+		{"on origin", origin, false},
+		{"within epsilon", cursorPos{X: 501, Y: 501}, false},
+		{"drifted", cursorPos{X: 507, Y: 496}, true},
+		{"moved by the user", cursorPos{X: 1500, Y: 200}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d cursorDrift
+			c := &fakeCursor{pos: tt.end}
+			d.restore(c, origin)
+
+			if restored := c.sets > 0; restored != tt.restore {
+				t.Fatalf("restored = %v, want %v", restored, tt.restore)
+			}
+			if tt.restore && c.pos != origin {
+				t.Errorf("pointer at %+v, want %+v", c.pos, origin)
+			}
+			if want := map[bool]int{true: 1}[tt.restore]; d.corrections != want {
+				t.Errorf("corrections = %d, want %d", d.corrections, want)
+			}
+		})
+	}
+}
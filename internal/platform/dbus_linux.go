@@ -0,0 +1,115 @@
+//go:build linux
+
+package platform
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// dbusCallTimeout bounds a method call to a desktop service, which may be
+// slow to answer while the session starts or hangs.
+const dbusCallTimeout = 5 * time.Second
+
+// connectSessionBus opens a private connection to the session bus. The
+// caller must close it.
+func connectSessionBus() (*dbus.Conn, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("connect to session bus: %w", err)
+	}
+	return conn, nil
+}
+
+// connectSystemBus opens a private connection to the system bus. The caller
+// must close it.
+func connectSystemBus() (*dbus.Conn, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("connect to system bus: %w", err)
+	}
+	return conn, nil
+}
+
+// sessionBusAvailable reports whether the session bus can be reached.
+func sessionBusAvailable() bool {
+	conn, err := connectSessionBus()
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// watchNameOwner calls lost once when the owner of name on conn's bus goes
+// away, as when the service behind it restarts, or when conn is closed,
+// until the returned function is called.
+func watchNameOwner(conn *dbus.Conn, name string, lost func()) (func(), error) {
+	match := []dbus.MatchOption{
+		dbus.WithMatchSender("org.freedesktop.DBus"),
+		dbus.WithMatchInterface("org.freedesktop.DBus"),
+		dbus.WithMatchMember("NameOwnerChanged"),
+		dbus.WithMatchArg(0, name),
+	}
+	if err := conn.AddMatchSignal(match...); err != nil {
+		return nil, fmt.Errorf("subscribe to NameOwnerChanged for %s: %w", name, err)
+	}
+	signals := make(chan *dbus.Signal, 4)
+	conn.Signal(signals)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case sig, ok := <-signals:
+				if !ok || nameOwnerLost(sig, name) {
+					lost()
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			conn.RemoveSignal(signals)
+			_ = conn.RemoveMatchSignal(match...)
+		})
+	}, nil
+}
+
+// nameOwnerLost reports whether sig is a NameOwnerChanged signal saying
+// that the owner of name went away or was replaced.
+func nameOwnerLost(sig *dbus.Signal, name string) bool {
+	if sig == nil || sig.Name != "org.freedesktop.DBus.NameOwnerChanged" || len(sig.Body) != 3 {
+		return false
+	}
+	changed, _ := sig.Body[0].(string)
+	oldOwner, _ := sig.Body[1].(string)
+	newOwner, _ := sig.Body[2].(string)
+	return changed == name && oldOwner != "" && oldOwner != newOwner
+}
+
+// simulateUserActivity tells the screensavers on the session bus that the
+// user is active, which resets their idle timers on X11 and Wayland alike.
+func simulateUserActivity() {
+	conn, err := connectSessionBus()
+	if err != nil {
+		logger().Debug("SimulateUserActivity skipped", "err", err)
+		return
+	}
+	defer conn.Close()
+	for _, s := range []struct{ dest, path string }{
+		{"org.freedesktop.ScreenSaver", "/org/freedesktop/ScreenSaver"},
+		{"org.gnome.ScreenSaver", "/org/gnome/ScreenSaver"},
+	} {
+		conn.Object(s.dest, dbus.ObjectPath(s.path)).Call(s.dest+".SimulateUserActivity", dbus.FlagNoReplyExpected)
+	}
+}
@@ -0,0 +1,65 @@
+package platform
+
+import (
+	"os/exec"
+	"runtime"
+)
+
+// DependencyInfo contains information about a missing dependency and how to install it.
+// This struct is used to provide user-friendly installation guidance.
+type DependencyInfo struct {
+	Name        string `json:"name"`                  // Name of the dependency (e.g., "ydotool", "xdotool")
+	WhyNeeded   string `json:"why_needed"`            // Explanation of why this dependency is needed
+	InstallCmd  string `json:"install_cmd,omitempty"` // Distro-specific installation command
+	Optional    bool   `json:"optional"`              // Whether the dependency is optional (all dependencies are currently optional)
+	Available   bool   `json:"available"`             // Whether the package exists in default repositories
+	Alternative string `json:"alternative,omitempty"` // Alternative installation methods or workarounds
+}
+
+// Diagnostics describes how keep-alive can prevent sleep and simulate
+// activity on this machine. It is gathered without starting a session.
+type Diagnostics struct {
+	OS                 string `json:"os"`
+	Arch               string `json:"arch"`
+	DesktopEnvironment string `json:"desktop_environment,omitempty"`
+	DisplayServer      string `json:"display_server,omitempty"`
+	// Inhibitors lists the sleep-prevention methods that would be tried, in
+	// priority order.
+	Inhibitors []string `json:"inhibitors"`
+	// SleepPrevention reports whether at least one inhibitor has the tools it
+	// needs.
+	SleepPrevention    bool                     `json:"sleep_prevention"`
+	Tools              map[string]bool          `json:"tools"`
+	ActivitySimulation ActivitySimulationStatus `json:"activity_simulation"`
+	// SimulationMethods lists the input backends --active would try, in
+	// priority order.
+	SimulationMethods   []string         `json:"simulation_methods"`
+	MissingDependencies []DependencyInfo `json:"missing_dependencies"`
+	// RemoteSession is set in a Remote Desktop session on Windows; see
+	// RemoteSessionNote.
+	RemoteSession bool `json:"remote_session"`
+	// ModernStandby is set on Windows machines with modern standby (S0 low
+	// power idle), where some ways into standby cannot be blocked.
+	ModernStandby bool `json:"modern_standby"`
+	// Sandbox names the Flatpak or Snap sandbox keep-alive runs in on
+	// Linux, where the desktop portal stands in for the desktop inhibitors.
+	Sandbox string `json:"sandbox,omitempty"`
+}
+
+// newDiagnostics returns Diagnostics for the running OS with the given tools
+// looked up on PATH.
+func newDiagnostics(tools ...string) Diagnostics {
+	d := Diagnostics{
+		OS:                  runtime.GOOS,
+		Arch:                runtime.GOARCH,
+		Inhibitors:          []string{},
+		SimulationMethods:   []string{},
+		Tools:               make(map[string]bool, len(tools)),
+		MissingDependencies: []DependencyInfo{},
+	}
+	for _, tool := range tools {
+		_, err := exec.LookPath(tool)
+		d.Tools[tool] = err == nil
+	}
+	return d
+}
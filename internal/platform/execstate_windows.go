@@ -0,0 +1,45 @@
+//go:build windows
+
+package platform
+
+import (
+	"runtime"
+	"sync"
+)
+
+// SetThreadExecutionState applies to the thread that calls it, and its
+// ES_CONTINUOUS state lasts until that thread resets it or exits. Goroutines
+// move between threads, so a refresh or reset made from whichever goroutine
+// happens to run it could land on another thread, leaving the state set on
+// the first one unreset or letting it end with a thread Go retires. Every
+// call is therefore made from one goroutine locked to its own thread, which
+// lives as long as the process.
+var (
+	stateThreadOnce  sync.Once
+	stateThreadCalls chan stateThreadCall
+)
+
+type stateThreadCall struct {
+	fn   func() error
+	done chan error
+}
+
+// onStateThread runs fn on the execution-state thread and returns its error.
+func onStateThread(fn func() error) error {
+	stateThreadOnce.Do(func() {
+		stateThreadCalls = make(chan stateThreadCall)
+		go runStateThread(stateThreadCalls)
+	})
+	call := stateThreadCall{fn: fn, done: make(chan error, 1)}
+	stateThreadCalls <- call
+	return <-call.done
+}
+
+// runStateThread runs calls on a thread of its own. It never unlocks the
+// thread, so no other goroutine is scheduled on it.
+func runStateThread(calls <-chan stateThreadCall) {
+	runtime.LockOSThread()
+	for call := range calls {
+		call.done <- call.fn()
+	}
+}
@@ -0,0 +1,37 @@
+//go:build windows
+
+package platform
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+var procGetCurrentThreadId = kernel32.NewProc("GetCurrentThreadId")
+
+func TestOnStateThreadUsesOneThread(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		threads = map[uintptr]bool{}
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runtime.Gosched()
+			_ = onStateThread(func() error {
+				id, _, _ := procGetCurrentThreadId.Call()
+				mu.Lock()
+				threads[id] = true
+				mu.Unlock()
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	if len(threads) != 1 {
+		t.Fatalf("execution-state calls ran on %d threads, want 1", len(threads))
+	}
+}
@@ -0,0 +1,96 @@
+//go:build darwin
+
+package platform
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// focusAssertionsPath is where macOS 12 and later record the Focus modes
+// turned on by the user, relative to the home directory.
+const focusAssertionsPath = "Library/DoNotDisturb/DB/Assertions.json"
+
+// screenSharingProcesses run only while the screen is being shared or
+// viewed: screensharingd serves Screen Sharing and Apple Remote Desktop
+// viewers, and CptHost is Zoom's screen-share helper.
+var screenSharingProcesses = []string{"screensharingd", "CptHost"}
+
+// focusActive reports whether a Focus mode, such as Do Not Disturb, has been
+// turned on. Focus modes started by a schedule or an automation leave no
+// assertion and are not seen.
+func focusActive() (bool, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return false, err
+	}
+	data, err := os.ReadFile(filepath.Join(home, focusAssertionsPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return parseFocusAssertions(data)
+}
+
+// parseFocusAssertions reports whether the Focus assertion store holds an
+// assertion, which it does while a Focus mode is on.
+func parseFocusAssertions(data []byte) (bool, error) {
+	var store struct {
+		Data []struct {
+			StoreAssertionRecords []json.RawMessage `json:"storeAssertionRecords"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &store); err != nil {
+		return false, fmt.Errorf("malformed Focus assertions: %v", err)
+	}
+	for _, d := range store.Data {
+		if len(d.StoreAssertionRecords) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// screenShared reports whether the screen is being shared, by the processes
+// that run only while it is.
+func screenShared() (bool, error) {
+	for _, name := range screenSharingProcesses {
+		pids, err := FindProcesses(name)
+		if err != nil {
+			return false, err
+		}
+		if len(pids) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// simulationHold returns why simulated input should be held off right now,
+// or "" when nothing stands in its way. Sleep is prevented regardless. A
+// check that fails counts as nothing standing in the way.
+func simulationHold() string {
+	if locked, err := screenLocked(); err != nil {
+		logger().Debug("screen lock check failed", "err", err)
+	} else if locked {
+		// Events posted at the lock screen keep no chat app active and
+		// look like someone trying the lock.
+		return "the screen is locked"
+	}
+	if shared, err := screenShared(); err != nil {
+		logger().Debug("screen sharing check failed", "err", err)
+	} else if shared {
+		// A wandering pointer shows on every viewer's screen.
+		return "the screen is being shared"
+	}
+	if focus, err := focusActive(); err != nil {
+		logger().Debug("Focus check failed", "err", err)
+	} else if focus {
+		return "a Focus mode is on"
+	}
+	return ""
+}
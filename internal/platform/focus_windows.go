@@ -0,0 +1,104 @@
+//go:build windows
+
+package platform
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// The QUERY_USER_NOTIFICATION_STATE values SHQueryUserNotificationState
+// returns while notifications would disturb the user.
+const (
+	qunsBusy                 = 2
+	qunsRunningD3DFullScreen = 3
+	qunsPresentationMode     = 4
+	qunsQuietTime            = 6
+)
+
+// wnfQuietHoursProfile is the WNF state name of Focus Assist's active
+// profile, WNF_SHEL_QUIETHOURS_ACTIVE_PROFILE_CHANGED. Windows publishes no
+// API for Focus Assist; this state is what the Action Center itself reads.
+const wnfQuietHoursProfile uint64 = 0x0d83063ea3bf1c75
+
+var (
+	shell32                          = syscall.NewLazyDLL("shell32.dll")
+	procSHQueryUserNotificationState = shell32.NewProc("SHQueryUserNotificationState")
+	ntdll                            = syscall.NewLazyDLL("ntdll.dll")
+	procNtQueryWnfStateData          = ntdll.NewProc("NtQueryWnfStateData")
+)
+
+// notificationState returns the user's notification state, which tells
+// whether a full-screen application or a presentation is running.
+func notificationState() (uint32, error) {
+	if err := procSHQueryUserNotificationState.Find(); err != nil {
+		return 0, err
+	}
+	var state uint32
+	r1, _, _ := procSHQueryUserNotificationState.Call(uintptr(unsafe.Pointer(&state)))
+	if r1 != 0 {
+		return 0, fmt.Errorf("SHQueryUserNotificationState: HRESULT %#x", uint32(r1))
+	}
+	return state, nil
+}
+
+// notificationStateHold returns why state should hold off simulated input,
+// or "" when it should not.
+func notificationStateHold(state uint32) string {
+	switch state {
+	case qunsPresentationMode:
+		return "presentation mode is on"
+	case qunsBusy, qunsRunningD3DFullScreen:
+		return "a full-screen application is running"
+	case qunsQuietTime:
+		return "quiet time is on"
+	}
+	return ""
+}
+
+// focusAssistActive reports whether Focus Assist is on, in its priority
+// only or alarms only profile.
+func focusAssistActive() (bool, error) {
+	if err := procNtQueryWnfStateData.Find(); err != nil {
+		return false, err
+	}
+	name := wnfQuietHoursProfile
+	var changeStamp, profile uint32
+	size := uint32(unsafe.Sizeof(profile))
+	r1, _, _ := procNtQueryWnfStateData.Call(
+		uintptr(unsafe.Pointer(&name)),
+		0, 0,
+		uintptr(unsafe.Pointer(&changeStamp)),
+		uintptr(unsafe.Pointer(&profile)),
+		uintptr(unsafe.Pointer(&size)),
+	)
+	if r1 != 0 {
+		return false, fmt.Errorf("NtQueryWnfStateData: NTSTATUS %#x", uint32(r1))
+	}
+	return profile != 0, nil
+}
+
+// simulationHold returns why simulated input should be held off right now,
+// or "" when nothing stands in its way. Sleep is prevented regardless. A
+// check that fails counts as nothing standing in the way.
+func simulationHold() string {
+	if locked, err := ScreenLocked(); err != nil {
+		logger().Debug("workstation lock check failed", "err", err)
+	} else if locked {
+		// Input sent to the lock screen keeps no chat app active.
+		return "the workstation is locked"
+	}
+	if state, err := notificationState(); err != nil {
+		logger().Debug("notification state check failed", "err", err)
+	} else if reason := notificationStateHold(state); reason != "" {
+		// A wandering pointer shows on the audience's screen.
+		return reason
+	}
+	if focus, err := focusAssistActive(); err != nil {
+		logger().Debug("Focus Assist check failed", "err", err)
+	} else if focus {
+		return "Focus Assist is on"
+	}
+	return ""
+}
@@ -0,0 +1,24 @@
+//go:build windows
+
+package platform
+
+import "testing"
+
+func TestNotificationStateHold(t *testing.T) {
+	for _, tt := range []struct {
+		state uint32
+		held  bool
+	}{
+		{1, false}, // QUNS_NOT_PRESENT
+		{qunsBusy, true},
+		{qunsRunningD3DFullScreen, true},
+		{qunsPresentationMode, true},
+		{5, false}, // QUNS_ACCEPTS_NOTIFICATIONS
+		{qunsQuietTime, true},
+		{7, false}, // QUNS_APP
+	} {
+		if got := notificationStateHold(tt.state) != ""; got != tt.held {
+			t.Errorf("notificationStateHold(%d) held = %v, want %v", tt.state, got, tt.held)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+//go:build linux
+
+package platform
+
+// GetAllowHibernateStatus reports whether hibernation can be let through
+// while sleep is held off, which needs logind.
+func GetAllowHibernateStatus() AllowHibernateStatus {
+	if !logindAvailable() {
+		return AllowHibernateStatus{
+			Message: "logind is not running, so the desktop inhibitors in use decide whether the system may hibernate.",
+		}
+	}
+	return AllowHibernateStatus{
+		Available: true,
+		Message:   "Hibernation is allowed; logind's sleep lock is not taken, so an explicit suspend is allowed as well.",
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !windows
+
+package platform
+
+// GetAllowHibernateStatus reports that letting the system hibernate while
+// sleep is held off is not supported here.
+func GetAllowHibernateStatus() AllowHibernateStatus {
+	return AllowHibernateStatus{
+		Message: "--allow-hibernate is only supported on Linux; caffeinate holds off hibernation along with sleep.",
+	}
+}
@@ -0,0 +1,52 @@
+//go:build windows
+
+package platform
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// Power setting GUIDs for "Hibernate after" and "Allow hybrid sleep" in the
+// sleep subgroup.
+const (
+	powerSettingHibernateIdle = "9d7815a6-7ee4-497e-8888-515a05f02364"
+	powerSettingHybridSleep   = "94ac6d29-73ce-41a6-809f-6363ba21b47e"
+)
+
+// GetAllowHibernateStatus reports that Windows cannot be let hibernate
+// while sleep is held off: ES_SYSTEM_REQUIRED resets the idle timer that
+// both count from.
+func GetAllowHibernateStatus() AllowHibernateStatus {
+	return AllowHibernateStatus{
+		Message: "--allow-hibernate has no effect on Windows: the execution state that holds off sleep holds off hibernation too. Windows still hibernates on a critically low battery.",
+	}
+}
+
+// powercfgValues reads the current AC and DC values of a sleep setting.
+func powercfgValues(setting string) (ac, dc uint64, err error) {
+	out, err := exec.Command("powercfg", "/query", "SCHEME_CURRENT", powerSubgroupSleep, setting).Output()
+	if err != nil {
+		return 0, 0, fmt.Errorf("powercfg: %w", err)
+	}
+	return parsePowercfgIndices(string(out))
+}
+
+// logHibernationSettings records the power plan's hibernation timeout and
+// hybrid sleep setting, both held off by ES_SYSTEM_REQUIRED, so that a
+// machine that hibernates anyway can be diagnosed from the log.
+func logHibernationSettings() {
+	hibAC, hibDC, err := powercfgValues(powerSettingHibernateIdle)
+	if err != nil {
+		logger().Debug("cannot read hibernation timeout", "err", err)
+		return
+	}
+	hybridAC, hybridDC, err := powercfgValues(powerSettingHybridSleep)
+	if err != nil {
+		logger().Debug("cannot read hybrid sleep setting", "err", err)
+		return
+	}
+	logger().Info("hibernation held off with sleep",
+		"hibernate_after_ac_s", hibAC, "hibernate_after_dc_s", hibDC,
+		"hybrid_sleep_ac", hybridAC != 0, "hybrid_sleep_dc", hybridDC != 0)
+}
@@ -0,0 +1,11 @@
+package platform
+
+import "time"
+
+// IdleSource is the idle time as read by one detection method. IdleSources
+// lists them so that their results can be compared.
+type IdleSource struct {
+	Name string
+	Idle time.Duration
+	Err  error
+}
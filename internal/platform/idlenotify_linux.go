@@ -0,0 +1,162 @@
+//go:build linux
+
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Requests and events of the ext-idle-notify-v1 protocol, by opcode.
+const (
+	extIdleNotifierGetInputIdleNotification = 2 // since version 2
+
+	extIdleNotificationEventIdled   = 0
+	extIdleNotificationEventResumed = 1
+)
+
+// waylandIdleNotifyTimeout is the idle timeout the compositor is asked to
+// report, and so the resolution of the idle time read from it.
+const waylandIdleNotifyTimeout = time.Second
+
+// waylandIdleMonitor follows the compositor's idle notifications on a
+// connection of its own, since ext-idle-notify reports when the user goes
+// idle and comes back rather than answering how long they have been idle.
+type waylandIdleMonitor struct {
+	conn         *waylandConn
+	notification uint32
+
+	mu      sync.Mutex
+	idle    bool
+	idledAt time.Time
+	err     error // set once the connection is lost
+}
+
+// newWaylandIdleMonitor asks the compositor on c to notify it when the user
+// has not touched the keyboard or pointer for waylandIdleNotifyTimeout. It
+// needs version 2 of ext_idle_notifier_v1: the input idle notifications it
+// added ignore idle inhibitors, while those of version 1 never fire under
+// keep-alive's own.
+func newWaylandIdleMonitor(c *waylandConn) (*waylandIdleMonitor, error) {
+	globals, err := c.globals()
+	if err != nil {
+		return nil, err
+	}
+	notifier, ok := globals["ext_idle_notifier_v1"]
+	if !ok {
+		return nil, errors.New("the compositor does not offer ext_idle_notifier_v1")
+	}
+	if notifier.version < 2 {
+		return nil, errors.New("the compositor's ext_idle_notifier_v1 predates input idle notifications")
+	}
+	seat, ok := globals["wl_seat"]
+	if !ok {
+		return nil, errors.New("the compositor does not offer wl_seat")
+	}
+	seatID, err := c.bind(seat, "wl_seat", 1)
+	if err != nil {
+		return nil, err
+	}
+	notifierID, err := c.bind(notifier, "ext_idle_notifier_v1", 2)
+	if err != nil {
+		return nil, err
+	}
+	m := &waylandIdleMonitor{conn: c, notification: c.newID()}
+	timeout := uint32(waylandIdleNotifyTimeout.Milliseconds())
+	if err := c.send(notifierID, extIdleNotifierGetInputIdleNotification, m.notification, timeout, seatID); err != nil {
+		return nil, err
+	}
+	if err := c.roundtrip(m.handle); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// handle records an idled or resumed event.
+func (m *waylandIdleMonitor) handle(ev waylandEvent) error {
+	if ev.sender != m.notification {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch ev.opcode {
+	case extIdleNotificationEventIdled:
+		m.idle, m.idledAt = true, time.Now()
+	case extIdleNotificationEventResumed:
+		m.idle = false
+	}
+	return nil
+}
+
+// dispatch reads events until the connection is lost.
+func (m *waylandIdleMonitor) dispatch() {
+	for {
+		ev, err := m.conn.read()
+		if err == nil && ev.sender == wlDisplayID && ev.opcode == wlDisplayEventError {
+			err = displayError(ev)
+		}
+		if err != nil {
+			m.mu.Lock()
+			m.err = fmt.Errorf("Wayland idle notifications stopped: %w", err)
+			m.mu.Unlock()
+			m.conn.close()
+			return
+		}
+		_ = m.handle(ev)
+	}
+}
+
+// idleTime returns how long the user has been idle, to within
+// waylandIdleNotifyTimeout. Idle time from before the monitor started is
+// not known to it, so that is not counted.
+func (m *waylandIdleMonitor) idleTime() (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return 0, m.err
+	}
+	if !m.idle {
+		return 0, nil
+	}
+	return time.Since(m.idledAt) + waylandIdleNotifyTimeout, nil
+}
+
+// waylandIdle is the process's idle monitor, started by the first read and
+// started again after the compositor goes away.
+var waylandIdle struct {
+	mu      sync.Mutex
+	monitor *waylandIdleMonitor
+}
+
+// waylandIdleTime reads the idle time from the compositor's ext-idle-notify
+// notifications, for compositors such as sway, Hyprland and River that run
+// no D-Bus idle monitor.
+func waylandIdleTime() (time.Duration, error) {
+	waylandIdle.mu.Lock()
+	defer waylandIdle.mu.Unlock()
+	if m := waylandIdle.monitor; m != nil {
+		idle, err := m.idleTime()
+		if err == nil {
+			return idle, nil
+		}
+		logger().Debug("restarting Wayland idle monitor", "err", err)
+		waylandIdle.monitor = nil
+	}
+
+	c, err := dialWayland()
+	if err != nil {
+		return 0, err
+	}
+	_ = c.conn.SetDeadline(time.Now().Add(waylandSetupTimeout))
+	m, err := newWaylandIdleMonitor(c)
+	if err != nil {
+		c.close()
+		return 0, err
+	}
+	_ = c.conn.SetDeadline(time.Time{})
+	waylandIdle.monitor = m
+	go m.dispatch()
+	return m.idleTime()
+}
@@ -0,0 +1,79 @@
+//go:build linux
+
+package platform
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWaylandIdleMonitor(t *testing.T) {
+	client, server := newFakeCompositorVersions(t, map[string]uint32{"ext_idle_notifier_v1": 2}, "wl_seat", "ext_idle_notifier_v1")
+	client.conn.SetDeadline(time.Now().Add(5 * time.Second))
+	m, err := newWaylandIdleMonitor(client)
+	if err != nil {
+		t.Fatalf("newWaylandIdleMonitor() error = %v", err)
+	}
+	client.conn.SetDeadline(time.Time{})
+	go m.dispatch()
+
+	if idle, err := m.idleTime(); err != nil || idle != 0 {
+		t.Fatalf("idleTime() before any event = %v, %v; want 0", idle, err)
+	}
+
+	// The registry is 2 and the first callback 3, so wl_seat is bound as 4,
+	// the notifier as 5 and the notification is 6.
+	const notification = 6
+	waitIdle := func(want func(time.Duration) bool) time.Duration {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			idle, err := m.idleTime()
+			if err != nil {
+				t.Fatalf("idleTime() error = %v", err)
+			}
+			if want(idle) {
+				return idle
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("idleTime() = %v, never reached the expected value", idle)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	server.conn.send(notification, extIdleNotificationEventIdled)
+	waitIdle(func(d time.Duration) bool { return d >= waylandIdleNotifyTimeout })
+	server.conn.send(notification, extIdleNotificationEventResumed)
+	waitIdle(func(d time.Duration) bool { return d == 0 })
+
+	server.conn.close()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := m.idleTime(); err != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("idleTime() kept working after the compositor went away")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var requested bool
+	for req := range server.requests {
+		if req == [2]uint32{5, extIdleNotifierGetInputIdleNotification} {
+			requested = true
+		}
+	}
+	if !requested {
+		t.Fatalf("no get_input_idle_notification request reached the compositor")
+	}
+}
+
+func TestWaylandIdleMonitorNeedsVersion2(t *testing.T) {
+	client, _ := newFakeCompositor(t, "wl_seat", "ext_idle_notifier_v1")
+	client.conn.SetDeadline(time.Now().Add(5 * time.Second))
+	defer client.close()
+	if _, err := newWaylandIdleMonitor(client); err == nil {
+		t.Fatalf("newWaylandIdleMonitor() accepted ext_idle_notifier_v1 version 1")
+	}
+}
@@ -0,0 +1,21 @@
+package platform
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+var currentLogger atomic.Pointer[slog.Logger]
+
+// SetLogger sets the logger used by the package. A nil logger restores the
+// default, slog.Default.
+func SetLogger(l *slog.Logger) {
+	currentLogger.Store(l)
+}
+
+func logger() *slog.Logger {
+	if l := currentLogger.Load(); l != nil {
+		return l
+	}
+	return slog.Default()
+}
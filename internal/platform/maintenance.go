@@ -0,0 +1,31 @@
+package platform
+
+import (
+	"errors"
+	"time"
+)
+
+// MaintenanceTarget is the clock value that ends a session at the next
+// maintenance window.
+const MaintenanceTarget = "maintenance"
+
+// ErrNoMaintenanceWindow is returned when the system has no maintenance
+// schedule that can be read.
+var ErrNoMaintenanceWindow = errors.New("no scheduled maintenance window found")
+
+// MaintenanceWindow is the next time the system plans to run maintenance or
+// install updates, and what schedules it.
+type MaintenanceWindow struct {
+	Start  time.Time
+	Source string
+}
+
+// nextDaily returns the first time after now at hour:minute in now's
+// location.
+func nextDaily(now time.Time, hour, minute int) time.Time {
+	t := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if !t.After(now) {
+		t = time.Date(now.Year(), now.Month(), now.Day()+1, hour, minute, 0, 0, now.Location())
+	}
+	return t
+}
@@ -0,0 +1,61 @@
+//go:build linux
+
+package platform
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// maintenanceTimers are systemd timers that install updates unattended.
+var maintenanceTimers = []string{
+	"apt-daily-upgrade.timer",
+	"dnf-automatic.timer",
+	"dnf-automatic-install.timer",
+	"dnf5-automatic.timer",
+}
+
+// readTimerNext is replaced in tests.
+var readTimerNext = func(unit string) (string, error) {
+	out, err := exec.Command("systemctl", "show", "--property=NextElapseUSecRealtime", "--value", unit).Output()
+	return string(out), err
+}
+
+// NextMaintenanceWindow returns the next run of the systemd timers that
+// install updates, such as apt-daily-upgrade.timer.
+func NextMaintenanceWindow(now time.Time) (MaintenanceWindow, error) {
+	var next MaintenanceWindow
+	for _, unit := range maintenanceTimers {
+		out, err := readTimerNext(unit)
+		if err != nil {
+			continue
+		}
+		t, ok := parseSystemdTimestamp(out, now.Location())
+		if !ok || !t.After(now) {
+			continue
+		}
+		if next.Start.IsZero() || t.Before(next.Start) {
+			next = MaintenanceWindow{Start: t, Source: unit}
+		}
+	}
+	if next.Start.IsZero() {
+		return next, fmt.Errorf("%w: none of %s is scheduled", ErrNoMaintenanceWindow, strings.Join(maintenanceTimers, ", "))
+	}
+	return next, nil
+}
+
+// parseSystemdTimestamp parses a timestamp as systemctl prints it, such as
+// "Fri 2025-01-10 06:24:49 CET". Inactive timers print nothing or "n/a".
+func parseSystemdTimestamp(s string, loc *time.Location) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "n/a" {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation("Mon 2006-01-02 15:04:05 MST", s, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
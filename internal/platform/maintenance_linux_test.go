@@ -0,0 +1,45 @@
+//go:build linux
+
+package platform
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNextMaintenanceWindowPicksEarliestTimer(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, loc)
+
+	orig := readTimerNext
+	t.Cleanup(func() { readTimerNext = orig })
+	readTimerNext = func(unit string) (string, error) {
+		switch unit {
+		case "apt-daily-upgrade.timer":
+			return "Sat 2025-01-11 06:24:49 CET\n", nil
+		case "dnf-automatic.timer":
+			return "Fri 2025-01-10 18:00:00 CET\n", nil
+		case "dnf5-automatic.timer":
+			// Already past.
+			return "Fri 2025-01-10 06:00:00 CET\n", nil
+		case "dnf-automatic-install.timer":
+			return "n/a\n", nil
+		}
+		return "", errors.New("unit not found")
+	}
+
+	w, err := NextMaintenanceWindow(now)
+	if err != nil {
+		t.Fatalf("NextMaintenanceWindow() error: %v", err)
+	}
+	want := time.Date(2025, 1, 10, 18, 0, 0, 0, loc)
+	if !w.Start.Equal(want) || w.Source != "dnf-automatic.timer" {
+		t.Errorf("NextMaintenanceWindow() = %v from %s, want %v from dnf-automatic.timer", w.Start, w.Source, want)
+	}
+
+	readTimerNext = func(string) (string, error) { return "\n", nil }
+	if _, err := NextMaintenanceWindow(now); !errors.Is(err, ErrNoMaintenanceWindow) {
+		t.Errorf("NextMaintenanceWindow() without timers error = %v, want ErrNoMaintenanceWindow", err)
+	}
+}
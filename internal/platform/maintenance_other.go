@@ -0,0 +1,14 @@
+//go:build !linux && !windows
+
+package platform
+
+import (
+	"fmt"
+	"time"
+)
+
+// NextMaintenanceWindow is not available: macOS and other systems do not
+// publish when they will install updates.
+func NextMaintenanceWindow(now time.Time) (MaintenanceWindow, error) {
+	return MaintenanceWindow{}, fmt.Errorf("%w on this system", ErrNoMaintenanceWindow)
+}
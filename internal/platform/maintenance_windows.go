@@ -0,0 +1,78 @@
+//go:build windows
+
+package platform
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	activeHoursKey = `HKLM\SOFTWARE\Microsoft\WindowsUpdate\UX\Settings`
+	maintenanceKey = `HKLM\SOFTWARE\Microsoft\Windows NT\CurrentVersion\Schedule\Maintenance`
+)
+
+var (
+	regDwordRe    = regexp.MustCompile(`REG_DWORD\s+0x([0-9a-fA-F]+)`)
+	regBoundaryRe = regexp.MustCompile(`REG_SZ\s+\d{4}-\d{2}-\d{2}T(\d{2}):(\d{2})`)
+)
+
+// readRegValue is replaced in tests.
+var readRegValue = func(key, name string) (string, error) {
+	out, err := exec.Command("reg", "query", key, "/v", name).Output()
+	return string(out), err
+}
+
+// NextMaintenanceWindow returns the earlier of the end of Windows Update
+// active hours, after which updates may restart the machine, and the daily
+// Automatic Maintenance start time. Either is skipped when its registry value
+// is missing, and ErrNoMaintenanceWindow is returned when both are.
+func NextMaintenanceWindow(now time.Time) (MaintenanceWindow, error) {
+	var next MaintenanceWindow
+	if out, err := readRegValue(activeHoursKey, "ActiveHoursEnd"); err == nil {
+		if h, ok := parseRegDword(out); ok && h < 24 {
+			next = MaintenanceWindow{Start: nextDaily(now, int(h), 0), Source: "Windows Update active hours"}
+		}
+	}
+
+	if out, err := readRegValue(maintenanceKey, "Activation Boundary"); err == nil {
+		if hour, minute, ok := parseActivationBoundary(out); ok {
+			if t := nextDaily(now, hour, minute); next.Start.IsZero() || t.Before(next.Start) {
+				next = MaintenanceWindow{Start: t, Source: "Windows Automatic Maintenance"}
+			}
+		}
+	}
+	if next.Start.IsZero() {
+		return next, fmt.Errorf("%w: neither active hours nor Automatic Maintenance is configured", ErrNoMaintenanceWindow)
+	}
+	return next, nil
+}
+
+// parseRegDword extracts a DWORD value from reg query output.
+func parseRegDword(out string) (uint64, bool) {
+	m := regDwordRe.FindStringSubmatch(out)
+	if m == nil {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(m[1], 16, 32)
+	return v, err == nil
+}
+
+// parseActivationBoundary extracts the daily start time from the Automatic
+// Maintenance "Activation Boundary", such as "2000-01-01T02:00:00". Only the
+// time of day is used.
+func parseActivationBoundary(out string) (hour, minute int, ok bool) {
+	m := regBoundaryRe.FindStringSubmatch(out)
+	if m == nil {
+		return 0, 0, false
+	}
+	hour, _ = strconv.Atoi(m[1])
+	minute, _ = strconv.Atoi(m[2])
+	if hour > 23 || minute > 59 {
+		return 0, 0, false
+	}
+	return hour, minute, true
+}
@@ -0,0 +1,48 @@
+//go:build windows
+
+package platform
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNextMaintenanceWindowWindows(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	orig := readRegValue
+	t.Cleanup(func() { readRegValue = orig })
+	values := map[string]string{
+		"ActiveHoursEnd":      "\r\nHKEY_LOCAL_MACHINE\\SOFTWARE\\Microsoft\\WindowsUpdate\\UX\\Settings\r\n    ActiveHoursEnd    REG_DWORD    0x14\r\n",
+		"Activation Boundary": "\r\nHKEY_LOCAL_MACHINE\\...\\Maintenance\r\n    Activation Boundary    REG_SZ    2000-01-01T02:00:00\r\n",
+	}
+	readRegValue = func(key, name string) (string, error) {
+		if v, ok := values[name]; ok {
+			return v, nil
+		}
+		return "", errors.New("not found")
+	}
+
+	// Active hours end at 20:00 today; maintenance runs at 02:00 tomorrow.
+	w, err := NextMaintenanceWindow(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2025, 1, 10, 20, 0, 0, 0, time.UTC); !w.Start.Equal(want) {
+		t.Errorf("Start = %v, want %v", w.Start, want)
+	}
+
+	// Without active hours only Automatic Maintenance applies.
+	delete(values, "ActiveHoursEnd")
+	w, _ = NextMaintenanceWindow(time.Date(2025, 1, 10, 18, 0, 0, 0, time.UTC))
+	if want := time.Date(2025, 1, 11, 2, 0, 0, 0, time.UTC); !w.Start.Equal(want) || w.Source != "Windows Automatic Maintenance" {
+		t.Errorf("NextMaintenanceWindow() = %v from %s, want %v from maintenance", w.Start, w.Source, want)
+	}
+
+	// Without either value no deadline is made up.
+	delete(values, "Activation Boundary")
+	if w, err := NextMaintenanceWindow(now); !errors.Is(err, ErrNoMaintenanceWindow) {
+		t.Errorf("NextMaintenanceWindow() without registry values = %v, %v; want ErrNoMaintenanceWindow", w.Start, err)
+	}
+}
@@ -0,0 +1,6 @@
+package platform
+
+// ModernStandbyNote says what a session holds on a Windows machine with
+// modern standby, whose sleep is a low-power state the system enters with
+// the display off rather than a suspend.
+const ModernStandbyNote = "This machine uses modern standby (S0 low power idle): Keep-Alive holds off the idle timeouts, keeping the display on, and keeps running in standby, but pressing the power button, closing the lid or choosing Sleep still enters standby, where the network may be disconnected. Away mode is not available."
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package platform
+
+// ModernStandby reports whether the machine uses modern standby, which
+// only Windows has.
+func ModernStandby() bool {
+	return false
+}
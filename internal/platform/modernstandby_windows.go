@@ -0,0 +1,38 @@
+//go:build windows
+
+package platform
+
+import "unsafe"
+
+// systemPowerCapabilitiesLevel is the CallNtPowerInformation level that
+// reads SYSTEM_POWER_CAPABILITIES.
+const systemPowerCapabilitiesLevel = 4
+
+var procCallNtPowerInformation = powrprof.NewProc("CallNtPowerInformation")
+
+// systemPowerCapabilities is SYSTEM_POWER_CAPABILITIES, of which only AoAc,
+// set on machines with modern standby, is read.
+type systemPowerCapabilities struct {
+	_    [20]byte
+	aoAc byte
+	_    [55]byte
+}
+
+// ModernStandby reports whether the machine uses modern standby (S0 low
+// power idle) rather than S3 sleep. There, the execution state only holds
+// off the idle timeouts while the display is on, and a process keeps
+// running in standby only with an execution-required power request.
+func ModernStandby() bool {
+	if procCallNtPowerInformation.Find() != nil {
+		return false
+	}
+	var caps systemPowerCapabilities
+	r1, _, _ := procCallNtPowerInformation.Call(
+		systemPowerCapabilitiesLevel,
+		0, 0,
+		uintptr(unsafe.Pointer(&caps)),
+		unsafe.Sizeof(caps),
+	)
+	// CallNtPowerInformation returns an NTSTATUS, zero on success.
+	return r1 == 0 && caps.aoAc != 0
+}
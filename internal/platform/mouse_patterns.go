@@ -3,6 +3,7 @@ package platform
 import (
 	"math"
 	"math/rand"
+	"sync"
 	"time"
 )
 
@@ -15,6 +16,10 @@ type MousePoint struct {
 // MousePatternGenerator generates natural mouse movement patterns
 type MousePatternGenerator struct {
 	rnd *rand.Rand
+
+	// shape may be changed from another goroutine while jitter runs.
+	shapeMu sync.Mutex
+	shape   MouseShape
 }
 
 // NewMousePatternGenerator creates a new pattern generator with a random source
@@ -22,17 +27,71 @@ func NewMousePatternGenerator(rnd *rand.Rand) *MousePatternGenerator {
 	return &MousePatternGenerator{rnd: rnd}
 }
 
+// SetShape selects the pattern and size used by GenerateShapePoints.
+func (g *MousePatternGenerator) SetShape(s MouseShape) {
+	g.shapeMu.Lock()
+	defer g.shapeMu.Unlock()
+	g.shape = s
+}
+
+// Shape returns the configured pattern and size.
+func (g *MousePatternGenerator) Shape() MouseShape {
+	g.shapeMu.Lock()
+	defer g.shapeMu.Unlock()
+	return g.shape
+}
+
+// GenerateShapePoints generates a jitter in the configured shape. Points are
+// absolute offsets relative to origin (0,0) and never exceed the configured
+// size.
+func (g *MousePatternGenerator) GenerateShapePoints() []MousePoint {
+	shape := g.Shape()
+	pattern := shape.Pattern
+	if pattern == MousePatternRandom {
+		// MousePatternRandom is listed last, so this picks a concrete shape.
+		pattern = MousePatterns[g.rnd.Intn(len(MousePatterns)-1)]
+	}
+
+	pointCount := MouseJitterPointsMin + g.rnd.Intn(MouseJitterPointsMax-MouseJitterPointsMin+1)
+	radius := g.jitterRadius(shape.Size)
+
+	switch pattern {
+	case MousePatternSquare:
+		return g.squarePoints(pointCount, radius)
+	case MousePatternZigzag:
+		return g.zigzagPoints(pointCount, radius)
+	case MousePatternWalk:
+		return g.walkPoints(pointCount, radius)
+	default:
+		return g.circlePoints(pointCount, radius)
+	}
+}
+
 // GenerateRoundJitterPoints generates a small round random pattern around origin.
 // Points are absolute offsets relative to origin (0,0).
 func (g *MousePatternGenerator) GenerateRoundJitterPoints() []MousePoint {
 	pointCount := MouseJitterPointsMin + g.rnd.Intn(MouseJitterPointsMax-MouseJitterPointsMin+1)
-	radius := MouseJitterRadiusMin + g.rnd.Float64()*(MouseJitterRadiusMax-MouseJitterRadiusMin)
+	return g.circlePoints(pointCount, g.jitterRadius(0))
+}
 
-	direction := 1.0
+// jitterRadius returns the nominal radius for a jitter. A fixed size is
+// shrunk so that the radius variation of the circle stays within it.
+func (g *MousePatternGenerator) jitterRadius(size int) float64 {
+	if size > 0 {
+		return float64(size) / (1 + MouseJitterRadiusVariation/2)
+	}
+	return MouseJitterRadiusMin + g.rnd.Float64()*(MouseJitterRadiusMax-MouseJitterRadiusMin)
+}
+
+func (g *MousePatternGenerator) direction() float64 {
 	if g.rnd.Intn(2) == 0 {
-		direction = -1.0
+		return -1.0
 	}
+	return 1.0
+}
 
+func (g *MousePatternGenerator) circlePoints(pointCount int, radius float64) []MousePoint {
+	direction := g.direction()
 	startAngle := g.rnd.Float64() * 2 * math.Pi
 	points := make([]MousePoint, 0, pointCount)
 
@@ -48,6 +107,86 @@ func (g *MousePatternGenerator) GenerateRoundJitterPoints() []MousePoint {
 	return points
 }
 
+// squarePoints traces an axis-aligned square whose corners lie on the radius,
+// starting from a random corner.
+func (g *MousePatternGenerator) squarePoints(pointCount int, radius float64) []MousePoint {
+	half := radius / math.Sqrt2
+	corners := [4]MousePoint{{half, half}, {-half, half}, {-half, -half}, {half, -half}}
+	direction := g.direction()
+	start := g.rnd.Intn(4)
+	points := make([]MousePoint, 0, pointCount)
+
+	for i := 0; i < pointCount; i++ {
+		pos := 4 * float64(i) / float64(pointCount)
+		side := int(pos)
+		frac := pos - float64(side)
+		from := corners[(start+int(direction)*side+8)%4]
+		to := corners[(start+int(direction)*(side+1)+8)%4]
+		points = append(points, MousePoint{
+			X: from.X + (to.X-from.X)*frac,
+			Y: from.Y + (to.Y-from.Y)*frac,
+		})
+	}
+
+	return points
+}
+
+// zigzagPoints sweeps across the origin while alternating above and below it,
+// horizontally or vertically.
+func (g *MousePatternGenerator) zigzagPoints(pointCount int, radius float64) []MousePoint {
+	width := radius * 0.8
+	amplitude := radius * 0.5
+	direction := g.direction()
+	vertical := g.rnd.Intn(2) == 0
+	points := make([]MousePoint, 0, pointCount)
+
+	for i := 0; i < pointCount; i++ {
+		along := direction * (-width + 2*width*float64(i)/float64(pointCount-1))
+		across := amplitude
+		if i%2 == 1 {
+			across = -amplitude
+		}
+		if vertical {
+			points = append(points, MousePoint{X: across, Y: along})
+		} else {
+			points = append(points, MousePoint{X: along, Y: across})
+		}
+	}
+
+	return points
+}
+
+// walkPoints takes short steps with gently changing heading, turning back
+// toward the origin whenever a step would leave the radius.
+func (g *MousePatternGenerator) walkPoints(pointCount int, radius float64) []MousePoint {
+	step := math.Max(radius/3, 1)
+	heading := g.rnd.Float64() * 2 * math.Pi
+	var x, y float64
+	points := make([]MousePoint, 0, pointCount)
+
+	for i := 0; i < pointCount; i++ {
+		heading += (g.rnd.Float64() - 0.5) * math.Pi / 2
+		nx := x + step*math.Cos(heading)
+		ny := y + step*math.Sin(heading)
+		if math.Hypot(nx, ny) > radius {
+			heading = math.Atan2(-y, -x) + (g.rnd.Float64()-0.5)*math.Pi/2
+			nx = x + step*math.Cos(heading)
+			ny = y + step*math.Sin(heading)
+		}
+		if d := math.Hypot(nx, ny); d > radius {
+			nx, ny = nx*radius/d, ny*radius/d
+		}
+		if math.Hypot(nx, ny) < 0.5 {
+			// Avoid a step that rounds back onto the origin.
+			nx, ny = step*math.Cos(heading), step*math.Sin(heading)
+		}
+		x, y = nx, ny
+		points = append(points, MousePoint{X: x, Y: y})
+	}
+
+	return points
+}
+
 // JitterSessionDuration returns a random jitter session duration around 0.5s.
 func (g *MousePatternGenerator) JitterSessionDuration() time.Duration {
 	if MouseJitterSessionDurationMax <= MouseJitterSessionDurationMin {
@@ -95,6 +234,30 @@ func observedActiveTimestamp(nowNS int64, idle time.Duration) int64 {
 	return activeNS
 }
 
+// displayBounds is a display's frame in global display coordinates.
+type displayBounds struct {
+	X, Y, Width, Height float64
+}
+
+// clampToDisplay returns points, offsets from (x0, y0), moved where needed
+// so that the pointer stays on the display, DisplayEdgeMargin away from its
+// edges. A display too small for the margin leaves points unchanged.
+func clampToDisplay(points []MousePoint, x0, y0 float64, b displayBounds) []MousePoint {
+	minX, maxX := b.X+DisplayEdgeMargin, b.X+b.Width-1-DisplayEdgeMargin
+	minY, maxY := b.Y+DisplayEdgeMargin, b.Y+b.Height-1-DisplayEdgeMargin
+	if minX > maxX || minY > maxY {
+		return points
+	}
+	clamped := make([]MousePoint, len(points))
+	for i, pt := range points {
+		clamped[i] = MousePoint{
+			X: min(max(x0+pt.X, minX), maxX) - x0,
+			Y: min(max(y0+pt.Y, minY), maxY) - y0,
+		}
+	}
+	return clamped
+}
+
 // relativeStepToPoint converts an absolute point to a relative step from current integer position.
 func relativeStepToPoint(currentX, currentY int, pt MousePoint) (dx, dy, targetX, targetY int) {
 	targetX = int(math.Round(pt.X))
@@ -57,6 +57,106 @@ func TestGenerateRoundJitterPointsInBounds(t *testing.T) {
 	}
 }
 
+func TestGenerateShapePointsInBounds(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	g := NewMousePatternGenerator(rnd)
+
+	for _, pattern := range MousePatterns {
+		for _, size := range []int{0, MinMousePatternSize, 30, MaxMousePatternSize} {
+			g.SetShape(MouseShape{Pattern: pattern, Size: size})
+			maxRadius := MouseJitterRadiusMax * (1 + MouseJitterRadiusVariation/2)
+			if size > 0 {
+				maxRadius = float64(size)
+			}
+
+			for i := 0; i < 100; i++ {
+				points := g.GenerateShapePoints()
+				if len(points) < MouseJitterPointsMin || len(points) > MouseJitterPointsMax {
+					t.Fatalf("%s/%d: point count out of bounds: got %d", pattern, size, len(points))
+				}
+				for _, pt := range points {
+					distance := math.Hypot(pt.X, pt.Y)
+					if distance > maxRadius+1e-9 {
+						t.Fatalf("%s/%d: point too far from origin: got %.3f, max %.3f", pattern, size, distance, maxRadius)
+					}
+					if math.Abs(pt.X) < 1e-9 && math.Abs(pt.Y) < 1e-9 {
+						t.Fatalf("%s/%d: point should not be origin", pattern, size)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateShapePointsSquareFollowsEdges(t *testing.T) {
+	g := NewMousePatternGenerator(rand.New(rand.NewSource(2)))
+	g.SetShape(MouseShape{Pattern: MousePatternSquare, Size: 40})
+	half := 40 / (1 + MouseJitterRadiusVariation/2) / math.Sqrt2
+
+	for _, pt := range g.GenerateShapePoints() {
+		onVertical := math.Abs(math.Abs(pt.X)-half) < 1e-9
+		onHorizontal := math.Abs(math.Abs(pt.Y)-half) < 1e-9
+		if !onVertical && !onHorizontal {
+			t.Fatalf("square point (%.3f, %.3f) is not on an edge of half-width %.3f", pt.X, pt.Y, half)
+		}
+	}
+}
+
+func TestClampToDisplay(t *testing.T) {
+	b := displayBounds{X: -1440, Y: 0, Width: 1440, Height: 900}
+	points := []MousePoint{{X: -20, Y: -20}, {X: 5, Y: 5}, {X: 20, Y: 20}}
+
+	// Near the top-left corner of a display left of the main one.
+	got := clampToDisplay(points, -1435, 3, b)
+	want := []MousePoint{{X: 3, Y: 5}, {X: 5, Y: 5}, {X: 20, Y: 20}}
+	for i := range want {
+		if math.Abs(got[i].X-want[i].X) > 1e-9 || math.Abs(got[i].Y-want[i].Y) > 1e-9 {
+			t.Errorf("point %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// Near the right edge, where the main display begins.
+	got = clampToDisplay(points, -4, 450, b)
+	if x := -4 + got[2].X; x != -1-DisplayEdgeMargin {
+		t.Errorf("pointer reaches x = %v, want %v", x, -1-DisplayEdgeMargin)
+	}
+
+	// Far from the edges nothing moves.
+	got = clampToDisplay(points, -720, 450, b)
+	for i := range points {
+		if got[i] != points[i] {
+			t.Errorf("point %d moved to %+v", i, got[i])
+		}
+	}
+
+	if got := clampToDisplay(points, 0, 0, displayBounds{Width: 10, Height: 10}); got[0] != points[0] {
+		t.Errorf("tiny display moved point to %+v", got[0])
+	}
+}
+
+func TestParseMousePattern(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    MousePattern
+		wantErr bool
+	}{
+		{"", MousePatternCircle, false},
+		{"circle", MousePatternCircle, false},
+		{" Walk ", MousePatternWalk, false},
+		{"random", MousePatternRandom, false},
+		{"spiral", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMousePattern(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseMousePattern(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Fatalf("ParseMousePattern(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
 func TestRelativeStepToPointReturnsToOrigin(t *testing.T) {
 	rnd := rand.New(rand.NewSource(7))
 	g := NewMousePatternGenerator(rnd)
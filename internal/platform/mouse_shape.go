@@ -0,0 +1,60 @@
+package platform
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MousePattern names the path traced by a simulated mouse jitter.
+type MousePattern string
+
+const (
+	MousePatternCircle MousePattern = "circle"
+	MousePatternSquare MousePattern = "square"
+	MousePatternZigzag MousePattern = "zigzag"
+	MousePatternWalk   MousePattern = "walk"
+	MousePatternRandom MousePattern = "random"
+)
+
+// MousePatterns lists the accepted pattern names in display order.
+var MousePatterns = []MousePattern{
+	MousePatternCircle,
+	MousePatternSquare,
+	MousePatternZigzag,
+	MousePatternWalk,
+	MousePatternRandom,
+}
+
+// Bounds for a user-supplied pattern size, in pixels from the origin.
+const (
+	MinMousePatternSize = 5
+	MaxMousePatternSize = 200
+)
+
+// ParseMousePattern converts a pattern name to a MousePattern. The empty
+// string selects the default circle.
+func ParseMousePattern(s string) (MousePattern, error) {
+	name := MousePattern(strings.ToLower(strings.TrimSpace(s)))
+	if name == "" {
+		return MousePatternCircle, nil
+	}
+	for _, p := range MousePatterns {
+		if name == p {
+			return p, nil
+		}
+	}
+	names := make([]string, len(MousePatterns))
+	for i, p := range MousePatterns {
+		names[i] = string(p)
+	}
+	return "", fmt.Errorf("unknown mouse pattern %q (use %s)", s, strings.Join(names, ", "))
+}
+
+// MouseShape selects the jitter pattern and its size. The zero value keeps
+// the default round jitter with a randomised radius.
+type MouseShape struct {
+	Pattern MousePattern
+	// Size is the maximum distance in pixels from the origin. Zero picks a
+	// random radius between MouseJitterRadiusMin and MouseJitterRadiusMax.
+	Size int
+}
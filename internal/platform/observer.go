@@ -0,0 +1,52 @@
+package platform
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// observerSlot holds the Observer a keep-alive reports to. The zero value
+// reports to no one.
+type observerSlot struct {
+	o atomic.Pointer[Observer]
+}
+
+func (s *observerSlot) set(o Observer) {
+	if o == nil {
+		s.o.Store(nil)
+		return
+	}
+	s.o.Store(&o)
+}
+
+func (s *observerSlot) inhibitorFailed(name string, err error) {
+	if o := s.o.Load(); o != nil {
+		(*o).InhibitorFailed(name, err)
+	}
+}
+
+func (s *observerSlot) inhibitorRestored(name string) {
+	if o := s.o.Load(); o != nil {
+		(*o).InhibitorRestored(name)
+	}
+}
+
+func (s *observerSlot) simulationSkipped() {
+	if o := s.o.Load(); o != nil {
+		(*o).SimulationSkipped()
+	}
+}
+
+func (s *observerSlot) simulationPerformed(method string) {
+	if o := s.o.Load(); o != nil {
+		(*o).SimulationPerformed(method)
+	}
+}
+
+func (s *observerSlot) sleepAttempted(t time.Time, blocked bool) {
+	if o := s.o.Load(); o != nil {
+		if so, ok := (*o).(SleepObserver); ok {
+			so.SleepAttempted(t, blocked)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+//go:build darwin
+
+package platform
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseHIDIdleTime(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want time.Duration
+	}{
+		{"decimal", `    | |   "HIDIdleTime" = 1500000000`, 1500 * time.Millisecond},
+		{"hex", `"HIDIdleTime" = 0x59682f00`, 1500 * time.Millisecond},
+		{"clamped", `"HIDIdleTime" = 18446744073709551615`, time.Duration(1<<63 - 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHIDIdleTime([]byte(tt.out))
+			if err != nil {
+				t.Fatalf("parseHIDIdleTime() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("parseHIDIdleTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, out := range []string{"", `"HIDIdleTime" = `, `"HIDIdleTime" = 123456789012345678901234`} {
+		if _, err := parseHIDIdleTime([]byte(out)); err == nil {
+			t.Errorf("parseHIDIdleTime(%q) expected error", out)
+		}
+	}
+}
+
+func TestParseDisplayServicesBrightness(t *testing.T) {
+	got, err := parseDisplayServicesBrightness("0.4375\n")
+	if err != nil {
+		t.Fatalf("parseDisplayServicesBrightness() error = %v", err)
+	}
+	if got != 44 {
+		t.Fatalf("parseDisplayServicesBrightness() = %d, want 44", got)
+	}
+
+	for _, out := range []string{"", "NaN", "1.5", "-0.1", "execution error: Error: DisplayServicesGetBrightness failed"} {
+		if _, err := parseDisplayServicesBrightness(out); err == nil {
+			t.Errorf("parseDisplayServicesBrightness(%q) expected error", out)
+		}
+	}
+}
+
+func TestParsePgrep(t *testing.T) {
+	got, err := parsePgrep("123\n4567\n")
+	if err != nil {
+		t.Fatalf("parsePgrep() error = %v", err)
+	}
+	if len(got) != 2 || got[0] != 123 || got[1] != 4567 {
+		t.Fatalf("parsePgrep() = %v, want [123 4567]", got)
+	}
+	if got, err := parsePgrep(""); err != nil || len(got) != 0 {
+		t.Fatalf("parsePgrep(\"\") = %v, %v, want none", got, err)
+	}
+	if _, err := parsePgrep("pgrep: illegal option"); err == nil {
+		t.Fatal("parsePgrep() expected error for non-numeric output")
+	}
+}
+
+func FuzzParseHIDIdleTime(f *testing.F) {
+	f.Add([]byte(`"HIDIdleTime" = 1500000000`))
+	f.Add([]byte(`"HIDIdleTime" = 0xffffffffffffffff`))
+	f.Add([]byte(`"HIDIdleTime" = 0x`))
+	f.Fuzz(func(t *testing.T, out []byte) {
+		d, err := parseHIDIdleTime(out)
+		if err == nil && d < 0 {
+			t.Fatalf("parseHIDIdleTime(%q) = %v, want non-negative", out, d)
+		}
+	})
+}
+
+func FuzzParseIORegBatteryPercentage(f *testing.F) {
+	f.Add(`"MaxCapacity" = 5100 "CurrentCapacity" = 3060`)
+	f.Add(`"MaxCapacity" = 0 "CurrentCapacity" = 1`)
+	f.Fuzz(func(t *testing.T, out string) {
+		p, err := parseIORegBatteryPercentage(out)
+		if err == nil && (p < 0 || p > 100) {
+			t.Fatalf("parseIORegBatteryPercentage(%q) = %d out of range", out, p)
+		}
+	})
+}
+
+func TestParseScriptDrift(t *testing.T) {
+	if got, ok := parseScriptDrift("drift 3.5\nok\n"); !ok || got != 3.5 {
+		t.Errorf("parseScriptDrift() = %v, %v, want 3.5, true", got, ok)
+	}
+	if _, ok := parseScriptDrift("ok\n"); ok {
+		t.Error("parseScriptDrift() found a drift in output without one")
+	}
+}
+
+func TestParseIORegScreenLocked(t *testing.T) {
+	locked := `+-o Root  <class IORegistryEntry, id 0x100000100, retain 30>
+    {
+      "IOConsoleUsers" = ({"kCGSSessionOnConsoleKey"=Yes,"kCGSSessionUserNameKey"="me","CGSSessionScreenIsLocked"=Yes,"kCGSSessionIDKey"=257})
+    }`
+	unlocked := `+-o Root  <class IORegistryEntry, id 0x100000100, retain 30>
+    {
+      "IOConsoleUsers" = ({"kCGSSessionOnConsoleKey"=Yes,"kCGSSessionUserNameKey"="me","kCGSSessionIDKey"=257})
+    }`
+	if !parseIORegScreenLocked([]byte(locked)) {
+		t.Error("parseIORegScreenLocked(locked) = false, want true")
+	}
+	if parseIORegScreenLocked([]byte(unlocked)) {
+		t.Error("parseIORegScreenLocked(unlocked) = true, want false")
+	}
+	if parseIORegScreenLocked(nil) {
+		t.Error("parseIORegScreenLocked(nil) = true, want false")
+	}
+}
+
+func TestParseFocusAssertions(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want bool
+	}{
+		{"on", `{"data":[{"storeAssertionRecords":[{"assertionDetails":{"assertionDetailsModeIdentifier":"com.apple.donotdisturb.mode.default"},"assertionStartDateTimestamp":752762400}]}],"header":{"version":3}}`, true},
+		{"off", `{"data":[{}],"header":{"version":3}}`, false},
+		{"emptied", `{"data":[{"storeAssertionRecords":[]}]}`, false},
+		{"empty", `{}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFocusAssertions([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("parseFocusAssertions() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseFocusAssertions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if _, err := parseFocusAssertions([]byte("not json")); err == nil {
+		t.Error("parseFocusAssertions(malformed) expected error")
+	}
+}
@@ -0,0 +1,193 @@
+//go:build linux
+
+package platform
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestDBusIdleTime(t *testing.T) {
+	got, err := dbusIdleTime(1500)
+	if err != nil {
+		t.Fatalf("dbusIdleTime() error = %v", err)
+	}
+	if got != 1500*time.Millisecond {
+		t.Fatalf("dbusIdleTime() = %v, want 1.5s", got)
+	}
+
+	for _, millis := range []uint64{1 << 63, math.MaxUint64, math.MaxInt64} {
+		if _, err := dbusIdleTime(millis); err == nil {
+			t.Errorf("dbusIdleTime(%d) expected error", millis)
+		}
+	}
+}
+
+func TestNameOwnerLost(t *testing.T) {
+	const name = "org.gnome.SessionManager"
+	changed := func(n, oldOwner, newOwner string) *dbus.Signal {
+		return &dbus.Signal{Name: "org.freedesktop.DBus.NameOwnerChanged", Body: []interface{}{n, oldOwner, newOwner}}
+	}
+	tests := []struct {
+		name string
+		sig  *dbus.Signal
+		want bool
+	}{
+		{"owner gone", changed(name, ":1.5", ""), true},
+		{"owner replaced", changed(name, ":1.5", ":1.9"), true},
+		{"name acquired", changed(name, "", ":1.9"), false},
+		{"other name", changed("org.freedesktop.ScreenSaver", ":1.5", ""), false},
+		{"other signal", &dbus.Signal{Name: "org.freedesktop.DBus.NameLost", Body: []interface{}{name}}, false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nameOwnerLost(tt.sig, name); got != tt.want {
+				t.Fatalf("nameOwnerLost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBrightnessctlMachine(t *testing.T) {
+	got, err := parseBrightnessctlMachine("intel_backlight,backlight,24000,50%,48000\n")
+	if err != nil {
+		t.Fatalf("parseBrightnessctlMachine() error = %v", err)
+	}
+	if got != 50 {
+		t.Fatalf("parseBrightnessctlMachine() = %d, want 50", got)
+	}
+
+	for _, out := range []string{"", "Device 'foo' not found.", "acpi_video0,backlight,5,150%,10", "a,b,c,x%,d"} {
+		if _, err := parseBrightnessctlMachine(out); err == nil {
+			t.Errorf("parseBrightnessctlMachine(%q) expected error", out)
+		}
+	}
+}
+
+func TestParseOSRelease(t *testing.T) {
+	input := `# comment
+NAME="Ubuntu"
+ID=ubuntu
+ ID_LIKE='debian'
+VERSION_ID="24.04"
+garbage line
+`
+	id, idLike, err := parseOSRelease(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseOSRelease() error = %v", err)
+	}
+	if id != "ubuntu" || idLike != "debian" {
+		t.Fatalf("parseOSRelease() = (%q, %q), want (ubuntu, debian)", id, idLike)
+	}
+
+	id, _, _ = parseOSRelease(strings.NewReader("ID=\"fed$(rm)ora\n"))
+	if id != "fedrmora" {
+		t.Fatalf("parseOSRelease() kept unsafe characters: %q", id)
+	}
+}
+
+func TestParseGroupGID(t *testing.T) {
+	input := `root:x:0:
+inputs:x:5:
+input:x:bad:
+input:x:104:alice,bob
+`
+	if got := parseGroupGID(strings.NewReader(input), "input"); got != 104 {
+		t.Fatalf("parseGroupGID() = %d, want 104", got)
+	}
+	if got := parseGroupGID(strings.NewReader("input:x:-1:\n"), "input"); got != -1 {
+		t.Fatalf("parseGroupGID() = %d, want -1 for negative GID", got)
+	}
+	if got := parseGroupGID(strings.NewReader("input\n"), "input"); got != -1 {
+		t.Fatalf("parseGroupGID() = %d, want -1 for truncated entry", got)
+	}
+}
+
+func FuzzParseOSRelease(f *testing.F) {
+	f.Add("ID=ubuntu\nID_LIKE=debian\n")
+	f.Add("ID=\"fedora\"\n")
+	f.Add("ID='\n")
+	f.Fuzz(func(t *testing.T, input string) {
+		id, idLike, _ := parseOSRelease(strings.NewReader(input))
+		for _, v := range []string{id, idLike} {
+			if len(v) > maxOSReleaseValueLen {
+				t.Fatalf("value too long: %d bytes", len(v))
+			}
+			if strings.ContainsAny(v, "\"'$`;\n") {
+				t.Fatalf("value %q contains unsafe characters", v)
+			}
+		}
+	})
+}
+
+func FuzzParseGroupGID(f *testing.F) {
+	f.Add("input:x:104:alice\n")
+	f.Add("input:x:\n")
+	f.Add("input:x:99999999999:\n")
+	f.Fuzz(func(t *testing.T, input string) {
+		if gid := parseGroupGID(strings.NewReader(input), "input"); gid < -1 {
+			t.Fatalf("parseGroupGID() = %d", gid)
+		}
+	})
+}
+
+func FuzzParseUPowerPercentage(f *testing.F) {
+	f.Add("    present: yes\n    percentage: 57.4%\n")
+	f.Add("percentage: 57,4%")
+	f.Add("percentage: NaN%")
+	f.Fuzz(func(t *testing.T, out string) {
+		p, err := parseUPowerPercentage(out)
+		if err == nil && (p < 0 || p > 100) {
+			t.Fatalf("parseUPowerPercentage(%q) = %d out of range", out, p)
+		}
+	})
+}
+
+func TestFindProcessesIn(t *testing.T) {
+	root := t.TempDir()
+	for pid, files := range map[string]map[string]string{
+		"100":  {"comm": "rsync\n", "cmdline": "rsync\x00-a\x00src\x00"},
+		"200":  {"comm": "long-running-bu\n", "cmdline": "/usr/bin/long-running-build\x00"},
+		"300":  {"comm": "python3\n", "cmdline": "/usr/bin/python3\x00rsync\x00"},
+		"400":  {"comm": "rsync\n"},
+		"self": {"comm": "rsync\n"},
+	} {
+		dir := filepath.Join(root, pid)
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	for _, tt := range []struct {
+		name string
+		want []int
+	}{
+		{"rsync", []int{100}},
+		{"long-running-build", []int{200}},
+		{"python3", []int{300}},
+		{"make", nil},
+	} {
+		got, err := findProcessesIn(root, tt.name, 400)
+		if err != nil {
+			t.Fatalf("findProcessesIn(%q) error = %v", tt.name, err)
+		}
+		sort.Ints(got)
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("findProcessesIn(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -4,10 +4,11 @@ package platform
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"math"
 	"math/rand"
+	"os"
 	"os/exec"
 	"regexp"
 	"strconv"
@@ -25,6 +26,16 @@ const (
 	// This protects against hangs if Accessibility is misconfigured or the
 	// scripting environment is not responding.
 	scriptExecutionTimeout = 3 * time.Second
+
+	// caffeinateRestartMinBackoff and caffeinateRestartMaxBackoff bound the
+	// wait between attempts to restart caffeinate after it exits on its own.
+	caffeinateRestartMinBackoff = time.Second
+	caffeinateRestartMaxBackoff = time.Minute
+
+	// caffeinateStableUptime is how long caffeinate must stay up before an
+	// exit is taken as a one-off and restarted at once; quicker exits keep
+	// backing off.
+	caffeinateStableUptime = 30 * time.Second
 )
 
 type darwinCapabilities struct {
@@ -33,16 +44,57 @@ type darwinCapabilities struct {
 	osascriptAvailable  bool
 }
 
-// getIdleTime returns the system idle time on macOS
+// IdleTime returns how long the user has been idle.
+func IdleTime() (time.Duration, error) {
+	return getIdleTime()
+}
+
+// ioregFallbackOnce limits the warning about falling back from ioreg to one
+// per process.
+var ioregFallbackOnce sync.Once
+
+// getIdleTime returns the system idle time on macOS. It is read in-process
+// with CGEventSourceSecondsSinceLastEventType; builds without cgo run ioreg
+// instead, whose text output differs between macOS versions, and when that
+// cannot be parsed ask CoreGraphics through osascript.
 func getIdleTime() (time.Duration, error) {
+	if nativeEventsAvailable {
+		return cgIdleTime()
+	}
+
 	idle, err := getIdleTimeIOReg()
 	if err == nil {
 		return idle, nil
 	}
 
-	return getIdleTimeCoreGraphics()
+	idle, cgErr := getIdleTimeCoreGraphics()
+	if cgErr != nil {
+		return 0, fmt.Errorf("ioreg: %v; CoreGraphics: %v", err, cgErr)
+	}
+	ioregFallbackOnce.Do(func() {
+		logger().Warn("ioreg idle time unavailable, using CoreGraphics", "err", err)
+	})
+	return idle, nil
 }
 
+// IdleSources reads the idle time from CGEventSource in builds with cgo,
+// and from ioreg and from CoreGraphics through osascript.
+func IdleSources() []IdleSource {
+	var sources []IdleSource
+	if nativeEventsAvailable {
+		idle, err := cgIdleTime()
+		sources = append(sources, IdleSource{Name: "CGEventSource", Idle: idle, Err: err})
+	}
+	ioreg, ioregErr := getIdleTimeIOReg()
+	cg, cgErr := getIdleTimeCoreGraphics()
+	return append(sources,
+		IdleSource{Name: "ioreg", Idle: ioreg, Err: ioregErr},
+		IdleSource{Name: "CoreGraphics", Idle: cg, Err: cgErr},
+	)
+}
+
+// getIdleTimeCoreGraphics asks CoreGraphics for the idle time through
+// osascript, for builds without cgo.
 func getIdleTimeCoreGraphics() (time.Duration, error) {
 	if _, err := exec.LookPath("osascript"); err != nil {
 		return 0, err
@@ -90,9 +142,18 @@ func getIdleTimeIOReg() (time.Duration, error) {
 		return 0, err
 	}
 
-	// Extract HIDIdleTime value (in nanoseconds, decimal or hex)
-	re := regexp.MustCompile(`"HIDIdleTime"\s*=\s*(0x[0-9a-fA-F]+|\d+)`)
-	matches := re.FindSubmatch(out)
+	return parseHIDIdleTime(out)
+}
+
+// hidIdleTimePattern matches the HIDIdleTime value (in nanoseconds, decimal or
+// hex). Digits are bounded so oversized values fail to match rather than
+// being silently truncated.
+var hidIdleTimePattern = regexp.MustCompile(`"HIDIdleTime"\s*=\s*(0x[0-9a-fA-F]{1,16}|\d{1,20})\b`)
+
+// parseHIDIdleTime extracts the idle time from `ioreg -c IOHIDSystem` output.
+// When several HID systems are listed the first one wins.
+func parseHIDIdleTime(out []byte) (time.Duration, error) {
+	matches := hidIdleTimePattern.FindSubmatch(out)
 	if len(matches) < 2 {
 		return 0, fmt.Errorf("HIDIdleTime not found in ioreg output")
 	}
@@ -109,6 +170,31 @@ func getIdleTimeIOReg() (time.Duration, error) {
 	return time.Duration(int64(nanos)), nil
 }
 
+// screenLocked reports whether the screen of the console session is locked.
+// Builds without cgo, and processes without a window server session, read
+// the console users from ioreg instead.
+func screenLocked() (bool, error) {
+	if locked, err := cgScreenLocked(); err == nil {
+		return locked, nil
+	}
+	out, err := exec.Command("ioreg", "-n", "Root", "-d1").Output()
+	if err != nil {
+		return false, err
+	}
+	return parseIORegScreenLocked(out), nil
+}
+
+// screenLockedPattern matches the lock flag that the window server sets on
+// a console user in the IOConsoleUsers list while its screen is locked; the
+// key is absent otherwise.
+var screenLockedPattern = regexp.MustCompile(`"CGSSessionScreenIsLocked"\s*=\s*Yes\b`)
+
+// parseIORegScreenLocked reports whether `ioreg -n Root -d1` output shows a
+// locked screen.
+func parseIORegScreenLocked(out []byte) bool {
+	return screenLockedPattern.Match(out)
+}
+
 func parseDarwinBatteryPercentage(output string) (int, error) {
 	re := regexp.MustCompile(`(\d+)%`)
 	matches := re.FindStringSubmatch(output)
@@ -126,16 +212,157 @@ func parseDarwinBatteryPercentage(output string) (int, error) {
 	return percentage, nil
 }
 
-func GetBatteryStatus() (BatteryStatus, error) {
+// parseIORegBatteryPercentage computes the charge level from the IOKit
+// AppleSmartBattery registry entry. CurrentCapacity is a percentage on Apple
+// silicon and mAh on Intel Macs; dividing by MaxCapacity handles both.
+func parseIORegBatteryPercentage(output string) (int, error) {
+	readInt := func(key string) (int, error) {
+		re := regexp.MustCompile(`"` + key + `"\s*=\s*(\d{1,9})\b`)
+		matches := re.FindStringSubmatch(output)
+		if len(matches) < 2 {
+			return 0, fmt.Errorf("%s not found in ioreg output", key)
+		}
+		return strconv.Atoi(matches[1])
+	}
+
+	current, err := readInt("CurrentCapacity")
+	if err != nil {
+		return 0, err
+	}
+	max, err := readInt("MaxCapacity")
+	if err != nil {
+		return 0, err
+	}
+	if max <= 0 {
+		return 0, fmt.Errorf("invalid MaxCapacity %d", max)
+	}
+
+	percentage := (current*100 + max/2) / max
+	if percentage < 0 || percentage > 100 {
+		return 0, fmt.Errorf("battery percentage out of range: %d", percentage)
+	}
+	return percentage, nil
+}
+
+// parseDarwinPowerSource reads the "Now drawing from" line of `pmset -g batt`.
+// A UPS counts as battery power since the mains supply is gone.
+func parseDarwinPowerSource(output string) (PowerSource, error) {
+	re := regexp.MustCompile(`drawing from '([^']*)'`)
+	matches := re.FindStringSubmatch(output)
+	if len(matches) < 2 {
+		return PowerSourceUnknown, fmt.Errorf("power source not found in pmset output")
+	}
+	switch matches[1] {
+	case "AC Power":
+		return PowerSourceAC, nil
+	case "Battery Power", "UPS Power":
+		return PowerSourceBattery, nil
+	default:
+		return PowerSourceUnknown, fmt.Errorf("unrecognized power source %q", matches[1])
+	}
+}
+
+func GetPowerSource() (PowerSource, error) {
 	out, err := exec.Command("pmset", "-g", "batt").CombinedOutput()
 	if err != nil {
-		return BatteryStatus{}, fmt.Errorf("failed to read battery status: %v", err)
+		return PowerSourceUnknown, fmt.Errorf("failed to read power source: %v", err)
+	}
+	return parseDarwinPowerSource(string(out))
+}
+
+// displayServicesPrelude binds the private DisplayServices brightness calls,
+// which also work on Apple silicon where IOKit's display parameters do not.
+const displayServicesPrelude = `
+ObjC.import('CoreGraphics');
+$.NSBundle.bundleWithPath('/System/Library/PrivateFrameworks/DisplayServices.framework').load;
+ObjC.bindFunction('DisplayServicesGetBrightness', ['int', ['unsigned int', 'float *']]);
+ObjC.bindFunction('DisplayServicesSetBrightness', ['int', ['unsigned int', 'float']]);
+`
+
+// parseDisplayServicesBrightness converts the 0..1 brightness printed by the
+// DisplayServices script to a percentage.
+func parseDisplayServicesBrightness(out string) (int, error) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(out), 64)
+	if err != nil || math.IsNaN(value) || value < 0 || value > 1 {
+		return 0, fmt.Errorf("unexpected brightness output: %q", strings.TrimSpace(out))
+	}
+	return int(math.Round(value * 100)), nil
+}
+
+// GetBrightness returns the main display brightness in percent.
+func GetBrightness() (int, error) {
+	script := displayServicesPrelude + `
+var level = Ref();
+if ($.DisplayServicesGetBrightness($.CGMainDisplayID(), level) !== 0) { throw new Error('DisplayServicesGetBrightness failed'); }
+level[0];
+`
+	out, err := runJXAScript(script)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read display brightness: %v (output: %q)", err, string(out))
 	}
+	return parseDisplayServicesBrightness(string(out))
+}
 
-	percentage, err := parseDarwinBatteryPercentage(string(out))
+// SetBrightness sets the main display brightness in percent.
+func SetBrightness(percent int) error {
+	script := displayServicesPrelude + fmt.Sprintf(`
+if ($.DisplayServicesSetBrightness($.CGMainDisplayID(), %.2f) !== 0) { throw new Error('DisplayServicesSetBrightness failed'); }
+`, float64(percent)/100)
+	out, err := runJXAScript(script)
 	if err != nil {
+		return fmt.Errorf("failed to set display brightness: %v (output: %q)", err, string(out))
+	}
+	return nil
+}
+
+// SetTaskbarProgress is unsupported: a terminal program has no Dock icon of
+// its own to decorate.
+func SetTaskbarProgress(fraction float64) error {
+	return errors.New("dock progress is unsupported on macOS")
+}
+
+// ClearTaskbarProgress is unsupported; see SetTaskbarProgress.
+func ClearTaskbarProgress() error {
+	return errors.New("dock progress is unsupported on macOS")
+}
+
+// Notify shows a notification in Notification Center. The title and body are
+// passed as script arguments so that they need no quoting.
+func Notify(title, body string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), scriptExecutionTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "osascript",
+		"-e", "on run argv",
+		"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+		"-e", "end run",
+		title, body).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to show notification: %v (output: %q)", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func GetBatteryStatus() (BatteryStatus, error) {
+	out, err := exec.Command("pmset", "-g", "batt").CombinedOutput()
+	if err != nil {
+		err = fmt.Errorf("failed to read battery status: %v", err)
+	} else {
+		var percentage int
+		if percentage, err = parseDarwinBatteryPercentage(string(out)); err == nil {
+			return BatteryStatus{Percentage: percentage, Available: true}, nil
+		}
+	}
+
+	// Fall back to reading IOKit directly.
+	ioregOut, ioregErr := exec.Command("ioreg", "-rn", "AppleSmartBattery").Output()
+	if ioregErr != nil {
 		return BatteryStatus{}, err
 	}
+	percentage, ioregErr := parseIORegBatteryPercentage(string(ioregOut))
+	if ioregErr != nil {
+		return BatteryStatus{}, fmt.Errorf("%v; ioreg fallback: %v", err, ioregErr)
+	}
 
 	return BatteryStatus{Percentage: percentage, Available: true}, nil
 }
@@ -158,6 +385,11 @@ type darwinKeepAlive struct {
 	// closed when cmd.Wait returns
 	waitDone chan struct{}
 
+	// restartBackoff is the wait before caffeinate is next restarted after
+	// an unexpected exit; it grows while caffeinate keeps exiting soon after
+	// it starts.
+	restartBackoff time.Duration
+
 	// last time we warned about jitter failure, unix nanos
 	lastJitterWarnNS int64
 
@@ -169,6 +401,39 @@ type darwinKeepAlive struct {
 
 	// shared activity controller for idle-gated jitter
 	activityCtrl *ActivityController
+
+	// drift tallies the pointer drift the movement script corrects.
+	drift cursorDrift
+
+	// jitterMu serializes jitter cycles, so the ticker and SimulateOnce never
+	// move the pointer at the same time.
+	jitterMu sync.Mutex
+
+	// timings holds user overrides of the activity intervals.
+	timings Timings
+
+	// mouseShape selects the jitter pattern and size.
+	mouseShape MouseShape
+
+	// budget caps the jitters performed during the session.
+	budget *SimulationBudget
+
+	// observer is told about inhibitor failures and simulations.
+	observer observerSlot
+
+	// presenceOnly runs activity simulation without caffeinate.
+	presenceOnly bool
+
+	// assertions are the caffeinate assertions held, or the defaults when
+	// empty.
+	assertions []Assertion
+
+	// watchPID is the process caffeinate is bound to with -w, or zero.
+	watchPID int
+
+	// held is the reason the last jitter tick held off simulation, or ""
+	// when it did not; see simulationHold.
+	held atomic.Value
 }
 
 // Start initiates the keep-alive functionality.
@@ -181,11 +446,24 @@ func (k *darwinKeepAlive) Start(ctx context.Context) error {
 	}
 
 	k.ctx, k.cancel = context.WithCancel(ctx)
+	k.restartBackoff = 0
 	k.rnd = newCryptoSeededRand()
 	k.patternGen = NewMousePatternGenerator(k.rnd)
+	k.patternGen.SetShape(k.mouseShape)
 	k.activityCtrl = NewActivityController("darwin", k.patternGen)
+	k.activityCtrl.setObserver(&k.observer)
+	k.activityCtrl.SetTimings(k.timings)
+	k.activityCtrl.SetBudget(k.budget)
 	atomic.StoreInt64(&k.lastJitterWarnNS, 0)
 
+	if k.presenceOnly {
+		k.activeMethod = ""
+		k.maybeStartChatAppTickerLocked()
+		logger().Info("keep-alive started", "mode", "presence-only")
+		k.isRunning = true
+		return nil
+	}
+
 	caps, err := detectDarwinCapabilities()
 	if err != nil {
 		k.cancel()
@@ -197,6 +475,7 @@ func (k *darwinKeepAlive) Start(ctx context.Context) error {
 		return err
 	}
 
+	k.watchSleepAttemptsLocked()
 	k.maybeStartChatAppTickerLocked()
 	k.logPmsetAssertions(caps)
 	k.setActiveMethod(caps)
@@ -214,13 +493,13 @@ func detectDarwinCapabilities() (darwinCapabilities, error) {
 	caps.caffeinateAvailable = true
 
 	if _, err := exec.LookPath("pmset"); err != nil {
-		log.Printf("darwin: pmset not available; proceeding without pmset touch assertion")
+		logger().Warn("pmset not available; proceeding without pmset touch assertion")
 	} else {
 		caps.pmsetAvailable = true
 	}
 
 	if _, err := exec.LookPath("osascript"); err != nil {
-		log.Printf("darwin: osascript not available; mouse jitter will not work: %v", err)
+		logger().Warn("osascript not available; mouse jitter needs keep-alive's own Accessibility grant", "err", err)
 	} else {
 		caps.osascriptAvailable = true
 	}
@@ -229,28 +508,140 @@ func detectDarwinCapabilities() (darwinCapabilities, error) {
 }
 
 func (k *darwinKeepAlive) startCaffeinateLocked() error {
-	k.cmd = exec.CommandContext(k.ctx, "caffeinate", "-s", "-d", "-m", "-i")
-	k.cmd.SysProcAttr = &syscall.SysProcAttr{
+	ctx := k.ctx
+	watchPID := k.watchPID
+	cmd := exec.CommandContext(ctx, "caffeinate", caffeinateArgs(k.assertions, watchPID)...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
 		Setpgid: true,
 		Pgid:    0,
 	}
 
-	if err := k.cmd.Start(); err != nil {
+	if err := cmd.Start(); err != nil {
 		return err
 	}
+	startedAt := time.Now()
 
-	k.waitDone = make(chan struct{})
+	done := make(chan struct{})
+	k.cmd = cmd
+	k.waitDone = done
 
 	k.wg.Add(1)
 	go func() {
 		defer k.wg.Done()
-		_ = k.cmd.Wait()
-		close(k.waitDone)
+		err := cmd.Wait()
+		close(done)
+		if ctx.Err() != nil {
+			return
+		}
+		if watchPID > 0 {
+			if alive, aliveErr := ProcessAlive(watchPID); aliveErr == nil && !alive {
+				// caffeinate -w let the assertions go with the watched
+				// process; the session ends as the keeper sees it exit.
+				logger().Info("caffeinate released its assertions", "reason", "watched process exited", "pid", watchPID)
+				return
+			}
+		}
+		// Something other than Stop ended caffeinate, so its assertions
+		// are gone and the system may sleep until it is running again.
+		logger().Warn("inhibitor failed", "inhibitor", "caffeinate", "pid", cmd.Process.Pid, "err", err)
+		k.observer.inhibitorFailed("caffeinate", err)
+		k.restartCaffeinate(ctx, time.Since(startedAt))
 	}()
 
 	return nil
 }
 
+// watchSleepAttemptsLocked reports the system's attempts to sleep for the
+// session and, when its assertions are meant to prevent idle sleep, refuses
+// idle sleep too: caffeinate may be down for a moment while it restarts.
+// Without the notifications the session runs on caffeinate alone. Callers
+// must hold k.mu.
+func (k *darwinKeepAlive) watchSleepAttemptsLocked() {
+	ctx := k.ctx
+	veto := vetoesIdleSleep(k.assertions)
+	stop, err := startSleepWatch(func(canVeto bool) bool {
+		return k.sleepAttempted(canVeto, canVeto && veto)
+	})
+	if err != nil {
+		logger().Debug("sleep notifications unavailable", "err", err)
+		return
+	}
+	k.wg.Add(1)
+	go func() {
+		defer k.wg.Done()
+		<-ctx.Done()
+		stop()
+	}()
+}
+
+// sleepAttempted logs and reports an attempt to sleep and returns whether
+// to allow it. It runs on the notification thread and takes no locks.
+func (k *darwinKeepAlive) sleepAttempted(canVeto, veto bool) (allow bool) {
+	switch {
+	case veto:
+		logger().Info("system attempted to sleep", "blocked", true)
+	case canVeto:
+		logger().Info("system attempted to sleep", "blocked", false, "reason", "the session holds no idle or system assertion")
+	default:
+		logger().Info("system is going to sleep", "blocked", false, "reason", "sleep was requested, as by closing the lid")
+	}
+	k.observer.sleepAttempted(time.Now(), veto)
+	return !veto
+}
+
+// restartCaffeinate starts caffeinate again after an unexpected exit once
+// it had been up for uptime, backing off between failed attempts until it
+// runs or ctx is cancelled. The backoff carries over to the next exit unless
+// caffeinate stayed up for caffeinateStableUptime, so one that exits as soon
+// as it starts is not respawned in a tight loop.
+func (k *darwinKeepAlive) restartCaffeinate(ctx context.Context, uptime time.Duration) {
+	k.mu.Lock()
+	if uptime >= caffeinateStableUptime {
+		k.restartBackoff = 0
+	}
+	delay := k.restartBackoff
+	k.mu.Unlock()
+
+	for {
+		if delay > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+
+		k.mu.Lock()
+		if ctx.Err() != nil || k.ctx != ctx {
+			k.mu.Unlock()
+			return
+		}
+		err := k.startCaffeinateLocked()
+		var pid int
+		if err == nil {
+			pid = k.cmd.Process.Pid
+			k.restartBackoff = nextCaffeinateBackoff(delay)
+		}
+		k.mu.Unlock()
+
+		if err == nil {
+			logger().Info("inhibitor recovered", "inhibitor", "caffeinate", "pid", pid)
+			k.observer.inhibitorRestored("caffeinate")
+			return
+		}
+		delay = nextCaffeinateBackoff(delay)
+		logger().Warn("restarting caffeinate failed", "retry_in", delay, "err", err)
+	}
+}
+
+// nextCaffeinateBackoff returns the restart backoff that follows d.
+func nextCaffeinateBackoff(d time.Duration) time.Duration {
+	if d < caffeinateRestartMinBackoff {
+		return caffeinateRestartMinBackoff
+	}
+	return min(d*2, caffeinateRestartMaxBackoff)
+}
+
 func (k *darwinKeepAlive) maybeStartChatAppTickerLocked() {
 	if !k.simulateActivity.Load() || k.ctx == nil {
 		return
@@ -286,36 +677,88 @@ func (k *darwinKeepAlive) logPmsetAssertions(caps darwinCapabilities) {
 
 	out, err := exec.Command("pmset", "-g", "assertions").CombinedOutput()
 	if err != nil {
-		log.Printf("darwin: pmset assertions check failed: %v", err)
+		logger().Warn("pmset assertions check failed", "err", err)
 		return
 	}
 
-	log.Printf("darwin: started keep alive; pmset assertions bytes=%d", len(out))
+	logger().Debug("pmset assertions read", "bytes", len(out))
 }
 
 func (k *darwinKeepAlive) setActiveMethod(caps darwinCapabilities) {
 	_ = caps
 	k.activeMethod = "caffeinate"
-	log.Printf("darwin: active method: %s", k.activeMethod)
+	logger().Info("keep-alive started", "method", k.activeMethod, "args", caffeinateArgs(k.assertions, k.watchPID))
 }
 
 // simulateChatAppActivity simulates natural user activity to keep Teams/Slack active.
 // Only triggers when the user is idle to avoid interfering with actual computer use.
 func (k *darwinKeepAlive) simulateChatAppActivity() {
-	if !k.simulateActivity.Load() {
+	if !k.simulateActivity.Load() || k.simulationHeld() {
 		return
 	}
 
+	k.jitterMu.Lock()
+	defer k.jitterMu.Unlock()
 	k.activityCtrl.MaybeJitter(
 		getIdleTime,
 		func(points []MousePoint, sessionDuration time.Duration) {
-			if err := k.jitterMouseRoundPattern(sessionDuration); err != nil {
+			method, err := k.jitterMouseRoundPattern(points, sessionDuration)
+			if err != nil {
 				k.warnJitterFailureOnce(err)
+				return
 			}
+			k.observer.simulationPerformed(method)
 		},
 	)
 }
 
+// simulationHeld reports whether simulation should hold off this tick, as
+// told by simulationHold, logging when that changes so that the log shows
+// why the pointer stopped moving.
+func (k *darwinKeepAlive) simulationHeld() bool {
+	reason := simulationHold()
+	if prev, _ := k.held.Swap(reason).(string); prev != reason {
+		if reason != "" {
+			logger().Info("activity simulation paused", "reason", reason)
+		} else {
+			logger().Info("activity simulation resumed")
+		}
+	}
+	return reason != ""
+}
+
+// SimulateOnce runs one jitter cycle immediately.
+func (k *darwinKeepAlive) SimulateOnce() SimulationResult {
+	k.mu.Lock()
+	running := k.isRunning
+	k.mu.Unlock()
+	if !running {
+		return SimulationResult{Err: errors.New("keep-alive is not running")}
+	}
+
+	status := GetActivitySimulationStatus()
+	if !status.Available {
+		return SimulationResult{Err: errors.New(status.Message)}
+	}
+	if locked, err := screenLocked(); err == nil && locked {
+		return SimulationResult{Err: errors.New("the screen is locked")}
+	}
+	res := SimulationResult{Tried: darwinSimulationMethods()}
+	res.Idle, res.IdleErr = getIdleTime()
+
+	k.jitterMu.Lock()
+	defer k.jitterMu.Unlock()
+	res.Points, res.Duration = k.activityCtrl.Fire(func(points []MousePoint, sessionDuration time.Duration) {
+		res.Method, res.Err = k.jitterMouseRoundPattern(points, sessionDuration)
+	})
+	if res.Err == nil {
+		k.observer.simulationPerformed(res.Method)
+	} else {
+		res.Method = ""
+	}
+	return res
+}
+
 func runJXAScript(script string) ([]byte, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), scriptExecutionTimeout)
 	defer cancel()
@@ -338,21 +781,90 @@ func (k *darwinKeepAlive) warnJitterFailureOnce(err error) {
 	}
 	atomic.StoreInt64(&k.lastJitterWarnNS, nowNS)
 
-	log.Printf("darwin: mouse jitter failed (%v). This can happen in headless/remote sessions where cursor warping is unavailable.", err)
+	logger().Warn("mouse jitter failed; cursor warping may be unavailable in headless or remote sessions", "err", err)
 }
 
-// jitterMouseRoundPattern applies a small random round pattern and returns to origin.
-func (k *darwinKeepAlive) jitterMouseRoundPattern(sessionDuration time.Duration) error {
-	points := k.patternGen.GenerateRoundJitterPoints()
+// jitterMouseRoundPattern applies a small jitter in the configured shape
+// and returns to origin. It posts the events itself when it can, and runs
+// the movement script through osascript otherwise. It returns the method
+// that moved the pointer.
+func (k *darwinKeepAlive) jitterMouseRoundPattern(points []MousePoint, sessionDuration time.Duration) (string, error) {
+	if nativeEventsAvailable {
+		err := k.jitterNative(points, sessionDuration)
+		if err == nil {
+			return darwinSimCGEvent, nil
+		}
+		if k.ctx != nil && k.ctx.Err() != nil {
+			return "", err
+		}
+		logger().Debug("posting mouse events failed; falling back to osascript", "err", err)
+	}
+
 	script := k.buildMouseMovementScript(points, sessionDuration)
 
 	out, err := runJXAScript(script)
 	if err != nil {
-		return fmt.Errorf("osascript failed: %v (output: %q)", err, string(out))
+		return "", fmt.Errorf("osascript failed: %v (output: %q)", err, string(out))
+	}
+	if drift, ok := parseScriptDrift(string(out)); ok && k.drift.needsCorrection(drift) {
+		// The script has already warped the pointer back.
+		k.drift.record(drift)
+	}
+	return darwinSimJXA, nil
+}
+
+// jitterNative moves the pointer through points with mouse events posted
+// from this process, then back to its origin. It needs this process to be
+// trusted for Accessibility, which is granted separately from osascript.
+func (k *darwinKeepAlive) jitterNative(points []MousePoint, sessionDuration time.Duration) error {
+	if !cgAccessibilityTrusted() {
+		return errors.New("keep-alive is not trusted for Accessibility")
+	}
+	origin, err := cgCursor{}.cursorPosition()
+	if err != nil {
+		return err
+	}
+	x0, y0 := float64(origin.X), float64(origin.Y)
+	if bounds, err := cgDisplayBounds(x0, y0); err == nil {
+		points = clampToDisplay(points, x0, y0, bounds)
+	} else {
+		logger().Debug("display bounds unavailable; jitter is not kept on the display", "err", err)
+	}
+	stepDelay := jitterStepDelay(sessionDuration, len(points))
+
+	for _, pt := range points {
+		if k.ctx != nil && k.ctx.Err() != nil {
+			_ = cgPostMouseMove(x0, y0)
+			return k.ctx.Err()
+		}
+		if err := cgPostMouseMove(x0+pt.X, y0+pt.Y); err != nil {
+			_ = cgPostMouseMove(x0, y0)
+			return err
+		}
+		time.Sleep(k.patternGen.JitterStepDelayWithVariance(stepDelay))
 	}
+
+	if err := cgPostMouseMove(x0, y0); err != nil {
+		return err
+	}
+	time.Sleep(k.patternGen.JitterStepDelayWithVariance(stepDelay))
+	k.drift.restore(cgCursor{}, origin)
 	return nil
 }
 
+// parseScriptDrift reads the "drift" line the movement script prints.
+func parseScriptDrift(out string) (float64, bool) {
+	for _, line := range strings.Split(out, "\n") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), "drift ")
+		if !ok {
+			continue
+		}
+		drift, err := strconv.ParseFloat(value, 64)
+		return drift, err == nil
+	}
+	return 0, false
+}
+
 func (k *darwinKeepAlive) buildMouseMovementScript(points []MousePoint, sessionDuration time.Duration) string {
 	stepDelay := jitterStepDelay(sessionDuration, len(points))
 
@@ -361,6 +873,7 @@ func (k *darwinKeepAlive) buildMouseMovementScript(points []MousePoint, sessionD
 	// input, unlike CGWarpMouseCursorPosition which only repositions the cursor.
 	script := `
 ObjC.import('CoreGraphics');
+ObjC.import('AppKit');
 
 function loc() {
 	var ev = $.CGEventCreate(null);
@@ -379,20 +892,65 @@ function moveTo(x, y) {
 	}
 }
 
+// The frame of the display holding p, in the global display coordinates
+// CoreGraphics uses: NSScreen measures up from the bottom of the main
+// display, which is listed first.
+function displayBounds(p) {
+	var screens = $.NSScreen.screens.js;
+	if (screens.length == 0) {
+		return null;
+	}
+	var mainHeight = screens[0].frame.size.height;
+	for (var i = 0; i < screens.length; i++) {
+		var f = screens[i].frame;
+		var b = {x: f.origin.x, y: mainHeight - f.origin.y - f.size.height, w: f.size.width, h: f.size.height};
+		if (p.x >= b.x && p.x < b.x + b.w && p.y >= b.y && p.y < b.y + b.h) {
+			return b;
+		}
+	}
+	return null;
+}
+
+// Keep the pointer off the display's edges, corners and neighbours.
+function clamp(v, lo, size) {
+	if (size <= 2 * margin + 1) {
+		return v;
+	}
+	return Math.min(Math.max(v, lo + margin), lo + size - 1 - margin);
+}
+
+function jitterTo(x, y) {
+	if (bounds != null) {
+		x = clamp(x, bounds.x, bounds.w);
+		y = clamp(y, bounds.y, bounds.h);
+	}
+	moveTo(x, y);
+}
+
 var origin = loc();
 var x0 = origin.x;
 var y0 = origin.y;
+var bounds = displayBounds(origin);
 `
+	script += fmt.Sprintf("var margin = %f;\n", DisplayEdgeMargin)
 
 	for _, pt := range points {
 		d := k.patternGen.JitterStepDelayWithVariance(stepDelay)
-		script += fmt.Sprintf("moveTo(x0 + %f, y0 + %f);\ndelay(%f);\n", pt.X, pt.Y, d.Seconds())
+		script += fmt.Sprintf("jitterTo(x0 + %f, y0 + %f);\ndelay(%f);\n", pt.X, pt.Y, d.Seconds())
 	}
 
 	returnD := k.patternGen.JitterStepDelayWithVariance(stepDelay)
 	script += fmt.Sprintf("\n// Return to origin\nmoveTo(x0, y0);\ndelay(%f);\n", returnD.Seconds())
-	script += `console.log("ok");
-`
+	script += fmt.Sprintf(`
+// Move the pointer back if it did not land on its origin
+var end = loc();
+var drift = Math.sqrt(Math.pow(end.x - x0, 2) + Math.pow(end.y - y0, 2));
+if (drift > %f && drift <= %f) {
+	$.CGWarpMouseCursorPosition($.CGPointMake(x0, y0));
+}
+console.log("drift " + drift);
+console.log("ok");
+`, CursorDriftEpsilon, float64(maxCursorCorrection))
 	return script
 }
 
@@ -405,7 +963,7 @@ func (k *darwinKeepAlive) killProcessLocked() {
 
 	// Try SIGTERM first
 	if err := k.cmd.Process.Signal(syscall.SIGTERM); err != nil {
-		log.Printf("darwin: failed to send SIGTERM to caffeinate (pid %d): %v", pid, err)
+		logger().Warn("sending SIGTERM to caffeinate failed", "pid", pid, "err", err)
 	}
 
 	// Wait briefly for clean shutdown
@@ -414,7 +972,7 @@ func (k *darwinKeepAlive) killProcessLocked() {
 		for _, to := range timeouts {
 			select {
 			case <-k.waitDone:
-				log.Printf("darwin: caffeinate process (pid %d) terminated cleanly", pid)
+				logger().Debug("caffeinate terminated", "pid", pid)
 				return
 			case <-time.After(to):
 			}
@@ -422,22 +980,22 @@ func (k *darwinKeepAlive) killProcessLocked() {
 	}
 
 	// Escalate to SIGKILL
-	log.Printf("darwin: caffeinate process (pid %d) did not terminate, sending SIGKILL", pid)
+	logger().Warn("caffeinate did not terminate, sending SIGKILL", "pid", pid)
 	if err := k.cmd.Process.Kill(); err != nil {
-		log.Printf("darwin: failed to kill caffeinate process (pid %d): %v", pid, err)
+		logger().Error("killing caffeinate failed", "pid", pid, "err", err)
 	}
 
 	// Also try killing the process group
 	if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil {
-		log.Printf("darwin: failed to kill caffeinate process group (pgid %d): %v", pid, err)
+		logger().Error("killing caffeinate process group failed", "pgid", pid, "err", err)
 	}
 
 	if k.waitDone != nil {
 		select {
 		case <-k.waitDone:
-			log.Printf("darwin: caffeinate process (pid %d) terminated after SIGKILL", pid)
+			logger().Debug("caffeinate terminated after SIGKILL", "pid", pid)
 		case <-time.After(500 * time.Millisecond):
-			log.Printf("darwin: warning: caffeinate process (pid %d) may still be running", pid)
+			logger().Warn("caffeinate may still be running", "pid", pid)
 		}
 	}
 }
@@ -453,16 +1011,16 @@ func (k *darwinKeepAlive) verifyProcessTerminated() bool {
 	// Check if process still exists by sending signal 0 (doesn't kill, just checks)
 	err := syscall.Kill(pid, 0)
 	if err == nil {
-		log.Printf("darwin: warning: caffeinate process (pid %d) still exists", pid)
+		logger().Warn("caffeinate still exists", "pid", pid)
 		return false
 	}
 
 	if err == syscall.ESRCH {
-		log.Printf("darwin: verified caffeinate process (pid %d) has terminated", pid)
+		logger().Debug("verified caffeinate has terminated", "pid", pid)
 		return true
 	}
 
-	log.Printf("darwin: could not verify caffeinate process (pid %d) status: %v", pid, err)
+	logger().Warn("could not verify caffeinate status", "pid", pid, "err", err)
 	return false
 }
 
@@ -498,16 +1056,16 @@ func (k *darwinKeepAlive) Stop() error {
 
 	select {
 	case <-done:
-		log.Printf("darwin: all goroutines completed")
+		logger().Debug("all goroutines completed")
 	case <-time.After(2 * time.Second):
-		log.Printf("darwin: warning: some goroutines did not complete within timeout")
+		logger().Warn("some goroutines did not complete within timeout")
 	}
 
 	k.mu.Lock()
 
 	// Verify process termination
 	if !k.verifyProcessTerminated() {
-		log.Printf("darwin: warning: caffeinate process may still be running")
+		logger().Warn("caffeinate may still be running")
 	}
 
 	k.isRunning = false
@@ -523,10 +1081,150 @@ func (k *darwinKeepAlive) Stop() error {
 	atomic.StoreInt64(&k.lastJitterWarnNS, 0)
 	k.mu.Unlock()
 
-	log.Printf("darwin: stopped; cleanup complete")
+	logger().Info("keep-alive stopped")
 	return nil
 }
 
+// Healthy reports whether caffeinate is running. It may be down briefly
+// while it is being restarted after an unexpected exit. In presence-only
+// mode there is no caffeinate, so a running keep-alive is healthy.
+func (k *darwinKeepAlive) Healthy() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !k.isRunning {
+		return errors.New("keep-alive is not running")
+	}
+	if k.presenceOnly {
+		return nil
+	}
+	if k.waitDone == nil {
+		return errors.New("caffeinate is not running")
+	}
+	select {
+	case <-k.waitDone:
+		return errors.New("caffeinate is not running")
+	default:
+		return nil
+	}
+}
+
+// ActiveMethods reports caffeinate while running.
+func (k *darwinKeepAlive) ActiveMethods() []string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !k.isRunning || k.activeMethod == "" {
+		return []string{}
+	}
+	return []string{k.activeMethod}
+}
+
+// StopNow kills caffeinate without giving it time to exit cleanly. Its
+// assertions are released with the process, so nothing else is waited for.
+func (k *darwinKeepAlive) StopNow() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !k.isRunning {
+		return
+	}
+
+	if k.cancel != nil {
+		k.cancel()
+	}
+	if k.activityTick != nil {
+		k.activityTick.Stop()
+	}
+	if k.chatAppActivityTick != nil {
+		k.chatAppActivityTick.Stop()
+	}
+	if k.cmd != nil && k.cmd.Process != nil {
+		pid := k.cmd.Process.Pid
+		if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil {
+			if err := k.cmd.Process.Kill(); err != nil {
+				logger().Error("killing caffeinate failed", "pid", pid, "err", err)
+			}
+		}
+	}
+
+	k.isRunning = false
+	k.cmd = nil
+	k.ctx = nil
+	k.cancel = nil
+	k.activityTick = nil
+	k.chatAppActivityTick = nil
+	k.waitDone = nil
+	if k.activityCtrl != nil {
+		k.activityCtrl.Reset()
+	}
+	atomic.StoreInt64(&k.lastJitterWarnNS, 0)
+	logger().Info("keep-alive stopped immediately")
+}
+
+// SetSimulationBudget caps the jitters performed, including for a running
+// session.
+func (k *darwinKeepAlive) SetSimulationBudget(budget *SimulationBudget) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.budget = budget
+	if k.activityCtrl != nil {
+		k.activityCtrl.SetBudget(budget)
+	}
+}
+
+// SetObserver sets the Observer told about inhibitor failures and
+// simulations, including for a running session.
+func (k *darwinKeepAlive) SetObserver(o Observer) {
+	k.observer.set(o)
+}
+
+// SetTimings applies new activity intervals, including to a running session.
+// caffeinate holds the assertions on macOS, so only the simulation timings
+// are used.
+func (k *darwinKeepAlive) SetTimings(t Timings) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.timings = t
+	if k.activityCtrl != nil {
+		k.activityCtrl.SetTimings(t)
+	}
+}
+
+// SetMouseShape selects the jitter pattern, including for a running session.
+func (k *darwinKeepAlive) SetMouseShape(shape MouseShape) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.mouseShape = shape
+	if k.patternGen != nil {
+		k.patternGen.SetShape(shape)
+	}
+}
+
+// SetPresenceOnly runs only activity simulation from the next start,
+// leaving sleep to the system.
+func (k *darwinKeepAlive) SetPresenceOnly(presenceOnly bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.presenceOnly = presenceOnly
+}
+
+// SetWatchPID binds caffeinate to the process pid with -w from the next
+// start, so that macOS drops the assertions when that process exits.
+func (k *darwinKeepAlive) SetWatchPID(pid int) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.watchPID = pid
+}
+
+// SetAssertions selects the assertions caffeinate holds from the next
+// start.
+func (k *darwinKeepAlive) SetAssertions(assertions []Assertion) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.assertions = assertions
+}
+
 func (k *darwinKeepAlive) SetSimulateActivity(simulate bool) {
 	k.mu.Lock()
 	defer k.mu.Unlock()
@@ -567,19 +1265,100 @@ func GetDependencyMessage() string {
 	return ""
 }
 
+// The activity-simulation methods on macOS: mouse events posted by
+// keep-alive itself, or by a JavaScript for Automation script run through
+// osascript.
+const (
+	darwinSimCGEvent = "CGEventPost"
+	darwinSimJXA     = "osascript"
+)
+
+// darwinSimulationMethods lists the methods available, in the order they
+// are tried. Posting events directly needs a cgo build and the
+// Accessibility grant for keep-alive; osascript has a grant of its own.
+func darwinSimulationMethods() []string {
+	var methods []string
+	if nativeEventsAvailable && cgAccessibilityTrusted() {
+		methods = append(methods, darwinSimCGEvent)
+	}
+	if _, err := exec.LookPath("osascript"); err == nil {
+		methods = append(methods, darwinSimJXA)
+	}
+	return methods
+}
+
 func GetActivitySimulationStatus() ActivitySimulationStatus {
-	if _, err := exec.LookPath("osascript"); err != nil {
+	methods := darwinSimulationMethods()
+	if len(methods) == 0 {
 		return ActivitySimulationStatus{
 			Available: false,
-			Message:   "Active status simulation is unavailable on macOS because osascript is not installed. KeepAlive will still prevent system sleep, but Slack/Teams activity cannot be simulated.",
+			Message:   "Active status simulation is unavailable on macOS because keep-alive is not allowed to post mouse events and osascript is not installed. Grant keep-alive Accessibility access in System Settings > Privacy & Security. KeepAlive will still prevent system sleep, but Slack/Teams activity cannot be simulated.",
+		}
+	}
+	if methods[0] == darwinSimCGEvent {
+		return ActivitySimulationStatus{
+			Available: true,
+			Method:    darwinSimCGEvent,
+			Message:   "Active status simulation posts CoreGraphics mouse events directly.",
 		}
 	}
 
 	return ActivitySimulationStatus{
 		Available: true,
-		Method:    "CoreGraphics mouse events",
-		Message:   "Active status simulation uses CoreGraphics mouse events. macOS Accessibility permissions may still be required in normal desktop sessions.",
+		Method:    darwinSimJXA,
+		Message:   "Active status simulation posts CoreGraphics mouse events through osascript. macOS Accessibility permissions may still be required in normal desktop sessions; granting them to keep-alive itself avoids starting osascript for each movement.",
+	}
+}
+
+// parsePgrep parses the pid-per-line output of pgrep.
+func parsePgrep(out string) ([]int, error) {
+	var pids []int
+	for _, line := range strings.Fields(out) {
+		pid, err := strconv.Atoi(line)
+		if err != nil || pid <= 0 {
+			return nil, fmt.Errorf("unexpected pgrep output: %q", line)
+		}
+		pids = append(pids, pid)
+	}
+	return pids, nil
+}
+
+// FindProcesses returns the pids of running processes named name.
+func FindProcesses(name string) ([]int, error) {
+	out, err := exec.Command("pgrep", "-x", name).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			// pgrep exits 1 when nothing matches.
+			return nil, nil
+		}
+		return nil, fmt.Errorf("pgrep failed: %v", err)
+	}
+	pids, err := parsePgrep(string(out))
+	if err != nil {
+		return nil, err
+	}
+	self := os.Getpid()
+	for i, pid := range pids {
+		if pid == self {
+			pids = append(pids[:i], pids[i+1:]...)
+			break
+		}
+	}
+	return pids, nil
+}
+
+// Diagnose reports the tools keep-alive uses on macOS.
+func Diagnose() Diagnostics {
+	d := newDiagnostics("caffeinate", "pmset", "osascript", "ioreg")
+	d.Inhibitors = []string{"caffeinate"}
+	if d.Tools["pmset"] {
+		d.Inhibitors = append(d.Inhibitors, "pmset touch")
 	}
+	d.SleepPrevention = d.Tools["caffeinate"]
+	d.ActivitySimulation = GetActivitySimulationStatus()
+	d.SimulationMethods = append(d.SimulationMethods, darwinSimulationMethods()...)
+	return d
 }
 
 // NewKeepAlive creates a new platform specific keep alive instance
@@ -1,17 +1,191 @@
 package platform
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // KeepAlive defines the interface for platform-specific keep-alive functionality
 type KeepAlive interface {
 	Start(ctx context.Context) error
+	// Stop releases everything the keep-alive holds and waits until it is
+	// released.
 	Stop() error
+	// StopNow releases what can be released without waiting on other
+	// processes or services, for use when the program is about to exit.
+	StopNow()
 	SetSimulateActivity(simulate bool)
+	SetTimings(t Timings)
+	SetMouseShape(s MouseShape)
+}
+
+// AwayModeKeepAlive is implemented by keep-alives that can request away
+// mode, in which the display and audio turn off as if the machine were
+// asleep while background work continues.
+type AwayModeKeepAlive interface {
+	SetAwayMode(enabled bool)
+}
+
+// HibernationKeepAlive is implemented by keep-alives that can hold off
+// sleep while still letting the system hibernate, for example on a
+// critically low battery.
+type HibernationKeepAlive interface {
+	SetAllowHibernate(allow bool)
+}
+
+// ActivitySimulator is implemented by keep-alives whose activity
+// simulation can run apart from their sleep inhibition. In presence-only
+// mode, Start and Stop begin and end simulating presence and hold no
+// inhibitor, leaving sleep to the system's own settings.
+type ActivitySimulator interface {
+	// SetPresenceOnly selects presence-only mode. It applies from the next
+	// start.
+	SetPresenceOnly(presenceOnly bool)
+}
+
+// AssertionSelectingKeepAlive is implemented by keep-alives that can hold
+// a chosen set of power assertions, such as keeping the system awake while
+// letting the display sleep.
+type AssertionSelectingKeepAlive interface {
+	// SetAssertions selects the assertions held, or DefaultAssertions when
+	// assertions is empty. It applies from the next start.
+	SetAssertions(assertions []Assertion)
+}
+
+// ProcessBoundKeepAlive is implemented by keep-alives that can have the
+// operating system release their sleep prevention when a process exits, so
+// that it ends with the watched process even if keep-alive itself dies
+// without cleaning up.
+type ProcessBoundKeepAlive interface {
+	// SetWatchPID binds sleep prevention to the process pid, or unbinds it
+	// when pid is zero. It applies from the next start.
+	SetWatchPID(pid int)
+}
+
+// ReasonedKeepAlive is implemented by keep-alives that tell the system why
+// it is kept awake, for tools that list what holds off sleep.
+type ReasonedKeepAlive interface {
+	// SetReason sets the reason given, or the platform's default when
+	// reason is empty. It applies immediately to a running keep-alive.
+	SetReason(reason string)
+}
+
+// BudgetedKeepAlive is implemented by keep-alives whose activity simulation
+// can be capped by a SimulationBudget.
+type BudgetedKeepAlive interface {
+	SetSimulationBudget(budget *SimulationBudget)
+}
+
+// SimulatingKeepAlive is implemented by keep-alives that can run one
+// activity-simulation cycle on request.
+type SimulatingKeepAlive interface {
+	// SimulateOnce moves the pointer once right away, whether or not the
+	// user is idle and whether or not --active is set. The keep-alive must
+	// be running.
+	SimulateOnce() SimulationResult
+}
+
+// MethodSelectingKeepAlive is implemented by keep-alives that can simulate
+// activity through more than one input method. They rank the methods
+// themselves, moving on from one that starts failing, unless one is
+// selected.
+type MethodSelectingKeepAlive interface {
+	// SetSimulationMethod selects the input method used for activity
+	// simulation, or restores automatic ranking when method is "".
+	SetSimulationMethod(method string) error
+}
+
+// KeyTappingKeepAlive is implemented by keep-alives that can simulate
+// activity by tapping a key instead of moving the pointer, for remote
+// desktops that ignore injected pointer moves.
+type KeyTappingKeepAlive interface {
+	// SetSimulationKey taps key, one of SimulationKeys, instead of moving
+	// the pointer, or moves the pointer again when key is "". It applies
+	// immediately to a running keep-alive.
+	SetSimulationKey(key string) error
+}
+
+// ReportingKeepAlive is implemented by keep-alives that can report how they
+// are keeping the system awake.
+type ReportingKeepAlive interface {
+	// ActiveMethods lists the sleep-prevention methods in effect, in the
+	// order they were activated. It is empty when the keep-alive is not
+	// running.
+	ActiveMethods() []string
+}
+
+// HealthCheckingKeepAlive is implemented by keep-alives that can tell
+// whether they are still holding the system awake.
+type HealthCheckingKeepAlive interface {
+	// Healthy returns nil while the running keep-alive holds the system
+	// awake, and otherwise why it does not.
+	Healthy() error
+}
+
+// Observer receives notable events from a running keep-alive. Its methods
+// are called from the keep-alive's own goroutines, sometimes with its locks
+// held, so they must return quickly and must not call back into it.
+type Observer interface {
+	// InhibitorFailed reports that a sleep inhibitor stopped working. The
+	// keep-alive tries to restore it on its own.
+	InhibitorFailed(name string, err error)
+	// InhibitorRestored reports that a failed sleep inhibitor works again.
+	InhibitorRestored(name string)
+	// SimulationPerformed reports that method moved the pointer.
+	SimulationPerformed(method string)
+	// SimulationSkipped reports that activity simulation held off because
+	// the user was using the machine.
+	SimulationSkipped()
+}
+
+// SleepObserver is implemented by Observers that want to hear about the
+// system trying to sleep while a keep-alive runs.
+type SleepObserver interface {
+	// SleepAttempted reports that the system tried to sleep at t, and
+	// whether the keep-alive blocked it.
+	SleepAttempted(t time.Time, blocked bool)
+}
+
+// ObservableKeepAlive is implemented by keep-alives that report to an
+// Observer.
+type ObservableKeepAlive interface {
+	SetObserver(o Observer)
+}
+
+// SimulationResult describes one activity-simulation cycle run by
+// SimulateOnce.
+type SimulationResult struct {
+	// Method is the input backend that moved the pointer, or "" if none did.
+	Method string
+	// Tried lists the backends available for the cycle, in the order tried.
+	Tried []string
+	// Points and Duration describe the jitter pattern.
+	Points   int
+	Duration time.Duration
+	// Idle is the idle time read before the cycle, or IdleErr if it could
+	// not be read.
+	Idle    time.Duration
+	IdleErr error
+	// Err is set when the cycle did not move the pointer.
+	Err error
+}
+
+// AwayModeStatus describes whether away mode can be requested.
+type AwayModeStatus struct {
+	Available bool   `json:"available"`
+	Message   string `json:"message"`
+}
+
+// AllowHibernateStatus describes whether the system can be let hibernate
+// while sleep is held off.
+type AllowHibernateStatus struct {
+	Available bool   `json:"available"`
+	Message   string `json:"message"`
 }
 
 // ActivitySimulationStatus describes whether --active can emit real user input.
 type ActivitySimulationStatus struct {
-	Available bool
-	Method    string
-	Message   string
+	Available bool   `json:"available"`
+	Method    string `json:"method,omitempty"`
+	Message   string `json:"message"`
 }
@@ -7,12 +7,13 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"log"
+	"io"
+	"math"
 	"math/rand"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -20,6 +21,8 @@ import (
 	"syscall"
 	"time"
 	"unsafe"
+
+	"github.com/godbus/dbus/v5"
 )
 
 const (
@@ -36,16 +39,24 @@ const (
 	desktopMATE    = "mate"
 	desktopUnknown = "unknown"
 
+	// wlroots compositors
+	desktopSway     = "sway"
+	desktopHyprland = "hyprland"
+
 	// GNOME SessionManager inhibit flags
 	gnomeInhibitSuspend = 4  // Inhibit suspending the session
 	gnomeInhibitIdle    = 8  // Inhibit the session being marked as idle
 	gnomeInhibitBoth    = 12 // Inhibit both suspend and idle
 
+	// KDE PowerDevil PolicyAgent inhibition types
+	kdePolicyInterruptSession     = 1 // Inhibit suspend, hibernation and the other idle actions
+	kdePolicyChangeScreenSettings = 4 // Inhibit dimming and turning off the screen
+
 	// Health check and verification intervals
-	healthCheckInterval  = 30 * time.Second
-	inhibitorVerifyDelay = 100 * time.Millisecond
-	stopTimeout          = 2 * time.Second
-	idleProbeTimeout     = 2 * time.Second
+	healthCheckInterval = 30 * time.Second
+	stopTimeout         = 2 * time.Second
+	stopNowGrace        = 500 * time.Millisecond
+	idleProbeTimeout    = 2 * time.Second
 
 	// uinput constants
 	uinputDevicePath = "/dev/uinput"
@@ -85,7 +96,13 @@ func runVerbose(name string, args ...string) (string, error) {
 }
 
 func runVerboseTimeout(timeout time.Duration, name string, args ...string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	return runVerboseContext(context.Background(), timeout, name, args...)
+}
+
+// runVerboseContext is runVerboseTimeout for a command that also ends with
+// ctx.
+func runVerboseContext(ctx context.Context, timeout time.Duration, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, name, args...)
@@ -102,7 +119,7 @@ func runVerboseTimeout(timeout time.Duration, name string, args ...string) (stri
 // runBestEffort executes a command and logs any errors but does not return them (best-effort operation).
 func runBestEffort(name string, args ...string) {
 	if out, err := runVerbose(name, args...); err != nil {
-		log.Printf("linux: best-effort command %s %s failed: %v (output: %q)", name, strings.Join(args, " "), err, out)
+		logger().Debug("best-effort command failed", "command", name+" "+strings.Join(args, " "), "err", err, "output", out)
 	}
 }
 
@@ -144,6 +161,15 @@ func detectDesktopEnvironment() string {
 		return desktopMATE
 	}
 
+	// Check for wlroots compositors, whose IPC sockets identify them even
+	// when XDG_CURRENT_DESKTOP is unset
+	if strings.Contains(xdgDesktop, desktopHyprland) || os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") != "" {
+		return desktopHyprland
+	}
+	if strings.Contains(xdgDesktop, desktopSway) || os.Getenv("SWAYSOCK") != "" {
+		return desktopSway
+	}
+
 	return desktopUnknown
 }
 
@@ -175,19 +201,8 @@ func detectLinuxDistribution() (string, string, error) {
 	}
 	defer file.Close()
 
-	var id, idLike string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, "ID=") {
-			id = strings.Trim(strings.TrimPrefix(line, "ID="), "\"")
-		}
-		if strings.HasPrefix(line, "ID_LIKE=") {
-			idLike = strings.Trim(strings.TrimPrefix(line, "ID_LIKE="), "\"")
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
+	id, idLike, err := parseOSRelease(file)
+	if err != nil {
 		return "unknown", "", fmt.Errorf("failed to parse /etc/os-release: %v", err)
 	}
 
@@ -244,7 +259,7 @@ func getPackageName(tool string, distro string) string {
 	// distro parameter is kept for potential future distro-specific variations
 
 	switch tool {
-	case "ydotool", "xdotool", "wtype", "xprintidle":
+	case "ydotool", "wtype":
 		// Package names are consistent across distributions
 		return tool
 	default:
@@ -305,7 +320,7 @@ func checkMissingDependencies(caps linuxCapabilities, displayServer string, hasU
 
 	distro, pkgManager, err := detectLinuxDistribution()
 	if err != nil {
-		log.Printf("linux: failed to detect distribution: %v", err)
+		logger().Debug("distribution detection failed", "err", err)
 		distro = desktopUnknown
 		pkgManager = "unknown"
 	}
@@ -334,39 +349,6 @@ func checkMissingDependencies(caps linuxCapabilities, displayServer string, hasU
 		}
 	}
 
-	// Check xdotool (X11 only)
-	if displayServer == displayServerX11 && !caps.xdotoolAvailable {
-		installCmd, _ := generateInstallCommand("xdotool", distro, pkgManager)
-		whyNeeded := "Provides mouse simulation on X11 display server"
-		alt := "Not needed if using Wayland or if uinput/ydotool is configured"
-		if !hasUinput && !caps.ydotoolAvailable {
-			alt = "Alternative: Install ydotool (works on both X11 and Wayland) or setup uinput"
-		}
-		missing = append(missing, DependencyInfo{
-			Name:        "xdotool",
-			WhyNeeded:   whyNeeded,
-			InstallCmd:  installCmd,
-			Optional:    true,
-			Available:   true,
-			Alternative: alt,
-		})
-	}
-
-	// Check xprintidle (X11 only, optional but recommended for robust idle detection)
-	if displayServer == displayServerX11 && !caps.xprintidleAvailable {
-		installCmd, _ := generateInstallCommand("xprintidle", distro, pkgManager)
-		whyNeeded := "Provides reliable idle time detection on X11 for --active jitter behavior"
-		alt := "The app will fall back to DBus idle detection when available"
-		missing = append(missing, DependencyInfo{
-			Name:        "xprintidle",
-			WhyNeeded:   whyNeeded,
-			InstallCmd:  installCmd,
-			Optional:    true,
-			Available:   true,
-			Alternative: alt,
-		})
-	}
-
 	return missing
 }
 
@@ -411,6 +393,54 @@ func formatDependencyMessages(missing []DependencyInfo, displayServer string, ha
 	return b.String()
 }
 
+// maxOSReleaseValueLen bounds values read from /etc/os-release so a corrupt
+// file cannot produce unbounded distro names in logs and install hints.
+const maxOSReleaseValueLen = 256
+
+// parseOSRelease extracts ID and ID_LIKE from os-release(5) content. Values may
+// be unquoted, single-quoted or double-quoted; comments and malformed lines
+// are ignored. Only the characters used by real distribution IDs are kept.
+func parseOSRelease(r io.Reader) (id, idLike string, err error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "ID":
+			id = sanitizeOSReleaseValue(value)
+		case "ID_LIKE":
+			idLike = sanitizeOSReleaseValue(value)
+		}
+	}
+	return id, idLike, scanner.Err()
+}
+
+func sanitizeOSReleaseValue(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	value = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '.', r == '_', r == '-', r == ' ':
+			return r
+		}
+		return -1
+	}, value)
+	if len(value) > maxOSReleaseValueLen {
+		value = value[:maxOSReleaseValueLen]
+	}
+	return strings.TrimSpace(value)
+}
+
 // getInputGroupGID looks up the "input" group GID by parsing /etc/group.
 // Returns the GID if found, or -1 if not found or on error.
 func getInputGroupGID() int {
@@ -420,16 +450,24 @@ func getInputGroupGID() int {
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	return parseGroupGID(file, "input")
+}
+
+// parseGroupGID returns the GID of the named group from group(5) content, or
+// -1 if the group is missing or its entry is malformed.
+func parseGroupGID(r io.Reader, name string) int {
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
-		line := scanner.Text()
 		// /etc/group format: groupname:password:GID:userlist
-		parts := strings.Split(line, ":")
-		if len(parts) >= 3 && parts[0] == "input" {
-			if gid, err := strconv.Atoi(parts[2]); err == nil {
-				return gid
-			}
+		parts := strings.Split(scanner.Text(), ":")
+		if len(parts) < 3 || parts[0] != name {
+			continue
 		}
+		gid, err := strconv.ParseUint(strings.TrimSpace(parts[2]), 10, 31)
+		if err != nil {
+			continue
+		}
+		return int(gid)
 	}
 	return -1
 }
@@ -487,7 +525,7 @@ func (l *loginctlInhibitor) Activate(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("loginctl inhibit-sleep failed: %v", err)
 	}
-	log.Printf("linux: loginctl inhibit-sleep activated for pid %d", l.pid)
+	logger().Info("loginctl inhibit-sleep activated", "pid", l.pid)
 	return nil
 }
 func (l *loginctlInhibitor) Deactivate() error {
@@ -497,51 +535,7 @@ func (l *loginctlInhibitor) Deactivate() error {
 	// Note: loginctl doesn't have a direct way to remove inhibition
 	// The inhibition is automatically removed when the process exits
 	// We can try to use inhibit-sleep with a timeout, but for now we'll just log
-	log.Printf("linux: loginctl inhibition will be removed when process exits (pid %d)", l.pid)
-	return nil
-}
-
-// systemdInhibitor implements sleep prevention using systemd-inhibit.
-type systemdInhibitor struct {
-	cmd *exec.Cmd
-}
-
-func (s *systemdInhibitor) Name() string { return "systemd-inhibit" }
-func (s *systemdInhibitor) Activate(ctx context.Context) error {
-	if !hasCommand("systemd-inhibit") {
-		return fmt.Errorf("systemd-inhibit command not found")
-	}
-	// Use a Go-based blocking process instead of sleep infinity for better control
-	// Create a simple blocking script that waits for context cancellation
-	s.cmd = exec.CommandContext(ctx, "systemd-inhibit",
-		"--what=idle:sleep:handle-lid-switch:shutdown",
-		"--who=keep-alive",
-		"--why=User requested keep-alive",
-		"--mode=block",
-		"sh", "-c", "while true; do sleep 1; done")
-
-	if err := s.cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start systemd-inhibit: %v", err)
-	}
-
-	// Verify the process started successfully
-	if s.cmd.Process == nil {
-		return fmt.Errorf("systemd-inhibit process is nil after Start()")
-	}
-
-	// Give it a moment to start, then verify it's running
-	time.Sleep(inhibitorVerifyDelay)
-	if err := s.cmd.Process.Signal(syscall.Signal(0)); err != nil {
-		return fmt.Errorf("systemd-inhibit process verification failed: %v", err)
-	}
-
-	log.Printf("linux: systemd-inhibit started successfully (pid %d)", s.cmd.Process.Pid)
-	return nil
-}
-func (s *systemdInhibitor) Deactivate() error {
-	if s.cmd != nil && s.cmd.Process != nil {
-		return s.cmd.Process.Kill()
-	}
+	logger().Debug("loginctl inhibition ends when the process exits", "pid", l.pid)
 	return nil
 }
 
@@ -551,67 +545,97 @@ type dbusStrategy struct {
 	path   string
 	iface  string
 	method string
-	args   []string
+	args   []interface{}
 	cookie uint32
 }
 
-func (d *dbusStrategy) call(method string, args ...string) (string, error) {
-	if hasCommand("dbus-send") {
-		fullArgs := append([]string{"--print-reply", "--dest=" + d.dest, d.path, d.iface + "." + method}, args...)
-		return runVerbose("dbus-send", fullArgs...)
-	}
-	if hasCommand("gdbus") {
-		fullArgs := append([]string{"call", "--session", "--dest", d.dest, "--object-path", d.path, "--method", d.iface + "." + method}, args...)
-		return runVerbose("gdbus", fullArgs...)
-	}
-	return "", fmt.Errorf("no dbus client (dbus-send/gdbus) found")
+func (d *dbusStrategy) call(ctx context.Context, conn *dbus.Conn, method string, args ...interface{}) *dbus.Call {
+	ctx, cancel := context.WithTimeout(ctx, dbusCallTimeout)
+	defer cancel()
+	return conn.Object(d.dest, dbus.ObjectPath(d.path)).CallWithContext(ctx, d.iface+"."+method, 0, args...)
 }
 
-func (d *dbusStrategy) parseCookie(out string) (uint32, error) {
-	// Simple parsing for both dbus-send and gdbus output (returns a uint32)
-	parts := strings.Fields(out)
-	if len(parts) > 0 {
-		lastPart := strings.TrimRight(parts[len(parts)-1], ")")
-		if val, err := strconv.ParseUint(lastPart, 10, 32); err == nil {
-			return uint32(val), nil
-		}
+// millisToDuration converts an idle time in milliseconds, rejecting values
+// that are negative or too large to represent.
+func millisToDuration(millis int64) (time.Duration, error) {
+	if millis < 0 {
+		return 0, fmt.Errorf("negative idle time %d", millis)
 	}
-	return 0, fmt.Errorf("failed to parse cookie from: %q", out)
+	if millis > int64(math.MaxInt64/time.Millisecond) {
+		return 0, fmt.Errorf("idle time %d ms out of range", millis)
+	}
+	return time.Duration(millis) * time.Millisecond, nil
 }
 
-// dbusInhibitor implements sleep prevention using DBus calls.
+// dbusInhibitor implements sleep prevention using DBus calls. Desktop
+// services drop an inhibit when the connection that took it closes, so the
+// inhibitor keeps its own connection open while it is active.
 type dbusInhibitor struct {
 	dbusStrategy
 	name         string
 	unInhibitArg string
+
+	conn      *dbus.Conn
+	stopWatch func()
+	// released is set once the service that issued cookie leaves the bus,
+	// which voids the cookie.
+	released atomic.Bool
 }
 
 func (d *dbusInhibitor) Name() string { return d.name }
 func (d *dbusInhibitor) Activate(ctx context.Context) error {
-	out, err := d.call(d.method, d.args...)
+	d.close()
+	conn, err := connectSessionBus()
 	if err != nil {
-		return fmt.Errorf("dbus call failed: %v (output: %q)", err, out)
+		return fmt.Errorf("dbus call failed: %w", err)
 	}
-	cookie, err := d.parseCookie(out)
-	if err != nil {
-		return fmt.Errorf("failed to parse cookie from dbus response: %v (output: %q)", err, out)
+	var cookie uint32
+	if err := d.call(ctx, conn, d.method, d.args...).Store(&cookie); err != nil {
+		conn.Close()
+		return fmt.Errorf("dbus call failed: %w", err)
 	}
 	if cookie == 0 {
+		conn.Close()
 		return fmt.Errorf("received invalid cookie (0) from dbus inhibitor %s", d.name)
 	}
+	d.conn = conn
 	d.cookie = cookie
-	log.Printf("linux: dbus inhibitor %s activated with cookie %d", d.name, cookie)
+	d.released.Store(false)
+	stop, err := watchNameOwner(conn, d.dest, func() { d.released.Store(true) })
+	if err != nil {
+		logger().Debug("dbus inhibitor cannot follow its service", "inhibitor", d.name, "err", err)
+	} else {
+		d.stopWatch = stop
+	}
+	logger().Info("dbus inhibitor activated", "inhibitor", d.name, "cookie", cookie)
 	return nil
 }
 
 func (d *dbusInhibitor) Deactivate() error {
-	if d.cookie == 0 {
+	if d.conn == nil {
 		return nil
 	}
-	_, err := d.call(d.unInhibitArg, "uint32:"+strconv.FormatUint(uint64(d.cookie), 10))
+	var err error
+	if d.cookie != 0 && !d.released.Load() {
+		err = d.call(context.Background(), d.conn, d.unInhibitArg, d.cookie).Err
+	}
+	d.close()
 	return err
 }
 
+// close stops following the service and closes the connection, which
+// releases any inhibit still held through it.
+func (d *dbusInhibitor) close() {
+	if d.stopWatch != nil {
+		d.stopWatch()
+		d.stopWatch = nil
+	}
+	if d.conn != nil {
+		d.conn.Close()
+		d.conn = nil
+	}
+}
+
 // gsettingsInhibitor implements sleep prevention by modifying GNOME settings.
 type gsettingsInhibitor struct {
 	prevSettings map[string]string
@@ -642,7 +666,7 @@ func (g *gsettingsInhibitor) Activate(ctx context.Context) error {
 		// Set new value
 		if out, err := runVerbose("gsettings", "set", s.schema, s.key, s.value); err != nil {
 			failedSettings = append(failedSettings, fmt.Sprintf("%s.%s: %v", s.schema, s.key, err))
-			log.Printf("linux: gsettings set failed for %s.%s: %v (out: %q)", s.schema, s.key, err, out)
+			logger().Warn("gsettings set failed", "schema", s.schema, "key", s.key, "err", err, "output", out)
 		} else {
 			// Verify the setting was actually applied
 			if verifyOut, verifyErr := runVerbose("gsettings", "get", s.schema, s.key); verifyErr == nil {
@@ -650,7 +674,7 @@ func (g *gsettingsInhibitor) Activate(ctx context.Context) error {
 				expectedValue := strings.Trim(s.value, "'\"")
 				actualValue := strings.Trim(verifyOut, "'\" \n")
 				if actualValue != expectedValue && actualValue != s.value {
-					log.Printf("linux: warning: gsettings verification failed for %s.%s: expected %q, got %q", s.schema, s.key, s.value, verifyOut)
+					logger().Warn("gsettings verification failed", "schema", s.schema, "key", s.key, "want", s.value, "got", verifyOut)
 				}
 			}
 		}
@@ -658,7 +682,7 @@ func (g *gsettingsInhibitor) Activate(ctx context.Context) error {
 
 	if len(failedSettings) > 0 {
 		// Don't fail completely if some settings fail, but log warnings
-		log.Printf("linux: gsettings: some settings failed to apply: %v", failedSettings)
+		logger().Warn("some gsettings failed to apply", "settings", failedSettings)
 		// Only fail if all settings failed
 		if len(failedSettings) == len(settings) {
 			return fmt.Errorf("all gsettings failed to apply: %v", failedSettings)
@@ -693,60 +717,89 @@ func (x *xsetInhibitor) Deactivate() error {
 	return nil
 }
 
-// getLinuxIdleTime returns the system idle time on Linux using the best available method.
-// Priority: xprintidle (X11) -> GNOME Mutter IdleMonitor (gdbus) -> freedesktop ScreenSaver (dbus-send).
+// IdleTime returns how long the user has been idle.
+func IdleTime() (time.Duration, error) {
+	return getLinuxIdleTime()
+}
+
+// IdleSources reads the idle time with the first method that works, named
+// after that method; see readLinuxIdleTime.
+func IdleSources() []IdleSource {
+	idle, source, err := readLinuxIdleTime()
+	return []IdleSource{{Name: source, Idle: idle, Err: err}}
+}
+
+// getLinuxIdleTime returns the system idle time on Linux using the best
+// available method; see readLinuxIdleTime.
 func getLinuxIdleTime() (time.Duration, error) {
+	idle, _, err := readLinuxIdleTime()
+	return idle, err
+}
+
+// readLinuxIdleTime returns the idle time and the method that read it.
+// Priority: MIT-SCREEN-SAVER (X11) -> GNOME Mutter IdleMonitor ->
+// freedesktop ScreenSaver, both over D-Bus -> ext-idle-notify (Wayland).
+func readLinuxIdleTime() (time.Duration, string, error) {
 	displayServer := detectDisplayServer()
 
-	if displayServer == displayServerX11 && hasCommand("xprintidle") {
-		out, err := runVerboseTimeout(idleProbeTimeout, "xprintidle")
+	if displayServer == displayServerX11 {
+		idle, err := x11IdleTime()
 		if err == nil {
-			millis, parseErr := strconv.ParseInt(out, 10, 64)
-			if parseErr == nil {
-				return time.Duration(millis) * time.Millisecond, nil
-			}
+			return idle, "MIT-SCREEN-SAVER", nil
 		}
+		logger().Debug("X server idle time unavailable", "err", err)
 	}
 
-	if hasCommand("gdbus") {
-		out, err := runVerboseTimeout(
-			idleProbeTimeout,
-			"gdbus", "call", "--session",
-			"--dest", "org.gnome.Mutter.IdleMonitor",
-			"--object-path", "/org/gnome/Mutter/IdleMonitor/Core",
-			"--method", "org.gnome.Mutter.IdleMonitor.GetIdletime",
-		)
-		if err == nil {
-			re := regexp.MustCompile(`(\d+)`)
-			m := re.FindStringSubmatch(out)
-			if len(m) > 1 {
-				if millis, parseErr := strconv.ParseInt(m[1], 10, 64); parseErr == nil {
-					return time.Duration(millis) * time.Millisecond, nil
-				}
-			}
-		}
+	if idle, source, err := dbusSessionIdleTime(); err == nil {
+		return idle, source, nil
+	} else if displayServer != displayServerWayland {
+		return 0, "MIT-SCREEN-SAVER/D-Bus", fmt.Errorf("no supported idle detection method available: %w", err)
 	}
 
-	if hasCommand("dbus-send") {
-		out, err := runVerboseTimeout(
-			idleProbeTimeout,
-			"dbus-send", "--session", "--print-reply",
-			"--dest=org.freedesktop.ScreenSaver",
-			"/org/freedesktop/ScreenSaver",
-			"org.freedesktop.ScreenSaver.GetSessionIdleTime",
-		)
-		if err == nil {
-			re := regexp.MustCompile(`uint(?:32|64)\s+(\d+)`)
-			m := re.FindStringSubmatch(out)
-			if len(m) > 1 {
-				if millis, parseErr := strconv.ParseInt(m[1], 10, 64); parseErr == nil {
-					return time.Duration(millis) * time.Millisecond, nil
-				}
-			}
+	idle, err := waylandIdleTime()
+	if err != nil {
+		return 0, "D-Bus/ext-idle-notify", fmt.Errorf("no supported idle detection method available: %w", err)
+	}
+	return idle, "ext-idle-notify", nil
+}
+
+// dbusSessionIdleTime reads the idle time from the Mutter IdleMonitor or,
+// failing that, the freedesktop ScreenSaver on the session bus.
+func dbusSessionIdleTime() (time.Duration, string, error) {
+	conn, err := connectSessionBus()
+	if err != nil {
+		return 0, "", err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), idleProbeTimeout)
+	defer cancel()
+
+	var mutterIdle uint64
+	err = conn.Object("org.gnome.Mutter.IdleMonitor", "/org/gnome/Mutter/IdleMonitor/Core").
+		CallWithContext(ctx, "org.gnome.Mutter.IdleMonitor.GetIdletime", 0).Store(&mutterIdle)
+	if err == nil {
+		if idle, convErr := dbusIdleTime(mutterIdle); convErr == nil {
+			return idle, "Mutter IdleMonitor", nil
 		}
 	}
 
-	return 0, fmt.Errorf("no supported idle detection method available")
+	var screenSaverIdle uint32
+	err = conn.Object("org.freedesktop.ScreenSaver", "/org/freedesktop/ScreenSaver").
+		CallWithContext(ctx, "org.freedesktop.ScreenSaver.GetSessionIdleTime", 0).Store(&screenSaverIdle)
+	if err != nil {
+		return 0, "", fmt.Errorf("no idle monitor on the session bus: %w", err)
+	}
+	idle, err := dbusIdleTime(uint64(screenSaverIdle))
+	return idle, "freedesktop ScreenSaver", err
+}
+
+// dbusIdleTime converts an idle time in milliseconds returned by the Mutter
+// IdleMonitor or freedesktop ScreenSaver interfaces.
+func dbusIdleTime(millis uint64) (time.Duration, error) {
+	if millis > math.MaxInt64 {
+		return 0, fmt.Errorf("idle time %d ms out of range", millis)
+	}
+	return millisToDuration(int64(millis))
 }
 
 // uinputSimulator provides native Linux mouse simulation using the uinput kernel interface.
@@ -860,26 +913,13 @@ func (u *uinputSimulator) close() {
 	u.cleanup()
 }
 
-// DependencyInfo contains information about a missing dependency and how to install it.
-// This struct is used to provide user-friendly installation guidance.
-type DependencyInfo struct {
-	Name        string // Name of the dependency (e.g., "ydotool", "xdotool")
-	WhyNeeded   string // Explanation of why this dependency is needed
-	InstallCmd  string // Distro-specific installation command
-	Optional    bool   // Whether the dependency is optional (all dependencies are currently optional)
-	Available   bool   // Whether the package exists in default repositories
-	Alternative string // Alternative installation methods or workarounds
-}
-
 // linuxCapabilities tracks available tools and system information for the Linux platform.
 type linuxCapabilities struct {
-	xdotoolAvailable    bool
-	xprintidleAvailable bool
+	xtestAvailable      bool
 	uinputAvailable     bool
 	ydotoolAvailable    bool
 	wtypeAvailable      bool
-	gdbusAvailable      bool
-	dbusSendAvailable   bool
+	sessionBusAvailable bool
 	displayServer       string
 	desktopEnvironment  string
 }
@@ -896,6 +936,31 @@ type linuxKeepAlive struct {
 	inhibitors   []inhibitor
 	uinput       *uinputSimulator
 
+	// uinputRecovery reopens the uinput device after persistent failures.
+	uinputRecovery uinputRecovery
+
+	// jitterMu serializes jitter cycles, so the ticker and SimulateOnce never
+	// drive the movers at the same time.
+	jitterMu sync.Mutex
+
+	// simMethod pins activity simulation to one mover, or is "" to rank them
+	// automatically. It is guarded by mu.
+	simMethod string
+	// lastMethod is the mover that last worked, which automatic ranking
+	// tries first. It is guarded by jitterMu.
+	lastMethod string
+
+	// drift moves the pointer back when a pattern leaves it off its origin.
+	drift cursorDrift
+
+	// allowHibernate leaves logind's sleep lock out of the inhibitors
+	// activated at the next start. It is guarded by mu.
+	allowHibernate bool
+
+	// presenceOnly runs activity simulation without activating any
+	// inhibitor or asserting system activity. It is guarded by mu.
+	presenceOnly bool
+
 	simulateActivity atomic.Bool
 
 	// random source and pattern generator for natural mouse movements
@@ -905,21 +970,34 @@ type linuxKeepAlive struct {
 	// shared activity controller for idle-gated jitter
 	activityCtrl *ActivityController
 
+	// timings holds user overrides of the activity intervals.
+	timings Timings
+
+	// mouseShape selects the jitter pattern and size.
+	mouseShape MouseShape
+
+	// budget caps the jitters performed during the session.
+	budget *SimulationBudget
+
+	// observer is told about inhibitor failures and simulations.
+	observer observerSlot
+
 	lastActivityWarnNS int64
 }
 
 func detectLinuxCapabilities() linuxCapabilities {
 	displayServer := detectDisplayServer()
-	// xprintidle only works on X11, not Wayland
-	xprintidleAvailable := hasCommand("xprintidle") && displayServer == displayServerX11
+	// XTEST is only used on X11; under Xwayland it reaches X clients only.
+	var xtestAvailable bool
+	if displayServer == displayServerX11 {
+		xtestAvailable, _ = x11Support()
+	}
 	return linuxCapabilities{
-		xdotoolAvailable:    hasCommand("xdotool"),
-		xprintidleAvailable: xprintidleAvailable,
+		xtestAvailable:      xtestAvailable,
 		uinputAvailable:     true, // Will be tested during setup
 		ydotoolAvailable:    hasCommand("ydotool"),
 		wtypeAvailable:      hasCommand("wtype"),
-		gdbusAvailable:      hasCommand("gdbus"),
-		dbusSendAvailable:   hasCommand("dbus-send"),
+		sessionBusAvailable: sessionBusAvailable(),
 		displayServer:       displayServer,
 		desktopEnvironment:  detectDesktopEnvironment(),
 	}
@@ -971,6 +1049,114 @@ func readLinuxBatteryCapacities(root string) ([]int, error) {
 	return capacities, nil
 }
 
+// readLinuxPowerSource determines the power source from sysfs. An online mains
+// or USB supply means AC; a system with supplies that are all offline, or
+// with a discharging battery, is on battery. Machines without any battery are
+// treated as AC powered.
+func readLinuxPowerSource(root string) (PowerSource, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return PowerSourceUnknown, fmt.Errorf("failed to read power supply directory: %v", err)
+	}
+
+	readAttr := func(dir, name string) string {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(b))
+	}
+
+	var haveMains, mainsOnline, haveBattery, discharging bool
+	for _, entry := range entries {
+		// Entries are usually symlinks into /sys/devices, so do not filter on
+		// IsDir; a missing type file skips non-supply entries.
+		dir := filepath.Join(root, entry.Name())
+		switch readAttr(dir, "type") {
+		case "Mains", "USB", "USB_C", "USB_PD":
+			haveMains = true
+			if readAttr(dir, "online") == "1" {
+				mainsOnline = true
+			}
+		case "Battery":
+			if readAttr(dir, "scope") == "Device" {
+				// Peripheral batteries (mice, headsets) say nothing about the host.
+				continue
+			}
+			haveBattery = true
+			if readAttr(dir, "status") == "Discharging" {
+				discharging = true
+			}
+		}
+	}
+
+	switch {
+	case mainsOnline:
+		return PowerSourceAC, nil
+	case haveMains && haveBattery:
+		return PowerSourceBattery, nil
+	case discharging:
+		return PowerSourceBattery, nil
+	default:
+		return PowerSourceAC, nil
+	}
+}
+
+func GetPowerSource() (PowerSource, error) {
+	return readLinuxPowerSource("/sys/class/power_supply")
+}
+
+// parseBrightnessctlMachine extracts the percentage from brightnessctl's
+// machine-readable output ("device,class,current,percent%,max").
+func parseBrightnessctlMachine(out string) (int, error) {
+	line := strings.TrimSpace(strings.SplitN(strings.TrimSpace(out), "\n", 2)[0])
+	fields := strings.Split(line, ",")
+	if len(fields) < 5 {
+		return 0, fmt.Errorf("unexpected brightnessctl output: %q", line)
+	}
+	percent, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(fields[3]), "%"))
+	if err != nil || percent < 0 || percent > 100 {
+		return 0, fmt.Errorf("invalid brightness percentage in brightnessctl output: %q", line)
+	}
+	return percent, nil
+}
+
+// GetBrightness returns the backlight level in percent via brightnessctl.
+func GetBrightness() (int, error) {
+	if !hasCommand("brightnessctl") {
+		return 0, fmt.Errorf("brightnessctl command not found")
+	}
+	out, err := runVerboseTimeout(idleProbeTimeout, "brightnessctl", "-m", "-c", "backlight", "info")
+	if err != nil {
+		return 0, fmt.Errorf("brightnessctl failed: %v (output: %q)", err, out)
+	}
+	return parseBrightnessctlMachine(out)
+}
+
+// SetBrightness sets the backlight level in percent via brightnessctl.
+func SetBrightness(percent int) error {
+	if !hasCommand("brightnessctl") {
+		return fmt.Errorf("brightnessctl command not found")
+	}
+	out, err := runVerboseTimeout(idleProbeTimeout, "brightnessctl", "-q", "-c", "backlight", "set", fmt.Sprintf("%d%%", percent))
+	if err != nil {
+		return fmt.Errorf("brightnessctl failed: %v (output: %q)", err, out)
+	}
+	return nil
+}
+
+// Notify shows a desktop notification through notify-send.
+func Notify(title, body string) error {
+	if !hasCommand("notify-send") {
+		return fmt.Errorf("notify-send command not found")
+	}
+	out, err := runVerboseTimeout(idleProbeTimeout, "notify-send", "--app-name=Keep-Alive", "--", title, body)
+	if err != nil {
+		return fmt.Errorf("notify-send failed: %v (output: %q)", err, out)
+	}
+	return nil
+}
+
 func lowestBatteryCapacity(capacities []int) (int, error) {
 	if len(capacities) == 0 {
 		return 0, fmt.Errorf("no battery capacity available")
@@ -985,10 +1171,61 @@ func lowestBatteryCapacity(capacities []int) (int, error) {
 	return lowest, nil
 }
 
+// parseUPowerPercentage extracts the charge level from `upower -i` output.
+func parseUPowerPercentage(output string) (int, error) {
+	var value string
+	for _, line := range strings.Split(output, "\n") {
+		key, rest, found := strings.Cut(strings.TrimSpace(line), ":")
+		if !found {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "present":
+			if strings.TrimSpace(rest) == "no" {
+				return 0, fmt.Errorf("no battery present")
+			}
+		case "percentage":
+			value = strings.TrimSuffix(strings.TrimSpace(rest), "%")
+		}
+	}
+	if value == "" {
+		return 0, fmt.Errorf("battery percentage not found in upower output")
+	}
+
+	// UPower may report fractional percentages and uses the C locale, but
+	// accept a decimal comma in case the environment overrides it.
+	f, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse upower percentage %q: %v", value, err)
+	}
+	percentage := int(f + 0.5)
+	if percentage < 0 || percentage > 100 {
+		return 0, fmt.Errorf("battery percentage out of range: %d", percentage)
+	}
+	return percentage, nil
+}
+
+// readUPowerBatteryPercentage queries UPower's aggregated display device, used
+// when sysfs does not expose a battery (e.g. some virtual machines).
+func readUPowerBatteryPercentage() (int, error) {
+	if !hasCommand("upower") {
+		return 0, fmt.Errorf("upower command not found")
+	}
+	out, err := runVerboseTimeout(idleProbeTimeout, "upower", "-i", "/org/freedesktop/UPower/devices/DisplayDevice")
+	if err != nil {
+		return 0, fmt.Errorf("upower failed: %v (output: %q)", err, out)
+	}
+	return parseUPowerPercentage(out)
+}
+
 func GetBatteryStatus() (BatteryStatus, error) {
 	capacities, err := readLinuxBatteryCapacities("/sys/class/power_supply")
 	if err != nil {
-		return BatteryStatus{}, err
+		percentage, upowerErr := readUPowerBatteryPercentage()
+		if upowerErr != nil {
+			return BatteryStatus{}, fmt.Errorf("%v; upower fallback: %v", err, upowerErr)
+		}
+		return BatteryStatus{Percentage: percentage, Available: true}, nil
 	}
 
 	percentage, err := lowestBatteryCapacity(capacities)
@@ -1007,7 +1244,7 @@ func createGNOMESuspendInhibitor(name string) *dbusInhibitor {
 			path:   "/org/gnome/SessionManager",
 			iface:  "org.gnome.SessionManager",
 			method: "Inhibit",
-			args:   []string{"string:keep-alive", "uint32:0", "string:Prevent system suspend", fmt.Sprintf("uint32:%d", gnomeInhibitSuspend)},
+			args:   []interface{}{"keep-alive", uint32(0), "Prevent system suspend", uint32(gnomeInhibitSuspend)},
 		},
 		unInhibitArg: "Uninhibit",
 	}
@@ -1022,27 +1259,45 @@ func createGNOMEIdleInhibitor(name string) *dbusInhibitor {
 			path:   "/org/gnome/SessionManager",
 			iface:  "org.gnome.SessionManager",
 			method: "Inhibit",
-			args:   []string{"string:keep-alive", "uint32:0", "string:Prevent session idle", fmt.Sprintf("uint32:%d", gnomeInhibitIdle)},
+			args:   []interface{}{"keep-alive", uint32(0), "Prevent session idle", uint32(gnomeInhibitIdle)},
 		},
 		unInhibitArg: "Uninhibit",
 	}
 }
 
 // buildLinuxInhibitors builds a prioritized list of inhibitors based on detected desktop environment.
-// Priority: systemd-inhibit (always first) → DE-specific DBus → gsettings (GNOME-based) → xset (X11 only)
-func buildLinuxInhibitors() []inhibitor {
+// Priority: logind (always first) → Wayland idle-inhibit → DE-specific DBus → gsettings (GNOME-based) → xset (X11 only)
+// With allowHibernate, the inhibitors that take logind's sleep lock leave it out.
+func buildLinuxInhibitors(allowHibernate bool) []inhibitor {
 	de := detectDesktopEnvironment()
 	displayServer := detectDisplayServer()
 	inhibitors := []inhibitor{}
 
-	// Always try systemd-inhibit first (works on all systems)
-	inhibitors = append(inhibitors, &systemdInhibitor{})
+	// Always try logind first (works on all systemd systems)
+	inhibitors = append(inhibitors, &logindInhibitor{allowHibernate: allowHibernate})
 
 	// Add loginctl for Wayland (works better on Wayland than some other methods)
-	if displayServer == displayServerWayland && hasCommand("loginctl") {
+	if displayServer == displayServerWayland && hasCommand("loginctl") && !allowHibernate {
 		inhibitors = append(inhibitors, &loginctlInhibitor{})
 	}
 
+	// Wayland compositors' own idle inhibitor, for compositors that run no
+	// desktop D-Bus services
+	if displayServer == displayServerWayland {
+		inhibitors = append(inhibitors, &waylandIdleInhibitor{})
+	}
+
+	// A Flatpak or Snap sandbox hides the session manager and screensaver
+	// names and gives gsettings a settings file of its own, so the desktop
+	// portal stands in for the desktop inhibitors there.
+	if linuxSandbox() != "" {
+		inhibitors = append(inhibitors, &portalInhibitor{})
+		if displayServer == displayServerX11 {
+			inhibitors = append(inhibitors, &xsetInhibitor{})
+		}
+		return inhibitors
+	}
+
 	// Add DE-specific inhibitors based on detected desktop
 	switch de {
 	case desktopCosmic:
@@ -1058,6 +1313,20 @@ func buildLinuxInhibitors() []inhibitor {
 		inhibitors = append(inhibitors, createGNOMEIdleInhibitor("dbus-gnome-idle"))
 		inhibitors = append(inhibitors, &gsettingsInhibitor{})
 	case desktopKDE:
+		// PowerDevil's own policy agent holds off every Plasma power
+		// policy, including the screen dimming and turning off the
+		// freedesktop interface leaves in place.
+		inhibitors = append(inhibitors, &dbusInhibitor{
+			name: "dbus-kde-powerdevil",
+			dbusStrategy: dbusStrategy{
+				dest:   "org.kde.Solid.PowerManagement.PolicyAgent",
+				path:   "/org/kde/Solid/PowerManagement/PolicyAgent",
+				iface:  "org.kde.Solid.PowerManagement.PolicyAgent",
+				method: "AddInhibition",
+				args:   []interface{}{uint32(kdePolicyInterruptSession | kdePolicyChangeScreenSettings), "keep-alive", "Keep system awake"},
+			},
+			unInhibitArg: "ReleaseInhibition",
+		})
 		inhibitors = append(inhibitors, &dbusInhibitor{
 			name: "dbus-kde",
 			dbusStrategy: dbusStrategy{
@@ -1065,7 +1334,7 @@ func buildLinuxInhibitors() []inhibitor {
 				path:   "/org/freedesktop/PowerManagement/Inhibit",
 				iface:  "org.freedesktop.PowerManagement.Inhibit",
 				method: "Inhibit",
-				args:   []string{"string:keep-alive", "string:Keep system awake"},
+				args:   []interface{}{"keep-alive", "Keep system awake"},
 			},
 			unInhibitArg: "UnInhibit",
 		})
@@ -1077,10 +1346,14 @@ func buildLinuxInhibitors() []inhibitor {
 				path:   "/org/xfce/PowerManager",
 				iface:  "org.xfce.PowerManager",
 				method: "Inhibit",
-				args:   []string{"string:keep-alive", "string:Keep system awake"},
+				args:   []interface{}{"keep-alive", "Keep system awake"},
 			},
 			unInhibitArg: "UnInhibit",
 		})
+	case desktopSway:
+		inhibitors = append(inhibitors, &swayInhibitor{})
+	case desktopHyprland:
+		inhibitors = append(inhibitors, &hyprlandInhibitor{})
 	case desktopMATE:
 		inhibitors = append(inhibitors, &dbusInhibitor{
 			name: "dbus-mate",
@@ -1089,7 +1362,7 @@ func buildLinuxInhibitors() []inhibitor {
 				path:   "/org/mate/SessionManager",
 				iface:  "org.mate.SessionManager",
 				method: "Inhibit",
-				args:   []string{"string:keep-alive", "uint32:0", "string:Keep system awake", fmt.Sprintf("uint32:%d", gnomeInhibitBoth)},
+				args:   []interface{}{"keep-alive", uint32(0), "Keep system awake", uint32(gnomeInhibitBoth)},
 			},
 			unInhibitArg: "Uninhibit",
 		})
@@ -1103,7 +1376,7 @@ func buildLinuxInhibitors() []inhibitor {
 			path:   "/org/freedesktop/ScreenSaver",
 			iface:  "org.freedesktop.ScreenSaver",
 			method: "Inhibit",
-			args:   []string{"string:keep-alive", "string:Keep system awake"},
+			args:   []interface{}{"keep-alive", "Keep system awake"},
 		},
 		unInhibitArg: "UnInhibit",
 	})
@@ -1119,26 +1392,27 @@ func buildLinuxInhibitors() []inhibitor {
 // verifyInhibitorActivation verifies that an inhibitor was successfully activated.
 func (k *linuxKeepAlive) verifyInhibitorActivation(inh inhibitor) bool {
 	switch v := inh.(type) {
-	case *systemdInhibitor:
-		// Verify systemd-inhibit process is running
-		if v.cmd != nil && v.cmd.Process != nil {
-			err := v.cmd.Process.Signal(syscall.Signal(0))
-			if err == nil {
-				log.Printf("linux: verified systemd-inhibit process (pid %d) is running", v.cmd.Process.Pid)
-				return true
-			}
-			log.Printf("linux: warning: systemd-inhibit process verification failed: %v", err)
+	case *logindInhibitor:
+		// Verify the inhibitor descriptor was received
+		if v.held() {
+			logger().Debug("verified logind inhibitor locks are held")
+			return true
 		}
+		logger().Warn("logind inhibitor activated but no descriptor received")
 		return false
+	case *waylandIdleInhibitor:
+		return v.held()
+	case *portalInhibitor:
+		return v.held()
 	case *dbusInhibitor:
 		// Verify DBus cookie was received
 		if v.cookie != 0 {
-			log.Printf("linux: verified DBus inhibitor %s with cookie %d", v.name, v.cookie)
+			logger().Debug("verified dbus inhibitor", "inhibitor", v.name, "cookie", v.cookie)
 			return true
 		}
-		log.Printf("linux: warning: DBus inhibitor %s activated but no cookie received", v.name)
+		logger().Warn("dbus inhibitor activated but no cookie received", "inhibitor", v.name)
 		return false
-	case *loginctlInhibitor, *gsettingsInhibitor, *xsetInhibitor:
+	case *loginctlInhibitor, *gsettingsInhibitor, *xsetInhibitor, *swayInhibitor, *hyprlandInhibitor:
 		// These don't return verification tokens, but if Activate succeeded, it worked
 		return true
 	default:
@@ -1147,14 +1421,14 @@ func (k *linuxKeepAlive) verifyInhibitorActivation(inh inhibitor) bool {
 }
 
 func (k *linuxKeepAlive) activateInhibitors(ctx context.Context) (int, error) {
-	allInhibitors := buildLinuxInhibitors()
+	allInhibitors := buildLinuxInhibitors(k.allowHibernate)
 	activeCount := 0
 	var activationErrors []string
 
 	for _, inh := range allInhibitors {
 		err := inh.Activate(ctx)
 		if err != nil {
-			log.Printf("linux: inhibitor %s failed: %v", inh.Name(), err)
+			logger().Debug("inhibitor failed", "inhibitor", inh.Name(), "err", err)
 			activationErrors = append(activationErrors, fmt.Sprintf("%s: %v", inh.Name(), err))
 			continue
 		}
@@ -1162,13 +1436,13 @@ func (k *linuxKeepAlive) activateInhibitors(ctx context.Context) (int, error) {
 		// Verify activation based on inhibitor type
 		verified := k.verifyInhibitorActivation(inh)
 		if !verified {
-			log.Printf("linux: warning: inhibitor %s activated but verification failed", inh.Name())
+			logger().Warn("inhibitor activated but verification failed", "inhibitor", inh.Name())
 		}
 
 		// Still add to active list if activation succeeded
 		k.inhibitors = append(k.inhibitors, inh)
 		if verified {
-			log.Printf("linux: activated and verified inhibitor: %s", inh.Name())
+			logger().Info("inhibitor active", "inhibitor", inh.Name())
 		}
 		activeCount++
 	}
@@ -1181,32 +1455,32 @@ func (k *linuxKeepAlive) activateInhibitors(ctx context.Context) (int, error) {
 		return 0, fmt.Errorf("%s", errorMsg)
 	}
 
-	log.Printf("linux: successfully activated %d inhibitor(s) out of %d attempted", activeCount, len(allInhibitors))
+	logger().Info("inhibitors activated", "active", activeCount, "attempted", len(allInhibitors))
 	return activeCount, nil
 }
 
 func (k *linuxKeepAlive) setupUinput() {
 	hasAccess, errMsg := checkUinputPermissions()
 	if !hasAccess {
-		log.Printf("linux: uinput not available: %s", errMsg)
+		logger().Info("uinput not available", "reason", errMsg)
 		k.uinput = nil
 		return
 	}
 
 	k.uinput = &uinputSimulator{}
 	if err := k.uinput.setup(); err != nil {
-		log.Printf("linux: uinput setup failed: %v", err)
+		logger().Warn("uinput setup failed", "err", err)
 		if errMsg != "" {
-			log.Printf("linux: permission hint: %s", errMsg)
+			logger().Warn("uinput permission hint", "hint", errMsg)
 		}
 		k.uinput = nil
 		return
 	}
-	log.Printf("linux: native uinput mouse simulation activated")
+	logger().Info("uinput mouse simulation activated")
 }
 
 func (k *linuxKeepAlive) startActivityTickerLocked(ctx context.Context) {
-	ticker := time.NewTicker(ActivityInterval)
+	ticker := time.NewTicker(k.timings.WithDefaults().ActivityInterval)
 	k.activityTick = ticker
 	k.wg.Add(1)
 	go func() {
@@ -1229,8 +1503,7 @@ func (k *linuxKeepAlive) simulateSystemActivity() {
 	// This works on both X11 and Wayland and prevents system from going idle
 	// On Wayland, increase frequency by calling multiple times
 	displayServer := detectDisplayServer()
-	runBestEffort("dbus-send", "--dest=org.freedesktop.ScreenSaver", "/org/freedesktop/ScreenSaver", "org.freedesktop.ScreenSaver.SimulateUserActivity")
-	runBestEffort("dbus-send", "--dest=org.gnome.ScreenSaver", "/org/gnome/ScreenSaver", "org.gnome.ScreenSaver.SimulateUserActivity")
+	simulateUserActivity()
 
 	// On Wayland, also try additional methods for better reliability
 	if displayServer == displayServerWayland {
@@ -1259,31 +1532,29 @@ func (k *linuxKeepAlive) startInhibitorHealthCheck(ctx context.Context) {
 	}()
 }
 
-// reactivateInhibitor attempts to reactivate a failed inhibitor.
-func (k *linuxKeepAlive) reactivateInhibitor(inh inhibitor) {
+// reactivateInhibitor reports an inhibitor that failed with cause and
+// attempts to reactivate it.
+func (k *linuxKeepAlive) reactivateInhibitor(inh inhibitor, cause error) {
+	name := inh.Name()
+	k.observer.inhibitorFailed(name, cause)
 	if k.ctx == nil {
 		return
 	}
 
-	name := inh.Name()
-	log.Printf("linux: attempting to reactivate %s", name)
+	logger().Info("reactivating inhibitor", "inhibitor", name)
 	if err := inh.Activate(k.ctx); err != nil {
-		log.Printf("linux: error: failed to reactivate %s: %v", name, err)
+		logger().Error("inhibitor reactivation failed", "inhibitor", name, "err", err)
 		return
 	}
 
+	k.observer.inhibitorRestored(name)
+
 	// Log success with type-specific details
 	switch v := inh.(type) {
-	case *systemdInhibitor:
-		if v.cmd != nil && v.cmd.Process != nil {
-			log.Printf("linux: successfully reactivated %s (new pid %d)", name, v.cmd.Process.Pid)
-		} else {
-			log.Printf("linux: successfully reactivated %s", name)
-		}
 	case *dbusInhibitor:
-		log.Printf("linux: successfully reactivated %s (new cookie %d)", name, v.cookie)
+		logger().Info("inhibitor reactivated", "inhibitor", name, "cookie", v.cookie)
 	default:
-		log.Printf("linux: successfully reactivated %s", name)
+		logger().Info("inhibitor reactivated", "inhibitor", name)
 	}
 }
 
@@ -1297,24 +1568,32 @@ func (k *linuxKeepAlive) verifyInhibitors() {
 
 	for _, inh := range k.inhibitors {
 		switch v := inh.(type) {
-		case *systemdInhibitor:
-			// Verify systemd-inhibit process is still running
-			if v.cmd != nil && v.cmd.Process != nil {
-				if err := v.cmd.Process.Signal(syscall.Signal(0)); err != nil {
-					log.Printf("linux: warning: systemd-inhibit process (pid %d) is not running: %v", v.cmd.Process.Pid, err)
-					k.reactivateInhibitor(inh)
-				}
-			} else {
-				log.Printf("linux: warning: systemd-inhibit process is nil, attempting to reactivate")
-				k.reactivateInhibitor(inh)
+		case *logindInhibitor:
+			// Verify the inhibitor descriptor is still held
+			if !v.held() {
+				logger().Warn("logind inhibitor locks missing, reactivating")
+				k.reactivateInhibitor(inh, fmt.Errorf("logind inhibitor descriptor missing"))
+			}
+		case *waylandIdleInhibitor:
+			if !v.held() {
+				logger().Warn("Wayland idle inhibitor lost, reactivating")
+				k.reactivateInhibitor(inh, fmt.Errorf("the compositor dropped the idle inhibitor"))
+			}
+		case *portalInhibitor:
+			if !v.held() {
+				logger().Warn("desktop portal left the bus, reactivating")
+				k.reactivateInhibitor(inh, fmt.Errorf("%s left the session bus", portalBusName))
 			}
 		case *dbusInhibitor:
 			// Verify DBus cookie is still valid
 			if v.cookie == 0 {
-				log.Printf("linux: warning: DBus inhibitor %s has invalid cookie (0), attempting to reactivate", v.name)
-				k.reactivateInhibitor(inh)
+				logger().Warn("dbus inhibitor has no cookie, reactivating", "inhibitor", v.name)
+				k.reactivateInhibitor(inh, fmt.Errorf("no inhibit cookie"))
+			} else if v.released.Load() {
+				logger().Warn("dbus inhibitor's service left the bus, reactivating", "inhibitor", v.name, "service", v.dest)
+				k.reactivateInhibitor(inh, fmt.Errorf("%s left the session bus", v.dest))
 			}
-		case *gsettingsInhibitor, *xsetInhibitor:
+		case *gsettingsInhibitor, *xsetInhibitor, *swayInhibitor, *hyprlandInhibitor:
 			// These inhibitors are persistent until deactivated
 		}
 	}
@@ -1337,33 +1616,81 @@ func (k *linuxKeepAlive) startChatAppTickerLocked(ctx context.Context, caps linu
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				k.simulateChatAppActivity(caps)
+				k.simulateChatAppActivity(ctx, caps)
 			}
 		}
 	}()
 }
 
-func (k *linuxKeepAlive) simulateChatAppActivity(caps linuxCapabilities) {
+func (k *linuxKeepAlive) simulateChatAppActivity(ctx context.Context, caps linuxCapabilities) {
 	if !k.simulateActivity.Load() {
 		return
 	}
 
+	k.jitterMu.Lock()
+	defer k.jitterMu.Unlock()
 	k.activityCtrl.MaybeJitter(
 		getLinuxIdleTime,
 		func(points []MousePoint, sessionDuration time.Duration) {
-			k.executeMousePattern(points, caps, sessionDuration)
+			if method := k.executeMousePattern(ctx, points, caps, sessionDuration); method != "" {
+				k.observer.simulationPerformed(method)
+			}
 		},
 	)
 }
 
+// SimulateOnce runs one jitter cycle immediately through the first mover that
+// works.
+func (k *linuxKeepAlive) SimulateOnce() SimulationResult {
+	k.mu.Lock()
+	running := k.isRunning
+	ctx := k.ctx
+	hasUinput := k.uinput != nil
+	pinned := k.simMethod
+	k.mu.Unlock()
+	if !running {
+		return SimulationResult{Err: fmt.Errorf("keep-alive is not running")}
+	}
+
+	caps := detectLinuxCapabilities()
+	caps.uinputAvailable = hasUinput
+	var res SimulationResult
+	res.Idle, res.IdleErr = getLinuxIdleTime()
+
+	k.jitterMu.Lock()
+	defer k.jitterMu.Unlock()
+	available := linuxSimulationMethods(caps, hasUinput)
+	res.Tried = []string{}
+	for _, m := range k.rankMovers(pinned) {
+		if slices.Contains(available, m) {
+			res.Tried = append(res.Tried, m)
+		}
+	}
+	res.Points, res.Duration = k.activityCtrl.Fire(func(points []MousePoint, sessionDuration time.Duration) {
+		res.Method = k.executeMousePattern(ctx, points, caps, sessionDuration)
+	})
+	switch {
+	case res.Method != "":
+		k.observer.simulationPerformed(res.Method)
+	case len(res.Tried) == 0 && pinned != "":
+		res.Err = fmt.Errorf("the selected method, %s, is not available", pinned)
+	case len(res.Tried) == 0:
+		res.Err = fmt.Errorf("no input method available: %s", strings.TrimSpace(linuxActivitySimulationStatus(caps, hasUinput).Message))
+	default:
+		res.Err = fmt.Errorf("every input method failed; see the log for details")
+	}
+	return res
+}
+
 // mouseMover defines an interface for executing mouse movements.
 type mouseMover interface {
 	move(dx, dy int) error
 	name() string
 }
 
-// executePatternCommon executes a mouse pattern using the provided mover.
-func (k *linuxKeepAlive) executePatternCommon(points []MousePoint, mover mouseMover, sessionDuration time.Duration) bool {
+// executePatternCommon executes a mouse pattern using the provided mover,
+// stopping early once ctx, the session's, is cancelled.
+func (k *linuxKeepAlive) executePatternCommon(ctx context.Context, points []MousePoint, mover mouseMover, sessionDuration time.Duration) bool {
 	if mover == nil {
 		return false
 	}
@@ -1378,7 +1705,7 @@ func (k *linuxKeepAlive) executePatternCommon(points []MousePoint, mover mouseMo
 
 	for _, pt := range points {
 		select {
-		case <-k.ctx.Done():
+		case <-ctx.Done():
 			if currentX != 0 || currentY != 0 {
 				_ = mover.move(-currentX, -currentY)
 			}
@@ -1389,7 +1716,7 @@ func (k *linuxKeepAlive) executePatternCommon(points []MousePoint, mover mouseMo
 		dx, dy, targetX, targetY := relativeStepToPoint(currentX, currentY, pt)
 		if dx != 0 || dy != 0 {
 			if err := mover.move(dx, dy); err != nil {
-				log.Printf("linux: %s move failed: %v", mover.name(), err)
+				logger().Warn("mouse move failed", "method", mover.name(), "err", err)
 				return false
 			}
 			currentX = targetX
@@ -1402,7 +1729,7 @@ func (k *linuxKeepAlive) executePatternCommon(points []MousePoint, mover mouseMo
 	// Return to origin
 	if currentX != 0 || currentY != 0 {
 		if err := mover.move(-currentX, -currentY); err != nil {
-			log.Printf("linux: %s move failed: %v", mover.name(), err)
+			logger().Warn("mouse move failed", "method", mover.name(), "err", err)
 			return false
 		}
 	}
@@ -1410,34 +1737,142 @@ func (k *linuxKeepAlive) executePatternCommon(points []MousePoint, mover mouseMo
 	return true
 }
 
-func (k *linuxKeepAlive) executeMousePattern(points []MousePoint, caps linuxCapabilities, sessionDuration time.Duration) {
-	// Execute pattern using available methods based on display server
-	// Priority: uinput → ydotool → xdotool (X11 only).
-	// These backends emit real pointer input. DBus idle resets are intentionally
-	// excluded from --active because chat apps may not treat them as user input.
+// linuxMovers are the mouse movers in their default order of preference.
+// These backends emit real pointer input. DBus idle resets are intentionally
+// excluded from --active because chat apps may not treat them as user input.
+var linuxMovers = []string{"uinput", "ydotool", "xtest"}
 
-	// Try uinput first (works on both X11 and Wayland if permissions allow)
-	if k.uinput != nil {
-		if k.executePatternUinput(points, sessionDuration) {
-			return
+// executeMousePattern returns the name of the mover that ran the pattern, or
+// "" if none did. Callers hold jitterMu.
+func (k *linuxKeepAlive) executeMousePattern(ctx context.Context, points []MousePoint, caps linuxCapabilities, sessionDuration time.Duration) string {
+	k.mu.Lock()
+	pinned := k.simMethod
+	k.mu.Unlock()
+
+	for _, method := range k.rankMovers(pinned) {
+		if !k.executePatternWith(ctx, method, points, caps, sessionDuration) {
+			continue
+		}
+		if k.lastMethod != "" && k.lastMethod != method {
+			logger().Info("activity simulation method switched", "from", k.lastMethod, "to", method)
 		}
+		k.lastMethod = method
+		return method
 	}
 
-	// Try ydotool (works on both X11 and Wayland)
-	if caps.ydotoolAvailable {
-		if k.executePatternYdotool(points, sessionDuration) {
-			return
+	k.warnActivityUnavailable(caps)
+	return ""
+}
+
+// rankMovers returns the movers to try in order: only pinned when it is set,
+// otherwise the one that last worked followed by the rest in their default
+// order, so a mover that starts failing is passed over for one that works.
+// Callers hold jitterMu.
+func (k *linuxKeepAlive) rankMovers(pinned string) []string {
+	if pinned != "" {
+		return []string{pinned}
+	}
+	ranked := make([]string, 0, len(linuxMovers))
+	if k.lastMethod != "" {
+		ranked = append(ranked, k.lastMethod)
+	}
+	for _, m := range linuxMovers {
+		if m != k.lastMethod {
+			ranked = append(ranked, m)
 		}
 	}
+	return ranked
+}
 
-	// Try xdotool (X11 only)
-	if caps.displayServer == displayServerX11 && caps.xdotoolAvailable {
-		if k.executePatternXdotool(points, sessionDuration) {
-			return
+// executePatternWith runs the pattern through the named mover, reporting
+// false when it is unavailable or fails. On X11, the pointer is checked
+// afterwards and moved back if it has drifted from its origin.
+func (k *linuxKeepAlive) executePatternWith(ctx context.Context, method string, points []MousePoint, caps linuxCapabilities, sessionDuration time.Duration) bool {
+	var loc cursorLocator
+	var origin cursorPos
+	if caps.displayServer == displayServerX11 {
+		if conn, err := dialX11(); err == nil {
+			defer conn.close()
+			if origin, err = conn.pointer(); err == nil {
+				loc = x11Locator{conn: conn}
+			}
 		}
 	}
 
-	k.warnActivityUnavailable(caps)
+	if !k.runPatternWith(ctx, method, points, caps, sessionDuration) {
+		return false
+	}
+	if loc != nil {
+		k.drift.restore(loc, origin)
+	}
+	return true
+}
+
+// runPatternWith runs the pattern through the named mover, reporting false
+// when it is unavailable or fails.
+func (k *linuxKeepAlive) runPatternWith(ctx context.Context, method string, points []MousePoint, caps linuxCapabilities, sessionDuration time.Duration) bool {
+	switch method {
+	case "uinput":
+		// Works on both X11 and Wayland if permissions allow. While a
+		// broken device is being recovered, the other movers take over.
+		k.mu.Lock()
+		usable := k.uinput != nil || k.uinputRecovery.broken
+		k.mu.Unlock()
+		if usable {
+			return k.executePatternUinputRecovering(ctx, points, sessionDuration)
+		}
+	case "ydotool":
+		// Works on both X11 and Wayland.
+		if caps.ydotoolAvailable {
+			return k.executePatternYdotool(ctx, points, sessionDuration)
+		}
+	case "xtest":
+		if caps.displayServer == displayServerX11 && caps.xtestAvailable {
+			return k.executePatternXTest(ctx, points, sessionDuration)
+		}
+	}
+	return false
+}
+
+// linuxSimulationMethods lists the movers that are available, in their
+// default order.
+func linuxSimulationMethods(caps linuxCapabilities, hasUinput bool) []string {
+	methods := []string{}
+	if hasUinput {
+		methods = append(methods, "uinput")
+	}
+	if caps.ydotoolAvailable {
+		methods = append(methods, "ydotool")
+	}
+	if caps.xtestAvailable && caps.displayServer == displayServerX11 {
+		methods = append(methods, "xtest")
+	}
+	return methods
+}
+
+// SetSimulationMethod pins activity simulation to the mover named method,
+// one of "uinput", "ydotool" and "xtest", or ranks the movers
+// automatically again when method is "". While running, a mover that is not
+// available is refused.
+func (k *linuxKeepAlive) SetSimulationMethod(method string) error {
+	if method == "xdotool" {
+		// XTEST is what xdotool moved the pointer through.
+		method = "xtest"
+	}
+	if method != "" && !slices.Contains(linuxMovers, method) {
+		return fmt.Errorf("unknown simulation method %q; choose from %s or auto", method, strings.Join(linuxMovers, ", "))
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if method != "" && k.isRunning {
+		available := linuxSimulationMethods(detectLinuxCapabilities(), k.uinput != nil)
+		if !slices.Contains(available, method) {
+			return fmt.Errorf("%s is not available on this system", method)
+		}
+	}
+	k.simMethod = method
+	return nil
 }
 
 func (k *linuxKeepAlive) warnActivityUnavailable(caps linuxCapabilities) {
@@ -1449,7 +1884,7 @@ func (k *linuxKeepAlive) warnActivityUnavailable(caps linuxCapabilities) {
 	atomic.StoreInt64(&k.lastActivityWarnNS, nowNS)
 
 	status := linuxActivitySimulationStatus(caps, k.uinput != nil)
-	log.Printf("linux: %s", status.Message)
+	logger().Warn("activity simulation unavailable", "reason", status.Message)
 }
 
 // uinputMover implements mouseMover for uinput.
@@ -1465,12 +1900,15 @@ func (u *uinputMover) name() string {
 	return "uinput"
 }
 
-func (k *linuxKeepAlive) executePatternUinput(points []MousePoint, sessionDuration time.Duration) bool {
-	if k.uinput == nil {
+func (k *linuxKeepAlive) executePatternUinput(ctx context.Context, points []MousePoint, sessionDuration time.Duration) bool {
+	k.mu.Lock()
+	sim := k.uinput
+	k.mu.Unlock()
+	if sim == nil {
 		return false
 	}
-	mover := &uinputMover{sim: k.uinput}
-	return k.executePatternCommon(points, mover, sessionDuration)
+	mover := &uinputMover{sim: sim}
+	return k.executePatternCommon(ctx, points, mover, sessionDuration)
 }
 
 // commandMover implements mouseMover for command-line tools.
@@ -1489,21 +1927,25 @@ func (c *commandMover) name() string {
 	return c.cmd
 }
 
-func (k *linuxKeepAlive) executePatternXdotool(points []MousePoint, sessionDuration time.Duration) bool {
-	mover := &commandMover{
-		cmd:  "xdotool",
-		args: []string{"mousemove_relative", "--"},
+// executePatternXTest runs the pattern through the X server's XTEST
+// extension.
+func (k *linuxKeepAlive) executePatternXTest(ctx context.Context, points []MousePoint, sessionDuration time.Duration) bool {
+	conn, err := dialX11()
+	if err != nil {
+		logger().Debug("X server unreachable for XTEST", "err", err)
+		return false
 	}
-	return k.executePatternCommon(points, mover, sessionDuration)
+	defer conn.close()
+	return k.executePatternCommon(ctx, points, &xtestMover{conn: conn}, sessionDuration)
 }
 
 // executePatternYdotool executes mouse pattern using ydotool (works on both X11 and Wayland).
-func (k *linuxKeepAlive) executePatternYdotool(points []MousePoint, sessionDuration time.Duration) bool {
+func (k *linuxKeepAlive) executePatternYdotool(ctx context.Context, points []MousePoint, sessionDuration time.Duration) bool {
 	mover := &commandMover{
 		cmd:  "ydotool",
 		args: []string{"mousemove", "--"},
 	}
-	return k.executePatternCommon(points, mover, sessionDuration)
+	return k.executePatternCommon(ctx, points, mover, sessionDuration)
 }
 
 func (k *linuxKeepAlive) Start(ctx context.Context) error {
@@ -1519,29 +1961,39 @@ func (k *linuxKeepAlive) Start(ctx context.Context) error {
 	// Initialize random source and pattern generator
 	k.rnd = newCryptoSeededRand()
 	k.patternGen = NewMousePatternGenerator(k.rnd)
+	k.patternGen.SetShape(k.mouseShape)
 	k.activityCtrl = NewActivityController("linux", k.patternGen)
+	k.activityCtrl.setObserver(&k.observer)
+	k.activityCtrl.SetTimings(k.timings)
+	k.activityCtrl.SetBudget(k.budget)
 
 	// Detect capabilities and log diagnostics
 	caps := detectLinuxCapabilities()
-	log.Printf("linux: === Startup Diagnostics ===")
-	log.Printf("linux: Desktop Environment: %s", caps.desktopEnvironment)
-	log.Printf("linux: Display Server: %s", caps.displayServer)
-	log.Printf("linux: Available tools: xdotool=%v, ydotool=%v, wtype=%v, xprintidle=%v, gdbus=%v, dbus-send=%v",
-		caps.xdotoolAvailable, caps.ydotoolAvailable, caps.wtypeAvailable, caps.xprintidleAvailable, caps.gdbusAvailable, caps.dbusSendAvailable)
+	logger().Debug("startup diagnostics",
+		"desktop", caps.desktopEnvironment,
+		"display_server", caps.displayServer,
+		"xtest", caps.xtestAvailable,
+		"ydotool", caps.ydotoolAvailable,
+		"wtype", caps.wtypeAvailable,
+		"session_bus", caps.sessionBusAvailable)
 
 	// Check uinput permissions and log status
 	hasUinputAccess, uinputErrMsg := checkUinputPermissions()
-	log.Printf("linux: uinput access: %v", hasUinputAccess)
+	logger().Debug("uinput access", "ok", hasUinputAccess)
 	if !hasUinputAccess && uinputErrMsg != "" {
-		log.Printf("linux: uinput permission issue: %s", uinputErrMsg)
+		logger().Debug("uinput permission issue", "reason", uinputErrMsg)
 	}
 
 	// Activate inhibitors
-	activeCount, err := k.activateInhibitors(k.ctx)
+	activeCount := 0
+	var err error
+	if !k.presenceOnly {
+		activeCount, err = k.activateInhibitors(k.ctx)
+	}
 	if err != nil {
 		k.cancel()
 		// Enhance error message with suggestions
-		enhancedErr := fmt.Errorf("%v\n\nTroubleshooting:\n- Ensure systemd-inhibit is available: which systemd-inhibit\n- Check DBus services: dbus-send --session --print-reply --dest=org.freedesktop.DBus /org/freedesktop/DBus org.freedesktop.DBus.ListNames\n- For Cosmic/GNOME: ensure org.gnome.SessionManager is available", err)
+		enhancedErr := fmt.Errorf("%v\n\nTroubleshooting:\n- Ensure logind is running: busctl status org.freedesktop.login1\n- Check DBus services: busctl --user list\n- For Cosmic/GNOME: ensure org.gnome.SessionManager is available", err)
 		return enhancedErr
 	}
 
@@ -1551,48 +2003,42 @@ func (k *linuxKeepAlive) Start(ctx context.Context) error {
 	hasUinput := k.uinput != nil
 	if k.uinput != nil {
 		caps.uinputAvailable = true
-		log.Printf("linux: uinput mouse simulation: enabled")
+		logger().Debug("uinput mouse simulation enabled")
 	} else {
-		log.Printf("linux: uinput mouse simulation: disabled (permissions or unavailable)")
+		logger().Debug("uinput mouse simulation disabled")
 	}
 
 	activeStatus := linuxActivitySimulationStatus(caps, hasUinput)
 	if k.simulateActivity.Load() && !activeStatus.Available {
-		log.Printf("linux: %s", activeStatus.Message)
+		logger().Warn("activity simulation unavailable", "reason", activeStatus.Message)
 	}
 
 	// Check for missing dependencies and log messages
 	missingDeps := checkMissingDependencies(caps, caps.displayServer, hasUinput)
 	if len(missingDeps) > 0 {
 		depMessage := formatDependencyMessages(missingDeps, caps.displayServer, hasUinput)
-		log.Printf("linux: missing dependencies detected:\n%s", depMessage)
+		logger().Warn("missing dependencies", "details", depMessage)
 	}
 
 	// Log mouse simulation capabilities
-	mouseMethods := []string{}
-	if k.uinput != nil {
-		mouseMethods = append(mouseMethods, "uinput")
-	}
-	if caps.ydotoolAvailable {
-		mouseMethods = append(mouseMethods, "ydotool")
-	}
-	if caps.xdotoolAvailable && caps.displayServer == displayServerX11 {
-		mouseMethods = append(mouseMethods, "xdotool")
-	}
+	mouseMethods := linuxSimulationMethods(caps, k.uinput != nil)
 	if len(mouseMethods) == 0 {
-		log.Printf("linux: warning: no mouse simulation methods available")
+		logger().Warn("no mouse simulation methods available")
 	} else {
-		log.Printf("linux: mouse simulation methods: %s", strings.Join(mouseMethods, ", "))
+		logger().Debug("mouse simulation methods", "methods", strings.Join(mouseMethods, ", "))
 	}
 
-	log.Printf("linux: === End Diagnostics ===")
-	log.Printf("linux: started successfully; active inhibitors: %d", activeCount)
+	if k.presenceOnly {
+		logger().Info("keep-alive started", "mode", "presence-only")
+	} else {
+		logger().Info("keep-alive started", "inhibitors", activeCount)
 
-	// Start periodic inhibitor health checks
-	k.startInhibitorHealthCheck(k.ctx)
+		// Start periodic inhibitor health checks
+		k.startInhibitorHealthCheck(k.ctx)
 
-	// Start system-level activity ticker to maintain keep-alive
-	k.startActivityTickerLocked(k.ctx)
+		// Start system-level activity ticker to maintain keep-alive
+		k.startActivityTickerLocked(k.ctx)
+	}
 
 	// Start chat app activity ticker if enabled
 	k.startChatAppTickerLocked(k.ctx, caps)
@@ -1638,19 +2084,19 @@ func (k *linuxKeepAlive) Stop() error {
 
 	select {
 	case <-done:
-		log.Printf("linux: all goroutines completed")
+		logger().Debug("all goroutines completed")
 	case <-time.After(stopTimeout):
-		log.Printf("linux: warning: some goroutines did not complete within timeout")
+		logger().Warn("some goroutines did not complete within timeout")
 	}
 
 	// Deactivate inhibitors (best effort - continue even if some fail)
 	for i := len(inhibitors) - 1; i >= 0; i-- {
 		inh := inhibitors[i]
 		if err := inh.Deactivate(); err != nil {
-			log.Printf("linux: error deactivating inhibitor %s: %v", inh.Name(), err)
+			logger().Error("inhibitor deactivation failed", "inhibitor", inh.Name(), "err", err)
 			deactivateErrors = append(deactivateErrors, err)
 		} else {
-			log.Printf("linux: deactivated inhibitor %s", inh.Name())
+			logger().Debug("inhibitor deactivated", "inhibitor", inh.Name())
 		}
 	}
 
@@ -1660,8 +2106,9 @@ func (k *linuxKeepAlive) Stop() error {
 	if k.uinput != nil {
 		k.uinput.close()
 		k.uinput = nil
-		log.Printf("linux: uinput device closed")
+		logger().Debug("uinput device closed")
 	}
+	k.uinputRecovery = uinputRecovery{}
 
 	k.inhibitors = nil
 	k.isRunning = false
@@ -1674,14 +2121,193 @@ func (k *linuxKeepAlive) Stop() error {
 	k.mu.Unlock()
 
 	if len(deactivateErrors) > 0 {
-		log.Printf("linux: stopped with %d inhibitor deactivation errors", len(deactivateErrors))
+		logger().Warn("stopped with inhibitor deactivation errors", "errors", len(deactivateErrors))
 		return fmt.Errorf("linux: %d inhibitors failed to deactivate", len(deactivateErrors))
 	}
 
-	log.Printf("linux: stopped; cleanup complete")
+	logger().Info("keep-alive stopped")
 	return nil
 }
 
+// Healthy reports whether at least one inhibitor still holds: the logind
+// inhibitor descriptor is open, the compositor keeps the Wayland idle
+// inhibitor, the desktop portal keeps its inhibit or a D-Bus inhibitor has
+// its cookie.
+// Inhibitors without a handle to check count as holding. In presence-only
+// mode there is nothing to hold, so a running keep-alive is healthy.
+func (k *linuxKeepAlive) Healthy() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !k.isRunning {
+		return fmt.Errorf("keep-alive is not running")
+	}
+	if k.presenceOnly {
+		return nil
+	}
+	for _, inh := range k.inhibitors {
+		switch v := inh.(type) {
+		case *logindInhibitor:
+			if v.held() {
+				return nil
+			}
+		case *waylandIdleInhibitor:
+			if v.held() {
+				return nil
+			}
+		case *portalInhibitor:
+			if v.held() {
+				return nil
+			}
+		case *dbusInhibitor:
+			if v.cookie != 0 && !v.released.Load() {
+				return nil
+			}
+		default:
+			return nil
+		}
+	}
+	return fmt.Errorf("no inhibitor is active")
+}
+
+// ActiveMethods lists the inhibitors that activated, in activation order.
+func (k *linuxKeepAlive) ActiveMethods() []string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	methods := make([]string, 0, len(k.inhibitors))
+	for _, inh := range k.inhibitors {
+		methods = append(methods, inh.Name())
+	}
+	return methods
+}
+
+// StopNow cancels the session and gives the inhibitors that need a D-Bus or
+// gsettings call stopNowGrace to be released so that a hung bus cannot hold
+// up exit. Whatever is still pending after that is abandoned; the logind
+// locks go with the process in any case.
+func (k *linuxKeepAlive) StopNow() {
+	k.mu.Lock()
+	if !k.isRunning {
+		k.mu.Unlock()
+		return
+	}
+
+	if k.cancel != nil {
+		k.cancel()
+	}
+	if k.activityTick != nil {
+		k.activityTick.Stop()
+		k.activityTick = nil
+	}
+	if k.chatAppTick != nil {
+		k.chatAppTick.Stop()
+		k.chatAppTick = nil
+	}
+
+	inhibitors := make([]inhibitor, len(k.inhibitors))
+	copy(inhibitors, k.inhibitors)
+	uinput := k.uinput
+
+	k.inhibitors = nil
+	k.isRunning = false
+	k.ctx = nil
+	k.cancel = nil
+	if k.activityCtrl != nil {
+		k.activityCtrl.Reset()
+	}
+	atomic.StoreInt64(&k.lastActivityWarnNS, 0)
+	k.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		// The jitter goroutine may still be using the uinput device.
+		k.wg.Wait()
+		if uinput != nil {
+			k.mu.Lock()
+			if k.uinput == uinput {
+				k.uinput = nil
+				k.uinputRecovery = uinputRecovery{}
+			}
+			k.mu.Unlock()
+			uinput.close()
+		}
+
+		for i := len(inhibitors) - 1; i >= 0; i-- {
+			if err := inhibitors[i].Deactivate(); err != nil {
+				logger().Error("inhibitor deactivation failed", "inhibitor", inhibitors[i].Name(), "err", err)
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+		logger().Info("keep-alive stopped immediately")
+	case <-time.After(stopNowGrace):
+		logger().Warn("keep-alive stopped without waiting for every inhibitor", "grace", stopNowGrace)
+	}
+}
+
+// SetSimulationBudget caps the jitters performed, including for a running
+// session.
+func (k *linuxKeepAlive) SetSimulationBudget(budget *SimulationBudget) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.budget = budget
+	if k.activityCtrl != nil {
+		k.activityCtrl.SetBudget(budget)
+	}
+}
+
+// SetObserver sets the Observer told about inhibitor failures and
+// simulations, including for a running session.
+func (k *linuxKeepAlive) SetObserver(o Observer) {
+	k.observer.set(o)
+}
+
+// SetTimings applies new activity intervals, including to a running session.
+func (k *linuxKeepAlive) SetTimings(t Timings) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.timings = t
+	if k.activityCtrl != nil {
+		k.activityCtrl.SetTimings(t)
+	}
+	if k.activityTick != nil {
+		k.activityTick.Reset(t.WithDefaults().ActivityInterval)
+	}
+}
+
+// SetMouseShape selects the jitter pattern, including for a running session.
+func (k *linuxKeepAlive) SetMouseShape(shape MouseShape) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.mouseShape = shape
+	if k.patternGen != nil {
+		k.patternGen.SetShape(shape)
+	}
+}
+
+// SetAllowHibernate lets the system hibernate while the keep-alive holds
+// off idle sleep. It applies from the next start, as the inhibitors are
+// chosen when they are activated.
+func (k *linuxKeepAlive) SetAllowHibernate(allow bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.allowHibernate = allow
+}
+
+// SetPresenceOnly runs only activity simulation from the next start,
+// leaving sleep to the system.
+func (k *linuxKeepAlive) SetPresenceOnly(presenceOnly bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.presenceOnly = presenceOnly
+}
+
 func (k *linuxKeepAlive) SetSimulateActivity(simulate bool) {
 	k.simulateActivity.Store(simulate)
 
@@ -1732,17 +2358,17 @@ func linuxActivitySimulationStatus(caps linuxCapabilities, hasUinput bool) Activ
 			Message:   "Active status simulation uses ydotool mouse events.",
 		}
 	}
-	if caps.displayServer == displayServerX11 && caps.xdotoolAvailable {
+	if caps.displayServer == displayServerX11 && caps.xtestAvailable {
 		return ActivitySimulationStatus{
 			Available: true,
-			Method:    "xdotool",
-			Message:   "Active status simulation uses xdotool mouse events.",
+			Method:    "xtest",
+			Message:   "Active status simulation uses X server XTEST mouse events.",
 		}
 	}
 
 	message := "Active status simulation is unavailable: no real Linux mouse input backend is available. KeepAlive will still prevent system sleep, but Slack/Teams activity cannot be simulated. Configure uinput permissions or install ydotool."
 	if caps.displayServer == displayServerX11 {
-		message += " On X11, xdotool is also supported."
+		message += " On X11, the X server's XTEST extension is also supported."
 	}
 
 	return ActivitySimulationStatus{
@@ -1757,6 +2383,80 @@ func GetActivitySimulationStatus() ActivitySimulationStatus {
 	return linuxActivitySimulationStatus(caps, hasUinput)
 }
 
+// findProcessesIn returns the pids under procRoot whose command name or
+// executable basename is name. The kernel truncates comm to 15 bytes, so long
+// names are also matched by prefix against it.
+func findProcessesIn(procRoot, name string, self int) ([]int, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", procRoot, err)
+	}
+
+	comm := name
+	if len(comm) > 15 {
+		comm = comm[:15]
+	}
+
+	var pids []int
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == self {
+			continue
+		}
+		dir := filepath.Join(procRoot, entry.Name())
+		if data, err := os.ReadFile(filepath.Join(dir, "comm")); err == nil && strings.TrimSpace(string(data)) == comm {
+			pids = append(pids, pid)
+			continue
+		}
+		if data, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+			argv0 := strings.SplitN(string(data), "\x00", 2)[0]
+			if argv0 != "" && filepath.Base(argv0) == name {
+				pids = append(pids, pid)
+			}
+		}
+	}
+	return pids, nil
+}
+
+// FindProcesses returns the pids of running processes named name.
+func FindProcesses(name string) ([]int, error) {
+	return findProcessesIn("/proc", name, os.Getpid())
+}
+
+// Diagnose reports the inhibitors, tools and dependencies available on this
+// Linux session.
+func Diagnose() Diagnostics {
+	d := newDiagnostics("loginctl", "gsettings", "xset", "swaymsg", "hyprctl",
+		"ydotool", "wtype", "upower", "brightnessctl")
+	caps := detectLinuxCapabilities()
+	hasUinput, _ := checkUinputPermissions()
+	d.Tools["uinput"] = hasUinput
+	d.Tools["dbus"] = caps.sessionBusAvailable
+	d.Tools["logind"] = logindAvailable()
+	d.Tools["wayland-idle-inhibit"] = caps.displayServer == displayServerWayland && waylandIdleInhibitSupported()
+	d.Tools["portal"] = portalAvailable()
+	d.Tools["xtest"] = caps.xtestAvailable
+	d.Tools["xscreensaver"] = false
+	if caps.displayServer == displayServerX11 {
+		_, d.Tools["xscreensaver"] = x11Support()
+	}
+	d.DesktopEnvironment = caps.desktopEnvironment
+	d.DisplayServer = caps.displayServer
+	d.Sandbox = linuxSandbox()
+
+	for _, inh := range buildLinuxInhibitors(false) {
+		d.Inhibitors = append(d.Inhibitors, inh.Name())
+	}
+	d.SleepPrevention = d.Tools["logind"] || d.Tools["dbus"] ||
+		(caps.displayServer == displayServerX11 && d.Tools["xset"])
+	d.ActivitySimulation = linuxActivitySimulationStatus(caps, hasUinput)
+	d.SimulationMethods = linuxSimulationMethods(caps, hasUinput)
+	if missing := checkMissingDependencies(caps, caps.displayServer, hasUinput); len(missing) > 0 {
+		d.MissingDependencies = missing
+	}
+	return d
+}
+
 func NewKeepAlive() (KeepAlive, error) {
 	return &linuxKeepAlive{}, nil
 }
@@ -5,6 +5,7 @@ package platform
 import (
 	"context"
 	"errors"
+	"time"
 )
 
 // unsupportedKeepAlive implements the KeepAlive interface for unsupported platforms
@@ -18,10 +19,22 @@ func (k *unsupportedKeepAlive) Stop() error {
 	return errors.New("unsupported platform")
 }
 
+func (k *unsupportedKeepAlive) StopNow() {
+	// No-op on unsupported platforms
+}
+
 func (k *unsupportedKeepAlive) SetSimulateActivity(simulate bool) {
 	// No-op on unsupported platforms
 }
 
+func (k *unsupportedKeepAlive) SetTimings(t Timings) {
+	// No-op on unsupported platforms
+}
+
+func (k *unsupportedKeepAlive) SetMouseShape(s MouseShape) {
+	// No-op on unsupported platforms
+}
+
 // GetDependencyMessage returns empty string on unsupported platforms
 func GetDependencyMessage() string {
 	return ""
@@ -38,6 +51,53 @@ func GetBatteryStatus() (BatteryStatus, error) {
 	return BatteryStatus{}, errors.New("battery status is unsupported on this platform")
 }
 
+func GetPowerSource() (PowerSource, error) {
+	return PowerSourceUnknown, errors.New("power source detection is unsupported on this platform")
+}
+
+func GetBrightness() (int, error) {
+	return 0, errors.New("brightness control is unsupported on this platform")
+}
+
+func SetBrightness(percent int) error {
+	return errors.New("brightness control is unsupported on this platform")
+}
+
+func IdleTime() (time.Duration, error) {
+	return 0, errors.New("idle time is unsupported on this platform")
+}
+
+func IdleSources() []IdleSource {
+	return nil
+}
+
+func Notify(title, body string) error {
+	return errors.New("notifications are unsupported on this platform")
+}
+
+func SetTaskbarProgress(fraction float64) error {
+	return errors.New("taskbar progress is unsupported on this platform")
+}
+
+func ClearTaskbarProgress() error {
+	return errors.New("taskbar progress is unsupported on this platform")
+}
+
+func ProcessAlive(pid int) (bool, error) {
+	return false, errors.New("process watching is unsupported on this platform")
+}
+
+func FindProcesses(name string) ([]int, error) {
+	return nil, errors.New("process watching is unsupported on this platform")
+}
+
+// Diagnose reports that no keep-alive methods exist on this platform.
+func Diagnose() Diagnostics {
+	d := newDiagnostics()
+	d.ActivitySimulation = GetActivitySimulationStatus()
+	return d
+}
+
 // NewKeepAlive creates a new platform-specific keep-alive instance
 func NewKeepAlive() (KeepAlive, error) {
 	return &unsupportedKeepAlive{}, nil
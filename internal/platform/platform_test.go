@@ -169,9 +169,9 @@ func TestLinuxCapabilityProbeSkips(t *testing.T) {
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
-	if _, err := exec.LookPath("systemd-inhibit"); err != nil {
+	if !Diagnose().Tools["logind"] {
 		if os.Getenv("DISPLAY") == "" {
-			t.Skip("no systemd-inhibit and no X11 DISPLAY; skipping")
+			t.Skip("no logind and no X11 DISPLAY; skipping")
 		}
 	}
 	keeper, err := NewKeepAlive()
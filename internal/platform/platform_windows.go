@@ -4,10 +4,16 @@ package platform
 
 import (
 	"context"
+	"encoding/csv"
 	"fmt"
-	"log"
+	"math"
 	"math/rand"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
@@ -19,15 +25,48 @@ func run(name string, args ...string) error {
 	return exec.Command(name, args...).Run()
 }
 
-const (
-	esSystemRequired  = 0x00000001
-	esDisplayRequired = 0x00000002
-	esContinuous      = 0x80000000
+// scriptExecutionTimeout limits how long we wait for a PowerShell script to
+// complete. PowerShell can take several seconds to start on a cold machine,
+// but a script that runs longer than this is hung.
+const scriptExecutionTimeout = 15 * time.Second
+
+// runPowerShell runs script in PowerShell with env added to its environment
+// and returns its combined output, giving up after scriptExecutionTimeout.
+func runPowerShell(script string, env ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), scriptExecutionTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return out, fmt.Errorf("powershell timed out after %s", scriptExecutionTimeout)
+	}
+	return out, err
+}
 
-	inputMouse     = 0
-	mouseEventMove = 0x0001
+const (
+	esSystemRequired   = 0x00000001
+	esDisplayRequired  = 0x00000002
+	esAwayModeRequired = 0x00000040
+	esContinuous       = 0x80000000
+
+	inputMouse            = 0
+	mouseEventMove        = 0x0001
+	mouseEventVirtualDesk = 0x4000
+	mouseEventAbsolute    = 0x8000
+
+	inputKeyboard    = 1
+	keyEventKeyUp    = 0x0002
+	keyEventScancode = 0x0008
 )
 
+// windowsSimMethods are the activity-simulation methods, in the order
+// SetSimulationMethod lists them: relative pointer moves, absolute pointer
+// moves, and key taps.
+var windowsSimMethods = []string{"SendInput", "absolute", "keyboard"}
+
 type mouseInput struct {
 	dx          int32
 	dy          int32
@@ -42,12 +81,30 @@ type input struct {
 	mi        mouseInput
 }
 
+// keyboardInput is KEYBDINPUT, padded to the size of mouseInput, the
+// largest member of INPUT's union, so that keyInput has the size of input.
+type keyboardInput struct {
+	wVk         uint16
+	wScan       uint16
+	dwFlags     uint32
+	time        uint32
+	dwExtraInfo uintptr
+	_           [8]byte
+}
+
+type keyInput struct {
+	inputType uint32
+	ki        keyboardInput
+}
+
 var (
 	kernel32                    = syscall.NewLazyDLL("kernel32.dll")
 	procSetThreadExecutionState = kernel32.NewProc("SetThreadExecutionState")
 	user32                      = syscall.NewLazyDLL("user32.dll")
 	procSendInput               = user32.NewProc("SendInput")
 	procGetLastInputInfo        = user32.NewProc("GetLastInputInfo")
+	procGetCursorPos            = user32.NewProc("GetCursorPos")
+	procSetCursorPos            = user32.NewProc("SetCursorPos")
 	procGetTickCount            = kernel32.NewProc("GetTickCount")
 	procGetSystemPowerStatus    = kernel32.NewProc("GetSystemPowerStatus")
 )
@@ -94,6 +151,92 @@ func GetBatteryStatus() (BatteryStatus, error) {
 	return BatteryStatus{Percentage: percentage, Available: true}, nil
 }
 
+func powerSourceFromWindowsStatus(status systemPowerStatus) PowerSource {
+	switch status.ACLineStatus {
+	case 0:
+		return PowerSourceBattery
+	case 1:
+		return PowerSourceAC
+	default:
+		return PowerSourceUnknown
+	}
+}
+
+func GetPowerSource() (PowerSource, error) {
+	var status systemPowerStatus
+	r1, _, err := procGetSystemPowerStatus.Call(uintptr(unsafe.Pointer(&status)))
+	if r1 == 0 {
+		return PowerSourceUnknown, err
+	}
+	return powerSourceFromWindowsStatus(status), nil
+}
+
+// parseWMIBrightness parses the CurrentBrightness value printed by PowerShell.
+// Machines with several WMI-controllable panels print one value per line; the
+// first is used.
+func parseWMIBrightness(out string) (int, error) {
+	line := strings.TrimSpace(strings.SplitN(strings.TrimSpace(out), "\n", 2)[0])
+	percent, err := strconv.Atoi(line)
+	if err != nil || percent < 0 || percent > 100 {
+		return 0, fmt.Errorf("unexpected brightness output: %q", line)
+	}
+	return percent, nil
+}
+
+// GetBrightness returns the built-in display brightness in percent via WMI.
+// External monitors are not exposed through WmiMonitorBrightness.
+func GetBrightness() (int, error) {
+	out, err := runPowerShell("(Get-CimInstance -Namespace root/WMI -ClassName WmiMonitorBrightness -ErrorAction Stop).CurrentBrightness")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read display brightness: %v (output: %q)", err, strings.TrimSpace(string(out)))
+	}
+	return parseWMIBrightness(string(out))
+}
+
+// SetBrightness sets the built-in display brightness in percent via WMI.
+func SetBrightness(percent int) error {
+	command := fmt.Sprintf("Get-CimInstance -Namespace root/WMI -ClassName WmiMonitorBrightnessMethods -ErrorAction Stop | Invoke-CimMethod -MethodName WmiSetBrightness -Arguments @{Timeout=0; Brightness=%d} | Out-Null", percent)
+	out, err := runPowerShell(command)
+	if err != nil {
+		return fmt.Errorf("failed to set display brightness: %v (output: %q)", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// notifyScript shows a toast under PowerShell's application ID, reading the
+// text from the environment so that it needs no quoting.
+const notifyScript = `
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] | Out-Null
+$title = [Security.SecurityElement]::Escape($env:KEEPALIVE_NOTIFY_TITLE)
+$body = [Security.SecurityElement]::Escape($env:KEEPALIVE_NOTIFY_BODY)
+$xml = New-Object Windows.Data.Xml.Dom.XmlDocument
+$xml.LoadXml("<toast><visual><binding template=""ToastGeneric""><text>$title</text><text>$body</text></binding></visual></toast>")
+$appID = '{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe'
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier($appID).Show([Windows.UI.Notifications.ToastNotification]::new($xml))
+`
+
+// Notify shows a toast notification.
+func Notify(title, body string) error {
+	out, err := runPowerShell(notifyScript, "KEEPALIVE_NOTIFY_TITLE="+title, "KEEPALIVE_NOTIFY_BODY="+body)
+	if err != nil {
+		return fmt.Errorf("failed to show notification: %v (output: %q)", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// IdleTime returns how long the user has been idle.
+func IdleTime() (time.Duration, error) {
+	return getIdleTime()
+}
+
+// IdleSources reads the idle time from GetLastInputInfo, the only source on
+// Windows.
+func IdleSources() []IdleSource {
+	idle, err := getIdleTime()
+	return []IdleSource{{Name: "GetLastInputInfo", Idle: idle, Err: err}}
+}
+
 func getIdleTime() (time.Duration, error) {
 	var lii lastInputInfo
 	lii.cbSize = uint32(unsafe.Sizeof(lii))
@@ -129,28 +272,90 @@ type windowsKeepAlive struct {
 
 	// shared activity controller for idle-gated jitter
 	activityCtrl *ActivityController
+
+	// jitterMu serializes jitter cycles, so the ticker and SimulateOnce never
+	// move the pointer at the same time.
+	jitterMu sync.Mutex
+
+	// drift moves the pointer back when a pattern leaves it off its origin,
+	// as pointer acceleration applied to SendInput's relative moves can.
+	drift cursorDrift
+
+	// timings holds user overrides of the activity intervals.
+	timings Timings
+
+	// mouseShape selects the jitter pattern and size.
+	mouseShape MouseShape
+
+	// budget caps the jitters performed during the session.
+	budget *SimulationBudget
+
+	// observer is told about inhibitor failures and simulations.
+	observer observerSlot
+
+	// awayMode requests away mode instead of keeping the display on.
+	awayMode bool
+
+	// presenceOnly runs activity simulation without setting the execution
+	// state.
+	presenceOnly bool
+
+	// remote is set when the session started in a Remote Desktop session.
+	// remoteInputWarned is set once refused input has been explained.
+	remote            atomic.Bool
+	remoteInputWarned atomic.Bool
+
+	// state holds the execution state flags the session last requested.
+	state atomic.Uint32
+
+	// request is held alongside the execution state so that the session's
+	// reason shows in `powercfg /requests`; it is nil when it could not be
+	// created. reason is the text it gives.
+	request *powerRequest
+	reason  string
+
+	// refreshFailing is set while refreshing the execution state fails.
+	refreshFailing atomic.Bool
+
+	// held is the reason the last jitter tick held off simulation, or ""
+	// when it did not; see simulationHold.
+	held atomic.Value
+
+	// simMethod is the selected activity-simulation method, or "" to tap
+	// simKey when one is set and move the pointer otherwise.
+	simMethod string
+	simKey    string
 }
 
-func setWindowsKeepAlive() error {
-	r1, _, err := procSetThreadExecutionState.Call(
-		uintptr(esSystemRequired | esDisplayRequired | esContinuous),
-	)
-	if r1 == 0 {
-		return err
+// executionState returns the SetThreadExecutionState flags for a session.
+// Away mode drops the display requirement so the machine can look asleep.
+func executionState(awayMode bool) uint32 {
+	if awayMode {
+		return esSystemRequired | esAwayModeRequired | esContinuous
 	}
-	return nil
+	return esSystemRequired | esDisplayRequired | esContinuous
+}
+
+// setWindowsKeepAlive sets the execution state on the execution-state
+// thread.
+func setWindowsKeepAlive(state uint32) error {
+	return onStateThread(func() error {
+		r1, _, err := procSetThreadExecutionState.Call(uintptr(state))
+		if r1 == 0 {
+			return err
+		}
+		return nil
+	})
 }
 
+// stopWindowsKeepAlive resets the execution state on the thread that set
+// it.
 func stopWindowsKeepAlive() error {
-	r1, _, err := procSetThreadExecutionState.Call(uintptr(esContinuous))
-	if r1 == 0 {
-		return err
-	}
-	return nil
+	return setWindowsKeepAlive(esContinuous)
 }
 
-func setPowerShellKeepAlive() error {
-	return run("powershell", "-NoProfile", "-NonInteractive", "-Command", `
+func setPowerShellKeepAlive(state uint32) error {
+	return run("powershell", "-NoProfile", "-NonInteractive", "-Command", fmt.Sprintf(`
 		$code = @"
 		using System;
 		using System.Runtime.InteropServices;
@@ -162,15 +367,26 @@ func setPowerShellKeepAlive() error {
 "@
 
 		Add-Type -TypeDefinition $code
-		[Sleep]::SetThreadExecutionState(0x80000003)
-	`)
+		[Sleep]::SetThreadExecutionState(0x%08X)
+	`, state))
 }
 
 func (k *windowsKeepAlive) activateKeepAliveMethod() error {
-	err := setWindowsKeepAlive()
+	awayMode := false
+	if k.awayMode {
+		if status := GetAwayModeStatus(); status.Available {
+			awayMode = true
+		} else {
+			logger().Warn("away mode unavailable; keeping the display on", "reason", status.Message)
+		}
+	}
+	state := executionState(awayMode)
+	k.state.Store(state)
+
+	err := setWindowsKeepAlive(state)
 	if err != nil {
 		// Fall back to PowerShell method
-		err = setPowerShellKeepAlive()
+		err = setPowerShellKeepAlive(state)
 		if err != nil {
 			return err
 		}
@@ -178,25 +394,64 @@ func (k *windowsKeepAlive) activateKeepAliveMethod() error {
 	} else {
 		k.activeMethod = "SetThreadExecutionState"
 	}
-	log.Printf("windows: active method: %s", k.activeMethod)
+	modernStandby := ModernStandby()
+	if modernStandby {
+		logger().Info("modern standby system", "note", ModernStandbyNote)
+	}
+	k.setPowerRequestLocked(powerRequestTypes(awayMode, modernStandby))
+	logger().Info("keep-alive started", "method", k.activeMethod, "away_mode", awayMode, "modern_standby", modernStandby)
 	return nil
 }
 
+// setPowerRequestLocked replaces the session's power request with one of
+// types giving the current reason. The execution state holds the system
+// awake without it, so a failure is only logged. Callers must hold k.mu.
+func (k *windowsKeepAlive) setPowerRequestLocked(types []uintptr) {
+	request, err := newPowerRequest(k.reason, types)
+	if err != nil {
+		logger().Warn("power request unavailable; powercfg /requests will not show the reason", "err", err)
+	}
+	k.releasePowerRequestLocked()
+	k.request = request
+}
+
+// releasePowerRequestLocked releases the session's power request, if any.
+// Callers must hold k.mu.
+func (k *windowsKeepAlive) releasePowerRequestLocked() {
+	if k.request != nil {
+		k.request.release()
+		k.request = nil
+	}
+}
+
 func (k *windowsKeepAlive) startActivityTickerLocked(ctx context.Context) {
-	ticker := time.NewTicker(ActivityInterval)
+	ticker := time.NewTicker(k.timings.WithDefaults().ActivityInterval)
 	k.activityTick = ticker
 	k.wg.Add(1)
 	go func() {
 		defer k.wg.Done()
 		defer ticker.Stop()
 
+		// failing is set while refreshes fail, so each failure is reported
+		// once rather than on every tick.
+		failing := false
 		for {
 			select {
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
 				// Refresh the keep-alive state
-				setWindowsKeepAlive()
+				err := setWindowsKeepAlive(k.state.Load())
+				if err != nil && !failing {
+					logger().Warn("inhibitor failed", "inhibitor", "SetThreadExecutionState", "err", err)
+					k.observer.inhibitorFailed("SetThreadExecutionState", err)
+				}
+				if err == nil && failing {
+					logger().Info("inhibitor recovered", "inhibitor", "SetThreadExecutionState")
+					k.observer.inhibitorRestored("SetThreadExecutionState")
+				}
+				failing = err != nil
+				k.refreshFailing.Store(failing)
 			}
 		}
 	}()
@@ -229,22 +484,146 @@ func (k *windowsKeepAlive) simulateChatAppActivity() {
 	if !k.simulateActivity.Load() {
 		return
 	}
+	if k.simulationHeld() {
+		return
+	}
 
+	k.jitterMu.Lock()
+	defer k.jitterMu.Unlock()
 	k.activityCtrl.MaybeJitter(
 		getIdleTime,
 		func(points []MousePoint, sessionDuration time.Duration) {
-			k.executeMousePattern(points, sessionDuration)
+			method, err := k.simulate(points, sessionDuration)
+			if err == nil {
+				k.observer.simulationPerformed(method)
+				return
+			}
+			if k.remote.Load() && !k.remoteInputWarned.Swap(true) {
+				logger().Warn("simulated input refused in a remote desktop session; it may be disconnected or its window minimized", "err", err)
+			}
 		},
 	)
 }
 
-func (k *windowsKeepAlive) executeMousePattern(points []MousePoint, sessionDuration time.Duration) {
+// simulationHeld reports whether simulation should hold off this tick, as
+// told by simulationHold, logging when that changes so that the log shows
+// why the pointer stopped moving.
+func (k *windowsKeepAlive) simulationHeld() bool {
+	reason := simulationHold()
+	if prev, _ := k.held.Swap(reason).(string); prev != reason {
+		if reason != "" {
+			logger().Info("activity simulation paused", "reason", reason)
+		} else {
+			logger().Info("activity simulation resumed")
+		}
+	}
+	return reason != ""
+}
+
+// SimulateOnce runs one jitter cycle immediately.
+func (k *windowsKeepAlive) SimulateOnce() SimulationResult {
+	k.mu.Lock()
+	running := k.isRunning
+	k.mu.Unlock()
+	if !running {
+		return SimulationResult{Err: fmt.Errorf("keep-alive is not running")}
+	}
+
+	k.mu.Lock()
+	method, _ := k.simulationMethodLocked()
+	k.mu.Unlock()
+	res := SimulationResult{Tried: []string{method}}
+	res.Idle, res.IdleErr = getIdleTime()
+
+	k.jitterMu.Lock()
+	defer k.jitterMu.Unlock()
+	res.Points, res.Duration = k.activityCtrl.Fire(func(points []MousePoint, sessionDuration time.Duration) {
+		method, res.Err = k.simulate(points, sessionDuration)
+	})
+	if res.Err == nil {
+		res.Method = method
+		k.observer.simulationPerformed(method)
+	}
+	return res
+}
+
+// simulationMethodLocked returns the method activity simulation uses and,
+// for keyboard, the key it taps. Without a chosen method, a Remote Desktop
+// session moves the pointer to absolute positions, since the RDP stack
+// often drops relative moves. Callers must hold k.mu.
+func (k *windowsKeepAlive) simulationMethodLocked() (method, key string) {
+	switch {
+	case k.simMethod == "keyboard":
+		key = k.simKey
+		if key == "" {
+			key = DefaultSimulationKey
+		}
+		return "keyboard", key
+	case k.simMethod == "" && k.simKey != "":
+		return "keyboard", k.simKey
+	case k.simMethod == "absolute", k.simMethod == "" && k.remote.Load():
+		return "absolute", ""
+	}
+	return "SendInput", ""
+}
+
+// simulate moves the pointer through points, or taps the key when keyboard
+// simulation is selected, and returns the method used.
+func (k *windowsKeepAlive) simulate(points []MousePoint, sessionDuration time.Duration) (string, error) {
+	k.mu.Lock()
+	method, key := k.simulationMethodLocked()
+	k.mu.Unlock()
+	switch method {
+	case "keyboard":
+		return method, tapKey(key)
+	case "absolute":
+		return method, k.executeAbsolutePattern(points, sessionDuration)
+	}
+	return method, k.executeMousePattern(points, sessionDuration)
+}
+
+// tapKey presses and releases key with SendInput, by scan code, which
+// virtual-desktop clients pass on even when they ignore relative pointer
+// moves.
+func tapKey(key string) error {
+	scancode, _, err := simulationKeyScancode(key)
+	if err != nil {
+		return err
+	}
+	events := [2]keyInput{
+		{inputType: inputKeyboard, ki: keyboardInput{wScan: scancode, dwFlags: keyEventScancode}},
+		{inputType: inputKeyboard, ki: keyboardInput{wScan: scancode, dwFlags: keyEventScancode | keyEventKeyUp}},
+	}
+	r1, _, err := procSendInput.Call(
+		uintptr(len(events)),
+		uintptr(unsafe.Pointer(&events[0])),
+		uintptr(unsafe.Sizeof(events[0])),
+	)
+	if r1 != uintptr(len(events)) {
+		logger().Warn("SendInput key tap failed", "key", key, "err", err)
+		return fmt.Errorf("SendInput: %w", err)
+	}
+	return nil
+}
+
+// executeMousePattern returns the first SendInput failure, if any. The
+// pattern carries on past failures so the pointer still returns to origin.
+func (k *windowsKeepAlive) executeMousePattern(points []MousePoint, sessionDuration time.Duration) error {
 	if len(points) == 0 {
-		return
+		return nil
 	}
 
 	stepDelay := jitterStepDelay(sessionDuration, len(points))
 
+	origin, originErr := windowsCursor{}.cursorPosition()
+
+	var firstErr error
+	move := func(dx, dy int) {
+		if err := k.sendMouseMove(int32(dx), int32(dy)); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
 	currentX := 0
 	currentY := 0
 
@@ -252,16 +631,16 @@ func (k *windowsKeepAlive) executeMousePattern(points []MousePoint, sessionDurat
 		select {
 		case <-k.ctx.Done():
 			if currentX != 0 || currentY != 0 {
-				k.sendMouseMove(int32(-currentX), int32(-currentY))
+				move(-currentX, -currentY)
 			}
-			return
+			return k.ctx.Err()
 		default:
 		}
 
 		dx, dy, targetX, targetY := relativeStepToPoint(currentX, currentY, pt)
 
 		if dx != 0 || dy != 0 {
-			k.sendMouseMove(int32(dx), int32(dy))
+			move(dx, dy)
 			currentX = targetX
 			currentY = targetY
 		}
@@ -271,12 +650,122 @@ func (k *windowsKeepAlive) executeMousePattern(points []MousePoint, sessionDurat
 
 	// Return to origin
 	if currentX != 0 || currentY != 0 {
-		k.sendMouseMove(int32(-currentX), int32(-currentY))
+		move(-currentX, -currentY)
 	}
 	time.Sleep(k.patternGen.JitterStepDelayWithVariance(stepDelay))
+	// In a Remote Desktop session the pointer follows the client's, which
+	// moving it back would fight.
+	if originErr == nil && !k.remote.Load() {
+		k.drift.restore(windowsCursor{}, origin)
+	}
+	return firstErr
+}
+
+// executeAbsolutePattern moves the pointer through points around where it
+// is, with absolute moves, and back to where it was. It returns the first
+// failure, if any, carrying on past failures like executeMousePattern.
+func (k *windowsKeepAlive) executeAbsolutePattern(points []MousePoint, sessionDuration time.Duration) error {
+	if len(points) == 0 {
+		return nil
+	}
+	origin, err := windowsCursor{}.cursorPosition()
+	if err != nil {
+		return err
+	}
+	screen := virtualScreen()
+	stepDelay := jitterStepDelay(sessionDuration, len(points))
+
+	var firstErr error
+	moveTo := func(x, y int) {
+		if err := sendAbsoluteMove(screen, x, y); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, pt := range points {
+		select {
+		case <-k.ctx.Done():
+			moveTo(origin.X, origin.Y)
+			return k.ctx.Err()
+		default:
+		}
+		_, _, dx, dy := relativeStepToPoint(0, 0, pt)
+		moveTo(origin.X+dx, origin.Y+dy)
+		time.Sleep(k.patternGen.JitterStepDelayWithVariance(stepDelay))
+	}
+	moveTo(origin.X, origin.Y)
+	return firstErr
+}
+
+// virtualScreen returns the bounds of the virtual screen, which spans every
+// monitor.
+func virtualScreen() displayBounds {
+	metric := func(index uintptr) float64 {
+		r1, _, _ := procGetSystemMetrics.Call(index)
+		return float64(int32(r1))
+	}
+	return displayBounds{
+		X:      metric(smXVirtualScreen),
+		Y:      metric(smYVirtualScreen),
+		Width:  metric(smCXVirtualScreen),
+		Height: metric(smCYVirtualScreen),
+	}
+}
+
+// absoluteCoordinates converts the screen position (x, y) to the
+// coordinates of an absolute move over the virtual screen, which run from
+// 0 to 65535 across it.
+func absoluteCoordinates(screen displayBounds, x, y int) (int32, int32) {
+	scale := func(v, origin, size float64) int32 {
+		if size <= 1 {
+			return 0
+		}
+		n := math.Round((v - origin) * 65535 / (size - 1))
+		return int32(min(max(n, 0), 65535))
+	}
+	return scale(float64(x), screen.X, screen.Width), scale(float64(y), screen.Y, screen.Height)
+}
+
+// sendAbsoluteMove moves the pointer to the screen position (x, y).
+func sendAbsoluteMove(screen displayBounds, x, y int) error {
+	dx, dy := absoluteCoordinates(screen, x, y)
+	inputEv := input{
+		inputType: inputMouse,
+		mi:        mouseInput{dx: dx, dy: dy, dwFlags: mouseEventMove | mouseEventAbsolute | mouseEventVirtualDesk},
+	}
+	r1, _, err := procSendInput.Call(
+		uintptr(1),
+		uintptr(unsafe.Pointer(&inputEv)),
+		uintptr(unsafe.Sizeof(inputEv)),
+	)
+	if r1 == 0 {
+		logger().Warn("SendInput absolute move failed", "x", x, "y", y, "err", err)
+		return fmt.Errorf("SendInput: %w", err)
+	}
+	return nil
 }
 
-func (k *windowsKeepAlive) sendMouseMove(dx, dy int32) {
+// windowsCursor reads and sets the pointer position with GetCursorPos and
+// SetCursorPos.
+type windowsCursor struct{}
+
+func (windowsCursor) cursorPosition() (cursorPos, error) {
+	var pt struct{ X, Y int32 }
+	r1, _, err := procGetCursorPos.Call(uintptr(unsafe.Pointer(&pt)))
+	if r1 == 0 {
+		return cursorPos{}, fmt.Errorf("GetCursorPos: %w", err)
+	}
+	return cursorPos{X: int(pt.X), Y: int(pt.Y)}, nil
+}
+
+func (windowsCursor) setCursorPosition(p cursorPos) error {
+	r1, _, err := procSetCursorPos.Call(uintptr(int32(p.X)), uintptr(int32(p.Y)))
+	if r1 == 0 {
+		return fmt.Errorf("SetCursorPos: %w", err)
+	}
+	return nil
+}
+
+func (k *windowsKeepAlive) sendMouseMove(dx, dy int32) error {
 	var inputEv input
 	inputEv.inputType = inputMouse
 	inputEv.mi = mouseInput{dx: dx, dy: dy, dwFlags: mouseEventMove}
@@ -287,8 +776,10 @@ func (k *windowsKeepAlive) sendMouseMove(dx, dy int32) {
 		uintptr(unsafe.Sizeof(inputEv)),
 	)
 	if r1 == 0 {
-		log.Printf("windows: SendInput move failed dx=%d dy=%d: %v", dx, dy, err)
+		logger().Warn("SendInput move failed", "dx", dx, "dy", dy, "err", err)
+		return fmt.Errorf("SendInput: %w", err)
 	}
+	return nil
 }
 
 // Start initiates the keep-alive functionality
@@ -305,15 +796,35 @@ func (k *windowsKeepAlive) Start(ctx context.Context) error {
 	// Initialize random source and pattern generator
 	k.rnd = newCryptoSeededRand()
 	k.patternGen = NewMousePatternGenerator(k.rnd)
+	k.patternGen.SetShape(k.mouseShape)
 	k.activityCtrl = NewActivityController("windows", k.patternGen)
-
-	// Activate keep-alive method
-	if err := k.activateKeepAliveMethod(); err != nil {
-		k.cancel()
-		return err
+	k.activityCtrl.setObserver(&k.observer)
+	k.activityCtrl.SetTimings(k.timings)
+	k.activityCtrl.SetBudget(k.budget)
+
+	k.remote.Store(InRemoteSession())
+	k.remoteInputWarned.Store(false)
+	if k.remote.Load() {
+		logger().Warn("remote desktop session", "note", RemoteSessionNote)
+		if method, _ := k.simulationMethodLocked(); method == "absolute" {
+			logger().Info("remote desktop session: simulating activity with absolute pointer moves")
+		}
 	}
 
-	k.startActivityTickerLocked(k.ctx)
+	if k.presenceOnly {
+		k.activeMethod = ""
+		logger().Info("keep-alive started", "mode", "presence-only")
+	} else {
+		// Activate keep-alive method
+		if err := k.activateKeepAliveMethod(); err != nil {
+			k.cancel()
+			return err
+		}
+		go logHibernationSettings()
+
+		k.startActivityTickerLocked(k.ctx)
+		k.startPowerSchemeWatchLocked(k.ctx)
+	}
 	k.startChatAppTickerLocked(k.ctx)
 
 	k.isRunning = true
@@ -340,6 +851,7 @@ func (k *windowsKeepAlive) Stop() error {
 		k.chatAppTick.Stop()
 		k.chatAppTick = nil
 	}
+	presenceOnly := k.presenceOnly
 
 	k.mu.Unlock()
 
@@ -352,21 +864,24 @@ func (k *windowsKeepAlive) Stop() error {
 
 	select {
 	case <-done:
-		log.Printf("windows: all goroutines completed")
+		logger().Debug("all goroutines completed")
 	case <-time.After(2 * time.Second):
-		log.Printf("windows: warning: some goroutines did not complete within timeout")
+		logger().Warn("some goroutines did not complete within timeout")
 	}
 
-	// Reset keep-alive state
+	// Reset keep-alive state, which presence-only mode never set
 	var stopErr error
-	if err := stopWindowsKeepAlive(); err != nil {
-		log.Printf("windows: error resetting keep-alive state: %v", err)
-		stopErr = err
-	} else {
-		log.Printf("windows: keep-alive state reset successfully")
+	if !presenceOnly {
+		if err := stopWindowsKeepAlive(); err != nil {
+			logger().Error("resetting execution state failed", "err", err)
+			stopErr = err
+		} else {
+			logger().Debug("execution state reset")
+		}
 	}
 
 	k.mu.Lock()
+	k.releasePowerRequestLocked()
 	k.isRunning = false
 	k.ctx = nil
 	k.cancel = nil
@@ -376,10 +891,188 @@ func (k *windowsKeepAlive) Stop() error {
 	}
 	k.mu.Unlock()
 
-	log.Printf("windows: stopped; cleanup complete")
+	logger().Info("keep-alive stopped")
 	return stopErr
 }
 
+// Healthy reports whether the execution state is still being refreshed.
+func (k *windowsKeepAlive) Healthy() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !k.isRunning {
+		return fmt.Errorf("keep-alive is not running")
+	}
+	if k.refreshFailing.Load() {
+		return fmt.Errorf("refreshing the execution state fails")
+	}
+	return nil
+}
+
+// ActiveMethods reports the API that set the execution state while
+// running: SetThreadExecutionState, or PowerShell when it was unavailable,
+// followed by PowerSetRequest while the power request is held.
+func (k *windowsKeepAlive) ActiveMethods() []string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !k.isRunning || k.activeMethod == "" {
+		return []string{}
+	}
+	methods := []string{k.activeMethod}
+	if k.request != nil {
+		methods = append(methods, "PowerSetRequest")
+	}
+	return methods
+}
+
+// StopNow resets the execution state without waiting for the activity
+// goroutines, which exit on their own once the context is cancelled.
+func (k *windowsKeepAlive) StopNow() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !k.isRunning {
+		return
+	}
+
+	if k.cancel != nil {
+		k.cancel()
+	}
+	if k.activityTick != nil {
+		k.activityTick.Stop()
+	}
+	if k.chatAppTick != nil {
+		k.chatAppTick.Stop()
+		k.chatAppTick = nil
+	}
+	if !k.presenceOnly {
+		if err := stopWindowsKeepAlive(); err != nil {
+			logger().Error("resetting execution state failed", "err", err)
+		}
+	}
+	k.releasePowerRequestLocked()
+
+	k.isRunning = false
+	k.ctx = nil
+	k.cancel = nil
+	k.activityTick = nil
+	if k.activityCtrl != nil {
+		k.activityCtrl.Reset()
+	}
+	logger().Info("keep-alive stopped immediately")
+}
+
+// SetSimulationBudget caps the jitters performed, including for a running
+// session.
+func (k *windowsKeepAlive) SetSimulationBudget(budget *SimulationBudget) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.budget = budget
+	if k.activityCtrl != nil {
+		k.activityCtrl.SetBudget(budget)
+	}
+}
+
+// SetObserver sets the Observer told about inhibitor failures and
+// simulations, including for a running session.
+func (k *windowsKeepAlive) SetObserver(o Observer) {
+	k.observer.set(o)
+}
+
+// SetTimings applies new activity intervals, including to a running session.
+func (k *windowsKeepAlive) SetTimings(t Timings) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.timings = t
+	if k.activityCtrl != nil {
+		k.activityCtrl.SetTimings(t)
+	}
+	if k.activityTick != nil {
+		k.activityTick.Reset(t.WithDefaults().ActivityInterval)
+	}
+}
+
+// SetAwayMode switches between away mode and keeping the display on,
+// including for a running session.
+func (k *windowsKeepAlive) SetAwayMode(enabled bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.awayMode == enabled {
+		return
+	}
+	k.awayMode = enabled
+	if k.isRunning {
+		if err := k.activateKeepAliveMethod(); err != nil {
+			logger().Error("changing away mode failed", "err", err)
+		}
+	}
+}
+
+// SetReason sets the reason the power request gives, including for a
+// running session.
+func (k *windowsKeepAlive) SetReason(reason string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.reason == reason {
+		return
+	}
+	k.reason = reason
+	if k.isRunning && k.request != nil {
+		k.setPowerRequestLocked(k.request.types)
+	}
+}
+
+// SetSimulationMethod selects "SendInput" to move the pointer, "absolute"
+// to move it to absolute positions or "keyboard" to tap a key, or "" to tap
+// the key set with SetSimulationKey, if any, and move the pointer
+// otherwise, to absolute positions in a Remote Desktop session.
+func (k *windowsKeepAlive) SetSimulationMethod(method string) error {
+	if method != "" && !slices.Contains(windowsSimMethods, method) {
+		return fmt.Errorf("unknown simulation method %q; choose from %s or auto", method, strings.Join(windowsSimMethods, ", "))
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.simMethod = method
+	return nil
+}
+
+// SetSimulationKey taps key instead of moving the pointer, including for a
+// running session, or moves the pointer again when key is "".
+func (k *windowsKeepAlive) SetSimulationKey(key string) error {
+	if key != "" {
+		canonical, err := ParseSimulationKey(key)
+		if err != nil {
+			return err
+		}
+		key = canonical
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.simKey = key
+	return nil
+}
+
+// SetPresenceOnly runs only activity simulation from the next start,
+// leaving sleep to the system.
+func (k *windowsKeepAlive) SetPresenceOnly(presenceOnly bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.presenceOnly = presenceOnly
+}
+
+// SetMouseShape selects the jitter pattern, including for a running session.
+func (k *windowsKeepAlive) SetMouseShape(shape MouseShape) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.mouseShape = shape
+	if k.patternGen != nil {
+		k.patternGen.SetShape(shape)
+	}
+}
+
 func (k *windowsKeepAlive) SetSimulateActivity(simulate bool) {
 	k.simulateActivity.Store(simulate)
 
@@ -411,6 +1104,106 @@ func GetActivitySimulationStatus() ActivitySimulationStatus {
 	}
 }
 
+const (
+	processQueryLimitedInformation = 0x1000
+	stillActive                    = 259
+	errorInvalidParameter          = syscall.Errno(87)
+)
+
+// ProcessAlive reports whether a process with the given pid is running.
+func ProcessAlive(pid int) (bool, error) {
+	if pid <= 0 {
+		return false, fmt.Errorf("invalid pid %d", pid)
+	}
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		if err == errorInvalidParameter {
+			return false, nil
+		}
+		if err == syscall.ERROR_ACCESS_DENIED {
+			// The process exists but belongs to another user.
+			return true, nil
+		}
+		return false, err
+	}
+	defer syscall.CloseHandle(h)
+
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return false, err
+	}
+	return code == stillActive, nil
+}
+
+// parseTasklistCSV extracts pids from `tasklist /FO CSV /NH` output. When no
+// process matches, tasklist prints an INFO line instead of CSV.
+func parseTasklistCSV(out string) ([]int, error) {
+	var pids []int
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		if strings.HasPrefix(strings.TrimSpace(out), "INFO:") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unexpected tasklist output: %v", err)
+	}
+	for _, record := range records {
+		if len(record) < 2 {
+			if len(record) == 1 && strings.HasPrefix(record[0], "INFO:") {
+				continue
+			}
+			return nil, fmt.Errorf("unexpected tasklist record: %q", record)
+		}
+		pid, err := strconv.Atoi(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected tasklist pid: %q", record[1])
+		}
+		pids = append(pids, pid)
+	}
+	return pids, nil
+}
+
+// FindProcesses returns the pids of running processes whose image name is
+// name, adding ".exe" when no extension is given.
+func FindProcesses(name string) ([]int, error) {
+	image := name
+	if filepath.Ext(image) == "" {
+		image += ".exe"
+	}
+	out, err := exec.Command("tasklist", "/FO", "CSV", "/NH", "/FI", "IMAGENAME eq "+image).Output()
+	if err != nil {
+		return nil, fmt.Errorf("tasklist failed: %v", err)
+	}
+	pids, err := parseTasklistCSV(string(out))
+	if err != nil {
+		return nil, err
+	}
+	self := os.Getpid()
+	for i, pid := range pids {
+		if pid == self {
+			pids = append(pids[:i], pids[i+1:]...)
+			break
+		}
+	}
+	return pids, nil
+}
+
+// Diagnose reports the APIs keep-alive uses on Windows.
+func Diagnose() Diagnostics {
+	d := newDiagnostics("powershell")
+	d.Inhibitors = []string{"SetThreadExecutionState"}
+	if d.Tools["powershell"] {
+		d.Inhibitors = append(d.Inhibitors, "PowerShell")
+	}
+	d.SleepPrevention = procSetThreadExecutionState.Find() == nil || d.Tools["powershell"]
+	d.ActivitySimulation = GetActivitySimulationStatus()
+	if d.ActivitySimulation.Available {
+		d.SimulationMethods = append(d.SimulationMethods, d.ActivitySimulation.Method)
+	}
+	d.RemoteSession = InRemoteSession()
+	d.ModernStandby = ModernStandby()
+	return d
+}
+
 // NewKeepAlive creates a new platform-specific keep-alive instance
 func NewKeepAlive() (KeepAlive, error) {
 	return &windowsKeepAlive{}, nil
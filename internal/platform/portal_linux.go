@@ -0,0 +1,143 @@
+//go:build linux
+
+package platform
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Flags of org.freedesktop.portal.Inhibit.Inhibit, naming what to hold off.
+const (
+	portalInhibitLogout     = 1
+	portalInhibitUserSwitch = 2
+	portalInhibitSuspend    = 4
+	portalInhibitIdle       = 8
+)
+
+const (
+	portalBusName = "org.freedesktop.portal.Desktop"
+	portalPath    = "/org/freedesktop/portal/desktop"
+)
+
+// Sandboxes keep-alive can run in.
+const (
+	sandboxFlatpak = "Flatpak"
+	sandboxSnap    = "Snap"
+)
+
+// flatpakInfoPath is the file Flatpak places at the root of every sandbox.
+var flatpakInfoPath = "/.flatpak-info"
+
+// linuxSandbox names the sandbox keep-alive runs in, or returns "" outside
+// one. Flatpak and Snap set these variables for the applications they run.
+func linuxSandbox() string {
+	if os.Getenv("FLATPAK_ID") != "" {
+		return sandboxFlatpak
+	}
+	if _, err := os.Stat(flatpakInfoPath); err == nil {
+		return sandboxFlatpak
+	}
+	if os.Getenv("SNAP") != "" && os.Getenv("SNAP_NAME") != "" {
+		return sandboxSnap
+	}
+	return ""
+}
+
+// portalInhibitor holds off suspend and idle through the desktop portal's
+// org.freedesktop.portal.Inhibit, which a sandbox lets through where it
+// hides the session manager and screensaver. The portal drops the inhibit
+// when the request is closed or the connection that made it goes away.
+type portalInhibitor struct {
+	conn      *dbus.Conn
+	handle    dbus.ObjectPath
+	stopWatch func()
+	// released is set once the portal leaves the bus, which voids the
+	// inhibit.
+	released atomic.Bool
+}
+
+func (p *portalInhibitor) Name() string { return "portal" }
+func (p *portalInhibitor) Activate(ctx context.Context) error {
+	p.close()
+	conn, err := connectSessionBus()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, dbusCallTimeout)
+	defer cancel()
+	options := map[string]dbus.Variant{"reason": dbus.MakeVariant("User requested keep-alive")}
+	var handle dbus.ObjectPath
+	err = conn.Object(portalBusName, portalPath).CallWithContext(ctx, "org.freedesktop.portal.Inhibit.Inhibit", 0,
+		"", uint32(portalInhibitSuspend|portalInhibitIdle), options).Store(&handle)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("portal Inhibit failed: %w", err)
+	}
+	if !handle.IsValid() {
+		conn.Close()
+		return fmt.Errorf("portal returned an invalid request handle %q", handle)
+	}
+	p.conn, p.handle = conn, handle
+	p.released.Store(false)
+	stop, err := watchNameOwner(conn, portalBusName, func() { p.released.Store(true) })
+	if err != nil {
+		logger().Debug("portal inhibitor cannot follow the portal", "err", err)
+	} else {
+		p.stopWatch = stop
+	}
+	logger().Info("portal inhibitor activated", "handle", handle)
+	return nil
+}
+
+func (p *portalInhibitor) Deactivate() error {
+	if p.conn == nil {
+		return nil
+	}
+	var err error
+	if !p.released.Load() {
+		ctx, cancel := context.WithTimeout(context.Background(), dbusCallTimeout)
+		err = p.conn.Object(portalBusName, p.handle).CallWithContext(ctx, "org.freedesktop.portal.Request.Close", 0).Err
+		cancel()
+	}
+	p.close()
+	return err
+}
+
+// close stops following the portal and closes the connection, which
+// releases the inhibit if it is still held.
+func (p *portalInhibitor) close() {
+	if p.stopWatch != nil {
+		p.stopWatch()
+		p.stopWatch = nil
+	}
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn = nil
+	}
+	p.handle = ""
+}
+
+// held reports whether the inhibit is in place.
+func (p *portalInhibitor) held() bool {
+	return p.conn != nil && !p.released.Load()
+}
+
+// portalAvailable reports whether the desktop portal runs on the session
+// bus.
+func portalAvailable() bool {
+	conn, err := connectSessionBus()
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+	var owned bool
+	if err := conn.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, portalBusName).Store(&owned); err != nil {
+		return false
+	}
+	return owned
+}
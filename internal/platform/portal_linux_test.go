@@ -0,0 +1,45 @@
+//go:build linux
+
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLinuxSandbox(t *testing.T) {
+	info := filepath.Join(t.TempDir(), ".flatpak-info")
+	old := flatpakInfoPath
+	flatpakInfoPath = info
+	t.Cleanup(func() { flatpakInfoPath = old })
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		infoFile bool
+		want     string
+	}{
+		{"unsandboxed", nil, false, ""},
+		{"flatpak_env", map[string]string{"FLATPAK_ID": "io.github.stigoleg.KeepAlive"}, false, sandboxFlatpak},
+		{"flatpak_info", nil, true, sandboxFlatpak},
+		{"snap", map[string]string{"SNAP": "/snap/keepalive/12", "SNAP_NAME": "keepalive"}, false, sandboxSnap},
+		{"snap_dir_only", map[string]string{"SNAP": "/snap/keepalive/12"}, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"FLATPAK_ID", "SNAP", "SNAP_NAME"} {
+				t.Setenv(key, tt.env[key])
+			}
+			os.Remove(info)
+			if tt.infoFile {
+				if err := os.WriteFile(info, []byte("[Application]\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := linuxSandbox(); got != tt.want {
+				t.Errorf("linuxSandbox() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
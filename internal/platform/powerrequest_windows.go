@@ -0,0 +1,101 @@
+//go:build windows
+
+package platform
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// DefaultPowerReason is the reason a power request gives when none is set.
+const DefaultPowerReason = "keep-alive: user requested awake"
+
+const (
+	powerRequestContextVersion      = 0
+	powerRequestContextSimpleString = 0x1
+
+	powerRequestDisplayRequired   = 0
+	powerRequestSystemRequired    = 1
+	powerRequestAwayModeRequired  = 2
+	powerRequestExecutionRequired = 3
+)
+
+var (
+	procPowerCreateRequest = kernel32.NewProc("PowerCreateRequest")
+	procPowerSetRequest    = kernel32.NewProc("PowerSetRequest")
+	procPowerClearRequest  = kernel32.NewProc("PowerClearRequest")
+	procCloseHandle        = kernel32.NewProc("CloseHandle")
+)
+
+// reasonContext is REASON_CONTEXT holding a simple reason string. The
+// detailed form of its union, which reads the reason from a resource, is
+// not used.
+type reasonContext struct {
+	version uint32
+	flags   uint32
+	reason  *uint16
+}
+
+// powerRequest is a power request created with PowerCreateRequest. Unlike
+// the execution state, it carries a reason, which `powercfg /requests`
+// lists next to the process.
+type powerRequest struct {
+	handle syscall.Handle
+	types  []uintptr
+}
+
+// powerRequestTypes returns the request types matching executionState. With
+// modern standby, where the system-required type does not keep the process
+// running once the system enters standby, the execution-required type is
+// added.
+func powerRequestTypes(awayMode, modernStandby bool) []uintptr {
+	types := []uintptr{powerRequestSystemRequired, powerRequestDisplayRequired}
+	if awayMode {
+		types = []uintptr{powerRequestSystemRequired, powerRequestAwayModeRequired}
+	}
+	if modernStandby {
+		types = append(types, powerRequestExecutionRequired)
+	}
+	return types
+}
+
+// newPowerRequest creates a power request giving reason and sets each of
+// types on it.
+func newPowerRequest(reason string, types []uintptr) (*powerRequest, error) {
+	if reason == "" {
+		reason = DefaultPowerReason
+	}
+	text, err := syscall.UTF16PtrFromString(reason)
+	if err != nil {
+		return nil, err
+	}
+	ctx := reasonContext{
+		version: powerRequestContextVersion,
+		flags:   powerRequestContextSimpleString,
+		reason:  text,
+	}
+	h, _, err := procPowerCreateRequest.Call(uintptr(unsafe.Pointer(&ctx)))
+	if syscall.Handle(h) == syscall.InvalidHandle {
+		return nil, err
+	}
+	r := &powerRequest{handle: syscall.Handle(h)}
+	for _, t := range types {
+		if ok, _, err := procPowerSetRequest.Call(h, t); ok == 0 {
+			r.release()
+			return nil, err
+		}
+		r.types = append(r.types, t)
+	}
+	return r, nil
+}
+
+// release clears the request's types and closes it.
+func (r *powerRequest) release() {
+	for _, t := range r.types {
+		if ok, _, err := procPowerClearRequest.Call(uintptr(r.handle), t); ok == 0 {
+			logger().Debug("clearing power request failed", "type", t, "err", err)
+		}
+	}
+	r.types = nil
+	procCloseHandle.Call(uintptr(r.handle))
+}
@@ -0,0 +1,20 @@
+//go:build windows
+
+package platform
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPowerRequestTypesMatchExecutionState(t *testing.T) {
+	if got := powerRequestTypes(false, false); !slices.Equal(got, []uintptr{powerRequestSystemRequired, powerRequestDisplayRequired}) {
+		t.Errorf("powerRequestTypes(false, false) = %v", got)
+	}
+	if got := powerRequestTypes(true, false); !slices.Equal(got, []uintptr{powerRequestSystemRequired, powerRequestAwayModeRequired}) {
+		t.Errorf("powerRequestTypes(true, false) = %v", got)
+	}
+	if got := powerRequestTypes(false, true); !slices.Equal(got, []uintptr{powerRequestSystemRequired, powerRequestDisplayRequired, powerRequestExecutionRequired}) {
+		t.Errorf("powerRequestTypes(false, true) = %v", got)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package platform
+
+import "context"
+
+// PowerSourceChanges returns nil: the power source is only polled on this
+// system.
+func PowerSourceChanges(ctx context.Context) <-chan struct{} {
+	return nil
+}
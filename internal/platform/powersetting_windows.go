@@ -0,0 +1,209 @@
+//go:build windows
+
+package platform
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// deviceNotifyCallback has PowerSettingRegisterNotification call a
+// function rather than post to a window.
+const deviceNotifyCallback = 2
+
+// powerSettingGUID is a GUID naming a power setting.
+type powerSettingGUID struct {
+	data1 uint32
+	data2 uint16
+	data3 uint16
+	data4 [8]byte
+}
+
+var (
+	// guidACDCPowerSource changes when the machine moves between AC power,
+	// battery and a UPS.
+	guidACDCPowerSource = powerSettingGUID{0x5d3e9a59, 0xe9d5, 0x4b00, [8]byte{0xa6, 0xbd, 0xff, 0x34, 0xff, 0x51, 0x65, 0x48}}
+	// guidActivePowerScheme changes when another power plan is chosen.
+	guidActivePowerScheme = powerSettingGUID{0x31f9f286, 0x5084, 0x42fe, [8]byte{0xb7, 0x20, 0x2b, 0x02, 0x64, 0x99, 0x37, 0x63}}
+	// guidStandbyTimeout and guidVideoPowerdownTimeout change when the
+	// current plan's sleep or display timeout is edited.
+	guidStandbyTimeout        = powerSettingGUID{0x29f6c1db, 0x86da, 0x48c5, [8]byte{0x9f, 0xdb, 0xf2, 0xb6, 0x7b, 0x1f, 0x44, 0xda}}
+	guidVideoPowerdownTimeout = powerSettingGUID{0x3c0bc021, 0xc8a8, 0x4e07, [8]byte{0xa9, 0x73, 0x6b, 0x14, 0xcb, 0xcb, 0x2b, 0x7e}}
+)
+
+// powerSchemeSettings are the settings whose changes can reset the idle
+// timeouts a session holds off.
+var powerSchemeSettings = []powerSettingGUID{guidActivePowerScheme, guidStandbyTimeout, guidVideoPowerdownTimeout}
+
+var (
+	powrprof                               = syscall.NewLazyDLL("powrprof.dll")
+	procPowerSettingRegisterNotification   = powrprof.NewProc("PowerSettingRegisterNotification")
+	procPowerSettingUnregisterNotification = powrprof.NewProc("PowerSettingUnregisterNotification")
+)
+
+// deviceNotifySubscribeParameters is DEVICE_NOTIFY_SUBSCRIBE_PARAMETERS.
+type deviceNotifySubscribeParameters struct {
+	callback uintptr
+	context  uintptr
+}
+
+// powerBroadcastSetting is the start of POWERBROADCAST_SETTING; the
+// setting's value that follows is not read.
+type powerBroadcastSetting struct {
+	setting    powerSettingGUID
+	dataLength uint32
+}
+
+// powerSettingWatchers maps the context each registration passes to its
+// callback to the function it calls. One callback serves every
+// registration, since Windows callbacks made with syscall.NewCallback are
+// never freed.
+var (
+	powerSettingMu       sync.Mutex
+	powerSettingWatchers = map[uintptr]func(powerSettingGUID){}
+	lastPowerSettingID   uintptr
+	powerSettingCallback = syscall.NewCallback(func(context, _ uintptr, setting *powerBroadcastSetting) uintptr {
+		powerSettingMu.Lock()
+		changed := powerSettingWatchers[context]
+		powerSettingMu.Unlock()
+		if changed != nil && setting != nil {
+			changed(setting.setting)
+		}
+		return 0
+	})
+)
+
+// watchPowerSettings calls changed with each of settings whose value
+// changes, from a thread of the system's, until the returned function is
+// called. Windows reports each setting's value once when it is registered;
+// those reports are not passed on. changed must return quickly.
+func watchPowerSettings(settings []powerSettingGUID, changed func(powerSettingGUID)) (func(), error) {
+	if err := procPowerSettingRegisterNotification.Find(); err != nil {
+		return nil, err
+	}
+
+	var seenMu sync.Mutex
+	seen := map[powerSettingGUID]bool{}
+	powerSettingMu.Lock()
+	lastPowerSettingID++
+	id := lastPowerSettingID
+	powerSettingWatchers[id] = func(g powerSettingGUID) {
+		seenMu.Lock()
+		first := !seen[g]
+		seen[g] = true
+		seenMu.Unlock()
+		if !first {
+			changed(g)
+		}
+	}
+	powerSettingMu.Unlock()
+
+	params := &deviceNotifySubscribeParameters{callback: powerSettingCallback, context: id}
+	var handles []uintptr
+	stop := func() {
+		for _, h := range handles {
+			procPowerSettingUnregisterNotification.Call(h)
+		}
+		runtime.KeepAlive(params)
+		powerSettingMu.Lock()
+		delete(powerSettingWatchers, id)
+		powerSettingMu.Unlock()
+	}
+	for i := range settings {
+		var h uintptr
+		r1, _, _ := procPowerSettingRegisterNotification.Call(
+			uintptr(unsafe.Pointer(&settings[i])),
+			deviceNotifyCallback,
+			uintptr(unsafe.Pointer(params)),
+			uintptr(unsafe.Pointer(&h)),
+		)
+		if r1 != 0 {
+			stop()
+			return nil, fmt.Errorf("PowerSettingRegisterNotification: %w", syscall.Errno(r1))
+		}
+		handles = append(handles, h)
+	}
+	return stop, nil
+}
+
+// PowerSourceChanges returns a channel that receives a value soon after the
+// machine moves between AC power and battery, until ctx is done, so that
+// AC-only sessions need not wait for the next poll. It returns nil when
+// the change cannot be watched.
+func PowerSourceChanges(ctx context.Context) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+	stop, err := watchPowerSettings([]powerSettingGUID{guidACDCPowerSource}, func(powerSettingGUID) {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		logger().Debug("power source notifications unavailable", "err", err)
+		return nil
+	}
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return changes
+}
+
+// startPowerSchemeWatchLocked re-asserts the execution state and power
+// request whenever the power plan, or its sleep or display timeout,
+// changes until ctx is done: changing them can restart the idle timers.
+// Callers must hold k.mu.
+func (k *windowsKeepAlive) startPowerSchemeWatchLocked(ctx context.Context) {
+	changes := make(chan powerSettingGUID, 1)
+	stop, err := watchPowerSettings(powerSchemeSettings, func(g powerSettingGUID) {
+		select {
+		case changes <- g:
+		default:
+		}
+	})
+	if err != nil {
+		logger().Debug("power scheme notifications unavailable", "err", err)
+		return
+	}
+	k.wg.Add(1)
+	go func() {
+		defer k.wg.Done()
+		defer stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case g := <-changes:
+				k.reassertAfterPowerChange(ctx, g)
+			}
+		}
+	}()
+}
+
+// reassertAfterPowerChange sets the session's execution state and power
+// request again after the power setting g changed.
+func (k *windowsKeepAlive) reassertAfterPowerChange(ctx context.Context, g powerSettingGUID) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if ctx.Err() != nil || !k.isRunning {
+		return
+	}
+	setting := "power plan"
+	switch g {
+	case guidStandbyTimeout:
+		setting = "sleep timeout"
+	case guidVideoPowerdownTimeout:
+		setting = "display timeout"
+	}
+	if err := setWindowsKeepAlive(k.state.Load()); err != nil {
+		logger().Warn("re-asserting the execution state failed", "changed", setting, "err", err)
+	}
+	if k.request != nil {
+		k.setPowerRequestLocked(k.request.types)
+	}
+	logger().Info("power settings changed; execution state re-asserted", "changed", setting)
+}
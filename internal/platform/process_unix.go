@@ -0,0 +1,28 @@
+//go:build darwin || linux
+
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// ProcessAlive reports whether a process with the given pid exists.
+func ProcessAlive(pid int) (bool, error) {
+	if pid <= 0 {
+		return false, fmt.Errorf("invalid pid %d", pid)
+	}
+	err := syscall.Kill(pid, 0)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, syscall.ESRCH):
+		return false, nil
+	case errors.Is(err, syscall.EPERM):
+		// The process exists but belongs to another user.
+		return true, nil
+	default:
+		return false, err
+	}
+}
@@ -0,0 +1,7 @@
+package platform
+
+// RemoteSessionNote says what a session holds when keep-alive runs in a
+// Remote Desktop session on Windows. The session runs as it does locally,
+// except that the pointer is not moved back after a jitter, since it follows
+// the client's pointer, and refused simulated input is warned about once.
+const RemoteSessionNote = "Running in a Remote Desktop session: the host is kept from sleeping, but the physical console is not kept awake and may still lock or turn its display off. Simulated input is refused while the session is disconnected, and some clients stop passing it on while their window is minimized."
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package platform
+
+// InRemoteSession reports whether keep-alive runs in a Remote Desktop
+// session, which only Windows has.
+func InRemoteSession() bool {
+	return false
+}
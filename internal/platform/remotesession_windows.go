@@ -0,0 +1,27 @@
+//go:build windows
+
+package platform
+
+const (
+	// smRemoteSession is the GetSystemMetrics index that is nonzero in a
+	// Remote Desktop session.
+	smRemoteSession = 0x1000
+
+	// The GetSystemMetrics indexes of the virtual screen's bounds.
+	smXVirtualScreen  = 76
+	smYVirtualScreen  = 77
+	smCXVirtualScreen = 78
+	smCYVirtualScreen = 79
+)
+
+var procGetSystemMetrics = user32.NewProc("GetSystemMetrics")
+
+// InRemoteSession reports whether keep-alive runs in a Remote Desktop
+// session rather than at the physical console.
+func InRemoteSession() bool {
+	if procGetSystemMetrics.Find() != nil {
+		return false
+	}
+	r1, _, _ := procGetSystemMetrics.Call(uintptr(smRemoteSession))
+	return r1 != 0
+}
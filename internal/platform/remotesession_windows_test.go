@@ -0,0 +1,45 @@
+//go:build windows
+
+package platform
+
+import "testing"
+
+func TestAbsoluteCoordinates(t *testing.T) {
+	// Two 1920x1080 monitors, the second to the left of the primary one.
+	screen := displayBounds{X: -1920, Y: 0, Width: 3840, Height: 1080}
+	for _, tt := range []struct {
+		x, y   int
+		wx, wy int32
+	}{
+		{-1920, 0, 0, 0},
+		{1919, 1079, 65535, 65535},
+		{0, 540, 32776, 32798},
+		{5000, -10, 65535, 0},
+	} {
+		if x, y := absoluteCoordinates(screen, tt.x, tt.y); x != tt.wx || y != tt.wy {
+			t.Errorf("absoluteCoordinates(%d, %d) = %d, %d, want %d, %d", tt.x, tt.y, x, y, tt.wx, tt.wy)
+		}
+	}
+}
+
+func TestRemoteSessionMovesToAbsolutePositions(t *testing.T) {
+	k := &windowsKeepAlive{}
+	k.remote.Store(true)
+	if method, _ := k.simulationMethodLocked(); method != "absolute" {
+		t.Errorf("method in a remote session = %q, want absolute", method)
+	}
+	k.simMethod = "SendInput"
+	if method, _ := k.simulationMethodLocked(); method != "SendInput" {
+		t.Errorf("chosen method in a remote session = %q, want SendInput", method)
+	}
+	k.simMethod = ""
+	k.simKey = "F15"
+	if method, _ := k.simulationMethodLocked(); method != "keyboard" {
+		t.Errorf("method with a key in a remote session = %q, want keyboard", method)
+	}
+	k.remote.Store(false)
+	k.simKey = ""
+	if method, _ := k.simulationMethodLocked(); method != "SendInput" {
+		t.Errorf("method at the console = %q, want SendInput", method)
+	}
+}
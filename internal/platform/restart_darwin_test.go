@@ -0,0 +1,75 @@
+//go:build darwin
+
+package platform
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCaffeinateRestartsAfterUnexpectedExit(t *testing.T) {
+	if _, err := exec.LookPath("caffeinate"); err != nil {
+		t.Skip("caffeinate not available")
+	}
+
+	k := &darwinKeepAlive{}
+	if err := k.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer k.Stop()
+
+	k.mu.Lock()
+	pid := k.cmd.Process.Pid
+	k.mu.Unlock()
+
+	if err := syscall.Kill(pid, syscall.SIGKILL); err != nil {
+		t.Fatalf("killing caffeinate: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		k.mu.Lock()
+		restarted := k.cmd != nil && k.cmd.Process.Pid != pid
+		k.mu.Unlock()
+		if restarted {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("caffeinate was not restarted")
+}
+
+func TestCaffeinateExitingAtOnceBacksOff(t *testing.T) {
+	// A caffeinate that exits as soon as it starts, as with assertion
+	// flags it rejects.
+	dir := t.TempDir()
+	starts := filepath.Join(dir, "starts")
+	script := "#!/bin/sh\necho started >> \"$KEEPALIVE_TEST_STARTS\"\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(dir, "caffeinate"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("KEEPALIVE_TEST_STARTS", starts)
+
+	k := &darwinKeepAlive{}
+	if err := k.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	time.Sleep(2500 * time.Millisecond)
+	k.Stop()
+
+	data, err := os.ReadFile(starts)
+	if err != nil {
+		t.Fatalf("caffeinate never started: %v", err)
+	}
+	// Started, restarted at once, then after 1s and 2s of backoff.
+	if n := bytes.Count(data, []byte("started")); n < 2 || n > 4 {
+		t.Fatalf("caffeinate started %d times in 2.5s, want it backing off", n)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build darwin
+
+package platform
+
+// ScreenLocked reports whether the screen of the console session is locked.
+func ScreenLocked() (bool, error) {
+	return screenLocked()
+}
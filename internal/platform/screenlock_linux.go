@@ -0,0 +1,24 @@
+//go:build linux
+
+package platform
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ScreenLocked reports whether the user's login session is locked, from
+// the LockedHint that screen lockers set through logind.
+func ScreenLocked() (bool, error) {
+	session := os.Getenv("XDG_SESSION_ID")
+	if session == "" {
+		return false, errors.New("XDG_SESSION_ID is not set; the login session is unknown")
+	}
+	out, err := exec.Command("loginctl", "show-session", session, "--property=LockedHint", "--value").Output()
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(out)) == "yes", nil
+}
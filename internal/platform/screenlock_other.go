@@ -0,0 +1,10 @@
+//go:build !darwin && !windows && !linux
+
+package platform
+
+import "errors"
+
+// ScreenLocked is unsupported on this platform.
+func ScreenLocked() (bool, error) {
+	return false, errors.New("screen lock detection is unsupported on this platform")
+}
@@ -0,0 +1,35 @@
+//go:build windows
+
+package platform
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// desktopSwitchDesktop is DESKTOP_SWITCHDESKTOP, the access right needed to
+// make a desktop the input desktop.
+const desktopSwitchDesktop = 0x0100
+
+var (
+	procOpenInputDesktop = user32.NewProc("OpenInputDesktop")
+	procSwitchDesktop    = user32.NewProc("SwitchDesktop")
+	procCloseDesktop     = user32.NewProc("CloseDesktop")
+)
+
+// ScreenLocked reports whether the workstation is locked. While it is, the
+// input desktop is the secure Winlogon desktop, which a user's process can
+// neither open nor switch to; the user's own desktop can always be switched
+// to, which leaves it as it is.
+func ScreenLocked() (bool, error) {
+	h, _, err := procOpenInputDesktop.Call(0, 0, desktopSwitchDesktop)
+	if h == 0 {
+		if err == syscall.ERROR_ACCESS_DENIED {
+			return true, nil
+		}
+		return false, fmt.Errorf("OpenInputDesktop: %w", err)
+	}
+	defer procCloseDesktop.Call(h)
+	r1, _, _ := procSwitchDesktop.Call(h)
+	return r1 == 0, nil
+}
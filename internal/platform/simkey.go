@@ -0,0 +1,49 @@
+package platform
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultSimulationKey is the key keyboard activity simulation taps unless
+// another is chosen. Like F13 to F24, it is missing from most keyboards and
+// ignored by applications, but counts as input, as Caffeine relies on.
+const DefaultSimulationKey = "F15"
+
+// simulationKeys are the keys keyboard activity simulation can tap, in
+// order, with their set 1 scan codes.
+var simulationKeys = []struct {
+	name     string
+	scancode uint16
+}{
+	{"F13", 0x64}, {"F14", 0x65}, {"F15", 0x66}, {"F16", 0x67},
+	{"F17", 0x68}, {"F18", 0x69}, {"F19", 0x6A}, {"F20", 0x6B},
+	{"F21", 0x6C}, {"F22", 0x6D}, {"F23", 0x6E}, {"F24", 0x76},
+}
+
+// SimulationKeys lists the keys keyboard activity simulation can tap.
+func SimulationKeys() []string {
+	names := make([]string, len(simulationKeys))
+	for i, k := range simulationKeys {
+		names[i] = k.name
+	}
+	return names
+}
+
+// ParseSimulationKey returns the canonical name of the key called name,
+// ignoring case.
+func ParseSimulationKey(name string) (string, error) {
+	_, canonical, err := simulationKeyScancode(name)
+	return canonical, err
+}
+
+// simulationKeyScancode returns the scan code and canonical name of the key
+// called name.
+func simulationKeyScancode(name string) (uint16, string, error) {
+	for _, k := range simulationKeys {
+		if strings.EqualFold(name, k.name) {
+			return k.scancode, k.name, nil
+		}
+	}
+	return 0, "", fmt.Errorf("unknown simulation key %q: use one of %s", name, strings.Join(SimulationKeys(), ", "))
+}
@@ -0,0 +1,26 @@
+package platform
+
+import "testing"
+
+func TestParseSimulationKey(t *testing.T) {
+	for _, tt := range []struct {
+		name, want string
+	}{
+		{"F15", "F15"},
+		{"f13", "F13"},
+		{"F24", "F24"},
+	} {
+		got, err := ParseSimulationKey(tt.name)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSimulationKey(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+	for _, name := range []string{"", "F12", "a", "shift"} {
+		if _, err := ParseSimulationKey(name); err == nil {
+			t.Errorf("ParseSimulationKey(%q) succeeded", name)
+		}
+	}
+	if code, _, _ := simulationKeyScancode(DefaultSimulationKey); code != 0x66 {
+		t.Errorf("F15 scan code = %#x, want 0x66", code)
+	}
+}
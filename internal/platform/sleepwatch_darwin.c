@@ -0,0 +1,63 @@
+//go:build darwin && cgo
+
+#include <CoreFoundation/CoreFoundation.h>
+#include <IOKit/IOMessage.h>
+#include <IOKit/pwr_mgt/IOPMLib.h>
+
+#include "_cgo_export.h"
+
+static io_connect_t ka_root_port = MACH_PORT_NULL;
+static IONotificationPortRef ka_notify_port = NULL;
+static io_object_t ka_notifier = 0;
+static volatile int ka_sleep_watch_stopping = 0;
+
+// ka_power_callback answers the power manager. kIOMessageCanSystemSleep is
+// idle sleep, which may be refused; kIOMessageSystemWillSleep is sleep that
+// was asked for, as by closing the lid, which may only be delayed, so it is
+// allowed at once.
+static void ka_power_callback(void *refcon, io_service_t service, uint32_t type, void *arg) {
+	switch (type) {
+	case kIOMessageCanSystemSleep:
+		if (kaSleepRequested(1)) {
+			IOAllowPowerChange(ka_root_port, (intptr_t)arg);
+		} else {
+			IOCancelPowerChange(ka_root_port, (intptr_t)arg);
+		}
+		break;
+	case kIOMessageSystemWillSleep:
+		kaSleepRequested(0);
+		IOAllowPowerChange(ka_root_port, (intptr_t)arg);
+		break;
+	}
+}
+
+int ka_sleep_watch_register(void) {
+	ka_sleep_watch_stopping = 0;
+	ka_root_port = IORegisterForSystemPower(NULL, &ka_notify_port, ka_power_callback, &ka_notifier);
+	if (ka_root_port == MACH_PORT_NULL) {
+		return 0;
+	}
+	CFRunLoopAddSource(CFRunLoopGetCurrent(), IONotificationPortGetRunLoopSource(ka_notify_port), kCFRunLoopDefaultMode);
+	return 1;
+}
+
+// ka_sleep_watch_run serves power notifications on the calling thread,
+// which must be the one that registered, until ka_sleep_watch_stop. The run
+// loop wakes every second to see whether it should stop, since stopping may
+// be asked for before it has started.
+void ka_sleep_watch_run(void) {
+	while (!ka_sleep_watch_stopping) {
+		CFRunLoopRunInMode(kCFRunLoopDefaultMode, 1.0, false);
+	}
+	CFRunLoopRemoveSource(CFRunLoopGetCurrent(), IONotificationPortGetRunLoopSource(ka_notify_port), kCFRunLoopDefaultMode);
+	IODeregisterForSystemPower(&ka_notifier);
+	IOServiceClose(ka_root_port);
+	IONotificationPortDestroy(ka_notify_port);
+	ka_root_port = MACH_PORT_NULL;
+	ka_notify_port = NULL;
+	ka_notifier = 0;
+}
+
+void ka_sleep_watch_stop(void) {
+	ka_sleep_watch_stopping = 1;
+}
@@ -0,0 +1,85 @@
+//go:build darwin && cgo
+
+package platform
+
+/*
+#cgo LDFLAGS: -framework IOKit -framework CoreFoundation
+
+int ka_sleep_watch_register(void);
+void ka_sleep_watch_run(void);
+void ka_sleep_watch_stop(void);
+*/
+import "C"
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+)
+
+var (
+	sleepWatchMu sync.Mutex
+	// sleepHandler decides on the system's attempts to sleep while a watch
+	// runs. The power manager has one callback for the process, so there is
+	// one watch at a time.
+	sleepHandler func(canVeto bool) (allow bool)
+)
+
+//export kaSleepRequested
+func kaSleepRequested(canVeto C.int) C.int {
+	sleepWatchMu.Lock()
+	handler := sleepHandler
+	sleepWatchMu.Unlock()
+	if handler == nil || handler(canVeto != 0) {
+		return 1
+	}
+	return 0
+}
+
+// startSleepWatch registers with IORegisterForSystemPower and calls handler
+// each time the system tries to sleep, until stop is called. canVeto is set
+// for idle sleep, which handler may refuse by returning false; other sleep
+// is allowed whatever it returns.
+func startSleepWatch(handler func(canVeto bool) (allow bool)) (stop func(), err error) {
+	sleepWatchMu.Lock()
+	if sleepHandler != nil {
+		sleepWatchMu.Unlock()
+		return nil, errors.New("sleep notifications are already watched")
+	}
+	sleepHandler = handler
+	sleepWatchMu.Unlock()
+
+	registered := make(chan bool, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// The notifications arrive on the run loop of the thread that
+		// registered for them.
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		if C.ka_sleep_watch_register() == 0 {
+			registered <- false
+			return
+		}
+		registered <- true
+		C.ka_sleep_watch_run()
+	}()
+
+	release := func() {
+		sleepWatchMu.Lock()
+		sleepHandler = nil
+		sleepWatchMu.Unlock()
+	}
+	if !<-registered {
+		release()
+		return nil, errors.New("IORegisterForSystemPower failed")
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			C.ka_sleep_watch_stop()
+			<-done
+			release()
+		})
+	}, nil
+}
@@ -0,0 +1,8 @@
+//go:build darwin && !cgo
+
+package platform
+
+// startSleepWatch needs IOKit, which builds without cgo cannot call.
+func startSleepWatch(func(canVeto bool) (allow bool)) (func(), error) {
+	return nil, errNoCgo
+}
@@ -0,0 +1,59 @@
+//go:build linux
+
+package platform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// launcherEntryPath is the object path the Unity LauncherEntry signal is sent
+// from. Docks only look at the application URI in the signal.
+const launcherEntryPath dbus.ObjectPath = "/com/canonical/unity/launcherentry/keepalive"
+
+// launcherAppURI returns the application URI of the dock icon to decorate.
+// Desktops pass the desktop file a program was launched from on to its
+// children in GIO_LAUNCHED_DESKTOP_FILE, so a terminal started from the dock
+// gets the progress on its own icon.
+func launcherAppURI() string {
+	if path := os.Getenv("GIO_LAUNCHED_DESKTOP_FILE"); path != "" {
+		return "application://" + filepath.Base(path)
+	}
+	return "application://keepalive.desktop"
+}
+
+// launcherEntryProps returns the properties of a LauncherEntry update, the
+// signal's a{sv} argument.
+func launcherEntryProps(fraction float64, visible bool) map[string]dbus.Variant {
+	return map[string]dbus.Variant{
+		"progress":         dbus.MakeVariant(min(max(fraction, 0), 1)),
+		"progress-visible": dbus.MakeVariant(visible),
+	}
+}
+
+// SetTaskbarProgress shows fraction, from 0 to 1, as a progress bar on the
+// dock icon through the Unity LauncherEntry D-Bus API, which Ubuntu Dock,
+// Dash to Dock, Plank and KDE's task manager understand.
+func SetTaskbarProgress(fraction float64) error {
+	return emitLauncherEntry(launcherEntryProps(fraction, true))
+}
+
+// ClearTaskbarProgress hides the progress bar shown by SetTaskbarProgress.
+func ClearTaskbarProgress() error {
+	return emitLauncherEntry(launcherEntryProps(0, false))
+}
+
+func emitLauncherEntry(props map[string]dbus.Variant) error {
+	conn, err := connectSessionBus()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.Emit(launcherEntryPath, "com.canonical.Unity.LauncherEntry.Update", launcherAppURI(), props); err != nil {
+		return fmt.Errorf("emit LauncherEntry update: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+//go:build linux
+
+package platform
+
+import "testing"
+
+func TestLauncherEntryProps(t *testing.T) {
+	tests := []struct {
+		fraction float64
+		visible  bool
+		want     float64
+	}{
+		{0.25, true, 0.25},
+		{1.5, true, 1},
+		{-0.5, true, 0},
+		{0, false, 0},
+	}
+	for _, tt := range tests {
+		props := launcherEntryProps(tt.fraction, tt.visible)
+		if got := props["progress"].Value(); got != tt.want {
+			t.Errorf("launcherEntryProps(%v, %v) progress = %v, want %v", tt.fraction, tt.visible, got, tt.want)
+		}
+		if got := props["progress-visible"].Value(); got != tt.visible {
+			t.Errorf("launcherEntryProps(%v, %v) progress-visible = %v, want %v", tt.fraction, tt.visible, got, tt.visible)
+		}
+	}
+}
+
+func TestLauncherAppURI(t *testing.T) {
+	t.Setenv("GIO_LAUNCHED_DESKTOP_FILE", "/usr/share/applications/org.gnome.Terminal.desktop")
+	if got, want := launcherAppURI(), "application://org.gnome.Terminal.desktop"; got != want {
+		t.Errorf("launcherAppURI() = %q, want %q", got, want)
+	}
+	t.Setenv("GIO_LAUNCHED_DESKTOP_FILE", "")
+	if got, want := launcherAppURI(), "application://keepalive.desktop"; got != want {
+		t.Errorf("launcherAppURI() = %q, want %q", got, want)
+	}
+}
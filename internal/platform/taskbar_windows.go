@@ -0,0 +1,119 @@
+//go:build windows
+
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	coinitApartmentThreaded = 0x2
+	clsctxInprocServer      = 0x1
+
+	// Taskbar progress states.
+	tbpfNoProgress = 0x0
+	tbpfNormal     = 0x2
+
+	// progressTotal is the denominator passed to SetProgressValue.
+	progressTotal = 1000
+)
+
+// ITaskbarList3 vtable slots.
+const (
+	taskbarRelease          = 2
+	taskbarHrInit           = 3
+	taskbarSetProgressValue = 9
+	taskbarSetProgressState = 10
+)
+
+var (
+	ole32                = syscall.NewLazyDLL("ole32.dll")
+	procCoInitializeEx   = ole32.NewProc("CoInitializeEx")
+	procCoUninitialize   = ole32.NewProc("CoUninitialize")
+	procCoCreateInstance = ole32.NewProc("CoCreateInstance")
+	procGetConsoleWindow = kernel32.NewProc("GetConsoleWindow")
+
+	clsidTaskbarList = syscall.GUID{Data1: 0x56fdf344, Data2: 0xfd6d, Data3: 0x11d0, Data4: [8]byte{0x95, 0x8a, 0x00, 0x60, 0x97, 0xc9, 0xa0, 0x90}}
+	iidTaskbarList3  = syscall.GUID{Data1: 0xea1afb91, Data2: 0x9e28, Data3: 0x4b86, Data4: [8]byte{0x90, 0xe9, 0x9e, 0x9f, 0x8a, 0x5e, 0xef, 0xaf}}
+)
+
+// taskbarList3 is the COM object layout: a pointer to its method table.
+type taskbarList3 struct {
+	vtbl *[11]uintptr
+}
+
+func (t *taskbarList3) call(slot int, args ...uintptr) error {
+	hr, _, _ := syscall.SyscallN(t.vtbl[slot], append([]uintptr{uintptr(unsafe.Pointer(t))}, args...)...)
+	if int32(hr) < 0 {
+		return fmt.Errorf("HRESULT 0x%08x", uint32(hr))
+	}
+	return nil
+}
+
+// withTaskbarList runs fn with the ITaskbarList3 interface and the console
+// window. The taskbar keeps the state set for a window after the interface
+// is released.
+func withTaskbarList(fn func(tbl *taskbarList3, hwnd uintptr) error) error {
+	hwnd, _, _ := procGetConsoleWindow.Call()
+	if hwnd == 0 {
+		return errors.New("no console window")
+	}
+
+	// COM initialisation is per thread.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	hr, _, _ := procCoInitializeEx.Call(0, coinitApartmentThreaded)
+	if int32(hr) < 0 {
+		return fmt.Errorf("CoInitializeEx failed: HRESULT 0x%08x", uint32(hr))
+	}
+	defer procCoUninitialize.Call()
+
+	var tbl *taskbarList3
+	hr, _, _ = procCoCreateInstance.Call(
+		uintptr(unsafe.Pointer(&clsidTaskbarList)),
+		0,
+		clsctxInprocServer,
+		uintptr(unsafe.Pointer(&iidTaskbarList3)),
+		uintptr(unsafe.Pointer(&tbl)),
+	)
+	if int32(hr) < 0 || tbl == nil {
+		return fmt.Errorf("ITaskbarList3 unavailable: HRESULT 0x%08x", uint32(hr))
+	}
+	defer tbl.call(taskbarRelease)
+
+	if err := tbl.call(taskbarHrInit); err != nil {
+		return fmt.Errorf("ITaskbarList3.HrInit failed: %v", err)
+	}
+	return fn(tbl, hwnd)
+}
+
+// SetTaskbarProgress shows fraction, from 0 to 1, as a progress bar on the
+// taskbar button of the console window. Windows Terminal hosts consoles in a
+// hidden window, so the bar only appears for classic console windows.
+func SetTaskbarProgress(fraction float64) error {
+	completed := uintptr(min(max(fraction, 0), 1) * progressTotal)
+	return withTaskbarList(func(tbl *taskbarList3, hwnd uintptr) error {
+		if err := tbl.call(taskbarSetProgressState, hwnd, tbpfNormal); err != nil {
+			return fmt.Errorf("SetProgressState failed: %v", err)
+		}
+		if err := tbl.call(taskbarSetProgressValue, hwnd, completed, progressTotal); err != nil {
+			return fmt.Errorf("SetProgressValue failed: %v", err)
+		}
+		return nil
+	})
+}
+
+// ClearTaskbarProgress removes the progress bar shown by SetTaskbarProgress.
+func ClearTaskbarProgress() error {
+	return withTaskbarList(func(tbl *taskbarList3, hwnd uintptr) error {
+		if err := tbl.call(taskbarSetProgressState, hwnd, tbpfNoProgress); err != nil {
+			return fmt.Errorf("SetProgressState failed: %v", err)
+		}
+		return nil
+	})
+}
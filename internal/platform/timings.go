@@ -0,0 +1,74 @@
+package platform
+
+import (
+	"fmt"
+	"time"
+)
+
+// Bounds for user-supplied Timings. They keep simulation from fighting an
+// active user and stop intervals that would defeat the OS idle timers.
+const (
+	MinIdleThreshold           = 10 * time.Second
+	MaxIdleThreshold           = time.Hour
+	MinActivityInterval        = time.Second
+	MaxActivityInterval        = 5 * time.Minute
+	MinChatAppActivityInterval = ChatAppCheckInterval
+	MaxChatAppActivityInterval = 30 * time.Minute
+)
+
+// Timings holds the tunable activity intervals. A zero field selects the
+// corresponding default constant.
+type Timings struct {
+	// IdleThreshold is the idle time required before simulating activity.
+	IdleThreshold time.Duration
+	// ActivityInterval is the period of system-level activity assertions.
+	ActivityInterval time.Duration
+	// ChatAppActivityInterval is the minimum gap between mouse jitters.
+	ChatAppActivityInterval time.Duration
+}
+
+// DefaultTimings returns the built-in intervals.
+func DefaultTimings() Timings {
+	return Timings{
+		IdleThreshold:           IdleThreshold,
+		ActivityInterval:        ActivityInterval,
+		ChatAppActivityInterval: ChatAppActivityInterval,
+	}
+}
+
+// WithDefaults returns t with zero fields replaced by their defaults.
+func (t Timings) WithDefaults() Timings {
+	d := DefaultTimings()
+	if t.IdleThreshold == 0 {
+		t.IdleThreshold = d.IdleThreshold
+	}
+	if t.ActivityInterval == 0 {
+		t.ActivityInterval = d.ActivityInterval
+	}
+	if t.ChatAppActivityInterval == 0 {
+		t.ChatAppActivityInterval = d.ChatAppActivityInterval
+	}
+	return t
+}
+
+// Validate reports the first non-zero field outside its bounds.
+func (t Timings) Validate() error {
+	checks := []struct {
+		name     string
+		value    time.Duration
+		min, max time.Duration
+	}{
+		{"idle threshold", t.IdleThreshold, MinIdleThreshold, MaxIdleThreshold},
+		{"activity interval", t.ActivityInterval, MinActivityInterval, MaxActivityInterval},
+		{"simulation interval", t.ChatAppActivityInterval, MinChatAppActivityInterval, MaxChatAppActivityInterval},
+	}
+	for _, c := range checks {
+		if c.value == 0 {
+			continue
+		}
+		if c.value < c.min || c.value > c.max {
+			return fmt.Errorf("%s must be between %s and %s, got %s", c.name, c.min, c.max, c.value)
+		}
+	}
+	return nil
+}
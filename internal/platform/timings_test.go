@@ -0,0 +1,67 @@
+package platform
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimingsWithDefaults(t *testing.T) {
+	got := Timings{IdleThreshold: 30 * time.Second}.WithDefaults()
+	want := Timings{
+		IdleThreshold:           30 * time.Second,
+		ActivityInterval:        ActivityInterval,
+		ChatAppActivityInterval: ChatAppActivityInterval,
+	}
+	if got != want {
+		t.Fatalf("WithDefaults() = %+v, want %+v", got, want)
+	}
+}
+
+func TestTimingsValidate(t *testing.T) {
+	valid := []Timings{
+		{},
+		DefaultTimings(),
+		{IdleThreshold: 30 * time.Second, ChatAppActivityInterval: 45 * time.Second},
+	}
+	for _, tt := range valid {
+		if err := tt.Validate(); err != nil {
+			t.Errorf("Validate(%+v) error = %v", tt, err)
+		}
+	}
+
+	invalid := []Timings{
+		{IdleThreshold: time.Second},
+		{IdleThreshold: 2 * time.Hour},
+		{ActivityInterval: -time.Second},
+		{ChatAppActivityInterval: time.Second},
+	}
+	for _, tt := range invalid {
+		if err := tt.Validate(); err == nil {
+			t.Errorf("Validate(%+v) expected error", tt)
+		}
+	}
+}
+
+func TestActivityControllerUsesIdleThreshold(t *testing.T) {
+	ac := NewActivityController("test", NewMousePatternGenerator(newCryptoSeededRand()))
+	ac.SetTimings(Timings{IdleThreshold: 30 * time.Second})
+	// Pretend the user was last active well before the threshold.
+	ac.lastUserActiveNS = time.Now().Add(-time.Minute).UnixNano()
+
+	jittered := ac.MaybeJitter(
+		func() (time.Duration, error) { return 45 * time.Second, nil },
+		func([]MousePoint, time.Duration) {},
+	)
+	if !jittered {
+		t.Fatal("expected jitter after exceeding a 30s idle threshold")
+	}
+
+	ac = NewActivityController("test", NewMousePatternGenerator(newCryptoSeededRand()))
+	ac.lastUserActiveNS = time.Now().Add(-time.Minute).UnixNano()
+	if ac.MaybeJitter(
+		func() (time.Duration, error) { return 45 * time.Second, nil },
+		func([]MousePoint, time.Duration) {},
+	) {
+		t.Fatal("expected no jitter below the default idle threshold")
+	}
+}
@@ -0,0 +1,137 @@
+//go:build linux
+
+package platform
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	// uinputFailureThreshold is the number of consecutive jitters that must
+	// fail before the uinput device is considered broken.
+	uinputFailureThreshold = 3
+
+	// Bounds for the delay between attempts to reopen a broken device.
+	uinputRecoveryMinBackoff = 5 * time.Second
+	uinputRecoveryMaxBackoff = 5 * time.Minute
+)
+
+// uinputRecovery tracks uinput write failures and decides when a broken
+// device is reopened. A device becomes invalid when the uinput module is
+// reloaded or /dev/uinput permissions change mid-session; reopening is then
+// retried with exponential backoff so a device that stays unavailable is not
+// hammered every cycle.
+type uinputRecovery struct {
+	failures int
+	broken   bool
+	backoff  time.Duration
+	nextTry  time.Time
+}
+
+// recordSuccess resets the failure count after a jitter completed.
+func (r *uinputRecovery) recordSuccess() {
+	r.failures = 0
+}
+
+// recordFailure counts a failed jitter and reports whether the device just
+// became broken.
+func (r *uinputRecovery) recordFailure(now time.Time) bool {
+	if r.broken {
+		return false
+	}
+	r.failures++
+	if r.failures < uinputFailureThreshold {
+		return false
+	}
+	r.broken = true
+	r.backoff = uinputRecoveryMinBackoff
+	r.nextTry = now.Add(r.backoff)
+	return true
+}
+
+// shouldRetry reports whether a broken device may be reopened now.
+func (r *uinputRecovery) shouldRetry(now time.Time) bool {
+	return r.broken && !now.Before(r.nextTry)
+}
+
+// retryFailed doubles the backoff after an unsuccessful reopen.
+func (r *uinputRecovery) retryFailed(now time.Time) {
+	r.backoff *= 2
+	if r.backoff > uinputRecoveryMaxBackoff {
+		r.backoff = uinputRecoveryMaxBackoff
+	}
+	r.nextTry = now.Add(r.backoff)
+}
+
+// recovered clears the broken state after the device was reopened.
+func (r *uinputRecovery) recovered() {
+	*r = uinputRecovery{}
+}
+
+// executePatternUinputRecovering runs the pattern through uinput, closing the
+// device after persistent failures and reopening it once the backoff allows.
+// It returns false whenever the caller should fall back to the next mover.
+// Callers hold jitterMu; the recovery state is guarded by k.mu, since Stop
+// resets it.
+func (k *linuxKeepAlive) executePatternUinputRecovering(ctx context.Context, points []MousePoint, sessionDuration time.Duration) bool {
+	now := time.Now()
+	k.mu.Lock()
+	broken, retry := k.uinputRecovery.broken, k.uinputRecovery.shouldRetry(now)
+	k.mu.Unlock()
+	if broken {
+		if !retry {
+			return false
+		}
+		if err := k.reopenUinput(); err != nil {
+			k.mu.Lock()
+			k.uinputRecovery.retryFailed(now)
+			backoff := k.uinputRecovery.backoff
+			k.mu.Unlock()
+			logger().Warn("uinput reopen failed", "retry_in", backoff, "err", err)
+			return false
+		}
+		logger().Info("uinput device reopened")
+	}
+
+	if k.executePatternUinput(ctx, points, sessionDuration) {
+		k.mu.Lock()
+		k.uinputRecovery.recordSuccess()
+		k.mu.Unlock()
+		return true
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !k.isRunning || !k.uinputRecovery.recordFailure(now) {
+		return false
+	}
+	logger().Warn("uinput failing; closing the device", "failures", uinputFailureThreshold, "retry_in", k.uinputRecovery.backoff)
+	if k.uinput != nil {
+		k.uinput.close()
+		k.uinput = nil
+	}
+	return false
+}
+
+// reopenUinput creates a fresh uinput device for the running session and
+// clears the broken state.
+func (k *linuxKeepAlive) reopenUinput() error {
+	sim := &uinputSimulator{}
+	if err := sim.setup(); err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !k.isRunning {
+		sim.close()
+		return errors.New("keep-alive stopped")
+	}
+	k.uinput = sim
+	k.uinputRecovery.recovered()
+	return nil
+}
@@ -0,0 +1,69 @@
+//go:build linux
+
+package platform
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestUinputRecoveryBackoff(t *testing.T) {
+	var r uinputRecovery
+	now := time.Unix(1000, 0)
+
+	for i := 1; i < uinputFailureThreshold; i++ {
+		if r.recordFailure(now) {
+			t.Fatalf("device marked broken after %d failures", i)
+		}
+	}
+	r.recordSuccess()
+	for i := 1; i < uinputFailureThreshold; i++ {
+		r.recordFailure(now)
+	}
+	if r.broken {
+		t.Fatal("a success should reset the consecutive failure count")
+	}
+	if !r.recordFailure(now) {
+		t.Fatalf("device not marked broken after %d consecutive failures", uinputFailureThreshold)
+	}
+	if r.recordFailure(now) {
+		t.Fatal("recordFailure should report the transition to broken only once")
+	}
+
+	if r.shouldRetry(now) {
+		t.Fatal("expected to wait before the first reopen")
+	}
+	if !r.shouldRetry(now.Add(uinputRecoveryMinBackoff)) {
+		t.Fatal("expected a reopen once the minimum backoff elapsed")
+	}
+
+	for i := 0; i < 10; i++ {
+		r.retryFailed(now)
+	}
+	if r.backoff != uinputRecoveryMaxBackoff {
+		t.Fatalf("backoff = %s, want capped at %s", r.backoff, uinputRecoveryMaxBackoff)
+	}
+	if r.shouldRetry(now.Add(uinputRecoveryMaxBackoff - time.Second)) {
+		t.Fatal("expected to wait for the full backoff")
+	}
+
+	r.recovered()
+	if r.broken || r.failures != 0 || r.shouldRetry(now) {
+		t.Fatalf("recovered() left state %+v", r)
+	}
+}
+
+func TestUinputRecoveringAfterStop(t *testing.T) {
+	// Stop clears k.ctx once it stops waiting for the jitter goroutine, so
+	// the pattern must run against the context it was started with.
+	k := &linuxKeepAlive{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if k.executePatternUinputRecovering(ctx, []MousePoint{{X: 1, Y: 1}}, time.Second) {
+		t.Fatal("pattern ran on a stopped keep-alive")
+	}
+	if k.uinputRecovery.failures != 0 {
+		t.Fatalf("failures = %d, want none counted after the session ended", k.uinputRecovery.failures)
+	}
+}
@@ -0,0 +1,534 @@
+//go:build linux
+
+package platform
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Requests and events of the Wayland core protocol and of the
+// idle-inhibit-unstable-v1 and wlr-layer-shell-unstable-v1 extensions, by
+// opcode. Only the messages keep-alive sends or reads are listed.
+const (
+	wlDisplayID = 1
+
+	wlDisplaySync        = 0
+	wlDisplayGetRegistry = 1
+	wlDisplayEventError  = 0
+
+	wlRegistryBind        = 0
+	wlRegistryEventGlobal = 0
+
+	wlCallbackEventDone = 0
+
+	wlCompositorCreateSurface = 0
+	wlCompositorCreateRegion  = 1
+
+	wlSurfaceAttach         = 1
+	wlSurfaceSetInputRegion = 5
+	wlSurfaceCommit         = 6
+
+	wlRegionDestroy = 0
+
+	wlShmCreatePool       = 0
+	wlShmPoolCreateBuffer = 0
+	wlShmPoolDestroy      = 1
+	wlShmFormatARGB8888   = 0
+
+	zwpIdleInhibitManagerCreateInhibitor = 1
+
+	zwlrLayerShellGetLayerSurface = 0
+	zwlrLayerShellLayerOverlay    = 3
+
+	zwlrLayerSurfaceSetSize        = 0
+	zwlrLayerSurfaceSetAnchor      = 1
+	zwlrLayerSurfaceAckConfigure   = 6
+	zwlrLayerSurfaceEventConfigure = 0
+	zwlrLayerSurfaceEventClosed    = 1
+	zwlrLayerSurfaceAnchorTopLeft  = 1 | 4
+)
+
+// waylandSetupTimeout bounds the exchange that sets up an inhibitor, so that
+// a compositor that stops answering cannot hold up a session start.
+const waylandSetupTimeout = 2 * time.Second
+
+// waylandGlobal is a global object the compositor advertises.
+type waylandGlobal struct {
+	name    uint32
+	version uint32
+}
+
+// waylandEvent is a message from the compositor.
+type waylandEvent struct {
+	sender uint32
+	opcode uint16
+	body   []byte
+}
+
+// waylandConn is a minimal client of the Wayland wire protocol: enough to
+// bind globals, create objects and read the events that answer them.
+type waylandConn struct {
+	conn   *net.UnixConn
+	in     *bufio.Reader
+	mu     sync.Mutex // serializes writes
+	nextID uint32
+
+	registry uint32
+}
+
+// dialWayland connects to the compositor named by WAYLAND_DISPLAY.
+func dialWayland() (*waylandConn, error) {
+	name := os.Getenv("WAYLAND_DISPLAY")
+	if name == "" {
+		name = "wayland-0"
+	}
+	path := name
+	if !filepath.IsAbs(path) {
+		dir := os.Getenv("XDG_RUNTIME_DIR")
+		if dir == "" {
+			return nil, errors.New("XDG_RUNTIME_DIR is not set; the Wayland socket cannot be found")
+		}
+		path = filepath.Join(dir, name)
+	}
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("connect to Wayland compositor: %w", err)
+	}
+	return newWaylandConn(conn), nil
+}
+
+func newWaylandConn(conn *net.UnixConn) *waylandConn {
+	return &waylandConn{conn: conn, in: bufio.NewReader(conn), nextID: wlDisplayID + 1}
+}
+
+func (c *waylandConn) close() error {
+	return c.conn.Close()
+}
+
+// newID allocates a client object id.
+func (c *waylandConn) newID() uint32 {
+	id := c.nextID
+	c.nextID++
+	return id
+}
+
+// encodeWaylandMessage encodes a request to object id. Arguments are
+// uint32 for uint, object and new_id arguments, int32 for int and string
+// for string arguments.
+func encodeWaylandMessage(id uint32, opcode uint16, args ...interface{}) []byte {
+	body := make([]byte, 0, 32)
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case uint32:
+			body = binary.NativeEndian.AppendUint32(body, v)
+		case int32:
+			body = binary.NativeEndian.AppendUint32(body, uint32(v))
+		case string:
+			body = binary.NativeEndian.AppendUint32(body, uint32(len(v)+1))
+			body = append(body, v...)
+			body = append(body, 0)
+			for len(body)%4 != 0 {
+				body = append(body, 0)
+			}
+		default:
+			panic(fmt.Sprintf("unsupported Wayland argument %T", arg))
+		}
+	}
+	msg := make([]byte, 0, 8+len(body))
+	msg = binary.NativeEndian.AppendUint32(msg, id)
+	msg = binary.NativeEndian.AppendUint32(msg, uint32(8+len(body))<<16|uint32(opcode))
+	return append(msg, body...)
+}
+
+// send sends a request to object id.
+func (c *waylandConn) send(id uint32, opcode uint16, args ...interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.conn.Write(encodeWaylandMessage(id, opcode, args...))
+	return err
+}
+
+// sendFD sends a request to object id that carries fd along with args.
+func (c *waylandConn) sendFD(id uint32, opcode uint16, fd int, args ...interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, _, err := c.conn.WriteMsgUnix(encodeWaylandMessage(id, opcode, args...), unix.UnixRights(fd), nil)
+	return err
+}
+
+// read reads the next event.
+func (c *waylandConn) read() (waylandEvent, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(c.in, header[:]); err != nil {
+		return waylandEvent{}, err
+	}
+	sizeOpcode := binary.NativeEndian.Uint32(header[4:])
+	size := int(sizeOpcode >> 16)
+	if size < 8 {
+		return waylandEvent{}, fmt.Errorf("malformed Wayland message of %d bytes", size)
+	}
+	ev := waylandEvent{
+		sender: binary.NativeEndian.Uint32(header[:4]),
+		opcode: uint16(sizeOpcode),
+		body:   make([]byte, size-8),
+	}
+	if _, err := io.ReadFull(c.in, ev.body); err != nil {
+		return waylandEvent{}, err
+	}
+	return ev, nil
+}
+
+// waylandUint reads a uint argument off the front of body.
+func waylandUint(body []byte) (uint32, []byte, error) {
+	if len(body) < 4 {
+		return 0, nil, errors.New("truncated Wayland message")
+	}
+	return binary.NativeEndian.Uint32(body), body[4:], nil
+}
+
+// waylandString reads a string argument off the front of body.
+func waylandString(body []byte) (string, []byte, error) {
+	n, rest, err := waylandUint(body)
+	if err != nil {
+		return "", nil, err
+	}
+	padded := (int(n) + 3) &^ 3
+	if n == 0 || padded > len(rest) {
+		return "", nil, errors.New("truncated Wayland string")
+	}
+	return string(rest[:n-1]), rest[padded:], nil
+}
+
+// displayError turns a wl_display.error event into an error.
+func displayError(ev waylandEvent) error {
+	_, rest, err := waylandUint(ev.body)
+	if err != nil {
+		return err
+	}
+	code, rest, err := waylandUint(rest)
+	if err != nil {
+		return err
+	}
+	msg, _, err := waylandString(rest)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("Wayland protocol error %d: %s", code, msg)
+}
+
+// roundtrip waits until the compositor has handled every request sent so
+// far, passing each event that arrives meanwhile to handle.
+func (c *waylandConn) roundtrip(handle func(waylandEvent) error) error {
+	callback := c.newID()
+	if err := c.send(wlDisplayID, wlDisplaySync, callback); err != nil {
+		return err
+	}
+	for {
+		ev, err := c.read()
+		if err != nil {
+			return err
+		}
+		switch {
+		case ev.sender == callback && ev.opcode == wlCallbackEventDone:
+			return nil
+		case ev.sender == wlDisplayID && ev.opcode == wlDisplayEventError:
+			return displayError(ev)
+		case handle != nil:
+			if err := handle(ev); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// globals lists the compositor's globals by interface name.
+func (c *waylandConn) globals() (map[string]waylandGlobal, error) {
+	c.registry = c.newID()
+	if err := c.send(wlDisplayID, wlDisplayGetRegistry, c.registry); err != nil {
+		return nil, err
+	}
+	globals := map[string]waylandGlobal{}
+	err := c.roundtrip(func(ev waylandEvent) error {
+		if ev.sender != c.registry || ev.opcode != wlRegistryEventGlobal {
+			return nil
+		}
+		name, rest, err := waylandUint(ev.body)
+		if err != nil {
+			return err
+		}
+		iface, rest, err := waylandString(rest)
+		if err != nil {
+			return err
+		}
+		version, _, err := waylandUint(rest)
+		if err != nil {
+			return err
+		}
+		globals[iface] = waylandGlobal{name: name, version: version}
+		return nil
+	})
+	return globals, err
+}
+
+// bind binds global g of interface iface at version and returns its id.
+func (c *waylandConn) bind(g waylandGlobal, iface string, version uint32) (uint32, error) {
+	id := c.newID()
+	return id, c.send(c.registry, wlRegistryBind, g.name, iface, min(version, g.version), id)
+}
+
+// waylandIdleInhibit holds a zwp_idle_inhibitor_v1 on a surface of its own.
+type waylandIdleInhibit struct {
+	conn         *waylandConn
+	surface      uint32
+	layerSurface uint32
+}
+
+// inhibitIdle creates an idle inhibitor. Where the compositor offers the
+// layer shell, the inhibitor's surface is mapped as a transparent 1x1 pixel
+// that takes no input, since Hyprland and River only honour inhibitors on
+// visible surfaces; sway honours one on a surface without a role.
+func (c *waylandConn) inhibitIdle() (*waylandIdleInhibit, error) {
+	globals, err := c.globals()
+	if err != nil {
+		return nil, err
+	}
+	manager, ok := globals["zwp_idle_inhibit_manager_v1"]
+	if !ok {
+		return nil, errors.New("the compositor does not offer zwp_idle_inhibit_manager_v1")
+	}
+	compositor, ok := globals["wl_compositor"]
+	if !ok {
+		return nil, errors.New("the compositor does not offer wl_compositor")
+	}
+	compositorID, err := c.bind(compositor, "wl_compositor", 4)
+	if err != nil {
+		return nil, err
+	}
+	managerID, err := c.bind(manager, "zwp_idle_inhibit_manager_v1", 1)
+	if err != nil {
+		return nil, err
+	}
+	inh := &waylandIdleInhibit{conn: c, surface: c.newID()}
+	if err := c.send(compositorID, wlCompositorCreateSurface, inh.surface); err != nil {
+		return nil, err
+	}
+
+	layerShell, hasLayerShell := globals["zwlr_layer_shell_v1"]
+	shm, hasShm := globals["wl_shm"]
+	if hasLayerShell && hasShm {
+		if err := inh.mapLayerSurface(compositorID, layerShell, shm); err != nil {
+			return nil, fmt.Errorf("map the inhibitor's surface: %w", err)
+		}
+	}
+
+	if err := c.send(managerID, zwpIdleInhibitManagerCreateInhibitor, c.newID(), inh.surface); err != nil {
+		return nil, err
+	}
+	if err := c.roundtrip(nil); err != nil {
+		return nil, err
+	}
+	return inh, nil
+}
+
+// mapLayerSurface gives the surface a layer-shell role on the overlay layer
+// and a transparent 1x1 buffer, with an empty input region.
+func (inh *waylandIdleInhibit) mapLayerSurface(compositorID uint32, layerShell, shm waylandGlobal) error {
+	c := inh.conn
+	region := c.newID()
+	if err := c.send(compositorID, wlCompositorCreateRegion, region); err != nil {
+		return err
+	}
+	if err := c.send(inh.surface, wlSurfaceSetInputRegion, region); err != nil {
+		return err
+	}
+	if err := c.send(region, wlRegionDestroy); err != nil {
+		return err
+	}
+
+	shellID, err := c.bind(layerShell, "zwlr_layer_shell_v1", 1)
+	if err != nil {
+		return err
+	}
+	inh.layerSurface = c.newID()
+	if err := c.send(shellID, zwlrLayerShellGetLayerSurface, inh.layerSurface, inh.surface, uint32(0), uint32(zwlrLayerShellLayerOverlay), "keepalive"); err != nil {
+		return err
+	}
+	if err := c.send(inh.layerSurface, zwlrLayerSurfaceSetSize, uint32(1), uint32(1)); err != nil {
+		return err
+	}
+	if err := c.send(inh.layerSurface, zwlrLayerSurfaceSetAnchor, uint32(zwlrLayerSurfaceAnchorTopLeft)); err != nil {
+		return err
+	}
+	if err := c.send(inh.surface, wlSurfaceCommit); err != nil {
+		return err
+	}
+
+	// The compositor answers the first commit with a configure event,
+	// which must be acknowledged before a buffer is attached.
+	var serial uint32
+	configured := false
+	err = c.roundtrip(func(ev waylandEvent) error {
+		if ev.sender == inh.layerSurface && ev.opcode == zwlrLayerSurfaceEventConfigure {
+			s, _, err := waylandUint(ev.body)
+			serial, configured = s, err == nil
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !configured {
+		return errors.New("the layer surface was not configured")
+	}
+	if err := c.send(inh.layerSurface, zwlrLayerSurfaceAckConfigure, serial); err != nil {
+		return err
+	}
+
+	shmID, err := c.bind(shm, "wl_shm", 1)
+	if err != nil {
+		return err
+	}
+	buffer, err := c.transparentPixel(shmID)
+	if err != nil {
+		return err
+	}
+	if err := c.send(inh.surface, wlSurfaceAttach, buffer, int32(0), int32(0)); err != nil {
+		return err
+	}
+	return c.send(inh.surface, wlSurfaceCommit)
+}
+
+// transparentPixel creates a 1x1 wl_buffer holding a fully transparent
+// pixel and returns its id.
+func (c *waylandConn) transparentPixel(shmID uint32) (uint32, error) {
+	const size = 4 // one ARGB8888 pixel, all zero
+	fd, err := unix.MemfdCreate("keepalive-pixel", unix.MFD_CLOEXEC)
+	if err != nil {
+		return 0, fmt.Errorf("memfd_create: %w", err)
+	}
+	defer unix.Close(fd)
+	if err := unix.Ftruncate(fd, size); err != nil {
+		return 0, fmt.Errorf("ftruncate: %w", err)
+	}
+	pool := c.newID()
+	if err := c.sendFD(shmID, wlShmCreatePool, fd, pool, int32(size)); err != nil {
+		return 0, err
+	}
+	buffer := c.newID()
+	if err := c.send(pool, wlShmPoolCreateBuffer, buffer, int32(0), int32(1), int32(1), int32(size), uint32(wlShmFormatARGB8888)); err != nil {
+		return 0, err
+	}
+	return buffer, c.send(pool, wlShmPoolDestroy)
+}
+
+// dispatch reads events until the connection closes, acknowledging the
+// layer surface's configure events. It calls lost if the compositor closes
+// the surface, reports an error or goes away.
+func (inh *waylandIdleInhibit) dispatch(lost func()) {
+	c := inh.conn
+	for {
+		ev, err := c.read()
+		if err != nil {
+			lost()
+			return
+		}
+		switch {
+		case ev.sender == wlDisplayID && ev.opcode == wlDisplayEventError:
+			logger().Warn("Wayland idle inhibitor failed", "err", displayError(ev))
+			lost()
+			return
+		case inh.layerSurface != 0 && ev.sender == inh.layerSurface && ev.opcode == zwlrLayerSurfaceEventClosed:
+			lost()
+			return
+		case inh.layerSurface != 0 && ev.sender == inh.layerSurface && ev.opcode == zwlrLayerSurfaceEventConfigure:
+			serial, _, err := waylandUint(ev.body)
+			if err == nil {
+				_ = c.send(inh.layerSurface, zwlrLayerSurfaceAckConfigure, serial)
+				_ = c.send(inh.surface, wlSurfaceCommit)
+			}
+		}
+	}
+}
+
+// waylandIdleInhibitSupported reports whether the compositor offers
+// zwp_idle_inhibit_manager_v1.
+func waylandIdleInhibitSupported() bool {
+	c, err := dialWayland()
+	if err != nil {
+		return false
+	}
+	defer c.close()
+	_ = c.conn.SetDeadline(time.Now().Add(waylandSetupTimeout))
+	globals, err := c.globals()
+	if err != nil {
+		return false
+	}
+	_, ok := globals["zwp_idle_inhibit_manager_v1"]
+	return ok
+}
+
+// waylandIdleInhibitor holds off idle through the compositor's
+// idle-inhibit protocol, for compositors such as sway, Hyprland and River
+// that run none of the desktop D-Bus services. It blanks neither the
+// screen nor locks the session while the compositor honours it.
+type waylandIdleInhibitor struct {
+	inhibit *waylandIdleInhibit
+	// lost is set once the compositor drops the inhibitor taken by the
+	// latest Activate.
+	lost *atomic.Bool
+}
+
+func (w *waylandIdleInhibitor) Name() string { return "wayland-idle-inhibit" }
+func (w *waylandIdleInhibitor) Activate(ctx context.Context) error {
+	w.release()
+	c, err := dialWayland()
+	if err != nil {
+		return err
+	}
+	_ = c.conn.SetDeadline(time.Now().Add(waylandSetupTimeout))
+	inh, err := c.inhibitIdle()
+	if err != nil {
+		c.close()
+		return err
+	}
+	_ = c.conn.SetDeadline(time.Time{})
+
+	lost := new(atomic.Bool)
+	w.inhibit, w.lost = inh, lost
+	go inh.dispatch(func() { lost.Store(true) })
+	logger().Info("Wayland idle inhibitor created", "layer_surface", inh.layerSurface != 0)
+	return nil
+}
+
+func (w *waylandIdleInhibitor) Deactivate() error {
+	return w.release()
+}
+
+// release closes the connection, which destroys the inhibitor.
+func (w *waylandIdleInhibitor) release() error {
+	if w.inhibit == nil {
+		return nil
+	}
+	err := w.inhibit.conn.close()
+	w.inhibit = nil
+	return err
+}
+
+// held reports whether the inhibitor is in place.
+func (w *waylandIdleInhibitor) held() bool {
+	return w.inhibit != nil && !w.lost.Load()
+}
@@ -0,0 +1,143 @@
+//go:build linux
+
+package platform
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestEncodeWaylandMessage(t *testing.T) {
+	got := encodeWaylandMessage(2, wlRegistryBind, uint32(7), "wl_shm", uint32(1), uint32(5))
+	var want []byte
+	for _, v := range []uint32{2, 32<<16 | wlRegistryBind, 7, 7} {
+		want = binary.NativeEndian.AppendUint32(want, v)
+	}
+	want = append(want, "wl_shm\x00\x00"...)
+	for _, v := range []uint32{1, 5} {
+		want = binary.NativeEndian.AppendUint32(want, v)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("encodeWaylandMessage() = %v, want %v", got, want)
+	}
+}
+
+func TestWaylandString(t *testing.T) {
+	body := encodeWaylandMessage(1, 0, "wl_compositor", uint32(4))[8:]
+	s, rest, err := waylandString(body)
+	if err != nil || s != "wl_compositor" {
+		t.Fatalf("waylandString() = %q, %v", s, err)
+	}
+	if v, _, err := waylandUint(rest); err != nil || v != 4 {
+		t.Fatalf("waylandUint() after string = %d, %v", v, err)
+	}
+	for _, bad := range [][]byte{nil, {0, 0, 0, 0}, binary.NativeEndian.AppendUint32(nil, 9)} {
+		if _, _, err := waylandString(bad); err == nil {
+			t.Errorf("waylandString(%v) expected error", bad)
+		}
+	}
+}
+
+// fakeCompositor answers a client on the other end of a socket pair: it
+// advertises globals, answers sync requests and records the requests it
+// receives. Globals are advertised at version 1 unless versions says
+// otherwise.
+type fakeCompositor struct {
+	conn     *waylandConn
+	globals  []string
+	versions map[string]uint32
+	requests chan [2]uint32
+}
+
+func newFakeCompositor(t *testing.T, globals ...string) (*waylandConn, *fakeCompositor) {
+	t.Helper()
+	return newFakeCompositorVersions(t, nil, globals...)
+}
+
+func newFakeCompositorVersions(t *testing.T, versions map[string]uint32, globals ...string) (*waylandConn, *fakeCompositor) {
+	t.Helper()
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		t.Fatalf("socketpair: %v", err)
+	}
+	conns := make([]*net.UnixConn, 2)
+	for i, fd := range fds {
+		f := os.NewFile(uintptr(fd), "wayland")
+		c, err := net.FileConn(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("FileConn: %v", err)
+		}
+		conns[i] = c.(*net.UnixConn)
+	}
+	server := &fakeCompositor{conn: newWaylandConn(conns[1]), globals: globals, versions: versions, requests: make(chan [2]uint32, 64)}
+	t.Cleanup(func() { server.conn.close() })
+	go server.serve()
+	return newWaylandConn(conns[0]), server
+}
+
+func (f *fakeCompositor) serve() {
+	registry := uint32(0)
+	for {
+		ev, err := f.conn.read()
+		if err != nil {
+			close(f.requests)
+			return
+		}
+		f.requests <- [2]uint32{ev.sender, uint32(ev.opcode)}
+		if ev.sender != wlDisplayID {
+			continue
+		}
+		id, _, _ := waylandUint(ev.body)
+		switch ev.opcode {
+		case wlDisplayGetRegistry:
+			registry = id
+			for i, g := range f.globals {
+				version := max(f.versions[g], 1)
+				f.conn.send(registry, wlRegistryEventGlobal, uint32(i+1), g, version)
+			}
+		case wlDisplaySync:
+			f.conn.send(id, wlCallbackEventDone, uint32(0))
+		}
+	}
+}
+
+func TestWaylandInhibitIdle(t *testing.T) {
+	client, server := newFakeCompositor(t, "wl_compositor", "zwp_idle_inhibit_manager_v1")
+	client.conn.SetDeadline(time.Now().Add(5 * time.Second))
+	inh, err := client.inhibitIdle()
+	if err != nil {
+		t.Fatalf("inhibitIdle() error = %v", err)
+	}
+	if inh.layerSurface != 0 {
+		t.Fatalf("inhibitIdle() mapped a layer surface without the layer shell")
+	}
+	client.close()
+
+	// The registry is 2 and the first callback 3, so wl_compositor is bound
+	// as 4 and the manager as 5.
+	var created bool
+	for req := range server.requests {
+		if req == [2]uint32{5, zwpIdleInhibitManagerCreateInhibitor} {
+			created = true
+		}
+	}
+	if !created {
+		t.Fatalf("no create_inhibitor request reached the compositor")
+	}
+}
+
+func TestWaylandInhibitIdleUnsupported(t *testing.T) {
+	client, _ := newFakeCompositor(t, "wl_compositor", "wl_shm")
+	client.conn.SetDeadline(time.Now().Add(5 * time.Second))
+	defer client.close()
+	if _, err := client.inhibitIdle(); err == nil {
+		t.Fatalf("inhibitIdle() succeeded without zwp_idle_inhibit_manager_v1")
+	}
+}
@@ -0,0 +1,462 @@
+//go:build linux
+
+package platform
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Core requests of the X11 protocol and requests of the XTEST and
+// MIT-SCREEN-SAVER extensions, by opcode. Only the requests keep-alive
+// sends are listed.
+const (
+	x11QueryPointer   = 38
+	x11WarpPointer    = 41
+	x11GetInputFocus  = 43
+	x11QueryExtension = 98
+
+	xtestFakeInput       = 2
+	xtestMotionNotify    = 6
+	screenSaverQueryInfo = 1
+)
+
+// Extension names as the X server knows them.
+const (
+	x11ExtXTest       = "XTEST"
+	x11ExtScreenSaver = "MIT-SCREEN-SAVER"
+)
+
+// x11Timeout bounds each exchange with the X server, so that a server that
+// stops answering cannot hold up a jitter or an idle poll.
+const x11Timeout = 2 * time.Second
+
+// x11Order is the byte order keep-alive asks the X server to use.
+var x11Order = binary.LittleEndian
+
+// x11Display is a parsed DISPLAY value.
+type x11Display struct {
+	network, address string
+	number           string
+	screen           int
+}
+
+// parseX11Display parses DISPLAY: ":0" and "unix:0" name a local socket,
+// "host:0" a TCP port, and an absolute path ending in ":0", as XQuartz and
+// other launchers set, the socket itself. An optional ".N" names the
+// screen.
+func parseX11Display(display string) (x11Display, error) {
+	colon := strings.LastIndexByte(display, ':')
+	if colon < 0 {
+		return x11Display{}, fmt.Errorf("malformed DISPLAY %q", display)
+	}
+	host, rest := display[:colon], display[colon+1:]
+	number, screen, hasScreen := strings.Cut(rest, ".")
+	if _, err := strconv.ParseUint(number, 10, 16); err != nil {
+		return x11Display{}, fmt.Errorf("malformed DISPLAY %q", display)
+	}
+	d := x11Display{number: number}
+	if hasScreen {
+		n, err := strconv.Atoi(screen)
+		if err != nil || n < 0 {
+			return x11Display{}, fmt.Errorf("malformed DISPLAY %q", display)
+		}
+		d.screen = n
+	}
+	switch {
+	case strings.HasPrefix(host, "/"):
+		d.network, d.address = "unix", host+":"+number
+	case host == "" || host == "unix":
+		d.network, d.address = "unix", "/tmp/.X11-unix/X"+number
+	default:
+		n, _ := strconv.Atoi(number)
+		d.network, d.address = "tcp", net.JoinHostPort(host, strconv.Itoa(6000+n))
+	}
+	return d, nil
+}
+
+// x11Conn is a minimal client of the X11 wire protocol: enough to query
+// extensions and send the handful of requests keep-alive needs.
+type x11Conn struct {
+	conn net.Conn
+	in   *bufio.Reader
+	seq  uint16
+	root uint32
+
+	extensions map[string]byte
+}
+
+// dialX11 connects to the X server named by DISPLAY.
+func dialX11() (*x11Conn, error) {
+	display := os.Getenv("DISPLAY")
+	if display == "" {
+		return nil, errors.New("DISPLAY is not set")
+	}
+	d, err := parseX11Display(display)
+	if err != nil {
+		return nil, err
+	}
+	var conn net.Conn
+	if d.network == "unix" && !strings.HasPrefix(d.address, "@") {
+		// Servers on Linux listen on an abstract socket of the same name
+		// too, which a sandbox's private /tmp does not hide.
+		conn, err = net.DialTimeout("unix", "@"+d.address, x11Timeout)
+	}
+	if conn == nil {
+		conn, err = net.DialTimeout(d.network, d.address, x11Timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connect to X server: %w", err)
+	}
+	name, data := x11Auth(d)
+	c, err := newX11Conn(conn, name, data, d.screen)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// newX11Conn sets up the connection on conn, authenticating with the given
+// protocol name and data, and picks the root window of screen.
+func newX11Conn(conn net.Conn, authName string, authData []byte, screen int) (*x11Conn, error) {
+	c := &x11Conn{conn: conn, in: bufio.NewReader(conn), extensions: map[string]byte{}}
+	_ = conn.SetDeadline(time.Now().Add(x11Timeout))
+	defer conn.SetDeadline(time.Time{})
+
+	setup := []byte{'l', 0}
+	setup = x11Order.AppendUint16(setup, 11)
+	setup = x11Order.AppendUint16(setup, 0)
+	setup = x11Order.AppendUint16(setup, uint16(len(authName)))
+	setup = x11Order.AppendUint16(setup, uint16(len(authData)))
+	setup = append(setup, 0, 0)
+	setup = x11Pad(append(setup, authName...))
+	setup = x11Pad(append(setup, authData...))
+	if _, err := conn.Write(setup); err != nil {
+		return nil, err
+	}
+
+	var header [8]byte
+	if _, err := io.ReadFull(c.in, header[:]); err != nil {
+		return nil, fmt.Errorf("read X connection setup: %w", err)
+	}
+	body := make([]byte, int(x11Order.Uint16(header[6:]))*4)
+	if _, err := io.ReadFull(c.in, body); err != nil {
+		return nil, fmt.Errorf("read X connection setup: %w", err)
+	}
+	switch header[0] {
+	case 1:
+	case 0:
+		reason := body[:min(int(header[1]), len(body))]
+		return nil, fmt.Errorf("X server refused the connection: %s", reason)
+	default:
+		return nil, errors.New("X server asked for further authentication")
+	}
+	root, err := x11SetupRoot(body, screen)
+	if err != nil {
+		return nil, err
+	}
+	c.root = root
+	return c, nil
+}
+
+// x11SetupRoot finds the root window of screen in the body of a successful
+// connection setup reply.
+func x11SetupRoot(body []byte, screen int) (uint32, error) {
+	const fixed = 32
+	if len(body) < fixed {
+		return 0, errors.New("truncated X connection setup")
+	}
+	vendorLen := int(x11Order.Uint16(body[16:]))
+	screens := int(body[20])
+	formats := int(body[21])
+	if screen >= screens {
+		return 0, fmt.Errorf("X server has no screen %d", screen)
+	}
+	off := fixed + (vendorLen+3)&^3 + 8*formats
+	for i := 0; ; i++ {
+		if off+40 > len(body) {
+			return 0, errors.New("truncated X connection setup")
+		}
+		if i == screen {
+			return x11Order.Uint32(body[off:]), nil
+		}
+		depths := int(body[off+39])
+		off += 40
+		for j := 0; j < depths; j++ {
+			if off+8 > len(body) {
+				return 0, errors.New("truncated X connection setup")
+			}
+			off += 8 + 24*int(x11Order.Uint16(body[off+2:]))
+		}
+	}
+}
+
+// x11Auth returns the MIT-MAGIC-COOKIE-1 for display d from the
+// Xauthority file, or nothing when there is none, for servers that let
+// local clients in without one.
+func x11Auth(d x11Display) (string, []byte) {
+	path := os.Getenv("XAUTHORITY")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", nil
+		}
+		path = filepath.Join(home, ".Xauthority")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil
+	}
+	hostname, _ := os.Hostname()
+	return findX11Cookie(data, hostname, d.number, d.network == "unix")
+}
+
+// Address families of Xauthority entries.
+const (
+	xauthFamilyLocal = 256
+	xauthFamilyWild  = 65535
+)
+
+// findX11Cookie finds the MIT-MAGIC-COOKIE-1 entry for display number in
+// the contents of an Xauthority file. Local connections match entries for
+// hostname; remote ones take the first entry for the display number.
+func findX11Cookie(data []byte, hostname, number string, local bool) (string, []byte) {
+	const cookieName = "MIT-MAGIC-COOKIE-1"
+	field := func() ([]byte, bool) {
+		if len(data) < 2 {
+			return nil, false
+		}
+		n := int(binary.BigEndian.Uint16(data))
+		if len(data) < 2+n {
+			return nil, false
+		}
+		f := data[2 : 2+n]
+		data = data[2+n:]
+		return f, true
+	}
+	for len(data) >= 2 {
+		family := binary.BigEndian.Uint16(data)
+		data = data[2:]
+		address, ok1 := field()
+		num, ok2 := field()
+		name, ok3 := field()
+		cookie, ok4 := field()
+		if !ok1 || !ok2 || !ok3 || !ok4 {
+			return "", nil
+		}
+		if string(name) != cookieName || (len(num) > 0 && string(num) != number) {
+			continue
+		}
+		switch {
+		case family == xauthFamilyWild,
+			local && family == xauthFamilyLocal && string(address) == hostname,
+			!local && family != xauthFamilyLocal:
+			return cookieName, cookie
+		}
+	}
+	return "", nil
+}
+
+// x11Pad pads b to a multiple of four bytes.
+func x11Pad(b []byte) []byte {
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func (c *x11Conn) close() error {
+	return c.conn.Close()
+}
+
+// send sends a request built from opcode, the data byte that follows it
+// and body, and returns its sequence number.
+func (c *x11Conn) send(opcode, data byte, body []byte) (uint16, error) {
+	body = x11Pad(body)
+	req := make([]byte, 0, 4+len(body))
+	req = append(req, opcode, data)
+	req = x11Order.AppendUint16(req, uint16(1+len(body)/4))
+	req = append(req, body...)
+	_ = c.conn.SetWriteDeadline(time.Now().Add(x11Timeout))
+	if _, err := c.conn.Write(req); err != nil {
+		return 0, err
+	}
+	c.seq++
+	return c.seq, nil
+}
+
+// reply reads until the reply to request seq arrives and returns it. An
+// error for that request or any sent before it is returned instead;
+// events are dropped.
+func (c *x11Conn) reply(seq uint16) ([]byte, error) {
+	_ = c.conn.SetReadDeadline(time.Now().Add(x11Timeout))
+	for {
+		var msg [32]byte
+		if _, err := io.ReadFull(c.in, msg[:]); err != nil {
+			return nil, err
+		}
+		switch msg[0] {
+		case 0:
+			return nil, fmt.Errorf("X error %d for request %d (major opcode %d)", msg[1], x11Order.Uint16(msg[2:]), msg[10])
+		case 1:
+			extra := make([]byte, int(x11Order.Uint32(msg[4:]))*4)
+			if _, err := io.ReadFull(c.in, extra); err != nil {
+				return nil, err
+			}
+			if x11Order.Uint16(msg[2:]) == seq {
+				return append(msg[:], extra...), nil
+			}
+		}
+	}
+}
+
+// call sends a request and waits for its reply.
+func (c *x11Conn) call(opcode, data byte, body []byte) ([]byte, error) {
+	seq, err := c.send(opcode, data, body)
+	if err != nil {
+		return nil, err
+	}
+	return c.reply(seq)
+}
+
+// sync waits until the server has handled every request sent so far, which
+// surfaces the errors of requests that have no reply.
+func (c *x11Conn) sync() error {
+	_, err := c.call(x11GetInputFocus, 0, nil)
+	return err
+}
+
+// extension returns the major opcode of the named extension.
+func (c *x11Conn) extension(name string) (byte, error) {
+	if major, ok := c.extensions[name]; ok {
+		return major, nil
+	}
+	body := x11Order.AppendUint16(nil, uint16(len(name)))
+	body = append(body, 0, 0)
+	body = append(body, name...)
+	r, err := c.call(x11QueryExtension, 0, body)
+	if err != nil {
+		return 0, err
+	}
+	if r[8] == 0 {
+		return 0, fmt.Errorf("the X server does not offer the %s extension", name)
+	}
+	c.extensions[name] = r[9]
+	return r[9], nil
+}
+
+// pointer returns the pointer position on the root window.
+func (c *x11Conn) pointer() (cursorPos, error) {
+	r, err := c.call(x11QueryPointer, 0, x11Order.AppendUint32(nil, c.root))
+	if err != nil {
+		return cursorPos{}, err
+	}
+	return cursorPos{X: int(int16(x11Order.Uint16(r[16:]))), Y: int(int16(x11Order.Uint16(r[18:])))}, nil
+}
+
+// warpPointer moves the pointer to p on the root window.
+func (c *x11Conn) warpPointer(p cursorPos) error {
+	body := x11Order.AppendUint32(nil, 0)
+	body = x11Order.AppendUint32(body, c.root)
+	body = append(body, make([]byte, 8)...)
+	body = x11Order.AppendUint16(body, uint16(int16(p.X)))
+	body = x11Order.AppendUint16(body, uint16(int16(p.Y)))
+	if _, err := c.send(x11WarpPointer, 0, body); err != nil {
+		return err
+	}
+	return c.sync()
+}
+
+// moveRelative moves the pointer by dx, dy through XTEST, as a real
+// device would, so the server resets its idle timer and clients see
+// motion events.
+func (c *x11Conn) moveRelative(dx, dy int) error {
+	major, err := c.extension(x11ExtXTest)
+	if err != nil {
+		return err
+	}
+	body := []byte{xtestMotionNotify, 1, 0, 0} // type, detail: relative
+	body = x11Order.AppendUint32(body, 0)      // time: now
+	body = x11Order.AppendUint32(body, 0)      // root: the pointer's screen
+	body = append(body, make([]byte, 8)...)
+	body = x11Order.AppendUint16(body, uint16(int16(dx)))
+	body = x11Order.AppendUint16(body, uint16(int16(dy)))
+	body = append(body, make([]byte, 8)...) // pad, device id: core pointer
+	if _, err := c.send(major, xtestFakeInput, body); err != nil {
+		return err
+	}
+	return c.sync()
+}
+
+// idleTime returns the time since the last user input, as the
+// MIT-SCREEN-SAVER extension reports it.
+func (c *x11Conn) idleTime() (time.Duration, error) {
+	major, err := c.extension(x11ExtScreenSaver)
+	if err != nil {
+		return 0, err
+	}
+	r, err := c.call(major, screenSaverQueryInfo, x11Order.AppendUint32(nil, c.root))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(x11Order.Uint32(r[16:])) * time.Millisecond, nil
+}
+
+// x11IdleTime reads the idle time from the X server.
+func x11IdleTime() (time.Duration, error) {
+	c, err := dialX11()
+	if err != nil {
+		return 0, err
+	}
+	defer c.close()
+	return c.idleTime()
+}
+
+// x11Support reports which of the extensions keep-alive uses the X server
+// offers.
+func x11Support() (xtest, screenSaver bool) {
+	c, err := dialX11()
+	if err != nil {
+		return false, false
+	}
+	defer c.close()
+	_, xtestErr := c.extension(x11ExtXTest)
+	_, screenSaverErr := c.extension(x11ExtScreenSaver)
+	return xtestErr == nil, screenSaverErr == nil
+}
+
+// xtestMover moves the pointer through XTEST.
+type xtestMover struct {
+	conn *x11Conn
+}
+
+func (x *xtestMover) move(dx, dy int) error {
+	return x.conn.moveRelative(dx, dy)
+}
+
+func (x *xtestMover) name() string {
+	return "xtest"
+}
+
+// x11Locator reads and sets the pointer position on the X server.
+type x11Locator struct {
+	conn *x11Conn
+}
+
+func (x x11Locator) cursorPosition() (cursorPos, error) {
+	return x.conn.pointer()
+}
+
+func (x x11Locator) setCursorPosition(p cursorPos) error {
+	return x.conn.warpPointer(p)
+}
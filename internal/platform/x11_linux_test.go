@@ -0,0 +1,203 @@
+//go:build linux
+
+package platform
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestParseX11Display(t *testing.T) {
+	tests := []struct {
+		display string
+		want    x11Display
+	}{
+		{":0", x11Display{network: "unix", address: "/tmp/.X11-unix/X0", number: "0"}},
+		{":1.2", x11Display{network: "unix", address: "/tmp/.X11-unix/X1", number: "1", screen: 2}},
+		{"unix:10", x11Display{network: "unix", address: "/tmp/.X11-unix/X10", number: "10"}},
+		{"localhost:10.0", x11Display{network: "tcp", address: "localhost:6010", number: "10"}},
+		{"/private/tmp/com.apple.launchd.x/org.xquartz:0", x11Display{network: "unix", address: "/private/tmp/com.apple.launchd.x/org.xquartz:0", number: "0"}},
+	}
+	for _, tt := range tests {
+		got, err := parseX11Display(tt.display)
+		if err != nil || got != tt.want {
+			t.Errorf("parseX11Display(%q) = %+v, %v; want %+v", tt.display, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "0", ":", ":x", ":0.x", ":0.-1"} {
+		if _, err := parseX11Display(bad); err == nil {
+			t.Errorf("parseX11Display(%q) expected error", bad)
+		}
+	}
+}
+
+func TestFindX11Cookie(t *testing.T) {
+	entry := func(family uint16, fields ...string) []byte {
+		b := binary.BigEndian.AppendUint16(nil, family)
+		for _, f := range fields {
+			b = binary.BigEndian.AppendUint16(b, uint16(len(f)))
+			b = append(b, f...)
+		}
+		return b
+	}
+	var data []byte
+	data = append(data, entry(xauthFamilyLocal, "otherhost", "0", "MIT-MAGIC-COOKIE-1", "other")...)
+	data = append(data, entry(xauthFamilyLocal, "myhost", "1", "MIT-MAGIC-COOKIE-1", "display1")...)
+	data = append(data, entry(xauthFamilyLocal, "myhost", "0", "XDM-AUTHORIZATION-1", "xdm")...)
+	data = append(data, entry(xauthFamilyLocal, "myhost", "0", "MIT-MAGIC-COOKIE-1", "mine")...)
+
+	if name, cookie := findX11Cookie(data, "myhost", "0", true); name != "MIT-MAGIC-COOKIE-1" || string(cookie) != "mine" {
+		t.Errorf("findX11Cookie() = %q, %q; want this host's cookie for display 0", name, cookie)
+	}
+	if name, _ := findX11Cookie(data, "myhost", "2", true); name != "" {
+		t.Errorf("findX11Cookie() for a display without an entry = %q, want none", name)
+	}
+	wild := entry(xauthFamilyWild, "", "", "MIT-MAGIC-COOKIE-1", "wild")
+	if _, cookie := findX11Cookie(wild, "myhost", "7", true); string(cookie) != "wild" {
+		t.Errorf("findX11Cookie() = %q, want the wildcard entry", cookie)
+	}
+	if name, _ := findX11Cookie(data[:len(data)-3], "myhost", "0", true); name != "" {
+		t.Errorf("findX11Cookie() on a truncated file = %q, want none", name)
+	}
+}
+
+// fakeXServer answers an X client on the other end of a pipe: it accepts
+// the connection setup, offers the extensions it is given and keeps a
+// pointer that XTEST and WarpPointer move.
+type fakeXServer struct {
+	conn       net.Conn
+	extensions map[string]byte
+	pointer    cursorPos
+	idle       uint32
+}
+
+const fakeXRoot = 0x2ab
+
+func newFakeXServer(t *testing.T, extensions map[string]byte) *x11Conn {
+	t.Helper()
+	client, server := net.Pipe()
+	f := &fakeXServer{conn: server, extensions: extensions, pointer: cursorPos{X: 100, Y: 50}, idle: 4200}
+	t.Cleanup(func() { server.Close() })
+	go f.serve()
+	c, err := newX11Conn(client, "", nil, 0)
+	if err != nil {
+		t.Fatalf("newX11Conn() error = %v", err)
+	}
+	t.Cleanup(func() { c.close() })
+	return c
+}
+
+func (f *fakeXServer) serve() {
+	order := binary.LittleEndian
+	var setup [12]byte
+	if _, err := io.ReadFull(f.conn, setup[:]); err != nil {
+		return
+	}
+	auth := (int(order.Uint16(setup[6:]))+3)&^3 + (int(order.Uint16(setup[8:]))+3)&^3
+	if _, err := io.ReadFull(f.conn, make([]byte, auth)); err != nil {
+		return
+	}
+	body := make([]byte, 32)
+	body = append(body, "fake"...)
+	order.PutUint16(body[16:], 4) // vendor length
+	body[20] = 1                  // screens
+	screen := make([]byte, 40)
+	order.PutUint32(screen, fakeXRoot)
+	body = append(body, screen...)
+	header := []byte{1, 0, 11, 0, 0, 0, 0, 0}
+	order.PutUint16(header[6:], uint16(len(body)/4))
+	if _, err := f.conn.Write(append(header, body...)); err != nil {
+		return
+	}
+
+	var seq uint16
+	for {
+		var req [4]byte
+		if _, err := io.ReadFull(f.conn, req[:]); err != nil {
+			return
+		}
+		data := make([]byte, int(order.Uint16(req[2:]))*4-4)
+		if _, err := io.ReadFull(f.conn, data); err != nil {
+			return
+		}
+		seq++
+		reply := make([]byte, 32)
+		reply[0] = 1
+		order.PutUint16(reply[2:], seq)
+		switch {
+		case req[0] == x11QueryExtension:
+			name := string(data[4 : 4+order.Uint16(data)])
+			if major, ok := f.extensions[name]; ok {
+				reply[8], reply[9] = 1, major
+			}
+		case req[0] == x11GetInputFocus:
+		case req[0] == x11QueryPointer:
+			order.PutUint16(reply[16:], uint16(int16(f.pointer.X)))
+			order.PutUint16(reply[18:], uint16(int16(f.pointer.Y)))
+		case req[0] == x11WarpPointer:
+			f.pointer = cursorPos{X: int(int16(order.Uint16(data[16:]))), Y: int(int16(order.Uint16(data[18:])))}
+			continue
+		case req[0] == f.extensions[x11ExtXTest] && req[1] == xtestFakeInput:
+			f.pointer.X += int(int16(order.Uint16(data[20:])))
+			f.pointer.Y += int(int16(order.Uint16(data[22:])))
+			continue
+		case req[0] == f.extensions[x11ExtScreenSaver] && req[1] == screenSaverQueryInfo:
+			order.PutUint32(reply[16:], f.idle)
+		default:
+			// BadRequest
+			reply = make([]byte, 32)
+			reply[1] = 1
+			order.PutUint16(reply[2:], seq)
+			reply[10] = req[0]
+		}
+		if _, err := f.conn.Write(reply); err != nil {
+			return
+		}
+	}
+}
+
+func TestX11Conn(t *testing.T) {
+	c := newFakeXServer(t, map[string]byte{x11ExtXTest: 132, x11ExtScreenSaver: 133})
+	if c.root != fakeXRoot {
+		t.Fatalf("root = %#x, want %#x", c.root, fakeXRoot)
+	}
+
+	if idle, err := c.idleTime(); err != nil || idle != 4200*time.Millisecond {
+		t.Fatalf("idleTime() = %v, %v; want 4.2s", idle, err)
+	}
+
+	if err := c.moveRelative(3, -2); err != nil {
+		t.Fatalf("moveRelative() error = %v", err)
+	}
+	if p, err := c.pointer(); err != nil || p != (cursorPos{X: 103, Y: 48}) {
+		t.Fatalf("pointer() after a relative move = %+v, %v; want 103,48", p, err)
+	}
+
+	if err := c.warpPointer(cursorPos{X: 7, Y: 9}); err != nil {
+		t.Fatalf("warpPointer() error = %v", err)
+	}
+	if p, err := c.pointer(); err != nil || p != (cursorPos{X: 7, Y: 9}) {
+		t.Fatalf("pointer() after a warp = %+v, %v; want 7,9", p, err)
+	}
+
+	if _, err := c.call(200, 0, nil); err == nil {
+		t.Fatalf("call() of an unknown request succeeded")
+	}
+	// The connection stays usable after an error.
+	if err := c.sync(); err != nil {
+		t.Fatalf("sync() after an error = %v", err)
+	}
+}
+
+func TestX11ConnWithoutExtensions(t *testing.T) {
+	c := newFakeXServer(t, nil)
+	if err := c.moveRelative(1, 1); err == nil {
+		t.Fatalf("moveRelative() succeeded without XTEST")
+	}
+	if _, err := c.idleTime(); err == nil {
+		t.Fatalf("idleTime() succeeded without MIT-SCREEN-SAVER")
+	}
+}
@@ -0,0 +1,142 @@
+// Package policy reads settings that an administrator enforces for every
+// user of a machine, such as a Group Policy or MDM profile. These override
+// anything given on the command line or chosen in the TUI.
+package policy
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/util"
+)
+
+// Setting names, shared by the registry, the managed preferences plist and
+// the Linux config file.
+const (
+	// KeyMaxDurationMinutes caps how long a session may run, in minutes.
+	KeyMaxDurationMinutes = "MaxDurationMinutes"
+	// KeyDisableActive forbids simulated input when true or non-zero.
+	KeyDisableActive = "DisableActive"
+)
+
+// Policy holds the administrator-enforced settings. The zero value enforces
+// nothing.
+type Policy struct {
+	// MaxDuration is the longest a session may run. Zero means no limit.
+	MaxDuration time.Duration
+	// DisableActive forbids activity simulation.
+	DisableActive bool
+	// Source names where the policy was read from, for messages.
+	Source string
+}
+
+// IsZero reports whether the policy enforces nothing.
+func (p Policy) IsZero() bool {
+	return p.MaxDuration == 0 && !p.DisableActive
+}
+
+// CheckDuration returns an error if a session of length d is not allowed.
+// A zero d is an indefinite session.
+func (p Policy) CheckDuration(d time.Duration) error {
+	if p.MaxDuration <= 0 {
+		return nil
+	}
+	if d <= 0 {
+		return fmt.Errorf("sessions are limited to %s by administrator policy (%s); choose a duration", util.FormatDuration(p.MaxDuration), p.Source)
+	}
+	if d > p.MaxDuration {
+		return fmt.Errorf("sessions are limited to %s by administrator policy (%s)", util.FormatDuration(p.MaxDuration), p.Source)
+	}
+	return nil
+}
+
+// CheckActive returns an error if activity simulation is not allowed.
+func (p Policy) CheckActive() error {
+	if p.DisableActive {
+		return fmt.Errorf("activity simulation is disabled by administrator policy (%s)", p.Source)
+	}
+	return nil
+}
+
+// set applies one setting. Names are matched without regard to case and
+// unknown names are ignored, so newer policies work with older releases.
+func (p *Policy) set(key, value string) error {
+	value = strings.TrimSpace(value)
+	switch {
+	case strings.EqualFold(key, KeyMaxDurationMinutes):
+		minutes, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a number of minutes", KeyMaxDurationMinutes, value)
+		}
+		p.MaxDuration = time.Duration(minutes) * time.Minute
+	case strings.EqualFold(key, KeyDisableActive):
+		disable, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not true or false", KeyDisableActive, value)
+		}
+		p.DisableActive = disable
+	}
+	return nil
+}
+
+// parseConfig reads "Key = value" lines. Blank lines and lines starting
+// with # or ; are skipped.
+func parseConfig(r io.Reader, source string) (Policy, error) {
+	p := Policy{Source: source}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return Policy{}, fmt.Errorf("%s:%d: expected Key = value", source, n)
+		}
+		if err := p.set(strings.TrimSpace(key), value); err != nil {
+			return Policy{}, fmt.Errorf("%s:%d: %w", source, n, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Policy{}, fmt.Errorf("%s: %w", source, err)
+	}
+	return p, nil
+}
+
+// parsePlistJSON reads a managed preferences plist that plutil has
+// converted to JSON.
+func parsePlistJSON(data []byte, source string) (Policy, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Policy{}, fmt.Errorf("%s: %w", source, err)
+	}
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		values[k] = fmt.Sprint(v)
+	}
+	return parseValues(values, source)
+}
+
+// parseValues applies settings read by name, such as the values of a
+// registry key, in name order.
+func parseValues(values map[string]string, source string) (Policy, error) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	p := Policy{Source: source}
+	for _, k := range keys {
+		if err := p.set(k, values[k]); err != nil {
+			return Policy{}, fmt.Errorf("%s: %w", source, err)
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,26 @@
+//go:build darwin
+
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+)
+
+// ManagedPreferencesPath is where an MDM profile for keep-alive is installed.
+const ManagedPreferencesPath = "/Library/Managed Preferences/com.stigoleg.keepalive.plist"
+
+// Load reads the machine policy. A missing profile enforces nothing.
+func Load() (Policy, error) {
+	if _, err := os.Stat(ManagedPreferencesPath); errors.Is(err, fs.ErrNotExist) {
+		return Policy{}, nil
+	}
+	out, err := exec.Command("plutil", "-convert", "json", "-o", "-", ManagedPreferencesPath).Output()
+	if err != nil {
+		return Policy{}, fmt.Errorf("reading %s: %w", ManagedPreferencesPath, err)
+	}
+	return parsePlistJSON(out, ManagedPreferencesPath)
+}
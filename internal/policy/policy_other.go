@@ -0,0 +1,25 @@
+//go:build !windows && !darwin
+
+package policy
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// ConfigPath is the machine-wide policy file.
+const ConfigPath = "/etc/keepalive/config"
+
+// Load reads the machine policy. A missing file enforces nothing.
+func Load() (Policy, error) {
+	f, err := os.Open(ConfigPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Policy{}, nil
+	}
+	if err != nil {
+		return Policy{}, err
+	}
+	defer f.Close()
+	return parseConfig(f, ConfigPath)
+}
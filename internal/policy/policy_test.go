@@ -0,0 +1,84 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseConfig(t *testing.T) {
+	in := `# fleet defaults
+MaxDurationMinutes = 480
+; comments with semicolons too
+disableactive = true
+FutureSetting = 1
+`
+	p, err := parseConfig(strings.NewReader(in), "/etc/keepalive/config")
+	if err != nil {
+		t.Fatalf("parseConfig() error = %v", err)
+	}
+	want := Policy{MaxDuration: 8 * time.Hour, DisableActive: true, Source: "/etc/keepalive/config"}
+	if p != want {
+		t.Fatalf("parseConfig() = %+v, want %+v", p, want)
+	}
+}
+
+func TestParseConfigRejectsBadValues(t *testing.T) {
+	for _, in := range []string{
+		"MaxDurationMinutes = eight hours",
+		"DisableActive = maybe",
+		"MaxDurationMinutes",
+	} {
+		if _, err := parseConfig(strings.NewReader(in), "config"); err == nil {
+			t.Errorf("parseConfig(%q) expected error", in)
+		}
+	}
+}
+
+func TestParsePlistJSON(t *testing.T) {
+	p, err := parsePlistJSON([]byte(`{"MaxDurationMinutes": 90, "DisableActive": false}`), "profile")
+	if err != nil {
+		t.Fatalf("parsePlistJSON() error = %v", err)
+	}
+	if p.MaxDuration != 90*time.Minute || p.DisableActive {
+		t.Fatalf("parsePlistJSON() = %+v", p)
+	}
+}
+
+func TestParseValues(t *testing.T) {
+	p, err := parseValues(map[string]string{"MaxDurationMinutes": "120", "DisableActive": "1"}, `HKLM\SOFTWARE\Policies\keepalive`)
+	if err != nil {
+		t.Fatalf("parseValues() error = %v", err)
+	}
+	if p.MaxDuration != 2*time.Hour || !p.DisableActive {
+		t.Fatalf("parseValues() = %+v", p)
+	}
+	if _, err := parseValues(map[string]string{"MaxDurationMinutes": "soon"}, "test"); err == nil {
+		t.Fatal("parseValues() accepted a bad value")
+	}
+}
+
+func TestCheckDuration(t *testing.T) {
+	p := Policy{MaxDuration: time.Hour, Source: "test"}
+	if err := p.CheckDuration(30 * time.Minute); err != nil {
+		t.Errorf("CheckDuration(30m) error = %v", err)
+	}
+	if err := p.CheckDuration(2 * time.Hour); err == nil {
+		t.Error("CheckDuration(2h) expected error")
+	}
+	if err := p.CheckDuration(0); err == nil {
+		t.Error("CheckDuration(indefinite) expected error")
+	}
+	if err := (Policy{}).CheckDuration(0); err != nil {
+		t.Errorf("zero policy CheckDuration(indefinite) error = %v", err)
+	}
+}
+
+func TestCheckActive(t *testing.T) {
+	if err := (Policy{DisableActive: true, Source: "test"}).CheckActive(); err == nil {
+		t.Error("CheckActive() expected error")
+	}
+	if err := (Policy{}).CheckActive(); err != nil {
+		t.Errorf("zero policy CheckActive() error = %v", err)
+	}
+}
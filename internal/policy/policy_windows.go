@@ -0,0 +1,64 @@
+//go:build windows
+
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// RegistryKey holds the Group Policy settings for keep-alive.
+const RegistryKey = `HKLM\SOFTWARE\Policies\keepalive`
+
+// registryPath is RegistryKey below HKEY_LOCAL_MACHINE.
+const registryPath = `SOFTWARE\Policies\keepalive`
+
+// Load reads the machine policy. A missing key enforces nothing; any other
+// failure to read it is returned, so that a policy that cannot be read is
+// not silently ignored.
+func Load() (Policy, error) {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, registryPath, registry.QUERY_VALUE)
+	if errors.Is(err, registry.ErrNotExist) {
+		return Policy{}, nil
+	}
+	if err != nil {
+		return Policy{}, fmt.Errorf("reading %s: %w", RegistryKey, err)
+	}
+	defer key.Close()
+
+	names, err := key.ReadValueNames(0)
+	if err != nil {
+		return Policy{}, fmt.Errorf("reading %s: %w", RegistryKey, err)
+	}
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		value, err := readRegistryValue(key, name)
+		if err != nil {
+			return Policy{}, fmt.Errorf("reading %s: %s: %w", RegistryKey, name, err)
+		}
+		values[name] = value
+	}
+	return parseValues(values, RegistryKey)
+}
+
+// readRegistryValue returns the value name of key as text: numbers in
+// decimal and strings as they are.
+func readRegistryValue(key registry.Key, name string) (string, error) {
+	_, typ, err := key.GetValue(name, nil)
+	if err != nil {
+		return "", err
+	}
+	switch typ {
+	case registry.DWORD, registry.QWORD:
+		n, _, err := key.GetIntegerValue(name)
+		return strconv.FormatUint(n, 10), err
+	case registry.SZ, registry.EXPAND_SZ:
+		s, _, err := key.GetStringValue(name)
+		return s, err
+	default:
+		return "", fmt.Errorf("unsupported registry value type %d", typ)
+	}
+}
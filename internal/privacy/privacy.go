@@ -0,0 +1,194 @@
+// Package privacy defines how far a session reaches into the user's machine
+// and records the user's consent to the levels that inject input. Levels
+// above inhibit-only must be chosen explicitly once; the choice is kept in
+// privacy.json next to the log, with when and how it was made, so that it
+// can be shown that input injection was knowingly enabled.
+package privacy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/logging"
+)
+
+// FileName is the consent file's name, kept next to the log file.
+const FileName = "privacy.json"
+
+// Level is how far a session reaches into the machine. Each level includes
+// the ones below it.
+type Level int
+
+const (
+	// None touches nothing, as when no session runs.
+	None Level = iota
+	// InhibitOnly holds the operating system's sleep inhibitors.
+	InhibitOnly
+	// SyntheticInput also injects simulated mouse input.
+	SyntheticInput
+	// PresenceAPI also sets the user's presence through chat applications'
+	// APIs. No session uses it yet; consenting to it covers integrations
+	// that will.
+	PresenceAPI
+)
+
+var levelNames = [...]string{"none", "inhibit-only", "synthetic-input", "presence-api"}
+
+// Levels returns the level names, from lowest to highest.
+func Levels() []string {
+	return levelNames[:]
+}
+
+func (l Level) String() string {
+	if l < None || int(l) >= len(levelNames) {
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel returns the level called name.
+func ParseLevel(name string) (Level, error) {
+	for i, n := range levelNames {
+		if strings.EqualFold(name, n) {
+			return Level(i), nil
+		}
+	}
+	return None, fmt.Errorf("unknown privacy level %q: use one of %s", name, strings.Join(levelNames[:], ", "))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// NeedsConsent reports whether l must be chosen explicitly before a session
+// uses it.
+func (l Level) NeedsConsent() bool {
+	return l > InhibitOnly
+}
+
+// Consent is the highest level the user has chosen.
+type Consent struct {
+	Level Level `json:"level"`
+	// GrantedAt is when the level was chosen; it is zero when no level
+	// needing consent has been.
+	GrantedAt time.Time `json:"granted_at,omitzero"`
+	// Via is how it was chosen: "tui" for the consent dialog, "command" for
+	// `keepalive privacy grant`.
+	Via string `json:"via,omitempty"`
+	// User is the account that chose it.
+	User string `json:"user,omitempty"`
+}
+
+// Allows reports whether a session may use l.
+func (c Consent) Allows(l Level) bool {
+	return !l.NeedsConsent() || c.Level >= l
+}
+
+// Check returns an error naming how to consent if c does not allow l.
+func (c Consent) Check(l Level) error {
+	if c.Allows(l) {
+		return nil
+	}
+	return fmt.Errorf("the %s privacy level has not been enabled; run 'keepalive privacy grant %s' to enable it", l, l)
+}
+
+// DefaultPath returns where consent is kept: next to the default log file.
+func DefaultPath() (string, error) {
+	logPath, err := logging.DefaultPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(logPath), FileName), nil
+}
+
+// Load reads the consent at path. Without a consent file, only the levels
+// that need none are allowed.
+func Load(path string) (Consent, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Consent{Level: InhibitOnly}, nil
+	}
+	if err != nil {
+		return Consent{}, err
+	}
+	var c Consent
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Consent{}, fmt.Errorf("malformed %s: %v", path, err)
+	}
+	return c, nil
+}
+
+// Grant records at path that the user chose l at now, through via, and
+// returns the consent in effect. Choosing a level already allowed keeps the
+// earlier record.
+func Grant(path string, l Level, via string, now time.Time) (Consent, error) {
+	if !l.NeedsConsent() {
+		return Consent{}, fmt.Errorf("the %s privacy level needs no consent", l)
+	}
+	c, err := Load(path)
+	if err != nil {
+		return Consent{}, err
+	}
+	if c.Allows(l) {
+		return c, nil
+	}
+	c = Consent{Level: l, GrantedAt: now, Via: via}
+	if u, err := user.Current(); err == nil {
+		c.User = u.Username
+	}
+	if err := save(path, c); err != nil {
+		return Consent{}, err
+	}
+	return c, nil
+}
+
+// Revoke withdraws consent, so that levels above inhibit-only must be
+// chosen again. A missing file is not an error.
+func Revoke(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// save writes c to path, replacing it atomically. Like the log, the file is
+// readable by the owner only.
+func save(path string, c Consent) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".privacy-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,73 @@
+package privacy
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGrantAndRevoke(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	now := time.Date(2026, 10, 16, 9, 12, 0, 0, time.UTC)
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() without a file error = %v", err)
+	}
+	if !c.Allows(InhibitOnly) || c.Allows(SyntheticInput) {
+		t.Fatalf("default consent = %+v, want inhibit-only allowed and synthetic-input not", c)
+	}
+	if c.Check(SyntheticInput) == nil {
+		t.Error("Check(SyntheticInput) = nil without consent")
+	}
+
+	c, err = Grant(path, SyntheticInput, "command", now)
+	if err != nil {
+		t.Fatalf("Grant() error = %v", err)
+	}
+	if c.Level != SyntheticInput || !c.GrantedAt.Equal(now) || c.Via != "command" {
+		t.Errorf("Grant() = %+v", c)
+	}
+
+	// A level already allowed keeps the first record.
+	if again, err := Grant(path, SyntheticInput, "tui", now.Add(time.Hour)); err != nil || !again.GrantedAt.Equal(now) || again.Via != "command" {
+		t.Errorf("second Grant() = %+v, %v; want the first record kept", again, err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Level != SyntheticInput || !loaded.GrantedAt.Equal(now) || loaded.Via != "command" || loaded.User != c.User {
+		t.Errorf("Load() = %+v, want %+v", loaded, c)
+	}
+	if !loaded.Allows(SyntheticInput) || loaded.Allows(PresenceAPI) {
+		t.Errorf("Allows() of %+v wrong", loaded)
+	}
+
+	if _, err := Grant(path, InhibitOnly, "command", now); err == nil {
+		t.Error("Grant(InhibitOnly) expected an error")
+	}
+
+	if err := Revoke(path); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if c, _ := Load(path); c.Allows(SyntheticInput) {
+		t.Error("synthetic-input still allowed after Revoke")
+	}
+	if err := Revoke(path); err != nil {
+		t.Errorf("Revoke() without a file error = %v", err)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for i, name := range Levels() {
+		l, err := ParseLevel(name)
+		if err != nil || l != Level(i) || l.String() != name {
+			t.Errorf("ParseLevel(%q) = %v, %v", name, l, err)
+		}
+	}
+	if _, err := ParseLevel("everything"); err == nil {
+		t.Error("ParseLevel(everything) expected an error")
+	}
+}
@@ -0,0 +1,208 @@
+// Package schedule parses recurring weekly time windows such as
+// "Mon-Fri 09:00-17:30" and answers when they are open.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const minutesPerDay = 24 * 60
+
+// window is open on each of days from start until end, in minutes after
+// midnight. An end at or before the start runs into the next day.
+type window struct {
+	days       [7]bool
+	start, end int
+}
+
+// Schedule is a set of weekly windows, evaluated in the location of the
+// times it is given.
+type Schedule struct {
+	spec    string
+	windows []window
+}
+
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Parse reads a schedule of one or more windows separated by semicolons.
+// Each window is a day list and a time range, for example
+// "Mon-Fri 09:00-17:30; Sat 10:00-14:00". Days may be names, ranges such as
+// "Mon-Fri", comma-separated lists, or "daily", "weekdays" or "weekends".
+// A range that ends before it starts, such as "22:00-06:00", runs past
+// midnight.
+func Parse(spec string) (*Schedule, error) {
+	s := &Schedule{spec: strings.TrimSpace(spec)}
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		w, err := parseWindow(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", part, err)
+		}
+		s.windows = append(s.windows, w)
+	}
+	if len(s.windows) == 0 {
+		return nil, errors.New("invalid schedule: expected days and times, such as \"Mon-Fri 09:00-17:30\"")
+	}
+	return s, nil
+}
+
+func parseWindow(s string) (window, error) {
+	var w window
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return w, errors.New("expected days and a time range, such as \"Mon-Fri 09:00-17:30\"")
+	}
+	days, err := parseDays(fields[0])
+	if err != nil {
+		return w, err
+	}
+	w.days = days
+
+	from, to, ok := strings.Cut(fields[1], "-")
+	if !ok {
+		return w, fmt.Errorf("expected a time range such as 09:00-17:30, got %q", fields[1])
+	}
+	if w.start, err = parseClock(from); err != nil {
+		return w, err
+	}
+	if w.end, err = parseClock(to); err != nil {
+		return w, err
+	}
+	if w.start == w.end {
+		return w, errors.New("start and end times are the same")
+	}
+	return w, nil
+}
+
+func parseDays(s string) ([7]bool, error) {
+	var days [7]bool
+	switch strings.ToLower(s) {
+	case "daily", "everyday":
+		return [7]bool{true, true, true, true, true, true, true}, nil
+	case "weekdays":
+		return [7]bool{false, true, true, true, true, true, false}, nil
+	case "weekends":
+		return [7]bool{true, false, false, false, false, false, true}, nil
+	}
+	for _, item := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(item, "-")
+		first, err := parseDay(from)
+		if err != nil {
+			return days, err
+		}
+		last := first
+		if isRange {
+			if last, err = parseDay(to); err != nil {
+				return days, err
+			}
+		}
+		// Ranges may wrap around the week, as in Fri-Mon.
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+func parseDay(s string) (time.Weekday, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if len(name) >= 3 {
+		for i, d := range dayNames {
+			if strings.HasPrefix(name, d) && strings.HasPrefix(strings.ToLower(time.Weekday(i).String()), name) {
+				return time.Weekday(i), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unknown day %q", s)
+}
+
+// parseClock reads HH:MM as minutes after midnight. 24:00 is accepted as an
+// end time.
+func parseClock(s string) (int, error) {
+	if s == "24:00" {
+		return minutesPerDay, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q: use HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// String returns the schedule as it was given.
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// at returns the instant minutes after midnight on the day offset days from
+// t's date.
+func at(t time.Time, days, minutes int) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()+days, 0, minutes, 0, 0, t.Location())
+}
+
+// span returns when w opens and closes for the day offset days from t's date.
+func (w window) span(t time.Time, days int) (open, close time.Time, ok bool) {
+	start := at(t, days, 0)
+	if !w.days[start.Weekday()] {
+		return time.Time{}, time.Time{}, false
+	}
+	end := w.end
+	if end <= w.start {
+		end += minutesPerDay
+	}
+	return at(t, days, w.start), at(t, days, end), true
+}
+
+// End returns when the window open at t closes, or the zero time if none
+// is open. Where windows overlap or adjoin, the latest close is returned,
+// looking no more than a week ahead.
+func (s *Schedule) End(t time.Time) time.Time {
+	var end time.Time
+	limit := t.AddDate(0, 0, 7)
+	for changed := true; changed && end.Before(limit); {
+		changed = false
+		probe := t
+		if !end.IsZero() {
+			probe = end
+		}
+		for _, w := range s.windows {
+			// A window that runs past midnight may have opened yesterday.
+			for _, days := range []int{-1, 0} {
+				open, close, ok := w.span(probe, days)
+				if ok && !probe.Before(open) && probe.Before(close) && close.After(end) {
+					end = close
+					changed = true
+				}
+			}
+		}
+	}
+	return end
+}
+
+// Active reports whether a window is open at t.
+func (s *Schedule) Active(t time.Time) bool {
+	return !s.End(t).IsZero()
+}
+
+// NextStart returns the next time after t at which a window opens.
+func (s *Schedule) NextStart(t time.Time) time.Time {
+	var next time.Time
+	for days := 0; days <= 7; days++ {
+		for _, w := range s.windows {
+			open, _, ok := w.span(t, days)
+			if ok && open.After(t) && (next.IsZero() || open.Before(next)) {
+				next = open
+			}
+		}
+	}
+	return next
+}
@@ -0,0 +1,128 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// Monday, 6 January 2025.
+func monday(hour, minute int) time.Time {
+	return time.Date(2025, 1, 6, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParseRejectsInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"Mon-Fri",
+		"Mon-Fri 09:00",
+		"Funday 09:00-17:00",
+		"Mon 25:00-26:00",
+		"Mon 09:00-09:00",
+		"Mon 09:00-17:00 extra",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) expected error", spec)
+		}
+	}
+}
+
+func TestActive(t *testing.T) {
+	s, err := Parse("Mon-Fri 09:00-17:30; Sat 10:00-14:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		at   time.Time
+		want bool
+	}{
+		{monday(8, 59), false},
+		{monday(9, 0), true},
+		{monday(17, 29), true},
+		{monday(17, 30), false},
+		{monday(0, 0).AddDate(0, 0, 5).Add(11 * time.Hour), true},  // Saturday 11:00
+		{monday(0, 0).AddDate(0, 0, 6).Add(11 * time.Hour), false}, // Sunday 11:00
+	}
+	for _, tt := range tests {
+		if got := s.Active(tt.at); got != tt.want {
+			t.Errorf("Active(%v) = %v, want %v", tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestOvernightWindow(t *testing.T) {
+	s, err := Parse("Fri 22:00-06:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	friday := monday(0, 0).AddDate(0, 0, 4)
+	if !s.Active(friday.Add(23 * time.Hour)) {
+		t.Error("not active on Friday at 23:00")
+	}
+	if !s.Active(friday.Add(29 * time.Hour)) {
+		t.Error("not active on Saturday at 05:00")
+	}
+	if s.Active(friday.Add(5 * time.Hour)) {
+		t.Error("active on Friday at 05:00")
+	}
+	if got, want := s.End(friday.Add(23*time.Hour)), friday.Add(30*time.Hour); !got.Equal(want) {
+		t.Errorf("End() = %v, want %v", got, want)
+	}
+}
+
+func TestAllDayWindow(t *testing.T) {
+	s, err := Parse("Mon-Fri 00:00-24:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Active(monday(0, 0)) || !s.Active(monday(23, 59)) {
+		t.Error("not active all of Monday")
+	}
+	saturday := monday(0, 0).AddDate(0, 0, 5)
+	if s.Active(saturday.Add(time.Hour)) {
+		t.Error("active on Saturday")
+	}
+	// The weekdays adjoin, so the window closes at the end of Friday.
+	if got := s.End(monday(10, 0)); !got.Equal(saturday) {
+		t.Errorf("End(Mon 10:00) = %v, want %v", got, saturday)
+	}
+}
+
+func TestNextStartAndEnd(t *testing.T) {
+	s, err := Parse("weekdays 09:00-12:00; weekdays 12:00-17:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.NextStart(monday(7, 0)), monday(9, 0); !got.Equal(want) {
+		t.Errorf("NextStart(Mon 07:00) = %v, want %v", got, want)
+	}
+	friday := monday(0, 0).AddDate(0, 0, 4)
+	if got, want := s.NextStart(friday.Add(18*time.Hour)), monday(9, 0).AddDate(0, 0, 7); !got.Equal(want) {
+		t.Errorf("NextStart(Fri 18:00) = %v, want %v", got, want)
+	}
+	// Adjoining windows close together.
+	if got, want := s.End(monday(10, 0)), monday(17, 0); !got.Equal(want) {
+		t.Errorf("End(Mon 10:00) = %v, want %v", got, want)
+	}
+	if !s.End(monday(18, 0)).IsZero() {
+		t.Error("End() outside a window should be zero")
+	}
+}
+
+func TestParseDays(t *testing.T) {
+	tests := map[string][7]bool{
+		"Mon":          {false, true, false, false, false, false, false},
+		"fri-mon":      {true, true, false, false, false, true, true},
+		"Tue,Thursday": {false, false, true, false, true, false, false},
+		"weekends":     {true, false, false, false, false, false, true},
+	}
+	for in, want := range tests {
+		got, err := parseDays(in)
+		if err != nil {
+			t.Errorf("parseDays(%q) error = %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("parseDays(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Package schema holds the JSON Schemas of the documents Keep-Alive gives
+// other programs: the session status, the session events and the entries
+// of the history file. They are embedded in the binary and printed by
+// `keepalive schema`.
+//
+// Each document carries a schema_version. It is bumped whenever a field is
+// renamed or removed or changes meaning, so that integrations can refuse
+// a document they do not understand rather than misread it; fields may be
+// added without bumping it, and readers should ignore fields they do not
+// know.
+package schema
+
+import (
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The current version of each document.
+const (
+	StatusVersion       = 1
+	EventVersion        = 1
+	HistoryEntryVersion = 1
+)
+
+//go:embed *.schema.json
+var files embed.FS
+
+const suffix = ".schema.json"
+
+// Names returns the names of the schemas, sorted.
+func Names() []string {
+	entries, _ := files.ReadDir(".")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), suffix))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the schema called name.
+func Get(name string) ([]byte, error) {
+	data, err := files.ReadFile(name + suffix)
+	if err != nil {
+		return nil, fmt.Errorf("unknown schema %q: use one of %s", name, strings.Join(Names(), ", "))
+	}
+	return data, nil
+}
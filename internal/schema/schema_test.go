@@ -0,0 +1,40 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSchemasMatchVersions(t *testing.T) {
+	versions := map[string]int{
+		"status":        StatusVersion,
+		"event":         EventVersion,
+		"history-entry": HistoryEntryVersion,
+	}
+	if got := Names(); len(got) != len(versions) {
+		t.Fatalf("Names() = %v, want %d schemas", got, len(versions))
+	}
+	for name, version := range versions {
+		data, err := Get(name)
+		if err != nil {
+			t.Fatalf("Get(%q): %v", name, err)
+		}
+		var doc struct {
+			Properties struct {
+				SchemaVersion struct {
+					Const int `json:"const"`
+				} `json:"schema_version"`
+			} `json:"properties"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			t.Fatalf("schema %q is not valid JSON: %v", name, err)
+		}
+		if doc.Properties.SchemaVersion.Const != version {
+			t.Errorf("schema %q has schema_version %d, want %d", name, doc.Properties.SchemaVersion.Const, version)
+		}
+	}
+
+	if _, err := Get("nonexistent"); err == nil {
+		t.Error("Get() of an unknown schema succeeded")
+	}
+}
@@ -0,0 +1,9 @@
+package service
+
+// The event IDs of the records EventLog writes, for monitoring tools to
+// filter on.
+const (
+	EventStarted uint32 = 1
+	EventStopped uint32 = 2
+	EventFailed  uint32 = 3
+)
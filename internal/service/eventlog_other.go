@@ -0,0 +1,25 @@
+//go:build !windows
+
+package service
+
+// EventLog writes a service's records to the Windows event log. Other
+// systems have none: their service managers keep the output instead, so
+// its methods do nothing.
+type EventLog struct{}
+
+// OpenEventLog returns nil: only Windows has an event log.
+func OpenEventLog() *EventLog {
+	return nil
+}
+
+// Started does nothing.
+func (e *EventLog) Started(profile string) {}
+
+// Stopped does nothing.
+func (e *EventLog) Stopped() {}
+
+// Failed does nothing.
+func (e *EventLog) Failed(err error) {}
+
+// Close does nothing.
+func (e *EventLog) Close() {}
@@ -0,0 +1,89 @@
+//go:build windows
+
+package service
+
+import (
+	"log/slog"
+
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// eventSourceKey is where the keepalive event source is registered.
+const eventSourceKey = `SYSTEM\CurrentControlSet\Services\EventLog\Application\` + Name
+
+// EventLog writes a service's start, stop and failure records to the
+// Windows Application event log, under the keepalive source, where
+// monitoring tools look for them. A nil *EventLog writes nothing.
+type EventLog struct {
+	log *eventlog.Log
+}
+
+// OpenEventLog opens the event log, or returns nil when it cannot be
+// written. Records written before the source is registered, as by a login
+// service installed without an elevated prompt, are kept but shown without
+// their text formatted.
+func OpenEventLog() *EventLog {
+	l, err := eventlog.Open(Name)
+	if err != nil {
+		slog.Debug("opening the event log failed", "err", err)
+		return nil
+	}
+	return &EventLog{log: l}
+}
+
+// Started records that the service has started with the given profile.
+func (e *EventLog) Started(profile string) {
+	e.report(EventStarted, "Keep-Alive started keeping the system awake: service run "+profile)
+}
+
+// Stopped records that the service has stopped.
+func (e *EventLog) Stopped() {
+	e.report(EventStopped, "Keep-Alive stopped keeping the system awake")
+}
+
+// Failed records that the service failed with err.
+func (e *EventLog) Failed(err error) {
+	e.report(EventFailed, "Keep-Alive failed: "+err.Error())
+}
+
+// Close closes the event log.
+func (e *EventLog) Close() {
+	if e != nil {
+		e.log.Close()
+	}
+}
+
+func (e *EventLog) report(id uint32, msg string) {
+	if e == nil {
+		return
+	}
+	var err error
+	if id == EventFailed {
+		err = e.log.Error(id, msg)
+	} else {
+		err = e.log.Info(id, msg)
+	}
+	if err != nil {
+		slog.Debug("writing to the event log failed", "id", id, "err", err)
+	}
+}
+
+// registerEventSource registers the keepalive event source, so that its
+// records are shown with their text. Registering needs an elevated prompt;
+// a source that is already registered is left alone.
+func registerEventSource() error {
+	if k, err := registry.OpenKey(registry.LOCAL_MACHINE, eventSourceKey, registry.QUERY_VALUE); err == nil {
+		k.Close()
+		return nil
+	}
+	return eventlog.InstallAsEventCreate(Name, eventlog.Error|eventlog.Warning|eventlog.Info)
+}
+
+// unregisterEventSource removes the keepalive event source. The records
+// already written stay in the log.
+func unregisterEventSource() {
+	if err := eventlog.Remove(Name); err != nil {
+		slog.Debug("removing the event source failed", "err", err)
+	}
+}
@@ -0,0 +1,101 @@
+// Package service registers keep-alive to start at login: as a systemd user
+// unit on Linux, a launchd agent on macOS and a Task Scheduler logon task on
+// Windows. A logon task is used rather than a Windows service because
+// services run outside the user's desktop session, where keeping the display
+// on or simulating input has no effect.
+//
+// For kiosk and lab machines, which must stay awake with nobody logged in,
+// InstallSystem registers a Windows service that starts at boot instead, and
+// RunSystemService runs the process under the service control manager.
+package service
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Name identifies the registered unit, agent or task.
+const (
+	Name         = "keepalive"
+	LaunchdLabel = "com.stigoleg.keepalive"
+)
+
+// ErrUnsupported is returned on systems without a supported service manager.
+var ErrUnsupported = errors.New("installing a login service is not supported on this system")
+
+// ErrNotInstalled is returned by Uninstall when there is nothing to remove.
+var ErrNotInstalled = errors.New("the keepalive login service is not installed")
+
+// State describes the registered service.
+type State struct {
+	Installed bool
+	Running   bool
+	// Path is the unit or agent file, or the task name on Windows.
+	Path string
+}
+
+// systemdUnit returns a systemd user unit that runs command.
+func systemdUnit(command []string) string {
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = systemdQuote(arg)
+	}
+	return fmt.Sprintf(`[Unit]
+Description=Keep-Alive: keep the system awake
+After=graphical-session.target
+
+[Service]
+Type=simple
+ExecStart=%s
+Restart=on-failure
+RestartSec=10
+
+[Install]
+WantedBy=default.target
+`, strings.Join(quoted, " "))
+}
+
+// systemdQuote quotes an ExecStart argument. Percent signs are escaped so
+// that systemd does not expand them as specifiers.
+func systemdQuote(arg string) string {
+	arg = strings.ReplaceAll(arg, "%", "%%")
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\$;") {
+		return arg
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `$$`)
+	return `"` + r.Replace(arg) + `"`
+}
+
+// launchdPlist returns a launchd agent that runs command at login and
+// restarts it if it fails.
+func launchdPlist(command []string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>` + LaunchdLabel + `</string>
+	<key>ProgramArguments</key>
+	<array>
+`)
+	for _, arg := range command {
+		b.WriteString("\t\t<string>" + html.EscapeString(arg) + "</string>\n")
+	}
+	b.WriteString(`	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>ProcessType</key>
+	<string>Interactive</string>
+</dict>
+</plist>
+`)
+	return b.String()
+}
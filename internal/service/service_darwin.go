@@ -0,0 +1,84 @@
+//go:build darwin
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// agentPath returns where the launchd agent is written.
+func agentPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", LaunchdLabel+".plist"), nil
+}
+
+// domain is the launchd domain of the logged-in user.
+func domain() string {
+	return fmt.Sprintf("gui/%d", os.Getuid())
+}
+
+// Install writes a launchd agent that runs command at login and loads it,
+// replacing an agent that is already loaded. It returns the agent path.
+func Install(command []string) (string, error) {
+	path, err := agentPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(launchdPlist(command)), 0o644); err != nil {
+		return "", err
+	}
+	// bootout fails when the agent is not loaded, which is fine.
+	_ = launchctl("bootout", domain()+"/"+LaunchdLabel)
+	return path, launchctl("bootstrap", domain(), path)
+}
+
+// Uninstall unloads the agent and removes it.
+func Uninstall() error {
+	path, err := agentPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return ErrNotInstalled
+	}
+	_ = launchctl("bootout", domain()+"/"+LaunchdLabel)
+	return os.Remove(path)
+}
+
+// Status reports whether the agent is installed and running.
+func Status() (State, error) {
+	path, err := agentPath()
+	if err != nil {
+		return State{}, err
+	}
+	s := State{Path: path}
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return s, err
+	}
+	s.Installed = true
+	out, err := exec.Command("launchctl", "print", domain()+"/"+LaunchdLabel).Output()
+	s.Running = err == nil && strings.Contains(string(out), "state = running")
+	return s, nil
+}
+
+func launchctl(args ...string) error {
+	out, err := exec.Command("launchctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("launchctl %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
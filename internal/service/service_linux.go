@@ -0,0 +1,95 @@
+//go:build linux
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const unitName = Name + ".service"
+
+// unitPath returns where the systemd user unit is written:
+// $XDG_CONFIG_HOME/systemd/user (~/.config/systemd/user).
+func unitPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if !filepath.IsAbs(dir) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "systemd", "user", unitName), nil
+}
+
+// Install writes a systemd user unit that runs command, enables it for
+// future logins and (re)starts it now. It returns the unit path.
+func Install(command []string) (string, error) {
+	path, err := unitPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(systemdUnit(command)), 0o644); err != nil {
+		return "", err
+	}
+	for _, args := range [][]string{{"daemon-reload"}, {"enable", unitName}, {"restart", unitName}} {
+		if err := systemctl(args...); err != nil {
+			return path, err
+		}
+	}
+	return path, nil
+}
+
+// Uninstall stops and disables the unit and removes it.
+func Uninstall() error {
+	path, err := unitPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return ErrNotInstalled
+	}
+	// A unit that systemd never loaded cannot be disabled; remove it anyway.
+	_ = systemctl("disable", "--now", unitName)
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	return systemctl("daemon-reload")
+}
+
+// Status reports whether the unit is installed and running.
+func Status() (State, error) {
+	path, err := unitPath()
+	if err != nil {
+		return State{}, err
+	}
+	s := State{Path: path}
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return s, err
+	}
+	s.Installed = true
+	// is-active exits non-zero unless the unit is active; the state is on
+	// stdout either way.
+	out, _ := exec.Command("systemctl", "--user", "is-active", unitName).Output()
+	s.Running = strings.TrimSpace(string(out)) == "active"
+	return s, nil
+}
+
+func systemctl(args ...string) error {
+	out, err := exec.Command("systemctl", append([]string{"--user"}, args...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl --user %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !windows
+
+package service
+
+// Install is not supported on this system.
+func Install(command []string) (string, error) {
+	return "", ErrUnsupported
+}
+
+// Uninstall is not supported on this system.
+func Uninstall() error {
+	return ErrUnsupported
+}
+
+// Status is not supported on this system.
+func Status() (State, error) {
+	return State{}, ErrUnsupported
+}
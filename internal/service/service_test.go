@@ -0,0 +1,44 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSystemdUnitQuotesArguments(t *testing.T) {
+	unit := systemdUnit([]string{"/usr/bin/keepalive", "service", "run", "--schedule", "Mon-Fri 09:00-17:30", "--dim", "50%"})
+	want := `ExecStart=/usr/bin/keepalive service run --schedule "Mon-Fri 09:00-17:30" --dim 50%%` + "\n"
+	if !strings.Contains(unit, want) {
+		t.Fatalf("unit missing %q:\n%s", want, unit)
+	}
+	if !strings.Contains(unit, "WantedBy=default.target") {
+		t.Fatalf("unit is not enabled for login:\n%s", unit)
+	}
+}
+
+func TestSystemdQuote(t *testing.T) {
+	for _, tt := range []struct{ in, want string }{
+		{"-a", "-a"},
+		{"", `""`},
+		{`say "hi"`, `"say \"hi\""`},
+		{"$HOME", `"$$HOME"`},
+	} {
+		if got := systemdQuote(tt.in); got != tt.want {
+			t.Errorf("systemdQuote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLaunchdPlistEscapesArguments(t *testing.T) {
+	plist := launchdPlist([]string{"/Applications/Keep Alive/keepalive", "service", "run", "--schedule", "Mon 09:00-17:00; Tue 09:00-12:00 & more"})
+	for _, want := range []string{
+		"<string>" + LaunchdLabel + "</string>",
+		"<string>/Applications/Keep Alive/keepalive</string>",
+		"<string>Mon 09:00-17:00; Tue 09:00-12:00 &amp; more</string>",
+		"<key>RunAtLoad</key>\n\t<true/>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("plist missing %q:\n%s", want, plist)
+		}
+	}
+}
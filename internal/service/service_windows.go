@@ -0,0 +1,79 @@
+//go:build windows
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// Install registers a Task Scheduler task that runs command when the user
+// logs on, replacing an existing one, and starts it now. It returns the task
+// name.
+func Install(command []string) (string, error) {
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = syscall.EscapeArg(arg)
+	}
+	if err := schtasks("/Create", "/F", "/TN", Name, "/SC", "ONLOGON", "/RL", "LIMITED", "/TR", strings.Join(quoted, " ")); err != nil {
+		return "", err
+	}
+	// Without an elevated prompt the source is not registered, and the
+	// event log shows the task's records without their text.
+	if err := registerEventSource(); err != nil {
+		slog.Debug("registering the event source failed", "err", err)
+	}
+	return Name, schtasks("/Run", "/TN", Name)
+}
+
+// Uninstall ends the task and removes it.
+func Uninstall() error {
+	if s, err := Status(); err != nil {
+		return err
+	} else if !s.Installed {
+		return ErrNotInstalled
+	}
+	// /End fails when the task is not running, which is fine.
+	_ = schtasks("/End", "/TN", Name)
+	if err := schtasks("/Delete", "/F", "/TN", Name); err != nil {
+		return err
+	}
+	if st, err := SystemStatus(); err == nil && !st.Installed {
+		unregisterEventSource()
+	}
+	return nil
+}
+
+// Status reports whether the task is registered and running.
+func Status() (State, error) {
+	s := State{Path: Name}
+	out, err := exec.Command("schtasks", "/Query", "/TN", Name, "/FO", "LIST").Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// schtasks exits 1 when the task does not exist.
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	s.Installed = true
+	for _, line := range strings.Split(string(out), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if ok && strings.TrimSpace(key) == "Status" {
+			s.Running = strings.TrimSpace(value) == "Running"
+		}
+	}
+	return s, nil
+}
+
+func schtasks(args ...string) error {
+	out, err := exec.Command("schtasks", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("schtasks %s: %v: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+
+package service
+
+// InstallSystem is only supported on Windows.
+func InstallSystem(command []string) (string, error) {
+	return "", ErrUnsupported
+}
+
+// UninstallSystem is only supported on Windows.
+func UninstallSystem() error {
+	return ErrUnsupported
+}
+
+// SystemStatus is only supported on Windows.
+func SystemStatus() (State, error) {
+	return State{}, ErrUnsupported
+}
+
+// IsSystemService reports false: only Windows has a service control
+// manager to start the process.
+func IsSystemService() bool {
+	return false
+}
+
+// RunSystemService is only supported on Windows.
+func RunSystemService(run func(stop <-chan struct{}) error) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,234 @@
+//go:build windows
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// DisplayName is the name the Windows service is listed under.
+const DisplayName = "Keep-Alive"
+
+const description = "Keeps the system awake, without a user logged in."
+
+// stopTimeout is how long InstallSystem and UninstallSystem wait for a
+// running service to stop.
+const stopTimeout = 10 * time.Second
+
+// InstallSystem registers a Windows service that runs command at boot,
+// replacing the command of an existing one, and starts it now. It returns
+// the service name. The service control manager only lets administrators
+// register services.
+func InstallSystem(command []string) (string, error) {
+	m, err := connect()
+	if err != nil {
+		return "", err
+	}
+	defer m.Disconnect()
+
+	cfg := mgr.Config{
+		ServiceType:  windows.SERVICE_WIN32_OWN_PROCESS,
+		StartType:    mgr.StartAutomatic,
+		ErrorControl: mgr.ErrorNormal,
+		DisplayName:  DisplayName,
+		Description:  description,
+	}
+	s, err := m.OpenService(Name)
+	if err == nil {
+		quoted := make([]string, len(command))
+		for i, arg := range command {
+			quoted[i] = syscall.EscapeArg(arg)
+		}
+		cfg.BinaryPathName = strings.Join(quoted, " ")
+		if err := s.UpdateConfig(cfg); err != nil {
+			s.Close()
+			return "", fmt.Errorf("updating the %s service: %w", Name, err)
+		}
+		// The new command takes effect when the service next starts.
+		if err := stopService(s); err != nil {
+			s.Close()
+			return "", err
+		}
+	} else {
+		s, err = m.CreateService(Name, command[0], cfg, command[1:]...)
+		if err != nil {
+			return "", fmt.Errorf("creating the %s service: %w", Name, err)
+		}
+	}
+	defer s.Close()
+
+	// Restart after a failure, as the systemd unit and launchd agent do.
+	restart := []mgr.RecoveryAction{{Type: mgr.ServiceRestart, Delay: 10 * time.Second}}
+	if err := s.SetRecoveryActions(restart, uint32((24 * time.Hour).Seconds())); err != nil {
+		return "", fmt.Errorf("setting the %s service to restart: %w", Name, err)
+	}
+	if err := registerEventSource(); err != nil {
+		return "", fmt.Errorf("registering the %s event source: %w", Name, err)
+	}
+	if err := s.Start(); err != nil {
+		return "", fmt.Errorf("starting the %s service: %w", Name, err)
+	}
+	return Name, nil
+}
+
+// UninstallSystem stops the Windows service and removes it.
+func UninstallSystem() error {
+	m, err := connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(Name)
+	if errors.Is(err, windows.ERROR_SERVICE_DOES_NOT_EXIST) {
+		return ErrNotInstalled
+	}
+	if err != nil {
+		return fmt.Errorf("opening the %s service: %w", Name, err)
+	}
+	defer s.Close()
+	if err := stopService(s); err != nil {
+		return err
+	}
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("removing the %s service: %w", Name, err)
+	}
+	// The login service writes to the event log too.
+	if st, err := Status(); err == nil && !st.Installed {
+		unregisterEventSource()
+	}
+	return nil
+}
+
+// SystemStatus reports whether the Windows service is registered and
+// running. Unlike changing the service, it needs no elevated prompt, so it
+// asks the service control manager for no more than the status.
+func SystemStatus() (State, error) {
+	st := State{Path: Name}
+	m, err := windows.OpenSCManager(nil, nil, windows.SC_MANAGER_CONNECT)
+	if err != nil {
+		return st, fmt.Errorf("connecting to the service control manager: %w", err)
+	}
+	defer windows.CloseServiceHandle(m)
+
+	name, _ := windows.UTF16PtrFromString(Name)
+	s, err := windows.OpenService(m, name, windows.SERVICE_QUERY_STATUS)
+	if errors.Is(err, windows.ERROR_SERVICE_DOES_NOT_EXIST) {
+		return st, nil
+	}
+	if err != nil {
+		return st, fmt.Errorf("opening the %s service: %w", Name, err)
+	}
+	defer windows.CloseServiceHandle(s)
+	st.Installed = true
+	var status windows.SERVICE_STATUS
+	if err := windows.QueryServiceStatus(s, &status); err != nil {
+		return st, fmt.Errorf("querying the %s service: %w", Name, err)
+	}
+	st.Running = status.CurrentState == windows.SERVICE_RUNNING
+	return st, nil
+}
+
+// IsSystemService reports whether the process was started by the service
+// control manager.
+func IsSystemService() bool {
+	ok, err := svc.IsWindowsService()
+	return err == nil && ok
+}
+
+// RunSystemService reports to the service control manager and calls run,
+// closing its stop channel when the service is told to stop or the system
+// shuts down. run must return once stop is closed. It returns run's error.
+func RunSystemService(run func(stop <-chan struct{}) error) error {
+	h := &handler{run: run}
+	if err := svc.Run(Name, h); err != nil {
+		return err
+	}
+	return h.err
+}
+
+// handler runs the service between the service control manager's start
+// and stop requests.
+type handler struct {
+	run func(stop <-chan struct{}) error
+	err error
+}
+
+// Execute implements svc.Handler.
+func (h *handler) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() { done <- h.run(stop) }()
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for {
+		select {
+		case h.err = <-done:
+			// Stopped on its own: a non-zero exit code has the service
+			// control manager apply the recovery actions.
+			if h.err != nil {
+				return true, 1
+			}
+			return false, 0
+		case r := <-requests:
+			switch r.Cmd {
+			case svc.Interrogate:
+				status <- r.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				close(stop)
+				h.err = <-done
+				return false, 0
+			}
+		}
+	}
+}
+
+// connect connects to the service control manager with the rights to
+// change services.
+func connect() (*mgr.Mgr, error) {
+	m, err := mgr.Connect()
+	if errors.Is(err, windows.ERROR_ACCESS_DENIED) {
+		return nil, errors.New("changing Windows services needs an elevated prompt (Run as administrator)")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connecting to the service control manager: %w", err)
+	}
+	return m, nil
+}
+
+// stopService stops s if it runs and waits until it has.
+func stopService(s *mgr.Service) error {
+	status, err := s.Query()
+	if err != nil {
+		return fmt.Errorf("querying the %s service: %w", Name, err)
+	}
+	if status.State == svc.Stopped {
+		return nil
+	}
+	if status.State != svc.StopPending {
+		if _, err := s.Control(svc.Stop); err != nil {
+			return fmt.Errorf("stopping the %s service: %w", Name, err)
+		}
+	}
+	deadline := time.Now().Add(stopTimeout)
+	for status.State != svc.Stopped {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("the %s service did not stop within %s", Name, stopTimeout)
+		}
+		time.Sleep(300 * time.Millisecond)
+		if status, err = s.Query(); err != nil {
+			return fmt.Errorf("querying the %s service: %w", Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+// Package sessionstate saves the running session so that one cut short by a
+// crash, a closed terminal or a reboot can be resumed on the next launch.
+// Unlike the status file, which lives in the runtime directory, the state
+// file is kept next to the log and survives a reboot. It is removed when a
+// session ends normally.
+package sessionstate
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/logging"
+)
+
+// FileName is the state file's name, kept next to the log file.
+const FileName = "session.json"
+
+// MaxAge is how long a saved session stays resumable after it was last
+// saved. It bounds how long a session without an end is offered.
+const MaxAge = 24 * time.Hour
+
+// State is a saved session.
+type State struct {
+	// PID is the process that ran the session.
+	PID   int       `json:"pid"`
+	Start time.Time `json:"start"`
+	// End is when a timed session ends; it is zero for an indefinite one.
+	End              time.Time `json:"end,omitzero"`
+	SimulateActivity bool      `json:"simulate_activity,omitempty"`
+	// Saved is when the state was written.
+	Saved time.Time `json:"saved"`
+}
+
+// Remaining returns how long the session had left at now. It is zero for an
+// indefinite session and for one that has already ended.
+func (s State) Remaining(now time.Time) time.Duration {
+	if s.End.IsZero() {
+		return 0
+	}
+	return max(s.End.Sub(now), 0)
+}
+
+// DefaultPath returns where the state is kept: next to the default log file.
+func DefaultPath() (string, error) {
+	logPath, err := logging.DefaultPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(logPath), FileName), nil
+}
+
+// Save writes s to path, replacing it atomically so that a crash never
+// leaves a partial file.
+func Save(path string, s State) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".session-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load reads the state at path. A missing file returns an error that
+// matches os.ErrNotExist.
+func Load(path string) (State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return State{}, err
+	}
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return State{}, err
+	}
+	return s, nil
+}
+
+// Remove deletes the state file. A missing file is not an error.
+func Remove(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Resumable returns the session saved at path if it can be resumed at now:
+// its process has exited, it has time left and it is no older than MaxAge.
+// A state file that is unreadable, has run out or is too old is removed; one
+// whose process is still running belongs to another instance and is left
+// alone. alive reports whether a process is running.
+func Resumable(path string, now time.Time, alive func(pid int) (bool, error)) (State, bool) {
+	s, err := Load(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return State{}, false
+	}
+	if err != nil {
+		Remove(path)
+		return State{}, false
+	}
+	if s.PID == os.Getpid() {
+		return State{}, false
+	}
+	if running, err := alive(s.PID); err == nil && running {
+		return State{}, false
+	}
+	if now.Sub(s.Saved) > MaxAge || (!s.End.IsZero() && s.Remaining(now) < time.Second) {
+		Remove(path)
+		return State{}, false
+	}
+	return s, true
+}
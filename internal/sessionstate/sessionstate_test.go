@@ -0,0 +1,89 @@
+package sessionstate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResumable(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	dead := func(int) (bool, error) { return false, nil }
+	alive := func(int) (bool, error) { return true, nil }
+
+	tests := []struct {
+		name      string
+		state     State
+		alive     func(int) (bool, error)
+		want      bool
+		wantKept  bool
+		remaining time.Duration
+	}{
+		{
+			name:      "timed with time left",
+			state:     State{PID: 1, End: now.Add(42 * time.Minute), Saved: now.Add(-time.Minute)},
+			alive:     dead,
+			want:      true,
+			wantKept:  true,
+			remaining: 42 * time.Minute,
+		},
+		{
+			name:     "indefinite",
+			state:    State{PID: 1, Saved: now.Add(-time.Hour)},
+			alive:    dead,
+			want:     true,
+			wantKept: true,
+		},
+		{
+			name:  "timed and over",
+			state: State{PID: 1, End: now.Add(-time.Minute), Saved: now.Add(-time.Hour)},
+			alive: dead,
+		},
+		{
+			name:  "too old",
+			state: State{PID: 1, Saved: now.Add(-MaxAge - time.Minute)},
+			alive: dead,
+		},
+		{
+			name:     "another instance",
+			state:    State{PID: 1, Saved: now},
+			alive:    alive,
+			wantKept: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), FileName)
+			if err := Save(path, tt.state); err != nil {
+				t.Fatalf("Save() error: %v", err)
+			}
+			got, ok := Resumable(path, now, tt.alive)
+			if ok != tt.want {
+				t.Fatalf("Resumable() = %v, want %v", ok, tt.want)
+			}
+			if ok && got.Remaining(now) != tt.remaining {
+				t.Errorf("Remaining() = %v, want %v", got.Remaining(now), tt.remaining)
+			}
+			if _, err := os.Stat(path); (err == nil) != tt.wantKept {
+				t.Errorf("state file kept = %v, want %v", err == nil, tt.wantKept)
+			}
+		})
+	}
+}
+
+func TestResumableRemovesCorruptState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Resumable(path, time.Now(), func(int) (bool, error) { return false, nil }); ok {
+		t.Error("a corrupt state file was resumable")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("corrupt state file not removed: %v", err)
+	}
+	if _, ok := Resumable(path, time.Now(), nil); ok {
+		t.Error("a missing state file was resumable")
+	}
+}
@@ -0,0 +1,119 @@
+// Package statusfile publishes the running session in a small file that
+// shell prompts can read without starting a process. The first line is
+// "<pid> <end>", where end is the Unix time at which the session ends, or 0
+// for an indefinite session. A second line, if present, names the method
+// keeping the system awake; prompts that read a single line never see it.
+// The file is removed when the session stops.
+package statusfile
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/util"
+)
+
+// Session is the content of the status file.
+type Session struct {
+	PID int
+	// End is zero for an indefinite session.
+	End time.Time
+	// Method is the method keeping the system awake, or empty if unknown.
+	Method string
+}
+
+// Path returns where the status file is kept: $XDG_RUNTIME_DIR/keepalive
+// when that is set, and a per-user directory under the temporary directory
+// otherwise. Write and Read refuse a directory that the current user does
+// not own alone, since the name is predictable.
+func Path() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "keepalive", "status")
+	}
+	return filepath.Join(util.UserTempDir(), "status")
+}
+
+// Write records a session run by the current process that ends at end, or
+// never if end is zero, and is held by method. The file is replaced
+// atomically so that readers never see a partial line.
+func Write(path string, end time.Time, method string) error {
+	if err := util.MakePrivateDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	var unix int64
+	if !end.IsZero() {
+		unix = end.Unix()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".status-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	line := fmt.Sprintf("%d %d\n", os.Getpid(), unix)
+	if method = strings.TrimSpace(method); method != "" && !strings.ContainsAny(method, "\r\n") {
+		line += method + "\n"
+	}
+	if _, err := tmp.WriteString(line); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Read returns the session recorded at path.
+func Read(path string) (Session, error) {
+	if err := util.CheckPrivateDir(filepath.Dir(path)); err != nil {
+		return Session{}, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return Session{}, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	if !sc.Scan() {
+		if err := sc.Err(); err != nil {
+			return Session{}, err
+		}
+		return Session{}, errors.New("empty status file")
+	}
+	fields := strings.Fields(sc.Text())
+	if len(fields) != 2 {
+		return Session{}, fmt.Errorf("malformed status line %q", sc.Text())
+	}
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil || pid <= 0 {
+		return Session{}, fmt.Errorf("malformed pid %q", fields[0])
+	}
+	unix, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || unix < 0 {
+		return Session{}, fmt.Errorf("malformed end time %q", fields[1])
+	}
+	s := Session{PID: pid}
+	if unix != 0 {
+		s.End = time.Unix(unix, 0)
+	}
+	if sc.Scan() {
+		s.Method = strings.TrimSpace(sc.Text())
+	}
+	return s, sc.Err()
+}
+
+// Remove deletes the status file. A missing file is not an error.
+func Remove(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package statusfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWriteAndRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keepalive", "status")
+	end := time.Unix(1760600000, 0)
+
+	if err := Write(path, end, ""); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprintf("%d 1760600000\n", os.Getpid()); string(data) != want {
+		t.Errorf("status file = %q, want %q", data, want)
+	}
+
+	if err := Write(path, time.Time{}, ""); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	if want := fmt.Sprintf("%d 0\n", os.Getpid()); string(data) != want {
+		t.Errorf("indefinite status file = %q, want %q", data, want)
+	}
+
+	if err := Remove(path); err != nil {
+		t.Fatalf("Remove() error: %v", err)
+	}
+	if err := Remove(path); err != nil {
+		t.Fatalf("Remove() of a missing file: %v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 0 {
+		t.Errorf("leftover files: %v", entries)
+	}
+}
+
+func TestRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keepalive", "status")
+	end := time.Unix(1760600000, 0)
+
+	if err := Write(path, end, "systemd-inhibit"); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	got, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	want := Session{PID: os.Getpid(), End: end, Method: "systemd-inhibit"}
+	if got != want {
+		t.Errorf("Read() = %+v, want %+v", got, want)
+	}
+
+	if err := Write(path, time.Time{}, ""); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	got, err = Read(path)
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if want := (Session{PID: os.Getpid()}); got != want {
+		t.Errorf("Read() = %+v, want %+v", got, want)
+	}
+
+	for _, content := range []string{"", "12\n", "x 0\n", "12 -5\n", "0 0\n"} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Read(path); err == nil {
+			t.Errorf("Read(%q) expected error", content)
+		}
+	}
+}
+
+func TestRefusesSharedDirectory(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the temporary directory is per user on Windows")
+	}
+	dir := filepath.Join(t.TempDir(), "keepalive-1000")
+	if err := os.Mkdir(dir, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(dir, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "status")
+	if err := Write(path, time.Time{}, ""); err == nil {
+		t.Error("Write() used a directory other users can write to")
+	}
+	if err := os.WriteFile(path, []byte("1 0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(path); err == nil {
+		t.Error("Read() trusted a directory other users can write to")
+	}
+}
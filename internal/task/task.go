@@ -0,0 +1,187 @@
+// Package task reads a work item from taskwarrior, or from any command that
+// prints one, so that a session can take its reason and length from it.
+package task
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/util"
+)
+
+// DefaultCommand exports a task from taskwarrior.
+const DefaultCommand = "task {id} export"
+
+// lookupTimeout bounds how long the task command may run.
+const lookupTimeout = 10 * time.Second
+
+// idPattern covers taskwarrior's numeric IDs and UUIDs, and the IDs of most
+// other todo tools, while keeping the ID safe to put in a shell command.
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:-]*$`)
+
+// Task is the work item a session is started for.
+type Task struct {
+	// ID is the reference recorded in the history: the task's UUID when the
+	// command reports one, and the ID it was looked up by otherwise.
+	ID          string
+	Description string
+	// Estimate is zero when the task has none.
+	Estimate time.Duration
+}
+
+// Lookup runs command with {id} replaced by id and reads the task it prints.
+// The command runs through the shell. It may print taskwarrior's export
+// format, a JSON array holding one task, or a single JSON object, with a
+// "description" and optionally an "estimate" given as an ISO 8601 duration
+// (PT1H30M, as taskwarrior stores duration attributes), a duration such as
+// 1h30m, or a number of minutes. Any other output is taken as the
+// description, from its first line.
+func Lookup(id, command string) (Task, error) {
+	if !idPattern.MatchString(id) {
+		return Task{}, fmt.Errorf("invalid task ID %q", id)
+	}
+	if command == "" {
+		command = DefaultCommand
+	}
+	command = strings.ReplaceAll(command, "{id}", id)
+
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := util.ShellCommand(ctx, command)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return Task{}, fmt.Errorf("task command %q timed out after %s", command, lookupTimeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return Task{}, fmt.Errorf("task command %q failed: %v: %s", command, err, msg)
+		}
+		return Task{}, fmt.Errorf("task command %q failed: %v", command, err)
+	}
+
+	t, err := Parse(stdout.Bytes())
+	if err != nil {
+		return Task{}, fmt.Errorf("task %s: %w", id, err)
+	}
+	if t.ID == "" {
+		t.ID = id
+	}
+	return t, nil
+}
+
+// exported is a task as printed by `task export`. Estimate is a user
+// defined attribute, so its type depends on how it was declared.
+type exported struct {
+	UUID        string          `json:"uuid"`
+	Description string          `json:"description"`
+	Estimate    json.RawMessage `json:"estimate"`
+}
+
+// Parse reads the output of a task command; see Lookup.
+func Parse(out []byte) (Task, error) {
+	out = bytes.TrimSpace(out)
+	if len(out) == 0 {
+		return Task{}, errors.New("no task found")
+	}
+
+	var e exported
+	switch out[0] {
+	case '[':
+		var list []exported
+		if err := json.Unmarshal(out, &list); err != nil {
+			return Task{}, fmt.Errorf("malformed task list: %v", err)
+		}
+		switch len(list) {
+		case 0:
+			return Task{}, errors.New("no task found")
+		case 1:
+			e = list[0]
+		default:
+			return Task{}, fmt.Errorf("%d tasks found, expected one", len(list))
+		}
+	case '{':
+		if err := json.Unmarshal(out, &e); err != nil {
+			return Task{}, fmt.Errorf("malformed task: %v", err)
+		}
+	default:
+		line, _, _ := strings.Cut(string(out), "\n")
+		return Task{Description: strings.TrimSpace(line)}, nil
+	}
+
+	t := Task{ID: e.UUID, Description: strings.TrimSpace(e.Description)}
+	if t.Description == "" {
+		return Task{}, errors.New("task has no description")
+	}
+	if len(e.Estimate) > 0 && string(e.Estimate) != "null" {
+		d, err := parseEstimate(e.Estimate)
+		if err != nil {
+			return Task{}, err
+		}
+		t.Estimate = d
+	}
+	return t, nil
+}
+
+// parseEstimate reads an estimate given as a JSON string or number.
+func parseEstimate(raw json.RawMessage) (time.Duration, error) {
+	var minutes float64
+	if err := json.Unmarshal(raw, &minutes); err == nil {
+		if minutes <= 0 {
+			return 0, fmt.Errorf("invalid estimate %s: must be positive", raw)
+		}
+		return time.Duration(minutes * float64(time.Minute)), nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return 0, fmt.Errorf("invalid estimate %s", raw)
+	}
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(strings.ToUpper(s), "P") {
+		return parseISODuration(s)
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil && n > 0 {
+		return time.Duration(n * float64(time.Minute)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid estimate %q", s)
+	}
+	return d, nil
+}
+
+// isoDurationPattern matches the ISO 8601 durations taskwarrior writes:
+// weeks, days, hours, minutes and seconds, without years or months, whose
+// length varies.
+var isoDurationPattern = regexp.MustCompile(`^P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseISODuration parses an ISO 8601 duration such as PT1H30M.
+func parseISODuration(s string) (time.Duration, error) {
+	m := isoDurationPattern.FindStringSubmatch(strings.ToUpper(s))
+	if m == nil || strings.HasSuffix(strings.ToUpper(s), "T") {
+		return 0, fmt.Errorf("invalid estimate %q: use an ISO 8601 duration such as PT1H30M", s)
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if m[i+1] == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(m[i+1], 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid estimate %q: %v", s, err)
+		}
+		d += time.Duration(n) * unit
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid estimate %q: must be positive", s)
+	}
+	return d, nil
+}
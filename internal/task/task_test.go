@@ -0,0 +1,76 @@
+package task
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want Task
+	}{
+		{
+			"taskwarrior export",
+			`[{"id":12,"description":"Write the quarterly report","estimate":"PT1H30M","uuid":"2f1c5e0a-9d3b-4c7e-8b2a-1f0e9d8c7b6a"}]`,
+			Task{ID: "2f1c5e0a-9d3b-4c7e-8b2a-1f0e9d8c7b6a", Description: "Write the quarterly report", Estimate: 90 * time.Minute},
+		},
+		{"object without estimate", `{"description":"Review PRs"}`, Task{Description: "Review PRs"}},
+		{"minutes", `{"description":"Review PRs","estimate":45}`, Task{Description: "Review PRs", Estimate: 45 * time.Minute}},
+		{"minutes string", `{"description":"Review PRs","estimate":"45"}`, Task{Description: "Review PRs", Estimate: 45 * time.Minute}},
+		{"go duration", `{"description":"Review PRs","estimate":"2h15m"}`, Task{Description: "Review PRs", Estimate: 2*time.Hour + 15*time.Minute}},
+		{"iso days", `{"description":"Migrate","estimate":"P1DT2H"}`, Task{Description: "Migrate", Estimate: 26 * time.Hour}},
+		{"null estimate", `{"description":"Review PRs","estimate":null}`, Task{Description: "Review PRs"}},
+		{"plain text", "(A) Call the bank +errands\nsecond line\n", Task{Description: "(A) Call the bank +errands"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.out))
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	for _, out := range []string{
+		"",
+		"[]",
+		`[{"description":"a"},{"description":"b"}]`,
+		`{"description":""}`,
+		`{"description":"x","estimate":"P1M"}`,
+		`{"description":"x","estimate":"PT"}`,
+		`{"description":"x","estimate":"soon"}`,
+		`{"description":"x","estimate":-5}`,
+		`[{"description":`,
+	} {
+		if _, err := Parse([]byte(out)); err == nil {
+			t.Errorf("Parse(%q) expected error", out)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	got, err := Lookup("7", `echo '{"description":"Task {id}","estimate":"PT25M"}'`)
+	if err != nil {
+		t.Fatalf("Lookup() error: %v", err)
+	}
+	if want := (Task{ID: "7", Description: "Task 7", Estimate: 25 * time.Minute}); got != want {
+		t.Errorf("Lookup() = %+v, want %+v", got, want)
+	}
+
+	if _, err := Lookup("7; rm -rf ~", "echo {id}"); err == nil {
+		t.Error("Lookup() accepted an ID with shell syntax")
+	}
+	if _, err := Lookup("7", "echo no such task >&2; exit 1"); err == nil || !strings.Contains(err.Error(), "no such task") {
+		t.Errorf("Lookup() error = %v, want the command's stderr", err)
+	}
+}
@@ -0,0 +1,255 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stigoleg/keep-alive/pkg/ui/countdown"
+)
+
+// attachedPollInterval is how often the attached dashboard reads the status
+// of the instance it is attached to.
+const attachedPollInterval = time.Second
+
+// AttachedStatus is the state of another keepalive instance, as shown by the
+// attached dashboard.
+type AttachedStatus struct {
+	Running          bool
+	Paused           bool
+	SimulateActivity bool
+	SimulationMethod string
+	// EndTime is zero for sessions without a time limit.
+	EndTime time.Time
+	Version string
+}
+
+// Attachment reaches a keepalive instance running in another process, such
+// as the login service, so that the TUI can act as its front-end instead of
+// starting a second keeper.
+type Attachment interface {
+	Status() (AttachedStatus, error)
+	Control(cmd RemoteCommand, d time.Duration) error
+}
+
+type attachedStatusMsg struct {
+	status AttachedStatus
+	err    error
+}
+
+type attachedControlMsg struct {
+	err error
+}
+
+type attachedTickMsg struct{}
+
+// AttachedModel is a dashboard over an instance reached through an
+// Attachment. Quitting it detaches and leaves that instance running.
+type AttachedModel struct {
+	attachment Attachment
+	status     AttachedStatus
+	// lost is set when the instance can no longer be reached.
+	lost   error
+	loaded bool
+	notice string
+	// ErrorMessage is the outcome of the last control request that failed.
+	ErrorMessage string
+	help         help.Model
+	keys         attachedKeyMap
+}
+
+// NewAttachedModel returns a dashboard over a. Notice, if set, is shown
+// below the status, for example to say that session flags were not applied.
+func NewAttachedModel(a Attachment, notice string) AttachedModel {
+	return AttachedModel{
+		attachment: a,
+		notice:     notice,
+		help:       NewHelpModel(),
+		keys:       defaultAttachedKeys(),
+	}
+}
+
+// Init implements tea.Model.
+func (m AttachedModel) Init() tea.Cmd {
+	return m.readStatus()
+}
+
+// Update implements tea.Model.
+func (m AttachedModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case attachedStatusMsg:
+		m.loaded = true
+		m.lost = msg.err
+		if msg.err == nil {
+			m.status = msg.status
+		}
+		return m, tea.Tick(attachedPollInterval, func(time.Time) tea.Msg { return attachedTickMsg{} })
+	case attachedTickMsg:
+		return m, m.readStatus()
+	case attachedControlMsg:
+		m.ErrorMessage = ""
+		if msg.err != nil {
+			m.ErrorMessage = "Request failed • " + msg.err.Error()
+		}
+		return m, nil
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m AttachedModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Detach):
+		return m, tea.Quit
+	case m.lost != nil || !m.loaded:
+		return m, nil
+	case key.Matches(msg, m.keys.StartStop):
+		if m.status.Running {
+			return m, m.control(RemoteStop, 0)
+		}
+		return m, m.control(RemoteStart, 0)
+	case !m.status.Running:
+		return m, nil
+	case key.Matches(msg, m.keys.Pause):
+		if m.status.Paused {
+			return m, m.control(RemoteResume, 0)
+		}
+		return m, m.control(RemotePause, 0)
+	case key.Matches(msg, m.keys.Extend):
+		return m, m.control(RemoteExtend, extendStep)
+	case key.Matches(msg, m.keys.Simulation):
+		if m.status.SimulateActivity {
+			return m, m.control(RemoteSimulationOff, 0)
+		}
+		return m, m.control(RemoteSimulationOn, 0)
+	}
+	return m, nil
+}
+
+// readStatus reads the status off the UI goroutine.
+func (m AttachedModel) readStatus() tea.Cmd {
+	a := m.attachment
+	return func() tea.Msg {
+		status, err := a.Status()
+		return attachedStatusMsg{status: status, err: err}
+	}
+}
+
+// control sends cmd off the UI goroutine; the next status read shows its
+// effect.
+func (m AttachedModel) control(cmd RemoteCommand, d time.Duration) tea.Cmd {
+	a := m.attachment
+	return func() tea.Msg {
+		return attachedControlMsg{err: a.Control(cmd, d)}
+	}
+}
+
+// View implements tea.Model.
+func (m AttachedModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(Current.Title.Render("Keep Alive (attached)"))
+	b.WriteString("\n\n")
+
+	source := "Showing the keepalive that is already running"
+	if m.status.Version != "" {
+		source += " (" + m.status.Version + ")"
+	}
+	b.WriteString(Current.Unselected.Render(source))
+	b.WriteString("\n")
+
+	switch {
+	case m.lost != nil:
+		b.WriteString(Current.Error.Render("Lost the running keepalive • " + m.lost.Error()))
+		b.WriteString("\n")
+	case !m.loaded:
+		b.WriteString(Current.Unselected.Render("Reading status..."))
+		b.WriteString("\n")
+	case !m.status.Running:
+		b.WriteString(Current.Unselected.Render("Not keeping the system awake"))
+		b.WriteString("\n")
+	default:
+		if m.status.Paused {
+			b.WriteString(Current.Error.Render("Paused"))
+		} else {
+			b.WriteString(Current.Awake.Render("System is being kept awake"))
+		}
+		b.WriteString("\n")
+		if m.status.SimulateActivity {
+			label := "Activity simulation enabled"
+			if m.status.SimulationMethod != "" {
+				label += " via " + m.status.SimulationMethod
+			}
+			b.WriteString(Current.Unselected.Render(label))
+			b.WriteString("\n")
+		}
+		if !m.status.EndTime.IsZero() {
+			remaining := time.Until(m.status.EndTime).Round(time.Second)
+			if remaining < 0 {
+				remaining = 0
+			}
+			b.WriteString(Current.Unselected.Render(countdown.Label(remaining, m.status.EndTime)))
+			b.WriteString("\n")
+		}
+	}
+
+	if m.notice != "" {
+		b.WriteString("\n" + Current.Unselected.Render(m.notice) + "\n")
+	}
+
+	b.WriteString("\n" + m.help.View(m.keys))
+
+	if m.ErrorMessage != "" {
+		b.WriteString("\n\n" + Current.Error.Render(m.ErrorMessage))
+	}
+
+	return b.String()
+}
+
+// attachedKeyMap holds the dashboard's bindings. It implements help.KeyMap.
+type attachedKeyMap struct {
+	StartStop  key.Binding
+	Pause      key.Binding
+	Extend     key.Binding
+	Simulation key.Binding
+	Detach     key.Binding
+}
+
+func defaultAttachedKeys() attachedKeyMap {
+	return attachedKeyMap{
+		StartStop: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "start/stop"),
+		),
+		Pause: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "pause/resume"),
+		),
+		Extend: key.NewBinding(
+			key.WithKeys("+", "="),
+			key.WithHelp("+", "+5 min"),
+		),
+		Simulation: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "simulation on/off"),
+		),
+		Detach: key.NewBinding(
+			key.WithKeys("q", "esc", "ctrl+c"),
+			key.WithHelp("q", "detach"),
+		),
+	}
+}
+
+// ShortHelp implements help.KeyMap.
+func (k attachedKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.StartStop, k.Pause, k.Extend, k.Simulation, k.Detach}
+}
+
+// FullHelp implements help.KeyMap.
+func (k attachedKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
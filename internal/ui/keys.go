@@ -3,6 +3,7 @@ package ui
 import (
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 // KeyMap defines key bindings for various UI states and common actions.
@@ -15,7 +16,11 @@ type KeyMap struct {
 	// Menu navigation
 	Up     key.Binding
 	Down   key.Binding
+	Top    key.Binding
+	Bottom key.Binding
 	Select key.Binding
+	// Number activates a list entry by its 1-based position.
+	Number key.Binding
 
 	// Timed input
 	Back      key.Binding
@@ -23,7 +28,13 @@ type KeyMap struct {
 	Backspace key.Binding
 
 	// Running
-	Stop key.Binding
+	Stop         key.Binding
+	Pause        key.Binding
+	SimulateOnce key.Binding
+	// Extend and Shorten move the end of a timed session by extendStep.
+	// Extend's help covers both.
+	Extend  key.Binding
+	Shorten key.Binding
 }
 
 // DefaultKeys returns the default key bindings for the application.
@@ -49,10 +60,23 @@ func DefaultKeys() KeyMap {
 			key.WithKeys("down", "j"),
 			key.WithHelp("↓/j", "down"),
 		),
+		// "gg" also jumps to the top; it is handled as a key sequence.
+		Top: key.NewBinding(
+			key.WithKeys("home"),
+			key.WithHelp("gg/home", "top"),
+		),
+		Bottom: key.NewBinding(
+			key.WithKeys("end", "G"),
+			key.WithHelp("G/end", "bottom"),
+		),
 		Select: key.NewBinding(
 			key.WithKeys("enter", " "),
 			key.WithHelp("enter", "select"),
 		),
+		Number: key.NewBinding(
+			key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+			key.WithHelp("1-9", "choose"),
+		),
 		Back: key.NewBinding(
 			key.WithKeys("esc"),
 			key.WithHelp("esc", "back"),
@@ -69,7 +93,30 @@ func DefaultKeys() KeyMap {
 			key.WithKeys("s", "esc"),
 			key.WithHelp("s/esc", "stop"),
 		),
+		Pause: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "pause/resume"),
+		),
+		SimulateOnce: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "test simulation"),
+		),
+		Extend: key.NewBinding(
+			key.WithKeys("+", "="),
+			key.WithHelp("+/-", "±5 min"),
+		),
+		Shorten: key.NewBinding(
+			key.WithKeys("-"),
+		),
+	}
+}
+
+// numberIndex returns the zero-based list index for a Number key press.
+func (k KeyMap) numberIndex(msg tea.KeyMsg) (int, bool) {
+	if !key.Matches(msg, k.Number) {
+		return 0, false
 	}
+	return int(msg.String()[0] - '1'), true
 }
 
 // NewHelpModel returns a configured help model.
@@ -93,15 +140,17 @@ func (k KeyMap) ForState(s state) help.KeyMap {
 func (s stateKeyMap) ShortHelp() []key.Binding {
 	switch s.state {
 	case stateMenu:
-		return []key.Binding{s.keys.Up, s.keys.Down, s.keys.Select, s.keys.ToggleHelp, s.keys.Quit}
+		return []key.Binding{s.keys.Up, s.keys.Down, s.keys.Select, s.keys.Number, s.keys.ToggleHelp, s.keys.Quit}
 	case stateTimedInput:
 		return []key.Binding{s.keys.Submit, s.keys.Backspace, s.keys.Back, s.keys.Quit}
 	case stateClockInput:
 		return []key.Binding{s.keys.Submit, s.keys.Backspace, s.keys.Back, s.keys.Quit}
-	case stateBatteryInput:
+	case stateBatteryInput, stateTemplateInput:
 		return []key.Binding{s.keys.Submit, s.keys.Backspace, s.keys.Back, s.keys.Quit}
+	case stateTemplates:
+		return []key.Binding{s.keys.Up, s.keys.Down, s.keys.Select, s.keys.Number, s.keys.Back}
 	case stateRunning:
-		return []key.Binding{s.keys.Stop, s.keys.Quit, s.keys.ToggleHelp}
+		return []key.Binding{s.keys.Stop, s.keys.Pause, s.keys.Extend, s.keys.SimulateOnce, s.keys.Quit, s.keys.ToggleHelp}
 	default:
 		return []key.Binding{s.keys.ToggleHelp, s.keys.Quit}
 	}
@@ -111,15 +160,17 @@ func (s stateKeyMap) ShortHelp() []key.Binding {
 func (s stateKeyMap) FullHelp() [][]key.Binding {
 	switch s.state {
 	case stateMenu:
-		return [][]key.Binding{{s.keys.Up, s.keys.Down, s.keys.Select}, {s.keys.ToggleHelp, s.keys.Quit}}
+		return [][]key.Binding{{s.keys.Up, s.keys.Down, s.keys.Top, s.keys.Bottom}, {s.keys.Select, s.keys.Number}, {s.keys.ToggleHelp, s.keys.Quit}}
 	case stateTimedInput:
 		return [][]key.Binding{{s.keys.Submit, s.keys.Backspace, s.keys.Back}, {s.keys.Quit}}
 	case stateClockInput:
 		return [][]key.Binding{{s.keys.Submit, s.keys.Backspace, s.keys.Back}, {s.keys.Quit}}
-	case stateBatteryInput:
+	case stateBatteryInput, stateTemplateInput:
 		return [][]key.Binding{{s.keys.Submit, s.keys.Backspace, s.keys.Back}, {s.keys.Quit}}
+	case stateTemplates:
+		return [][]key.Binding{{s.keys.Up, s.keys.Down, s.keys.Top, s.keys.Bottom}, {s.keys.Select, s.keys.Number}, {s.keys.Back}}
 	case stateRunning:
-		return [][]key.Binding{{s.keys.Stop, s.keys.Quit}, {s.keys.ToggleHelp}}
+		return [][]key.Binding{{s.keys.Stop, s.keys.Pause, s.keys.Extend, s.keys.SimulateOnce, s.keys.Quit}, {s.keys.ToggleHelp}}
 	default:
 		return [][]key.Binding{{s.keys.ToggleHelp, s.keys.Quit}}
 	}
@@ -11,12 +11,26 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	"github.com/stigoleg/keep-alive/internal/keepalive"
 	"github.com/stigoleg/keep-alive/internal/platform"
+	"github.com/stigoleg/keep-alive/internal/schedule"
+	"github.com/stigoleg/keep-alive/internal/sessionstate"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 const batteryPollInterval = 30 * time.Second
 
+// extendStep is how far the extend and shorten keys move the end of a timed
+// session.
+const extendStep = 5 * time.Minute
+
+// powerSourceRefreshInterval is how often the running view refreshes the AC-only
+// and schedule suspension state.
+const powerSourceRefreshInterval = 5 * time.Second
+
+// keeperCheckInterval is how often the running view checks whether the keeper
+// ended the session on its own, for a watched process or an idle limit.
+const keeperCheckInterval = time.Second
+
 const defaultTerminalWidth = 80
 
 // state represents the different states of the TUI.
@@ -27,6 +41,8 @@ const (
 	stateTimedInput
 	stateClockInput
 	stateBatteryInput
+	stateTemplates
+	stateTemplateInput
 	stateRunning
 )
 
@@ -42,20 +58,63 @@ type Model struct {
 	Clock              time.Time
 	ShowHelp           bool
 	ShowDependencyInfo bool
-	DependencyWarning  string
-	ActivityWarning    string
-	version            string
-	Keys               KeyMap
-	Help               help.Model
-	HelpViewport       viewport.Model
-	timer              timer.Model
-	progress           progress.Model
-	SimulateActivity   bool
-	BatteryThreshold   int
-	BatteryPercentage  int
-	BatteryError       string
-	Width              int
-	Height             int
+	// ShowAccessibility shows the dialog explaining the macOS Accessibility
+	// grant that activity simulation needs.
+	ShowAccessibility bool
+	// ShowConsent asks the user to enable the synthetic-input privacy level
+	// before activity simulation is turned on for the first time.
+	ShowConsent       bool
+	DependencyWarning string
+	// Capabilities is shown in the dependency information view once the
+	// probe started by the caller reports it.
+	Capabilities      *platform.Capabilities
+	ActivityWarning   string
+	version           string
+	Keys              KeyMap
+	Help              help.Model
+	HelpViewport      viewport.Model
+	timer             timer.Model
+	progress          progress.Model
+	SimulateActivity  bool
+	ACOnly            bool
+	Schedule          *schedule.Schedule
+	Watch             keepalive.ProcessWatch
+	UntilIdle         time.Duration
+	WhileCmd          string
+	BatteryThreshold  int
+	BatteryPercentage int
+	BatteryError      string
+	Width             int
+	Height            int
+
+	// pausedAt is when the running session was paused, if it is; pausedFor
+	// is the session's total time paused before that.
+	pausedAt  time.Time
+	pausedFor time.Duration
+
+	// SimulationNotice reports the last simulation test fire.
+	SimulationNotice string
+
+	// UpdateVersion is a newer release found by the update check.
+	UpdateVersion string
+
+	// Reason is what the session is for, such as the description of the
+	// task given with --from-task.
+	Reason string
+
+	// Resumable is a session cut short by a crash that the menu offers to
+	// resume, if any.
+	Resumable *sessionstate.State
+
+	// Templates lists the presets on the templates screen. Nil selects
+	// DefaultTemplates.
+	Templates        []Template
+	templateSelected int
+	pendingTemplate  Template
+
+	// pendingG is set after a single "g" so that a second one jumps to the
+	// top of a list.
+	pendingG bool
 }
 
 // InitialModel returns the initial model for the TUI.
@@ -64,7 +123,7 @@ func InitialModel() Model {
 		State:              stateMenu,
 		Selected:           0,
 		textInput:          newMinutesTextInput(),
-		KeepAlive:          keepalive.NewKeeper(),
+		KeepAlive:          keepalive.New(),
 		ShowHelp:           false,
 		ShowDependencyInfo: false,
 		DependencyWarning:  "",
@@ -90,6 +149,16 @@ func InitialModelWithBattery(threshold int, status platform.BatteryStatus, simul
 
 // InitialModelWithLimits returns a model initialized with any active runtime limits.
 func InitialModelWithLimits(minutes int, threshold int, status platform.BatteryStatus, simulateActivity bool) Model {
+	return initialRunningModel(minutes, time.Time{}, threshold, status, simulateActivity)
+}
+
+// InitialModelUntil returns a model running until the wall-clock time clock,
+// with an optional battery threshold.
+func InitialModelUntil(clock time.Time, threshold int, status platform.BatteryStatus, simulateActivity bool) Model {
+	return initialRunningModel(0, clock, threshold, status, simulateActivity)
+}
+
+func initialRunningModel(minutes int, clock time.Time, threshold int, status platform.BatteryStatus, simulateActivity bool) Model {
 	m := InitialModel()
 	m.SimulateActivity = simulateActivity
 	if minutes > 0 {
@@ -97,6 +166,11 @@ func InitialModelWithLimits(minutes int, threshold int, status platform.BatteryS
 		m.Duration = time.Duration(minutes) * time.Minute
 		m.timer = timer.NewWithInterval(m.Duration, time.Second/10)
 	}
+	if !clock.IsZero() {
+		m.Clock = clock
+		m.Duration = time.Until(clock)
+		m.timer = timer.NewWithInterval(m.Duration, time.Second/10)
+	}
 	if threshold > 0 {
 		m.BatteryThreshold = threshold
 		m.BatteryPercentage = status.Percentage
@@ -107,7 +181,9 @@ func InitialModelWithLimits(minutes int, threshold int, status platform.BatteryS
 
 	m.KeepAlive.SetSimulateActivity(simulateActivity)
 	var err error
-	if m.Duration > 0 {
+	if !m.Clock.IsZero() {
+		err = m.KeepAlive.StartUntil(m.Clock)
+	} else if m.Duration > 0 {
 		err = m.KeepAlive.StartTimed(m.Duration)
 	} else {
 		err = m.KeepAlive.StartIndefinite()
@@ -131,6 +207,12 @@ func (m Model) Init() tea.Cmd {
 		if m.BatteryThreshold > 0 {
 			cmds = append(cmds, batteryPollCmd())
 		}
+		if m.maySuspend() {
+			cmds = append(cmds, powerSourceRefreshCmd(m.StartTime))
+		}
+		if m.keeperMayStop() {
+			cmds = append(cmds, keeperCheckCmd(m.StartTime))
+		}
 		if len(cmds) > 0 {
 			return tea.Batch(cmds...)
 		}
@@ -149,12 +231,21 @@ func (m Model) View() string {
 	return View(m)
 }
 
-// TimeRemaining returns the remaining duration for timed keep-alive
+// TimeRemaining returns the remaining duration for timed keep-alive. Time
+// spent paused does not count.
 func (m Model) TimeRemaining() time.Duration {
 	if m.State != stateRunning {
 		return 0
 	}
-	elapsed := time.Since(m.StartTime)
+	if !m.Clock.IsZero() && m.KeepAlive.IsRunning() {
+		// The keeper follows the wall clock across system sleep.
+		return m.KeepAlive.TimeRemaining()
+	}
+	at := time.Now()
+	if !m.pausedAt.IsZero() {
+		at = m.pausedAt
+	}
+	elapsed := at.Sub(m.StartTime) - m.pausedFor
 	remaining := m.Duration - elapsed
 	if remaining < 0 {
 		return 0
@@ -172,11 +263,80 @@ func (m Model) Version() string {
 	return m.version
 }
 
+// UpdateAvailableMsg reports that a newer release than the running version
+// has been published.
+type UpdateAvailableMsg struct {
+	Version string
+}
+
+// CapabilitiesMsg carries what keep-alive can do on this machine, probed
+// after startup for the dependency information view.
+type CapabilitiesMsg struct {
+	Capabilities platform.Capabilities
+}
+
 // SetDependencyWarning sets the dependency warning message
 func (m *Model) SetDependencyWarning(message string) {
 	m.DependencyWarning = message
 }
 
+// SetACOnly suspends keep-alive while on battery power, including for a
+// session that is already running.
+func (m *Model) SetACOnly(acOnly bool) {
+	m.ACOnly = acOnly
+	m.KeepAlive.SetACOnly(acOnly)
+}
+
+// SetSchedule keeps the system awake only within the windows of s, including
+// for a session that is already running. Nil removes the restriction.
+func (m *Model) SetSchedule(s *schedule.Schedule) {
+	m.Schedule = s
+	m.KeepAlive.SetSchedule(s)
+}
+
+// maySuspend reports whether the keeper can suspend and resume the session
+// without the UI asking it to.
+func (m Model) maySuspend() bool {
+	return m.ACOnly || m.Schedule != nil
+}
+
+// SetProcessWatch ends the session, and the program, when the watched process
+// exits.
+func (m *Model) SetProcessWatch(w keepalive.ProcessWatch) {
+	m.Watch = w
+	m.KeepAlive.SetProcessWatch(w)
+}
+
+// SetUntilIdle ends the session, and the program, once the user has been
+// idle for d.
+func (m *Model) SetUntilIdle(d time.Duration) {
+	m.UntilIdle = d
+	m.KeepAlive.SetUntilIdle(d)
+}
+
+// SetWhileCmd ends the session, and the program, once command no longer
+// succeeds. It applies to sessions without a duration or end time.
+func (m *Model) SetWhileCmd(command string) {
+	m.WhileCmd = command
+}
+
+// keeperMayStop reports whether the keeper can end the session without the
+// UI asking it to.
+func (m Model) keeperMayStop() bool {
+	return !m.Watch.IsZero() || m.UntilIdle > 0 || !m.Clock.IsZero() || m.WhileCmd != ""
+}
+
+// SetResumable offers s, a session cut short by a crash, at the top of the
+// menu.
+func (m *Model) SetResumable(s sessionstate.State) {
+	m.Resumable = &s
+}
+
+// SetReason sets what the session is for, shown while it runs.
+func (m *Model) SetReason(reason string) {
+	m.Reason = reason
+}
+
 func (m *Model) SetActivityWarning(message string) {
 	m.ActivityWarning = message
 }
@@ -193,8 +353,8 @@ func newMinutesTextInput() textinput.Model {
 
 func newClockTextInput() textinput.Model {
 	ti := textinput.New()
-	ti.Placeholder = "e.g. 22:00 or 10:00PM"
-	ti.CharLimit = 16
+	ti.Placeholder = "e.g. 22:00, 10:00PM or maintenance"
+	ti.CharLimit = 40
 	ti.Width = 24
 	ti.Focus()
 	return ti
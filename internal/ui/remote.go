@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stigoleg/keep-alive/internal/privacy"
+)
+
+// RemoteCommand identifies an action requested by an external controller such
+// as the D-Bus service.
+type RemoteCommand int
+
+const (
+	RemoteStart RemoteCommand = iota
+	RemoteStop
+	RemoteExtend
+	RemotePause
+	RemoteResume
+	RemoteSimulationOn
+	RemoteSimulationOff
+)
+
+// RemoteCommandMsg asks the TUI to perform an action on behalf of an external
+// controller so that the screen stays in sync with the keeper. The outcome is
+// sent on Reply, which must be buffered.
+type RemoteCommandMsg struct {
+	Command  RemoteCommand
+	Duration time.Duration
+	Reply    chan error
+}
+
+// handleRemoteCommand applies a RemoteCommandMsg regardless of which screen or
+// overlay is currently shown.
+func handleRemoteCommand(msg RemoteCommandMsg, m Model) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var err error
+
+	switch msg.Command {
+	case RemoteStart:
+		if m.State == stateRunning {
+			err = errors.New("keep-alive already running")
+			break
+		}
+		m, cmd = startSession(m, msg.Duration, time.Time{})
+		if m.State != stateRunning {
+			err = errors.New(strings.TrimPrefix(m.ErrorMessage, "System Error • "))
+		}
+	case RemoteStop:
+		if m.State != stateRunning {
+			break
+		}
+		var cleaned Model
+		cleaned, err = cleanup(m)
+		if err == nil {
+			m = cleaned
+		}
+	case RemoteExtend:
+		if m.State != stateRunning {
+			err = errors.New("keep-alive is not running")
+			break
+		}
+		m, err = extendSession(m, msg.Duration)
+	case RemotePause, RemoteResume:
+		if m.State != stateRunning {
+			err = errors.New("keep-alive is not running")
+			break
+		}
+		switch {
+		case msg.Command == RemotePause && !m.KeepAlive.Paused():
+			m, cmd, err = pauseSession(m)
+		case msg.Command == RemoteResume && m.KeepAlive.Paused():
+			m, cmd, err = resumeSession(m)
+		}
+	case RemoteSimulationOn, RemoteSimulationOff:
+		on := msg.Command == RemoteSimulationOn
+		if !on && m.KeepAlive.PresenceOnly() {
+			err = errors.New("presence-only sessions need activity simulation")
+			break
+		}
+		if c, limited := m.KeepAlive.Consent(); on && limited {
+			if err = c.Check(privacy.SyntheticInput); err != nil {
+				break
+			}
+		}
+		m.KeepAlive.SetSimulateActivity(on)
+		if on && !m.KeepAlive.SimulateActivity() {
+			err = errors.New("activity simulation is not allowed by the administrator policy")
+			break
+		}
+		m.SimulateActivity = on
+	default:
+		err = errors.New("unknown remote command")
+	}
+
+	if msg.Reply != nil {
+		msg.Reply <- err
+	}
+	return m, cmd
+}
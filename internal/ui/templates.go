@@ -0,0 +1,217 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stigoleg/keep-alive/internal/keepalive"
+)
+
+// Template is a preset session offered on the templates screen.
+type Template struct {
+	Name        string
+	Description string
+	// Duration limits the session; zero keeps the system awake indefinitely.
+	Duration time.Duration
+	// Clock is the wall-clock end time shown for clock-based sessions.
+	Clock            time.Time
+	SimulateActivity bool
+	// Watch ends the session when the process exits.
+	Watch keepalive.ProcessWatch
+	// Input, when set, asks for a value before the session starts.
+	Input *TemplateInput
+}
+
+// TemplateInput describes the value a template asks for before starting.
+type TemplateInput struct {
+	Prompt      string
+	Placeholder string
+	// Apply returns the template completed with the entered value.
+	Apply func(t Template, value string, now time.Time) (Template, error)
+}
+
+// DefaultTemplates returns the built-in session templates.
+func DefaultTemplates() []Template {
+	return []Template{
+		{
+			Name:             "Meeting",
+			Description:      "1 hour, presence on",
+			Duration:         time.Hour,
+			SimulateActivity: true,
+		},
+		{
+			Name:        "Presentation",
+			Description: "90 minutes, no mouse movement",
+			Duration:    90 * time.Minute,
+		},
+		{
+			Name:             "Workday",
+			Description:      "until a clock time, presence on",
+			SimulateActivity: true,
+			Input: &TemplateInput{
+				Prompt:      "Enter the end of your workday (e.g., 17:00 or 5:00PM):",
+				Placeholder: "e.g. 17:00",
+				Apply:       applyClockTemplate,
+			},
+		},
+		{
+			Name:        "Build",
+			Description: "until a process exits",
+			Input: &TemplateInput{
+				Prompt:      "Enter the PID or name of the process to wait for:",
+				Placeholder: "e.g. 1234 or rsync",
+				Apply:       applyWatchTemplate,
+			},
+		},
+	}
+}
+
+// applyClockTemplate ends the session at the entered clock time.
+func applyClockTemplate(t Template, value string, now time.Time) (Template, error) {
+	target, err := parseClockTarget(value, now)
+	if err != nil {
+		return t, err
+	}
+	t.Duration = target.Sub(now)
+	t.Clock = target
+	return t, nil
+}
+
+// applyWatchTemplate watches the entered PID, or the process name when the
+// value is not a number.
+func applyWatchTemplate(t Template, value string, _ time.Time) (Template, error) {
+	w := keepalive.ProcessWatch{Name: value}
+	if pid, err := strconv.Atoi(value); err == nil {
+		w = keepalive.ProcessWatch{PID: pid}
+	}
+	if err := w.Check(); err != nil {
+		return t, err
+	}
+	t.Watch = w
+	return t, nil
+}
+
+// templates returns the configured templates, falling back to the built-in set.
+func (m Model) templates() []Template {
+	if m.Templates != nil {
+		return m.Templates
+	}
+	return DefaultTemplates()
+}
+
+// openTemplates shows the templates screen.
+func openTemplates(m Model) (Model, tea.Cmd) {
+	m.State = stateTemplates
+	m.templateSelected = 0
+	m.ErrorMessage = ""
+	return m, nil
+}
+
+func handleTemplatesState(msg tea.Msg, m Model) (Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	templates := m.templates()
+	if selected, ok := m.listJump(keyMsg, m.templateSelected, len(templates)); ok {
+		m.templateSelected = selected
+		return m, nil
+	}
+	if i, ok := m.Keys.numberIndex(keyMsg); ok {
+		if i >= len(templates) {
+			return m, nil
+		}
+		m.templateSelected = i
+		return chooseTemplate(m, templates[i])
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.Keys.ToggleHelp):
+		m.ShowHelp = true
+		m = syncHelpViewport(m)
+	case key.Matches(keyMsg, m.Keys.Back):
+		m.State = stateMenu
+		m.ErrorMessage = ""
+	case key.Matches(keyMsg, m.Keys.Up):
+		if m.templateSelected > 0 {
+			m.templateSelected--
+		}
+	case key.Matches(keyMsg, m.Keys.Down):
+		if m.templateSelected < len(templates)-1 {
+			m.templateSelected++
+		}
+	case key.Matches(keyMsg, m.Keys.Select) || keyMsg.Type == tea.KeyEnter:
+		if m.templateSelected >= len(templates) {
+			return m, nil
+		}
+		return chooseTemplate(m, templates[m.templateSelected])
+	case key.Matches(keyMsg, m.Keys.Quit):
+		return handleQuit(m)
+	}
+	return m, nil
+}
+
+// chooseTemplate starts t, first asking for its input if it needs one.
+func chooseTemplate(m Model, t Template) (Model, tea.Cmd) {
+	if t.Input != nil {
+		m.State = stateTemplateInput
+		m.pendingTemplate = t
+		m.ErrorMessage = ""
+		m.textInput = newTemplateTextInput(t.Input.Placeholder)
+		return m, nil
+	}
+	return startTemplate(m, t)
+}
+
+func handleTemplateInputState(msg tea.Msg, m Model) (Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.Keys.Back):
+		m.State = stateTemplates
+		m.ErrorMessage = ""
+		return m, nil
+	case key.Matches(keyMsg, m.Keys.Submit) || keyMsg.Type == tea.KeyEnter:
+		value := strings.TrimSpace(m.textInput.Value())
+		if value == "" {
+			m.ErrorMessage = "Value Required • " + m.pendingTemplate.Input.Prompt
+			return m, nil
+		}
+		t, err := m.pendingTemplate.Input.Apply(m.pendingTemplate, value, time.Now())
+		if err != nil {
+			m.ErrorMessage = err.Error()
+			return m, nil
+		}
+		return startTemplate(m, t)
+	}
+
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(keyMsg)
+	m.ErrorMessage = ""
+	return m, cmd
+}
+
+// startTemplate starts a session with the template's settings. The activity
+// toggle follows the template so the menu reflects the last session.
+func startTemplate(m Model, t Template) (Model, tea.Cmd) {
+	m.SimulateActivity = t.SimulateActivity
+	m.SetProcessWatch(t.Watch)
+	return startSession(m, t.Duration, t.Clock)
+}
+
+func newTemplateTextInput(placeholder string) textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = placeholder
+	ti.CharLimit = 32
+	ti.Width = 24
+	ti.Focus()
+	return ti
+}
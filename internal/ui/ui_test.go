@@ -2,8 +2,11 @@ package ui
 
 import (
 	"context"
+	"errors"
+	"os"
 	"os/exec"
 	"runtime"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -11,6 +14,8 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/stigoleg/keep-alive/internal/keepalive"
 	"github.com/stigoleg/keep-alive/internal/platform"
+	"github.com/stigoleg/keep-alive/internal/privacy"
+	"github.com/stigoleg/keep-alive/internal/sessionstate"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -61,6 +66,17 @@ func TestMenuView(t *testing.T) {
 	}
 }
 
+func TestMenuViewShowsUpdate(t *testing.T) {
+	m := InitialModel()
+	if strings.Contains(View(m), "keepalive upgrade") {
+		t.Fatal("menu mentions an upgrade before one was found")
+	}
+	m, _ = Update(UpdateAvailableMsg{Version: "9.9.9"}, m)
+	if view := View(m); !strings.Contains(view, "9.9.9 is available") {
+		t.Errorf("menu does not show the available update:\n%s", view)
+	}
+}
+
 func TestUpdate(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -102,7 +118,7 @@ func TestUpdate(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.model.KeepAlive = keepalive.NewKeeper()
+			tt.model.KeepAlive = keepalive.New()
 			got, _ := Update(tt.msg, tt.model)
 			if got.State != tt.wantType {
 				t.Errorf("Update() state = %v, want %v", got.State, tt.wantType)
@@ -114,7 +130,7 @@ func TestUpdate(t *testing.T) {
 func TestTimedInputView(t *testing.T) {
 	m := Model{
 		State:     stateTimedInput,
-		KeepAlive: keepalive.NewKeeper(),
+		KeepAlive: keepalive.New(),
 	}
 	m.textInput = newMinutesTextInput()
 	m.textInput.SetValue("5")
@@ -128,10 +144,30 @@ func TestTimedInputView(t *testing.T) {
 	}
 }
 
+func TestDurationPreview(t *testing.T) {
+	now := time.Date(2026, 10, 16, 14, 12, 0, 0, time.Local)
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"2h30m", "2h30m, ends at 16:42"},
+		{"230", "3h50m, ends at 18:02"},
+		{"12h", "12h, ends at Sat 02:12"},
+		{"", ""},
+		{"2x", ""},
+		{"0", ""},
+	}
+	for _, tt := range tests {
+		if got := durationPreview(tt.value, now); got != tt.want {
+			t.Errorf("durationPreview(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
 func TestClockInputView(t *testing.T) {
 	m := Model{
 		State:     stateClockInput,
-		KeepAlive: keepalive.NewKeeper(),
+		KeepAlive: keepalive.New(),
 	}
 	m.textInput = newClockTextInput()
 	m.textInput.SetValue("22:00")
@@ -149,7 +185,7 @@ func TestBatteryInputSetsThreshold(t *testing.T) {
 	restore := stubBatteryStatus(platformBatteryStatus(80), nil)
 	defer restore()
 
-	m := Model{State: stateBatteryInput, KeepAlive: keepalive.NewKeeper()}
+	m := Model{State: stateBatteryInput, KeepAlive: keepalive.New()}
 	m.textInput = newBatteryTextInput(0)
 	m.textInput.SetValue("65")
 
@@ -169,7 +205,7 @@ func TestBatteryInputRejectsThresholdAtCurrentBattery(t *testing.T) {
 	restore := stubBatteryStatus(platformBatteryStatus(65), nil)
 	defer restore()
 
-	m := Model{State: stateBatteryInput, KeepAlive: keepalive.NewKeeper()}
+	m := Model{State: stateBatteryInput, KeepAlive: keepalive.New()}
 	m.textInput = newBatteryTextInput(0)
 	m.textInput.SetValue("65")
 
@@ -184,7 +220,7 @@ func TestBatteryInputRejectsThresholdAtCurrentBattery(t *testing.T) {
 
 func TestTimedInputValidationErrors(t *testing.T) {
 	// Empty input
-	m := Model{State: stateTimedInput, KeepAlive: keepalive.NewKeeper()}
+	m := Model{State: stateTimedInput, KeepAlive: keepalive.New()}
 	m.textInput = newMinutesTextInput()
 	m.textInput.SetValue("")
 	got, _ := Update(tea.KeyMsg{Type: tea.KeyEnter}, m)
@@ -193,7 +229,7 @@ func TestTimedInputValidationErrors(t *testing.T) {
 	}
 
 	// Zero minutes
-	m2 := Model{State: stateTimedInput, KeepAlive: keepalive.NewKeeper()}
+	m2 := Model{State: stateTimedInput, KeepAlive: keepalive.New()}
 	m2.textInput = newMinutesTextInput()
 	m2.textInput.SetValue("0")
 	got2, _ := Update(tea.KeyMsg{Type: tea.KeyEnter}, m2)
@@ -207,7 +243,7 @@ func TestRunningView(t *testing.T) {
 		State:     stateRunning,
 		StartTime: time.Now(),
 		Duration:  5 * time.Minute,
-		KeepAlive: keepalive.NewKeeper(),
+		KeepAlive: keepalive.New(),
 	}
 	view := View(m)
 
@@ -225,7 +261,7 @@ func TestRunningView(t *testing.T) {
 func TestRunningViewBatteryMode(t *testing.T) {
 	m := Model{
 		State:             stateRunning,
-		KeepAlive:         keepalive.NewKeeper(),
+		KeepAlive:         keepalive.New(),
 		BatteryThreshold:  20,
 		BatteryPercentage: 42,
 	}
@@ -239,12 +275,41 @@ func TestRunningViewBatteryMode(t *testing.T) {
 	}
 }
 
+func TestRunningViewACOnly(t *testing.T) {
+	m := Model{
+		State:     stateRunning,
+		KeepAlive: keepalive.New(),
+		ACOnly:    true,
+	}
+	view := View(m)
+
+	if !strings.Contains(view, "AC power only") {
+		t.Error("expected view to show AC-only mode")
+	}
+}
+
+func TestStalePowerSourceRefreshIsDropped(t *testing.T) {
+	m := Model{
+		State:     stateRunning,
+		StartTime: time.Now(),
+		KeepAlive: keepalive.New(),
+		ACOnly:    true,
+	}
+
+	if _, cmd := Update(powerSourceRefreshMsg{session: m.StartTime}, m); cmd == nil {
+		t.Fatal("expected refresh to be rescheduled for the current session")
+	}
+	if _, cmd := Update(powerSourceRefreshMsg{session: m.StartTime.Add(-time.Minute)}, m); cmd != nil {
+		t.Fatal("expected refresh from an earlier session to be dropped")
+	}
+}
+
 func TestRunningViewCombinedLimits(t *testing.T) {
 	m := Model{
 		State:             stateRunning,
 		StartTime:         time.Now(),
 		Duration:          5 * time.Minute,
-		KeepAlive:         keepalive.NewKeeper(),
+		KeepAlive:         keepalive.New(),
 		BatteryThreshold:  20,
 		BatteryPercentage: 42,
 	}
@@ -261,7 +326,7 @@ func TestRunningViewCombinedLimits(t *testing.T) {
 func TestBatteryStatusAtThresholdQuits(t *testing.T) {
 	m := Model{
 		State:            stateRunning,
-		KeepAlive:        keepalive.NewKeeper(),
+		KeepAlive:        keepalive.New(),
 		BatteryThreshold: 20,
 	}
 
@@ -277,7 +342,7 @@ func TestBatteryStatusAtThresholdQuits(t *testing.T) {
 func TestBatteryStatusAboveThresholdKeepsRunning(t *testing.T) {
 	m := Model{
 		State:            stateRunning,
-		KeepAlive:        keepalive.NewKeeper(),
+		KeepAlive:        keepalive.New(),
 		BatteryThreshold: 20,
 	}
 
@@ -438,7 +503,7 @@ func TestErrorDisplay(t *testing.T) {
 	m := Model{
 		State:        stateMenu,
 		ErrorMessage: "test error",
-		KeepAlive:    keepalive.NewKeeper(),
+		KeepAlive:    keepalive.New(),
 	}
 	view := View(m)
 
@@ -483,7 +548,7 @@ func TestTimeRemaining(t *testing.T) {
 	})
 
 	// Create a keeper with a short duration
-	k := keepalive.NewKeeper()
+	k := keepalive.New()
 	defer k.Stop() // Ensure cleanup even if test fails
 
 	// Start timed with a short duration
@@ -521,3 +586,608 @@ func TestTimeRemaining(t *testing.T) {
 		t.Error("TimeRemaining not 0 after stop")
 	}
 }
+
+func TestRemoteCommandRepliesWhenIdle(t *testing.T) {
+	m := InitialModel()
+
+	reply := make(chan error, 1)
+	m, _ = Update(RemoteCommandMsg{Command: RemoteExtend, Duration: time.Minute, Reply: reply}, m)
+	if err := <-reply; err == nil {
+		t.Fatal("expected error extending while idle")
+	}
+
+	m, _ = Update(RemoteCommandMsg{Command: RemoteStop, Reply: reply}, m)
+	if err := <-reply; err != nil {
+		t.Fatalf("stop while idle returned error: %v", err)
+	}
+	if m.State != stateMenu {
+		t.Fatalf("state = %v, want menu", m.State)
+	}
+}
+
+func TestRemoteCommandStartAndExtend(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode")
+	}
+
+	m := InitialModel()
+	defer m.KeepAlive.Stop()
+
+	reply := make(chan error, 1)
+	m, _ = Update(RemoteCommandMsg{Command: RemoteStart, Duration: time.Minute, Reply: reply}, m)
+	if err := <-reply; err != nil {
+		if err.Error() == "unsupported platform" {
+			t.Skip("Skipping on unsupported platform")
+		}
+		t.Fatalf("remote start failed: %v", err)
+	}
+	if m.State != stateRunning {
+		t.Fatalf("state = %v, want running", m.State)
+	}
+
+	m, _ = Update(RemoteCommandMsg{Command: RemoteExtend, Duration: time.Minute, Reply: reply}, m)
+	if err := <-reply; err != nil {
+		t.Fatalf("remote extend failed: %v", err)
+	}
+	if m.Duration != 2*time.Minute {
+		t.Fatalf("Duration = %v, want 2m", m.Duration)
+	}
+
+	m, _ = Update(RemoteCommandMsg{Command: RemoteStop, Reply: reply}, m)
+	if err := <-reply; err != nil {
+		t.Fatalf("remote stop failed: %v", err)
+	}
+	if m.State != stateMenu || m.KeepAlive.IsRunning() {
+		t.Fatal("expected session to be stopped")
+	}
+}
+
+func TestRemotePauseIgnoresStaleError(t *testing.T) {
+	m := InitialModel()
+	m.KeepAlive = keepalive.New(keepalive.WithPlatform(fakeKeepAlive{}))
+	m, _ = startSession(m, time.Hour, time.Time{})
+	if m.State != stateRunning {
+		t.Fatalf("session not started: %s", m.ErrorMessage)
+	}
+	defer m.KeepAlive.Stop()
+
+	reply := make(chan error, 1)
+	for _, cmd := range []RemoteCommand{RemotePause, RemotePause, RemoteResume, RemoteResume} {
+		m.ErrorMessage = "Battery status unavailable"
+		m, _ = Update(RemoteCommandMsg{Command: cmd, Reply: reply}, m)
+		if err := <-reply; err != nil {
+			t.Fatalf("remote command %v with an unrelated error shown = %v, want nil", cmd, err)
+		}
+	}
+	if m.KeepAlive.Paused() {
+		t.Fatal("session still paused after pause and resume")
+	}
+}
+
+func TestTemplatesScreenNavigation(t *testing.T) {
+	m := InitialModel()
+	m.Selected = 3
+
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyEnter}, m)
+	if m.State != stateTemplates {
+		t.Fatalf("state = %v, want templates", m.State)
+	}
+	if view := View(m); !strings.Contains(view, "Meeting") || !strings.Contains(view, "Workday") {
+		t.Fatalf("templates view missing built-in templates:\n%s", view)
+	}
+
+	for i := 0; i < 4; i++ {
+		m, _ = Update(tea.KeyMsg{Type: tea.KeyDown}, m)
+	}
+	if m.templateSelected != 3 {
+		t.Fatalf("templateSelected = %d, want 3", m.templateSelected)
+	}
+
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyEnter}, m)
+	if m.State != stateTemplateInput {
+		t.Fatalf("state = %v, want template input", m.State)
+	}
+
+	m.textInput.SetValue("no-such-process-keepalive-test")
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyEnter}, m)
+	if m.State != stateTemplateInput || m.ErrorMessage == "" {
+		t.Fatalf("expected invalid input to keep the prompt open with an error, state = %v", m.State)
+	}
+
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyEsc}, m)
+	if m.State != stateTemplates {
+		t.Fatalf("state = %v, want templates after esc", m.State)
+	}
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyEsc}, m)
+	if m.State != stateMenu {
+		t.Fatalf("state = %v, want menu after esc", m.State)
+	}
+}
+
+func TestClockTemplateApply(t *testing.T) {
+	now := time.Date(2025, 1, 2, 18, 0, 0, 0, time.Local)
+	tmpl := DefaultTemplates()[2]
+
+	got, err := tmpl.Input.Apply(tmpl, "17:00", now)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got.Duration != 23*time.Hour {
+		t.Fatalf("Duration = %v, want 23h", got.Duration)
+	}
+	if !got.Clock.Equal(now.Add(23 * time.Hour)) {
+		t.Fatalf("Clock = %v, want %v", got.Clock, now.Add(23*time.Hour))
+	}
+	if !got.SimulateActivity {
+		t.Fatal("expected workday template to keep presence on")
+	}
+}
+
+func TestParseClockTargetMaintenance(t *testing.T) {
+	now := time.Date(2025, 1, 2, 18, 0, 0, 0, time.Local)
+	orig := nextMaintenanceWindow
+	t.Cleanup(func() { nextMaintenanceWindow = orig })
+	nextMaintenanceWindow = func(time.Time) (platform.MaintenanceWindow, error) {
+		return platform.MaintenanceWindow{Start: now.Add(9 * time.Hour), Source: "test"}, nil
+	}
+
+	got, err := parseClockTarget(" maintenance ", now)
+	if err != nil {
+		t.Fatalf("parseClockTarget() error = %v", err)
+	}
+	if !got.Equal(now.Add(9 * time.Hour)) {
+		t.Fatalf("parseClockTarget() = %v, want %v", got, now.Add(9*time.Hour))
+	}
+
+	nextMaintenanceWindow = func(time.Time) (platform.MaintenanceWindow, error) {
+		return platform.MaintenanceWindow{}, platform.ErrNoMaintenanceWindow
+	}
+	if _, err := parseClockTarget("maintenance", now); err == nil {
+		t.Fatal("expected an error without a maintenance window")
+	}
+}
+
+func TestWatchTemplateApply(t *testing.T) {
+	tmpl := DefaultTemplates()[3]
+
+	got, err := tmpl.Input.Apply(tmpl, strconv.Itoa(os.Getpid()), time.Now())
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got.Watch != (keepalive.ProcessWatch{PID: os.Getpid()}) {
+		t.Fatalf("Watch = %+v, want own PID", got.Watch)
+	}
+	if got.Duration != 0 {
+		t.Fatalf("Duration = %v, want indefinite", got.Duration)
+	}
+
+	if _, err := tmpl.Input.Apply(tmpl, "no-such-process-keepalive-test", time.Now()); err == nil {
+		t.Fatal("expected an error for a process that is not running")
+	}
+}
+
+func TestProcessWatchQuitsWhenKeeperStops(t *testing.T) {
+	m := InitialModel()
+	m.State = stateRunning
+	m.StartTime = time.Now()
+	m.Watch = keepalive.ProcessWatch{PID: os.Getpid()}
+
+	if !strings.Contains(View(m), "Until PID") {
+		t.Fatalf("running view missing watched process:\n%s", View(m))
+	}
+
+	// The keeper is not running, as after the watched process exited.
+	_, cmd := Update(keeperCheckMsg{session: m.StartTime}, m)
+	if cmd == nil {
+		t.Fatal("expected a quit command once the keeper stopped")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Fatal("expected tea.Quit once the keeper stopped")
+	}
+
+	// Messages from an earlier session are ignored.
+	if _, cmd := Update(keeperCheckMsg{session: m.StartTime.Add(-time.Minute)}, m); cmd != nil {
+		t.Fatal("expected stale watch message to be ignored")
+	}
+}
+
+func TestCapabilitiesInDependencyInfo(t *testing.T) {
+	m := InitialModel()
+	info := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("i")}
+
+	if m, _ = Update(info, m); m.ShowDependencyInfo {
+		t.Fatal("dependency information opened with nothing to show")
+	}
+	caps := platform.Capabilities{SleepInhibition: platform.Capability{Supported: true, Methods: []string{"systemd-inhibit"}}}
+	m, _ = Update(CapabilitiesMsg{Capabilities: caps}, m)
+	m, _ = Update(info, m)
+	if !m.ShowDependencyInfo {
+		t.Fatal("dependency information did not open once capabilities were known")
+	}
+	view := View(m)
+	for _, want := range []string{"systemd-inhibit", "Display inhibition", "unavailable"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestMenuNumberAndJumpKeys(t *testing.T) {
+	m := InitialModel()
+
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("G")}, m)
+	if m.Selected != len(menuItems)-1 {
+		t.Fatalf("Selected after G = %d, want %d", m.Selected, len(menuItems)-1)
+	}
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("g")}, m)
+	if m.Selected != len(menuItems)-1 {
+		t.Fatal("a single g should not move the selection")
+	}
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("g")}, m)
+	if m.Selected != 0 {
+		t.Fatalf("Selected after gg = %d, want 0", m.Selected)
+	}
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyEnd}, m)
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyHome}, m)
+	if m.Selected != 0 {
+		t.Fatalf("Selected after home = %d, want 0", m.Selected)
+	}
+
+	// g followed by another key does not leave a pending jump behind.
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("g")}, m)
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyDown}, m)
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("g")}, m)
+	if m.Selected != 1 {
+		t.Fatalf("Selected = %d, want 1 after g, down, g", m.Selected)
+	}
+
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("9")}, m)
+	if m.State != stateMenu {
+		t.Fatalf("state = %v, want menu for a number without an entry", m.State)
+	}
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("3")}, m)
+	if m.State != stateClockInput || m.Selected != 2 {
+		t.Fatalf("state = %v, Selected = %d, want clock input from 3", m.State, m.Selected)
+	}
+
+	m = InitialModel()
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("4")}, m)
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("3")}, m)
+	if m.State != stateTemplateInput || m.pendingTemplate.Name != "Workday" {
+		t.Fatalf("state = %v, template = %q, want the Workday prompt", m.State, m.pendingTemplate.Name)
+	}
+}
+
+func TestPauseKeyFreezesCountdown(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode")
+	}
+
+	m := InitialModel()
+	defer m.KeepAlive.Stop()
+
+	reply := make(chan error, 1)
+	m, _ = Update(RemoteCommandMsg{Command: RemoteStart, Duration: time.Minute, Reply: reply}, m)
+	if err := <-reply; err != nil {
+		if err.Error() == "unsupported platform" {
+			t.Skip("Skipping on unsupported platform")
+		}
+		t.Fatalf("remote start failed: %v", err)
+	}
+
+	pressP := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}}
+	m, _ = Update(pressP, m)
+	if !m.KeepAlive.Paused() {
+		t.Fatalf("keeper not paused after p: %s", m.ErrorMessage)
+	}
+	if view := View(m); !strings.Contains(view, "Paused") {
+		t.Errorf("running view does not show the pause:\n%s", view)
+	}
+	remaining := m.TimeRemaining()
+	time.Sleep(50 * time.Millisecond)
+	if got := m.TimeRemaining(); got != remaining {
+		t.Errorf("TimeRemaining() while paused = %v, want %v", got, remaining)
+	}
+
+	m, _ = Update(RemoteCommandMsg{Command: RemoteResume, Reply: reply}, m)
+	if err := <-reply; err != nil {
+		t.Fatalf("remote resume failed: %v", err)
+	}
+	if m.KeepAlive.Paused() {
+		t.Fatal("keeper still paused after resume")
+	}
+	if got := m.TimeRemaining(); got > remaining || got < remaining-time.Second {
+		t.Errorf("TimeRemaining() after resume = %v, want about %v", got, remaining)
+	}
+}
+
+// fakeKeepAlive is a platform keep-alive that does nothing.
+type fakeKeepAlive struct{}
+
+func (fakeKeepAlive) Start(context.Context) error       { return nil }
+func (fakeKeepAlive) Stop() error                       { return nil }
+func (fakeKeepAlive) StopNow()                          {}
+func (fakeKeepAlive) SetSimulateActivity(bool)          {}
+func (fakeKeepAlive) SetTimings(platform.Timings)       {}
+func (fakeKeepAlive) SetMouseShape(platform.MouseShape) {}
+
+func TestResumeKeepsClockSessionEnd(t *testing.T) {
+	m := InitialModel()
+	m.KeepAlive = keepalive.New(keepalive.WithPlatform(fakeKeepAlive{}))
+	clock := time.Now().Add(10 * time.Minute).Round(0)
+	m, _ = startSession(m, time.Until(clock), clock)
+	if m.State != stateRunning {
+		t.Fatalf("session not started: %s", m.ErrorMessage)
+	}
+	defer m.KeepAlive.Stop()
+
+	pressP := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}}
+	m, _ = Update(pressP, m)
+	time.Sleep(20 * time.Millisecond)
+	left := time.Until(clock)
+	m, _ = Update(pressP, m)
+	if m.KeepAlive.Paused() {
+		t.Fatalf("keeper still paused after p, p: %s", m.ErrorMessage)
+	}
+	if !m.Clock.Equal(clock) {
+		t.Errorf("Clock after resume = %v, want %v", m.Clock, clock)
+	}
+	if got := m.KeepAlive.EndTime(); !got.Equal(clock) {
+		t.Errorf("keeper EndTime() after resume = %v, want %v", got, clock)
+	}
+	if got := m.timer.Timeout; got > left {
+		t.Errorf("countdown after resume = %v, want at most %v", got, left)
+	}
+}
+
+func TestSimulationNoticeFollowsSession(t *testing.T) {
+	m := InitialModel()
+	m.State = stateRunning
+	m.StartTime = time.Now()
+
+	m, _ = Update(simulationMsg{session: m.StartTime, result: platform.SimulationResult{Method: "uinput", Points: 8, Duration: time.Second}}, m)
+	if want := "moved the pointer with uinput (8 points over 1s)"; !strings.Contains(m.SimulationNotice, want) {
+		t.Errorf("SimulationNotice = %q, want it to contain %q", m.SimulationNotice, want)
+	}
+	if view := View(m); !strings.Contains(view, m.SimulationNotice) {
+		t.Errorf("running view does not show the notice:\n%s", view)
+	}
+
+	m.SimulationNotice = ""
+	m, _ = Update(simulationMsg{session: m.StartTime.Add(-time.Minute), result: platform.SimulationResult{Method: "uinput"}}, m)
+	if m.SimulationNotice != "" {
+		t.Errorf("result from an earlier session shown: %q", m.SimulationNotice)
+	}
+
+	m, _ = Update(simulationMsg{session: m.StartTime, err: errors.New("keep-alive is suspended")}, m)
+	if m.SimulationNotice != "Simulation test failed: keep-alive is suspended" {
+		t.Errorf("SimulationNotice = %q", m.SimulationNotice)
+	}
+}
+
+func TestExtendKeysMoveSessionEnd(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode")
+	}
+
+	m := InitialModel()
+	defer m.KeepAlive.Stop()
+
+	reply := make(chan error, 1)
+	m, _ = Update(RemoteCommandMsg{Command: RemoteStart, Duration: 10 * time.Minute, Reply: reply}, m)
+	if err := <-reply; err != nil {
+		if err.Error() == "unsupported platform" {
+			t.Skip("Skipping on unsupported platform")
+		}
+		t.Fatalf("remote start failed: %v", err)
+	}
+	end := m.KeepAlive.EndTime()
+
+	press := func(r rune) {
+		m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}, m)
+	}
+	press('+')
+	if m.Duration != 15*time.Minute || m.KeepAlive.EndTime().Sub(end) != 5*time.Minute {
+		t.Fatalf("after +: Duration = %v, end moved by %v", m.Duration, m.KeepAlive.EndTime().Sub(end))
+	}
+	press('-')
+	press('-')
+	if m.Duration != 5*time.Minute || m.ErrorMessage != "" {
+		t.Fatalf("after -, -: Duration = %v, error %q", m.Duration, m.ErrorMessage)
+	}
+	if remaining := m.TimeRemaining(); remaining > 5*time.Minute || remaining < 4*time.Minute {
+		t.Errorf("TimeRemaining() = %v, want about 5m", remaining)
+	}
+
+	press('-')
+	if m.ErrorMessage == "" || m.Duration != 5*time.Minute {
+		t.Errorf("shortening past the end: Duration = %v, error %q", m.Duration, m.ErrorMessage)
+	}
+	if !m.KeepAlive.IsRunning() {
+		t.Error("session stopped")
+	}
+}
+
+func TestResumeMenuEntry(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode")
+	}
+
+	m := InitialModel()
+	defer m.KeepAlive.Stop()
+	m.SetResumable(sessionstate.State{End: time.Now().Add(42*time.Minute + 10*time.Second)})
+
+	entries := m.menuEntries()
+	if len(entries) != len(menuItems)+1 || entries[0] != "Resume previous session (42m remaining)" {
+		t.Fatalf("menu entries = %q", entries)
+	}
+	if !strings.Contains(View(m), "1. Resume previous session (42m remaining)") {
+		t.Error("the resume entry is not shown first")
+	}
+
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("1")}, m)
+	if m.ErrorMessage == "System Error • unsupported platform" {
+		t.Skip("Skipping on unsupported platform")
+	}
+	if m.State != stateRunning {
+		t.Fatalf("state = %v, want running (%s)", m.State, m.ErrorMessage)
+	}
+	if m.Duration < 42*time.Minute || m.Duration > 43*time.Minute || m.Resumable != nil {
+		t.Errorf("resumed with Duration = %v, Resumable = %v", m.Duration, m.Resumable)
+	}
+	if len(m.menuEntries()) != len(menuItems) {
+		t.Error("the resume entry is still offered after resuming")
+	}
+}
+
+type fakeAttachment struct {
+	status AttachedStatus
+	err    error
+	sent   []RemoteCommand
+}
+
+func (a *fakeAttachment) Status() (AttachedStatus, error) {
+	return a.status, a.err
+}
+
+func (a *fakeAttachment) Control(cmd RemoteCommand, d time.Duration) error {
+	a.sent = append(a.sent, cmd)
+	return nil
+}
+
+func TestAttachedModel(t *testing.T) {
+	a := &fakeAttachment{status: AttachedStatus{Running: true, SimulateActivity: true, SimulationMethod: "uinput", Version: "1.2.3"}}
+	m := NewAttachedModel(a, "")
+
+	next, _ := m.Update(m.Init()())
+	m = next.(AttachedModel)
+	view := m.View()
+	for _, want := range []string{"attached", "(1.2.3)", "System is being kept awake", "via uinput", "detach"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("attached view missing %q:\n%s", want, view)
+		}
+	}
+
+	press := func(s string) {
+		t.Helper()
+		next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)})
+		m = next.(AttachedModel)
+		if cmd != nil {
+			cmd()
+		}
+	}
+	press("p")
+	press("+")
+	press("a")
+	press("s")
+	want := []RemoteCommand{RemotePause, RemoteExtend, RemoteSimulationOff, RemoteStop}
+	if len(a.sent) != len(want) {
+		t.Fatalf("sent %v, want %v", a.sent, want)
+	}
+	for i := range want {
+		if a.sent[i] != want[i] {
+			t.Errorf("sent[%d] = %v, want %v", i, a.sent[i], want[i])
+		}
+	}
+
+	a.err = errors.New("no running keepalive found")
+	next, _ = m.Update(m.readStatus()())
+	m = next.(AttachedModel)
+	if !strings.Contains(m.View(), "Lost the running keepalive") {
+		t.Errorf("attached view does not report the lost instance:\n%s", m.View())
+	}
+	a.sent = nil
+	press("s")
+	if len(a.sent) != 0 {
+		t.Errorf("controls sent after losing the instance: %v", a.sent)
+	}
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if _, ok := cmd().(tea.QuitMsg); !ok || next == nil {
+		t.Error("q does not detach")
+	}
+}
+
+func TestConsentDialog(t *testing.T) {
+	origGrant, origTrusted := grantConsent, accessibilityTrusted
+	t.Cleanup(func() { grantConsent, accessibilityTrusted = origGrant, origTrusted })
+	accessibilityTrusted = func() bool { return true }
+	grants := 0
+	grantConsent = func(level privacy.Level) (privacy.Consent, error) {
+		grants++
+		return privacy.Consent{Level: level, GrantedAt: time.Now(), Via: "tui"}, nil
+	}
+
+	m := InitialModel()
+	m.KeepAlive.SetConsent(privacy.Consent{Level: privacy.InhibitOnly})
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")}, m)
+	if m.SimulateActivity || !m.ShowConsent {
+		t.Fatalf("enabling simulation without consent: SimulateActivity = %v, ShowConsent = %v", m.SimulateActivity, m.ShowConsent)
+	}
+	if view := View(m); !strings.Contains(view, "synthetic-input") {
+		t.Errorf("dialog does not name the privacy level:\n%s", view)
+	}
+
+	// Any key but 'y' leaves simulation off and records nothing.
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyEsc}, m)
+	if m.ShowConsent || m.SimulateActivity || grants != 0 || m.State != stateMenu {
+		t.Errorf("after Esc: ShowConsent = %v, SimulateActivity = %v, grants = %d, state = %v", m.ShowConsent, m.SimulateActivity, grants, m.State)
+	}
+
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")}, m)
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")}, m)
+	if m.ShowConsent || !m.SimulateActivity || grants != 1 {
+		t.Fatalf("after 'y': ShowConsent = %v, SimulateActivity = %v, grants = %d", m.ShowConsent, m.SimulateActivity, grants)
+	}
+
+	// Once consented, simulation is toggled without asking again.
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")}, m)
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")}, m)
+	if m.ShowConsent || !m.SimulateActivity || grants != 1 {
+		t.Errorf("toggling after consent: ShowConsent = %v, SimulateActivity = %v, grants = %d", m.ShowConsent, m.SimulateActivity, grants)
+	}
+}
+
+func TestAccessibilityDialog(t *testing.T) {
+	origTrusted, origOpen := accessibilityTrusted, openAccessibilitySettings
+	t.Cleanup(func() { accessibilityTrusted, openAccessibilitySettings = origTrusted, origOpen })
+	accessibilityTrusted = func() bool { return false }
+	opened := 0
+	openAccessibilitySettings = func() error {
+		opened++
+		return nil
+	}
+
+	m := InitialModel()
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")}, m)
+	if !m.SimulateActivity || !m.ShowAccessibility {
+		t.Fatalf("enabling simulation: SimulateActivity = %v, ShowAccessibility = %v, want both true", m.SimulateActivity, m.ShowAccessibility)
+	}
+	if view := View(m); !strings.Contains(view, "granted Accessibility access") {
+		t.Errorf("dialog does not explain the grant:\n%s", view)
+	}
+
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("o")}, m)
+	if m.ShowAccessibility || opened != 1 {
+		t.Errorf("after 'o': ShowAccessibility = %v, opened = %d, want false and 1", m.ShowAccessibility, opened)
+	}
+
+	// Turning simulation off and on again shows the dialog again; Esc
+	// closes it without opening anything.
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")}, m)
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")}, m)
+	if !m.ShowAccessibility {
+		t.Fatal("dialog not shown when simulation was enabled again")
+	}
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyEsc}, m)
+	if m.ShowAccessibility || opened != 1 || m.State != stateMenu {
+		t.Errorf("after Esc: ShowAccessibility = %v, opened = %d, state = %v", m.ShowAccessibility, opened, m.State)
+	}
+
+	accessibilityTrusted = func() bool { return true }
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")}, m)
+	m, _ = Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")}, m)
+	if m.ShowAccessibility {
+		t.Error("dialog shown although Accessibility is granted")
+	}
+}
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -10,7 +11,9 @@ import (
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/timer"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stigoleg/keep-alive/internal/keepalive"
 	"github.com/stigoleg/keep-alive/internal/platform"
+	"github.com/stigoleg/keep-alive/internal/privacy"
 	"github.com/stigoleg/keep-alive/internal/util"
 )
 
@@ -21,6 +24,22 @@ type batteryStatusMsg struct {
 
 var readBatteryStatus = platform.GetBatteryStatus
 
+// accessibilityTrusted and openAccessibilitySettings are replaced in tests.
+var (
+	accessibilityTrusted      = platform.AccessibilityTrusted
+	openAccessibilitySettings = platform.OpenAccessibilitySettings
+)
+
+// grantConsent records that the user enabled level in the consent dialog;
+// it is replaced in tests.
+var grantConsent = func(level privacy.Level) (privacy.Consent, error) {
+	path, err := privacy.DefaultPath()
+	if err != nil {
+		return privacy.Consent{}, err
+	}
+	return privacy.Grant(path, level, "tui", time.Now())
+}
+
 func batteryPollCmd() tea.Cmd {
 	return tea.Tick(batteryPollInterval, func(time.Time) tea.Msg {
 		status, err := readBatteryStatus()
@@ -28,6 +47,46 @@ func batteryPollCmd() tea.Cmd {
 	})
 }
 
+// powerSourceRefreshMsg re-renders the running view so AC-only and schedule
+// suspension changes show up. session identifies the session that scheduled it.
+type powerSourceRefreshMsg struct {
+	session time.Time
+}
+
+func powerSourceRefreshCmd(session time.Time) tea.Cmd {
+	return tea.Tick(powerSourceRefreshInterval, func(time.Time) tea.Msg {
+		return powerSourceRefreshMsg{session: session}
+	})
+}
+
+// keeperCheckMsg checks whether the keeper ended the session on its own.
+// session identifies the session that scheduled it.
+type keeperCheckMsg struct {
+	session time.Time
+}
+
+func keeperCheckCmd(session time.Time) tea.Cmd {
+	return tea.Tick(keeperCheckInterval, func(time.Time) tea.Msg {
+		return keeperCheckMsg{session: session}
+	})
+}
+
+// simulationMsg carries the result of a simulation test fire. session
+// identifies the session that requested it.
+type simulationMsg struct {
+	session time.Time
+	result  platform.SimulationResult
+	err     error
+}
+
+func simulateOnceCmd(m Model) tea.Cmd {
+	keeper, session := m.KeepAlive, m.StartTime
+	return func() tea.Msg {
+		res, err := keeper.SimulateOnce()
+		return simulationMsg{session: session, result: res, err: err}
+	}
+}
+
 func runningCommands(m Model) tea.Cmd {
 	var cmds []tea.Cmd
 	if m.Duration > 0 {
@@ -36,6 +95,12 @@ func runningCommands(m Model) tea.Cmd {
 	if m.BatteryThreshold > 0 {
 		cmds = append(cmds, batteryPollCmd())
 	}
+	if m.maySuspend() {
+		cmds = append(cmds, powerSourceRefreshCmd(m.StartTime))
+	}
+	if m.keeperMayStop() {
+		cmds = append(cmds, keeperCheckCmd(m.StartTime))
+	}
 	return tea.Batch(cmds...)
 }
 
@@ -48,11 +113,38 @@ func Update(msg tea.Msg, m Model) (Model, tea.Cmd) {
 		m = syncHelpViewport(m)
 		return m, nil
 	}
+	if remoteMsg, ok := msg.(RemoteCommandMsg); ok {
+		return handleRemoteCommand(remoteMsg, m)
+	}
+	if updateMsg, ok := msg.(UpdateAvailableMsg); ok {
+		m.UpdateVersion = updateMsg.Version
+		return m, nil
+	}
+	if capsMsg, ok := msg.(CapabilitiesMsg); ok {
+		m.Capabilities = &capsMsg.Capabilities
+		return m, nil
+	}
 
+	if m.ShowConsent {
+		// Still process timer messages so progress and timeout continue under the dialog
+		switch msg.(type) {
+		case timer.TickMsg, timer.TimeoutMsg, batteryStatusMsg, powerSourceRefreshMsg, keeperCheckMsg, simulationMsg:
+			return handleRunningState(msg, m)
+		}
+		return handleConsentState(msg, m)
+	}
+	if m.ShowAccessibility {
+		// Still process timer messages so progress and timeout continue under the dialog
+		switch msg.(type) {
+		case timer.TickMsg, timer.TimeoutMsg, batteryStatusMsg, powerSourceRefreshMsg, keeperCheckMsg, simulationMsg:
+			return handleRunningState(msg, m)
+		}
+		return handleAccessibilityState(msg, m)
+	}
 	if m.ShowDependencyInfo {
 		// Still process timer messages so progress and timeout continue under the overlay
 		switch msg.(type) {
-		case timer.TickMsg, timer.TimeoutMsg, batteryStatusMsg:
+		case timer.TickMsg, timer.TimeoutMsg, batteryStatusMsg, powerSourceRefreshMsg, keeperCheckMsg, simulationMsg:
 			return handleRunningState(msg, m)
 		}
 		return handleDependencyInfoState(msg, m)
@@ -60,7 +152,7 @@ func Update(msg tea.Msg, m Model) (Model, tea.Cmd) {
 	if m.ShowHelp {
 		// Still process timer messages so progress and timeout continue under the overlay
 		switch msg.(type) {
-		case timer.TickMsg, timer.TimeoutMsg, batteryStatusMsg:
+		case timer.TickMsg, timer.TimeoutMsg, batteryStatusMsg, powerSourceRefreshMsg, keeperCheckMsg, simulationMsg:
 			return handleRunningState(msg, m)
 		}
 		return handleHelpState(msg, m)
@@ -75,6 +167,10 @@ func Update(msg tea.Msg, m Model) (Model, tea.Cmd) {
 		return handleClockInputState(msg, m)
 	case stateBatteryInput:
 		return handleBatteryInputState(msg, m)
+	case stateTemplates:
+		return handleTemplatesState(msg, m)
+	case stateTemplateInput:
+		return handleTemplateInputState(msg, m)
 	case stateRunning:
 		return handleRunningState(msg, m)
 	}
@@ -118,6 +214,55 @@ func handleDependencyInfoState(msg tea.Msg, m Model) (Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleAccessibilityState handles messages while the Accessibility dialog
+// is shown: "o" opens System Settings, and any other key closes it.
+func handleAccessibilityState(msg tea.Msg, m Model) (Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	m.ShowAccessibility = false
+	if keyMsg.String() == "o" {
+		if err := openAccessibilitySettings(); err != nil {
+			m.ErrorMessage = "System Error • " + err.Error()
+		}
+	}
+	return m, nil
+}
+
+// handleConsentState handles the consent dialog: 'y' records the consent
+// and turns activity simulation on, and any other key leaves it off.
+func handleConsentState(msg tea.Msg, m Model) (Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	m.ShowConsent = false
+	if keyMsg.String() != "y" {
+		return m, nil
+	}
+	c, err := grantConsent(privacy.SyntheticInput)
+	if err != nil {
+		m.ErrorMessage = "Consent not recorded • " + err.Error()
+		return m, nil
+	}
+	m.KeepAlive.SetConsent(c)
+	m.SimulateActivity = true
+	if m.State == stateRunning {
+		m.KeepAlive.SetSimulateActivity(true)
+	}
+	m.ActivityWarning = activityWarningFor(true)
+	m.ShowAccessibility = !accessibilityTrusted()
+	return m, nil
+}
+
+// simulationConsented reports whether the user has enabled the privacy
+// level that activity simulation needs.
+func simulationConsented(m Model) bool {
+	c, limited := m.KeepAlive.Consent()
+	return !limited || c.Allows(privacy.SyntheticInput)
+}
+
 // handleMenuState handles messages in the menu state
 func handleMenuState(msg tea.Msg, m Model) (Model, tea.Cmd) {
 	switch msg := msg.(type) {
@@ -129,12 +274,25 @@ func handleMenuState(msg tea.Msg, m Model) (Model, tea.Cmd) {
 
 // handleMenuKeyMsg handles keyboard input in the menu state
 func handleMenuKeyMsg(msg tea.KeyMsg, m Model) (Model, tea.Cmd) {
+	entries := len(m.menuEntries())
+	if selected, ok := m.listJump(msg, m.Selected, entries); ok {
+		m.Selected = selected
+		return m, nil
+	}
+	if i, ok := m.Keys.numberIndex(msg); ok {
+		if i < entries {
+			m.Selected = i
+			return handleMenuSelection(m)
+		}
+		return m, nil
+	}
+
 	switch {
 	case key.Matches(msg, m.Keys.ToggleHelp):
 		m.ShowHelp = true
 		m = syncHelpViewport(m)
 	case key.Matches(msg, m.Keys.ToggleDependencyInfo):
-		if m.DependencyWarning != "" || m.ActivityWarning != "" {
+		if hasInfoWarning(m) || m.Capabilities != nil {
 			m.ShowDependencyInfo = true
 		}
 	case key.Matches(msg, m.Keys.Up):
@@ -142,7 +300,7 @@ func handleMenuKeyMsg(msg tea.KeyMsg, m Model) (Model, tea.Cmd) {
 			m.Selected--
 		}
 	case key.Matches(msg, m.Keys.Down):
-		if m.Selected < 3 {
+		if m.Selected < entries-1 {
 			m.Selected++
 		}
 	case key.Matches(msg, m.Keys.Select):
@@ -153,14 +311,25 @@ func handleMenuKeyMsg(msg tea.KeyMsg, m Model) (Model, tea.Cmd) {
 	case key.Matches(msg, m.Keys.Quit):
 		return handleQuit(m)
 	case msg.String() == "a":
+		if err := m.KeepAlive.Policy().CheckActive(); err != nil && !m.SimulateActivity {
+			m.ErrorMessage = err.Error()
+			return m, nil
+		}
+		if !m.SimulateActivity && !simulationConsented(m) {
+			m.ShowConsent = true
+			return m, nil
+		}
 		m.SimulateActivity = !m.SimulateActivity
 		m.ActivityWarning = activityWarningFor(m.SimulateActivity)
+		m.ShowAccessibility = m.SimulateActivity && !accessibilityTrusted()
 		return m, nil
 	case msg.String() == "b":
 		m.State = stateBatteryInput
 		m.ErrorMessage = ""
 		m.textInput = newBatteryTextInput(m.BatteryThreshold)
 		return m, nil
+	case msg.String() == "t":
+		return openTemplates(m)
 	case msg.String() == "B":
 		m.BatteryThreshold = 0
 		m.BatteryPercentage = 0
@@ -171,9 +340,36 @@ func handleMenuKeyMsg(msg tea.KeyMsg, m Model) (Model, tea.Cmd) {
 	return m, nil
 }
 
+// listJump handles gg/home and G/end in a list of n entries. It returns the
+// new selection and whether the key was consumed.
+func (m *Model) listJump(msg tea.KeyMsg, selected, n int) (int, bool) {
+	pending := m.pendingG
+	m.pendingG = false
+	switch {
+	case key.Matches(msg, m.Keys.Top):
+		return 0, true
+	case key.Matches(msg, m.Keys.Bottom):
+		return n - 1, true
+	case msg.String() == "g":
+		if pending {
+			return 0, true
+		}
+		m.pendingG = true
+		return selected, true
+	}
+	return selected, false
+}
+
 // handleMenuSelection processes the selected menu item
 func handleMenuSelection(m Model) (Model, tea.Cmd) {
-	switch m.Selected {
+	selected := m.Selected
+	if m.Resumable != nil {
+		if selected == 0 {
+			return resumePreviousSession(m)
+		}
+		selected--
+	}
+	switch selected {
 	case 0:
 		return startSession(m, 0, time.Time{})
 	case 1:
@@ -185,11 +381,30 @@ func handleMenuSelection(m Model) (Model, tea.Cmd) {
 		m.ErrorMessage = ""
 		m.textInput = newClockTextInput()
 	case 3:
+		return openTemplates(m)
+	case 4:
 		return handleQuit(m)
 	}
 	return m, nil
 }
 
+// resumePreviousSession starts the session offered for resuming with the
+// time it had left and its activity simulation setting.
+func resumePreviousSession(m Model) (Model, tea.Cmd) {
+	s := *m.Resumable
+	m.Resumable = nil
+	m.Selected = 0
+	left := s.Remaining(time.Now()).Round(time.Second)
+	if !s.End.IsZero() && left <= 0 {
+		m.ErrorMessage = "The previous session has already ended"
+		return m, nil
+	}
+	if s.SimulateActivity && m.KeepAlive.Policy().CheckActive() == nil && simulationConsented(m) {
+		m.SimulateActivity = true
+	}
+	return startSession(m, left, time.Time{})
+}
+
 // handleClockInputState handles messages in the clock input state
 func handleClockInputState(msg tea.Msg, m Model) (Model, tea.Cmd) {
 	switch msg := msg.(type) {
@@ -233,20 +448,37 @@ func handleClockInputSubmit(m Model) (Model, tea.Cmd) {
 	}
 
 	now := time.Now()
-	target, err := util.ParseTimeStringWithNow(value, now)
+	target, err := parseClockTarget(value, now)
 	if err != nil {
 		m.ErrorMessage = err.Error()
 		return m, nil
 	}
-	if target.Before(now) {
-		target = target.Add(24 * time.Hour)
+
+	return startSession(m, target.Sub(now), target)
+}
+
+// nextMaintenanceWindow is replaced in tests.
+var nextMaintenanceWindow = platform.NextMaintenanceWindow
+
+// parseClockTarget resolves a clock time to its next occurrence after now,
+// and "maintenance" to the next scheduled maintenance window.
+func parseClockTarget(value string, now time.Time) (time.Time, error) {
+	var target time.Time
+	var err error
+	if strings.EqualFold(strings.TrimSpace(value), platform.MaintenanceTarget) {
+		var w platform.MaintenanceWindow
+		w, err = nextMaintenanceWindow(now)
+		target = w.Start
+	} else {
+		target, err = util.ParseTimeStringWithNow(value, now)
+	}
+	if err != nil {
+		return time.Time{}, err
 	}
 	if !target.After(now) {
-		m.ErrorMessage = "Invalid Clock • Please enter a future time"
-		return m, nil
+		return time.Time{}, errors.New("Invalid Clock • Please enter a future time")
 	}
-
-	return startSession(m, target.Sub(now), target)
+	return target, nil
 }
 
 // handleBatteryInputState handles messages in the battery input state
@@ -384,8 +616,12 @@ func startSession(m Model, dur time.Duration, clock time.Time) (Model, tea.Cmd)
 	m.KeepAlive.SetSimulateActivity(m.SimulateActivity)
 
 	var err error
-	if dur > 0 {
+	if !clock.IsZero() {
+		err = m.KeepAlive.StartUntil(clock)
+	} else if dur > 0 {
 		err = m.KeepAlive.StartTimed(dur)
+	} else if m.WhileCmd != "" {
+		err = m.KeepAlive.StartWhile(keepalive.CommandCondition(m.WhileCmd), keepalive.CommandPollInterval)
 	} else {
 		err = m.KeepAlive.StartIndefinite()
 	}
@@ -395,9 +631,13 @@ func startSession(m Model, dur time.Duration, clock time.Time) (Model, tea.Cmd)
 	}
 
 	m.State = stateRunning
+	m.Resumable = nil
 	m.StartTime = time.Now()
 	m.Duration = dur
 	m.Clock = clock
+	m.pausedAt = time.Time{}
+	m.pausedFor = 0
+	m.SimulationNotice = ""
 	m.ErrorMessage = ""
 	if dur > 0 {
 		m.timer = timer.NewWithInterval(dur, time.Second/10)
@@ -431,10 +671,7 @@ func handleRunningState(msg tea.Msg, m Model) (Model, tea.Cmd) {
 			cmds = append(cmds, tcmd)
 		}
 		if m.Duration > 0 {
-			remaining := time.Until(m.StartTime.Add(m.Duration))
-			if remaining < 0 {
-				remaining = 0
-			}
+			remaining := m.TimeRemaining()
 			percent := 1 - (float64(remaining) / float64(m.Duration))
 			if percent < 0 {
 				percent = 0
@@ -446,9 +683,40 @@ func handleRunningState(msg tea.Msg, m Model) (Model, tea.Cmd) {
 		}
 		return m, tea.Batch(cmds...)
 	case timer.TimeoutMsg:
+		if !m.Clock.IsZero() {
+			// The countdown drifts from the wall clock when the clock is
+			// changed; count down again to the keeper's end.
+			if left := m.KeepAlive.TimeRemaining(); left >= time.Second {
+				m.timer = timer.NewWithInterval(left, time.Second/10)
+				return m, m.timer.Init()
+			}
+		}
+		if err := m.KeepAlive.Expire(); err != nil {
+			m.ErrorMessage = err.Error()
+			return m, nil
+		}
 		return handleQuit(m)
 	case batteryStatusMsg:
 		return handleBatteryStatusMsg(msg, m)
+	case powerSourceRefreshMsg:
+		if m.State != stateRunning || !m.maySuspend() || !msg.session.Equal(m.StartTime) {
+			return m, nil
+		}
+		return m, powerSourceRefreshCmd(m.StartTime)
+	case keeperCheckMsg:
+		if m.State != stateRunning || !m.keeperMayStop() || !msg.session.Equal(m.StartTime) {
+			return m, nil
+		}
+		if !m.KeepAlive.IsRunning() {
+			return handleQuit(m)
+		}
+		return m, keeperCheckCmd(m.StartTime)
+	case simulationMsg:
+		if m.State != stateRunning || !msg.session.Equal(m.StartTime) {
+			return m, nil
+		}
+		m.SimulationNotice = simulationNotice(msg)
+		return m, nil
 	}
 	if len(cmds) > 0 {
 		return m, tea.Batch(cmds...)
@@ -485,15 +753,110 @@ func handleRunningKeyMsg(msg tea.KeyMsg, m Model) (Model, tea.Cmd) {
 		m.ShowHelp = true
 		m = syncHelpViewport(m)
 	case key.Matches(msg, m.Keys.ToggleDependencyInfo):
-		if m.DependencyWarning != "" || m.ActivityWarning != "" {
-			m.ShowDependencyInfo = true
-		}
+		// The session's counters are always there to show.
+		m.ShowDependencyInfo = true
 	case key.Matches(msg, m.Keys.Stop):
 		return handleStopAndReturn(m)
+	case key.Matches(msg, m.Keys.Pause):
+		return pauseKeyPressed(m)
+	case key.Matches(msg, m.Keys.Extend):
+		return extendKeyPressed(m, extendStep)
+	case key.Matches(msg, m.Keys.Shorten):
+		return extendKeyPressed(m, -extendStep)
+	case key.Matches(msg, m.Keys.SimulateOnce):
+		m.SimulationNotice = "Testing activity simulation..."
+		return m, simulateOnceCmd(m)
 	}
 	return m, nil
 }
 
+// simulationNotice describes a simulation test fire in one line.
+func simulationNotice(msg simulationMsg) string {
+	res := msg.result
+	switch {
+	case msg.err != nil:
+		return "Simulation test failed: " + msg.err.Error()
+	case res.Err != nil:
+		return "Simulation test failed: " + res.Err.Error()
+	}
+	return fmt.Sprintf("Simulation test: moved the pointer with %s (%d points over %s)",
+		res.Method, res.Points, res.Duration.Round(time.Millisecond))
+}
+
+// extendKeyPressed moves the end of the running session by d, showing any
+// error instead of failing.
+func extendKeyPressed(m Model, d time.Duration) (Model, tea.Cmd) {
+	m, err := extendSession(m, d)
+	if err != nil {
+		m.ErrorMessage = err.Error()
+		return m, nil
+	}
+	m.ErrorMessage = ""
+	return m, nil
+}
+
+// extendSession moves the end of the running timed session by d, which may
+// be negative, in the keeper and in the countdown alike.
+func extendSession(m Model, d time.Duration) (Model, error) {
+	if err := m.KeepAlive.Extend(d); err != nil {
+		return m, err
+	}
+	m.Duration += d
+	m.timer.Timeout += d
+	if !m.Clock.IsZero() {
+		m.Clock = m.Clock.Add(d)
+	}
+	return m, nil
+}
+
+// pauseKeyPressed pauses the running session, or resumes it if it is
+// paused, showing any error instead of failing.
+func pauseKeyPressed(m Model) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var err error
+	if m.KeepAlive.Paused() {
+		m, cmd, err = resumeSession(m)
+	} else {
+		m, cmd, err = pauseSession(m)
+	}
+	if err != nil {
+		m.ErrorMessage = err.Error()
+		return m, nil
+	}
+	m.ErrorMessage = ""
+	return m, cmd
+}
+
+// pauseSession pauses the running session and freezes its countdown.
+func pauseSession(m Model) (Model, tea.Cmd, error) {
+	if err := m.KeepAlive.Pause(); err != nil {
+		return m, nil, err
+	}
+	m.pausedAt = time.Now()
+	if m.Duration > 0 {
+		return m, m.timer.Stop(), nil
+	}
+	return m, nil, nil
+}
+
+// resumeSession resumes a paused session. A clock session keeps its end, as
+// the keeper's does, so its countdown starts again from the time left.
+func resumeSession(m Model) (Model, tea.Cmd, error) {
+	if err := m.KeepAlive.Resume(); err != nil {
+		return m, nil, err
+	}
+	m.pausedFor += time.Since(m.pausedAt)
+	m.pausedAt = time.Time{}
+	if !m.Clock.IsZero() {
+		m.timer = timer.NewWithInterval(m.KeepAlive.TimeRemaining(), time.Second/10)
+		return m, m.timer.Init(), nil
+	}
+	if m.Duration > 0 {
+		return m, m.timer.Start(), nil
+	}
+	return m, nil, nil
+}
+
 func activityWarningFor(enabled bool) string {
 	if !enabled {
 		return ""
@@ -516,6 +879,8 @@ func cleanup(m Model) (Model, error) {
 	m.Duration = 0
 	m.Clock = time.Time{}
 	m.StartTime = time.Time{}
+	m.pausedAt = time.Time{}
+	m.pausedFor = 0
 	m.ErrorMessage = ""
 	m.BatteryThreshold = 0
 	m.BatteryPercentage = 0
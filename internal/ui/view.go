@@ -9,6 +9,12 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
 	"github.com/charmbracelet/x/ansi"
+
+	"github.com/stigoleg/keep-alive/internal/keepalive"
+	"github.com/stigoleg/keep-alive/internal/platform"
+	"github.com/stigoleg/keep-alive/internal/sessionstate"
+	"github.com/stigoleg/keep-alive/internal/util"
+	"github.com/stigoleg/keep-alive/pkg/ui/countdown"
 )
 
 const (
@@ -21,6 +27,12 @@ const (
 
 // View renders the current state of the model to a string.
 func View(m Model) string {
+	if m.ShowConsent {
+		return consentView(m)
+	}
+	if m.ShowAccessibility {
+		return accessibilityView(m)
+	}
 	if m.ShowDependencyInfo {
 		return dependencyInfoView(m)
 	}
@@ -37,6 +49,10 @@ func View(m Model) string {
 		return clockInputView(m)
 	case stateBatteryInput:
 		return batteryInputView(m)
+	case stateTemplates:
+		return templatesView(m)
+	case stateTemplateInput:
+		return templateInputView(m)
 	case stateRunning:
 		return runningView(m)
 	}
@@ -54,12 +70,49 @@ func baseView(m Model) string {
 		return clockInputView(m)
 	case stateBatteryInput:
 		return batteryInputView(m)
+	case stateTemplates:
+		return templatesView(m)
+	case stateTemplateInput:
+		return templateInputView(m)
 	case stateRunning:
 		return runningView(m)
 	}
 	return ""
 }
 
+// menuItems are the main menu entries, in selection order.
+var menuItems = []string{
+	"Keep system awake indefinitely",
+	"Keep system awake for X minutes",
+	"Keep system awake until clock time",
+	"Start from a template",
+	"Quit keep-alive",
+}
+
+// menuEntries returns the menu entries, led by the session to resume if
+// there is one.
+func (m Model) menuEntries() []string {
+	if m.Resumable == nil {
+		return menuItems
+	}
+	return append([]string{resumeLabel(*m.Resumable, time.Now())}, menuItems...)
+}
+
+// resumeLabel describes the menu entry resuming s, such as "Resume previous
+// session (42m remaining)".
+func resumeLabel(s sessionstate.State, now time.Time) string {
+	if s.End.IsZero() {
+		return "Resume previous session (indefinite)"
+	}
+	left := s.Remaining(now)
+	if left >= time.Minute {
+		left = left.Round(time.Minute)
+	} else {
+		left = left.Round(time.Second)
+	}
+	return fmt.Sprintf("Resume previous session (%s remaining)", util.FormatDuration(left))
+}
+
 func ErrorBanner(message string) string {
 	return "\n" + Current.Error.Render(strings.TrimSpace(message)) + "\n"
 }
@@ -73,14 +126,7 @@ func menuView(m Model) string {
 	b.WriteString(Current.Unselected.Render("Select an option:"))
 	b.WriteString("\n\n")
 
-	menuItems := []string{
-		"Keep system awake indefinitely",
-		"Keep system awake for X minutes",
-		"Keep system awake until clock time",
-		"Quit keep-alive",
-	}
-
-	for i, opt := range menuItems {
+	for i, opt := range m.menuEntries() {
 		var menuLine strings.Builder
 
 		if i == m.Selected {
@@ -89,6 +135,7 @@ func menuView(m Model) string {
 			menuLine.WriteString(Current.Unselected.Render("  "))
 		}
 
+		opt = fmt.Sprintf("%d. %s", i+1, opt)
 		if i == m.Selected {
 			menuLine.WriteString(Current.Selected.Render(opt))
 		} else {
@@ -127,6 +174,9 @@ func menuView(m Model) string {
 		b.WriteString(Current.Error.Render(warningText))
 		b.WriteString("\n")
 	}
+	if m.UpdateVersion != "" {
+		b.WriteString("\n" + updateNotice(m) + "\n")
+	}
 
 	if m.ErrorMessage != "" {
 		b.WriteString("\n" + Current.Error.Render(m.ErrorMessage))
@@ -153,7 +203,11 @@ func timedInputView(m Model) string {
 		inputView = " "
 	}
 	b.WriteString(Current.InputBox.Render(inputView))
-	b.WriteString("\n\n")
+	b.WriteString("\n")
+	if preview := durationPreview(m.textInput.Value(), time.Now()); preview != "" {
+		b.WriteString(Current.Unselected.Render(preview))
+	}
+	b.WriteString("\n")
 
 	if m.ErrorMessage != "" {
 		b.WriteString("\n\n" + Current.Error.Render(m.ErrorMessage))
@@ -166,6 +220,23 @@ func timedInputView(m Model) string {
 	return b.String()
 }
 
+// durationPreview describes the duration being typed and when a session of
+// that length started at now would end, so that a mistake such as "230" read
+// as minutes shows before the session starts. It is "" while value does not
+// parse.
+func durationPreview(value string, now time.Time) string {
+	d, err := util.ParseDuration(strings.TrimSpace(value))
+	if err != nil || d <= 0 {
+		return ""
+	}
+	end := now.Add(d)
+	layout := "15:04"
+	if y, m, day := end.Date(); y != now.Year() || m != now.Month() || day != now.Day() {
+		layout = "Mon 15:04"
+	}
+	return fmt.Sprintf("%s, ends at %s", util.FormatDuration(d), end.Format(layout))
+}
+
 func clockInputView(m Model) string {
 	var b strings.Builder
 
@@ -222,22 +293,144 @@ func batteryInputView(m Model) string {
 	return b.String()
 }
 
+func templatesView(m Model) string {
+	var b strings.Builder
+
+	b.WriteString(Current.Title.Render("Session Templates"))
+	b.WriteString("\n\n")
+
+	b.WriteString(Current.Unselected.Render("Select a template:"))
+	b.WriteString("\n\n")
+
+	for i, t := range m.templates() {
+		line := fmt.Sprintf("%d. %s — %s", i+1, t.Name, t.Description)
+		if i == m.templateSelected {
+			b.WriteString(Current.Selected.Render("> " + line))
+		} else {
+			b.WriteString(Current.Unselected.Render("  " + line))
+		}
+		b.WriteString("\n")
+	}
+
+	if m.ErrorMessage != "" {
+		b.WriteString("\n" + Current.Error.Render(m.ErrorMessage))
+	}
+
+	footer := m.Help.View(m.Keys.ForState(stateTemplates))
+	b.WriteString("\n\n" + footer)
+	return b.String()
+}
+
+func templateInputView(m Model) string {
+	var b strings.Builder
+
+	b.WriteString(Current.Title.Render(m.pendingTemplate.Name))
+	b.WriteString("\n\n")
+
+	if m.pendingTemplate.Input != nil {
+		b.WriteString(Current.Unselected.Render(m.pendingTemplate.Input.Prompt))
+		b.WriteString("\n")
+	}
+
+	inputView := m.textInput.View()
+	if strings.TrimSpace(inputView) == "" {
+		inputView = " "
+	}
+	b.WriteString(Current.InputBox.Render(inputView))
+	b.WriteString("\n\n")
+
+	if m.ErrorMessage != "" {
+		b.WriteString("\n\n" + Current.Error.Render(m.ErrorMessage))
+	}
+
+	footer := m.Help.View(m.Keys.ForState(stateTemplateInput))
+	b.WriteString("\n" + footer)
+
+	return b.String()
+}
+
 func runningView(m Model) string {
 	var b strings.Builder
 
 	b.WriteString(Current.Title.Render("Keep Alive Active"))
 	b.WriteString("\n\n")
 
-	b.WriteString(Current.Awake.Render("System is being kept awake"))
+	switch {
+	case m.KeepAlive.Paused():
+		b.WriteString(Current.Error.Render("Paused"))
+		b.WriteString("\n")
+		b.WriteString(Current.Unselected.Render("The system may sleep. Press 'p' to resume"))
+	case m.Schedule != nil && m.KeepAlive.OffSchedule():
+		b.WriteString(Current.Error.Render("Outside scheduled hours"))
+		b.WriteString("\n")
+		next := m.Schedule.NextStart(time.Now())
+		b.WriteString(Current.Unselected.Render("Next activation: " + next.Format("Mon 15:04")))
+	case m.ACOnly && m.KeepAlive.Suspended():
+		b.WriteString(Current.Error.Render("Paused while on battery power"))
+		b.WriteString("\n")
+		b.WriteString(Current.Unselected.Render("Resumes when AC power returns"))
+	default:
+		b.WriteString(Current.Awake.Render("System is being kept awake"))
+		if m.ACOnly {
+			b.WriteString("\n")
+			b.WriteString(Current.Unselected.Render("AC power only"))
+		}
+		if m.Schedule != nil {
+			if end := m.Schedule.End(time.Now()); !end.IsZero() {
+				b.WriteString("\n")
+				b.WriteString(Current.Unselected.Render("Scheduled until " + end.Format("Mon 15:04")))
+			}
+		}
+	}
 	b.WriteString("\n")
+	if m.Reason != "" {
+		b.WriteString(Current.Unselected.Render("Working on: " + m.Reason))
+		b.WriteString("\n")
+	}
 	if m.SimulateActivity {
-		if m.ActivityWarning != "" {
+		budget := m.KeepAlive.SimulationBudget()
+		used, limit := budget.Used()
+		switch {
+		case m.ActivityWarning != "":
 			b.WriteString(Current.Error.Render("Activity simulation unavailable"))
-		} else {
-			b.WriteString(Current.Unselected.Render("Activity simulation enabled"))
+		case budget.Exhausted():
+			b.WriteString(Current.Error.Render(fmt.Sprintf("Activity simulation stopped after %d moves", limit)))
+		case m.KeepAlive.Quiet():
+			label := "Activity simulation off for quiet hours"
+			if end := m.KeepAlive.QuietHours().End(time.Now()); !end.IsZero() {
+				label += " until " + end.Format("Mon 15:04")
+			}
+			b.WriteString(Current.Unselected.Render(label))
+		case limit > 0:
+			b.WriteString(Current.Unselected.Render(fmt.Sprintf("Activity simulation enabled%s (%d/%d)", simulationVia(m), used, limit)))
+		default:
+			b.WriteString(Current.Unselected.Render("Activity simulation enabled" + simulationVia(m)))
 		}
 		b.WriteString("\n")
 	}
+	if a, ok := m.KeepAlive.LastSleepAttempt(); ok {
+		outcome := "allowed"
+		if a.Blocked {
+			outcome = "blocked"
+		}
+		b.WriteString(Current.Unselected.Render(fmt.Sprintf("System attempted to sleep at %s, %s", a.Time.Local().Format("15:04"), outcome)))
+		b.WriteString("\n")
+	}
+	b.WriteString(Current.Unselected.Render(privacyLine(m)))
+	b.WriteString("\n")
+
+	if !m.Watch.IsZero() {
+		b.WriteString(Current.Unselected.Render(fmt.Sprintf("Until %s exits", m.Watch)))
+		b.WriteString("\n")
+	}
+	if m.WhileCmd != "" {
+		b.WriteString(Current.Unselected.Render(fmt.Sprintf("While %q succeeds", m.WhileCmd)))
+		b.WriteString("\n")
+	}
+	if m.UntilIdle > 0 {
+		b.WriteString(Current.Unselected.Render(fmt.Sprintf("Until idle for %s", util.FormatDuration(m.UntilIdle))))
+		b.WriteString("\n")
+	}
 
 	if m.BatteryThreshold > 0 {
 		b.WriteString(Current.Unselected.Render(fmt.Sprintf("Battery: %d%%", m.BatteryPercentage)))
@@ -252,14 +445,7 @@ func runningView(m Model) string {
 
 	// Show countdown and progress bar if this is a timed session
 	if m.Duration > time.Duration(0) {
-		remaining := m.TimeRemaining()
-		minutes := int(remaining.Minutes())
-		seconds := int(remaining.Seconds()) % 60
-		countdown := fmt.Sprintf("%d:%02d remaining", minutes, seconds)
-		if !m.Clock.IsZero() {
-			countdown = fmt.Sprintf("%s remaining (until %s)", countdown, m.Clock.Format("15:04"))
-		}
-		b.WriteString(Current.Unselected.Render(countdown))
+		b.WriteString(Current.Unselected.Render(countdown.Label(m.TimeRemaining(), m.Clock)))
 		b.WriteString("\n\n")
 
 		// Render bubbles progress component (percent maintained in update)
@@ -267,6 +453,14 @@ func runningView(m Model) string {
 		b.WriteString("\n")
 	}
 
+	if m.SimulationNotice != "" {
+		b.WriteString("\n" + Current.Unselected.Render(m.SimulationNotice) + "\n")
+	}
+
+	if m.UpdateVersion != "" {
+		b.WriteString("\n" + updateNotice(m) + "\n")
+	}
+
 	footer := m.Help.View(m.Keys.ForState(stateRunning))
 	b.WriteString("\n" + footer)
 
@@ -277,6 +471,25 @@ func runningView(m Model) string {
 	return b.String()
 }
 
+// privacyLine shows the session's privacy level and when the levels that
+// need consent were enabled.
+func privacyLine(m Model) string {
+	line := "Privacy level: " + m.KeepAlive.PrivacyLevel().String()
+	if c, limited := m.KeepAlive.Consent(); limited && !c.GrantedAt.IsZero() && m.KeepAlive.PrivacyLevel().NeedsConsent() {
+		line += fmt.Sprintf(" (enabled %s)", c.GrantedAt.Local().Format("2006-01-02 15:04"))
+	}
+	return line
+}
+
+// simulationVia names the input method that last moved the pointer, such as
+// " via uinput", or is empty before the first move.
+func simulationVia(m Model) string {
+	if method := m.KeepAlive.SimulationMethod(); method != "" {
+		return " via " + method
+	}
+	return ""
+}
+
 // Help overlay with version and CLI usage
 func helpView(m Model) string {
 	if m.Height <= 0 {
@@ -535,10 +748,44 @@ func wrapHelpLine(value string, width int) string {
 func flagHelpRows() [][]string {
 	return [][]string{
 		{"-d, --duration string", `Duration to keep system alive (e.g., "2h30m" or "150")`},
-		{"-c, --clock string", `Time to keep system alive until (e.g., "22:00" or "10:00PM")`},
+		{"-c, --clock string", `Time to keep system alive until (e.g., "22:00", "maintenance")`},
 		{"-b, --battery int", "Keep system awake until battery reaches this percentage"},
+		{"    --battery-min int", "Alias for --battery"},
 		{"-a, --active", "Simulate activity when a real input backend is available"},
-		{"-l, --log", "Enable logging to debug.log"},
+		{"    --ac-only", "Pause while on battery power and resume on AC"},
+		{"    --schedule spec", `Only keep awake within weekly hours ("Mon-Fri 09:00-17:30")`},
+		{"    --quiet-hours spec", `Pause activity simulation within weekly hours ("Mon-Fri 14:00-15:00")`},
+		{"    --dim percent", "Dim the display to this brightness while active"},
+		{"    --idle-threshold dur", "Idle time before simulating activity (default 2m)"},
+		{"    --sim-interval dur", "Minimum time between simulated activity (default 30s)"},
+		{"    --activity-interval dur", "Interval between system activity assertions (default 10s)"},
+		{"    --pattern name", "Jitter shape: circle, square, zigzag, walk, random"},
+		{"    --pattern-size px", "Maximum jitter distance in pixels (5-200)"},
+		{"    --max-idle-simulations n", "Stop simulating after n mouse moves per session"},
+		{"    --ignore-conflicts", "Simulate even while another jiggler runs"},
+		{"    --presence-only", "Simulate activity without preventing sleep"},
+		{"    --watch-pid pid", "Keep awake until the process with this PID exits"},
+		{"    --watch-name name", "Keep awake while a process with this name runs"},
+		{"    --until-idle-for dur", "Stop once you have been idle this long"},
+		{"    --stop-on-lock", "Stop once the screen is locked"},
+		{"    --while-cmd cmd", "Keep awake while a shell command succeeds"},
+		{"    --on-expire cmd", "Run a shell command when a timed session ends"},
+		{"    --notify", "Show a desktop notification when a timed session ends"},
+		{"    --away-mode", "Windows: display off, system awake (away mode)"},
+		{"    --allow-hibernate", "Linux: hold off sleep, let the system hibernate"},
+		{"    --assertions list", "macOS: power assertions to hold (display, idle, ...)"},
+		{"    --display-only", "macOS: keep only the display awake"},
+		{"    --tag key=value", "Label the session in the history (repeatable)"},
+		{"    --from-task id", "Start a session for a taskwarrior task"},
+		{"    --task-command cmd", "Command printing the task; {id} is its ID"},
+		{"-l, --log", "Enable logging to the log file"},
+		{"    --log-file path", "Write the log to this file"},
+		{"    --log-level level", "Minimum log level: debug, info, warn, error"},
+		{"    --verbose", "Log debug messages (same as --log-level debug)"},
+		{"    --dry-run", "Show what would be used without activating anything"},
+		{"    --check-updates", "Show when a newer release is available"},
+		{"    --menubar", "macOS: show the session in the menu bar"},
+		{"    --tray", "Windows: show the session in the notification area"},
 		{"-v, --version", "Show version information"},
 		{"-h, --help", "Show help message"},
 	}
@@ -551,19 +798,44 @@ func exampleHelpRows() [][]string {
 		{"keepalive --active", "Keep system awake and simulate activity when supported"},
 		{"keepalive -d 150", "Keep system awake for 150 minutes"},
 		{"keepalive -c 22:00", "Keep system awake until 10:00 PM"},
+		{"keepalive -c maintenance", "Keep system awake until the next maintenance window"},
 		{"keepalive -b 20", "Keep system awake until battery is 20% or lower"},
 		{"keepalive -d 20 -b 65", "Exit when duration ends or battery reaches 65%"},
+		{"keepalive --ac-only", "Keep system awake only while plugged in"},
+		{`keepalive --schedule "Mon-Fri 09:00-17:30"`, "Keep system awake during work hours"},
+		{"keepalive -d 3h --dim 10", "Keep system awake for 3 hours with the display dimmed"},
+		{"keepalive -a --idle-threshold 30s", "Simulate activity after 30 seconds of idle time"},
+		{"keepalive -a --pattern zigzag", "Simulate activity with zigzag mouse motions"},
+		{"keepalive --watch-name rsync", "Keep system awake while rsync runs"},
+		{"keepalive --until-idle-for 10m", "Stay awake while you work, sleep 10m after you leave"},
+		{"keepalive --while-cmd \"pgrep ffmpeg\"", "Keep system awake while ffmpeg runs"},
+		{`keepalive -d 2h --on-expire "pmset sleepnow"`, "Put the Mac to sleep when 2 hours are up"},
+		{"keepalive -c 17:00 --notify", "Notify when the session ends at 5 PM"},
 		{"keepalive --version", "Show version information"},
+		{"keepalive version --json", "Show build metadata as JSON"},
+		{"keepalive doctor --json", "Report capabilities and dependencies as JSON"},
+		{"keepalive run -- make -j8", "Keep system awake while a command runs"},
+		{"keepalive service install -a", "Start keep-alive with activity simulation at login"},
+		{`eval "$(keepalive prompt-snippet bash)"`, "Define keepalive_prompt for the bash prompt"},
+		{"source <(keepalive completion bash)", "Enable tab completion in bash"},
+		{"keepalive history --tag project=foo --since 7d", "Awake time for project foo this week"},
+		{"keepalive upgrade", "Install the latest release"},
 	}
 }
 
 func navigationHelpRows() [][]string {
 	return [][]string{
 		{"up/k, down/j", "Navigate menu"},
+		{"gg/Home, G/End", "Jump to the first or last option"},
 		{"Enter", "Select option"},
+		{"1-5", "Select an option by its number"},
 		{"a", "Toggle activity simulation"},
 		{"b", "Set battery threshold"},
 		{"B", "Clear battery threshold"},
+		{"t", "Start from a session template"},
+		{"p", "Pause or resume a running session"},
+		{"+/-", "Extend or shorten a timed session by 5 minutes"},
+		{"f", "Run one activity-simulation cycle now to test it"},
 		{"h/?", "Toggle help overlay"},
 		{"i", "Show dependency information if available"},
 		{"q/Esc", "Quit or go back"},
@@ -642,6 +914,12 @@ func padLine(line string, width int) string {
 // dependencyInfoView displays detailed dependency information
 func dependencyInfoView(m Model) string {
 	message := infoMessage(m)
+	if m.Capabilities != nil {
+		message = strings.TrimSpace(capabilitiesSummary(*m.Capabilities) + "\n\n" + message)
+	}
+	if m.State == stateRunning {
+		message = strings.TrimSpace(countersSummary(m.KeepAlive.Status().Counters) + "\n\n" + message)
+	}
 	if message == "" {
 		return Current.Help.Render("No dependency information available.")
 	}
@@ -656,6 +934,74 @@ Press 'i' or 'Esc' to close this view.
 	return Current.Help.Render(fmt.Sprintf(header, m.Version(), message))
 }
 
+// consentView asks the user to enable the synthetic-input privacy level.
+func consentView(m Model) string {
+	return Current.Help.Render(`Keep-Alive — Enable Activity Simulation
+
+Activity simulation injects mouse input: while you are away, Keep-Alive
+moves the pointer so that chat applications keep showing you as active.
+This raises the session's privacy level from inhibit-only, which only
+prevents sleep, to synthetic-input.
+
+Your choice is recorded with the time and your user name in privacy.json
+next to the log, and is asked for only once. 'keepalive privacy revoke'
+withdraws it.
+
+Press 'y' to enable activity simulation, or any other key to leave it off.
+`)
+}
+
+// accessibilityView explains the macOS Accessibility grant and offers to
+// open System Settings.
+func accessibilityView(m Model) string {
+	return Current.Help.Render(`Keep-Alive — Accessibility Access
+
+Activity simulation moves the mouse pointer, which macOS only allows apps
+granted Accessibility access. Keep-Alive has not been granted it yet.
+
+Until it is, the mouse events are posted through osascript, which is slower
+and needs a grant of its own; if that is missing too, simulation fails and
+the log says so.
+
+To grant it, turn Keep-Alive on in System Settings > Privacy & Security >
+Accessibility. When Keep-Alive runs in a terminal, macOS lists the terminal
+app (Terminal, iTerm2...) instead.
+
+Press 'o' to open System Settings, or any other key to continue.
+`)
+}
+
+// updateNotice tells the user that a newer release is available.
+func updateNotice(m Model) string {
+	return Current.Unselected.Render(fmt.Sprintf("Keep-Alive %s is available. Run 'keepalive upgrade' to install it.", m.UpdateVersion))
+}
+
+// capabilitiesSummary lists what keep-alive can do on this machine, one
+// capability per line.
+func capabilitiesSummary(c platform.Capabilities) string {
+	lines := []string{"Capabilities:"}
+	for _, row := range c.List() {
+		if row.Supported {
+			lines = append(lines, fmt.Sprintf("  %-19s %s", row.Name, strings.Join(row.Methods, ", ")))
+		} else {
+			lines = append(lines, fmt.Sprintf("  %-19s unavailable", row.Name))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// countersSummary lists the running session's counters, one per line.
+func countersSummary(c keepalive.Counters) string {
+	return strings.Join([]string{
+		"Session:",
+		fmt.Sprintf("  %-19s %d", "Activations", c.Activations),
+		fmt.Sprintf("  %-19s %d", "Reactivations", c.Reactivations),
+		fmt.Sprintf("  %-19s %d", "Simulations", c.Simulations),
+		fmt.Sprintf("  %-19s %d", "Idle skips", c.IdleSkips),
+		fmt.Sprintf("  %-19s %d", "Inhibitor failures", c.InhibitorFailures),
+	}, "\n")
+}
+
 func hasInfoWarning(m Model) bool {
 	return m.DependencyWarning != "" || m.ActivityWarning != ""
 }
@@ -0,0 +1,281 @@
+// Package update finds the latest GitHub release of Keep-Alive and replaces
+// the running binary with it. Downloads are verified against the release's
+// checksums file before anything is written.
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// LatestURL is the GitHub API endpoint describing the latest release.
+const LatestURL = "https://api.github.com/repos/stigoleg/keep-alive/releases/latest"
+
+// maxDownload bounds the size of a downloaded archive or checksums file.
+const maxDownload = 100 << 20
+
+// Release is a published release.
+type Release struct {
+	// Version is the release tag without its leading "v".
+	Version string
+	URL     string
+	Assets  map[string]string
+}
+
+// Latest fetches the latest release from url, normally LatestURL.
+func Latest(ctx context.Context, url string) (Release, error) {
+	body, err := get(ctx, url)
+	if err != nil {
+		return Release{}, err
+	}
+	var doc struct {
+		TagName string `json:"tag_name"`
+		HTMLURL string `json:"html_url"`
+		Assets  []struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return Release{}, fmt.Errorf("parsing release: %w", err)
+	}
+	if doc.TagName == "" {
+		return Release{}, errors.New("release has no tag")
+	}
+	r := Release{
+		Version: strings.TrimPrefix(doc.TagName, "v"),
+		URL:     doc.HTMLURL,
+		Assets:  make(map[string]string, len(doc.Assets)),
+	}
+	for _, a := range doc.Assets {
+		r.Assets[a.Name] = a.URL
+	}
+	return r, nil
+}
+
+// Newer reports whether version latest is newer than current. Versions that
+// are not dotted numbers, such as "dev", are never newer or older.
+func Newer(current, latest string) bool {
+	c, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	for i := range c {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return false
+}
+
+// parseVersion splits "1.6.0" into its numbers. Pre-release and build
+// suffixes are ignored.
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	fields := strings.Split(v, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
+
+// AssetName returns the archive published for goos and goarch, such as
+// "keep-alive_Linux_x86_64.tar.gz".
+func AssetName(goos, goarch string) string {
+	arch := goarch
+	if arch == "amd64" {
+		arch = "x86_64"
+	}
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+	return "keep-alive_" + strings.ToUpper(goos[:1]) + goos[1:] + "_" + arch + ext
+}
+
+// Download fetches the archive for the running platform from r, verifies its
+// checksum and returns the keepalive binary it contains.
+func Download(ctx context.Context, r Release) ([]byte, error) {
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	url, ok := r.Assets[name]
+	if !ok {
+		return nil, fmt.Errorf("release %s has no archive for %s/%s", r.Version, runtime.GOOS, runtime.GOARCH)
+	}
+	sumsURL := ""
+	for asset, u := range r.Assets {
+		if strings.HasSuffix(asset, "checksums.txt") {
+			sumsURL = u
+		}
+	}
+	if sumsURL == "" {
+		return nil, fmt.Errorf("release %s has no checksums file", r.Version)
+	}
+
+	sums, err := get(ctx, sumsURL)
+	if err != nil {
+		return nil, fmt.Errorf("downloading checksums: %w", err)
+	}
+	want, err := checksumFor(sums, name)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := get(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", name, err)
+	}
+	sum := sha256.Sum256(archive)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return nil, fmt.Errorf("checksum mismatch for %s: got %s, want %s", name, got, want)
+	}
+	return extract(name, archive)
+}
+
+// checksumFor finds name in a sha256sum-style checksums file.
+func checksumFor(sums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("no checksum listed for %s", name)
+}
+
+// binaryName is the executable inside a release archive.
+func binaryName() string {
+	if runtime.GOOS == "windows" {
+		return "keepalive.exe"
+	}
+	return "keepalive"
+}
+
+// extract returns the keepalive binary from a .tar.gz or .zip archive.
+func extract(name string, archive []byte) ([]byte, error) {
+	if strings.HasSuffix(name, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range zr.File {
+			if filepath.Base(f.Name) != binaryName() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			return io.ReadAll(io.LimitReader(rc, maxDownload))
+		}
+		return nil, fmt.Errorf("%s does not contain %s", name, binaryName())
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s does not contain %s", name, binaryName())
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag == tar.TypeReg && filepath.Base(hdr.Name) == binaryName() {
+			return io.ReadAll(io.LimitReader(tr, maxDownload))
+		}
+	}
+}
+
+// Replace writes binary over the executable at path. The new file is written
+// next to it and renamed into place, so a failed upgrade leaves the old
+// binary working. Windows cannot overwrite a running executable, so there it
+// is first moved aside to path+".old".
+func Replace(path string, binary []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".keepalive-upgrade-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0o111); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp.Name(), path); err != nil {
+			os.Rename(old, path)
+			return err
+		}
+		return nil
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// get fetches url, failing on any status other than 200.
+func get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "keepalive-update")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxDownload))
+}
@@ -0,0 +1,154 @@
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		current, latest string
+		want            bool
+	}{
+		{"1.6.0", "1.7.0", true},
+		{"1.6.0", "v1.6.1", true},
+		{"1.6.0", "2.0", true},
+		{"1.6.0", "1.6.0", false},
+		{"1.10.0", "1.9.0", false},
+		{"1.6.0-rc1", "1.6.0", false},
+		{"dev", "1.7.0", false},
+		{"1.6.0", "nightly", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.current, tt.latest); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.current, tt.latest, got, tt.want)
+		}
+	}
+}
+
+func TestAssetName(t *testing.T) {
+	tests := map[[2]string]string{
+		{"linux", "amd64"}:   "keep-alive_Linux_x86_64.tar.gz",
+		{"darwin", "arm64"}:  "keep-alive_Darwin_arm64.tar.gz",
+		{"windows", "amd64"}: "keep-alive_Windows_x86_64.zip",
+	}
+	for in, want := range tests {
+		if got := AssetName(in[0], in[1]); got != want {
+			t.Errorf("AssetName(%q, %q) = %q, want %q", in[0], in[1], got, want)
+		}
+	}
+}
+
+// releaseServer serves a release whose archive for the running platform
+// holds binary, listed in the checksums file with sum.
+func releaseServer(t *testing.T, binary []byte, sum func(archive []byte) string) *httptest.Server {
+	t.Helper()
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	archive := makeArchive(t, name, binary)
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	mux.HandleFunc("/latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"tag_name":"v9.9.9","html_url":"%[1]s/release","assets":[
+			{"name":%[2]q,"browser_download_url":"%[1]s/archive"},
+			{"name":"keep-alive_9.9.9_checksums.txt","browser_download_url":"%[1]s/checksums"}]}`, srv.URL, name)
+	})
+	mux.HandleFunc("/archive", func(w http.ResponseWriter, r *http.Request) { w.Write(archive) })
+	mux.HandleFunc("/checksums", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s  keep-alive_Other_arm64.tar.gz\n%s  %s\n", strings.Repeat("0", 64), sum(archive), name)
+	})
+	return srv
+}
+
+func makeArchive(t *testing.T, name string, binary []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if strings.HasSuffix(name, ".zip") {
+		zw := zip.NewWriter(&buf)
+		w, err := zw.Create(binaryName())
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(binary)
+		zw.Close()
+		return buf.Bytes()
+	}
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "README.md", Mode: 0o644, Size: 2, Typeflag: tar.TypeReg})
+	tw.Write([]byte("hi"))
+	tw.WriteHeader(&tar.Header{Name: binaryName(), Mode: 0o755, Size: int64(len(binary)), Typeflag: tar.TypeReg})
+	tw.Write(binary)
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestLatestAndDownload(t *testing.T) {
+	srv := releaseServer(t, []byte("new binary"), sha256Hex)
+
+	r, err := Latest(context.Background(), srv.URL+"/latest")
+	if err != nil {
+		t.Fatalf("Latest() error: %v", err)
+	}
+	if r.Version != "9.9.9" || r.URL != srv.URL+"/release" {
+		t.Errorf("Latest() = %+v", r)
+	}
+	got, err := Download(context.Background(), r)
+	if err != nil {
+		t.Fatalf("Download() error: %v", err)
+	}
+	if string(got) != "new binary" {
+		t.Errorf("Download() = %q, want %q", got, "new binary")
+	}
+}
+
+func TestDownloadChecksumMismatch(t *testing.T) {
+	srv := releaseServer(t, []byte("new binary"), func([]byte) string { return strings.Repeat("a", 64) })
+
+	r, err := Latest(context.Background(), srv.URL+"/latest")
+	if err != nil {
+		t.Fatalf("Latest() error: %v", err)
+	}
+	if _, err := Download(context.Background(), r); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Download() error = %v, want checksum mismatch", err)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), binaryName())
+	if err := os.WriteFile(path, []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Replace(path, []byte("new")); err != nil {
+		t.Fatalf("Replace() error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "new" {
+		t.Errorf("binary = %q, %v; want %q", data, err, "new")
+	}
+	if runtime.GOOS != "windows" {
+		if info, _ := os.Stat(path); info.Mode().Perm()&0o100 == 0 {
+			t.Errorf("binary mode = %v, want executable", info.Mode())
+		}
+	}
+}
@@ -27,3 +27,15 @@ func ParseDuration(input string) (time.Duration, error) {
 	}
 	return duration, nil
 }
+
+// FormatDuration formats d without trailing zero units, e.g. "1h30m".
+func FormatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,29 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// MakePrivateDir creates dir for the current user alone if it does not
+// exist, and checks with CheckPrivateDir that an existing one is theirs, since
+// another local user may have prepared it in a shared directory such as /tmp.
+func MakePrivateDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	return CheckPrivateDir(dir)
+}
+
+// UserTempDir returns keep-alive's directory for the current user under the
+// temporary directory, for files that have nowhere better to go. It is
+// shared on most systems, so create it with MakePrivateDir.
+func UserTempDir() string {
+	if runtime.GOOS == "windows" {
+		// %TEMP% is already per user.
+		return filepath.Join(os.TempDir(), "keepalive")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("keepalive-%d", os.Getuid()))
+}
@@ -0,0 +1,21 @@
+//go:build !unix
+
+package util
+
+import (
+	"fmt"
+	"os"
+)
+
+// CheckPrivateDir returns an error unless dir is a directory. The temporary
+// directory is already per user on Windows, so ownership is not checked.
+func CheckPrivateDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	return nil
+}
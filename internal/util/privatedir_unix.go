@@ -0,0 +1,28 @@
+//go:build unix
+
+package util
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// CheckPrivateDir returns an error unless dir is a directory, not a symbolic
+// link, that the current user owns and no one else can access.
+func CheckPrivateDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Getuid() {
+		return fmt.Errorf("%s is owned by another user", dir)
+	}
+	if perm := info.Mode().Perm(); perm&0o077 != 0 {
+		return fmt.Errorf("%s is accessible to other users (mode %#o)", dir, perm)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+//go:build unix
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMakePrivateDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keepalive-1000")
+	if err := MakePrivateDir(dir); err != nil {
+		t.Fatalf("MakePrivateDir() of a new directory: %v", err)
+	}
+	if err := MakePrivateDir(dir); err != nil {
+		t.Fatalf("MakePrivateDir() of its own directory: %v", err)
+	}
+
+	if err := os.Chmod(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := MakePrivateDir(dir); err == nil {
+		t.Error("MakePrivateDir() accepted a directory others can read")
+	}
+
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(dir, link); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckPrivateDir(link); err == nil {
+		t.Error("CheckPrivateDir() accepted a symbolic link")
+	}
+
+	if os.Getuid() == 0 {
+		other := filepath.Join(t.TempDir(), "other")
+		if err := os.Mkdir(other, 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chown(other, 65534, 65534); err != nil {
+			t.Fatal(err)
+		}
+		if err := CheckPrivateDir(other); err == nil {
+			t.Error("CheckPrivateDir() accepted another user's directory")
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package util
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+)
+
+// ShellCommand returns a command that runs command through the system
+// shell: cmd /C on Windows and sh -c elsewhere.
+func ShellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
@@ -4,12 +4,39 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	// Embed the zone database so named zones resolve on systems without
+	// one, such as most Windows installs.
+	_ "time/tzdata"
 )
 
-// ParseTimeString parses a time string in either 12-hour or 24-hour format
-// Supported formats:
+// zoneAbbreviations maps common abbreviations that are not zone names in
+// their own right to a zone observing them. Such times are read as the wall
+// clock in that region, whether or not daylight saving is in effect.
+var zoneAbbreviations = map[string]string{
+	"PST":  "America/Los_Angeles",
+	"PDT":  "America/Los_Angeles",
+	"MDT":  "America/Denver",
+	"CST":  "America/Chicago",
+	"CDT":  "America/Chicago",
+	"EDT":  "America/New_York",
+	"BST":  "Europe/London",
+	"CEST": "Europe/Paris",
+	"EEST": "Europe/Helsinki",
+	"JST":  "Asia/Tokyo",
+	"AEST": "Australia/Sydney",
+	"AEDT": "Australia/Sydney",
+}
+
+// ParseTimeString parses a clock time and returns the next instant it
+// refers to. Supported formats:
 // - 24-hour: "HH:MM" (e.g., "23:30", "09:45")
 // - 12-hour: "HH:MM[AM|PM]" (e.g., "11:30PM", "09:45AM")
+// - either, preceded by a date: "2025-01-10 09:00", "tomorrow 07:30"
+// - any of the above, followed by a zone: "17:00 CET", "09:00 +02:00"
+//
+// A time without a date is today if still ahead, otherwise tomorrow. A time
+// with a date must be in the future.
 func ParseTimeString(timeStr string) (time.Time, error) {
 	return ParseTimeStringWithNow(timeStr, time.Now())
 }
@@ -17,24 +44,95 @@ func ParseTimeString(timeStr string) (time.Time, error) {
 // ParseTimeStringWithNow is like ParseTimeString but accepts a custom "now" time
 // This is primarily used for testing to ensure consistent results
 func ParseTimeStringWithNow(timeStr string, now time.Time) (time.Time, error) {
-	timeStr = strings.TrimSpace(strings.ToUpper(timeStr))
+	fields := strings.Fields(timeStr)
+
+	loc := now.Location()
+	if len(fields) > 1 {
+		if l, ok := parseZone(fields[len(fields)-1]); ok {
+			loc = l
+			fields = fields[:len(fields)-1]
+		}
+	}
+	local := now.In(loc)
+
+	var day time.Time
+	dated := false
+	if len(fields) > 1 {
+		switch first := strings.ToLower(fields[0]); first {
+		case "today":
+			day = local
+			dated = true
+		case "tomorrow":
+			day = local.AddDate(0, 0, 1)
+			dated = true
+		default:
+			if d, err := time.ParseInLocation("2006-01-02", first, loc); err == nil {
+				day = d
+				dated = true
+			}
+		}
+		if dated {
+			fields = fields[1:]
+		}
+	}
+	if !dated {
+		day = local
+	}
 
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	hour, minute, ok := parseClock(strings.ToUpper(strings.Join(fields, " ")))
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid time format: %s\n\nValid formats:\n"+
+			"• 24-hour format: HH:MM (e.g., '23:30', '09:45')\n"+
+			"• 12-hour format: HH:MM[AM|PM] (e.g., '11:30PM', '9:45 AM')\n"+
+			"• With a date: '2025-01-10 09:00', 'tomorrow 07:30'\n"+
+			"• With a time zone: '17:00 CET', '09:00 +02:00'", strings.TrimSpace(timeStr))
+	}
 
-	// Try 24-hour format first
-	if t, err := time.Parse("15:04", timeStr); err == nil {
-		return today.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute), nil
+	t := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+	if !dated && t.Before(now) {
+		t = time.Date(day.Year(), day.Month(), day.Day()+1, hour, minute, 0, 0, loc)
+	}
+	if dated && !t.After(now) {
+		return time.Time{}, fmt.Errorf("time is in the past: %s", strings.TrimSpace(timeStr))
 	}
+	return t.In(now.Location()), nil
+}
 
-	// Try 12-hour format with AM/PM
-	formats := []string{"3:04PM", "3:04 PM", "03:04PM", "03:04 PM"}
-	for _, format := range formats {
-		if t, err := time.Parse(format, timeStr); err == nil {
-			return today.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute), nil
+// parseClock parses a 24-hour or 12-hour clock time, already upper-cased.
+func parseClock(s string) (hour, minute int, ok bool) {
+	for _, format := range []string{"15:04", "3:04PM", "3:04 PM", "03:04PM", "03:04 PM"} {
+		if t, err := time.Parse(format, s); err == nil {
+			return t.Hour(), t.Minute(), true
 		}
 	}
+	return 0, 0, false
+}
 
-	return time.Time{}, fmt.Errorf("invalid time format: %s\n\nValid formats:\n"+
-		"• 24-hour format: HH:MM (e.g., '23:30', '09:45')\n"+
-		"• 12-hour format: HH:MM[AM|PM] (e.g., '11:30PM', '9:45 AM')", timeStr)
+// parseZone resolves a zone name ("Europe/Oslo", "CET", "UTC"), a common
+// abbreviation ("PST") or a UTC offset ("+02:00", "-0500").
+func parseZone(s string) (*time.Location, bool) {
+	if s[0] == '+' || s[0] == '-' {
+		for _, format := range []string{"-07:00", "-0700", "-07"} {
+			if t, err := time.Parse(format, s); err == nil {
+				_, offset := t.Zone()
+				return time.FixedZone(s, offset), true
+			}
+		}
+		return nil, false
+	}
+	if name, ok := zoneAbbreviations[strings.ToUpper(s)]; ok {
+		s = name
+	} else if strings.ToUpper(s) == s || strings.ToLower(s) == s {
+		// Zone abbreviations and "UTC" are matched without regard to case.
+		s = strings.ToUpper(s)
+	}
+	// "Local" would silently mean this machine's zone.
+	if s == "Local" || s == "" {
+		return nil, false
+	}
+	loc, err := time.LoadLocation(s)
+	if err != nil {
+		return nil, false
+	}
+	return loc, true
 }
@@ -157,10 +157,61 @@ func TestParseTimeString(t *testing.T) {
 				t.Errorf("ParseTimeString(%q) got minute %d, want %d", tt.timeStr, got.Minute(), tt.wantMin)
 			}
 
-			// Verify the date is today
-			if got.Year() != today.Year() || got.Month() != today.Month() || got.Day() != today.Day() {
-				t.Errorf("ParseTimeString(%q) got date %v, want today's date", tt.timeStr, got)
+			// Verify the date is the next occurrence: today, or tomorrow
+			// once the time has passed
+			want := today.Add(time.Duration(tt.wantHour)*time.Hour + time.Duration(tt.wantMin)*time.Minute)
+			if want.Before(now) {
+				want = want.AddDate(0, 0, 1)
+			}
+			if got.Year() != want.Year() || got.Month() != want.Month() || got.Day() != want.Day() {
+				t.Errorf("ParseTimeString(%q) got date %v, want %v", tt.timeStr, got, want)
 			}
 		})
 	}
 }
+
+func TestParseTimeStringWithNowQualified(t *testing.T) {
+	oslo, err := time.LoadLocation("Europe/Oslo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 1, 9, 18, 0, 0, 0, oslo)
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"17:00", time.Date(2025, 1, 10, 17, 0, 0, 0, oslo)},
+		{"19:00", time.Date(2025, 1, 9, 19, 0, 0, 0, oslo)},
+		{"2025-01-10 09:00", time.Date(2025, 1, 10, 9, 0, 0, 0, oslo)},
+		{"2025-01-10 9:00PM", time.Date(2025, 1, 10, 21, 0, 0, 0, oslo)},
+		{"tomorrow 07:30", time.Date(2025, 1, 10, 7, 30, 0, 0, oslo)},
+		{"Today 20:00", time.Date(2025, 1, 9, 20, 0, 0, 0, oslo)},
+		{"17:00 UTC", time.Date(2025, 1, 9, 17, 0, 0, 0, time.UTC)},
+		{"17:00 utc", time.Date(2025, 1, 9, 17, 0, 0, 0, time.UTC)},
+		{"16:00 UTC", time.Date(2025, 1, 10, 16, 0, 0, 0, time.UTC)},
+		{"10:00 PST", time.Date(2025, 1, 9, 18, 0, 0, 0, time.UTC)},
+		{"09:00 PM CET", time.Date(2025, 1, 9, 21, 0, 0, 0, oslo)},
+		{"17:30 +00:00", time.Date(2025, 1, 9, 17, 30, 0, 0, time.UTC)},
+		{"2025-01-10 09:00 America/New_York", time.Date(2025, 1, 10, 14, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimeStringWithNow(tt.in, now)
+		if err != nil {
+			t.Errorf("ParseTimeStringWithNow(%q) unexpected error: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseTimeStringWithNow(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+		if got.Location() != oslo {
+			t.Errorf("ParseTimeStringWithNow(%q) location = %v, want the caller's", tt.in, got.Location())
+		}
+	}
+
+	for _, in := range []string{"2025-01-09 09:00", "today 17:00", "17:00 Nowhere/City", "tomorrow", "2025-13-01 09:00"} {
+		if _, err := ParseTimeStringWithNow(in, now); err == nil {
+			t.Errorf("ParseTimeStringWithNow(%q) expected error", in)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package keepalive_test
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/stigoleg/keep-alive/pkg/keepalive"
+)
+
+func Example() {
+	k := keepalive.New(keepalive.WithSimulateActivity(true))
+	if err := k.StartFor(context.Background(), 30*time.Minute); err != nil {
+		log.Fatal(err)
+	}
+	defer k.Stop()
+
+	// Long-running work here; the system stays awake until it returns.
+}
+
+func ExampleKeeper_Subscribe() {
+	k := keepalive.New()
+	events, unsubscribe := k.Subscribe()
+	defer unsubscribe()
+
+	if err := k.StartFor(context.Background(), time.Hour); err != nil {
+		log.Fatal(err)
+	}
+	for e := range events {
+		if e.Type == keepalive.EventInhibitorFailed {
+			log.Printf("%s stopped working: %v", e.Inhibitor, e.Err)
+		}
+		if e.Type == keepalive.EventExpired {
+			break
+		}
+	}
+}
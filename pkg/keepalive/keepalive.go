@@ -0,0 +1,249 @@
+// Package keepalive keeps the system awake from Go programs. It is the
+// stable, importable API of Keep-Alive: a Keeper holds the sleep inhibitors
+// of the running operating system for a session, optionally simulating
+// user activity so that chat applications do not show the user as away.
+//
+// A minimal use keeps the system awake while work runs:
+//
+//	k := keepalive.New()
+//	if err := k.Start(ctx); err != nil {
+//		return err
+//	}
+//	defer k.Stop()
+//
+// The API follows semantic versioning with the module: exported names are
+// not removed or changed incompatibly within a major version.
+package keepalive
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/stigoleg/keep-alive/internal/keepalive"
+	"github.com/stigoleg/keep-alive/internal/platform"
+)
+
+// Keeper keeps the system awake for one session at a time. Its methods are
+// safe for concurrent use.
+type Keeper struct {
+	k *keepalive.Keeper
+}
+
+// Option configures a Keeper built by New.
+type Option keepalive.Option
+
+// New returns a Keeper configured by opts. It uses the sleep inhibitors of
+// the running operating system unless WithPlatform replaces them.
+func New(opts ...Option) *Keeper {
+	internal := make([]keepalive.Option, len(opts))
+	for i, opt := range opts {
+		internal[i] = keepalive.Option(opt)
+	}
+	return &Keeper{k: keepalive.New(internal...)}
+}
+
+// WithIdleThreshold sets how long the user must be idle before activity is
+// simulated. The default is two minutes.
+func WithIdleThreshold(d time.Duration) Option {
+	return Option(keepalive.WithIdleThreshold(d))
+}
+
+// WithActivityInterval sets the period of system-level activity
+// assertions. The default is ten seconds.
+func WithActivityInterval(d time.Duration) Option {
+	return Option(keepalive.WithActivityInterval(d))
+}
+
+// WithSimulationInterval sets the minimum time between simulated mouse
+// movements. The default is thirty seconds.
+func WithSimulationInterval(d time.Duration) Option {
+	return Option(keepalive.WithSimulationInterval(d))
+}
+
+// WithSimulateActivity turns activity simulation on or off. It is off by
+// default, so only sleep is prevented.
+func WithSimulateActivity(simulate bool) Option {
+	return Option(keepalive.WithSimulateActivity(simulate))
+}
+
+// WithPresenceOnly runs sessions with activity simulation alone, holding
+// no sleep inhibitor, so that the system sleeps by its own settings. It
+// needs WithSimulateActivity(true) and a built-in platform.
+func WithPresenceOnly(presenceOnly bool) Option {
+	return Option(keepalive.WithPresenceOnly(presenceOnly))
+}
+
+// WithAwayMode requests away mode on Windows (ES_AWAYMODE_REQUIRED): the
+// display turns off and audio is muted, so the machine appears asleep,
+// while the system stays awake for background work such as recording or
+// serving media. Other platforms ignore it.
+func WithAwayMode(enabled bool) Option {
+	return Option(keepalive.WithAwayMode(enabled))
+}
+
+// WithLogger sets the logger for the Keeper's messages. The default is
+// slog.Default.
+func WithLogger(l *slog.Logger) Option {
+	return Option(keepalive.WithLogger(l))
+}
+
+// WithPlatform replaces the operating system's sleep inhibitors with p, for
+// programs that hold the system awake in their own way and for tests.
+func WithPlatform(p Platform) Option {
+	return Option(keepalive.WithPlatform(platformAdapter{p}))
+}
+
+// Platform holds the system awake for a Keeper.
+type Platform interface {
+	// Start begins keeping the system awake. It is called when a session
+	// starts or resumes; ctx is done when the session ends.
+	Start(ctx context.Context) error
+	// Stop releases everything Start acquired and waits until it is
+	// released. It is called when a session ends or is paused.
+	Stop() error
+}
+
+// platformAdapter runs a Platform as a platform keep-alive, which also
+// takes settings that only the built-in platforms use.
+type platformAdapter struct {
+	Platform
+}
+
+func (a platformAdapter) StopNow() { _ = a.Stop() }
+
+func (platformAdapter) SetSimulateActivity(bool) {}
+
+func (platformAdapter) SetTimings(platform.Timings) {}
+
+func (platformAdapter) SetMouseShape(platform.MouseShape) {}
+
+// Start keeps the system awake until Stop is called or ctx is done.
+func (k *Keeper) Start(ctx context.Context) error {
+	return k.k.StartIndefiniteContext(ctx)
+}
+
+// StartFor keeps the system awake for d, or until Stop is called or ctx is
+// done if that comes first.
+func (k *Keeper) StartFor(ctx context.Context, d time.Duration) error {
+	return k.k.StartTimedContext(ctx, d)
+}
+
+// StartUntil keeps the system awake until the wall-clock time t, even if
+// the system sleeps in between or its clock is changed, or until Stop is
+// called or ctx is done if that comes first.
+func (k *Keeper) StartUntil(ctx context.Context, t time.Time) error {
+	return k.k.StartUntilContext(ctx, t)
+}
+
+// StartWhile keeps the system awake while cond returns true, checking it
+// every poll, or until Stop is called or ctx is done. It fails if cond does
+// not hold when called.
+func (k *Keeper) StartWhile(ctx context.Context, cond func() bool, poll time.Duration) error {
+	return k.k.StartWhileContext(ctx, cond, poll)
+}
+
+// Stop ends the session and waits until the system may sleep again.
+// Stopping a Keeper that is not running does nothing.
+func (k *Keeper) Stop() error {
+	return k.k.Stop()
+}
+
+// Running reports whether a session is running, paused or not.
+func (k *Keeper) Running() bool {
+	return k.k.IsRunning()
+}
+
+// Extend moves the end of a timed session by d, which may be negative. A
+// session cannot be shortened by the time it has left or more.
+func (k *Keeper) Extend(d time.Duration) error {
+	return k.k.Extend(d)
+}
+
+// Pause lets the system sleep until Resume. A timed session's countdown is
+// frozen while it is paused.
+func (k *Keeper) Pause() error {
+	return k.k.Pause()
+}
+
+// Resume continues a paused session.
+func (k *Keeper) Resume() error {
+	return k.k.Resume()
+}
+
+// Paused reports whether the session is paused.
+func (k *Keeper) Paused() bool {
+	return k.k.Paused()
+}
+
+// Remaining returns how long a timed session has left, or zero for an
+// indefinite session and when none is running.
+func (k *Keeper) Remaining() time.Duration {
+	return k.k.TimeRemaining()
+}
+
+// EndTime returns when a timed session ends, or the zero time for an
+// indefinite session and when none is running.
+func (k *Keeper) EndTime() time.Time {
+	return k.k.EndTime()
+}
+
+// Status returns a snapshot of the current session: its mode and end, the
+// sleep-prevention methods in effect, the last simulated input and the
+// session's counters.
+func (k *Keeper) Status() Status {
+	return k.k.Status()
+}
+
+// Status is a snapshot of a Keeper's session.
+type Status = keepalive.Status
+
+// Counters tallies what happened during a session, as reported in
+// Status.Counters.
+type Counters = keepalive.Counters
+
+// The session modes reported in Status.Mode.
+const (
+	ModeIndefinite = keepalive.ModeIndefinite
+	ModeTimed      = keepalive.ModeTimed
+	ModeUntil      = keepalive.ModeUntil
+	ModeWhile      = keepalive.ModeWhile
+)
+
+// SetSimulateActivity turns activity simulation on or off, for the running
+// session and those started afterwards.
+func (k *Keeper) SetSimulateActivity(simulate bool) {
+	k.k.SetSimulateActivity(simulate)
+}
+
+// SetSimulationInterval sets the minimum time between simulated mouse
+// movements, for the running session and those started afterwards. Zero
+// restores the default of thirty seconds.
+func (k *Keeper) SetSimulationInterval(d time.Duration) error {
+	return k.k.SetSimulationInterval(d)
+}
+
+// Subscribe returns a channel of the Keeper's events and a function that
+// ends the subscription and closes the channel. A subscriber that falls
+// behind misses events rather than holding the Keeper up.
+func (k *Keeper) Subscribe() (<-chan Event, func()) {
+	return k.k.Subscribe()
+}
+
+// Event describes a change in a Keeper's state.
+type Event = keepalive.Event
+
+// EventType identifies the change an Event describes.
+type EventType = keepalive.EventType
+
+// The event types.
+const (
+	EventStarted             = keepalive.EventStarted
+	EventStopped             = keepalive.EventStopped
+	EventExpired             = keepalive.EventExpired
+	EventInhibitorFailed     = keepalive.EventInhibitorFailed
+	EventSimulationPerformed = keepalive.EventSimulationPerformed
+	EventPlatformRestarted   = keepalive.EventPlatformRestarted
+	EventSimulationChanged   = keepalive.EventSimulationChanged
+	EventSleepAttempted      = keepalive.EventSleepAttempted
+)
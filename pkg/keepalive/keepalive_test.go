@@ -0,0 +1,94 @@
+package keepalive
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakePlatform records the calls a Keeper makes to it.
+type fakePlatform struct {
+	mu     sync.Mutex
+	starts int
+	stops  int
+}
+
+func (p *fakePlatform) Start(context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.starts++
+	return nil
+}
+
+func (p *fakePlatform) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stops++
+	return nil
+}
+
+func (p *fakePlatform) counts() (starts, stops int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.starts, p.stops
+}
+
+func TestKeeperWithPlatform(t *testing.T) {
+	p := &fakePlatform{}
+	k := New(WithPlatform(p))
+	events, unsubscribe := k.Subscribe()
+	defer unsubscribe()
+
+	if err := k.StartFor(context.Background(), time.Hour); err != nil {
+		t.Fatalf("StartFor() error: %v", err)
+	}
+	if !k.Running() {
+		t.Fatal("Running() = false after StartFor")
+	}
+	if got := k.Remaining(); got <= 59*time.Minute || got > time.Hour {
+		t.Errorf("Remaining() = %v, want about an hour", got)
+	}
+	if s := k.Status(); s.Mode != ModeTimed || len(s.Methods) != 0 {
+		t.Errorf("Status() = %+v, want a timed session without reported methods", s)
+	}
+	if e := <-events; e.Type != EventStarted {
+		t.Errorf("first event = %v, want %v", e.Type, EventStarted)
+	}
+
+	if err := k.Pause(); err != nil {
+		t.Fatalf("Pause() error: %v", err)
+	}
+	if !k.Paused() {
+		t.Error("Paused() = false after Pause")
+	}
+	if err := k.Resume(); err != nil {
+		t.Fatalf("Resume() error: %v", err)
+	}
+
+	if err := k.Stop(); err != nil {
+		t.Fatalf("Stop() error: %v", err)
+	}
+	if k.Running() {
+		t.Error("Running() = true after Stop")
+	}
+	if starts, stops := p.counts(); starts != 2 || stops != 2 {
+		t.Errorf("platform started %d and stopped %d times, want 2 and 2", starts, stops)
+	}
+}
+
+func TestStartStopsWithContext(t *testing.T) {
+	k := New(WithPlatform(&fakePlatform{}))
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := k.Start(ctx); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for k.Running() {
+		if time.Now().After(deadline) {
+			t.Fatal("session still running after its context was canceled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,251 @@
+// Package countdown is the session countdown of the Keep-Alive TUI as a
+// Bubble Tea component, for programs that embed a keepalive.Keeper in their
+// own interface:
+//
+//	k := keepalive.New()
+//	c := countdown.New(k)
+//	defer c.Close()
+//
+// Forward messages to the component's Update and place its View where the
+// countdown should appear. It follows the Keeper's events, so it shows
+// sessions started, stopped or run out by other parts of the program too.
+package countdown
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/stigoleg/keep-alive/pkg/keepalive"
+)
+
+// refreshInterval is how often the countdown is redrawn. Extending and
+// pausing a session emit no event, so the component reads the Keeper on
+// every refresh as well.
+const refreshInterval = time.Second
+
+// defaultWidth is the width of the progress bar, as in the Keep-Alive TUI.
+const defaultWidth = 34
+
+// Source is the Keeper the countdown follows. *keepalive.Keeper implements
+// it.
+type Source interface {
+	Subscribe() (<-chan keepalive.Event, func())
+	Running() bool
+	Paused() bool
+	Remaining() time.Duration
+	EndTime() time.Time
+}
+
+// Styles holds the styles of the countdown's lines.
+type Styles struct {
+	// Text styles the countdown and the state of untimed sessions.
+	Text lipgloss.Style
+	// Paused styles the line shown while the session is paused.
+	Paused lipgloss.Style
+}
+
+// DefaultStyles returns styles matching the Keep-Alive TUI.
+func DefaultStyles() Styles {
+	base := lipgloss.NewStyle().PaddingLeft(2).PaddingRight(1)
+	return Styles{
+		Text:   base.Foreground(lipgloss.Color("#FAFAFA")),
+		Paused: base.Foreground(lipgloss.AdaptiveColor{Light: "#FF0000", Dark: "#FF4040"}).Bold(true),
+	}
+}
+
+// Option configures a Model built by New.
+type Option func(*Model)
+
+// WithWidth sets the width of the progress bar.
+func WithWidth(width int) Option {
+	return func(m *Model) { m.progress.Width = width }
+}
+
+// WithStyles replaces the default styles.
+func WithStyles(s Styles) Option {
+	return func(m *Model) { m.styles = s }
+}
+
+type eventMsg struct {
+	id    int64
+	event keepalive.Event
+	// closed is set when the subscription has ended.
+	closed bool
+}
+
+type tickMsg struct {
+	id int64
+}
+
+// lastID numbers the Models so that each only takes its own messages when
+// several are embedded in one program.
+var lastID atomic.Int64
+
+// Model is the countdown component. It implements tea.Model.
+type Model struct {
+	id          int64
+	source      Source
+	events      <-chan keepalive.Event
+	unsubscribe func()
+	progress    progress.Model
+	styles      Styles
+
+	running   bool
+	paused    bool
+	remaining time.Duration
+	end       time.Time
+	// elapsed is how long the session has run unpaused, counted between
+	// refreshes, so that the bar follows extensions and pauses.
+	elapsed  time.Duration
+	lastTick time.Time
+	now      func() time.Time
+}
+
+// New returns a countdown following source. It subscribes to the source's
+// events; call Close when the component is no longer shown.
+func New(source Source, opts ...Option) Model {
+	events, unsubscribe := source.Subscribe()
+	m := Model{
+		id:          lastID.Add(1),
+		source:      source,
+		events:      events,
+		unsubscribe: unsubscribe,
+		progress:    progress.New(progress.WithDefaultGradient(), progress.WithWidth(defaultWidth)),
+		styles:      DefaultStyles(),
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(&m)
+	}
+	m.refresh()
+	return m
+}
+
+// Close ends the subscription to the source's events.
+func (m Model) Close() {
+	m.unsubscribe()
+}
+
+// Init implements tea.Model.
+func (m Model) Init() tea.Cmd {
+	return tea.Batch(m.waitForEvent(), m.tick(), m.progress.SetPercent(m.Percent()))
+}
+
+// Update implements tea.Model.
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case eventMsg:
+		if msg.id != m.id || msg.closed {
+			return m, nil
+		}
+		if msg.event.Type == keepalive.EventStarted {
+			m.elapsed = 0
+			m.lastTick = time.Time{}
+		}
+		m.refresh()
+		return m, tea.Batch(m.waitForEvent(), m.progress.SetPercent(m.Percent()))
+	case tickMsg:
+		if msg.id != m.id {
+			return m, nil
+		}
+		m.refresh()
+		return m, tea.Batch(m.tick(), m.progress.SetPercent(m.Percent()))
+	case progress.FrameMsg:
+		p, cmd := m.progress.Update(msg)
+		if p, ok := p.(progress.Model); ok {
+			m.progress = p
+		}
+		return m, cmd
+	}
+	return m, nil
+}
+
+// refresh reads the session's state from the source.
+func (m *Model) refresh() {
+	now := m.now()
+	wasCounting := m.running && !m.paused && !m.lastTick.IsZero()
+	m.running = m.source.Running()
+	m.paused = m.running && m.source.Paused()
+	m.remaining = m.source.Remaining()
+	m.end = m.source.EndTime()
+	if !m.running {
+		m.elapsed = 0
+		m.lastTick = time.Time{}
+		return
+	}
+	if wasCounting && !m.paused {
+		m.elapsed += now.Sub(m.lastTick)
+	}
+	m.lastTick = now
+}
+
+// waitForEvent delivers the next event from the source.
+func (m Model) waitForEvent() tea.Cmd {
+	id, events := m.id, m.events
+	return func() tea.Msg {
+		e, ok := <-events
+		return eventMsg{id: id, event: e, closed: !ok}
+	}
+}
+
+func (m Model) tick() tea.Cmd {
+	id := m.id
+	return tea.Tick(refreshInterval, func(time.Time) tea.Msg { return tickMsg{id: id} })
+}
+
+// Timed reports whether the source runs a session with an end.
+func (m Model) Timed() bool {
+	return m.running && !m.end.IsZero()
+}
+
+// Percent returns how much of a timed session has passed, from 0 to 1.
+func (m Model) Percent() float64 {
+	if !m.Timed() {
+		return 0
+	}
+	total := m.elapsed + m.remaining
+	if total <= 0 {
+		return 1
+	}
+	return min(max(float64(m.elapsed)/float64(total), 0), 1)
+}
+
+// View implements tea.Model. It is empty when no session is running.
+func (m Model) View() string {
+	if !m.running {
+		return ""
+	}
+	var b strings.Builder
+	if m.paused {
+		b.WriteString(m.styles.Paused.Render("Paused"))
+		b.WriteString("\n")
+	}
+	if !m.Timed() {
+		if !m.paused {
+			b.WriteString(m.styles.Text.Render("Running until stopped"))
+			b.WriteString("\n")
+		}
+		return strings.TrimSuffix(b.String(), "\n")
+	}
+	b.WriteString(m.styles.Text.Render(Label(m.remaining, m.end)))
+	b.WriteString("\n\n")
+	b.WriteString(m.progress.View())
+	return b.String()
+}
+
+// Label formats the countdown line of a timed session, such as
+// "12:05 remaining (until 15:04)". The end is left out when it is zero.
+func Label(remaining time.Duration, end time.Time) string {
+	remaining = max(remaining, 0)
+	label := fmt.Sprintf("%d:%02d remaining", int(remaining.Minutes()), int(remaining.Seconds())%60)
+	if !end.IsZero() {
+		label += fmt.Sprintf(" (until %s)", end.Format("15:04"))
+	}
+	return label
+}
@@ -0,0 +1,131 @@
+package countdown
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stigoleg/keep-alive/pkg/keepalive"
+)
+
+// fakeSource is a Keeper whose state the test sets.
+type fakeSource struct {
+	events    chan keepalive.Event
+	running   bool
+	paused    bool
+	remaining time.Duration
+	end       time.Time
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{events: make(chan keepalive.Event, 1)}
+}
+
+func (f *fakeSource) Subscribe() (<-chan keepalive.Event, func()) {
+	return f.events, func() {}
+}
+
+func (f *fakeSource) Running() bool            { return f.running }
+func (f *fakeSource) Paused() bool             { return f.paused }
+func (f *fakeSource) Remaining() time.Duration { return f.remaining }
+func (f *fakeSource) EndTime() time.Time       { return f.end }
+
+func TestModel(t *testing.T) {
+	src := newFakeSource()
+	now := time.Date(2026, 1, 2, 14, 0, 0, 0, time.Local)
+	m := New(src)
+	m.now = func() time.Time { return now }
+
+	if v := m.View(); v != "" {
+		t.Fatalf("View() with no session = %q, want empty", v)
+	}
+
+	// A one-hour session starts.
+	src.running, src.remaining, src.end = true, time.Hour, now.Add(time.Hour)
+	m = update(t, m, eventMsg{id: m.id, event: keepalive.Event{Type: keepalive.EventStarted}})
+	if !strings.Contains(m.View(), "60:00 remaining (until 15:00)") {
+		t.Errorf("View() after start = %q", m.View())
+	}
+	if p := m.Percent(); p != 0 {
+		t.Errorf("Percent() after start = %v, want 0", p)
+	}
+
+	// Fifteen minutes pass.
+	now = now.Add(15 * time.Minute)
+	src.remaining = 45 * time.Minute
+	m = update(t, m, tickMsg{id: m.id})
+	if p := m.Percent(); p != 0.25 {
+		t.Errorf("Percent() after 15 of 60 minutes = %v, want 0.25", p)
+	}
+
+	// The session is paused for ten minutes: the bar holds still.
+	src.paused = true
+	m = update(t, m, tickMsg{id: m.id})
+	now = now.Add(10 * time.Minute)
+	m = update(t, m, tickMsg{id: m.id})
+	if p := m.Percent(); p != 0.25 {
+		t.Errorf("Percent() while paused = %v, want 0.25", p)
+	}
+	if !strings.Contains(m.View(), "Paused") {
+		t.Errorf("View() while paused = %q, want Paused", m.View())
+	}
+
+	// It resumes and is extended by fifteen minutes.
+	src.paused = false
+	src.remaining = time.Hour
+	m = update(t, m, tickMsg{id: m.id})
+	if p := m.Percent(); p != 0.2 {
+		t.Errorf("Percent() after extending = %v, want 0.2", p)
+	}
+
+	// Ticks of another countdown are ignored.
+	now = now.Add(30 * time.Minute)
+	src.remaining = 30 * time.Minute
+	m = update(t, m, tickMsg{id: m.id + 1})
+	if p := m.Percent(); p != 0.2 {
+		t.Errorf("Percent() after another model's tick = %v, want 0.2", p)
+	}
+
+	// It runs out.
+	src.running, src.remaining, src.end = false, 0, time.Time{}
+	m = update(t, m, eventMsg{id: m.id, event: keepalive.Event{Type: keepalive.EventExpired}})
+	if v := m.View(); v != "" {
+		t.Errorf("View() after expiry = %q, want empty", v)
+	}
+}
+
+func TestModelIndefinite(t *testing.T) {
+	src := newFakeSource()
+	src.running = true
+	m := New(src)
+	if v := m.View(); !strings.Contains(v, "Running until stopped") {
+		t.Errorf("View() = %q, want Running until stopped", v)
+	}
+	if m.Timed() {
+		t.Error("Timed() = true for an indefinite session")
+	}
+}
+
+func TestLabel(t *testing.T) {
+	end := time.Date(2026, 1, 2, 15, 4, 0, 0, time.Local)
+	tests := []struct {
+		remaining time.Duration
+		end       time.Time
+		want      string
+	}{
+		{12*time.Minute + 5*time.Second, time.Time{}, "12:05 remaining"},
+		{90 * time.Minute, end, "90:00 remaining (until 15:04)"},
+		{-time.Second, time.Time{}, "0:00 remaining"},
+	}
+	for _, tt := range tests {
+		if got := Label(tt.remaining, tt.end); got != tt.want {
+			t.Errorf("Label(%v, %v) = %q, want %q", tt.remaining, tt.end, got, tt.want)
+		}
+	}
+}
+
+func update(t *testing.T, m Model, msg any) Model {
+	t.Helper()
+	next, _ := m.Update(msg)
+	return next.(Model)
+}
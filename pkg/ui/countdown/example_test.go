@@ -0,0 +1,45 @@
+package countdown_test
+
+import (
+	"context"
+	"log"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stigoleg/keep-alive/pkg/keepalive"
+	"github.com/stigoleg/keep-alive/pkg/ui/countdown"
+)
+
+// app embeds the countdown in a program of its own.
+type app struct {
+	countdown countdown.Model
+}
+
+func (a app) Init() tea.Cmd { return a.countdown.Init() }
+
+func (a app) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(tea.KeyMsg); ok {
+		return a, tea.Quit
+	}
+	m, cmd := a.countdown.Update(msg)
+	a.countdown = m.(countdown.Model)
+	return a, cmd
+}
+
+func (a app) View() string { return "Deploying...\n\n" + a.countdown.View() + "\n" }
+
+func Example() {
+	k := keepalive.New()
+	c := countdown.New(k, countdown.WithWidth(40))
+	defer c.Close()
+
+	if err := k.StartFor(context.Background(), 30*time.Minute); err != nil {
+		log.Fatal(err)
+	}
+	defer k.Stop()
+
+	if _, err := tea.NewProgram(app{countdown: c}).Run(); err != nil {
+		log.Fatal(err)
+	}
+}